@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// a11yLine describes msg as one line of plain text. In --a11y mode each
+// message is printed as it arrives, so screen readers announce it as new
+// terminal output.
+func a11yLine(msg shared.Message, twentyFourHour bool) string {
+	timeFmt := "15:04"
+	if !twentyFourHour {
+		timeFmt = "03:04 PM"
+	}
+	sender, at := displayName(msg.Sender), formatTimestamp(msg.CreatedAt, timeFmt)
+	from := fmt.Sprintf("From %s at %s: ", sender, at)
+	switch {
+	case msg.Type == shared.AnnouncementType:
+		return fmt.Sprintf("Announcement from %s at %s: %s", sender, at, msg.Content)
+	case msg.Type == shared.GapMessageType && msg.Gap != nil:
+		return gapText(msg.Gap) + "."
+	case msg.Type == translationMessageType:
+		return "Translation: " + msg.Content
+	case msg.Type == shared.FileMessageType && msg.File != nil:
+		return fmt.Sprintf("%ssent file %s, %d bytes. Type :savefile %s to save it.", from, msg.File.Filename, msg.File.Size, msg.File.Filename)
+	case msg.Type == shared.PollMessageType && msg.Poll != nil:
+		options := make([]string, len(msg.Poll.Options))
+		for i, o := range msg.Poll.Options {
+			options[i] = fmt.Sprintf("%d. %s, %d votes", i+1, o.Text, o.Votes)
+		}
+		return fmt.Sprintf("%spoll: %s. Options: %s.", from, msg.Poll.Question, strings.Join(options, "; "))
+	case msg.Type == shared.ArtMessageType:
+		return from + "posted ASCII art, not read out."
+	case msg.Type == shared.DiagramMessageType:
+		return from + "posted a diagram, not read out. Type :diagram edit to open it."
+	}
+	return from + msg.Content
+}
+
+// a11yView draws the screen without panels, borders or colors: status, then
+// the composer. Messages are not drawn here; they are printed above it.
+func (m *model) a11yView() string {
+	switch {
+	case m.showHelp:
+		return m.helpViewport.View() + "\nArrows and Page Up/Down scroll. Ctrl+H closes help."
+	case m.showCodeSnippet:
+		return m.codeSnippetModel.View()
+	case m.showFilePicker:
+		return m.filePickerModel.View()
+	case m.showSnippetViewer:
+		return snippetViewerTitle(m.snippetInfo) + "\n" + m.snippetViewer.View() + "\nArrows scroll, c copies, Esc closes."
+	case m.showNotes:
+		return m.notesView()
+	case m.showDiagram:
+		return m.diagram.View(m.styles)
+	case m.showNotifyRules:
+		return m.notifyRules.View(m.styles)
+	case m.showSpellPopup:
+		return m.spellPopup.View()
+	case m.showCommandForm:
+		return m.commandForm.View(m.styles)
+	case m.showEmojiPicker:
+		return m.emojiPicker.View(m.styles)
+	case m.showDBMenu:
+		return m.dbMenuViewport.View()
+	}
+	var b strings.Builder
+	if m.banner != "" {
+		b.WriteString("Status: " + m.banner + "\n")
+	}
+	if notice := maintenanceNotice(m.maintenance, time.Now(), m.twentyFourHour); notice != "" {
+		b.WriteString(notice + "\n")
+	}
+	if m.sending {
+		b.WriteString("Sending...\n")
+	}
+	b.WriteString(m.textarea.View())
+	return b.String()
+}
+
+// linkNewestFirst returns the nth most recent link posted in msgs (1 = newest)
+func linkNewestFirst(msgs []shared.Message, n int) (string, bool) {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if isIgnored(msgs[i].Sender) {
+			continue
+		}
+		links := urlRegex.FindAllString(msgs[i].Content, -1)
+		for j := len(links) - 1; j >= 0; j-- {
+			if n--; n == 0 {
+				return links[j], true
+			}
+		}
+	}
+	return "", false
+}
+
+// announce prints msg above the composer in --a11y mode
+func (m *model) announce(msg shared.Message) tea.Cmd {
+	if !*a11yMode || isIgnored(msg.Sender) {
+		return nil
+	}
+	return tea.Println(a11yLine(msg, m.twentyFourHour))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestA11yLine(t *testing.T) {
+	at := time.Date(2024, 1, 1, 14, 5, 0, 0, time.Local)
+	tests := []struct {
+		msg  shared.Message
+		want string
+	}{
+		{shared.Message{Sender: "alice", Content: "hi", CreatedAt: at}, "From alice at 14:05: hi"},
+		{shared.Message{Sender: "admin", Content: "restart at 5", Type: shared.AnnouncementType, CreatedAt: at}, "Announcement from admin at 14:05: restart at 5"},
+		{shared.Message{Sender: "bob", Type: shared.FileMessageType, File: &shared.FileMeta{Filename: "a.txt", Size: 3}, CreatedAt: at}, "From bob at 14:05: sent file a.txt, 3 bytes. Type :savefile a.txt to save it."},
+		{shared.Message{Sender: "bob", Type: shared.PollMessageType, Poll: &shared.Poll{Question: "Lunch?", Options: []shared.PollOption{{Text: "Yes", Votes: 2}, {Text: "No"}}}, CreatedAt: at}, "From bob at 14:05: poll: Lunch?. Options: 1. Yes, 2 votes; 2. No, 0 votes."},
+		{shared.Message{Sender: "carol", Content: " /\\_/\\", Type: shared.ArtMessageType, CreatedAt: at}, "From carol at 14:05: posted ASCII art, not read out."},
+		{shared.Message{Sender: "System", Content: "3 messages hidden by moderation", Type: shared.GapMessageType, Gap: &shared.HistoryGap{Hidden: 3}, CreatedAt: at}, "3 messages hidden by moderation."},
+	}
+	for _, tt := range tests {
+		if got := a11yLine(tt.msg, true); got != tt.want {
+			t.Errorf("a11yLine() = %q, want %q", got, tt.want)
+		}
+	}
+	if got := a11yLine(tests[0].msg, false); got != "From alice at 02:05 PM: hi" {
+		t.Errorf("12h a11yLine() = %q", got)
+	}
+}
+
+func TestLinkNewestFirst(t *testing.T) {
+	msgs := []shared.Message{
+		{Sender: "alice", Content: "see https://a.example and https://b.example"},
+		{Sender: "bob", Content: "no links here"},
+		{Sender: "carol", Content: "https://c.example"},
+	}
+	for n, want := range map[int]string{1: "https://c.example", 2: "https://b.example", 3: "https://a.example"} {
+		if got, ok := linkNewestFirst(msgs, n); !ok || got != want {
+			t.Errorf("linkNewestFirst(%d) = %q, %v, want %q", n, got, ok, want)
+		}
+	}
+	if _, ok := linkNewestFirst(msgs, 4); ok {
+		t.Error("Expected no fourth link")
+	}
+}
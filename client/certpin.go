@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+// certPinError is returned when the server presents a certificate other
+// than the pinned one
+type certPinError struct {
+	pinned, got string
+}
+
+func (e certPinError) Error() string {
+	return fmt.Sprintf("server certificate does not match the pinned fingerprint (pinned %s, got %s): the certificate changed or someone is intercepting the connection", e.pinned, e.got)
+}
+
+// certFingerprint is the SHA-256 fingerprint of cert in the form openssl
+// prints it (openssl x509 -noout -fingerprint -sha256), so it can be checked
+// against the server out of band
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// normalizeFingerprint lets pins be written with or without colons, in
+// either case, or pasted with openssl's "sha256 Fingerprint=" prefix
+func normalizeFingerprint(fp string) string {
+	if _, after, ok := strings.Cut(fp, "="); ok {
+		fp = after
+	}
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(fp))
+}
+
+// pinnedTLSConfig trusts exactly the certificate with fingerprint pin. The
+// CA chain is not checked, so self-signed certificates work once pinned.
+func pinnedTLSConfig(pin string) *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("server sent no certificate")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			if got := certFingerprint(cert); normalizeFingerprint(got) != normalizeFingerprint(pin) {
+				return certPinError{pinned: pin, got: got}
+			}
+			return nil
+		},
+	}
+}
+
+// serverTLSConfig is the TLS setup for talking to the server: the pinned
+// certificate, no verification for --skip-tls-verify, or nil for the
+// system's CA check, plus the client certificate for mutual TLS
+func serverTLSConfig(cfg config.Config) (*tls.Config, error) {
+	var tlsConfig *tls.Config
+	switch {
+	case cfg.CertFingerprint != "":
+		tlsConfig = pinnedTLSConfig(cfg.CertFingerprint)
+	case *skipTLSVerify:
+		tlsConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if cfg.ClientCert == "" && cfg.ClientKey == "" {
+		return tlsConfig, nil
+	}
+	cert, err := loadClientCertificate(cfg.ClientCert, cfg.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	return tlsConfig, nil
+}
+
+// loadClientCertificate reads the certificate and key presented to servers
+// that require mutual TLS
+func loadClientCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	if certFile == "" || keyFile == "" {
+		return tls.Certificate{}, fmt.Errorf("mutual TLS needs both client_cert and client_key")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("cannot load client certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// probeCertificate fetches the certificate of a wss:// server, reporting its
+// fingerprint and whether the system trusts it for that host
+func probeCertificate(serverURL string) (string, bool, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", false, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", host, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return "", false, err
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", false, fmt.Errorf("server sent no certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	_, verifyErr := certs[0].Verify(x509.VerifyOptions{DNSName: u.Hostname(), Intermediates: intermediates})
+	return certFingerprint(certs[0]), verifyErr == nil, nil
+}
+
+// confirmCertificate asks whether to trust a certificate the system does not
+func confirmCertificate(serverURL, fingerprint string, in io.Reader, out io.Writer) bool {
+	fmt.Fprintf(out, "⚠️  The certificate of %s is not trusted by this system (self-signed?).\n", serverURL)
+	fmt.Fprintf(out, "   SHA-256 fingerprint: %s\n", fingerprint)
+	fmt.Fprintf(out, "   Check it with the server's admin (openssl x509 -noout -fingerprint -sha256 -in cert.pem).\n")
+	fmt.Fprintf(out, "Trust and pin this certificate? [y/N]: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	a := strings.ToLower(strings.TrimSpace(answer))
+	return a == "y" || a == "yes"
+}
+
+// trustOnFirstUse offers to pin the certificate of a wss:// server the system
+// does not trust, the first time the profile connects. Once pinned, the
+// client refuses any other certificate for that server. Servers with a
+// certificate the system trusts are left to normal verification.
+func trustOnFirstUse(cfg *config.Config, configFilePath string, in io.Reader, out io.Writer) {
+	if cfg.CertFingerprint != "" || cfg.SkipTLSVerify || *skipTLSVerify || isOnion(cfg.ServerURL) ||
+		!strings.HasPrefix(cfg.ServerURL, "wss://") {
+		return
+	}
+	fingerprint, trusted, err := probeCertificate(cfg.ServerURL)
+	if err != nil || trusted {
+		return // connecting reports the problem, if any
+	}
+	if !confirmCertificate(cfg.ServerURL, fingerprint, in, out) {
+		return
+	}
+	cfg.CertFingerprint = fingerprint
+	saved := false
+	if loader, err := config.NewInteractiveConfigLoader(); err == nil {
+		saved, _ = loader.SetProfileCertFingerprint(cfg.ServerURL, cfg.Username, fingerprint)
+	}
+	if base, err := config.LoadConfig(configFilePath); err == nil && base.ServerURL == cfg.ServerURL {
+		base.CertFingerprint = fingerprint
+		saved = config.SaveConfig(configFilePath, base) == nil || saved
+	}
+	if saved {
+		fmt.Fprintln(out, "📌 Certificate pinned")
+	} else {
+		fmt.Fprintln(out, "📌 Certificate trusted for this session")
+	}
+}
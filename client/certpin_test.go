@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+func TestPinnedTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "https://")
+	fingerprint := certFingerprint(srv.Certificate())
+
+	// Written the way openssl prints it, in lower case
+	pin := "sha256 Fingerprint=" + strings.ToLower(fingerprint)
+	conn, err := tls.Dial("tcp", addr, pinnedTLSConfig(pin))
+	if err != nil {
+		t.Fatalf("Expected the pinned self-signed certificate to be accepted: %v", err)
+	}
+	conn.Close()
+
+	wrong := strings.Repeat("AB:", 31) + "AB"
+	_, err = tls.Dial("tcp", addr, pinnedTLSConfig(wrong))
+	var pinErr certPinError
+	if !errors.As(err, &pinErr) || pinErr.got != fingerprint {
+		t.Errorf("Expected a pin mismatch naming the server's certificate, got %v", err)
+	}
+}
+
+func TestProbeCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	fingerprint, trusted, err := probeCertificate("wss://" + strings.TrimPrefix(srv.URL, "https://") + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trusted {
+		t.Error("Expected the test server's certificate not to be trusted by the system")
+	}
+	if fingerprint != certFingerprint(srv.Certificate()) {
+		t.Errorf("Expected the server's fingerprint, got %s", fingerprint)
+	}
+}
+
+func TestConfirmCertificate(t *testing.T) {
+	var out bytes.Buffer
+	if !confirmCertificate("wss://chat.example.com/ws", "AB:CD", strings.NewReader("y\n"), &out) {
+		t.Error("Expected yes to trust the certificate")
+	}
+	if !strings.Contains(out.String(), "AB:CD") {
+		t.Errorf("Expected the fingerprint to be shown, got %q", out.String())
+	}
+	if confirmCertificate("wss://chat.example.com/ws", "AB:CD", strings.NewReader("\n"), &out) {
+		t.Error("Expected the default answer to refuse")
+	}
+}
+
+func TestServerTLSConfigClientCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// The test server's own certificate doubles as a client certificate
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	cert := srv.TLS.Certificates[0]
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{
+		CertFingerprint: certFingerprint(srv.Certificate()),
+		ClientCert:      certFile,
+		ClientKey:       keyFile,
+	}
+	tlsConfig, err := serverTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("serverTLSConfig: %v", err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected the client certificate to be accepted: %v", err)
+	}
+	resp.Body.Close()
+
+	cfg.ClientKey = ""
+	if _, err := serverTLSConfig(cfg); err == nil {
+		t.Error("Expected a certificate without a key to be rejected")
+	}
+}
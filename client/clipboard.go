@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/client/termux"
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/x/term"
+)
+
+// errNoTerminalClipboard means the output isn't a terminal that could take
+// an OSC 52 copy
+var errNoTerminalClipboard = errors.New("output is not a terminal")
+
+// terminalClipboard is where OSC 52 copies are written, or nil when the
+// output isn't a terminal. Replaced in tests.
+var terminalClipboard = func() io.Writer {
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return nil
+	}
+	return os.Stdout
+}
+
+// The system clipboard: the Android one through Termux:API on Termux.
+// Indirection so tests can stand in for it.
+var (
+	systemClipboardWrite = func(text string) error {
+		if isTermux() {
+			return termux.ClipboardSet(text)
+		}
+		return clipboard.WriteAll(text)
+	}
+	systemClipboardRead = func() (string, error) {
+		if isTermux() {
+			return termux.ClipboardGet()
+		}
+		return clipboard.ReadAll()
+	}
+	systemClipboardMissing = func() bool {
+		if isTermux() {
+			return !termux.Available("termux-clipboard-set")
+		}
+		return clipboard.Unsupported
+	}
+)
+
+// Clipboard modes, set with :clipboard or the clipboard config setting
+const (
+	clipboardAuto   = "auto"   // the system clipboard, or the terminal's when that is out of reach
+	clipboardOSC52  = "osc52"  // always the terminal's
+	clipboardSystem = "system" // never the terminal's
+)
+
+var (
+	clipboardMu   sync.RWMutex
+	clipboardMode = clipboardAuto
+)
+
+// setClipboardMode switches how copies are made; empty means auto
+func setClipboardMode(mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "":
+		mode = clipboardAuto
+	case clipboardAuto, clipboardOSC52, clipboardSystem:
+	default:
+		return errors.New(i18n.T("clipboard.usage"))
+	}
+	clipboardMu.Lock()
+	clipboardMode = mode
+	clipboardMu.Unlock()
+	return nil
+}
+
+func currentClipboardMode() string {
+	clipboardMu.RLock()
+	defer clipboardMu.RUnlock()
+	return clipboardMode
+}
+
+// osc52Support is whether the terminal is known to take OSC 52 copies
+type osc52Support int
+
+const (
+	osc52Unknown osc52Support = iota
+	osc52Supported
+	osc52Unsupported
+)
+
+// detectOSC52Support guesses from the environment whether the terminal
+// honors OSC 52. Terminals can't be asked without reading a reply off the
+// input, and over SSH only TERM survives, so most remote sessions are
+// unknown: auto mode still tries those and only skips known refusers.
+func detectOSC52Support(getenv func(string) string) osc52Support {
+	termName, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case termName == "dumb" || termName == "linux":
+		// No escape sequences worth sending, or the Linux console
+		return osc52Unsupported
+	case program == "Apple_Terminal" || getenv("VTE_VERSION") != "":
+		// macOS Terminal and VTE terminals (GNOME Terminal, Tilix) ignore it
+		return osc52Unsupported
+	case getenv("WT_SESSION") != "", getenv("KITTY_WINDOW_ID") != "", getenv("ALACRITTY_SOCKET") != "",
+		program == "iTerm.app", program == "WezTerm", program == "ghostty":
+		return osc52Supported
+	}
+	for _, name := range []string{"xterm-kitty", "alacritty", "foot", "wezterm", "xterm-ghostty", "contour"} {
+		if strings.HasPrefix(termName, name) {
+			return osc52Supported
+		}
+	}
+	return osc52Unknown
+}
+
+// overSSH reports whether the client runs in an SSH session, where the
+// machine's own clipboard isn't the one in front of the user
+func overSSH() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// copyOSC52 asks the terminal to put text on its clipboard. Most modern
+// terminals (Windows Terminal, iTerm2, kitty, WezTerm, foot, Alacritty)
+// honor it, including across SSH; tmux and screen need it wrapped.
+func copyOSC52(text string) error {
+	out := terminalClipboard()
+	if out == nil {
+		return errNoTerminalClipboard
+	}
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(out)
+	return err
+}
+
+// writeClipboard copies text as the clipboard mode says. In auto mode the
+// terminal is asked to copy it, and viaTerminal is true, when the system
+// clipboard can't be reached (no xclip or wl-copy, Windows' clipboard held
+// by another program) or isn't the user's (over SSH), unless the terminal
+// is known to ignore OSC 52. A copy through the terminal can't be confirmed.
+func writeClipboard(text string) (viaTerminal bool, err error) {
+	mode := currentClipboardMode()
+	if mode == clipboardOSC52 {
+		return true, copyOSC52(text)
+	}
+	terminalCopies := mode == clipboardAuto && detectOSC52Support(os.Getenv) != osc52Unsupported
+	if terminalCopies && (overSSH() || systemClipboardMissing()) && copyOSC52(text) == nil {
+		return true, nil
+	}
+	native := text
+	if runtime.GOOS == "windows" {
+		// Windows programs expect CRLF line endings on the clipboard
+		native = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	}
+	err = safeClipboardOperation(func() error {
+		return systemClipboardWrite(native)
+	}, 2*time.Second)
+	if err == nil {
+		return false, nil
+	}
+	if terminalCopies && copyOSC52(text) == nil {
+		return true, nil
+	}
+	return false, err
+}
+
+// readClipboard returns the text on the system clipboard with Windows line
+// endings turned into plain newlines. Terminals don't let programs read
+// their clipboard, so there is no OSC 52 fallback: paste with the
+// terminal's own paste key instead.
+func readClipboard() (string, error) {
+	var text string
+	err := safeClipboardOperation(func() error {
+		var readErr error
+		text, readErr = systemClipboardRead()
+		return readErr
+	}, 2*time.Second)
+	return strings.ReplaceAll(text, "\r\n", "\n"), err
+}
+
+// copiedBanner is banner, noting when the copy was left to the terminal
+func copiedBanner(banner string, viaTerminal bool) string {
+	if viaTerminal {
+		return banner + " " + i18n.T("clipboard.via_terminal")
+	}
+	return banner
+}
+
+// applyClipboardCommand handles ":clipboard", which describes how copies
+// are made, and ":clipboard auto|osc52|system". It returns the mode to
+// keep and a banner.
+func applyClipboardCommand(text, current string) (string, string, error) {
+	args := strings.Fields(strings.TrimPrefix(text, ":clipboard"))
+	if len(args) > 1 {
+		return current, "", errors.New(i18n.T("clipboard.usage"))
+	}
+	if len(args) == 1 {
+		if err := setClipboardMode(args[0]); err != nil {
+			return current, "", err
+		}
+		current = currentClipboardMode()
+	}
+	return current, clipboardStatus(), nil
+}
+
+// clipboardStatus describes the clipboard mode and what was detected
+func clipboardStatus() string {
+	system := i18n.T("clipboard.system_available")
+	switch {
+	case systemClipboardMissing() && isTermux():
+		system = i18n.T("clipboard.system_missing_termux")
+	case systemClipboardMissing():
+		system = i18n.T("clipboard.system_missing")
+	}
+	var terminal string
+	switch detectOSC52Support(os.Getenv) {
+	case osc52Supported:
+		terminal = i18n.T("clipboard.osc52_supported")
+	case osc52Unsupported:
+		terminal = i18n.T("clipboard.osc52_unsupported")
+	default:
+		terminal = i18n.T("clipboard.osc52_unknown")
+	}
+	status := i18n.T("banner.clipboard_status", currentClipboardMode(), system, terminal)
+	if overSSH() {
+		status += " " + i18n.T("clipboard.over_ssh")
+	}
+	return status
+}
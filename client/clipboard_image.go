@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// errNoClipboardImage means the clipboard holds no image, or no helper is
+// installed that can read one
+var errNoClipboardImage = errors.New("no image in clipboard")
+
+// Indirection so tests can stand in for the platform helpers
+var (
+	clipboardLookPath = exec.LookPath
+	clipboardCommand  = func(name string, args ...string) ([]byte, error) {
+		return exec.Command(name, args...).Output()
+	}
+)
+
+// clipboardImage is image data read from the clipboard, ready to send as a file
+type clipboardImage struct {
+	data     []byte
+	filename string
+}
+
+var osascriptPNGRegex = regexp.MustCompile(`«data PNGf([0-9A-Fa-f]+)»`)
+
+// readClipboardImage returns the image on the system clipboard using the
+// platform's helper: wl-paste or xclip on Linux, pngpaste or osascript on
+// macOS, PowerShell on Windows. The termux-api clipboard is text-only, so on
+// Termux images are shared by copying their path instead (see imageFromPath).
+func readClipboardImage() (*clipboardImage, error) {
+	var data []byte
+	var err error
+	switch {
+	case isTermux():
+		err = errNoClipboardImage
+	case runtime.GOOS == "linux" || runtime.GOOS == "freebsd" || runtime.GOOS == "openbsd":
+		data, err = readUnixClipboardImage()
+	case runtime.GOOS == "darwin":
+		data, err = readDarwinClipboardImage()
+	case runtime.GOOS == "windows":
+		data, err = readWindowsClipboardImage()
+	default:
+		err = errNoClipboardImage
+	}
+	if err != nil || !isImageData(data) {
+		return nil, errNoClipboardImage
+	}
+	return &clipboardImage{data: data, filename: clipboardImageName(data, time.Now())}, nil
+}
+
+func readUnixClipboardImage() ([]byte, error) {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := clipboardLookPath("wl-paste"); err == nil {
+			types, err := clipboardCommand("wl-paste", "--list-types")
+			if err == nil {
+				if mime := pickImageType(string(types)); mime != "" {
+					return clipboardCommand("wl-paste", "--no-newline", "--type", mime)
+				}
+			}
+		}
+	}
+	if _, err := clipboardLookPath("xclip"); err == nil {
+		targets, err := clipboardCommand("xclip", "-selection", "clipboard", "-t", "TARGETS", "-o")
+		if err == nil {
+			if mime := pickImageType(string(targets)); mime != "" {
+				return clipboardCommand("xclip", "-selection", "clipboard", "-t", mime, "-o")
+			}
+		}
+	}
+	return nil, errNoClipboardImage
+}
+
+func readDarwinClipboardImage() ([]byte, error) {
+	if _, err := clipboardLookPath("pngpaste"); err == nil {
+		if data, err := clipboardCommand("pngpaste", "-"); err == nil {
+			return data, nil
+		}
+	}
+	out, err := clipboardCommand("osascript", "-e", "the clipboard as «class PNGf»")
+	if err != nil {
+		return nil, errNoClipboardImage
+	}
+	m := osascriptPNGRegex.FindSubmatch(out)
+	if m == nil {
+		return nil, errNoClipboardImage
+	}
+	return hex.DecodeString(string(m[1]))
+}
+
+func readWindowsClipboardImage() ([]byte, error) {
+	const script = `Add-Type -AssemblyName System.Windows.Forms; Add-Type -AssemblyName System.Drawing; ` +
+		`$img = [Windows.Forms.Clipboard]::GetImage(); ` +
+		`if ($img) { $ms = New-Object IO.MemoryStream; $img.Save($ms, [Drawing.Imaging.ImageFormat]::Png); [Convert]::ToBase64String($ms.ToArray()) }`
+	out, err := clipboardCommand("powershell", "-NoProfile", "-STA", "-Command", script)
+	if err != nil || len(bytes.TrimSpace(out)) == 0 {
+		return nil, errNoClipboardImage
+	}
+	return base64.StdEncoding.DecodeString(string(bytes.TrimSpace(out)))
+}
+
+// imageFromPath treats pasted text naming an existing image file (for example
+// a screenshot path copied with termux-clipboard-set) as an image paste
+func imageFromPath(text string) *clipboardImage {
+	path := strings.Trim(strings.TrimSpace(text), `"'`)
+	path = strings.TrimPrefix(path, "file://")
+	if path == "" || strings.ContainsAny(path, "\n") {
+		return nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".bmp":
+	default:
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > maxClipboardImageBytes {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !isImageData(data) {
+		return nil
+	}
+	return &clipboardImage{data: data, filename: filepath.Base(path)}
+}
+
+// maxClipboardImageBytes bounds what is read from disk before the send limit applies
+const maxClipboardImageBytes = 64 * 1024 * 1024
+
+// pickImageType returns the preferred image MIME type from a clipboard type list
+func pickImageType(types string) string {
+	available := strings.Fields(types)
+	for _, preferred := range []string{"image/png", "image/jpeg", "image/gif", "image/webp", "image/bmp"} {
+		for _, t := range available {
+			if t == preferred {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+// isImageData sniffs raw bytes for a known image format
+func isImageData(data []byte) bool {
+	return len(data) > 0 && strings.HasPrefix(http.DetectContentType(data), "image/")
+}
+
+// clipboardImageName builds a timestamped filename with the sniffed extension
+func clipboardImageName(data []byte, now time.Time) string {
+	ext := ".png"
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/gif":
+		ext = ".gif"
+	case "image/webp":
+		ext = ".webp"
+	case "image/bmp":
+		ext = ".bmp"
+	}
+	return "clipboard-" + now.Format("20060102-150405") + ext
+}
+
+// looksBinary reports clipboard "text" that is really binary data and would
+// paste as garbage
+func looksBinary(text string) bool {
+	return !utf8.ValidString(text) || strings.ContainsRune(text, 0)
+}
+
+// maxFileBytes returns the client-side file size limit (default 1MB), from
+// MARCHAT_MAX_FILE_BYTES or MARCHAT_MAX_FILE_MB
+func maxFileBytes() int64 {
+	if envBytes := os.Getenv("MARCHAT_MAX_FILE_BYTES"); envBytes != "" {
+		if v, err := strconv.ParseInt(envBytes, 10, 64); err == nil && v > 0 {
+			return v
+		}
+	} else if envMB := os.Getenv("MARCHAT_MAX_FILE_MB"); envMB != "" {
+		if v, err := strconv.ParseInt(envMB, 10, 64); err == nil && v > 0 {
+			return v * 1024 * 1024
+		}
+	}
+	return 1024 * 1024
+}
+
+// formatByteSize renders a size for banners
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%d KB", n/1024)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
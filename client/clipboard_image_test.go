@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// stubClipboardHelpers replaces the platform helpers for the duration of a test
+func stubClipboardHelpers(t *testing.T, installed []string, run func(name string, args ...string) ([]byte, error)) {
+	t.Helper()
+	origLook, origCmd := clipboardLookPath, clipboardCommand
+	t.Cleanup(func() { clipboardLookPath, clipboardCommand = origLook, origCmd })
+	clipboardLookPath = func(name string) (string, error) {
+		for _, n := range installed {
+			if n == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	clipboardCommand = run
+}
+
+func TestReadUnixClipboardImage(t *testing.T) {
+	pngData := testPNG(t)
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	stubClipboardHelpers(t, []string{"xclip"}, func(name string, args ...string) ([]byte, error) {
+		joined := strings.Join(args, " ")
+		switch {
+		case strings.Contains(joined, "TARGETS"):
+			return []byte("TARGETS\nimage/png\ntext/plain\n"), nil
+		case strings.Contains(joined, "-t image/png"):
+			return pngData, nil
+		}
+		return nil, errors.New("unexpected call: " + joined)
+	})
+	data, err := readUnixClipboardImage()
+	if err != nil || !bytes.Equal(data, pngData) {
+		t.Errorf("Expected PNG from xclip, got %d bytes (%v)", len(data), err)
+	}
+
+	// Text-only clipboard
+	stubClipboardHelpers(t, []string{"xclip"}, func(name string, args ...string) ([]byte, error) {
+		return []byte("UTF8_STRING\ntext/plain\n"), nil
+	})
+	if _, err := readUnixClipboardImage(); err != errNoClipboardImage {
+		t.Errorf("Expected errNoClipboardImage for text clipboard, got %v", err)
+	}
+
+	// Wayland prefers wl-paste
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	stubClipboardHelpers(t, []string{"wl-paste"}, func(name string, args ...string) ([]byte, error) {
+		if args[0] == "--list-types" {
+			return []byte("image/jpeg\n"), nil
+		}
+		if name == "wl-paste" && args[len(args)-1] == "image/jpeg" {
+			return pngData, nil
+		}
+		return nil, errors.New("unexpected call")
+	})
+	if data, err := readUnixClipboardImage(); err != nil || len(data) == 0 {
+		t.Errorf("Expected image from wl-paste, got %v", err)
+	}
+}
+
+func TestReadDarwinAndWindowsClipboardImage(t *testing.T) {
+	pngData := testPNG(t)
+
+	stubClipboardHelpers(t, nil, func(name string, args ...string) ([]byte, error) {
+		return []byte("«data PNGf" + strings.ToUpper(hex.EncodeToString(pngData)) + "»\n"), nil
+	})
+	if data, err := readDarwinClipboardImage(); err != nil || !bytes.Equal(data, pngData) {
+		t.Errorf("Expected PNG decoded from osascript output, got %v", err)
+	}
+
+	stubClipboardHelpers(t, nil, func(name string, args ...string) ([]byte, error) {
+		return []byte(base64.StdEncoding.EncodeToString(pngData) + "\r\n"), nil
+	})
+	if data, err := readWindowsClipboardImage(); err != nil || !bytes.Equal(data, pngData) {
+		t.Errorf("Expected PNG decoded from PowerShell output, got %v", err)
+	}
+
+	stubClipboardHelpers(t, nil, func(name string, args ...string) ([]byte, error) {
+		return nil, nil
+	})
+	if _, err := readWindowsClipboardImage(); err != errNoClipboardImage {
+		t.Errorf("Expected errNoClipboardImage for empty PowerShell output, got %v", err)
+	}
+}
+
+func TestImageFromPath(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "shot.png")
+	if err := os.WriteFile(imgPath, testPNG(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fakePath := filepath.Join(dir, "fake.png")
+	if err := os.WriteFile(fakePath, []byte("not an image"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if img := imageFromPath(" \"" + imgPath + "\"\n"); img == nil || img.filename != "shot.png" {
+		t.Errorf("Expected image from quoted path, got %+v", img)
+	}
+	if img := imageFromPath("file://" + imgPath); img == nil {
+		t.Error("Expected image from file:// URL")
+	}
+	for _, text := range []string{fakePath, filepath.Join(dir, "missing.png"), "hello world", imgPath + "\nmore"} {
+		if img := imageFromPath(text); img != nil {
+			t.Errorf("imageFromPath(%q) should not return an image", text)
+		}
+	}
+}
+
+func TestClipboardImageHelpers(t *testing.T) {
+	if got := pickImageType("TARGETS text/plain image/bmp image/png"); got != "image/png" {
+		t.Errorf("pickImageType preferred %q, want image/png", got)
+	}
+	if got := pickImageType("text/plain"); got != "" {
+		t.Errorf("pickImageType should find nothing, got %q", got)
+	}
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	if got := clipboardImageName(testPNG(t), now); got != "clipboard-20240506-070809.png" {
+		t.Errorf("Unexpected image name %q", got)
+	}
+	if !looksBinary("PNG\x00\x01") || !looksBinary(string([]byte{0xff, 0xfe})) || looksBinary("héllo\n") {
+		t.Error("looksBinary misclassified clipboard text")
+	}
+	if got := formatByteSize(1536); got != "1 KB" {
+		t.Errorf("formatByteSize(1536) = %q", got)
+	}
+}
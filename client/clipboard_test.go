@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeClipboards stands in for the system clipboard and a terminal that
+// takes OSC 52 copies, returning what the terminal was sent
+func fakeClipboards(t *testing.T, systemErr error) (system *string, screen *bytes.Buffer) {
+	t.Helper()
+	for _, env := range []string{"SSH_TTY", "SSH_CONNECTION", "TMUX", "TERM_PROGRAM", "VTE_VERSION", "WT_SESSION", "KITTY_WINDOW_ID", "ALACRITTY_SOCKET"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("TERM", "xterm-256color")
+	oldWrite, oldRead, oldMissing, oldTerminal := systemClipboardWrite, systemClipboardRead, systemClipboardMissing, terminalClipboard
+	t.Cleanup(func() {
+		systemClipboardWrite, systemClipboardRead, systemClipboardMissing, terminalClipboard = oldWrite, oldRead, oldMissing, oldTerminal
+		_ = setClipboardMode(clipboardAuto)
+	})
+	system, screen = new(string), new(bytes.Buffer)
+	systemClipboardWrite = func(text string) error {
+		if systemErr != nil {
+			return systemErr
+		}
+		*system = text
+		return nil
+	}
+	systemClipboardRead = func() (string, error) { return *system, systemErr }
+	systemClipboardMissing = func() bool { return false }
+	terminalClipboard = func() io.Writer { return screen }
+	return system, screen
+}
+
+func TestWriteClipboard(t *testing.T) {
+	osc52 := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("hello")) + "\x07"
+
+	system, screen := fakeClipboards(t, nil)
+	if via, err := writeClipboard("hello"); err != nil || via || *system != "hello" || screen.Len() != 0 {
+		t.Errorf("Expected the system clipboard used, got via terminal %v, %v, %q", via, err, screen.String())
+	}
+
+	// No clipboard tool installed: the terminal copies it
+	_, screen = fakeClipboards(t, errors.New("no clipboard utilities available"))
+	if via, err := writeClipboard("hello"); err != nil || !via || screen.String() != osc52 {
+		t.Errorf("Expected an OSC 52 copy, got via terminal %v, %v, %q", via, err, screen.String())
+	}
+
+	// Over SSH the local clipboard belongs to the server's machine
+	system, screen = fakeClipboards(t, nil)
+	t.Setenv("SSH_TTY", "/dev/pts/3")
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if via, err := writeClipboard("hello"); err != nil || !via || *system != "" {
+		t.Errorf("Expected the terminal used first over SSH, got via terminal %v, %v", via, err)
+	}
+	if !strings.HasPrefix(screen.String(), "\x1bPtmux;") {
+		t.Errorf("Expected the copy wrapped for tmux, got %q", screen.String())
+	}
+
+	// Neither a clipboard nor a terminal
+	fakeClipboards(t, errors.New("no clipboard utilities available"))
+	terminalClipboard = func() io.Writer { return nil }
+	if _, err := writeClipboard("hello"); err == nil {
+		t.Error("Expected the system clipboard's error")
+	}
+}
+
+func TestReadClipboardLineEndings(t *testing.T) {
+	system, _ := fakeClipboards(t, nil)
+	*system = "one\r\ntwo\r\n"
+	if text, err := readClipboard(); err != nil || text != "one\ntwo\n" {
+		t.Errorf("Expected Windows line endings dropped, got %q (%v)", text, err)
+	}
+}
+
+func TestClipboardModes(t *testing.T) {
+	// A clipboard helper is missing: auto goes straight to the terminal
+	system, screen := fakeClipboards(t, nil)
+	systemClipboardMissing = func() bool { return true }
+	if via, err := writeClipboard("hello"); err != nil || !via || *system != "" {
+		t.Errorf("Expected the terminal used without a clipboard helper, got via terminal %v, %v", via, err)
+	}
+
+	// ...unless the terminal is known to ignore OSC 52
+	system, screen = fakeClipboards(t, nil)
+	systemClipboardMissing = func() bool { return true }
+	t.Setenv("TERM_PROGRAM", "Apple_Terminal")
+	if via, _ := writeClipboard("hello"); via || screen.Len() != 0 || *system != "hello" {
+		t.Errorf("Expected no OSC 52 for a terminal that ignores it, got via terminal %v, %q", via, screen.String())
+	}
+
+	system, screen = fakeClipboards(t, nil)
+	if err := setClipboardMode("OSC52"); err != nil {
+		t.Fatal(err)
+	}
+	if via, err := writeClipboard("hello"); err != nil || !via || *system != "" || screen.Len() == 0 {
+		t.Errorf("Expected osc52 mode to always use the terminal, got via terminal %v, %v", via, err)
+	}
+
+	_, screen = fakeClipboards(t, errors.New("no clipboard utilities available"))
+	t.Setenv("SSH_TTY", "/dev/pts/3")
+	if err := setClipboardMode(clipboardSystem); err != nil {
+		t.Fatal(err)
+	}
+	if via, err := writeClipboard("hello"); err == nil || via || screen.Len() != 0 {
+		t.Errorf("Expected system mode never to use the terminal, got via terminal %v, %v, %q", via, err, screen.String())
+	}
+
+	if err := setClipboardMode("primary"); err == nil {
+		t.Error("Expected an unknown mode refused")
+	}
+}
+
+func TestDetectOSC52Support(t *testing.T) {
+	cases := []struct {
+		env  map[string]string
+		want osc52Support
+	}{
+		{map[string]string{"TERM": "xterm-256color", "WT_SESSION": "1b2c"}, osc52Supported},
+		{map[string]string{"TERM": "xterm-kitty"}, osc52Supported},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app"}, osc52Supported},
+		{map[string]string{"TERM": "xterm-256color", "VTE_VERSION": "7600"}, osc52Unsupported},
+		{map[string]string{"TERM": "linux"}, osc52Unsupported},
+		// All an SSH session usually passes on
+		{map[string]string{"TERM": "xterm-256color"}, osc52Unknown},
+	}
+	for _, c := range cases {
+		if got := detectOSC52Support(func(k string) string { return c.env[k] }); got != c.want {
+			t.Errorf("%v: got %v, want %v", c.env, got, c.want)
+		}
+	}
+}
+
+func TestClipboardCommand(t *testing.T) {
+	fakeClipboards(t, nil)
+	t.Setenv("SSH_CONNECTION", "10.0.0.2 51000 10.0.0.1 22")
+	mode, banner, err := applyClipboardCommand(":clipboard", clipboardAuto)
+	if err != nil || mode != clipboardAuto || !strings.Contains(banner, "auto") || !strings.Contains(banner, "SSH") {
+		t.Errorf("Expected the current mode described, got %q, %q (%v)", mode, banner, err)
+	}
+	if mode, _, err := applyClipboardCommand(":clipboard osc52", clipboardAuto); err != nil || mode != clipboardOSC52 || currentClipboardMode() != clipboardOSC52 {
+		t.Errorf("Expected osc52 mode set, got %q (%v)", mode, err)
+	}
+	if mode, _, err := applyClipboardCommand(":clipboard on off", clipboardOSC52); err == nil || mode != clipboardOSC52 {
+		t.Errorf("Expected usage and the mode kept, got %q (%v)", mode, err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// How far the server's clock is ahead of this machine's, measured from the
+// version frame that answers the handshake. Messages carry the server's
+// timestamps, so "now" for relative times and local notes is taken from
+// the server's clock too.
+var (
+	clockMu       sync.RWMutex
+	clockOffset   time.Duration
+	clockError    time.Duration // the offset is right to within this
+	clockMeasured bool
+)
+
+const (
+	// How far apart the clocks may be before a skew is reported
+	clockSkewTolerance = time.Second
+	// Longest handshake round trip the clock is measured from
+	maxClockRoundTrip = 10 * time.Second
+)
+
+// measureClockOffset estimates the server's clock offset from the time the
+// handshake was sent, the time the answer arrived and the server's time in
+// it. The server read its clock somewhere in between, so the estimate is
+// taken halfway and is off by at most half the round trip.
+func measureClockOffset(sent, received, server time.Time) (offset, maxError time.Duration) {
+	rtt := max(received.Sub(sent), 0)
+	return server.Sub(sent.Add(rtt / 2)), rtt / 2
+}
+
+// setClockOffset records the measured offset, returning how much it moved
+func setClockOffset(offset, maxError time.Duration) time.Duration {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	moved := offset - clockOffset
+	clockOffset, clockError, clockMeasured = offset, maxError, true
+	return moved
+}
+
+// serverNow is the current time by the server's clock
+func serverNow() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return time.Now().Add(clockOffset)
+}
+
+// syncServerClock measures the server's clock from a version frame that
+// arrived at received, returning how much the offset moved. Servers that
+// don't send their time, and answers too slow to measure by (such as one
+// a daemon replays on attach), leave the offset as it was.
+func syncServerClock(info shared.VersionInfo, received time.Time) (moved time.Duration, ok bool) {
+	rtt := received.Sub(info.ClientTime)
+	if info.ServerTime.IsZero() || info.ClientTime.IsZero() || rtt < 0 || rtt > maxClockRoundTrip {
+		return 0, false
+	}
+	return setClockOffset(measureClockOffset(info.ClientTime, received, info.ServerTime)), true
+}
+
+// clockSkew is how far the server's clock is ahead of this machine's, or
+// zero when the clocks agree as closely as can be measured
+func clockSkew() time.Duration {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	if clockOffset.Abs() <= max(clockSkewTolerance, clockError) {
+		return 0
+	}
+	return clockOffset
+}
+
+// skewDescription says how far and which way the server's clock is off,
+// to the second
+func skewDescription(offset time.Duration) string {
+	d := offset.Abs().Round(time.Second).String()
+	if offset > 0 {
+		return i18n.T("clock.ahead", d)
+	}
+	return i18n.T("clock.behind", d)
+}
+
+// serverClockStatus describes the server and its clock for :whois server
+func serverClockStatus(serverVersion string) string {
+	clockMu.RLock()
+	maxError, measured := clockError, clockMeasured
+	clockMu.RUnlock()
+	if serverVersion == "" {
+		serverVersion = i18n.T("whois.unknown_version")
+	}
+	var clock string
+	switch skew := clockSkew(); {
+	case !measured:
+		clock = i18n.T("clock.unmeasured")
+	case skew == 0:
+		clock = i18n.T("clock.in_sync", maxError.Round(time.Millisecond))
+	default:
+		clock = "⚠ " + skewDescription(skew) + " " + i18n.T("clock.error", maxError.Round(time.Millisecond))
+	}
+	return i18n.T("banner.whois_server", serverVersion, clock)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// resetServerClock forgets any measured offset
+func resetServerClock(t *testing.T) {
+	t.Cleanup(func() {
+		clockMu.Lock()
+		clockOffset, clockError, clockMeasured = 0, 0, false
+		clockMu.Unlock()
+	})
+}
+
+func TestMeasureClockOffset(t *testing.T) {
+	sent := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// The server, 2 minutes ahead, answered in the middle of a 200ms round trip
+	offset, maxError := measureClockOffset(sent, sent.Add(200*time.Millisecond), sent.Add(2*time.Minute+100*time.Millisecond))
+	if offset != 2*time.Minute || maxError != 100*time.Millisecond {
+		t.Errorf("Expected a 2m offset to within 100ms, got %v to within %v", offset, maxError)
+	}
+}
+
+func TestSyncServerClock(t *testing.T) {
+	resetServerClock(t)
+	sent := time.Now()
+	if _, ok := syncServerClock(shared.VersionInfo{ServerVersion: "v0.9.0"}, sent); ok {
+		t.Error("Expected a server that doesn't send its time to be ignored")
+	}
+	replayed := shared.VersionInfo{ClientTime: sent.Add(-time.Minute), ServerTime: sent}
+	if _, ok := syncServerClock(replayed, sent); ok {
+		t.Error("Expected an answer too slow to measure by to be ignored")
+	}
+	if status := serverClockStatus(""); !strings.Contains(status, "not measured") || !strings.Contains(status, "unknown version") {
+		t.Errorf("Expected an unmeasured clock, got %q", status)
+	}
+
+	info := shared.VersionInfo{ServerVersion: "v1.0.0", ClientTime: sent, ServerTime: sent.Add(-90 * time.Second)}
+	moved, ok := syncServerClock(info, sent.Add(20*time.Millisecond))
+	if !ok || moved.Round(time.Second) != -90*time.Second || clockSkew().Round(time.Second) != -90*time.Second {
+		t.Fatalf("Expected the server 90s behind, moved %v (skew %v)", moved, clockSkew())
+	}
+	if d := time.Until(serverNow()).Round(time.Second); d != -90*time.Second {
+		t.Errorf("Expected server time 90s behind the local clock, got %v", d)
+	}
+	if status := serverClockStatus("v1.0.0"); !strings.Contains(status, "v1.0.0") || !strings.Contains(status, "1m30s behind") {
+		t.Errorf("Expected the skew in the status, got %q", status)
+	}
+
+	// Clocks within a second of each other count as in sync
+	info.ServerTime = sent.Add(300 * time.Millisecond)
+	if _, ok := syncServerClock(info, sent.Add(20*time.Millisecond)); !ok || clockSkew() != 0 {
+		t.Errorf("Expected the clocks in sync, got a skew of %v", clockSkew())
+	}
+	if status := serverClockStatus("v1.0.0"); !strings.Contains(status, "in sync") {
+		t.Errorf("Expected the clocks in sync, got %q", status)
+	}
+}
+
+func TestRelativeTimesUseServerClock(t *testing.T) {
+	resetServerClock(t)
+	t.Cleanup(func() { setRelativeTimes(false) })
+	setRelativeTimes(true)
+	// The server runs 10 minutes behind and just stamped this message
+	setClockOffset(-10*time.Minute, 0)
+	msg := shared.Message{Sender: "alice", Content: "hi", CreatedAt: time.Now().Add(-10 * time.Minute)}
+	out := renderMessages([]shared.Message{msg}, getThemeStyles("system"), "me", nil, 80, true)
+	if !strings.Contains(out, "just now") {
+		t.Errorf("Expected the message to be shown as just sent:\n%s", out)
+	}
+}
@@ -1,16 +1,29 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"regexp"
 	"strings"
 
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/alecthomas/chroma/lexers"
 	"github.com/alecthomas/chroma/quick"
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
+// autoDetectLanguage is the language list entry that guesses the language
+// from the code when the snippet is previewed
+const autoDetectLanguage = "auto"
+
+// codeBlockRegex matches markdown code blocks in message content
+var codeBlockRegex = regexp.MustCompile("```([a-zA-Z0-9+]*)\n([\\s\\S]*?)```")
+
+var lineNumberStyle = lipgloss.NewStyle().Faint(true)
+
 type codeSnippetState int
 
 const (
@@ -54,7 +67,7 @@ type codeSnippetModel struct {
 
 func newCodeSnippetModel(styles themeStyles, width, height int, onSend func(string), onCancel func()) codeSnippetModel {
 	languages := []string{
-		"go", "python", "javascript", "typescript", "java", "c", "cpp", "csharp",
+		autoDetectLanguage, "go", "python", "javascript", "typescript", "java", "c", "cpp", "csharp",
 		"rust", "php", "ruby", "swift", "kotlin", "scala", "haskell", "clojure",
 		"lua", "perl", "bash", "powershell", "sql", "html", "css", "json",
 		"yaml", "xml", "markdown", "dockerfile", "makefile", "vim", "diff",
@@ -273,6 +286,9 @@ func (m codeSnippetModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "ctrl+s":
 				// Ctrl+S to finish input and show preview
 				m.code = strings.Join(m.lines, "\n")
+				if m.selected == autoDetectLanguage {
+					m.selected = detectLanguage(m.code)
+				}
 				var sb strings.Builder
 				err := quick.Highlight(&sb, m.code, m.selected, "terminal256", "monokai")
 				if err != nil {
@@ -285,7 +301,7 @@ func (m codeSnippetModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Copy selected text to clipboard
 				if m.hasSelection {
 					selectedText := m.getSelectedText()
-					if err := clipboard.WriteAll(selectedText); err != nil {
+					if _, err := writeClipboard(selectedText); err != nil {
 						log.Printf("Failed to copy to clipboard: %v", err)
 					}
 				}
@@ -294,7 +310,7 @@ func (m codeSnippetModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Cut selected text to clipboard
 				if m.hasSelection {
 					selectedText := m.getSelectedText()
-					if err := clipboard.WriteAll(selectedText); err != nil {
+					if _, err := writeClipboard(selectedText); err != nil {
 						log.Printf("Failed to copy to clipboard: %v", err)
 					}
 					m.deleteSelection()
@@ -302,7 +318,7 @@ func (m codeSnippetModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			case "ctrl+v":
 				// Paste from clipboard
-				clipboardText, err := clipboard.ReadAll()
+				clipboardText, err := readClipboard()
 				if err != nil {
 					log.Printf("Failed to read from clipboard: %v", err)
 				} else {
@@ -489,7 +505,7 @@ func (m codeSnippetModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "c":
 				// copy to clipboard - sanitize the code first
 				sanitizedCode := strings.ReplaceAll(m.code, "\x00", "")
-				if err := clipboard.WriteAll(sanitizedCode); err != nil {
+				if _, err := writeClipboard(sanitizedCode); err != nil {
 					// Silently ignore clipboard errors - not critical for functionality
 					log.Printf("Failed to copy to clipboard: %v", err)
 				}
@@ -507,10 +523,16 @@ func (m codeSnippetModel) View() string {
 		return m.langList.View() + "\n" + m.styles.Time.Render("Press Enter to select language, Esc to cancel.")
 	case stateInputCode:
 		var sb strings.Builder
-		sb.WriteString(m.styles.User.Render(fmt.Sprintf("Language: %s", m.selected)) + "\n\n")
+		language := m.selected
+		if language == autoDetectLanguage {
+			language = "auto-detect on preview"
+		}
+		sb.WriteString(m.styles.User.Render(fmt.Sprintf("Language: %s", language)) + "\n\n")
 
 		// Display all lines with cursor and selection
+		gutterWidth := len(fmt.Sprint(len(m.lines)))
 		for i, line := range m.lines {
+			sb.WriteString(lineNumberStyle.Render(fmt.Sprintf("%*d ", gutterWidth, i+1)))
 			if i == m.cursorY {
 				// Current line with cursor
 				beforeCursor := line[:m.cursorX]
@@ -600,7 +622,10 @@ func (m codeSnippetModel) formatCodeBlock(language, plainCode string, copied boo
 		return fmt.Sprintf("```%s\n%s\n```\n\n%s", language, plainCode, m.styles.Time.Render("Press Enter to send, 'r' to restart, Esc to cancel."))
 	}
 
-	highlighted := sb.String()
+	highlighted := numberLines(sb.String())
+	if language != "" {
+		highlighted = m.styles.User.Render("Language: "+language) + "\n\n" + highlighted
+	}
 
 	// Add status message
 	if copied {
@@ -611,3 +636,91 @@ func (m codeSnippetModel) formatCodeBlock(language, plainCode string, copied boo
 
 	return highlighted
 }
+
+// detectLanguage guesses the language of a snippet, first with chroma's lexer
+// analysers and then with a few cheap heuristics. It returns "" when unsure.
+func detectLanguage(code string) string {
+	if lexer := lexers.Analyse(code); lexer != nil {
+		if aliases := lexer.Config().Aliases; len(aliases) > 0 {
+			return aliases[0]
+		}
+		return strings.ToLower(lexer.Config().Name)
+	}
+
+	trimmed := strings.TrimSpace(code)
+	firstLine, _, _ := strings.Cut(trimmed, "\n")
+	switch {
+	case trimmed == "":
+		return ""
+	case strings.HasPrefix(firstLine, "#!"):
+		switch {
+		case strings.Contains(firstLine, "python"):
+			return "python"
+		case strings.Contains(firstLine, "node"):
+			return "javascript"
+		default:
+			return "bash"
+		}
+	case json.Valid([]byte(trimmed)) && (trimmed[0] == '{' || trimmed[0] == '['):
+		return "json"
+	case strings.HasPrefix(trimmed, "<?php"):
+		return "php"
+	case strings.HasPrefix(strings.ToLower(trimmed), "<!doctype html"), strings.HasPrefix(strings.ToLower(trimmed), "<html"):
+		return "html"
+	case strings.HasPrefix(trimmed, "<?xml"):
+		return "xml"
+	case strings.HasPrefix(trimmed, "diff --git"), strings.HasPrefix(trimmed, "--- ") && strings.Contains(trimmed, "\n+++ "):
+		return "diff"
+	case strings.HasPrefix(trimmed, "FROM ") && strings.Contains(trimmed, "\nRUN "):
+		return "dockerfile"
+	}
+
+	heuristics := []struct {
+		language string
+		pattern  *regexp.Regexp
+	}{
+		{"sql", regexp.MustCompile(`(?i)^\s*(select\s.+\sfrom|insert\s+into|update\s.+\sset|create\s+table|delete\s+from)\b`)},
+		{"rust", regexp.MustCompile(`(?m)^\s*(pub\s+)?fn\s+\w+.*\{|\blet\s+mut\b|println!\(`)},
+		{"python", regexp.MustCompile(`(?m)^\s*(def\s+\w+\(.*\)\s*:|class\s+\w+.*:|import\s+\w+$|from\s+\w+\s+import\b)`)},
+		{"cpp", regexp.MustCompile(`(?m)^\s*#include\s*<\w+>|std::`)},
+		{"c", regexp.MustCompile(`(?m)^\s*#include\s*[<"]\w+\.h[>"]`)},
+		{"java", regexp.MustCompile(`\bpublic\s+(static\s+)?(class|void)\b|System\.out\.print`)},
+		{"typescript", regexp.MustCompile(`\binterface\s+\w+\s*\{|:\s*(string|number|boolean)\b`)},
+		{"javascript", regexp.MustCompile(`\bfunction\s*\w*\s*\(|=>|\bconsole\.log\(|\b(const|let)\s+\w+\s*=`)},
+		{"yaml", regexp.MustCompile(`(?m)^[\w-]+:\s*\S*\n[\w\s-]+:`)},
+		{"bash", regexp.MustCompile(`(?m)^\s*(echo|export|cd|sudo|apt|npm|go|git)\s`)},
+	}
+	for _, h := range heuristics {
+		if h.pattern.MatchString(trimmed) {
+			return h.language
+		}
+	}
+	return ""
+}
+
+// numberLines prefixes each line of a (possibly highlighted) snippet with a
+// right-aligned line number gutter
+func numberLines(code string) string {
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+	var sb strings.Builder
+	for i, line := range lines {
+		sb.WriteString(lineNumberStyle.Render(fmt.Sprintf("%*d │ ", width, i+1)))
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// codeBlocksNewestFirst returns the code of every markdown code block in the
+// messages, most recent first, for :copycode
+func codeBlocksNewestFirst(msgs []shared.Message) []string {
+	var blocks []string
+	for i := len(msgs) - 1; i >= 0; i-- {
+		matches := codeBlockRegex.FindAllStringSubmatch(msgs[i].Content, -1)
+		for j := len(matches) - 1; j >= 0; j-- {
+			blocks = append(blocks, strings.TrimRight(matches[j][2], "\n"))
+		}
+	}
+	return blocks
+}
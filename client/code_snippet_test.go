@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Cod-e-Codes/marchat/shared"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -511,3 +512,74 @@ func TestCodeSnippetPreviewError(t *testing.T) {
 		t.Error("Expected error to be shown in view or fallback content")
 	}
 }
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(1)\n}", "go"},
+		{"#!/usr/bin/env python3\nprint('hi')", "python"},
+		{"def greet(name):\n    return name", "python"},
+		{"{\"name\": \"marchat\"}", "json"},
+		{"SELECT id FROM users WHERE id = 1;", "sql"},
+		{"fn main() {\n    println!(\"hi\");\n}", "rust"},
+		{"const add = (a, b) => a + b;", "javascript"},
+		{"#include <stdio.h>\nint main(void) { return 0; }", "c"},
+		{"just some words", ""},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.code); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCodeSnippetAutoDetectOnPreview(t *testing.T) {
+	var sent string
+	model := newCodeSnippetModel(getMockThemeStyles(), 80, 24, func(code string) { sent = code }, func() {})
+	if model.languages[0] != autoDetectLanguage {
+		t.Fatalf("Expected %q to be the first language option", autoDetectLanguage)
+	}
+
+	model.state = stateInputCode
+	model.selected = autoDetectLanguage
+	model.lines = []string{"def main():", "    pass"}
+	if view := model.View(); !strings.Contains(view, "1 ") || !strings.Contains(view, "2 ") {
+		t.Errorf("Expected line numbers in the editor view, got %q", view)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	model = updated.(codeSnippetModel)
+	if model.selected != "python" {
+		t.Errorf("Expected python to be detected, got %q", model.selected)
+	}
+	model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.HasPrefix(sent, "```python\n") {
+		t.Errorf("Expected detected language in sent markdown, got %q", sent)
+	}
+}
+
+func TestNumberLines(t *testing.T) {
+	got := numberLines("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n")
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("Expected 10 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], " 1 │ ") || !strings.HasSuffix(lines[0], "a") || !strings.Contains(lines[9], "10 │ ") {
+		t.Errorf("Unexpected gutter: %q / %q", lines[0], lines[9])
+	}
+}
+
+func TestCodeBlocksNewestFirst(t *testing.T) {
+	msgs := []shared.Message{
+		{Content: "```go\nold()\n```"},
+		{Content: "no code here"},
+		{Content: "two blocks ```py\nfirst()\n``` and ```\nsecond()\n```"},
+	}
+	got := codeBlocksNewestFirst(msgs)
+	want := []string{"second()", "first()", "old()"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("codeBlocksNewestFirst() = %v, want %v", got, want)
+	}
+}
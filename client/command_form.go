@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commandForm asks for a command's arguments one field at a time. It opens
+// when a command with more than one required argument is sent bare.
+type commandForm struct {
+	command shared.CommandInfo
+	inputs  []textinput.Model
+	focus   int
+	err     string
+}
+
+// needsForm reports whether cmd has enough required arguments that a form
+// is easier than remembering their order
+func needsForm(cmd shared.CommandInfo) bool {
+	required := 0
+	for _, arg := range cmd.Args {
+		if arg.Required {
+			required++
+		}
+	}
+	return required > 1
+}
+
+// formFor returns the command to ask arguments for when text is the bare
+// name of a server command that needs a form
+func (m *model) formFor(text string) (shared.CommandInfo, bool) {
+	for _, cmd := range m.serverCommands {
+		if cmd.Name == text && needsForm(cmd) {
+			return cmd, true
+		}
+	}
+	return shared.CommandInfo{}, false
+}
+
+func newCommandForm(cmd shared.CommandInfo) commandForm {
+	f := commandForm{command: cmd}
+	for _, arg := range cmd.Args {
+		in := textinput.New()
+		in.Prompt = ""
+		in.Width = 30
+		switch {
+		case len(arg.Choices) > 0:
+			in.Placeholder = strings.Join(arg.Choices, "|")
+		case arg.Description != "":
+			in.Placeholder = arg.Description
+		default:
+			in.Placeholder = arg.Type
+		}
+		f.inputs = append(f.inputs, in)
+	}
+	f.inputs[0].Focus()
+	return f
+}
+
+// move focuses the next (delta 1) or previous (delta -1) field, wrapping
+func (f *commandForm) move(delta int) {
+	f.inputs[f.focus].Blur()
+	f.focus = (f.focus + delta + len(f.inputs)) % len(f.inputs)
+	f.inputs[f.focus].Focus()
+}
+
+// last reports whether the last field has focus
+func (f *commandForm) last() bool {
+	return f.focus == len(f.inputs)-1
+}
+
+// update passes a key to the focused field
+func (f *commandForm) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return cmd
+}
+
+// commandLine builds the command from the fields, quoting values so the
+// server splits them back into the same arguments. The server checks
+// types; this only catches what would shift arguments out of place.
+func (f *commandForm) commandLine() (string, error) {
+	parts := []string{f.command.Name}
+	var skipped string
+	for i, arg := range f.command.Args {
+		value := strings.TrimSpace(f.inputs[i].Value())
+		if value == "" {
+			if arg.Required {
+				return "", fmt.Errorf("%s", i18n.T("form.required", arg.Name))
+			}
+			if skipped == "" {
+				skipped = arg.Name
+			}
+			continue
+		}
+		if skipped != "" {
+			return "", fmt.Errorf("%s", i18n.T("form.fill_first", skipped, arg.Name))
+		}
+		if len(arg.Choices) > 0 && !slices.Contains(arg.Choices, value) {
+			return "", fmt.Errorf("%s", i18n.T("form.choices", arg.Name, strings.Join(arg.Choices, ", ")))
+		}
+		parts = append(parts, quoteArg(value))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// quoteArg quotes value for the server's command parser when it has
+// spaces or quotes
+func quoteArg(value string) string {
+	if !strings.ContainsAny(value, " \"\\") {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// View renders the form
+func (f commandForm) View(styles themeStyles) string {
+	width := 0
+	for _, arg := range f.command.Args {
+		width = max(width, len(arg.Name)+1)
+	}
+	var b strings.Builder
+	b.WriteString(styles.User.Render(f.command.Usage) + "\n")
+	if f.command.Description != "" {
+		b.WriteString(styles.Time.Render(f.command.Description) + "\n")
+	}
+	b.WriteString("\n")
+	for i, arg := range f.command.Args {
+		label := arg.Name
+		if arg.Required {
+			label += "*"
+		}
+		marker := "  "
+		if i == f.focus {
+			marker = "> "
+		}
+		fmt.Fprintf(&b, "%s%-*s %s\n", marker, width, label, f.inputs[i].View())
+	}
+	if f.err != "" {
+		b.WriteString("\n" + styles.Mention.Render(f.err) + "\n")
+	}
+	b.WriteString("\n" + styles.Time.Render(i18n.T("form.hint")))
+	return b.String()
+}
+
+// openCommandForm shows the argument form for cmd
+func (m *model) openCommandForm(cmd shared.CommandInfo) {
+	m.commandForm = newCommandForm(cmd)
+	m.showCommandForm = true
+	m.textarea.SetValue("")
+}
+
+// updateCommandForm handles a key while the argument form is open
+func (m *model) updateCommandForm(v tea.KeyMsg) tea.Cmd {
+	switch v.String() {
+	case "esc", "ctrl+c":
+		m.showCommandForm = false
+		m.banner = i18n.T("banner.form_cancelled")
+	case "tab", "down":
+		m.commandForm.move(1)
+	case "shift+tab", "up":
+		m.commandForm.move(-1)
+	case "enter":
+		if !m.commandForm.last() {
+			m.commandForm.move(1)
+			return nil
+		}
+		line, err := m.commandForm.commandLine()
+		if err != nil {
+			m.commandForm.err = err.Error()
+			return nil
+		}
+		m.showCommandForm = false
+		m.sendServerCommand(line)
+	default:
+		return m.commandForm.update(v)
+	}
+	return nil
+}
+
+// sendServerCommand sends command for the server to run, unencrypted
+func (m *model) sendServerCommand(command string) {
+	if m.conn == nil {
+		return
+	}
+	msg := shared.Message{Sender: m.cfg.Username, Content: command, Type: shared.AdminCommandType}
+	if err := writeAdminCommand(m.conn, msg); err != nil {
+		m.banner = i18n.T("banner.admin_command_connection_lost")
+		return
+	}
+	m.banner = ""
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestCommandForm(t *testing.T) {
+	cmd := shared.CommandInfo{
+		Name:  ":ticket",
+		Usage: ":ticket <project> <priority> [title...]",
+		Args: []shared.CommandArg{
+			{Name: "project", Required: true},
+			{Name: "priority", Required: true, Choices: []string{"low", "high"}},
+			{Name: "assignee"},
+			{Name: "title", Rest: true},
+		},
+	}
+	m := &model{serverCommands: []shared.CommandInfo{cmd, {Name: ":echo", Args: []shared.CommandArg{{Name: "text", Required: true}}}}}
+	if _, ok := m.formFor(":echo"); ok {
+		t.Error("Commands with one required argument should not open a form")
+	}
+	if _, ok := m.formFor(":ticket web high"); ok {
+		t.Error("Commands sent with arguments should not open a form")
+	}
+	got, ok := m.formFor(":ticket")
+	if !ok {
+		t.Fatal("Expected a form for :ticket")
+	}
+
+	f := newCommandForm(got)
+	fill := func(values ...string) {
+		for i := range f.inputs {
+			f.inputs[i].SetValue("")
+		}
+		for i, v := range values {
+			f.inputs[i].SetValue(v)
+		}
+	}
+
+	fill("web", "high", "", `Login "button" broken`)
+	if _, err := f.commandLine(); err == nil || !strings.Contains(err.Error(), "assignee") {
+		t.Errorf("Expected an error for skipping assignee, got %v", err)
+	}
+	fill("web", "high", "bob", `Login "button" broken`)
+	if line, err := f.commandLine(); err != nil || line != `:ticket web high bob "Login \"button\" broken"` {
+		t.Errorf("commandLine() = %q, %v", line, err)
+	}
+	fill("web")
+	if _, err := f.commandLine(); err == nil || !strings.Contains(err.Error(), "priority") {
+		t.Errorf("Expected a missing priority error, got %v", err)
+	}
+	fill("web", "urgent")
+	if _, err := f.commandLine(); err == nil || !strings.Contains(err.Error(), "low, high") {
+		t.Errorf("Expected a choices error, got %v", err)
+	}
+
+	f.move(-1)
+	if !f.last() {
+		t.Error("Moving back from the first field should wrap to the last")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// helpServerCommands and helpAdminCommands are shown until the server has
+// answered :help with the commands this user can actually run
+var helpServerCommands = []helpEntry{
+	{":sessions", "help.cmd.sessions"},
+	{":sessions revoke <id>", "help.cmd.sessions_revoke"},
+	{":poll \"Q\" \"A\" \"B\"", "help.cmd.poll"},
+	{":poll list|close <id>", "help.cmd.poll_list_close"},
+	{":vote <id> <n>", "help.cmd.vote"},
+	{":schedule <when> <msg>", "help.cmd.schedule"},
+	{":scheduled [cancel <id>]", "help.cmd.scheduled"},
+	{":remind [@user] <when> <text>", "help.cmd.remind"},
+	{":reminders [cancel <id>]", "help.cmd.reminders"},
+	{":emoji list", "help.cmd.emoji_list"},
+	{":nick [name]", "help.cmd.nick"},
+	{":topic", "help.cmd.topic"},
+	{":motd", "help.cmd.motd"},
+	{":notes", "help.cmd.notes"},
+	{":group [list|show <name>]", "help.cmd.group"},
+	{":stats me|channel", "help.cmd.stats"},
+	{":email set <address>|off", "help.cmd.email"},
+}
+
+var helpAdminCommands = []helpEntry{
+	{":mute <user> [1h]", "help.cmd.mute"},
+	{":unmute <user>", "help.cmd.unmute"},
+	{":slowmode <10s|off>", "help.cmd.slowmode"},
+	{":filter add [block] <word|/regex/>", "help.cmd.filter_add"},
+	{":filter list|remove <id>", "help.cmd.filter_list_remove"},
+	{":invite create [24h]", "help.cmd.invite_create"},
+	{":cleanup", "help.cmd.cleanup"},
+	{":announce <text>", "help.cmd.announce"},
+	{":cron add [announce] <cron> <msg>", "help.cmd.cron"},
+	{":topic set <text>|clear", "help.cmd.topic_set"},
+	{":motd set <text>|clear", "help.cmd.motd_set"},
+	{":group create|delete|add|remove", "help.cmd.group_manage"},
+	{":emoji add <code> <glyph> [img.png]", "help.cmd.emoji_add"},
+	{":emoji remove <code>", "help.cmd.emoji_remove"},
+}
+
+// requestCommands asks the server which commands this user can run. The
+// answer arrives as a "commands" message. Spectators can't run any.
+func (m *model) requestCommands() {
+	if m.conn == nil || *readOnly {
+		return
+	}
+	msg := shared.Message{Sender: m.cfg.Username, Content: ":help", Type: shared.AdminCommandType}
+	if err := writeAdminCommand(m.conn, msg); err != nil {
+		m.banner = i18n.T("banner.admin_command_connection_lost")
+	}
+}
+
+// setServerCommands stores the server's answer to :help, refreshing the
+// help overlay if it is open
+func (m *model) setServerCommands(data json.RawMessage) {
+	var commands []shared.CommandInfo
+	if err := json.Unmarshal(data, &commands); err != nil {
+		return
+	}
+	m.serverCommands = commands
+	if m.showHelp {
+		m.helpViewport.SetContent(m.generateHelpContent())
+	}
+}
+
+// isServerCommand reports whether the server said text is a command this
+// user can run, such as one provided by a plugin
+func (m *model) isServerCommand(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, cmd := range m.serverCommands {
+		if cmd.Name == fields[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// serverCommandLines lists the server's commands for the help overlay:
+// admin-only ones when admin is set, the rest otherwise. Before the server
+// has answered :help it falls back to the built-in list.
+func (m *model) serverCommandLines(admin bool, indent string, width int) string {
+	if m.serverCommands == nil {
+		if admin {
+			return helpLines(helpAdminCommands, indent, width)
+		}
+		return helpLines(helpServerCommands, indent, width)
+	}
+	var b strings.Builder
+	for _, cmd := range m.serverCommands {
+		if cmd.AdminOnly != admin {
+			continue
+		}
+		desc := cmd.Description
+		if cmd.Plugin != "" {
+			desc += " [" + cmd.Plugin + "]"
+		}
+		fmt.Fprintf(&b, "%s%-*s %s\n", indent, width, cmd.Usage, desc)
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestServerCommandLines(t *testing.T) {
+	m := &model{}
+	if got := m.serverCommandLines(false, "", 20); !strings.Contains(got, ":nick [name]") {
+		t.Errorf("Before :help is answered the built-in list should show, got:\n%s", got)
+	}
+
+	data, _ := json.Marshal([]shared.CommandInfo{
+		{Name: ":nick", Usage: ":nick [name]", Description: "Set or clear your display name"},
+		{Name: ":echo", Usage: ":echo <message>", Description: "Echo a message", Plugin: "echo"},
+		{Name: ":ban", Usage: ":ban <username>", Description: "Ban a user", AdminOnly: true},
+	})
+	m.setServerCommands(data)
+
+	user := m.serverCommandLines(false, "", 20)
+	if !strings.Contains(user, ":echo <message>") || !strings.Contains(user, "Echo a message [echo]") {
+		t.Errorf("Plugin commands should be listed with their plugin, got:\n%s", user)
+	}
+	if strings.Contains(user, ":ban") || strings.Contains(user, ":sessions") {
+		t.Errorf("Only the server's non-admin commands should be listed, got:\n%s", user)
+	}
+	if admin := m.serverCommandLines(true, "", 20); !strings.Contains(admin, ":ban <username>") || strings.Contains(admin, ":nick") {
+		t.Errorf("Admin lines should hold only admin commands, got:\n%s", admin)
+	}
+
+	if !m.isServerCommand(":echo hello") || m.isServerCommand(":echoes") || m.isServerCommand("hello") {
+		t.Error("isServerCommand should match listed command names only")
+	}
+}
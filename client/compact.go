@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// defaultCompactMinutes is how long after a header message later messages
+// from the same sender join its group, unless :compact says otherwise
+const defaultCompactMinutes = 5
+
+// Compact display, turned on with :compact: consecutive messages from one
+// sender within the window share a single name and time header, and
+// messages are not spaced apart. A zero window means it is off.
+var (
+	compactMu     sync.RWMutex
+	compactWindow time.Duration
+)
+
+// setCompactMode turns compact display on with a window of minutes (0 uses
+// the default) or off
+func setCompactMode(on bool, minutes int) {
+	compactMu.Lock()
+	defer compactMu.Unlock()
+	if !on {
+		compactWindow = 0
+		return
+	}
+	if minutes <= 0 {
+		minutes = defaultCompactMinutes
+	}
+	compactWindow = time.Duration(minutes) * time.Minute
+}
+
+// compactGrouping returns the window messages are grouped within, or 0
+// when compact display is off
+func compactGrouping() time.Duration {
+	compactMu.RLock()
+	defer compactMu.RUnlock()
+	return compactWindow
+}
+
+// groupable reports whether msg is drawn as an ordinary chat bubble, the
+// only kind compact display folds under a shared header
+func groupable(msg shared.Message) bool {
+	switch msg.Type {
+	case "", shared.TextMessage:
+		return true
+	case shared.FileMessageType:
+		return msg.File != nil
+	case shared.AudioMessageType:
+		return msg.Audio != nil
+	}
+	return false
+}
+
+// applyCompactCommand handles ":compact" (toggle), ":compact on|off" and
+// ":compact <minutes>", which turns it on with that window. It returns the
+// new setting and the banner to show.
+func applyCompactCommand(text string, on bool, minutes int) (bool, int, string, error) {
+	args := strings.Fields(strings.TrimPrefix(text, ":compact"))
+	switch {
+	case len(args) == 0:
+		on = !on
+	case len(args) == 1 && args[0] == "on":
+		on = true
+	case len(args) == 1 && args[0] == "off":
+		on = false
+	case len(args) == 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > 24*60 {
+			return on, minutes, "", errors.New(i18n.T("compact.usage"))
+		}
+		on, minutes = true, n
+	default:
+		return on, minutes, "", errors.New(i18n.T("compact.usage"))
+	}
+	if !on {
+		return on, minutes, i18n.T("banner.compact_off"), nil
+	}
+	shown := minutes
+	if shown <= 0 {
+		shown = defaultCompactMinutes
+	}
+	return on, minutes, i18n.T("banner.compact_on", shown), nil
+}
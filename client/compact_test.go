@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestApplyCompactCommand(t *testing.T) {
+	tests := []struct {
+		text        string
+		on          bool
+		minutes     int
+		wantOn      bool
+		wantMinutes int
+		wantErr     bool
+	}{
+		{":compact", false, 0, true, 0, false},
+		{":compact", true, 10, false, 10, false},
+		{":compact on", true, 0, true, 0, false},
+		{":compact off", true, 3, false, 3, false},
+		{":compact 15", false, 0, true, 15, false},
+		{":compact 0", false, 5, false, 5, true},
+		{":compact soon", false, 5, false, 5, true},
+	}
+	for _, tt := range tests {
+		on, minutes, banner, err := applyCompactCommand(tt.text, tt.on, tt.minutes)
+		if (err != nil) != tt.wantErr || on != tt.wantOn || minutes != tt.wantMinutes {
+			t.Errorf("applyCompactCommand(%q, %v, %d) = %v, %d, %v", tt.text, tt.on, tt.minutes, on, minutes, err)
+		}
+		if err == nil && banner == "" {
+			t.Errorf("applyCompactCommand(%q) gave no banner", tt.text)
+		}
+	}
+}
+
+func TestCompactRendering(t *testing.T) {
+	t.Cleanup(func() { setCompactMode(false, 0) })
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	msgs := []shared.Message{
+		{Sender: "alice", Content: "first", CreatedAt: base},
+		{Sender: "alice", Content: "second", CreatedAt: base.Add(time.Minute)},
+		{Sender: "alice", Content: "third", CreatedAt: base.Add(4 * time.Minute)},
+		{Sender: "bob", Content: "hi", CreatedAt: base.Add(5 * time.Minute)},
+		{Sender: "alice", Content: "back", CreatedAt: base.Add(6 * time.Minute)},
+		{Sender: "alice", Content: "later", CreatedAt: base.Add(20 * time.Minute)},
+	}
+	render := func() string {
+		return renderMessages(msgs, baseThemeStyles(), "me", []string{"alice", "bob"}, 80, true)
+	}
+
+	normal := render()
+	if n := strings.Count(normal, "alice"); n != 5 {
+		t.Fatalf("Expected a header per message without compact display, got %d", n)
+	}
+
+	setCompactMode(true, 5)
+	compact := render()
+	for _, text := range []string{"first", "second", "third", "hi", "back", "later"} {
+		if !strings.Contains(compact, text) {
+			t.Errorf("Compact display lost %q", text)
+		}
+	}
+	// first-third share a header; bob breaks the run; "later" is past the window
+	if n := strings.Count(compact, "alice"); n != 3 {
+		t.Errorf("Expected three alice headers, got %d:\n%s", n, compact)
+	}
+	if strings.Count(compact, "\n") >= strings.Count(normal, "\n") {
+		t.Error("Compact display should take fewer lines")
+	}
+
+	// The selected message shows its own time inside a group
+	setRevealedMessage(&msgs[1])
+	defer setRevealedMessage(nil)
+	if n := strings.Count(render(), "alice"); n != 4 {
+		t.Errorf("Expected the selected message to get a header, got %d", n)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"compress/flate"
+	"sync"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/gorilla/websocket"
+)
+
+// Compression defaults: fast, and only for frames big enough to benefit,
+// such as code snippets and files
+const (
+	defaultCompressionLevel     = flate.BestSpeed
+	defaultCompressionThreshold = 256
+)
+
+var (
+	compressionMu        sync.RWMutex
+	compressionLevel     = defaultCompressionLevel
+	compressionThreshold = defaultCompressionThreshold
+)
+
+// setCompression applies compression_level (1-9, -1 = off, 0 = default) and
+// compression_threshold (bytes, 0 = default) from cfg
+func setCompression(cfg config.Config) {
+	level, threshold := cfg.CompressionLevel, cfg.CompressionThreshold
+	switch {
+	case level < 0:
+		level = 0
+	case level == 0 || level > flate.BestCompression:
+		level = defaultCompressionLevel
+	}
+	if threshold <= 0 {
+		threshold = defaultCompressionThreshold
+	}
+	compressionMu.Lock()
+	compressionLevel, compressionThreshold = level, threshold
+	compressionMu.Unlock()
+}
+
+// compressionSettings returns the flate level (0 = off) and the smallest
+// frame worth compressing
+func compressionSettings() (int, int) {
+	compressionMu.RLock()
+	defer compressionMu.RUnlock()
+	return compressionLevel, compressionThreshold
+}
+
+// setWriteCompression compresses the next frame on conn if it is large
+// enough; it has no effect unless the server negotiated permessage-deflate
+func setWriteCompression(conn *websocket.Conn, size int) {
+	level, threshold := compressionSettings()
+	conn.EnableWriteCompression(level > 0 && size >= threshold)
+}
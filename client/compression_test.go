@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+func TestSetCompression(t *testing.T) {
+	t.Cleanup(func() { setCompression(config.Config{}) })
+
+	setCompression(config.Config{})
+	if level, threshold := compressionSettings(); level != defaultCompressionLevel || threshold != defaultCompressionThreshold {
+		t.Errorf("Expected the defaults, got level %d, threshold %d", level, threshold)
+	}
+	setCompression(config.Config{CompressionLevel: 9, CompressionThreshold: 1024})
+	if level, threshold := compressionSettings(); level != 9 || threshold != 1024 {
+		t.Errorf("Expected level 9 above 1024 bytes, got level %d, threshold %d", level, threshold)
+	}
+	setCompression(config.Config{CompressionLevel: -1})
+	if level, _ := compressionSettings(); level != 0 {
+		t.Errorf("Expected -1 to turn compression off, got level %d", level)
+	}
+}
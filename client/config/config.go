@@ -30,6 +30,14 @@ type Config struct {
 	TwentyFourHour bool   `json:"twenty_four_hour"`
 	SkipTLSVerify  bool   `json:"skip_tls_verify,omitempty"`
 
+	// SHA-256 fingerprint of the server's certificate; when set it is
+	// trusted instead of the CA chain, and any other certificate is refused
+	CertFingerprint string `json:"cert_fingerprint,omitempty"`
+
+	// Client certificate for servers that require mutual TLS (PEM files)
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+
 	// Bell notification settings (legacy - kept for backward compatibility)
 	EnableBell    bool `json:"enable_bell,omitempty"`     // Enable/disable bell
 	BellOnMention bool `json:"bell_on_mention,omitempty"` // Only bell on mentions
@@ -44,20 +52,144 @@ type Config struct {
 	QuietHoursStart      int    `json:"quiet_hours_start,omitempty"`     // Quiet hours start (hour 0-23)
 	QuietHoursEnd        int    `json:"quiet_hours_end,omitempty"`       // Quiet hours end (hour 0-23)
 
+	// Rules that escalate or silence notifications (:notify rules)
+	NotifyRules []NotifyRule `json:"notify_rules,omitempty"`
+
+	// Translation settings (LibreTranslate-compatible endpoint)
+	TranslateURL    string `json:"translate_url,omitempty"`
+	TranslateAPIKey string `json:"translate_api_key,omitempty"`
+	TranslateTarget string `json:"translate_target,omitempty"` // Target language code, default "en"
+
+	// Spell-check settings
+	SpellCheck     bool   `json:"spell_check,omitempty"`
+	SpellCheckDict string `json:"spell_check_dict,omitempty"` // Extra hunspell .dic or plain word list
+
+	// Voice notes (:voice, :play). The record command writes {file} until
+	// it is interrupted, the encode command turns {in} into Ogg Opus {out},
+	// and the play command plays {file}; empty uses sox and opusenc, or the
+	// Termux API on Android
+	VoiceRecordCommand string `json:"voice_record_command,omitempty"`
+	VoiceEncodeCommand string `json:"voice_encode_command,omitempty"`
+	VoicePlayCommand   string `json:"voice_play_command,omitempty"`
+
+	// Messages longer than this many lines are offered as shared snippets (default 20, -1 disables)
+	SnippetThreshold int `json:"snippet_threshold,omitempty"`
+
+	// Emoji picker history, most recent first
+	RecentEmoji []string `json:"recent_emoji,omitempty"`
+
+	// Show "2m ago" instead of clock times (:time cycles 12h, 24h, relative)
+	RelativeTime bool `json:"relative_time,omitempty"`
+
+	// Compact display (:compact): messages from one sender within
+	// CompactMinutes of the first (default 5) share one header
+	Compact        bool `json:"compact,omitempty"`
+	CompactMinutes int  `json:"compact_minutes,omitempty"`
+
+	// Time zone timestamps are shown in (IANA name, e.g. "Europe/Berlin");
+	// empty means the machine's. ShowServerTime adds the server's clock.
+	TimeZone       string `json:"time_zone,omitempty"`
+	ShowServerTime bool   `json:"show_server_time,omitempty"`
+
+	// How copies reach a clipboard (:clipboard): "auto" (default) uses the
+	// system clipboard, falling back to the terminal's through OSC 52 over
+	// SSH or without a clipboard helper; "osc52" always uses the terminal's
+	// and "system" never does
+	Clipboard string `json:"clipboard,omitempty"`
+
+	// UI language, e.g. "es"; empty follows MARCHAT_LANG, then LANG
+	Locale string `json:"locale,omitempty"`
+
+	// Where received files are saved (empty = ~/Downloads/marchat), and
+	// the largest file from others saved automatically (0 = never)
+	DownloadDir      string `json:"download_dir,omitempty"`
+	AutoSaveMaxBytes int64  `json:"auto_save_max_bytes,omitempty"`
+
+	// Received files kept for :savefile and :play: at most FileCacheBytes
+	// in memory (default 64MB), least recently used dropped first, with
+	// files over FileSpillBytes (default 8MB) kept in a temp directory
+	FileCacheBytes int64 `json:"file_cache_bytes,omitempty"`
+	FileSpillBytes int64 `json:"file_spill_bytes,omitempty"`
+
+	// Display name set with :nick, restored on connect
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Users whose messages are hidden with :ignore
+	Ignored []string `json:"ignored,omitempty"`
+
+	// Shell commands run on chat events
+	Hooks []Hook `json:"hooks,omitempty"`
+
+	// Keepalive and reconnect tuning
+	ConnectionTimings
+
+	// Wire format: "json" keeps plain JSON frames; otherwise the client
+	// offers CBOR, which servers that support it switch to
+	WireFormat string `json:"wire_format,omitempty"`
+
+	// WebSocket compression: flate level 1-9 (-1 = off, 0 = fast) and the
+	// smallest frame in bytes worth compressing (0 = 256)
+	CompressionLevel     int `json:"compression_level,omitempty"`
+	CompressionThreshold int `json:"compression_threshold,omitempty"`
+
 	// Quick start settings
 	SaveCredentials bool  `json:"save_credentials"`
 	LastUsed        int64 `json:"last_used,omitempty"`
 }
 
+// ConnectionTimings tunes keepalive and reconnects, in seconds; zero keeps
+// the default. A profile's values take precedence over config.json's.
+type ConnectionTimings struct {
+	PingInterval      int `json:"ping_interval,omitempty"`       // default 50
+	WriteTimeout      int `json:"write_timeout,omitempty"`       // default 10
+	ReconnectDelay    int `json:"reconnect_delay,omitempty"`     // first retry, doubling each time; default 1
+	ReconnectMaxDelay int `json:"reconnect_max_delay,omitempty"` // default 30
+}
+
+// Hook runs a shell command when something happens in chat. The command gets
+// MARCHAT_* environment variables describing the event, and only runs once
+// the user has confirmed it at startup.
+type Hook struct {
+	Event    string `json:"event"` // "mention" or "file"
+	Command  string `json:"command"`
+	Enabled  bool   `json:"enabled"`
+	Approved string `json:"approved,omitempty"` // digest of the confirmed event and command
+}
+
+// NotifyRule escalates or silences notifications for messages that match a
+// keyword in the text, a sender, or the channel they were posted in. Rules
+// are checked in order and the first match decides.
+type NotifyRule struct {
+	Match  string `json:"match"` // "keyword", "sender" or "channel"
+	Value  string `json:"value"`
+	Action string `json:"action"` // "escalate" or "silence"
+}
+
 // ConnectionProfile represents a saved connection profile
 type ConnectionProfile struct {
-	Name      string `json:"name"`
-	ServerURL string `json:"server_url"`
-	Username  string `json:"username"`
-	IsAdmin   bool   `json:"is_admin"`
-	UseE2E    bool   `json:"use_e2e"`
-	Theme     string `json:"theme,omitempty"`
-	LastUsed  int64  `json:"last_used,omitempty"` // Unix timestamp
+	Name       string   `json:"name"`
+	ServerURL  string   `json:"server_url"`
+	Username   string   `json:"username"`
+	IsAdmin    bool     `json:"is_admin"`
+	UseE2E     bool     `json:"use_e2e"`
+	Theme      string   `json:"theme,omitempty"`
+	SpellCheck bool     `json:"spell_check,omitempty"`
+	Ignored    []string `json:"ignored,omitempty"`   // Users hidden with :ignore
+	LastUsed   int64    `json:"last_used,omitempty"` // Unix timestamp
+
+	NotifyRules []NotifyRule `json:"notify_rules,omitempty"` // Escalate or silence notifications (:notify rules)
+
+	TimeZone       string `json:"time_zone,omitempty"`        // Overrides the machine's zone for this server
+	ShowServerTime bool   `json:"show_server_time,omitempty"` // Show the server's clock next to local time
+
+	DownloadDir      string `json:"download_dir,omitempty"`        // Where received files are saved
+	AutoSaveMaxBytes int64  `json:"auto_save_max_bytes,omitempty"` // Save smaller files automatically (0 = off)
+
+	CertFingerprint string `json:"cert_fingerprint,omitempty"` // Pinned server certificate (SHA-256)
+	ClientCert      string `json:"client_cert,omitempty"`      // Mutual TLS certificate (PEM path)
+	ClientKey       string `json:"client_key,omitempty"`       // Mutual TLS key (PEM path)
+
+	ConnectionTimings
 }
 
 type Profiles struct {
@@ -173,12 +305,13 @@ func (icl *InteractiveConfigLoader) LoadOrPromptConfig(overrides map[string]inte
 		}
 
 		profile := ConnectionProfile{
-			Name:      profileName,
-			ServerURL: newCfg.ServerURL,
-			Username:  newCfg.Username,
-			IsAdmin:   newCfg.IsAdmin,
-			UseE2E:    newCfg.UseE2E,
-			Theme:     newCfg.Theme,
+			Name:       profileName,
+			ServerURL:  newCfg.ServerURL,
+			Username:   newCfg.Username,
+			IsAdmin:    newCfg.IsAdmin,
+			UseE2E:     newCfg.UseE2E,
+			Theme:      newCfg.Theme,
+			SpellCheck: newCfg.SpellCheck,
 		}
 
 		if err := icl.saveProfile(profile); err != nil {
@@ -358,13 +491,146 @@ func (icl *InteractiveConfigLoader) SaveProfiles(profiles *Profiles) error {
 
 func (icl *InteractiveConfigLoader) profileToConfig(profile ConnectionProfile) Config {
 	return Config{
-		Username:       profile.Username,
-		ServerURL:      profile.ServerURL,
-		IsAdmin:        profile.IsAdmin,
-		UseE2E:         profile.UseE2E,
-		Theme:          profile.Theme,
-		TwentyFourHour: true, // Default
+		Username:          profile.Username,
+		ServerURL:         profile.ServerURL,
+		IsAdmin:           profile.IsAdmin,
+		UseE2E:            profile.UseE2E,
+		Theme:             profile.Theme,
+		SpellCheck:        profile.SpellCheck,
+		Ignored:           profile.Ignored,
+		NotifyRules:       profile.NotifyRules,
+		TimeZone:          profile.TimeZone,
+		ShowServerTime:    profile.ShowServerTime,
+		DownloadDir:       profile.DownloadDir,
+		AutoSaveMaxBytes:  profile.AutoSaveMaxBytes,
+		CertFingerprint:   profile.CertFingerprint,
+		ClientCert:        profile.ClientCert,
+		ClientKey:         profile.ClientKey,
+		ConnectionTimings: profile.ConnectionTimings,
+		TwentyFourHour:    true, // Default
+	}
+}
+
+// SetProfileSpellCheck records the spell-check preference on the saved
+// profiles for this server and username
+func (icl *InteractiveConfigLoader) SetProfileSpellCheck(serverURL, username string, enabled bool) error {
+	profiles, err := icl.LoadProfiles()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, p := range profiles.Profiles {
+		if p.ServerURL == serverURL && p.Username == username && p.SpellCheck != enabled {
+			profiles.Profiles[i].SpellCheck = enabled
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return icl.SaveProfiles(profiles)
+}
+
+// SetProfileIgnored records the :ignore list on the saved profiles for this
+// server and username
+func (icl *InteractiveConfigLoader) SetProfileIgnored(serverURL, username string, ignored []string) error {
+	profiles, err := icl.LoadProfiles()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, p := range profiles.Profiles {
+		if p.ServerURL == serverURL && p.Username == username {
+			profiles.Profiles[i].Ignored = ignored
+			changed = true
+		}
 	}
+	if !changed {
+		return nil
+	}
+	return icl.SaveProfiles(profiles)
+}
+
+// SetProfileNotifyRules records the notification rules on the saved
+// profiles for this server and username
+func (icl *InteractiveConfigLoader) SetProfileNotifyRules(serverURL, username string, rules []NotifyRule) error {
+	profiles, err := icl.LoadProfiles()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, p := range profiles.Profiles {
+		if p.ServerURL == serverURL && p.Username == username {
+			profiles.Profiles[i].NotifyRules = rules
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return icl.SaveProfiles(profiles)
+}
+
+// SetProfileTimeZone records the time zone settings on the saved profiles
+// for this server and username
+func (icl *InteractiveConfigLoader) SetProfileTimeZone(serverURL, username, zone string, showServerTime bool) error {
+	profiles, err := icl.LoadProfiles()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, p := range profiles.Profiles {
+		if p.ServerURL == serverURL && p.Username == username {
+			profiles.Profiles[i].TimeZone = zone
+			profiles.Profiles[i].ShowServerTime = showServerTime
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return icl.SaveProfiles(profiles)
+}
+
+// SetProfileDownloads records the download directory and auto-save limit on
+// the saved profiles for this server and username
+func (icl *InteractiveConfigLoader) SetProfileDownloads(serverURL, username, dir string, autoSaveMaxBytes int64) error {
+	profiles, err := icl.LoadProfiles()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, p := range profiles.Profiles {
+		if p.ServerURL == serverURL && p.Username == username {
+			profiles.Profiles[i].DownloadDir = dir
+			profiles.Profiles[i].AutoSaveMaxBytes = autoSaveMaxBytes
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return icl.SaveProfiles(profiles)
+}
+
+// SetProfileCertFingerprint pins the server certificate on the saved profiles
+// for this server and username, reporting whether any profile matched
+func (icl *InteractiveConfigLoader) SetProfileCertFingerprint(serverURL, username, fingerprint string) (bool, error) {
+	profiles, err := icl.LoadProfiles()
+	if err != nil {
+		return false, err
+	}
+	changed := false
+	for i, p := range profiles.Profiles {
+		if p.ServerURL == serverURL && p.Username == username {
+			profiles.Profiles[i].CertFingerprint = fingerprint
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, icl.SaveProfiles(profiles)
 }
 
 func (icl *InteractiveConfigLoader) applyOverrides(cfg *Config, overrides map[string]interface{}) {
@@ -497,6 +763,27 @@ func (icl *InteractiveConfigLoader) AutoConnect() (*Config, error) {
 	return &cfg, nil
 }
 
+// ConnectLink finds the saved profile for a server and username opened from
+// a marchat:// link, marking it as used. It reports false when there is none.
+func (icl *InteractiveConfigLoader) ConnectLink(serverURL, username string) (*Config, bool) {
+	profiles, err := icl.LoadProfiles()
+	if err != nil {
+		return nil, false
+	}
+	for i, p := range profiles.Profiles {
+		if strings.EqualFold(p.ServerURL, serverURL) && strings.EqualFold(p.Username, username) {
+			fmt.Printf("Connecting to: %s (%s@%s)\n", p.Name, p.Username, p.ServerURL)
+			profiles.Profiles[i].LastUsed = time.Now().Unix()
+			if err := icl.SaveProfiles(profiles); err != nil {
+				fmt.Printf("Warning: Could not update profile usage timestamp: %v\n", err)
+			}
+			cfg := icl.profileToConfig(p)
+			return &cfg, true
+		}
+	}
+	return nil, false
+}
+
 // QuickStartConnect shows profiles with management features and connects to selected one
 func (icl *InteractiveConfigLoader) QuickStartConnect() (*Config, error) {
 	profiles, err := icl.LoadProfiles()
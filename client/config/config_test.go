@@ -334,17 +334,23 @@ func TestInteractiveConfigLoaderProfileToConfig(t *testing.T) {
 	icl := &InteractiveConfigLoader{}
 
 	profile := ConnectionProfile{
-		Name:      "test-profile",
-		ServerURL: "ws://test:8080",
-		Username:  "testuser",
-		IsAdmin:   true,
-		UseE2E:    true,
-		Theme:     "modern",
-		LastUsed:  time.Now().Unix(),
+		Name:           "test-profile",
+		ServerURL:      "ws://test:8080",
+		Username:       "testuser",
+		IsAdmin:        true,
+		UseE2E:         true,
+		Theme:          "modern",
+		TimeZone:       "Asia/Tokyo",
+		ShowServerTime: true,
+		LastUsed:       time.Now().Unix(),
 	}
 
 	cfg := icl.profileToConfig(profile)
 
+	if cfg.TimeZone != profile.TimeZone || !cfg.ShowServerTime {
+		t.Errorf("Expected time zone %s with server time, got %s (%v)", profile.TimeZone, cfg.TimeZone, cfg.ShowServerTime)
+	}
+
 	if cfg.Username != profile.Username {
 		t.Errorf("Expected username %s, got %s", profile.Username, cfg.Username)
 	}
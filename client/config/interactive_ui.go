@@ -414,6 +414,7 @@ type ProfileSelectionModel struct {
 	modified        bool                     // tracks if profiles were modified
 	icl             *InteractiveConfigLoader // for saving profiles
 	selectedProfile *ConnectionProfile       // Store the actual selected profile
+	discovered      []ConnectionProfile      // LAN servers from --discover; never saved
 }
 
 func NewProfileSelectionModel(profiles []ConnectionProfile, showNewOption bool) ProfileSelectionModel {
@@ -478,7 +479,7 @@ func (m ProfileSelectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor--
 			}
 		case "down", "j":
-			maxCursor := len(m.profiles) - 1
+			maxCursor := len(m.profiles) + len(m.discovered) - 1
 			if m.showNewOption {
 				maxCursor++
 			}
@@ -486,12 +487,19 @@ func (m ProfileSelectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 		case "enter":
-			if m.showNewOption && m.cursor == len(m.profiles) {
+			if m.showNewOption && m.cursor == len(m.profiles)+len(m.discovered) {
 				// Selected "Create New Profile"
 				m.choice = m.cursor
 				m.selected = true
 				// selectedProfile remains nil for "create new"
 				return m, tea.Quit
+			} else if m.cursor >= len(m.profiles) && m.cursor < len(m.profiles)+len(m.discovered) {
+				// A discovered server starts a new profile for it
+				m.choice = m.cursor
+				m.selected = true
+				profile := m.discovered[m.cursor-len(m.profiles)]
+				m.selectedProfile = &profile
+				return m, tea.Quit
 			} else if m.cursor < len(m.profiles) {
 				m.choice = m.cursor
 				m.selected = true
@@ -690,10 +698,23 @@ func (m ProfileSelectionModel) View() string {
 		b.WriteString("\n")
 	}
 
+	if len(m.discovered) > 0 {
+		b.WriteString("\n" + infoStyle.Render("On this network:") + "\n")
+		for i, server := range m.discovered {
+			line := fmt.Sprintf("%s (%s) [LAN]", server.Name, server.ServerURL)
+			if m.cursor == len(m.profiles)+i {
+				b.WriteString(focusedStyle.Render("▶ " + line))
+			} else {
+				b.WriteString("  " + line)
+			}
+			b.WriteString("\n")
+		}
+	}
+
 	// Add "Create New Profile" option if enabled
 	if m.showNewOption {
 		newProfileLine := "Create New Profile"
-		if m.cursor == len(m.profiles) {
+		if m.cursor == len(m.profiles)+len(m.discovered) {
 			b.WriteString(focusedStyle.Render("▶ " + newProfileLine))
 		} else {
 			b.WriteString("  " + newProfileLine)
@@ -830,7 +851,7 @@ func (m ProfileSelectionModel) IsModified() bool {
 
 // IsCreateNew returns true if user selected "Create New Profile"
 func (m ProfileSelectionModel) IsCreateNew() bool {
-	return m.showNewOption && m.choice == len(m.profiles)
+	return m.showNewOption && m.choice >= len(m.profiles)
 }
 
 // GetSelectedProfile returns the actual selected profile object
@@ -893,7 +914,14 @@ func RunEnhancedProfileSelection(profiles []ConnectionProfile, icl *InteractiveC
 
 // RunEnhancedProfileSelectionWithNew runs the enhanced profile selection UI with "Create New" option
 func RunEnhancedProfileSelectionWithNew(profiles []ConnectionProfile, icl *InteractiveConfigLoader) (*ConnectionProfile, bool, error) {
+	return RunProfileSelectionWithDiscovered(profiles, nil, icl)
+}
+
+// RunProfileSelectionWithDiscovered also lists servers found on the LAN.
+// Choosing one reports create-new with the discovered server as the profile.
+func RunProfileSelectionWithDiscovered(profiles, discovered []ConnectionProfile, icl *InteractiveConfigLoader) (*ConnectionProfile, bool, error) {
 	model := NewEnhancedProfileSelectionModel(profiles, true, icl)
+	model.discovered = discovered
 
 	program := tea.NewProgram(model)
 	finalModel, err := program.Run()
@@ -1147,7 +1175,22 @@ func RunSensitiveDataPrompt(isAdmin, useE2E bool) (adminKey, keystorePass string
 
 // RunInteractiveConfig runs the interactive configuration UI
 func RunInteractiveConfig() (*Config, string, error) {
+	return RunInteractiveConfigPrefilled("", "")
+}
+
+// RunInteractiveConfigPrefilled runs the configuration UI with the server URL
+// and username already filled in, e.g. from a marchat:// link
+func RunInteractiveConfigPrefilled(serverURL, username string) (*Config, string, error) {
 	model := NewConfigUI()
+	if serverURL != "" {
+		model.inputs[serverURLField].SetValue(serverURL)
+		model.focusIndex = int(usernameField)
+		if username != "" {
+			model.inputs[usernameField].SetValue(username)
+			model.focusIndex = int(adminField)
+		}
+		model.updateFocus()
+	}
 
 	program := tea.NewProgram(model)
 	finalModel, err := program.Run()
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -376,6 +377,39 @@ func TestProfileSelectionModelSelection(t *testing.T) {
 	}
 }
 
+// Test selecting a server found by --discover
+func TestProfileSelectionModelDiscovered(t *testing.T) {
+	profiles := []ConnectionProfile{
+		{Name: "Profile1", Username: "user1", ServerURL: "wss://server1.com"},
+	}
+
+	model := NewProfileSelectionModel(profiles, true)
+	model.discovered = []ConnectionProfile{{Name: "lanbox", ServerURL: "ws://192.168.1.5:8080/ws"}}
+
+	if !strings.Contains(model.View(), "lanbox (ws://192.168.1.5:8080/ws) [LAN]") {
+		t.Error("Expected discovered server in view")
+	}
+
+	// Saved profile, discovered server, then "Create New Profile"
+	var updated tea.Model = model
+	for i := 0; i < 3; i++ {
+		updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if cursor := updated.(ProfileSelectionModel).cursor; cursor != 2 {
+		t.Errorf("Expected cursor to stop at 2, got %d", cursor)
+	}
+
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyUp})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	psModel := updated.(ProfileSelectionModel)
+	if !psModel.IsCreateNew() {
+		t.Error("Expected a discovered server to create a new profile")
+	}
+	if p := psModel.GetSelectedProfile(); p == nil || p.ServerURL != "ws://192.168.1.5:8080/ws" {
+		t.Errorf("Expected the discovered server as the selected profile, got %+v", p)
+	}
+}
+
 // Test ProfileSelectionModel operations
 func TestProfileSelectionModelOperations(t *testing.T) {
 	profiles := []ConnectionProfile{
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// daemonReplayLimit is how many recent chat messages the daemon hands to a
+// TUI when it attaches
+const daemonReplayLimit = 200
+
+// daemonSocketPath is where a --daemon listens for TUI clients to attach
+func daemonSocketPath() string {
+	return filepath.Join(getClientConfigDir(), "daemon.sock")
+}
+
+// daemonStatus is served at /status on the daemon socket
+type daemonStatus struct {
+	Server    string `json:"server"`
+	Username  string `json:"username"`
+	Connected bool   `json:"connected"`
+	Attached  bool   `json:"attached"`
+}
+
+// daemonHTTPClient talks HTTP over the daemon's unix socket
+func daemonHTTPClient(path string) *http.Client {
+	return &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+// queryDaemon returns the status of the daemon listening at path
+func queryDaemon(path string) (daemonStatus, error) {
+	var status daemonStatus
+	resp, err := daemonHTTPClient(path).Get("http://daemon/status")
+	if err != nil {
+		return status, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// attachDaemon connects to a running daemon holding a session for the same
+// server and username, or returns nil when there is none
+func attachDaemon(serverURL, username string) *websocket.Conn {
+	path := daemonSocketPath()
+	status, err := queryDaemon(path)
+	if err != nil || status.Server != serverURL || status.Username != username {
+		return nil
+	}
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 2 * time.Second,
+		NetDialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	conn, _, err := dialer.Dial("ws://daemon/attach", nil)
+	if err != nil {
+		log.Printf("Could not attach to daemon: %v", err)
+		return nil
+	}
+	log.Printf("Attached to daemon session at %s", path)
+	return conn
+}
+
+// chatDaemon holds one server session without a TUI. It notifies on
+// mentions and relays the session to a TUI that attaches over the socket.
+type chatDaemon struct {
+	cfg      config.Config
+	notifier *NotificationManager
+	hooks    []config.Hook
+
+	mu       sync.Mutex
+	server   *websocket.Conn
+	attached *websocket.Conn
+	latest   map[string][]byte // last frame of each wsMsg type, e.g. the user list
+	recent   [][]byte          // chat messages since connecting, for replay
+	since    time.Time         // when the current connection was made
+
+	writeMu sync.Mutex // serializes writes to server
+}
+
+// runDaemon keeps the session connected until interrupted
+func runDaemon(cfg *config.Config, hooks []config.Hook) error {
+	path := daemonSocketPath()
+	if status, err := queryDaemon(path); err == nil {
+		return fmt.Errorf("a daemon is already running for %s on %s", status.Username, status.Server)
+	}
+	_ = os.Remove(path) // left over from a daemon that did not exit cleanly
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", path, err)
+	}
+	defer os.Remove(path)
+	_ = os.Chmod(path, 0600)
+
+	// The daemon inspects frames and relays them to the TUI as they are,
+	// and an attached TUI expects JSON
+	daemonCfg := *cfg
+	daemonCfg.WireFormat = "json"
+
+	// Notifying is the daemon's job, so mentions reach the desktop even
+	// when the TUI only rings the bell; "none" still silences it
+	notifCfg := configToNotificationConfig(*cfg)
+	if notifCfg.Mode != NotificationModeNone {
+		notifCfg.DesktopEnabled = true
+		notifCfg.DesktopOnMention = true
+	}
+	d := &chatDaemon{
+		cfg:      daemonCfg,
+		notifier: NewNotificationManager(notifCfg),
+		hooks:    hooks,
+		latest:   make(map[string][]byte),
+	}
+	setIgnoredUsers(cfg.Ignored)
+	if err := setDisplayZone(cfg.TimeZone, cfg.ShowServerTime); err != nil {
+		log.Printf("Warning: %v, showing local time", err)
+	}
+
+	srv := &http.Server{Handler: d.handler()}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("🛰️  marchat daemon for %s on %s\n", cfg.Username, cfg.ServerURL)
+	fmt.Printf("Run marchat-client again to open the chat; it attaches via %s\n", path)
+	return d.run(ctx)
+}
+
+// run connects and reconnects to the server until ctx is done
+func (d *chatDaemon) run(ctx context.Context) error {
+	timings := connectionTimings()
+	delay := timings.ReconnectDelay
+	for ctx.Err() == nil {
+		conn, err := dialChat(d.cfg.ServerURL, d.cfg)
+		if err != nil {
+			var usernameErr wsUsernameError
+			if errors.As(err, &usernameErr) {
+				return usernameErr
+			}
+			if !retryable(err) {
+				return err
+			}
+			var pinErr certPinError
+			if errors.As(err, &pinErr) {
+				return pinErr
+			}
+			fmt.Printf("Connection failed: %v (retrying in %s)\n", err, delay)
+		} else {
+			delay = timings.ReconnectDelay
+			fmt.Println("✅ Connected")
+			if err := d.serve(ctx, conn); err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Println("🚫 Connection lost. Reconnecting...")
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(reconnectWait(delay)):
+		}
+		if delay < timings.ReconnectMax {
+			delay = min(delay*2, timings.ReconnectMax)
+		}
+	}
+	return nil
+}
+
+// serve relays frames from one server connection until it drops. It
+// returns an error only when reconnecting cannot help.
+func (d *chatDaemon) serve(ctx context.Context, conn *websocket.Conn) error {
+	d.mu.Lock()
+	d.server = conn
+	// The server replays its history on connect
+	d.recent = nil
+	d.since = serverNow()
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(connectionTimings().Ping)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = writePing(conn)
+			}
+		}
+	}()
+
+	var rejected error
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			log.Printf("Daemon read error: %v", err)
+			if rejection, ok := rejectionFromClose(err, d.cfg.Username); ok && !retryable(rejection) {
+				rejected = rejection
+			}
+			break
+		}
+		var ws wsMsg
+		if json.Unmarshal(raw, &ws) == nil {
+			if rejection, ok := usernameRejection(ws, d.cfg.Username); ok {
+				rejected = rejection
+				break
+			}
+			d.syncServerClock(ws)
+		}
+		d.handleFrame(raw)
+	}
+
+	d.mu.Lock()
+	d.server = nil
+	// Let the TUI see the disconnect; it re-attaches while we reconnect
+	if d.attached != nil {
+		d.attached.Close()
+		d.attached = nil
+	}
+	d.mu.Unlock()
+	conn.Close()
+	return rejected
+}
+
+// syncServerClock measures the server's clock from its version frame, so
+// history is told apart from new messages whatever the local clock says
+func (d *chatDaemon) syncServerClock(ws wsMsg) {
+	var info shared.VersionInfo
+	if ws.Type != "version" || json.Unmarshal(ws.Data, &info) != nil {
+		return
+	}
+	if moved, ok := syncServerClock(info, time.Now()); ok {
+		d.mu.Lock()
+		d.since = d.since.Add(moved)
+		d.mu.Unlock()
+	}
+}
+
+// handleFrame records, logs and forwards one frame from the server
+func (d *chatDaemon) handleFrame(raw []byte) {
+	var msg shared.Message
+	isChat := json.Unmarshal(raw, &msg) == nil && msg.Sender != ""
+
+	d.mu.Lock()
+	if isChat {
+		d.recent = append(d.recent, raw)
+		if len(d.recent) > daemonReplayLimit {
+			d.recent = d.recent[len(d.recent)-daemonReplayLimit:]
+		}
+	} else {
+		var ws wsMsg
+		if json.Unmarshal(raw, &ws) == nil && ws.Type != "" {
+			d.latest[ws.Type] = raw
+		}
+	}
+	attached := d.attached
+	d.mu.Unlock()
+
+	if attached != nil {
+		// The TUI shows and notifies for itself
+		if err := attached.WriteMessage(websocket.TextMessage, raw); err != nil {
+			log.Printf("Daemon relay to TUI failed: %v", err)
+		}
+		return
+	}
+	if isChat {
+		d.logMessage(msg)
+	}
+}
+
+// logMessage prints a message and notifies on mentions
+func (d *chatDaemon) logMessage(msg shared.Message) {
+	if isIgnored(msg.Sender) {
+		return
+	}
+	content := msg.Content
+	if msg.Encrypted {
+		content = "[encrypted message]"
+	}
+	fmt.Printf("[%s] %s: %s\n", formatTimestamp(msg.CreatedAt, "15:04:05"), displayName(msg.Sender), content)
+	// History replayed on connect was already seen or missed
+	if msg.Encrypted || msg.Sender == d.cfg.Username || msg.CreatedAt.Before(d.since) {
+		return
+	}
+	// Rules can also escalate messages that don't mention us
+	if daemonShouldNotify(msg, d.cfg.Username) || d.notifier.RuleAction(msg, defaultChannel) == notifyEscalate {
+		d.notifier.NotifyMessage(msg, defaultChannel, NotificationLevelMention)
+	}
+	runHooks(d.hooks, msg, d.cfg.Username, d.cfg.ServerURL)
+}
+
+// daemonShouldNotify reports whether msg mentions username or is an
+// announcement; the daemon stays quiet about everything else
+func daemonShouldNotify(msg shared.Message, username string) bool {
+	if msg.Type == shared.AnnouncementType {
+		return true
+	}
+	return mentionsUser(msg, username)
+}
+
+// handler serves the daemon socket
+func (d *chatDaemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", d.handleStatus)
+	mux.HandleFunc("/attach", d.handleAttach)
+	return mux
+}
+
+func (d *chatDaemon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	status := daemonStatus{
+		Server:    d.cfg.ServerURL,
+		Username:  d.cfg.Username,
+		Connected: d.server != nil,
+		Attached:  d.attached != nil,
+	}
+	d.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+var daemonUpgrader = websocket.Upgrader{}
+
+// handleAttach hands the session to a TUI: it replays the current state,
+// then relays frames both ways until the TUI quits
+func (d *chatDaemon) handleAttach(w http.ResponseWriter, r *http.Request) {
+	conn, err := daemonUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	if d.attached != nil {
+		d.attached.Close() // the newest TUI wins
+	}
+	d.attached = conn
+	replay := make([][]byte, 0, len(d.latest)+len(d.recent))
+	for _, frame := range d.latest {
+		replay = append(replay, frame)
+	}
+	replay = append(replay, d.recent...)
+	// Replay under the lock so no live frame overtakes it
+	for _, frame := range replay {
+		if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+			break
+		}
+	}
+	d.mu.Unlock()
+	fmt.Println("📺 TUI attached")
+
+	for {
+		msgType, raw, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		d.mu.Lock()
+		server := d.server
+		d.mu.Unlock()
+		if server == nil {
+			continue // dropped while reconnecting
+		}
+		d.writeMu.Lock()
+		_ = server.SetWriteDeadline(time.Now().Add(connectionTimings().Write))
+		setWriteCompression(server, len(raw))
+		err = server.WriteMessage(msgType, raw)
+		d.writeMu.Unlock()
+		if err != nil {
+			log.Printf("Daemon relay to server failed: %v", err)
+		}
+	}
+
+	d.mu.Lock()
+	if d.attached == conn {
+		d.attached = nil
+		fmt.Println("📺 TUI detached")
+	}
+	d.mu.Unlock()
+	conn.Close()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestDaemonAttach(t *testing.T) {
+	t.Setenv("MARCHAT_CONFIG_DIR", t.TempDir())
+
+	// A stand-in chat server that records what it receives
+	received := make(chan string, 4)
+	upgrader := websocket.Upgrader{}
+	chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- string(raw)
+		}
+	}))
+	defer chat.Close()
+	serverConn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(chat.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverConn.Close()
+
+	d := &chatDaemon{
+		cfg:      config.Config{ServerURL: "ws://chat.example/ws", Username: "alice"},
+		notifier: NewNotificationManager(NotificationConfig{}),
+		latest:   make(map[string][]byte),
+		server:   serverConn,
+	}
+	ln, err := net.Listen("unix", daemonSocketPath())
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := &http.Server{Handler: d.handler()}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	d.handleFrame([]byte(`{"type":"userlist","data":{"users":["alice","bob"]}}`))
+	d.handleFrame([]byte(`{"sender":"bob","content":"hi @alice"}`))
+
+	if attachDaemon("ws://chat.example/ws", "bob") != nil {
+		t.Fatal("Should not attach to another user's session")
+	}
+	conn := attachDaemon("ws://chat.example/ws", "alice")
+	if conn == nil {
+		t.Fatal("Expected to attach to the daemon")
+	}
+	defer conn.Close()
+
+	// State first, then recent messages
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, want := range []string{`"userlist"`, `"hi @alice"`} {
+		_, raw, err := conn.ReadMessage()
+		if err != nil || !strings.Contains(string(raw), want) {
+			t.Fatalf("Expected replayed frame with %s, got %q (%v)", want, raw, err)
+		}
+	}
+
+	// Live frames and outgoing messages are relayed
+	d.handleFrame([]byte(`{"sender":"bob","content":"live"}`))
+	if _, raw, err := conn.ReadMessage(); err != nil || !strings.Contains(string(raw), "live") {
+		t.Fatalf("Expected live frame, got %q (%v)", raw, err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"content":"from tui"}`)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if !strings.Contains(got, "from tui") {
+			t.Errorf("Server got %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Message from the TUI was not relayed to the server")
+	}
+}
+
+func TestDaemonShouldNotify(t *testing.T) {
+	tests := []struct {
+		msg  shared.Message
+		want bool
+	}{
+		{shared.Message{Sender: "bob", Content: "hey @Alice"}, true},
+		{shared.Message{Sender: "bob", Content: "hey everyone"}, false},
+		{shared.Message{Sender: "bob", Content: "@here standup", Mentions: []string{"alice", "carol"}}, true},
+		{shared.Message{Sender: "bob", Content: "@devs deploy", Mentions: []string{"carol"}}, false},
+		{shared.Message{Sender: "System", Content: "Maintenance soon", Type: shared.AnnouncementType}, true},
+	}
+	for _, tt := range tests {
+		if got := daemonShouldNotify(tt.msg, "alice"); got != tt.want {
+			t.Errorf("daemonShouldNotify(%q) = %v, want %v", tt.msg.Content, got, tt.want)
+		}
+	}
+}
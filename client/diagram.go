@@ -0,0 +1,391 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// diagramTool is what the composer draws between the marked point and the
+// cursor
+type diagramTool int
+
+const (
+	diagramNoTool diagramTool = iota
+	diagramBox
+	diagramLine
+	diagramArrow
+)
+
+// maxDiagramUndo caps the composer's undo history
+const maxDiagramUndo = 50
+
+// diagramComposer is a keyboard whiteboard: a fixed grid of characters the
+// size of the largest diagram the server accepts, seen through a window
+// that follows the cursor. Shapes are drawn in plain ASCII so they line up
+// in every terminal.
+type diagramComposer struct {
+	grid     [][]rune
+	x, y     int
+	typeCol  int // column Enter returns to, where typing started
+	markX    int
+	markY    int
+	tool     diagramTool // diagramNoTool unless a start point is marked
+	undo     [][][]rune
+	top      int // first row shown
+	left     int // first column shown
+	viewW    int
+	viewH    int
+	errorMsg string
+}
+
+// newDiagramComposer returns an empty composer showing viewW x viewH cells
+func newDiagramComposer(viewW, viewH int) diagramComposer {
+	grid := make([][]rune, shared.MaxDiagramLines)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", shared.MaxDiagramWidth))
+	}
+	return diagramComposer{
+		grid:  grid,
+		viewW: min(max(viewW, 20), shared.MaxDiagramWidth),
+		viewH: min(max(viewH, 5), shared.MaxDiagramLines),
+	}
+}
+
+// load places a received diagram in the top-left corner to work on
+func (d *diagramComposer) load(text string) {
+	for y, line := range strings.Split(text, "\n") {
+		if y >= len(d.grid) {
+			break
+		}
+		for x, r := range []rune(line) {
+			if x >= len(d.grid[y]) {
+				break
+			}
+			d.grid[y][x] = r
+		}
+	}
+}
+
+// String returns the drawing without the empty margins around it
+func (d *diagramComposer) String() string {
+	rows := make([]string, len(d.grid))
+	indent := shared.MaxDiagramWidth
+	for i, row := range d.grid {
+		rows[i] = strings.TrimRight(string(row), " ")
+		if rows[i] != "" {
+			indent = min(indent, len(row)-len([]rune(strings.TrimLeft(string(row), " "))))
+		}
+	}
+	for i, row := range rows {
+		if row != "" {
+			rows[i] = string([]rune(row)[indent:])
+		}
+	}
+	return strings.Trim(strings.Join(rows, "\n"), "\n")
+}
+
+// snapshot saves the grid for ctrl+z before a change
+func (d *diagramComposer) snapshot() {
+	saved := make([][]rune, len(d.grid))
+	for i, row := range d.grid {
+		saved[i] = append([]rune(nil), row...)
+	}
+	d.undo = append(d.undo, saved)
+	if len(d.undo) > maxDiagramUndo {
+		d.undo = d.undo[1:]
+	}
+}
+
+// set writes one cell, ignoring anything off the grid
+func (d *diagramComposer) set(x, y int, r rune) {
+	if y >= 0 && y < len(d.grid) && x >= 0 && x < len(d.grid[y]) {
+		d.grid[y][x] = r
+	}
+}
+
+// stroke draws a straight edge cell, turning crossings into '+'
+func (d *diagramComposer) stroke(x, y int, r rune) {
+	if y < 0 || y >= len(d.grid) || x < 0 || x >= len(d.grid[y]) {
+		return
+	}
+	if existing := d.grid[y][x]; (existing == '-' && r == '|') || (existing == '|' && r == '-') {
+		r = '+'
+	}
+	d.grid[y][x] = r
+}
+
+// drawBox draws a rectangle with (x1, y1) and (x2, y2) as opposite corners
+func (d *diagramComposer) drawBox(x1, y1, x2, y2 int) {
+	if x1 == x2 || y1 == y2 {
+		d.drawLine(x1, y1, x2, y2, false)
+		return
+	}
+	x1, x2 = min(x1, x2), max(x1, x2)
+	y1, y2 = min(y1, y2), max(y1, y2)
+	for x := x1 + 1; x < x2; x++ {
+		d.stroke(x, y1, '-')
+		d.stroke(x, y2, '-')
+	}
+	for y := y1 + 1; y < y2; y++ {
+		d.stroke(x1, y, '|')
+		d.stroke(x2, y, '|')
+	}
+	for _, c := range [][2]int{{x1, y1}, {x2, y1}, {x1, y2}, {x2, y2}} {
+		d.set(c[0], c[1], '+')
+	}
+}
+
+// drawLine joins two points: across along the first row, then up or down
+// the last column, with an arrowhead at the end when arrow is set
+func (d *diagramComposer) drawLine(x1, y1, x2, y2 int, arrow bool) {
+	if x1 == x2 && y1 == y2 {
+		return
+	}
+	step := 1
+	if x2 < x1 {
+		step = -1
+	}
+	for x := x1; x != x2; x += step {
+		d.stroke(x, y1, '-')
+	}
+	step = 1
+	if y2 < y1 {
+		step = -1
+	}
+	for y := y1; y != y2; y += step {
+		d.stroke(x2, y, '|')
+	}
+	if x1 != x2 && y1 != y2 {
+		d.set(x2, y1, '+')
+	}
+	end := '-'
+	if y1 != y2 {
+		end = '|'
+	}
+	if arrow {
+		switch {
+		case y2 > y1:
+			end = 'v'
+		case y2 < y1:
+			end = '^'
+		case x2 > x1:
+			end = '>'
+		default:
+			end = '<'
+		}
+	}
+	d.stroke(x2, y2, end)
+}
+
+// move shifts the cursor, keeping it on the grid and in view
+func (d *diagramComposer) move(dx, dy int) {
+	d.x = min(max(d.x+dx, 0), shared.MaxDiagramWidth-1)
+	d.y = min(max(d.y+dy, 0), shared.MaxDiagramLines-1)
+	if d.x < d.left {
+		d.left = d.x
+	} else if d.x >= d.left+d.viewW {
+		d.left = d.x - d.viewW + 1
+	}
+	if d.y < d.top {
+		d.top = d.y
+	} else if d.y >= d.top+d.viewH {
+		d.top = d.y - d.viewH + 1
+	}
+}
+
+// pickTool marks the start of a shape, or draws it if the same tool is
+// already marked
+func (d *diagramComposer) pickTool(tool diagramTool) {
+	if d.tool != tool {
+		d.tool, d.markX, d.markY = tool, d.x, d.y
+		return
+	}
+	d.snapshot()
+	switch tool {
+	case diagramBox:
+		d.drawBox(d.markX, d.markY, d.x, d.y)
+	case diagramLine, diagramArrow:
+		d.drawLine(d.markX, d.markY, d.x, d.y, tool == diagramArrow)
+	}
+	d.tool = diagramNoTool
+}
+
+// Update edits the grid. Arrows move, typing writes, ctrl+b, ctrl+l and
+// ctrl+a mark and then draw a box, line or arrow, and ctrl+z undoes. The
+// caller handles ctrl+s (send) and esc once no shape is marked (close).
+func (d *diagramComposer) Update(v tea.KeyMsg) {
+	d.errorMsg = ""
+	switch v.String() {
+	case "up":
+		d.move(0, -1)
+	case "down":
+		d.move(0, 1)
+	case "left":
+		d.move(-1, 0)
+	case "right":
+		d.move(1, 0)
+	case "home":
+		d.move(-d.x, 0)
+	case "end":
+		d.move(shared.MaxDiagramWidth, 0)
+	default:
+		d.edit(v)
+		return
+	}
+	d.typeCol = d.x
+}
+
+// edit handles keys that change the grid
+func (d *diagramComposer) edit(v tea.KeyMsg) {
+	switch v.String() {
+	case "ctrl+b":
+		d.pickTool(diagramBox)
+	case "ctrl+l":
+		d.pickTool(diagramLine)
+	case "ctrl+a":
+		d.pickTool(diagramArrow)
+	case "ctrl+z":
+		if n := len(d.undo); n > 0 {
+			d.grid, d.undo = d.undo[n-1], d.undo[:n-1]
+		}
+	case "enter":
+		d.move(d.typeCol-d.x, 1)
+	case "backspace":
+		if d.x > 0 {
+			d.snapshot()
+			d.move(-1, 0)
+			d.set(d.x, d.y, ' ')
+		}
+	case "delete":
+		d.snapshot()
+		d.set(d.x, d.y, ' ')
+	default:
+		if v.Type != tea.KeyRunes && v.Type != tea.KeySpace {
+			return
+		}
+		runes := v.Runes
+		if v.Type == tea.KeySpace {
+			runes = []rune{' '}
+		}
+		for _, r := range runes {
+			if r != '\n' && r != '\r' && !shared.IsDiagramRune(r) {
+				d.errorMsg = i18n.T("diagram.not_single_width")
+				return
+			}
+		}
+		d.snapshot()
+		// Pasted text keeps its line breaks
+		for _, r := range runes {
+			switch r {
+			case '\r':
+			case '\n':
+				d.move(d.typeCol-d.x, 1)
+			default:
+				d.set(d.x, d.y, r)
+				d.move(1, 0)
+			}
+		}
+	}
+}
+
+// View draws the visible part of the grid with the cursor and any marked
+// start point highlighted
+func (d *diagramComposer) View(styles themeStyles) string {
+	var b strings.Builder
+	cursor := styles.Mention.Reverse(true)
+	mark := styles.Time.Reverse(true)
+	for y := d.top; y < d.top+d.viewH && y < len(d.grid); y++ {
+		for x := d.left; x < d.left+d.viewW && x < len(d.grid[y]); x++ {
+			cell := string(d.grid[y][x])
+			switch {
+			case x == d.x && y == d.y:
+				b.WriteString(cursor.Render(cell))
+			case d.tool != diagramNoTool && x == d.markX && y == d.markY:
+				b.WriteString(mark.Render(cell))
+			default:
+				b.WriteString(cell)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	status := i18n.T("diagram.position", d.x+1, d.y+1)
+	switch d.tool {
+	case diagramBox:
+		status += " • " + i18n.T("diagram.marked_box")
+	case diagramLine:
+		status += " • " + i18n.T("diagram.marked_line")
+	case diagramArrow:
+		status += " • " + i18n.T("diagram.marked_arrow")
+	}
+	if d.errorMsg != "" {
+		status += " • " + d.errorMsg
+	}
+	return styles.User.Render(i18n.T("diagram.title")) + "\n\n" +
+		b.String() + "\n" +
+		styles.Time.Render(status+"\n"+i18n.T("diagram.hint"))
+}
+
+// latestDiagram returns the diagram to reopen with :diagram edit: the
+// selected message if it is one, otherwise the newest
+func (m *model) latestDiagram() (shared.Message, bool) {
+	if i := m.selectedMessage(); i >= 0 && m.messages[i].Type == shared.DiagramMessageType {
+		return m.messages[i], true
+	}
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Type == shared.DiagramMessageType && !isIgnored(m.messages[i].Sender) {
+			return m.messages[i], true
+		}
+	}
+	return shared.Message{}, false
+}
+
+// openDiagramComposer shows the composer, starting from text if given
+func (m *model) openDiagramComposer(text string) {
+	m.diagram = newDiagramComposer(m.width-16, m.height-16)
+	m.diagram.load(text)
+	m.showDiagram = true
+}
+
+// updateDiagram handles keys while the composer is open
+func (m *model) updateDiagram(v tea.KeyMsg) {
+	switch v.String() {
+	case "esc":
+		if m.diagram.tool != diagramNoTool {
+			m.diagram.tool = diagramNoTool
+			return
+		}
+		m.showDiagram = false
+	case "ctrl+s":
+		m.sendDiagram()
+	default:
+		m.diagram.Update(v)
+	}
+}
+
+// sendDiagram sends the composer's drawing and closes it
+func (m *model) sendDiagram() {
+	text, err := shared.NormalizeDiagram(m.diagram.String())
+	if err != nil {
+		m.diagram.errorMsg = err.Error()
+		return
+	}
+	if m.useE2E {
+		m.diagram.errorMsg = i18n.T("diagram.encrypted")
+		return
+	}
+	if m.conn == nil {
+		m.diagram.errorMsg = i18n.T("banner.not_connected")
+		return
+	}
+	msg := shared.Message{Sender: m.cfg.Username, Content: text, Type: shared.DiagramMessageType}
+	if err := writeFrame(m.conn, msg); err != nil {
+		m.diagram.errorMsg = i18n.T("banner.send_connection_lost")
+		return
+	}
+	m.showDiagram = false
+	m.banner = i18n.T("banner.diagram_sent")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// press sends keys to the composer: single runes are typed, names such as
+// "ctrl+b" or "right" are special keys
+func press(d *diagramComposer, keys ...string) {
+	special := map[string]tea.KeyType{
+		"up": tea.KeyUp, "down": tea.KeyDown, "left": tea.KeyLeft, "right": tea.KeyRight,
+		"enter": tea.KeyEnter, "backspace": tea.KeyBackspace, "space": tea.KeySpace,
+		"ctrl+b": tea.KeyCtrlB, "ctrl+l": tea.KeyCtrlL, "ctrl+a": tea.KeyCtrlA, "ctrl+z": tea.KeyCtrlZ,
+	}
+	for _, k := range keys {
+		if t, ok := special[k]; ok {
+			d.Update(tea.KeyMsg{Type: t})
+		} else {
+			d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+}
+
+// repeat returns key n times
+func repeat(key string, n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestDiagramComposerDrawing(t *testing.T) {
+	d := newDiagramComposer(40, 10)
+
+	// A box, a label inside it and an arrow out of it
+	press(&d, "right", "right", "ctrl+b")
+	press(&d, repeat("right", 6)...)
+	press(&d, "down", "down", "ctrl+b")
+	press(&d, repeat("left", 4)...)
+	press(&d, "up", "a", "p", "i")
+	press(&d, "right", "right", "ctrl+a")
+	press(&d, repeat("right", 3)...)
+	press(&d, "down", "down", "ctrl+a")
+
+	want := "" +
+		"+-----+    \n" +
+		"| api |---+\n" +
+		"+-----+   |\n" +
+		"          v"
+	got, err := shared.NormalizeDiagram(d.String())
+	if err != nil || got != want {
+		t.Errorf("Diagram =\n%s\nwant\n%s (%v)", got, want, err)
+	}
+
+	// Undo takes back the arrow; typing over a line replaces it
+	press(&d, "ctrl+z")
+	if got, _ := shared.NormalizeDiagram(d.String()); got != "+-----+\n| api |\n+-----+" {
+		t.Errorf("After undo =\n%s", got)
+	}
+
+	// Crossing lines meet at a plus
+	d = newDiagramComposer(40, 10)
+	press(&d, "down", "ctrl+l", "right", "right", "right", "right", "ctrl+l")
+	press(&d, "up", "left", "left", "ctrl+l", "down", "down", "ctrl+l")
+	if got, _ := shared.NormalizeDiagram(d.String()); got != "  |  \n--+--\n  |  " {
+		t.Errorf("Crossing =\n%s", got)
+	}
+}
+
+func TestDiagramComposerText(t *testing.T) {
+	d := newDiagramComposer(40, 10)
+	press(&d, "right", "right", "o", "n", "e", "enter", "t", "w", "o", "backspace", "space")
+	if got := d.String(); got != "one\ntw" {
+		t.Errorf("Typed text = %q", got)
+	}
+	press(&d, "🚀")
+	if d.errorMsg == "" {
+		t.Error("Wide characters should be refused")
+	}
+
+	// Reopening a received diagram keeps its layout
+	d = newDiagramComposer(40, 10)
+	d.load("+--+\n|  |\n+--+")
+	if got := d.String(); got != "+--+\n|  |\n+--+" {
+		t.Errorf("Loaded diagram = %q", got)
+	}
+
+	// The view follows the cursor past the window
+	press(&d, repeat("right", 45)...)
+	if d.left == 0 || d.x != 45 {
+		t.Errorf("Expected the view to scroll, left=%d x=%d", d.left, d.x)
+	}
+}
+
+func TestLatestDiagram(t *testing.T) {
+	base := time.Now()
+	m := &model{messages: []shared.Message{
+		{Sender: "bob", Content: "+-+", Type: shared.DiagramMessageType, CreatedAt: base},
+		{Sender: "carol", Content: "[a]->[b]", Type: shared.DiagramMessageType, CreatedAt: base.Add(time.Second)},
+		{Sender: "bob", Content: "nice", CreatedAt: base.Add(2 * time.Second)},
+	}}
+	if d, ok := m.latestDiagram(); !ok || d.Sender != "carol" {
+		t.Errorf("Expected carol's diagram, got %+v", d)
+	}
+	setRevealedMessage(&m.messages[0])
+	defer setRevealedMessage(nil)
+	if d, ok := m.latestDiagram(); !ok || d.Sender != "bob" {
+		t.Errorf("Expected the selected diagram, got %+v", d)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// discoverTimeout is how long --discover waits for servers to answer
+const discoverTimeout = 3 * time.Second
+
+// discoverServers browses the LAN for servers started with --advertise and
+// returns them as unsaved profiles for the selection menu
+func discoverServers() []config.ConnectionProfile {
+	fmt.Println("🔍 Looking for marchat servers on the local network...")
+	servers, err := shared.Browse(context.Background(), discoverTimeout)
+	if err != nil {
+		fmt.Printf("Warning: LAN discovery failed: %v\n", err)
+		return nil
+	}
+	if len(servers) == 0 {
+		fmt.Println("No marchat servers found on the local network")
+		return nil
+	}
+	return discoveredProfiles(servers)
+}
+
+func discoveredProfiles(servers []shared.DiscoveredServer) []config.ConnectionProfile {
+	profiles := make([]config.ConnectionProfile, 0, len(servers))
+	for _, s := range servers {
+		profiles = append(profiles, config.ConnectionProfile{Name: s.Name, ServerURL: s.WebSocketURL()})
+	}
+	return profiles
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// defaultDownloadDir is where received files are saved when no download
+// directory is configured: ~/Downloads/marchat, or a downloads folder in
+// the config directory when there is no ~/Downloads
+func defaultDownloadDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if info, err := os.Stat(filepath.Join(home, "Downloads")); err == nil && info.IsDir() {
+			return filepath.Join(home, "Downloads", "marchat")
+		}
+	}
+	if dir, err := config.GetConfigDir(); err == nil {
+		return filepath.Join(dir, "downloads")
+	}
+	return "downloads"
+}
+
+// downloadDir is where this profile saves received files
+func (m *model) downloadDir() string {
+	if m.cfg.DownloadDir != "" {
+		return expandHome(m.cfg.DownloadDir)
+	}
+	return defaultDownloadDir()
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// saveReceivedFile writes a received file into dir without overwriting
+// anything: when the name is taken it saves as name[1].ext, name[2].ext and
+// so on. The sender's file name is reduced to its base so it can't escape
+// dir. It returns the path written and whether the name had to change.
+func saveReceivedFile(dir string, file *shared.FileMeta) (string, bool, error) {
+	name := filepath.Base(filepath.Clean("/" + strings.ReplaceAll(file.Filename, `\`, "/")))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "", false, fmt.Errorf("invalid file name %q", file.Filename)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", false, err
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	try := name
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, try)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			try = fmt.Sprintf("%s[%d]%s", base, i, ext)
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+		_, err = f.Write(file.Data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", false, err
+		}
+		return path, try != name, nil
+	}
+}
+
+// saveFileBanner reports where a file was saved, saying so when an
+// existing file kept its name
+func saveFileBanner(file *shared.FileMeta, path string, renamed bool) string {
+	if renamed {
+		return i18n.T("banner.file_saved_renamed", filepath.Base(file.Filename), path)
+	}
+	return i18n.T("banner.file_saved_as", path)
+}
+
+// autoSave saves a file received from someone else when it is no larger
+// than the profile's auto-save limit, returning the banner to show or ""
+func (m *model) autoSave(msg shared.Message) string {
+	limit := m.cfg.AutoSaveMaxBytes
+	if limit <= 0 || msg.File == nil || msg.Sender == m.cfg.Username || isIgnored(msg.Sender) ||
+		int64(len(msg.File.Data)) > limit || msg.CreatedAt.Before(m.connectedAt) {
+		return ""
+	}
+	path, renamed, err := saveReceivedFile(m.downloadDir(), msg.File)
+	if err != nil {
+		return i18n.T("banner.file_save_failed", err.Error())
+	}
+	if renamed {
+		return i18n.T("banner.file_auto_saved_renamed", filepath.Base(msg.File.Filename), path)
+	}
+	return i18n.T("banner.file_auto_saved", path)
+}
+
+// parseByteSize reads sizes such as 512KB, 2MB or 1500 (bytes)
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1024}, {"MB", 1024 * 1024}, {"K", 1024}, {"M", 1024 * 1024}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 512KB or 2MB)", s)
+	}
+	return n * multiplier, nil
+}
+
+// applyDownloadsCommand handles ":downloads [dir <path|default>|autosave
+// <size|off>]", returning the new directory and auto-save limit and the
+// banner to show
+func applyDownloadsCommand(text, dir string, autoSave int64) (string, int64, string, error) {
+	args := strings.Fields(strings.TrimPrefix(text, ":downloads"))
+	if len(args) == 0 {
+		where := dir
+		if where == "" {
+			where = i18n.T("downloads.default_dir", defaultDownloadDir())
+		}
+		if autoSave > 0 {
+			return dir, autoSave, i18n.T("banner.downloads_status_autosave", where, formatByteSize(autoSave)), nil
+		}
+		return dir, autoSave, i18n.T("banner.downloads_status", where), nil
+	}
+	switch {
+	case args[0] == "dir" && len(args) >= 2:
+		path := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(text, ":downloads")), "dir"))
+		if path == "default" {
+			return "", autoSave, i18n.T("banner.downloads_dir_set", defaultDownloadDir()), nil
+		}
+		abs, err := filepath.Abs(expandHome(path))
+		if err != nil {
+			return dir, autoSave, "", err
+		}
+		return abs, autoSave, i18n.T("banner.downloads_dir_set", abs), nil
+	case args[0] == "autosave" && len(args) == 2:
+		if args[1] == "off" || args[1] == "0" {
+			return dir, 0, i18n.T("banner.downloads_autosave_off"), nil
+		}
+		limit, err := parseByteSize(args[1])
+		if err != nil {
+			return dir, autoSave, "", err
+		}
+		return dir, limit, i18n.T("banner.downloads_autosave_on", formatByteSize(limit)), nil
+	}
+	return dir, autoSave, "", errors.New(i18n.T("downloads.usage"))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestSaveReceivedFile(t *testing.T) {
+	dir := t.TempDir()
+	file := &shared.FileMeta{Filename: "notes.txt", Data: []byte("one")}
+
+	path, renamed, err := saveReceivedFile(dir, file)
+	if err != nil || renamed || path != filepath.Join(dir, "notes.txt") {
+		t.Fatalf("first save = %q, %v, %v", path, renamed, err)
+	}
+	file.Data = []byte("two")
+	path, renamed, err = saveReceivedFile(dir, file)
+	if err != nil || !renamed || path != filepath.Join(dir, "notes[1].txt") {
+		t.Fatalf("second save = %q, %v, %v", path, renamed, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "notes.txt")); string(data) != "one" {
+		t.Errorf("existing file was overwritten: %q", data)
+	}
+
+	// Senders can't pick a path outside the download directory
+	for _, name := range []string{"../escape.txt", `..\..\escape.txt`, "/etc/escape.txt"} {
+		path, _, err := saveReceivedFile(dir, &shared.FileMeta{Filename: name, Data: []byte("x")})
+		if err != nil {
+			t.Fatalf("save %q failed: %v", name, err)
+		}
+		if filepath.Dir(path) != dir {
+			t.Errorf("save %q wrote to %q", name, path)
+		}
+	}
+	if _, _, err := saveReceivedFile(dir, &shared.FileMeta{Filename: "..", Data: []byte("x")}); err == nil {
+		t.Error("expected an error for a file named ..")
+	}
+
+	// The directory is created when missing
+	nested := filepath.Join(dir, "a", "b")
+	if _, _, err := saveReceivedFile(nested, file); err != nil {
+		t.Fatalf("save into new directory failed: %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"1500":   1500,
+		"512KB":  512 * 1024,
+		"512k":   512 * 1024,
+		"2MB":    2 * 1024 * 1024,
+		"2 mb":   2 * 1024 * 1024,
+		"100B":   100,
+		"0":      0,
+		"1m":     1024 * 1024,
+		" 10KB ": 10 * 1024,
+	}
+	for in, want := range tests {
+		got, err := parseByteSize(in)
+		if err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-5", "lots", "1.5MB"} {
+		if _, err := parseByteSize(in); err == nil {
+			t.Errorf("parseByteSize(%q) should fail", in)
+		}
+	}
+}
+
+func TestApplyDownloadsCommand(t *testing.T) {
+	dir, autoSave, banner, err := applyDownloadsCommand(":downloads", "", 0)
+	if err != nil || dir != "" || autoSave != 0 || !strings.Contains(banner, defaultDownloadDir()) {
+		t.Fatalf("status = %q, %d, %q, %v", dir, autoSave, banner, err)
+	}
+
+	target := filepath.Join(t.TempDir(), "my files")
+	dir, _, _, err = applyDownloadsCommand(":downloads dir "+target, "", 0)
+	if err != nil || dir != target {
+		t.Fatalf("dir = %q, %v; want %q", dir, err, target)
+	}
+	dir, _, _, err = applyDownloadsCommand(":downloads dir default", target, 0)
+	if err != nil || dir != "" {
+		t.Fatalf("dir default = %q, %v", dir, err)
+	}
+
+	_, autoSave, banner, err = applyDownloadsCommand(":downloads autosave 1MB", "", 0)
+	if err != nil || autoSave != 1024*1024 {
+		t.Fatalf("autosave = %d, %v", autoSave, err)
+	}
+	if _, _, banner, _ = applyDownloadsCommand(":downloads", "", autoSave); !strings.Contains(banner, formatByteSize(autoSave)) {
+		t.Errorf("status should mention the auto-save limit: %q", banner)
+	}
+	_, autoSave, _, err = applyDownloadsCommand(":downloads autosave off", "", autoSave)
+	if err != nil || autoSave != 0 {
+		t.Fatalf("autosave off = %d, %v", autoSave, err)
+	}
+
+	for _, bad := range []string{":downloads autosave lots", ":downloads dir", ":downloads nonsense"} {
+		if _, _, _, err := applyDownloadsCommand(bad, "/keep", 42); err == nil {
+			t.Errorf("%q should fail", bad)
+		}
+	}
+}
+
+func TestAutoSave(t *testing.T) {
+	dir := t.TempDir()
+	connected := time.Now()
+	m := &model{cfg: config.Config{Username: "me", DownloadDir: dir, AutoSaveMaxBytes: 10}, connectedAt: connected}
+	small := shared.Message{Sender: "alice", CreatedAt: connected.Add(time.Second), Type: shared.FileMessageType,
+		File: &shared.FileMeta{Filename: "hi.txt", Data: []byte("hello")}}
+
+	if banner := m.autoSave(small); banner == "" {
+		t.Fatal("small file from someone else should be saved")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "hi.txt")); err != nil {
+		t.Fatalf("file not saved: %v", err)
+	}
+
+	skipped := map[string]shared.Message{
+		"too large": {Sender: "alice", CreatedAt: small.CreatedAt, File: &shared.FileMeta{Filename: "big.txt", Data: make([]byte, 11)}},
+		"own":       {Sender: "me", CreatedAt: small.CreatedAt, File: &shared.FileMeta{Filename: "own.txt", Data: []byte("x")}},
+		"history":   {Sender: "alice", CreatedAt: connected.Add(-time.Hour), File: &shared.FileMeta{Filename: "old.txt", Data: []byte("x")}},
+	}
+	for name, msg := range skipped {
+		if banner := m.autoSave(msg); banner != "" {
+			t.Errorf("%s: file should not be auto-saved, got %q", name, banner)
+		}
+		if _, err := os.Stat(filepath.Join(dir, msg.File.Filename)); err == nil {
+			t.Errorf("%s: %s was written", name, msg.File.Filename)
+		}
+	}
+
+	m.cfg.AutoSaveMaxBytes = 0
+	small.File = &shared.FileMeta{Filename: "off.txt", Data: []byte("x")}
+	if banner := m.autoSave(small); banner != "" {
+		t.Errorf("auto-save off should not save, got %q", banner)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"path/filepath"
+	"strings"
+
+	_ "image/gif" // decoders for image.Decode
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+)
+
+// Downscaling stops rather than shrink an image's shorter side below this
+const minDownscaleSide = 64
+
+// downscaleJPEGQuality is used when re-encoding opaque images
+const downscaleJPEGQuality = 85
+
+var errCannotDownscale = errors.New("image cannot be made small enough")
+
+// downscaleImage re-encodes an image to fit in limit bytes, shrinking it
+// step by step. Opaque images become JPEGs, others stay PNGs so
+// transparency survives. It returns the new data and its extension.
+func downscaleImage(data []byte, limit int64) ([]byte, string, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+	opaque := false
+	if o, ok := src.(interface{ Opaque() bool }); ok {
+		opaque = o.Opaque()
+	}
+
+	// Re-encoding alone may be enough, so the first try keeps the size
+	scale := 1.0
+	for attempt := 0; attempt < 10; attempt++ {
+		w := int(float64(rgba.Bounds().Dx()) * scale)
+		h := int(float64(rgba.Bounds().Dy()) * scale)
+		if min(w, h) < minDownscaleSide {
+			break
+		}
+		var buf bytes.Buffer
+		ext := ".png"
+		if opaque {
+			ext = ".jpg"
+			err = jpeg.Encode(&buf, resizeBox(rgba, w, h), &jpeg.Options{Quality: downscaleJPEGQuality})
+		} else {
+			err = png.Encode(&buf, resizeBox(rgba, w, h))
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if int64(buf.Len()) <= limit {
+			return buf.Bytes(), ext, nil
+		}
+		// Encoded size roughly follows the pixel count
+		scale *= min(0.9, math.Sqrt(float64(limit)/float64(buf.Len()))*0.95)
+	}
+	return nil, "", errCannotDownscale
+}
+
+// resizeBox shrinks src to w x h by averaging the source pixels each
+// destination pixel covers
+func resizeBox(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if w == sw && h == sh {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			o := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[o+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// isImageFileName reports whether a file name has an extension
+// downscaleImage can decode
+func isImageFileName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// downscaledName marks a file name as a smaller copy with a new extension
+func downscaledName(name, ext string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "-small" + ext
+}
+
+// offerDownscaled offers to send a smaller copy of an image that is over
+// the size limit, returning false when data isn't an image that can be
+// shrunk to fit
+func (m *model) offerDownscaled(name string, data []byte, limit int64) bool {
+	if !isImageData(data) {
+		return false
+	}
+	small, ext, err := downscaleImage(data, limit)
+	if err != nil {
+		return false
+	}
+	m.pendingImage = &clipboardImage{data: small, filename: downscaledName(name, ext)}
+	m.banner = i18n.T("banner.image_downscale_prompt", name, formatByteSize(int64(len(data))), formatByteSize(limit), formatByteSize(int64(len(small))))
+	return true
+}
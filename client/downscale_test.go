@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// noisyPNG builds a PNG that compresses badly, so it is large for its size
+func noisyPNG(t *testing.T, w, h int, alpha bool) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := uint8(255)
+			if alpha && x < w/2 {
+				a = 128
+			}
+			img.SetNRGBA(x, y, color.NRGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), a})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownscaleImage(t *testing.T) {
+	data := noisyPNG(t, 600, 400, false)
+	limit := int64(len(data) / 4)
+	small, ext, err := downscaleImage(data, limit)
+	if err != nil {
+		t.Fatalf("downscaleImage failed: %v", err)
+	}
+	if ext != ".jpg" || int64(len(small)) > limit {
+		t.Errorf("Expected a JPEG under %d bytes, got %s of %d", limit, ext, len(small))
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(small))
+	// Shrunk, keeping the 3:2 aspect ratio give or take rounding
+	if err != nil || format != "jpeg" || cfg.Width > 600 || max(cfg.Width*2-cfg.Height*3, cfg.Height*3-cfg.Width*2) > 5 {
+		t.Errorf("Unexpected result %s %dx%d (%v)", format, cfg.Width, cfg.Height, err)
+	}
+
+	// Transparency is kept by staying a PNG
+	data = noisyPNG(t, 300, 300, true)
+	small, ext, err = downscaleImage(data, int64(len(data)/3))
+	if err != nil || ext != ".png" {
+		t.Fatalf("Expected a PNG, got %q (%v)", ext, err)
+	}
+	if img, err := png.Decode(bytes.NewReader(small)); err != nil {
+		t.Error(err)
+	} else if _, _, _, a := img.At(0, 0).RGBA(); a == 0xffff {
+		t.Error("Expected the transparent half to stay transparent")
+	}
+
+	if _, _, err := downscaleImage(data, 100); err != errCannotDownscale {
+		t.Errorf("Expected an impossible limit to fail, got %v", err)
+	}
+	if _, _, err := downscaleImage([]byte("not an image"), 100); err == nil {
+		t.Error("Expected non-images to fail")
+	}
+}
+
+func TestResizeBox(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		v := uint8(0)
+		if x%2 == 1 {
+			v = 200
+		}
+		for y := 0; y < 2; y++ {
+			src.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	dst := resizeBox(src, 2, 1)
+	if got := dst.RGBAAt(0, 0); got != (color.RGBA{100, 100, 100, 255}) {
+		t.Errorf("Expected pixels to be averaged, got %v", got)
+	}
+}
+
+func TestOfferDownscaled(t *testing.T) {
+	data := noisyPNG(t, 400, 300, false)
+	m := &model{}
+	if !m.offerDownscaled("holiday.png", data, int64(len(data)/4)) {
+		t.Fatal("Expected a downscaled copy to be offered")
+	}
+	if m.pendingImage == nil || m.pendingImage.filename != "holiday-small.jpg" {
+		t.Errorf("Unexpected pending image %+v", m.pendingImage)
+	}
+	if !strings.Contains(m.banner, "holiday.png") {
+		t.Errorf("Expected the banner to name the file, got %q", m.banner)
+	}
+
+	m = &model{}
+	if m.offerDownscaled("notes.txt", []byte("plain text"), 4) || m.pendingImage != nil {
+		t.Error("Only images should be offered downscaled")
+	}
+	if !isImageFileName("A.JPEG") || isImageFileName("a.webp") {
+		t.Error("Unexpected isImageFileName results")
+	}
+}
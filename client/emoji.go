@@ -0,0 +1,444 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/charmbracelet/lipgloss"
+)
+
+//go:embed emoji/emoji.txt
+var emojiData string
+
+const (
+	maxRecentEmoji     = 24
+	maxEmojiImageBytes = 64 * 1024
+	emojiPickerRows    = 10
+	emojiPickerResults = 200
+)
+
+// emojiEntry is one emoji from the bundled Unicode set, or a custom emoji
+// registered on the server
+type emojiEntry struct {
+	Char      string
+	Name      string
+	Group     string
+	Shortcode string
+	Custom    bool
+}
+
+// insertText is what the picker puts in the composer. Custom emoji are
+// inserted as shortcodes so every client renders them its own way.
+func (e emojiEntry) insertText() string {
+	if e.Custom {
+		return ":" + e.Shortcode + ":"
+	}
+	return e.Char
+}
+
+// Common shortcodes from other chat apps that don't match a CLDR name
+var emojiAliases = map[string]string{
+	"+1":         "👍",
+	"-1":         "👎",
+	"thumbsup":   "👍",
+	"thumbsdown": "👎",
+	"heart":      "❤️",
+	"smile":      "😄",
+	"joy":        "😂",
+	"tada":       "🎉",
+	"shrug":      "🤷",
+	"100":        "💯",
+	"ok":         "👌",
+	"wave":       "👋",
+}
+
+// Emoticons converted when messages are rendered
+var emoticons = map[string]string{
+	":)": "😊",
+	":(": "🙁",
+	":D": "😃",
+	"<3": "❤️",
+	":P": "😛",
+}
+
+// Custom emoji synced from the server, keyed by shortcode
+var (
+	customEmojiMu sync.RWMutex
+	customEmoji   = map[string]shared.CustomEmoji{}
+)
+
+var (
+	emojiOnce        sync.Once
+	emojiList        []emojiEntry
+	emojiByShortcode map[string]string
+	shortcodeRegex   = regexp.MustCompile(`:([a-z0-9_+-]+):`)
+	nonAlnumRegex    = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// loadEmoji parses the bundled emoji list on first use
+func loadEmoji() {
+	emojiOnce.Do(func() {
+		emojiByShortcode = make(map[string]string)
+		group := ""
+		for _, line := range strings.Split(emojiData, "\n") {
+			switch {
+			case line == "" || strings.HasPrefix(line, "#"):
+				continue
+			case strings.HasPrefix(line, "@"):
+				group = line[1:]
+				continue
+			}
+			char, name, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			e := emojiEntry{Char: char, Name: name, Group: group, Shortcode: emojiShortcode(name)}
+			emojiList = append(emojiList, e)
+			if _, exists := emojiByShortcode[e.Shortcode]; !exists {
+				emojiByShortcode[e.Shortcode] = char
+			}
+		}
+		for alias, char := range emojiAliases {
+			emojiByShortcode[alias] = char
+		}
+	})
+}
+
+// emojiShortcode turns a CLDR name into a shortcode, e.g. "flag: Japan" -> "flag_japan"
+func emojiShortcode(name string) string {
+	return strings.Trim(nonAlnumRegex.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// setCustomEmoji replaces the server's custom emoji registry
+func setCustomEmoji(emoji []shared.CustomEmoji) {
+	registry := make(map[string]shared.CustomEmoji, len(emoji))
+	for _, e := range emoji {
+		registry[strings.ToLower(e.Shortcode)] = e
+	}
+	customEmojiMu.Lock()
+	customEmoji = registry
+	customEmojiMu.Unlock()
+	// Messages laid out with the old set may show shortcodes that now resolve
+	resetRenderCache()
+}
+
+// customEmojiEntries lists the server's custom emoji for the picker
+func customEmojiEntries() []emojiEntry {
+	customEmojiMu.RLock()
+	defer customEmojiMu.RUnlock()
+	entries := make([]emojiEntry, 0, len(customEmoji))
+	for code, e := range customEmoji {
+		char := e.Glyph
+		if char == "" {
+			char = "🖼"
+		}
+		entries = append(entries, emojiEntry{Char: char, Name: strings.ReplaceAll(code, "_", " "), Group: "Server", Shortcode: code, Custom: true})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Shortcode < entries[j].Shortcode })
+	return entries
+}
+
+// lookupShortcode returns the emoji for a shortcode without colons. Server
+// shortcodes take precedence; an image is used when the terminal can show it.
+func lookupShortcode(code string) (string, bool) {
+	code = strings.ToLower(code)
+	customEmojiMu.RLock()
+	custom, ok := customEmoji[code]
+	customEmojiMu.RUnlock()
+	if ok {
+		if len(custom.Image) > 0 && inlineImagesSupported() {
+			return kittyInlineImage(custom.Image), true
+		}
+		if custom.Glyph != "" {
+			return custom.Glyph, true
+		}
+		return "", false
+	}
+	loadEmoji()
+	char, ok := emojiByShortcode[code]
+	return char, ok
+}
+
+// inlineImagesSupported reports a terminal that speaks the kitty graphics
+// protocol (kitty, WezTerm, Ghostty). MARCHAT_INLINE_IMAGES=false turns
+// images off.
+func inlineImagesSupported() bool {
+	if strings.EqualFold(os.Getenv("MARCHAT_INLINE_IMAGES"), "false") {
+		return false
+	}
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", strings.Contains(os.Getenv("TERM"), "kitty"):
+		return true
+	case os.Getenv("TERM_PROGRAM") == "WezTerm", os.Getenv("TERM_PROGRAM") == "ghostty":
+		return true
+	}
+	return false
+}
+
+// kittyInlineImage draws a PNG over two cells without moving the cursor; the
+// two trailing spaces reserve the cells so the layout width stays right
+func kittyInlineImage(png []byte) string {
+	const chunkSize = 4096
+	data := base64.StdEncoding.EncodeToString(png)
+	var b strings.Builder
+	for first := true; first || data != ""; first = false {
+		chunk := data
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		data = data[len(chunk):]
+		more := 0
+		if data != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&b, "\x1b_Gf=100,a=T,c=2,r=1,C=1,q=2,m=%d;%s\x1b\\", more, chunk)
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.String() + "  "
+}
+
+// emojiCommandFile reads the PNG named at the end of ":emoji add" so it can
+// be uploaded with the command. Other text is returned unchanged.
+func emojiCommandFile(text string) (string, *shared.FileMeta, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 || fields[0] != ":emoji" || fields[1] != "add" || !strings.EqualFold(filepath.Ext(fields[len(fields)-1]), ".png") {
+		return text, nil, nil
+	}
+	path := fields[len(fields)-1]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return text, nil, err
+	}
+	if len(data) > maxEmojiImageBytes {
+		return text, nil, fmt.Errorf("%s is too large (max %d KB)", filepath.Base(path), maxEmojiImageBytes/1024)
+	}
+	command := strings.Join(fields[:len(fields)-1], " ")
+	return command, &shared.FileMeta{Filename: filepath.Base(path), Size: int64(len(data)), Data: data}, nil
+}
+
+// fuzzyScore rates how well query matches target: substring matches beat
+// scattered subsequences, and matches at a word start rank highest. A
+// negative score means no match.
+func fuzzyScore(query, target string) int {
+	if query == "" {
+		return 0
+	}
+	if idx := strings.Index(target, query); idx >= 0 {
+		score := 1000 - idx - (len(target) - len(query))
+		if idx == 0 || target[idx-1] == ' ' || target[idx-1] == '_' {
+			score += 500
+		}
+		return score
+	}
+	runes := []rune(target)
+	score, gaps, ti := 500, 0, 0
+	for _, qc := range query {
+		found := false
+		for ti < len(runes) {
+			tc := runes[ti]
+			ti++
+			if tc == qc {
+				found = true
+				break
+			}
+			gaps++
+		}
+		if !found {
+			return -1
+		}
+	}
+	return score - gaps*5 - len(runes)
+}
+
+// searchEmoji returns emoji matching query, best first. An empty query lists
+// recently used emoji followed by the full set in Unicode order.
+func searchEmoji(query string, recent []string, limit int) []emojiEntry {
+	loadEmoji()
+	query = strings.ToLower(strings.TrimSpace(query))
+	var results []emojiEntry
+	custom := customEmojiEntries()
+	if query == "" {
+		seen := make(map[string]bool)
+		for _, char := range recent {
+			if e, ok := emojiForChar(char, custom); ok && !seen[char] {
+				e.Group = "Recently used"
+				results = append(results, e)
+				seen[char] = true
+			}
+		}
+		for _, e := range custom {
+			if !seen[e.insertText()] {
+				results = append(results, e)
+			}
+		}
+		for _, e := range emojiList {
+			if len(results) >= limit {
+				break
+			}
+			if !seen[e.Char] {
+				results = append(results, e)
+			}
+		}
+		return results
+	}
+
+	type scored struct {
+		entry emojiEntry
+		score int
+	}
+	var matches []scored
+	for _, e := range append(custom, emojiList...) {
+		best := fuzzyScore(query, strings.ToLower(e.Name))
+		if s := fuzzyScore(strings.ReplaceAll(query, " ", "_"), e.Shortcode); s > best {
+			best = s
+		}
+		if best >= 0 {
+			matches = append(matches, scored{e, best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	for _, m := range matches {
+		if len(results) >= limit {
+			break
+		}
+		results = append(results, m.entry)
+	}
+	return results
+}
+
+// emojiForChar finds the entry for an emoji character, or for a custom
+// emoji's :shortcode:
+func emojiForChar(char string, custom []emojiEntry) (emojiEntry, bool) {
+	for _, e := range custom {
+		if e.insertText() == char {
+			return e, true
+		}
+	}
+	loadEmoji()
+	for _, e := range emojiList {
+		if e.Char == char {
+			return e, true
+		}
+	}
+	return emojiEntry{}, false
+}
+
+// addRecentEmoji moves char to the front of the recently used list
+func addRecentEmoji(recent []string, char string) []string {
+	updated := []string{char}
+	for _, r := range recent {
+		if r != char && len(updated) < maxRecentEmoji {
+			updated = append(updated, r)
+		}
+	}
+	return updated
+}
+
+// renderEmojis replaces :shortcodes: and a few classic emoticons with emoji
+func renderEmojis(s string) string {
+	if strings.Count(s, ":") >= 2 {
+		s = shortcodeRegex.ReplaceAllStringFunc(s, func(match string) string {
+			if char, ok := lookupShortcode(match[1 : len(match)-1]); ok {
+				return char
+			}
+			return match
+		})
+	}
+	for k, v := range emoticons {
+		s = strings.ReplaceAll(s, k, v)
+	}
+	return s
+}
+
+// emojiPicker is the Alt+E overlay state: a search query and the matches
+type emojiPicker struct {
+	query   string
+	results []emojiEntry
+	cursor  int
+}
+
+// newEmojiPicker opens the picker showing recently used emoji first
+func newEmojiPicker(recent []string) emojiPicker {
+	p := emojiPicker{}
+	p.setQuery("", recent)
+	return p
+}
+
+// setQuery re-runs the search and resets the selection
+func (p *emojiPicker) setQuery(query string, recent []string) {
+	p.query = query
+	p.results = searchEmoji(query, recent, emojiPickerResults)
+	p.cursor = 0
+}
+
+// move shifts the selection, clamped to the results
+func (p *emojiPicker) move(delta int) {
+	p.cursor += delta
+	if p.cursor >= len(p.results) {
+		p.cursor = len(p.results) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// selected returns the highlighted emoji
+func (p emojiPicker) selected() (emojiEntry, bool) {
+	if p.cursor < 0 || p.cursor >= len(p.results) {
+		return emojiEntry{}, false
+	}
+	return p.results[p.cursor], true
+}
+
+// View renders the search box and a scrolling window of matches
+func (p emojiPicker) View(styles themeStyles) string {
+	var b strings.Builder
+	b.WriteString(styles.User.Render("Emoji") + "  " + styles.Msg.Render("Search: "+p.query+"▏") + "\n\n")
+	if len(p.results) == 0 {
+		b.WriteString(styles.Time.Render("No matching emoji") + "\n")
+	}
+	start := 0
+	if p.cursor >= emojiPickerRows {
+		start = p.cursor - emojiPickerRows + 1
+	}
+	end := start + emojiPickerRows
+	if end > len(p.results) {
+		end = len(p.results)
+	}
+	for i := start; i < end; i++ {
+		e := p.results[i]
+		line := fmt.Sprintf("%s  %-32s %s", e.Char, truncateRunes(e.Name, 32), styles.Time.Render(":"+e.Shortcode+":"))
+		if i == p.cursor {
+			line = lipgloss.NewStyle().Bold(true).Render("▶ " + line)
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	footer := "type to search • ↑/↓ select • enter insert • esc close"
+	if e, ok := p.selected(); ok && p.query == "" {
+		footer = e.Group + " • " + footer
+	}
+	b.WriteString("\n" + styles.Time.Render(footer))
+	return b.String()
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with …
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestRenderEmojiShortcodes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"ship it :rocket:", "ship it 🚀"},
+		{":thumbs_up: :+1:", "👍 👍"},
+		{"made in :flag_japan:", "made in 🇯🇵"},
+		{":not_an_emoji: stays", ":not_an_emoji: stays"},
+		{"10:30:45", "10:30:45"},
+		{"nice :)", "nice 😊"},
+	}
+	for _, tt := range tests {
+		if got := renderEmojis(tt.in); got != tt.want {
+			t.Errorf("renderEmojis(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchEmoji(t *testing.T) {
+	if results := searchEmoji("", nil, 5000); len(results) < 1800 {
+		t.Errorf("Expected the full emoji set, got %d entries", len(results))
+	}
+
+	results := searchEmoji("rocket", nil, 5)
+	if len(results) == 0 || results[0].Char != "🚀" {
+		t.Errorf("Expected rocket first, got %+v", results)
+	}
+	// Fuzzy: letters in order but not contiguous
+	results = searchEmoji("thmbup", nil, 5)
+	if len(results) == 0 || results[0].Char != "👍" {
+		t.Errorf("Expected thumbs up for a fuzzy query, got %+v", results)
+	}
+	// Word-start matches outrank matches inside a word
+	results = searchEmoji("cat", nil, 3)
+	if len(results) == 0 || !strings.HasPrefix(results[0].Name, "cat") {
+		t.Errorf("Expected a name starting with cat first, got %+v", results)
+	}
+	if results := searchEmoji("zzzzqqq", nil, 5); len(results) != 0 {
+		t.Errorf("Expected no matches, got %+v", results)
+	}
+}
+
+func TestRecentEmoji(t *testing.T) {
+	recent := addRecentEmoji(nil, "🚀")
+	recent = addRecentEmoji(recent, "👍")
+	recent = addRecentEmoji(recent, "🚀")
+	if len(recent) != 2 || recent[0] != "🚀" || recent[1] != "👍" {
+		t.Errorf("Unexpected recent list %v", recent)
+	}
+	for i := 0; i < maxRecentEmoji+5; i++ {
+		recent = addRecentEmoji(recent, searchEmoji("", nil, 100)[i].Char)
+	}
+	if len(recent) != maxRecentEmoji {
+		t.Errorf("Recent list should be capped at %d, got %d", maxRecentEmoji, len(recent))
+	}
+
+	results := searchEmoji("", []string{"👍", "not-an-emoji"}, 10)
+	if results[0].Char != "👍" || results[0].Group != "Recently used" {
+		t.Errorf("Recently used emoji should come first, got %+v", results[0])
+	}
+	for _, r := range results[1:] {
+		if r.Char == "👍" {
+			t.Error("Recently used emoji should not be listed twice")
+		}
+	}
+}
+
+func TestEmojiPicker(t *testing.T) {
+	p := newEmojiPicker(nil)
+	p.setQuery("party", nil)
+	if e, ok := p.selected(); !ok || !strings.Contains(e.Name, "party") {
+		t.Errorf("Expected a party emoji selected, got %+v", e)
+	}
+	p.move(-5)
+	if p.cursor != 0 {
+		t.Errorf("Cursor should clamp at 0, got %d", p.cursor)
+	}
+	p.move(1000)
+	if p.cursor != len(p.results)-1 {
+		t.Errorf("Cursor should clamp at the last result, got %d", p.cursor)
+	}
+	view := p.View(baseThemeStyles())
+	if !strings.Contains(view, "Search: party") {
+		t.Errorf("View should show the query:\n%s", view)
+	}
+}
+
+func TestCustomEmoji(t *testing.T) {
+	t.Cleanup(func() { setCustomEmoji(nil) })
+	t.Setenv("KITTY_WINDOW_ID", "")
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("TERM_PROGRAM", "")
+	png := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("x", 5000))
+	setCustomEmoji([]shared.CustomEmoji{
+		{Shortcode: "shipit", Glyph: "🐿️"},
+		{Shortcode: "parrot", Image: png},
+		{Shortcode: "rocket", Glyph: "🛰️"},
+	})
+
+	if got := renderEmojis(":shipit: :parrot: :rocket:"); got != "🐿️ :parrot: 🛰️" {
+		t.Errorf("Without graphics support got %q", got)
+	}
+
+	t.Setenv("KITTY_WINDOW_ID", "1")
+	got := renderEmojis(":parrot:")
+	if !strings.HasPrefix(got, "\x1b_Gf=100,a=T") || !strings.Contains(got, "m=1;") || !strings.HasSuffix(got, "\x1b\\  ") {
+		t.Errorf("Expected a chunked kitty image, got %q", got)
+	}
+	t.Setenv("MARCHAT_INLINE_IMAGES", "false")
+	if got := renderEmojis(":parrot:"); got != ":parrot:" {
+		t.Errorf("MARCHAT_INLINE_IMAGES=false should disable images, got %q", got)
+	}
+
+	results := searchEmoji("shipit", nil, 3)
+	if len(results) == 0 || !results[0].Custom || results[0].insertText() != ":shipit:" {
+		t.Errorf("Expected the custom emoji first, got %+v", results)
+	}
+	results = searchEmoji("", []string{":shipit:"}, 5)
+	if results[0].Shortcode != "shipit" || results[0].Group != "Recently used" || results[1].Shortcode != "parrot" {
+		t.Errorf("Expected recent then server emoji first, got %+v", results[:2])
+	}
+}
+
+func TestEmojiCommandFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shipit.png")
+	if err := os.WriteFile(path, []byte("\x89PNG\r\n\x1a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	command, file, err := emojiCommandFile(":emoji add shipit 🐿️ " + path)
+	if err != nil || command != ":emoji add shipit 🐿️" || file == nil || file.Filename != "shipit.png" {
+		t.Errorf("emojiCommandFile = %q, %+v, %v", command, file, err)
+	}
+	if command, file, err := emojiCommandFile(":emoji add shipit 🐿️"); command != ":emoji add shipit 🐿️" || file != nil || err != nil {
+		t.Errorf("Commands without an image should pass through, got %q %+v %v", command, file, err)
+	}
+	if _, _, err := emojiCommandFile(":emoji add shipit missing.png"); err == nil {
+		t.Error("Expected an error for a missing image")
+	}
+}
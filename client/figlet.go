@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed fonts/*.flf
+var bundledFonts embed.FS
+
+const (
+	defaultFigletFont = "banner"
+	maxArtWidth       = 80
+	maxArtInput       = 60
+	cowsayBubbleWidth = 40
+)
+
+// figletFont is a parsed FIGlet (.flf) font. Glyphs are laid out at full
+// width; kerning and smushing rules in the header are ignored.
+type figletFont struct {
+	height    int
+	hardblank rune
+	glyphs    map[rune][]string
+}
+
+// parseFigletFont reads a FIGlet font: the flf2a header, comment lines, then
+// the required ASCII and Deutsch characters, then optional code-tagged ones
+func parseFigletFont(r io.Reader) (*figletFont, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty font file")
+	}
+	header := strings.Fields(scanner.Text())
+	if len(header) < 6 || !strings.HasPrefix(header[0], "flf2a") || len(header[0]) < 6 {
+		return nil, fmt.Errorf("not a FIGlet font")
+	}
+	hardblank, _ := utf8.DecodeRuneInString(header[0][5:])
+	height, err := strconv.Atoi(header[1])
+	if err != nil || height < 1 {
+		return nil, fmt.Errorf("invalid font height %q", header[1])
+	}
+	comments, err := strconv.Atoi(header[5])
+	if err != nil || comments < 0 {
+		return nil, fmt.Errorf("invalid comment count %q", header[5])
+	}
+	for i := 0; i < comments; i++ {
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("font ends inside its header comments")
+		}
+	}
+
+	font := &figletFont{height: height, hardblank: hardblank, glyphs: make(map[rune][]string)}
+	readGlyph := func() ([]string, bool) {
+		rows := make([]string, 0, height)
+		for len(rows) < height && scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), " \r")
+			// Strip the endmark, which appears once or twice at the end of each row
+			if end, size := utf8.DecodeLastRuneInString(line); size > 0 {
+				line = strings.TrimSuffix(line[:len(line)-size], string(end))
+			}
+			rows = append(rows, line)
+		}
+		return rows, len(rows) == height
+	}
+
+	required := make([]rune, 0, 102)
+	for c := rune(32); c < 127; c++ {
+		required = append(required, c)
+	}
+	required = append(required, 'Ä', 'Ö', 'Ü', 'ä', 'ö', 'ü', 'ß')
+	for _, c := range required {
+		rows, ok := readGlyph()
+		if !ok {
+			if c < 127 {
+				return nil, fmt.Errorf("font ends before character %q", c)
+			}
+			return font, nil
+		}
+		font.glyphs[c] = rows
+	}
+	// Code-tagged characters: a line with the code, then the glyph
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		code, err := strconv.ParseInt(fields[0], 0, 32)
+		rows, ok := readGlyph()
+		if !ok {
+			break
+		}
+		if err == nil && code >= 0 {
+			font.glyphs[rune(code)] = rows
+		}
+	}
+	return font, scanner.Err()
+}
+
+// render draws text in the font, one figlet row per line of text
+func (f *figletFont) render(text string) string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		rows := make([]strings.Builder, f.height)
+		for _, c := range line {
+			glyph, ok := f.glyphs[c]
+			if !ok {
+				glyph = f.glyphs['?']
+			}
+			for i := range rows {
+				if i < len(glyph) {
+					rows[i].WriteString(strings.ReplaceAll(glyph[i], string(f.hardblank), " "))
+				}
+			}
+		}
+		for i := range rows {
+			out = append(out, strings.TrimRight(rows[i].String(), " "))
+		}
+	}
+	// Drop trailing blank rows left by the font's descender space
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+// width is the rendered width of text on a single line
+func (f *figletFont) width(text string) int {
+	widest := 0
+	for _, row := range strings.Split(f.render(text), "\n") {
+		if w := utf8.RuneCountInString(row); w > widest {
+			widest = w
+		}
+	}
+	return widest
+}
+
+// figletFonts lists the bundled fonts plus any .flf files in the user's
+// fonts directory
+func figletFonts(configDir string) []string {
+	seen := make(map[string]bool)
+	if entries, err := bundledFonts.ReadDir("fonts"); err == nil {
+		for _, e := range entries {
+			seen[strings.TrimSuffix(e.Name(), ".flf")] = true
+		}
+	}
+	if configDir != "" {
+		matches, _ := filepath.Glob(filepath.Join(configDir, "fonts", "*.flf"))
+		for _, m := range matches {
+			seen[strings.TrimSuffix(filepath.Base(m), ".flf")] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadFigletFont finds a font by name, preferring the user's fonts directory
+// so a bundled font can be overridden
+func loadFigletFont(name, configDir string) (*figletFont, error) {
+	if name != filepath.Base(name) || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid font name %q", name)
+	}
+	if configDir != "" {
+		if f, err := os.Open(filepath.Join(configDir, "fonts", name+".flf")); err == nil {
+			defer f.Close()
+			return parseFigletFont(f)
+		}
+	}
+	f, err := bundledFonts.Open("fonts/" + name + ".flf")
+	if err != nil {
+		return nil, fmt.Errorf("unknown font %q (available: %s)", name, strings.Join(figletFonts(configDir), ", "))
+	}
+	defer f.Close()
+	return parseFigletFont(f)
+}
+
+// figletText renders text as banner letters, breaking between words so each
+// row fits within maxArtWidth
+func figletText(font *figletFont, text string) (string, error) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return "", fmt.Errorf("nothing to render")
+	}
+	if utf8.RuneCountInString(strings.Join(words, " ")) > maxArtInput {
+		return "", fmt.Errorf("text too long (max %d characters)", maxArtInput)
+	}
+	var lines []string
+	current := ""
+	for _, word := range words {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && font.width(candidate) > maxArtWidth {
+			lines = append(lines, current)
+			candidate = word
+		}
+		if font.width(candidate) > maxArtWidth {
+			return "", fmt.Errorf("%q is too wide to render", word)
+		}
+		current = candidate
+	}
+	lines = append(lines, current)
+	return font.render(strings.Join(lines, "\n")), nil
+}
+
+// cowsay draws the classic cow with a speech bubble, or a thought bubble
+// when think is set
+func cowsay(text string, think bool) (string, error) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return "", fmt.Errorf("nothing to say")
+	}
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for utf8.RuneCountInString(word) > cowsayBubbleWidth {
+			runes := []rune(word)
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, string(runes[:cowsayBubbleWidth]))
+			word = string(runes[cowsayBubbleWidth:])
+		}
+		if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > cowsayBubbleWidth {
+			lines = append(lines, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	width := 0
+	for _, l := range lines {
+		if w := utf8.RuneCountInString(l); w > width {
+			width = w
+		}
+	}
+	var b strings.Builder
+	b.WriteString(" " + strings.Repeat("_", width+2) + "\n")
+	for i, l := range lines {
+		left, right := "|", "|"
+		switch {
+		case think:
+			left, right = "(", ")"
+		case len(lines) == 1:
+			left, right = "<", ">"
+		case i == 0:
+			left, right = "/", "\\"
+		case i == len(lines)-1:
+			left, right = "\\", "/"
+		}
+		b.WriteString(left + " " + l + strings.Repeat(" ", width-utf8.RuneCountInString(l)) + " " + right + "\n")
+	}
+	b.WriteString(" " + strings.Repeat("-", width+2) + "\n")
+
+	trail := "\\"
+	if think {
+		trail = "o"
+	}
+	b.WriteString("        " + trail + "   ^__^\n")
+	b.WriteString("         " + trail + "  (oo)\\_______\n")
+	b.WriteString("            (__)\\       )\\/\\\n")
+	b.WriteString("                ||----w |\n")
+	b.WriteString("                ||     ||")
+	return b.String(), nil
+}
+
+// artCommand renders :figlet, :cowsay and :cowthink. The second return value
+// is false when text is not an art command.
+func artCommand(text, configDir string) (string, bool, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", false, nil
+	}
+	args := strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+	switch fields[0] {
+	case ":cowsay", ":cowthink":
+		art, err := cowsay(args, fields[0] == ":cowthink")
+		return art, true, err
+	case ":figlet":
+		fontName := defaultFigletFont
+		if len(fields) > 1 && fields[1] == "-f" {
+			if len(fields) < 3 {
+				return "", true, fmt.Errorf("missing font name after -f")
+			}
+			fontName = fields[2]
+			args = strings.TrimSpace(strings.TrimPrefix(args, "-f"))
+			args = strings.TrimSpace(strings.TrimPrefix(args, fontName))
+		}
+		font, err := loadFigletFont(fontName, configDir)
+		if err != nil {
+			return "", true, err
+		}
+		art, err := figletText(font, args)
+		return art, true, err
+	}
+	return "", false, nil
+}
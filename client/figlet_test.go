@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFigletFont(t *testing.T) {
+	// A two-row font where only "A" has a visible glyph; rows end with @ or @@
+	var src strings.Builder
+	src.WriteString("flf2a$ 2 2 4 -1 1\ntest font\n")
+	for c := 32; c < 127; c++ {
+		switch c {
+		case 'A':
+			src.WriteString("/\\@\n/\\\\@@\n")
+		case '?':
+			src.WriteString("?$@\n $@@\n")
+		default:
+			src.WriteString("$@\n$@@\n")
+		}
+	}
+	font, err := parseFigletFont(strings.NewReader(src.String()))
+	if err != nil {
+		t.Fatalf("parseFigletFont failed: %v", err)
+	}
+	if got := font.render("AA"); got != "/\\/\\\n/\\\\/\\\\" {
+		t.Errorf("render(AA) = %q", got)
+	}
+	if got := font.render("A\u00e9"); got != "/\\?\n/\\\\" {
+		t.Errorf("Missing glyphs should fall back to '?', got %q", got)
+	}
+
+	if _, err := parseFigletFont(strings.NewReader("not a font\n")); err == nil {
+		t.Error("Expected an error for a file without the flf2a header")
+	}
+	if _, err := parseFigletFont(strings.NewReader("flf2a$ 2 2 4 -1 0\n$@\n$@@\n")); err == nil {
+		t.Error("Expected an error for a truncated font")
+	}
+}
+
+func TestBundledFigletFonts(t *testing.T) {
+	for _, name := range figletFonts("") {
+		font, err := loadFigletFont(name, "")
+		if err != nil {
+			t.Fatalf("Bundled font %s failed to load: %v", name, err)
+		}
+		art := font.render("Hi!")
+		if rows := strings.Split(art, "\n"); len(rows) != 5 {
+			t.Errorf("Font %s: expected 5 rows, got %d:\n%s", name, len(rows), art)
+		}
+		if font.render("hi") != font.render("HI") {
+			t.Errorf("Font %s: lowercase should render as capitals", name)
+		}
+	}
+	if _, err := loadFigletFont("../banner", ""); err == nil {
+		t.Error("Font names with path separators should be rejected")
+	}
+	if _, err := loadFigletFont("nope", ""); err == nil || !strings.Contains(err.Error(), "banner") {
+		t.Errorf("Unknown font error should list available fonts, got %v", err)
+	}
+}
+
+func TestUserFigletFont(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "fonts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var src strings.Builder
+	src.WriteString("flf2a$ 1 1 2 -1 0\n")
+	for c := 32; c < 127; c++ {
+		src.WriteString(string(rune(c)) + "@@\n")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fonts", "plain.flf"), []byte(src.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	art, ok, err := artCommand(":figlet -f plain hello there", dir)
+	if !ok || err != nil || art != "hello there" {
+		t.Errorf("artCommand with user font = %q, %v, %v", art, ok, err)
+	}
+}
+
+func TestFigletTextWraps(t *testing.T) {
+	font, err := loadFigletFont(defaultFigletFont, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	art, err := figletText(font, "hello wonderful world")
+	if err != nil {
+		t.Fatalf("figletText failed: %v", err)
+	}
+	for _, row := range strings.Split(art, "\n") {
+		if len([]rune(row)) > maxArtWidth {
+			t.Errorf("Row wider than %d columns: %q", maxArtWidth, row)
+		}
+	}
+	if rows := strings.Count(art, "\n") + 1; rows <= 5 {
+		t.Errorf("Expected the text to wrap onto several banner lines, got %d rows", rows)
+	}
+	if _, err := figletText(font, strings.Repeat("x", maxArtInput+1)); err == nil {
+		t.Error("Expected an error for overly long text")
+	}
+}
+
+func TestCowsay(t *testing.T) {
+	art, err := cowsay("moo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := " _____\n< moo >\n -----\n        \\   ^__^"
+	if !strings.HasPrefix(art, want) {
+		t.Errorf("Unexpected cowsay output:\n%s", art)
+	}
+
+	long := strings.Repeat("grass ", 12)
+	art, _ = cowsay(long, true)
+	lines := strings.Split(art, "\n")
+	if !strings.HasPrefix(lines[1], "( ") || !strings.HasPrefix(lines[2], "( ") || !strings.Contains(art, "o   ^__^") {
+		t.Errorf("Expected a multi-line thought bubble:\n%s", art)
+	}
+	if _, ok, err := artCommand(":cowsay", ""); !ok || err == nil {
+		t.Error("Expected an error for :cowsay without text")
+	}
+	if _, ok, _ := artCommand(":cowsaying hi", ""); ok {
+		t.Error("Only exact command names should be treated as art commands")
+	}
+}
@@ -198,7 +198,8 @@ func (m filePickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 									maxBytes = v * 1024 * 1024
 								}
 							}
-							if info.Size() > maxBytes {
+							// Images over the limit are offered downscaled once picked
+							if info.Size() > maxBytes && !isImageFileName(selectedItem.path) {
 								// Build friendly limit message
 								limitMsg := fmt.Sprintf("%d bytes", maxBytes)
 								if maxBytes%(1024*1024) == 0 {
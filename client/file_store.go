@@ -0,0 +1,203 @@
+package main
+
+import (
+	"container/list"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Limits for received files kept for :savefile and :play, unless
+// config.json sets file_cache_bytes or file_spill_bytes
+const (
+	defaultFileCacheBytes = 64 * 1024 * 1024
+	defaultFileSpillBytes = 8 * 1024 * 1024
+)
+
+// storedFile is a received file: its bytes in memory, or the path of its
+// spilled copy
+type storedFile struct {
+	name string
+	size int64
+	data []byte
+	path string
+}
+
+// fileStore keeps received files by name so long sessions don't hold every
+// payload forever. Files up to spillBytes stay in memory, least recently
+// used first out once they add up to more than maxBytes; larger files are
+// written to a temporary directory that clear removes.
+type fileStore struct {
+	mu         sync.Mutex
+	maxBytes   int64
+	spillBytes int64
+	memBytes   int64
+	files      map[string]*list.Element // of *storedFile
+	order      *list.List               // most recently used first
+	spillDir   string
+}
+
+// newFileStore keeps up to maxBytes in memory and spills files larger than
+// spillBytes to disk; zero for either uses the default
+func newFileStore(maxBytes, spillBytes int64) *fileStore {
+	if maxBytes <= 0 {
+		maxBytes = defaultFileCacheBytes
+	}
+	if spillBytes <= 0 {
+		spillBytes = defaultFileSpillBytes
+	}
+	return &fileStore{maxBytes: maxBytes, spillBytes: min(spillBytes, maxBytes), files: make(map[string]*list.Element), order: list.New()}
+}
+
+// put stores file under its name, replacing an earlier file of that name
+func (s *fileStore) put(file *shared.FileMeta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.files[file.Filename]; ok {
+		s.remove(el)
+	}
+	f := &storedFile{name: file.Filename, size: int64(len(file.Data))}
+	if f.size > s.spillBytes {
+		path, err := s.spill(file)
+		if err != nil {
+			return err
+		}
+		f.path = path
+	} else {
+		f.data = file.Data
+		s.memBytes += f.size
+	}
+	s.files[f.name] = s.order.PushFront(f)
+	for s.memBytes > s.maxBytes {
+		s.evictOldest()
+	}
+	return nil
+}
+
+// get returns the file stored under name, reading a spilled file back in,
+// or nil when it was never received or has been evicted
+func (s *fileStore) get(name string) (*shared.FileMeta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.files[name]
+	if !ok {
+		return nil, nil
+	}
+	s.order.MoveToFront(el)
+	f := el.Value.(*storedFile)
+	data := f.data
+	if f.path != "" {
+		var err error
+		if data, err = os.ReadFile(f.path); err != nil {
+			return nil, err
+		}
+	}
+	return &shared.FileMeta{Filename: f.name, Size: f.size, Data: data}, nil
+}
+
+// usage reports how many files are kept and their bytes in memory and on
+// disk
+func (s *fileStore) usage() (count int, memBytes, diskBytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for el := s.order.Front(); el != nil; el = el.Next() {
+		if f := el.Value.(*storedFile); f.path != "" {
+			diskBytes += f.size
+		}
+	}
+	return len(s.files), s.memBytes, diskBytes
+}
+
+// clear forgets every file and removes the spill directory, returning how
+// many files were dropped
+func (s *fileStore) clear() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.files)
+	s.files = make(map[string]*list.Element)
+	s.order.Init()
+	s.memBytes = 0
+	if s.spillDir == "" {
+		return n, nil
+	}
+	err := os.RemoveAll(s.spillDir)
+	s.spillDir = ""
+	return n, err
+}
+
+// spill writes file to the spill directory, creating it on first use;
+// callers hold s.mu
+func (s *fileStore) spill(file *shared.FileMeta) (string, error) {
+	if s.spillDir == "" {
+		dir, err := os.MkdirTemp("", "marchat-files-")
+		if err != nil {
+			return "", err
+		}
+		s.spillDir = dir
+	}
+	f, err := os.CreateTemp(s.spillDir, "file-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(file.Data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// evictOldest drops the least recently used file held in memory; callers
+// hold s.mu
+func (s *fileStore) evictOldest() {
+	for el := s.order.Back(); el != nil; el = el.Prev() {
+		if el.Value.(*storedFile).path == "" {
+			s.remove(el)
+			return
+		}
+	}
+}
+
+// remove drops one file; callers hold s.mu
+func (s *fileStore) remove(el *list.Element) {
+	f := s.order.Remove(el).(*storedFile)
+	delete(s.files, f.name)
+	if f.path != "" {
+		os.Remove(f.path)
+	} else {
+		s.memBytes -= f.size
+	}
+}
+
+// files is where this session keeps received files
+func (m *model) files() *fileStore {
+	if m.receivedFiles == nil {
+		m.receivedFiles = newFileStore(m.cfg.FileCacheBytes, m.cfg.FileSpillBytes)
+	}
+	return m.receivedFiles
+}
+
+// applyFilesCommand handles ":files", which reports what received files
+// are kept, and ":files clear", which drops them, returning the banner
+func applyFilesCommand(text string, store *fileStore) (string, error) {
+	args := strings.Fields(strings.TrimPrefix(text, ":files"))
+	switch {
+	case len(args) == 0:
+		count, mem, disk := store.usage()
+		return i18n.T("banner.files_status", count, formatByteSize(mem), formatByteSize(disk)), nil
+	case len(args) == 1 && args[0] == "clear":
+		n, err := store.clear()
+		if err != nil {
+			return "", err
+		}
+		return i18n.T("banner.files_cleared", n), nil
+	}
+	return "", errors.New(i18n.T("files.usage"))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestFileStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := newFileStore(10, 5)
+	t.Cleanup(func() { s.clear() })
+	for _, name := range []string{"a", "b"} {
+		if err := s.put(&shared.FileMeta{Filename: name, Data: []byte("1234")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Using a makes b the oldest, so the next file evicts b
+	if f, _ := s.get("a"); f == nil {
+		t.Fatal("Expected a to be kept")
+	}
+	if err := s.put(&shared.FileMeta{Filename: "c", Data: []byte("1234")}); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := s.get("b"); f != nil {
+		t.Error("Expected b to be evicted")
+	}
+	if count, mem, _ := s.usage(); count != 2 || mem != 8 {
+		t.Errorf("Expected 2 files in 8 bytes, got %d in %d", count, mem)
+	}
+}
+
+func TestFileStoreSpillsLargeFiles(t *testing.T) {
+	s := newFileStore(10, 5)
+	data := []byte("a large file")
+	if err := s.put(&shared.FileMeta{Filename: "big.bin", Size: int64(len(data)), Data: data}); err != nil {
+		t.Fatal(err)
+	}
+	if _, mem, disk := s.usage(); mem != 0 || disk != int64(len(data)) {
+		t.Errorf("Expected the file on disk only, got %d in memory and %d on disk", mem, disk)
+	}
+	f, err := s.get("big.bin")
+	if err != nil || f == nil || !bytes.Equal(f.Data, data) {
+		t.Fatalf("Expected the spilled file back, got %+v, %v", f, err)
+	}
+
+	dir := s.spillDir
+	if n, err := s.clear(); err != nil || n != 1 {
+		t.Fatalf("clear() = %d, %v", n, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the spill directory to be removed, got %v", err)
+	}
+	if f, _ := s.get("big.bin"); f != nil {
+		t.Error("Expected no files after clear")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/base64"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// sealedNonceSize is the length of the nonce in front of encrypted content
+// (chacha20poly1305.NonceSize)
+const sealedNonceSize = 12
+
+// decodeFrame sorts a frame from the server into a chat message, which has
+// a sender, or a typed control message. Anything else is nil.
+func decodeFrame(codec shared.Codec, raw []byte) tea.Msg {
+	var msg shared.Message
+	if err := codec.Unmarshal(raw, &msg); err == nil && msg.Sender != "" {
+		return msg
+	}
+	var ws wsMsg
+	if err := codec.Unmarshal(raw, &ws); err == nil && ws.Type != "" {
+		return ws
+	}
+	return nil
+}
+
+// splitSealedContent splits encrypted message content, base64 of the nonce
+// followed by the ciphertext, reporting false when it can't be that
+func splitSealedContent(content string) (nonce, ciphertext []byte, ok bool) {
+	decoded, err := base64.StdEncoding.DecodeString(content)
+	if err != nil || len(decoded) <= sealedNonceSize {
+		return nil, nil, false
+	}
+	return decoded[:sealedNonceSize], decoded[sealedNonceSize:], true
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// frameTestKey is the session key the fuzz seeds are encrypted with
+var frameTestKey = &shared.SessionKey{Key: bytes.Repeat([]byte{7}, 32), KeyID: "test"}
+
+// sealedContent encrypts text the way a peer's client sends it
+func sealedContent(t testing.TB, text string) string {
+	encrypted, err := shared.EncryptTextMessage(frameTestKey, "alice", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(append(encrypted.Nonce, encrypted.Encrypted...))
+}
+
+func TestDecodeFrame(t *testing.T) {
+	for _, subprotocol := range shared.Subprotocols {
+		codec := shared.CodecFor(subprotocol)
+		raw, _ := codec.Marshal(shared.Message{Sender: "alice", Content: "hi"})
+		if msg, ok := decodeFrame(codec, raw).(shared.Message); !ok || msg.Content != "hi" {
+			t.Errorf("%s: expected a chat message, got %#v", subprotocol, decodeFrame(codec, raw))
+		}
+		raw, _ = codec.Marshal(wsMsg{Type: "userlist", Data: json.RawMessage(`{"users":["alice"]}`)})
+		if ws, ok := decodeFrame(codec, raw).(wsMsg); !ok || ws.Type != "userlist" {
+			t.Errorf("%s: expected a control message, got %#v", subprotocol, decodeFrame(codec, raw))
+		}
+	}
+	for _, raw := range []string{``, `{`, `null`, `[]`, `{"sender":""}`, `{"sender":7}`} {
+		if frame := decodeFrame(shared.CodecFor(shared.SubprotocolJSON), []byte(raw)); frame != nil {
+			t.Errorf("Expected %q not to decode, got %#v", raw, frame)
+		}
+	}
+}
+
+func TestSplitSealedContent(t *testing.T) {
+	nonce, ciphertext, ok := splitSealedContent(sealedContent(t, "secret"))
+	if !ok || len(nonce) != sealedNonceSize {
+		t.Fatalf("Expected sealed content to split, got %v", ok)
+	}
+	msg, err := shared.DecryptTextMessage(frameTestKey, &shared.EncryptedMessage{Nonce: nonce, Encrypted: ciphertext, IsEncrypted: true})
+	if err != nil || msg.Content != "secret" {
+		t.Errorf("Expected the split content to decrypt, got %v: %v", msg, err)
+	}
+	for _, content := range []string{"", "plain text", "AAAA", base64.StdEncoding.EncodeToString(make([]byte, sealedNonceSize))} {
+		if _, _, ok := splitSealedContent(content); ok {
+			t.Errorf("Expected %q not to split", content)
+		}
+	}
+}
+
+// FuzzDecodeFrame feeds arbitrary frames, as a hostile server or relay
+// could send, through the client's decoding in both wire formats: sorting
+// them into messages and control messages, splitting and decrypting
+// sealed content and applying user list deltas must never panic
+func FuzzDecodeFrame(f *testing.F) {
+	jsonCodec, cborCodec := shared.CodecFor(shared.SubprotocolJSON), shared.CodecFor(shared.SubprotocolCBOR)
+	seeds := []any{
+		shared.Message{Sender: "alice", Content: "hello", Type: shared.TextMessage},
+		shared.Message{Sender: "alice", Content: sealedContent(f, "secret"), Encrypted: true},
+		shared.Message{Sender: "alice", Type: shared.FileMessageType, File: &shared.FileMeta{Filename: "a.txt", Size: 2, Data: []byte("hi")}},
+		wsMsg{Type: "userlist", Data: json.RawMessage(`{"users":["alice","bob"],"seq":1}`)},
+		wsMsg{Type: "userlist_delta", Data: json.RawMessage(`{"seq":2,"joined":["carol"],"left":["bob"],"display_names":{"alice":""}}`)},
+	}
+	for _, seed := range seeds {
+		raw, _ := jsonCodec.Marshal(seed)
+		f.Add(raw)
+		raw, _ = cborCodec.Marshal(seed)
+		f.Add(raw)
+	}
+	f.Add([]byte(`{"sender":"alice","encrypted":true,"content":"!!!"}`))
+	f.Add([]byte(`{"type":"userlist_delta","data":null}`))
+	f.Add([]byte{0xbf, 0x66})
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		for _, codec := range []shared.Codec{jsonCodec, cborCodec} {
+			switch frame := decodeFrame(codec, raw).(type) {
+			case nil:
+			case shared.Message:
+				if frame.Sender == "" {
+					t.Fatal("Decoded a chat message without a sender")
+				}
+				if nonce, ciphertext, ok := splitSealedContent(frame.Content); ok {
+					if len(nonce) != sealedNonceSize || len(ciphertext) == 0 {
+						t.Fatalf("Split sealed content into %d and %d bytes", len(nonce), len(ciphertext))
+					}
+					_, _ = shared.DecryptTextMessage(frameTestKey, &shared.EncryptedMessage{Nonce: nonce, Encrypted: ciphertext, IsEncrypted: true})
+				}
+			case wsMsg:
+				if frame.Type == "" {
+					t.Fatal("Decoded a control message without a type")
+				}
+				var ul UserList
+				_ = json.Unmarshal(frame.Data, &ul)
+				var delta UserListDelta
+				if json.Unmarshal(frame.Data, &delta) == nil {
+					applyUserListDelta(ul, delta)
+				}
+			default:
+				t.Fatalf("Decoded an unexpected %T", frame)
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/chroma/quick"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxHighlights caps how many highlighted code blocks are kept; past it the
+// cache starts over, which only costs highlighting the visible blocks again
+const maxHighlights = 512
+
+// highlightKey identifies a code block to highlight
+type highlightKey struct {
+	language string
+	code     string
+}
+
+// highlightReadyMsg tells Update that code blocks finished highlighting in
+// the background, so the messages holding them should be redrawn
+type highlightReadyMsg struct{}
+
+// codeHighlighter keeps highlighted code blocks and, once started, runs
+// chroma on a background goroutine so a redraw never waits on it. Until a
+// block is ready it is drawn with line numbers but without colors.
+type codeHighlighter struct {
+	mu     sync.Mutex
+	done   map[highlightKey]string
+	queued map[highlightKey]bool
+	jobs   chan highlightKey
+}
+
+// highlighter is the cache renderCodeBlocks draws from
+var highlighter = &codeHighlighter{done: make(map[highlightKey]string), queued: make(map[highlightKey]bool)}
+
+// start begins highlighting in the background, calling send after each
+// batch of blocks is ready. Before start, blocks are highlighted in place.
+func (h *codeHighlighter) start(send func(tea.Msg)) {
+	h.mu.Lock()
+	if h.jobs != nil {
+		h.mu.Unlock()
+		return
+	}
+	jobs := make(chan highlightKey, 64)
+	h.jobs = jobs
+	h.mu.Unlock()
+
+	go func() {
+		defer terminal.Recover()
+		for key := range jobs {
+			out := highlightBlock(key)
+			h.mu.Lock()
+			h.store(key, out)
+			delete(h.queued, key)
+			h.mu.Unlock()
+			if len(jobs) == 0 {
+				send(highlightReadyMsg{})
+			}
+		}
+	}()
+}
+
+// code returns the highlighted block for key and whether it is final. A
+// block still being highlighted comes back plain and not final.
+func (h *codeHighlighter) code(key highlightKey) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if out, ok := h.done[key]; ok {
+		return out, true
+	}
+	if h.jobs == nil {
+		out := highlightBlock(key)
+		h.store(key, out)
+		return out, true
+	}
+	if !h.queued[key] {
+		select {
+		case h.jobs <- key:
+			h.queued[key] = true
+		default:
+			// The queue is full; the next redraw asks again
+		}
+	}
+	return numberLines(key.code), false
+}
+
+// store records a highlighted block; callers hold h.mu
+func (h *codeHighlighter) store(key highlightKey, out string) {
+	if len(h.done) >= maxHighlights {
+		h.done = make(map[highlightKey]string)
+	}
+	h.done[key] = out
+}
+
+// highlightBlock runs chroma over one code block, falling back to the
+// original fenced block if highlighting fails
+func highlightBlock(key highlightKey) string {
+	var sb strings.Builder
+	if err := quick.Highlight(&sb, key.code, key.language, "terminal256", "monokai"); err != nil {
+		return "```" + key.language + "\n" + key.code + "```"
+	}
+	return numberLines(sb.String())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCodeHighlighterBackground(t *testing.T) {
+	h := &codeHighlighter{done: make(map[highlightKey]string), queued: make(map[highlightKey]bool)}
+	key := highlightKey{language: "go", code: "func main() {}\n"}
+
+	// Before start, blocks are highlighted in place
+	if out, ok := h.code(key); !ok || out != highlightBlock(key) {
+		t.Fatalf("Expected an in-place highlight before start, got %q, %v", out, ok)
+	}
+
+	ready := make(chan tea.Msg, 1)
+	h.start(func(msg tea.Msg) { ready <- msg })
+	other := highlightKey{language: "python", code: "print('hi')\n"}
+	out, ok := h.code(other)
+	if ok {
+		t.Fatal("A new block should not be final before the background highlight")
+	}
+	if !strings.Contains(out, "print('hi')") {
+		t.Errorf("Expected the plain code meanwhile, got %q", out)
+	}
+
+	select {
+	case msg := <-ready:
+		if _, isReady := msg.(highlightReadyMsg); !isReady {
+			t.Fatalf("Expected highlightReadyMsg, got %T", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Background highlight never finished")
+	}
+	if out, ok := h.code(other); !ok || out != highlightBlock(other) {
+		t.Errorf("Expected the highlighted block once ready, got %q, %v", out, ok)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Hook events
+const (
+	hookEventMention = "mention"
+	hookEventFile    = "file"
+)
+
+// hookTimeout stops hook commands that hang
+const hookTimeout = 30 * time.Second
+
+// hookDigest fingerprints a hook's event and command, so editing either
+// needs confirming again
+func hookDigest(h config.Hook) string {
+	sum := sha256.Sum256([]byte(h.Event + "\x00" + h.Command))
+	return hex.EncodeToString(sum[:8])
+}
+
+// confirmHooks asks before enabling hooks that are new or changed since they
+// were last approved, and reports whether any approval was recorded
+func confirmHooks(hooks []config.Hook, in io.Reader, out io.Writer) bool {
+	reader := bufio.NewReader(in)
+	changed := false
+	for i := range hooks {
+		h := &hooks[i]
+		if !h.Enabled || h.Approved == hookDigest(*h) {
+			continue
+		}
+		fmt.Fprintf(out, "⚠️  Hook on %s wants to run: %s\nAllow it? [y/N]: ", h.Event, h.Command)
+		answer, _ := reader.ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a == "y" || a == "yes" {
+			h.Approved = hookDigest(*h)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// activeHooks returns the hooks that are enabled and approved
+func activeHooks(hooks []config.Hook) []config.Hook {
+	var active []config.Hook
+	for _, h := range hooks {
+		if h.Enabled && h.Approved == hookDigest(h) {
+			active = append(active, h)
+		} else if h.Enabled {
+			log.Printf("Hook on %s not approved, skipping: %s", h.Event, h.Command)
+		}
+	}
+	return active
+}
+
+// setupHooks confirms new hooks on the terminal and saves the approvals to
+// config.json. Hooks are not confirmed, and so stay off, without a terminal.
+func setupHooks(cfg *config.Config, configFilePath string, interactive bool) []config.Hook {
+	if interactive && confirmHooks(cfg.Hooks, os.Stdin, os.Stdout) {
+		base, err := config.LoadConfig(configFilePath)
+		if err != nil {
+			base = *cfg
+		}
+		base.Hooks = cfg.Hooks
+		if err := config.SaveConfig(configFilePath, base); err != nil {
+			fmt.Printf("Warning: Could not save hook approvals: %v\n", err)
+		}
+	}
+	return activeHooks(cfg.Hooks)
+}
+
+// hookEvents lists the events msg triggers for username
+func hookEvents(msg shared.Message, username string) []string {
+	if msg.Sender == username || isIgnored(msg.Sender) {
+		return nil
+	}
+	var events []string
+	if mentionsUser(msg, username) {
+		events = append(events, hookEventMention)
+	}
+	if msg.Type == shared.FileMessageType && msg.File != nil {
+		events = append(events, hookEventFile)
+	}
+	return events
+}
+
+// hookEnv describes an event to a hook command
+func hookEnv(event string, msg shared.Message, serverURL string) []string {
+	env := []string{
+		"MARCHAT_EVENT=" + event,
+		"MARCHAT_SENDER=" + msg.Sender,
+		"MARCHAT_CONTENT=" + msg.Content,
+		"MARCHAT_SERVER=" + serverURL,
+	}
+	if msg.File != nil {
+		env = append(env,
+			"MARCHAT_FILENAME="+msg.File.Filename,
+			"MARCHAT_FILESIZE="+strconv.FormatInt(msg.File.Size, 10))
+	}
+	return env
+}
+
+// runHooks starts the hooks matching the events msg triggers
+func runHooks(hooks []config.Hook, msg shared.Message, username, serverURL string) {
+	if len(hooks) == 0 {
+		return
+	}
+	for _, event := range hookEvents(msg, username) {
+		for _, h := range hooks {
+			if h.Event == event {
+				go runHookCommand(h.Command, hookEnv(event, msg, serverURL))
+			}
+		}
+	}
+}
+
+// runHookCommand runs command in the platform shell with env added
+func runHookCommand(command string, env []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Hook %q failed: %v %s", command, err, strings.TrimSpace(string(out)))
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestConfirmHooks(t *testing.T) {
+	hooks := []config.Hook{
+		{Event: "mention", Command: "notify-me", Enabled: true},
+		{Event: "file", Command: "rm -rf /", Enabled: true},
+		{Event: "mention", Command: "never-asked", Enabled: false},
+	}
+	var out strings.Builder
+	if !confirmHooks(hooks, strings.NewReader("y\nn\n"), &out) {
+		t.Fatal("Expected an approval to be recorded")
+	}
+	if strings.Contains(out.String(), "never-asked") {
+		t.Error("Disabled hooks should not be confirmed")
+	}
+	active := activeHooks(hooks)
+	if len(active) != 1 || active[0].Command != "notify-me" {
+		t.Fatalf("Expected only the approved hook to be active, got %+v", active)
+	}
+
+	// Editing an approved command needs confirming again
+	hooks[0].Command = "something-else"
+	if len(activeHooks(hooks)) != 0 {
+		t.Error("Edited hooks should not run until confirmed")
+	}
+	if confirmHooks(hooks, strings.NewReader("\n\n"), &out) {
+		t.Error("Blank answers should not approve hooks")
+	}
+}
+
+func TestHookEvents(t *testing.T) {
+	file := &shared.FileMeta{Filename: "a.txt", Size: 3}
+	tests := []struct {
+		msg  shared.Message
+		want []string
+	}{
+		{shared.Message{Sender: "bob", Content: "hi @alice"}, []string{hookEventMention}},
+		{shared.Message{Sender: "bob", Type: shared.FileMessageType, File: file}, []string{hookEventFile}},
+		{shared.Message{Sender: "bob", Content: "hello"}, nil},
+		{shared.Message{Sender: "alice", Content: "@alice"}, nil},
+	}
+	for _, tt := range tests {
+		if got := hookEvents(tt.msg, "alice"); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("hookEvents(%+v) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestRunHookCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	msg := shared.Message{Sender: "bob", Type: shared.FileMessageType, File: &shared.FileMeta{Filename: "a.txt", Size: 3}}
+	env := hookEnv(hookEventFile, msg, "ws://chat/ws")
+	if err := runHookCommand(`echo "$MARCHAT_EVENT $MARCHAT_SENDER $MARCHAT_FILENAME $MARCHAT_FILESIZE" > "`+out+`"`, env); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || strings.TrimSpace(string(data)) != "file bob a.txt 3" {
+		t.Errorf("Unexpected hook output %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package i18n holds the client's user-facing strings. Each locale is a flat
+// JSON catalog in locales/, keyed by message ID; English is the reference
+// and the fallback for anything a catalog lacks.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the reference catalog every key must exist in
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	catalogs = loadCatalogs()
+
+	mu      sync.RWMutex
+	current = DefaultLocale
+)
+
+func loadCatalogs() map[string]map[string]string {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	all := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		data, err := localeFS.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", f.Name(), err))
+		}
+		all[strings.TrimSuffix(f.Name(), ".json")] = catalog
+	}
+	return all
+}
+
+// Locales lists the available locale codes
+func Locales() []string {
+	codes := make([]string, 0, len(catalogs))
+	for code := range catalogs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Match returns the available locale for a tag such as "es", "es-MX" or the
+// "es_ES.UTF-8" form of $LANG, or "" when there is none
+func Match(tag string) string {
+	tag = strings.ToLower(tag)
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	tag = strings.ReplaceAll(tag, "_", "-")
+	for tag != "" {
+		if _, ok := catalogs[tag]; ok {
+			return tag
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return ""
+}
+
+// Detect picks the locale from configured, falling back to MARCHAT_LANG and
+// then the usual POSIX locale variables
+func Detect(configured string) string {
+	for _, tag := range []string{configured, os.Getenv("MARCHAT_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if code := Match(tag); code != "" {
+			return code
+		}
+	}
+	return DefaultLocale
+}
+
+// SetLocale switches the catalog T reads from, reporting false (and leaving
+// it unchanged) when tag matches no locale
+func SetLocale(tag string) bool {
+	code := Match(tag)
+	if code == "" {
+		return false
+	}
+	mu.Lock()
+	current = code
+	mu.Unlock()
+	return true
+}
+
+// Locale returns the current locale code
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T returns the message for key in the current locale, formatted with args
+// as by fmt.Sprintf. Entries are always format strings, so a literal percent
+// sign is written %%. Keys missing from the locale fall back to English, and
+// unknown keys are returned as is so they stand out.
+func T(key string, args ...any) string {
+	msg, ok := catalogs[Locale()][key]
+	if !ok {
+		if msg, ok = catalogs[DefaultLocale][key]; !ok {
+			return key
+		}
+	}
+	return fmt.Sprintf(msg, args...)
+}
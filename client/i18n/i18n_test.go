@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var verbRegex = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// verbs lists the formatting verbs of a catalog entry, ignoring order so
+// translations may reorder arguments with %[n]s
+func verbs(msg string) string {
+	var found []string
+	for _, v := range verbRegex.FindAllString(msg, -1) {
+		if v != "%%" {
+			found = append(found, v[len(v)-1:])
+		}
+	}
+	sort.Strings(found)
+	return strings.Join(found, "")
+}
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	en := catalogs[DefaultLocale]
+	if len(en) == 0 {
+		t.Fatal("English catalog is empty")
+	}
+	for code, catalog := range catalogs {
+		if code == DefaultLocale {
+			continue
+		}
+		for key, msg := range catalog {
+			ref, ok := en[key]
+			if !ok {
+				t.Errorf("%s: key %q is not in the English catalog", code, key)
+				continue
+			}
+			if verbs(msg) != verbs(ref) {
+				t.Errorf("%s: %q has verbs %q, English has %q", code, key, verbs(msg), verbs(ref))
+			}
+		}
+		var missing []string
+		for key := range en {
+			if _, ok := catalog[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			t.Logf("%s: %d untranslated keys fall back to English: %s", code, len(missing), strings.Join(missing, ", "))
+		}
+	}
+}
+
+func TestKeysUsedByClientExist(t *testing.T) {
+	files, err := filepath.Glob("../*.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("cannot list client sources: %v", err)
+	}
+	used := regexp.MustCompile(`i18n\.T\("([^"]+)"`)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range used.FindAllStringSubmatch(string(src), -1) {
+			if _, ok := catalogs[DefaultLocale][m[1]]; !ok {
+				t.Errorf("%s uses %q, which is not in the English catalog", filepath.Base(file), m[1])
+			}
+		}
+	}
+	// Keys stored in tables rather than passed to T directly
+	tableKey := regexp.MustCompile(`"(help\.(?:cmd|key)\.[a-z0-9_]+)"`)
+	src, err := os.ReadFile("../main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range tableKey.FindAllStringSubmatch(string(src), -1) {
+		if _, ok := catalogs[DefaultLocale][m[1]]; !ok {
+			t.Errorf("help table uses %q, which is not in the English catalog", m[1])
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"es":          "es",
+		"es-MX":       "es",
+		"es_ES.UTF-8": "es",
+		"EN_us":       "en",
+		"C.UTF-8":     "",
+		"fr":          "",
+		"":            "",
+	}
+	for tag, want := range tests {
+		if got := Match(tag); got != want {
+			t.Errorf("Match(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("MARCHAT_LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "es_ES.UTF-8")
+	if got := Detect(""); got != "es" {
+		t.Errorf("Expected LANG to pick es, got %q", got)
+	}
+	if got := Detect("en"); got != "en" {
+		t.Errorf("Expected the configured locale to win, got %q", got)
+	}
+	t.Setenv("LANG", "fr_FR.UTF-8")
+	if got := Detect("klingon"); got != DefaultLocale {
+		t.Errorf("Expected the default locale, got %q", got)
+	}
+}
+
+func TestT(t *testing.T) {
+	t.Cleanup(func() { SetLocale(DefaultLocale) })
+	if got := T("banner.file_sent", "a.txt"); got != "File sent: a.txt" {
+		t.Errorf("T() = %q", got)
+	}
+	if SetLocale("fr") {
+		t.Error("Expected an unknown locale to be refused")
+	}
+	if !SetLocale("es-AR") || Locale() != "es" {
+		t.Fatalf("Expected es-AR to select es, got %q", Locale())
+	}
+	if got := T("banner.file_sent", "a.txt"); got != "Archivo enviado: a.txt" {
+		t.Errorf("T() in es = %q", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("Expected unknown keys to be returned as is, got %q", got)
+	}
+}
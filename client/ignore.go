@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Users hidden with :ignore, keyed by lowercase username. The list is saved
+// in the config and on the connection profile.
+var (
+	ignoredMu    sync.RWMutex
+	ignoredUsers = map[string]bool{}
+)
+
+// setIgnoredUsers replaces the ignore list
+func setIgnoredUsers(users []string) {
+	set := make(map[string]bool, len(users))
+	for _, u := range users {
+		set[strings.ToLower(u)] = true
+	}
+	ignoredMu.Lock()
+	ignoredUsers = set
+	ignoredMu.Unlock()
+}
+
+// isIgnored reports whether messages from sender are hidden
+func isIgnored(sender string) bool {
+	ignoredMu.RLock()
+	defer ignoredMu.RUnlock()
+	return ignoredUsers[strings.ToLower(sender)]
+}
+
+// hiddenMessageCount counts the messages in the buffer hidden by :ignore
+func hiddenMessageCount(msgs []shared.Message) int {
+	n := 0
+	for _, msg := range msgs {
+		if isIgnored(msg.Sender) {
+			n++
+		}
+	}
+	return n
+}
+
+// resolveUsername maps a name typed after :ignore, with or without "@", to
+// a canonical username; display names of online users are accepted too
+func resolveUsername(name string, users []string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+	for _, u := range users {
+		if strings.EqualFold(u, name) {
+			return u
+		}
+	}
+	for _, u := range users {
+		if strings.EqualFold(displayName(u), name) {
+			return u
+		}
+	}
+	return name
+}
+
+// applyIgnoreCommand handles :ignore [user] and :unignore <user>, returning
+// the updated list and a banner. The list is unchanged on error.
+func applyIgnoreCommand(text, me string, ignored, users []string) ([]string, string, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ignored, "", fmt.Errorf("usage: :ignore [user]")
+	}
+	if len(fields) == 1 {
+		if fields[0] == ":unignore" {
+			return ignored, "", fmt.Errorf("usage: :unignore <user>")
+		}
+		if len(ignored) == 0 {
+			return ignored, "Not ignoring anyone", nil
+		}
+		return ignored, "Ignoring: " + strings.Join(ignored, ", "), nil
+	}
+	if len(fields) > 2 {
+		return ignored, "", fmt.Errorf("usage: %s <user>", fields[0])
+	}
+	user := resolveUsername(fields[1], users)
+
+	kept := make([]string, 0, len(ignored)+1)
+	found := false
+	for _, u := range ignored {
+		if strings.EqualFold(u, user) {
+			found = true
+			continue
+		}
+		kept = append(kept, u)
+	}
+	if fields[0] == ":unignore" {
+		if !found {
+			return ignored, "", fmt.Errorf("%s is not ignored", user)
+		}
+		return kept, "No longer ignoring " + user, nil
+	}
+	if strings.EqualFold(user, me) || strings.EqualFold(user, "System") || user == "" {
+		return ignored, "", fmt.Errorf("cannot ignore %s", user)
+	}
+	if found {
+		return ignored, "", fmt.Errorf("%s is already ignored", user)
+	}
+	kept = append(kept, user)
+	sort.Slice(kept, func(i, j int) bool { return strings.ToLower(kept[i]) < strings.ToLower(kept[j]) })
+	return kept, "Ignoring " + user + " (their messages and notifications are hidden)", nil
+}
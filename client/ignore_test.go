@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestApplyIgnoreCommand(t *testing.T) {
+	t.Cleanup(func() { setIgnoredUsers(nil) })
+	users := []string{"alice", "Bob", "me"}
+
+	ignored, banner, err := applyIgnoreCommand(":ignore @bob", "me", nil, users)
+	if err != nil || len(ignored) != 1 || ignored[0] != "Bob" || !strings.Contains(banner, "Ignoring Bob") {
+		t.Fatalf("applyIgnoreCommand = %v, %q, %v", ignored, banner, err)
+	}
+	ignored, _, err = applyIgnoreCommand(":ignore alice", "me", ignored, users)
+	if err != nil || strings.Join(ignored, ",") != "alice,Bob" {
+		t.Errorf("Expected a sorted list, got %v (%v)", ignored, err)
+	}
+	if _, banner, _ := applyIgnoreCommand(":ignore", "me", ignored, users); banner != "Ignoring: alice, Bob" {
+		t.Errorf("Unexpected list banner %q", banner)
+	}
+	for _, bad := range []string{":ignore me", ":ignore System", ":ignore bob", ":unignore carol", ":unignore", ":ignore a b"} {
+		if got, _, err := applyIgnoreCommand(bad, "me", ignored, users); err == nil || len(got) != 2 {
+			t.Errorf("%q should fail without changing the list, got %v", bad, got)
+		}
+	}
+	ignored, _, err = applyIgnoreCommand(":unignore BOB", "me", ignored, users)
+	if err != nil || strings.Join(ignored, ",") != "alice" {
+		t.Errorf("Expected bob to be removed, got %v (%v)", ignored, err)
+	}
+}
+
+func TestIgnoredMessagesHidden(t *testing.T) {
+	t.Cleanup(func() { setIgnoredUsers(nil) })
+	setIgnoredUsers([]string{"Troll"})
+
+	now := time.Now()
+	msgs := []shared.Message{
+		{Sender: "troll", Content: "spam spam", CreatedAt: now},
+		{Sender: "alice", Content: "hello", CreatedAt: now.Add(time.Second)},
+	}
+	out := renderMessages(msgs, baseThemeStyles(), "me", []string{"alice", "troll"}, 80, true)
+	if strings.Contains(out, "spam") || !strings.Contains(out, "hello") {
+		t.Errorf("Expected only alice's message, got %q", out)
+	}
+	if n := hiddenMessageCount(msgs); n != 1 {
+		t.Errorf("hiddenMessageCount = %d, want 1", n)
+	}
+
+	m := &model{cfg: config.Config{Username: "me"}}
+	if notify, _ := m.shouldNotify(shared.Message{Sender: "troll", Content: "@me hi"}); notify {
+		t.Error("Ignored users should not trigger notifications")
+	}
+}
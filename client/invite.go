@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// pendingInvite is sent with the first handshake after --invite, then
+// cleared: once redeemed the server remembers the user
+var pendingInvite string
+
+// chatLink is what a marchat:// link contributes to the startup flags
+type chatLink struct {
+	server string // WebSocket URL
+	user   string
+	invite string
+}
+
+// parseChatLink reads a marchat:// link, as passed by --invite or by the
+// OS URL handler
+func parseChatLink(raw string) (chatLink, error) {
+	link, err := shared.ParseChatURL(raw)
+	if err != nil {
+		return chatLink{}, err
+	}
+	return chatLink{server: link.WebSocketURL(), user: link.User, invite: link.Invite}, nil
+}
+
+// parseInviteFlag reads --invite, which takes a marchat:// link or a bare
+// token
+func parseInviteFlag(invite string) (chatLink, error) {
+	invite = strings.TrimSpace(invite)
+	if !strings.Contains(invite, "://") {
+		return chatLink{invite: invite}, nil
+	}
+	return parseChatLink(invite)
+}
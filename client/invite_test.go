@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseChatLink(t *testing.T) {
+	link, err := parseChatLink("marchat://chat.example.com:8443/general?user=alice&invite=TOKEN")
+	if err != nil {
+		t.Fatalf("parseChatLink failed: %v", err)
+	}
+	if link.server != "wss://chat.example.com:8443/ws" || link.user != "alice" || link.invite != "TOKEN" {
+		t.Errorf("Unexpected link %+v", link)
+	}
+
+	if link, _ := parseChatLink("marchat://localhost:8080?tls=false"); link.server != "ws://localhost:8080/ws" {
+		t.Errorf("Expected a plain WebSocket URL, got %q", link.server)
+	}
+	if _, err := parseChatLink("https://chat.example.com"); err == nil {
+		t.Error("Expected an error for a non-marchat link")
+	}
+}
+
+func TestParseInviteFlag(t *testing.T) {
+	link, err := parseInviteFlag("marchat://chat.example.com?invite=TOKEN")
+	if err != nil || link.invite != "TOKEN" || link.server != "wss://chat.example.com/ws" {
+		t.Errorf("parseInviteFlag = %+v, %v", link, err)
+	}
+
+	link, err = parseInviteFlag("  TOKEN  ")
+	if err != nil || link.invite != "TOKEN" || link.server != "" {
+		t.Errorf("Bare token: %+v, %v", link, err)
+	}
+}
+
+func TestWriteTermuxURLOpener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bin", "termux-url-opener")
+	if err := writeTermuxURLOpener(path, "/usr/bin/marchat-client"); err != nil {
+		t.Fatalf("writeTermuxURLOpener failed: %v", err)
+	}
+	script, _ := os.ReadFile(path)
+	if !strings.Contains(string(script), `marchat://*) exec "/usr/bin/marchat-client" "$1"`) {
+		t.Errorf("Unexpected script:\n%s", script)
+	}
+	// Our own script may be rewritten, someone else's may not
+	if err := writeTermuxURLOpener(path, "/usr/bin/marchat-client"); err != nil {
+		t.Errorf("Rewriting our script failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nyt-dlp \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeTermuxURLOpener(path, "/usr/bin/marchat-client"); err == nil {
+		t.Error("Expected an existing termux-url-opener to be left alone")
+	}
+}
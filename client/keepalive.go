@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/client/termux"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// Defaults for the connection timings a profile or config.json can override
+const (
+	pingPeriod            = 50 * time.Second
+	writeTimeout          = 10 * time.Second
+	reconnectInitialDelay = time.Second
+	reconnectMaxDelay     = 30 * time.Second // for exponential backoff
+)
+
+// connTimings controls keepalive and reconnects. Users behind NATs or proxies
+// that drop idle connections early can ping more often.
+type connTimings struct {
+	Ping           time.Duration
+	Write          time.Duration
+	ReconnectDelay time.Duration
+	ReconnectMax   time.Duration
+}
+
+var (
+	timingsMu sync.RWMutex
+	timings   = timingsFromConfig(config.Config{})
+)
+
+// timingsFromConfig reads the timings from cfg, where they are in seconds and
+// zero (or less) means the default
+func timingsFromConfig(cfg config.Config) connTimings {
+	seconds := func(n int, def time.Duration) time.Duration {
+		if n <= 0 {
+			return def
+		}
+		return time.Duration(n) * time.Second
+	}
+	t := connTimings{
+		Ping:           seconds(cfg.PingInterval, pingPeriod),
+		Write:          seconds(cfg.WriteTimeout, writeTimeout),
+		ReconnectDelay: seconds(cfg.ReconnectDelay, reconnectInitialDelay),
+		ReconnectMax:   seconds(cfg.ReconnectMaxDelay, reconnectMaxDelay),
+	}
+	if t.ReconnectMax < t.ReconnectDelay {
+		t.ReconnectMax = t.ReconnectDelay
+	}
+	return t
+}
+
+// setConnectionTimings applies the timings from cfg to new connections
+func setConnectionTimings(cfg config.Config) {
+	timingsMu.Lock()
+	timings = timingsFromConfig(cfg)
+	timingsMu.Unlock()
+}
+
+// connectionTimings returns the timings in use
+func connectionTimings() connTimings {
+	timingsMu.RLock()
+	defer timingsMu.RUnlock()
+	return timings
+}
+
+// restartJitter spreads reconnects after a server restart so every client
+// does not arrive at the new instance in the same instant
+const restartJitter = 2 * time.Second
+
+// restartReconnectDelay is how long to wait before reconnecting to a server
+// that closed the connection because it is restarting
+func restartReconnectDelay() time.Duration {
+	return connectionTimings().ReconnectDelay + rand.N(restartJitter)
+}
+
+// reconnectWait is how long to wait before reconnecting after delay of
+// backoff. On a phone running on a low battery it is stretched, since each
+// attempt wakes the radio; reading the battery can take a second, so this
+// is called off the UI goroutine.
+func reconnectWait(delay time.Duration) time.Duration {
+	if !isTermux() {
+		return delay
+	}
+	battery, err := termux.BatteryStatus()
+	if err != nil {
+		return delay
+	}
+	return termux.ReconnectDelay(delay, battery)
+}
+
+// writeFrame sends v on conn in the negotiated wire format, giving up after
+// the write timeout so a dead connection is noticed instead of blocking the UI
+func writeFrame(conn *websocket.Conn, v any) error {
+	codec := shared.CodecFor(conn.Subprotocol())
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	frameType := websocket.TextMessage
+	if codec.Binary() {
+		frameType = websocket.BinaryMessage
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(connectionTimings().Write))
+	setWriteCompression(conn, len(data))
+	return conn.WriteMessage(frameType, data)
+}
+
+// writePing sends a keepalive ping. Control frames may be written alongside
+// other writes, so this is safe from the ping goroutines.
+func writePing(conn *websocket.Conn) error {
+	return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(connectionTimings().Write))
+}
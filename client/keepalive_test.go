@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+func TestTimingsFromConfig(t *testing.T) {
+	def := timingsFromConfig(config.Config{})
+	if def.Ping != pingPeriod || def.Write != writeTimeout || def.ReconnectDelay != reconnectInitialDelay || def.ReconnectMax != reconnectMaxDelay {
+		t.Errorf("Expected the defaults for an empty config, got %+v", def)
+	}
+
+	got := timingsFromConfig(config.Config{ConnectionTimings: config.ConnectionTimings{
+		PingInterval:      15,
+		WriteTimeout:      -1,
+		ReconnectDelay:    5,
+		ReconnectMaxDelay: 2,
+	}})
+	if got.Ping != 15*time.Second {
+		t.Errorf("Expected a 15s ping, got %s", got.Ping)
+	}
+	if got.Write != writeTimeout {
+		t.Errorf("Expected a negative write timeout to use the default, got %s", got.Write)
+	}
+	if got.ReconnectDelay != 5*time.Second || got.ReconnectMax != 5*time.Second {
+		t.Errorf("Expected the max reconnect delay to be at least the first, got %s and %s", got.ReconnectDelay, got.ReconnectMax)
+	}
+}
+
+func TestRestartReconnectDelay(t *testing.T) {
+	base := connectionTimings().ReconnectDelay
+	for range 20 {
+		if d := restartReconnectDelay(); d < base || d >= base+restartJitter {
+			t.Fatalf("Expected a delay in [%s, %s), got %s", base, base+restartJitter, d)
+		}
+	}
+}
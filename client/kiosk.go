@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderKioskMessages lays out messages for a wall display: every message
+// uses the full width, with the sender on its own line above bold, padded
+// text so it reads from across a room
+func renderKioskMessages(msgs []shared.Message, styles themeStyles, width int, twentyFourHour bool) string {
+	if len(msgs) > maxMessages {
+		msgs = msgs[len(msgs)-maxMessages:]
+	}
+	sortMessages(msgs)
+
+	timeFmt := "15:04"
+	if !twentyFourHour {
+		timeFmt = "03:04 PM"
+	}
+	body := styles.Msg.Bold(true).Width(width-4).Padding(0, 2)
+	var b strings.Builder
+	for _, msg := range msgs {
+		if isIgnored(msg.Sender) || msg.Type == translationMessageType || msg.Type == shared.GapMessageType {
+			continue
+		}
+		timestamp := styles.Time.Render(formatTimestamp(msg.CreatedAt, timeFmt))
+		if msg.Type == shared.AnnouncementType {
+			b.WriteString(renderAnnouncement(msg, styles, width, timestamp) + "\n\n")
+			continue
+		}
+		content := msg.Content
+		switch {
+		case msg.Type == shared.FileMessageType && msg.File != nil:
+			content = "📎 " + msg.File.Filename
+		case msg.Type == shared.PollMessageType && msg.Poll != nil:
+			content = "📊 " + msg.Poll.Question
+		case msg.Type != shared.ArtMessageType && msg.Type != shared.DiagramMessageType:
+			content = renderEmojis(content)
+		}
+		header := styles.User.Bold(true).Render(strings.ToUpper(displayName(msg.Sender))) + "  " + timestamp
+		b.WriteString(lipgloss.JoinVertical(lipgloss.Left, header, body.Render(content)) + "\n\n\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestRenderKioskMessages(t *testing.T) {
+	t.Cleanup(func() { setIgnoredUsers(nil) })
+	setIgnoredUsers([]string{"Troll"})
+	now := time.Date(2024, 1, 1, 14, 5, 0, 0, time.Local)
+	msgs := []shared.Message{
+		{Sender: "alice", Content: "build is green", CreatedAt: now},
+		{Sender: "Troll", Content: "spam", CreatedAt: now.Add(time.Second)},
+		{Sender: "bob", Type: shared.FileMessageType, File: &shared.FileMeta{Filename: "report.pdf"}, CreatedAt: now.Add(2 * time.Second)},
+	}
+
+	out := renderKioskMessages(msgs, getThemeStyles("system"), 60, true)
+	for _, want := range []string{"ALICE", "build is green", "14:05", "BOB", "report.pdf"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in kiosk output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "spam") {
+		t.Error("Ignored users should not be shown on a kiosk")
+	}
+}
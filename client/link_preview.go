@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// renderLinkPreview draws the server's summary of a link as a compact
+// block beneath the message
+func renderLinkPreview(p *shared.LinkPreview, styles themeStyles) string {
+	bar := styles.Time.Render("▎ ")
+	title := styles.User.Render(p.Title)
+	if p.SiteName != "" && p.SiteName != p.Title {
+		title = styles.Time.Render(p.SiteName+" · ") + title
+	}
+	lines := []string{bar + title}
+	if p.Description != "" {
+		lines = append(lines, bar+styles.Time.Render(p.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findPreviewMessage returns the index of the message a link preview
+// belongs to, or -1. The server sends previews after the message itself.
+func findPreviewMessage(msgs []shared.Message, update shared.PreviewUpdate) int {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Sender == update.Sender && msgs[i].CreatedAt.Equal(update.CreatedAt) && !msgs[i].Encrypted {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestLinkPreviewMessages(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	m := &model{messages: []shared.Message{
+		{Sender: "alice", Content: "https://example.com/a", CreatedAt: at},
+		{Sender: "bob", Content: "https://example.com/b", CreatedAt: at},
+	}}
+	data, _ := json.Marshal(shared.PreviewUpdate{Sender: "bob", CreatedAt: at, Preview: shared.LinkPreview{URL: "https://example.com/b", Title: "Example", SiteName: "Example Site", Description: "An example page"}})
+	m.Update(wsMsg{Type: "preview", Data: data})
+	if m.messages[0].Preview != nil || m.messages[1].Preview == nil || m.messages[1].Preview.Title != "Example" {
+		t.Fatalf("Expected the preview on bob's message only, got %+v", m.messages)
+	}
+
+	out := renderMessages(m.messages, getThemeStyles("system"), "alice", nil, 80, true)
+	for _, want := range []string{"Example Site · Example", "An example page"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the rendered preview", want)
+		}
+	}
+	if i := findPreviewMessage(m.messages, shared.PreviewUpdate{Sender: "bob", CreatedAt: at.Add(time.Second)}); i != -1 {
+		t.Errorf("Previews should only match their own message, got %d", i)
+	}
+}
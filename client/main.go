@@ -1,7 +1,6 @@
 package main
 
 import (
-	"crypto/tls"
 	"flag"
 	"fmt"
 	"net/url"
@@ -9,6 +8,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,15 +16,15 @@ import (
 
 	"github.com/Cod-e-Codes/marchat/client/config"
 	"github.com/Cod-e-Codes/marchat/client/crypto"
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/client/termux"
 	"github.com/Cod-e-Codes/marchat/shared"
-	"github.com/alecthomas/chroma/quick"
 
 	"os/exec"
-	"os/signal"
-	"syscall"
 
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 
 	"context"
 	"sync"
@@ -38,14 +38,14 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/gorilla/websocket"
+	"github.com/muesli/termenv"
 )
 
 const maxMessages = 100
 const maxUsersDisplay = 20
 const userListWidth = 18
-const pingPeriod = 50 * time.Second        // moved from magic number
-const reconnectMaxDelay = 30 * time.Second // for exponential backoff
 
 var mentionRegex *regexp.Regexp
 var urlRegex *regexp.Regexp
@@ -76,6 +76,10 @@ type keyMap struct {
 	TimeFormatHotkey  key.Binding
 	ClearHotkey       key.Binding
 	CodeSnippetHotkey key.Binding
+	SpellCheckHotkey  key.Binding
+	EmojiPicker       key.Binding
+	SelectOlder       key.Binding
+	SelectNewer       key.Binding
 	// Notification controls
 	NotifyDesktop key.Binding
 	// Admin UI commands
@@ -118,7 +122,7 @@ func (k keyMap) FullHelp() [][]key.Binding {
 func (k keyMap) GetCommandHelp(isAdmin, useE2E bool) [][]key.Binding {
 	commands := [][]key.Binding{
 		{k.SendFile, k.SaveFile, k.Theme, k.CodeSnippet},
-		{k.SendFileHotkey, k.ThemeHotkey, k.TimeFormatHotkey, k.ClearHotkey, k.CodeSnippetHotkey},
+		{k.SendFileHotkey, k.ThemeHotkey, k.TimeFormatHotkey, k.ClearHotkey, k.CodeSnippetHotkey, k.SpellCheckHotkey, k.EmojiPicker, k.SelectOlder, k.SelectNewer},
 	}
 
 	// Individual E2E commands removed - only global E2E encryption is supported
@@ -223,6 +227,22 @@ func newKeyMap() keyMap {
 			key.WithKeys("alt+c"),
 			key.WithHelp("alt+c", "create code snippet"),
 		),
+		SpellCheckHotkey: key.NewBinding(
+			key.WithKeys("alt+w"),
+			key.WithHelp("alt+w", "fix misspelled word"),
+		),
+		EmojiPicker: key.NewBinding(
+			key.WithKeys("alt+e"),
+			key.WithHelp("alt+e", "emoji picker"),
+		),
+		SelectOlder: key.NewBinding(
+			key.WithKeys("alt+up"),
+			key.WithHelp("alt+↑", "select an older message"),
+		),
+		SelectNewer: key.NewBinding(
+			key.WithKeys("alt+down"),
+			key.WithHelp("alt+↓", "select a newer message"),
+		),
 		// Notification controls
 		NotifyDesktop: key.NewBinding(
 			key.WithKeys("alt+n"),
@@ -284,8 +304,8 @@ func newKeyMap() keyMap {
 			key.WithHelp("alt+u", "uninstall plugin (admin)"),
 		),
 		PluginEnable: key.NewBinding(
-			key.WithKeys("alt+e"),
-			key.WithHelp("alt+e", "enable plugin (admin)"),
+			key.WithKeys("alt+o"),
+			key.WithHelp("alt+o", "enable plugin (admin)"),
 		),
 		PluginDisable: key.NewBinding(
 			key.WithKeys("alt+d"),
@@ -299,23 +319,37 @@ func newKeyMap() keyMap {
 	}
 }
 
-// sortMessagesByTimestamp ensures messages are displayed in chronological order
-// This provides client-side protection against server ordering issues
-func sortMessagesByTimestamp(messages []shared.Message) {
-	sort.Slice(messages, func(i, j int) bool {
-		// Primary sort: by timestamp
-		if !messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
-			return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+// sortMessages puts messages in the server's channel order, by sequence
+// number. Timestamps, then sender and content, only break ties: between
+// messages from servers that don't number them, and a local note and the
+// message it was placed after (see placeUnnumbered)
+func sortMessages(messages []shared.Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := &messages[i], &messages[j]
+		if a.Seq != b.Seq {
+			return a.Seq < b.Seq
 		}
-		// Secondary sort: by sender for deterministic ordering when timestamps are identical
-		if messages[i].Sender != messages[j].Sender {
-			return messages[i].Sender < messages[j].Sender
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
 		}
-		// Tertiary sort: by content for full deterministic ordering
-		return messages[i].Content < messages[j].Content
+		if a.Sender != b.Sender {
+			return a.Sender < b.Sender
+		}
+		return a.Content < b.Content
 	})
 }
 
+// placeUnnumbered gives a message the server didn't number, such as a
+// command reply or a local note, the number of the latest numbered message
+// so it sorts just after it. Numbered messages move that mark along.
+func (m *model) placeUnnumbered(msg *shared.Message) {
+	if msg.Seq == 0 {
+		msg.Seq = m.lastSeq
+		return
+	}
+	m.lastSeq = max(m.lastSeq, msg.Seq)
+}
+
 func init() {
 	mentionRegex = regexp.MustCompile(`\B@([a-zA-Z0-9_]+)\b`)
 	// URL regex pattern to match http/https URLs and common domain patterns
@@ -327,7 +361,7 @@ func init() {
 	debugLogPath := filepath.Join(configDir, "marchat-client-debug.log")
 	f, err := os.OpenFile(debugLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err == nil {
-		log.SetOutput(f)
+		log.SetOutput(shared.RedactWriter(f))
 	}
 	// If file creation fails, logs will go to stdout (but won't interfere with TUI)
 
@@ -373,16 +407,29 @@ var (
 	useE2E             = flag.Bool("e2e", false, "Enable end-to-end encryption")
 	keystorePassphrase = flag.String("keystore-passphrase", "", "Passphrase for keystore (required for E2E)")
 	skipTLSVerify      = flag.Bool("skip-tls-verify", false, "Skip TLS certificate verification")
+	registerHandler    = flag.Bool("register-url-handler", false, "Open marchat:// links with this client (desktop Linux, Windows, Termux)")
+	scan               = flag.Bool("scan", false, "Join by scanning a server's pairing QR code (Termux camera) or pasting its link")
+	inviteFlag         = flag.String("invite", "", "Invite link (marchat://...) or token from an admin's :invite create")
+	discover           = flag.Bool("discover", false, "Look for marchat servers on the local network (mDNS) and list them with your profiles")
+	joinPassphrase     = flag.String("join-passphrase", "", "Passphrase for servers that require one to join (or MARCHAT_JOIN_PASSPHRASE)")
 	quickStart         = flag.Bool("quick-start", false, "Use last connection or select from saved profiles")
 	autoConnect        = flag.Bool("auto", false, "Automatically connect using most recent profile")
 	nonInteractive     = flag.Bool("non-interactive", false, "Skip interactive prompts (require all flags)")
+	readOnly           = flag.Bool("read-only", false, "Connect as a spectator that can view the chat but not post (the server must allow spectators)")
+	kioskMode          = flag.Bool("kiosk", false, "Wall display mode: large messages without the input box or user list, reconnecting forever")
+	a11yMode           = flag.Bool("a11y", false, "Screen reader mode: plain text without panels, borders or colors, printing each message as it arrives")
+	torProxy           = flag.String("tor-proxy", "", "SOCKS5 address of the local tor for .onion servers (default $MARCHAT_TOR_PROXY or 127.0.0.1:9050)")
+	daemonMode         = flag.Bool("daemon", false, "Stay connected without the TUI, logging messages and notifying on mentions; later launches attach to it")
+	recordFile         = flag.String("record", "", "Append the session's messages and events to this journal; play it back with: marchat-client replay <file>")
 )
 
+// terminal is put back the way it was found however the client exits,
+// including panics on goroutines Bubble Tea doesn't watch
+var terminal = shared.NewTerminalGuard(os.Stdin, os.Stdout)
+
 // isTermux detects if the client is running in Termux environment
 func isTermux() bool {
-	return os.Getenv("TERMUX_VERSION") != "" ||
-		os.Getenv("PREFIX") == "/data/data/com.termux/files/usr" ||
-		(os.Getenv("ANDROID_DATA") != "" && os.Getenv("ANDROID_ROOT") != "")
+	return termux.Detected()
 }
 
 // safeClipboardOperation wraps clipboard operations with a timeout to prevent freezing
@@ -476,7 +523,7 @@ func debugEncryptAndSend(recipients []string, plaintext string, ws *websocket.Co
 		len(msg.Content), msg.Type)
 
 	// Send message
-	if err := ws.WriteJSON(msg); err != nil {
+	if err := writeFrame(ws, msg); err != nil {
 		log.Printf("ERROR: WebSocket write failed: %v", err)
 		return err
 	}
@@ -569,7 +616,7 @@ func debugWebSocketWrite(ws *websocket.Conn, msg interface{}) error {
 		}
 	}
 
-	return ws.WriteJSON(msg)
+	return writeFrame(ws, msg)
 }
 
 type model struct {
@@ -577,12 +624,14 @@ type model struct {
 	configFilePath string // Store the config file path for saving
 	textarea       textarea.Model
 	viewport       viewport.Model
+	messageSpan    lineSpan // lines of the chat the last redraw laid out
 	messages       []shared.Message
 	styles         themeStyles
 	banner         string
 	connected      bool
 
-	users []string // NEW: user list
+	users    []string // NEW: user list
+	userList UserList // the server's list users comes from, which deltas apply to
 
 	width  int // NEW: track window width
 	height int // NEW: track window height
@@ -591,6 +640,12 @@ type model struct {
 
 	twentyFourHour bool // NEW: timestamp format toggle
 
+	relativeTicking bool // a relativeTick is pending
+
+	// Planned maintenance window from the server, counted down in the banner
+	maintenance        shared.Maintenance
+	maintenanceTicking bool // a maintenanceTick is pending
+
 	sending bool // NEW: sending message feedback
 
 	conn    *websocket.Conn // persistent WebSocket connection
@@ -599,8 +654,8 @@ type model struct {
 	cancel  context.CancelFunc
 	wg      sync.WaitGroup
 
-	reconnectDelay time.Duration               // for exponential backoff
-	receivedFiles  map[string]*shared.FileMeta // filename -> filemeta for saving
+	reconnectDelay time.Duration // for exponential backoff
+	receivedFiles  *fileStore    // received files kept for :savefile and :play
 
 	// E2E Encryption
 	keystore *crypto.KeyStore
@@ -631,6 +686,92 @@ type model struct {
 	// Notification system
 	notificationManager *NotificationManager
 
+	// Approved automation hooks; history sent before connectedAt skips them
+	hooks       []config.Hook
+	connectedAt time.Time
+
+	// Highest sequence number the server has sent, see placeUnnumbered
+	lastSeq int64
+
+	// Translation hook (nil when no endpoint is configured)
+	translator      Translator
+	translateTarget string
+
+	// Composer spell-check (nil when disabled)
+	spellChecker   *SpellChecker
+	showSpellPopup bool
+	spellPopup     spellPopup
+
+	// Commands the server says this user can run, from :help, and the
+	// argument form for those that declare several required arguments
+	serverCommands  []shared.CommandInfo
+	showCommandForm bool
+	commandForm     commandForm
+
+	// Shared snippets
+	pendingSnippet    string // Long message waiting for the share-as-snippet choice
+	pendingOversize   bool   // pendingSnippet is over the server's limit, so it cannot go inline
+	idleDisconnected  bool   // the server's idle timeout closed the connection; a key press reconnects
+	showSnippetViewer bool
+	snippetViewer     viewport.Model
+	snippetInfo       shared.Snippet
+
+	// Clipboard image, or a downscaled copy of an image over the size
+	// limit, waiting for confirmation before it is sent as a file
+	pendingImage *clipboardImage
+
+	// Channel's shared notes overlay (:notes), and whether we are waiting
+	// for the server to send the notes or grant the edit lock
+	showNotes        bool
+	notes            notesOverlay
+	pendingNotes     bool
+	pendingNotesEdit bool
+
+	// Diagram composer overlay (:diagram)
+	showDiagram bool
+	diagram     diagramComposer
+
+	// Notification rules overlay (:notify rules), and the channel incoming
+	// messages are posted in as the server last named it
+	showNotifyRules bool
+	notifyRules     notifyRulesOverlay
+	channel         string
+
+	// Voice note being recorded (Enter sends, Esc cancels), and the
+	// latest one received, which :play picks when given no name
+	voiceRecorder *voiceRecorder
+	lastVoiceNote string
+
+	// Emoji picker overlay (Alt+E)
+	showEmojiPicker bool
+	emojiPicker     emojiPicker
+
+	// Set after sending :nick so the accepted name is saved to the config
+	pendingNick bool
+
+	// Slow mode interval announced by the server, and when we last posted
+	slowMode   time.Duration
+	lastPostAt time.Time
+
+	// Largest message the server accepts, announced after the handshake (0 = unknown)
+	maxMessageBytes int
+
+	// Mention throttle announced by the server (0 = none), and when we
+	// recently mentioned each @name
+	mentionLimit  int
+	mentionWindow time.Duration
+	mentionLog    map[string][]time.Time
+
+	// Server version when it does not fit this client, shown in the footer
+	incompatibleServer string
+	serverVersion      string // from the version frame, for :whois server
+
+	recorder *sessionRecorder // journals the session with --record
+	replay   *replayer        // plays a journal back instead of connecting
+
+	// Channel topic shown in the header, set by admins with :topic
+	topic string
+
 	// Plugin command input system
 	pendingPluginAction string // e.g., "install", "uninstall", "enable", "disable"
 }
@@ -669,6 +810,7 @@ func configToNotificationConfig(cfg config.Config) NotificationConfig {
 	notifCfg.QuietHoursEnabled = cfg.QuietHoursEnabled
 	notifCfg.QuietHoursStart = cfg.QuietHoursStart
 	notifCfg.QuietHoursEnd = cfg.QuietHoursEnd
+	notifCfg.Rules = cfg.NotifyRules
 
 	return notifCfg
 }
@@ -698,22 +840,22 @@ func notificationConfigToConfig(notifCfg NotificationConfig, cfg *config.Config)
 	cfg.QuietHoursEnabled = notifCfg.QuietHoursEnabled
 	cfg.QuietHoursStart = notifCfg.QuietHoursStart
 	cfg.QuietHoursEnd = notifCfg.QuietHoursEnd
+	cfg.NotifyRules = notifCfg.Rules
 }
 
 // shouldNotify determines the notification level for a message
 func (m *model) shouldNotify(msg shared.Message) (bool, NotificationLevel) {
-	// Don't notify for our own messages
-	if msg.Sender == m.cfg.Username {
+	// Don't notify for our own messages or ignored users
+	if msg.Sender == m.cfg.Username || isIgnored(msg.Sender) {
 		return false, NotificationLevelInfo
 	}
 
 	// Check if the message mentions the current user
-	mentionPattern := fmt.Sprintf("@%s", m.cfg.Username)
-	isMention := strings.Contains(strings.ToLower(msg.Content), strings.ToLower(mentionPattern))
+	isMention := mentionsUser(msg, m.cfg.Username)
 
 	// Determine notification level
 	level := NotificationLevelInfo
-	if isMention {
+	if isMention || msg.Type == shared.AnnouncementType {
 		level = NotificationLevelMention
 	}
 
@@ -725,6 +867,17 @@ func (m *model) shouldNotify(msg shared.Message) (bool, NotificationLevel) {
 	return true, level
 }
 
+// containsMention reports whether content mentions @username
+func containsMention(content, username string) bool {
+	return strings.Contains(strings.ToLower(content), "@"+strings.ToLower(username))
+}
+
+// mentionsUser reports whether msg mentions username directly or through a
+// group mention such as @here, which the server expands into msg.Mentions
+func mentionsUser(msg shared.Message, username string) bool {
+	return containsMention(msg.Content, username) || slices.Contains(msg.Mentions, strings.ToLower(username))
+}
+
 type themeStyles struct {
 	User      lipgloss.Style
 	Time      lipgloss.Style
@@ -857,6 +1010,17 @@ func getThemeStyles(theme string) themeStyles {
 }
 
 func renderMessages(msgs []shared.Message, styles themeStyles, username string, users []string, width int, twentyFourHour bool) string {
+	content, _ := renderMessageWindow(msgs, styles, username, users, width, twentyFourHour, messageWindow{})
+	return content
+}
+
+// renderMessageWindow is renderMessages laying out only the messages win
+// covers, and returns the lines it laid out
+func renderMessageWindow(msgs []shared.Message, styles themeStyles, username string, users []string, width int, twentyFourHour bool, win messageWindow) (string, lineSpan) {
+	if *kioskMode {
+		content := renderKioskMessages(msgs, styles, width, twentyFourHour)
+		return content, lineSpan{hi: strings.Count(content, "\n")}
+	}
 	const max = maxMessages
 	if len(msgs) > max {
 		msgs = msgs[len(msgs)-max:]
@@ -864,24 +1028,40 @@ func renderMessages(msgs []shared.Message, styles themeStyles, username string,
 
 	// CRITICAL FIX: Sort messages client-side to ensure consistent ordering
 	// This handles cases where server-side ordering may be inconsistent
-	sortMessagesByTimestamp(msgs)
+	sortMessages(msgs)
 
-	var b strings.Builder
+	// Messages carry the server's timestamps
+	now := serverNow()
+	var pieces []messagePiece
 	var prevDate string
+	// Compact display: the sender and time of the message heading the
+	// current group, and whether the last thing drawn was part of one
+	compact := compactGrouping()
+	stylesKey := stylesHash(styles)
+	var groupSender string
+	var groupAt time.Time
+	inGroup := false
 	for _, msg := range msgs {
 		sender := msg.Sender
-		align := lipgloss.Left
-		msgBoxStyle := lipgloss.NewStyle().Width(width - 4)
-		if sender == username {
-			align = lipgloss.Right
-			msgBoxStyle = msgBoxStyle.Background(lipgloss.Color("#222244")).Foreground(lipgloss.Color("#FFFFFF"))
-		} else {
-			msgBoxStyle = msgBoxStyle.Background(lipgloss.Color("#222222")).Foreground(lipgloss.Color("#AAAAAA"))
+		// Messages from users hidden with :ignore are counted in the footer instead
+		if isIgnored(sender) {
+			continue
+		}
+		piece := messagePiece{msg: msg, tail: "\n\n"}
+		dateStr := displayTime(msg.CreatedAt).Format("2006-01-02")
+		continued := compact > 0 && groupable(msg) && sender == groupSender && dateStr == prevDate &&
+			msg.CreatedAt.Sub(groupAt) <= compact
+		if inGroup && !continued {
+			// Space groups apart as much as normal messages are
+			piece.lead = "\n"
+			inGroup = false
+		}
+		if !continued {
+			groupSender = ""
 		}
 		// Date header if date changes
-		dateStr := msg.CreatedAt.Format("2006-01-02")
 		if dateStr != prevDate {
-			b.WriteString(styles.Time.Render(dateStr) + "\n")
+			piece.lead += styles.Time.Render(dateStr) + "\n"
 			prevDate = dateStr
 		}
 		// Time format
@@ -889,50 +1069,223 @@ func renderMessages(msgs []shared.Message, styles themeStyles, username string,
 		if !twentyFourHour {
 			timeFmt = "03:04:05 PM"
 		}
-		timestamp := styles.Time.Render(msg.CreatedAt.Format(timeFmt))
-		var content string
-		if msg.Type == shared.FileMessageType && msg.File != nil {
-			fileInfo := styles.Mention.Render("[File] ") + styles.User.Render(msg.File.Filename) + styles.Time.Render(fmt.Sprintf(" (%d bytes)", msg.File.Size))
-			content = fileInfo + "\n" + styles.Msg.Render("Type :savefile "+msg.File.Filename+" to save.")
-		} else {
-			content = renderEmojis(msg.Content)
-			// Render code blocks with syntax highlighting
-			content = renderCodeBlocks(content)
-			// Render hyperlinks
-			content = renderHyperlinks(content, styles)
-			// Improved mention highlighting: highlight if any @username in user list (case-insensitive)
-			matches := mentionRegex.FindAllStringSubmatch(msg.Content, -1)
-			highlight := false
-			for _, m := range matches {
-				if len(m) > 1 {
-					for _, u := range users {
-						if strings.EqualFold(m[1], u) {
-							highlight = true
-							break
-						}
-					}
-					if highlight {
-						break
-					}
+		timestamp := styles.Time.Render(messageTimestamp(msg, timeFmt, now))
+		if isRevealed(msg) {
+			timestamp = "▶ " + timestamp
+		}
+		switch {
+		case msg.Type == shared.AnnouncementType:
+			piece.layout = func() string { return renderAnnouncement(msg, styles, width, timestamp) }
+		case msg.Type == shared.GapMessageType && msg.Gap != nil:
+			piece.layout = func() string { return renderGap(msg.Gap, styles, width) }
+		case msg.Type == translationMessageType:
+			piece.layout = func() string {
+				return messageBoxStyle(width, sender == username).Render(styles.Time.Render("↳ " + msg.Content))
+			}
+		case msg.Type == shared.PollMessageType && msg.Poll != nil:
+			piece.layout = func() string { return renderPoll(msg.Poll, styles, width, timeFmt) }
+		default:
+			// Later messages in a compact group drop the header unless picked
+			// in selection mode, which shows their own time
+			key := renderKey{
+				message:   messageHash(msg),
+				styles:    stylesKey,
+				width:     width,
+				stamp:     timestamp,
+				name:      displayName(sender),
+				own:       sender == username,
+				highlight: mentionHighlight(msg, users),
+				header:    !continued || isRevealed(msg),
+			}
+			piece.key = &key
+			piece.layout = func() string {
+				block, final := renderMessageBlock(msg, styles, key)
+				if final {
+					messageBlocks.put(key, block)
 				}
+				return block
 			}
-			if highlight {
-				content = styles.Mention.Render(content)
-			} else {
-				content = styles.Msg.Render(content)
+			if compact > 0 && groupable(msg) {
+				if !continued {
+					groupSender, groupAt = sender, msg.CreatedAt
+				}
+				piece.tail = "\n"
+				inGroup = true
 			}
 		}
-		meta := styles.User.Render(sender) + " " + timestamp
-		wrapped := msgBoxStyle.Render(content)
-		msgBlock := lipgloss.JoinVertical(lipgloss.Left, meta, wrapped)
-		b.WriteString(msgBoxStyle.Align(align).Render(msgBlock) + "\n\n")
+		pieces = append(pieces, piece)
 	}
-	return b.String()
+
+	content, span := layoutMessageWindow(pieces, width, win)
+	messageBlocks.sweep()
+	return content, span
+}
+
+// messageBoxStyle is the bubble a message is drawn in: right-aligned and
+// shaded blue for our own messages, left-aligned and gray for others
+func messageBoxStyle(width int, own bool) lipgloss.Style {
+	style := lipgloss.NewStyle().Width(width - 4)
+	if own {
+		return style.Align(lipgloss.Right).Background(lipgloss.Color("#222244")).Foreground(lipgloss.Color("#FFFFFF"))
+	}
+	return style.Align(lipgloss.Left).Background(lipgloss.Color("#222222")).Foreground(lipgloss.Color("#AAAAAA"))
+}
+
+// mentionHighlight reports whether msg is drawn highlighted: the server
+// resolved mentions for it, or it mentions someone in the user list
+func mentionHighlight(msg shared.Message, users []string) bool {
+	if len(msg.Mentions) > 0 {
+		return true
+	}
+	for _, m := range mentionRegex.FindAllStringSubmatch(msg.Content, -1) {
+		if len(m) > 1 {
+			for _, u := range users {
+				if strings.EqualFold(m[1], u) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// renderMessageBlock lays out one chat message, art or diagram in its
+// bubble, with the name and time header unless key.header is off. This is
+// the expensive part of a redraw, so renderMessages caches the result once
+// it is final, that is once its code blocks are highlighted.
+func renderMessageBlock(msg shared.Message, styles themeStyles, key renderKey) (string, bool) {
+	box := messageBoxStyle(key.width, key.own)
+	meta := styles.User.Render(key.name) + " " + key.stamp
+	if msg.Type == shared.ArtMessageType || msg.Type == statsMessageType {
+		// Keep art and tables monospaced: clip long rows instead of wrapping them
+		art := lipgloss.NewStyle().MaxWidth(key.width - 4).Render(styles.Msg.Render(msg.Content))
+		return box.Render(lipgloss.JoinVertical(lipgloss.Left, meta, art)), true
+	}
+	if msg.Type == shared.DiagramMessageType {
+		// Diagrams arrive padded to one width; clip rather than wrap so
+		// the columns stay lined up
+		diagram := lipgloss.NewStyle().MaxWidth(key.width - 4).Render(styles.Msg.Render(msg.Content))
+		meta += " " + styles.Time.Render(i18n.T("diagram.rework_hint"))
+		return box.Render(lipgloss.JoinVertical(lipgloss.Left, meta, diagram)), true
+	}
+	var content string
+	final := true
+	if msg.Type == shared.FileMessageType && msg.File != nil {
+		fileInfo := styles.Mention.Render("[File] ") + styles.User.Render(msg.File.Filename) + styles.Time.Render(fmt.Sprintf(" (%d bytes)", msg.File.Size))
+		content = fileInfo + "\n" + styles.Msg.Render("Type :savefile "+msg.File.Filename+" to save.")
+	} else if msg.Type == shared.AudioMessageType && msg.Audio != nil {
+		voiceInfo := styles.Mention.Render("[Voice] ") + styles.User.Render("▶ "+formatVoiceDuration(msg.Audio.Duration())) + " " + styles.Time.Render(renderWaveform(msg.Audio.Waveform))
+		content = voiceInfo + "\n" + styles.Msg.Render("Type :play "+msg.Audio.Filename+" to listen.")
+	} else {
+		content = renderEmojis(msg.Content)
+		// Render code blocks with syntax highlighting
+		content, final = renderCodeBlocks(content)
+		// Render hyperlinks
+		content = renderHyperlinks(content, styles)
+		if key.highlight {
+			content = styles.Mention.Render(content)
+		} else {
+			content = styles.Msg.Render(content)
+		}
+		if msg.Preview != nil {
+			content += "\n" + renderLinkPreview(msg.Preview, styles)
+		}
+	}
+	wrapped := box.UnsetAlign().Render(content)
+	if !key.header {
+		return box.Render(wrapped), final
+	}
+	return box.Render(lipgloss.JoinVertical(lipgloss.Left, meta, wrapped)), final
+}
+
+// renderAnnouncement draws an admin announcement as a full-width banner so it
+// stands apart from the left/right aligned chat bubbles
+func renderAnnouncement(msg shared.Message, styles themeStyles, width int, timestamp string) string {
+	bannerWidth := width - 6 // leave room for the border within the message area
+	if bannerWidth < 20 {
+		bannerWidth = 20
+	}
+	title := styles.Banner.Render("ANNOUNCEMENT") + " " + styles.User.Render(displayName(msg.Sender)) + " " + timestamp
+	body := renderHyperlinks(renderEmojis(msg.Content), styles)
+	banner := lipgloss.NewStyle().
+		Width(bannerWidth).
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(styles.Banner.GetForeground()).
+		Padding(0, 1).
+		Align(lipgloss.Center)
+	return banner.Render(lipgloss.JoinVertical(lipgloss.Center, title, styles.Msg.Bold(true).Render(body)))
+}
+
+// renderGap draws a gap marker as a faint centered divider, so withheld
+// history reads as a break in the conversation rather than a message
+func renderGap(gap *shared.HistoryGap, styles themeStyles, width int) string {
+	return lipgloss.NewStyle().Width(width - 4).Align(lipgloss.Center).Render(styles.Time.Faint(true).Render("── " + gapText(gap) + " ──"))
+}
+
+// gapText says how many messages a gap marker stands for
+func gapText(gap *shared.HistoryGap) string {
+	if gap.Hidden == 1 {
+		return i18n.T("history.gap_one")
+	}
+	return i18n.T("history.gap", gap.Hidden)
+}
+
+// renderPoll draws a poll as a bordered box with one bar per option
+func renderPoll(p *shared.Poll, styles themeStyles, width int, timeFmt string) string {
+	barWidth := width / 3
+	if barWidth < 10 {
+		barWidth = 10
+	} else if barWidth > 40 {
+		barWidth = 40
+	}
+
+	total := p.TotalVotes()
+	var b strings.Builder
+	b.WriteString(styles.User.Render(fmt.Sprintf("Poll #%d", p.ID)) + styles.Time.Render(" by "+p.Creator) + "\n")
+	b.WriteString(styles.Msg.Bold(true).Render(p.Question) + "\n")
+	for i, opt := range p.Options {
+		filled, pct := 0, 0
+		if total > 0 {
+			filled = opt.Votes * barWidth / total
+			pct = opt.Votes * 100 / total
+		}
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+		b.WriteString(fmt.Sprintf("\n%d. %s\n   ", i+1, opt.Text))
+		b.WriteString(styles.Mention.Render(bar) + styles.Time.Render(fmt.Sprintf(" %d (%d%%)", opt.Votes, pct)))
+	}
+
+	var status string
+	if p.Closed {
+		status = fmt.Sprintf("Closed - %d vote(s)", total)
+	} else {
+		status = fmt.Sprintf("Open - %d vote(s) - closes %s - :vote %d <n>", total, displayTime(p.ExpiresAt).Format(timeFmt), p.ID)
+	}
+	b.WriteString("\n\n" + styles.Time.Render(status))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Box.GetBorderTopForeground()).
+		Padding(0, 1)
+	return box.Render(b.String())
+}
+
+// findPollMessage returns the index of the message holding an earlier state of
+// poll, or -1. Poll IDs restart with the server, so the creation time must match too.
+func findPollMessage(msgs []shared.Message, poll *shared.Poll) int {
+	for i, msg := range msgs {
+		if msg.Type == shared.PollMessageType && msg.Poll != nil &&
+			msg.Poll.ID == poll.ID && msg.Poll.CreatedAt.Equal(poll.CreatedAt) {
+			return i
+		}
+	}
+	return -1
 }
 
 type wsMsg struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
+
+	receivedAt time.Time // when the read loop got it
 }
 
 type wsErr error
@@ -949,7 +1302,10 @@ func (e wsUsernameError) Error() string {
 type wsConnected bool
 
 type UserList struct {
-	Users []string `json:"users"`
+	Users        []string          `json:"users"`
+	DisplayNames map[string]string `json:"display_names,omitempty"`
+	Away         []string          `json:"away,omitempty"`
+	Seq          uint64            `json:"seq,omitempty"`
 }
 
 type codeSnippetMsg struct {
@@ -960,20 +1316,47 @@ type fileSendMsg struct {
 	filePath string
 }
 
-func (m *model) connectWebSocket(serverURL string) error {
-	escapedUsername := url.QueryEscape(m.cfg.Username)
+// dialChat connects to the server and completes the handshake, answering
+// any join challenge
+func dialChat(serverURL string, cfg config.Config) (*websocket.Conn, error) {
+	escapedUsername := url.QueryEscape(cfg.Username)
 	fullURL := serverURL + "?username=" + escapedUsername
 
 	log.Printf("Attempting to connect to: %s", fullURL)
-	log.Printf("Username: %s, Admin: %v", m.cfg.Username, *isAdmin)
+	log.Printf("Username: %s, Admin: %v", cfg.Username, *isAdmin)
 	if *isAdmin {
-		log.Printf("Admin key: %s", *adminKey)
+		log.Printf("Admin key: %s", shared.Redacted)
 	}
 
 	// Create custom dialer with TLS configuration
-	dialer := websocket.DefaultDialer
-	if *skipTLSVerify {
-		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	base := *websocket.DefaultDialer
+	dialer := &base
+	tlsConfig, err := serverTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dialer.TLSClientConfig = tlsConfig
+	level, _ := compressionSettings()
+	dialer.EnableCompression = level > 0
+	if cfg.WireFormat != "json" {
+		dialer.Subprotocols = []string{shared.SubprotocolCBOR}
+	}
+	if isOnion(serverURL) {
+		if err := checkOnionTLS(serverURL, *skipTLSVerify); err != nil {
+			return nil, err
+		}
+		torDial, err := torDialContext()
+		if err != nil {
+			return nil, err
+		}
+		dialer = &websocket.Dialer{
+			NetDialContext:    torDial,
+			HandshakeTimeout:  onionDialTimeout,
+			TLSClientConfig:   tlsConfig,
+			EnableCompression: level > 0,
+			Subprotocols:      dialer.Subprotocols,
+		}
+		log.Printf("Connecting through tor at %s", torProxyAddr())
 	}
 
 	log.Printf("Attempting WebSocket connection to: %s", fullURL)
@@ -992,36 +1375,56 @@ func (m *model) connectWebSocket(serverURL string) error {
 			}
 		}
 
-		// Check if this might be a duplicate username error based on response
-		if resp != nil && resp.StatusCode == 403 {
-			log.Printf("Connection forbidden - likely duplicate username")
-			return wsUsernameError{message: "Username already taken - please choose a different username"}
-		}
+		return nil, err
+	}
 
-		return err
+	log.Printf("WebSocket connection established successfully (wire format: %q)", conn.Subprotocol())
+	if level > 0 {
+		_ = conn.SetCompressionLevel(level)
 	}
 
-	log.Printf("WebSocket connection established successfully")
-	m.conn = conn
-	m.connected = true
-	m.banner = "✅ Connected to server!"
-	m.ctx, m.cancel = context.WithCancel(context.Background())
-	m.wg.Add(1)
+	// Public servers may challenge new connections before the handshake
+	challenge := shared.ParseJoinChallenge(resp.Header)
+	passphrase := *joinPassphrase
+	if passphrase == "" {
+		passphrase = os.Getenv("MARCHAT_JOIN_PASSPHRASE")
+	}
+	if challenge.Passphrase && passphrase == "" {
+		conn.Close()
+		return nil, fmt.Errorf("this server requires a join passphrase: use --join-passphrase or MARCHAT_JOIN_PASSPHRASE")
+	}
 
 	// Send handshake as first message
 	handshake := shared.Handshake{
-		Username: m.cfg.Username,
-		Admin:    *isAdmin,
-		AdminKey: "",
+		Username:       cfg.Username,
+		Admin:          *isAdmin,
+		AdminKey:       "",
+		DisplayName:    cfg.DisplayName,
+		ReadOnly:       *readOnly,
+		ClientVersion:  shared.ClientVersion,
+		UserListDeltas: true,
 	}
-	if *isAdmin {
+	if *isAdmin && !*readOnly {
 		handshake.AdminKey = *adminKey
 	}
+	if challenge.Bits > 0 {
+		log.Printf("Solving join challenge (%d bits)", challenge.Bits)
+		handshake.ChallengeSolution = shared.SolveProofOfWork(challenge.Nonce, challenge.Bits)
+	}
+	if challenge.Passphrase {
+		handshake.Passphrase = passphrase
+	}
+	if pendingInvite != "" {
+		handshake.Invite = pendingInvite
+		pendingInvite = ""
+	}
 
 	log.Printf("Sending handshake: %+v", handshake)
-	if err := m.conn.WriteJSON(handshake); err != nil {
+	handshake.ClientTime = time.Now()
+	if err := writeFrame(conn, handshake); err != nil {
 		log.Printf("Failed to send handshake: %v", err)
-		return err
+		conn.Close()
+		return nil, err
 	}
 	log.Printf("Handshake sent successfully")
 
@@ -1029,34 +1432,36 @@ func (m *model) connectWebSocket(serverURL string) error {
 	time.Sleep(100 * time.Millisecond)
 
 	// Test if connection is still alive after handshake
-	if err := m.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+	if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 		log.Printf("Connection test failed after handshake: %v", err)
-		log.Printf("Error type: %T", err)
-
-		// Check different types of errors that might indicate connection was closed
-		if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-			if ce, ok := err.(*websocket.CloseError); ok {
-				log.Printf("Close error detected - Code: %d, Text: '%s'", ce.Code, ce.Text)
-				if strings.Contains(ce.Text, "Username already taken") || strings.Contains(ce.Text, "already taken") {
-					return wsUsernameError{message: "Username already taken - please choose a different username"}
-				}
-			}
+		// The server refuses with a close code, sometimes with details first
+		rejection := readRefusal(conn, cfg.Username)
+		conn.Close()
+		if rejection != nil {
+			return nil, rejection
 		}
+		return nil, err
+	}
+	return conn, nil
+}
 
-		// Also check for "connection closed" type errors which might indicate duplicate username
-		errStr := err.Error()
-		log.Printf("Error string: '%s'", errStr)
-		if strings.Contains(errStr, "use of closed network connection") ||
-			strings.Contains(errStr, "connection reset") ||
-			strings.Contains(errStr, "broken pipe") {
-			// Connection was closed immediately after handshake - likely duplicate username
-			log.Printf("Connection closed immediately after handshake - assuming duplicate username")
-			return wsUsernameError{message: "Username already taken - please choose a different username"}
+func (m *model) connectWebSocket(serverURL string) error {
+	// A running --daemon already holds the session; attach to it instead
+	conn := attachDaemon(serverURL, m.cfg.Username)
+	if conn == nil {
+		var err error
+		if conn, err = dialChat(serverURL, m.cfg); err != nil {
+			return err
 		}
-
-		return err
 	}
 
+	m.conn = conn
+	m.connected = true
+	m.connectedAt = serverNow()
+	m.banner = i18n.T("banner.connected")
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.wg.Add(1)
+
 	// Set pong handler
 	m.conn.SetPongHandler(func(appData string) error {
 		return nil
@@ -1064,20 +1469,23 @@ func (m *model) connectWebSocket(serverURL string) error {
 
 	// Start ping goroutine
 	go func() {
-		ticker := time.NewTicker(pingPeriod)
+		defer terminal.Recover()
+		ticker := time.NewTicker(connectionTimings().Ping)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-m.ctx.Done():
 				return
 			case <-ticker.C:
-				_ = m.conn.WriteMessage(websocket.PingMessage, nil)
+				_ = writePing(m.conn)
 			}
 		}
 	}()
 
 	go func() {
 		defer m.wg.Done()
+		defer terminal.Recover()
+		codec := shared.CodecFor(conn.Subprotocol())
 		for {
 			select {
 			case <-m.ctx.Done():
@@ -1085,23 +1493,9 @@ func (m *model) connectWebSocket(serverURL string) error {
 			default:
 				msgType, raw, err := conn.ReadMessage()
 				if err != nil {
-					// Check if it's a close error with a specific message
-					if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-						if ce, ok := err.(*websocket.CloseError); ok {
-							log.Printf("WebSocket closed: %d - %s", ce.Code, ce.Text)
-							// Check for duplicate username error
-							if strings.Contains(ce.Text, "Username already taken") {
-								m.msgChan <- wsUsernameError{message: ce.Text}
-								return
-							}
-						}
-					}
-
-					// Check for the specific "bad close code" error that indicates duplicate username
-					errStr := err.Error()
-					if strings.Contains(errStr, "bad close code") {
-						log.Printf("Detected bad close code - likely duplicate username: %v", err)
-						m.msgChan <- wsUsernameError{message: "Username already taken - please choose a different username"}
+					if rejection, ok := rejectionFromClose(err, m.cfg.Username); ok {
+						log.Printf("Connection refused by server: %v", err)
+						m.msgChan <- rejection
 						return
 					}
 
@@ -1110,75 +1504,62 @@ func (m *model) connectWebSocket(serverURL string) error {
 					return
 				}
 
-				// Handle close messages explicitly
-				if msgType == websocket.CloseMessage {
-					log.Printf("Received close message: %s", string(raw))
-					if strings.Contains(string(raw), "Username already taken") {
-						m.msgChan <- wsUsernameError{message: string(raw)}
-						return
-					}
-					m.msgChan <- wsErr(fmt.Errorf("connection closed: %s", string(raw)))
-					return
+				if msgType == websocket.BinaryMessage {
+					log.Printf("Received message: %d bytes", len(raw))
+				} else {
+					log.Printf("Received message: %s", string(raw))
 				}
 
-				log.Printf("Received message: %s", string(raw))
-
-				// Try to unmarshal as shared.Message first
-				var msg shared.Message
-				if err := json.Unmarshal(raw, &msg); err == nil {
-					if msg.Sender != "" {
-						// Check if this is an encrypted message
-						if m.useE2E && msg.Encrypted && msg.Content != "" {
-							// Try to decode base64 encrypted content (nonce + encrypted_data)
-							if decoded, err := base64.StdEncoding.DecodeString(msg.Content); err == nil && len(decoded) > 12 {
-								// This might be encrypted content, try to decrypt it
-								log.Printf("DEBUG: Detected potential encrypted content, attempting decryption")
-
-								// Extract nonce (first 12 bytes) and encrypted data (rest)
-								nonce := decoded[:12]
-								encryptedData := decoded[12:]
-
-								// Create an EncryptedMessage struct for decryption
-								encryptedMsg := shared.EncryptedMessage{
-									Sender:      msg.Sender,
-									CreatedAt:   msg.CreatedAt,
-									Encrypted:   encryptedData,
-									Nonce:       nonce,
-									IsEncrypted: true,
-									Type:        msg.Type,
-								}
-
-								conversationID := "global" // Same as sending
-								decryptedMsg, err := m.keystore.DecryptMessage(&encryptedMsg, conversationID)
-								if err != nil {
-									log.Printf("DEBUG: Failed to decrypt message: %v", err)
-									// Keep original message but mark as failed decryption
-									msg.Content = "[ENCRYPTED - DECRYPTION FAILED]"
-									m.msgChan <- msg
-									continue
-								}
-
-								log.Printf("DEBUG: Successfully decrypted message")
-								m.msgChan <- *decryptedMsg
+				switch frame := decodeFrame(codec, raw).(type) {
+				case shared.Message:
+					// Encrypted content is base64 of the nonce and ciphertext
+					if m.useE2E && frame.Encrypted && frame.Content != "" {
+						if nonce, ciphertext, ok := splitSealedContent(frame.Content); ok {
+							log.Printf("DEBUG: Detected potential encrypted content, attempting decryption")
+							encryptedMsg := shared.EncryptedMessage{
+								Sender:      frame.Sender,
+								CreatedAt:   frame.CreatedAt,
+								Encrypted:   ciphertext,
+								Nonce:       nonce,
+								IsEncrypted: true,
+								Type:        frame.Type,
+							}
+
+							conversationID := "global" // Same as sending
+							decryptedMsg, err := m.keystore.DecryptMessage(&encryptedMsg, conversationID)
+							if err != nil {
+								log.Printf("DEBUG: Failed to decrypt message: %v", err)
+								// Keep original message but mark as failed decryption
+								frame.Content = "[ENCRYPTED - DECRYPTION FAILED]"
+								m.recorder.record(frame)
+								m.msgChan <- frame
 								continue
 							}
-						}
 
-						// Regular message (not encrypted or decryption not needed)
-						m.msgChan <- msg
-						continue
+							log.Printf("DEBUG: Successfully decrypted message")
+							decryptedMsg.Seq = frame.Seq
+							m.recorder.record(*decryptedMsg)
+							m.msgChan <- *decryptedMsg
+							continue
+						}
 					}
-				}
 
-				// Then try as wsMsg
-				var ws wsMsg
-				if err := json.Unmarshal(raw, &ws); err == nil && ws.Type != "" {
-					log.Printf("Received wsMsg type: %s", ws.Type)
-					m.msgChan <- ws
-					continue
+					// Regular message (not encrypted or decryption not needed)
+					m.recorder.record(frame)
+					m.msgChan <- frame
+				case wsMsg:
+					log.Printf("Received wsMsg type: %s", frame.Type)
+					frame.receivedAt = time.Now()
+					// The server explains a refused username before closing
+					if rejection, ok := usernameRejection(frame, m.cfg.Username); ok {
+						m.msgChan <- rejection
+						return
+					}
+					m.recorder.record(frame)
+					m.msgChan <- frame
+				default:
+					log.Printf("Could not parse message: %s", string(raw))
 				}
-
-				log.Printf("Could not parse message: %s", string(raw))
 			}
 		}
 	}()
@@ -1195,9 +1576,23 @@ func (m *model) closeWebSocket() {
 	m.wg.Wait()
 }
 
+// reconnectAfter reconnects once delay has passed, longer on a phone low
+// on battery
+func (m *model) reconnectAfter(delay time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(reconnectWait(delay))
+		return m.Init()()
+	}
+}
+
 func (m *model) Init() tea.Cmd {
-	m.msgChan = make(chan tea.Msg, 10) // buffered to avoid blocking
-	m.reconnectDelay = time.Second     // reset on each Init
+	m.msgChan = make(chan tea.Msg, 10)                    // buffered to avoid blocking
+	m.reconnectDelay = connectionTimings().ReconnectDelay // reset on each Init
+	if m.replay != nil {
+		// The journal stands in for the server
+		go m.replay.play(m.msgChan)
+		return m.listenWebSocket()
+	}
 	return func() tea.Msg {
 		err := m.connectWebSocket(m.cfg.ServerURL)
 		if err != nil {
@@ -1207,6 +1602,9 @@ func (m *model) Init() tea.Cmd {
 				log.Printf("Detected username error: %s", usernameErr.message)
 				return usernameErr
 			}
+			if rejection, ok := err.(connectionRejected); ok {
+				return rejection
+			}
 			log.Printf("Returning generic wsErr")
 			return wsErr(err)
 		}
@@ -1218,16 +1616,162 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch v := msg.(type) {
 	case wsConnected:
 		m.connected = true
-		m.banner = "✅ Connected to server!"
-		m.reconnectDelay = time.Second // reset on success
+		m.banner = i18n.T("banner.connected")
+		m.reconnectDelay = connectionTimings().ReconnectDelay // reset on success
+		m.requestCommands()
+		if usingRelativeTimes() && !m.relativeTicking {
+			m.relativeTicking = true
+			return m, tea.Batch(m.listenWebSocket(), relativeTick())
+		}
 		return m, m.listenWebSocket()
+	case highlightReadyMsg:
+		m.refreshMessages()
+		return m, nil
+	case relativeTickMsg:
+		if !usingRelativeTimes() {
+			m.relativeTicking = false
+			return m, nil
+		}
+		m.refreshMessages()
+		return m, relativeTick()
+	case maintenanceTickMsg:
+		if maintenanceNotice(m.maintenance, time.Now(), m.twentyFourHour) == "" {
+			m.maintenanceTicking = false
+			return m, nil
+		}
+		return m, maintenanceTick()
 	case wsMsg:
 		if v.Type == "userlist" {
 			var ul UserList
 			if err := json.Unmarshal(v.Data, &ul); err == nil {
-				m.users = ul.Users
-				userListWidth := 18
-				m.userListViewport.SetContent(renderUserList(m.users, m.cfg.Username, m.styles, userListWidth, *isAdmin, m.selectedUserIndex))
+				m.setUserList(ul)
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "userlist_delta" {
+			var delta UserListDelta
+			if err := json.Unmarshal(v.Data, &delta); err == nil {
+				// A delta out of sequence is dropped; the next full list
+				// brings the user list back in step
+				if ul, ok := applyUserListDelta(m.userList, delta); ok {
+					m.setUserList(ul)
+				}
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "slowmode" {
+			var status struct {
+				IntervalSeconds int `json:"interval_seconds"`
+			}
+			if err := json.Unmarshal(v.Data, &status); err == nil {
+				m.slowMode = time.Duration(status.IntervalSeconds) * time.Second
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "preview" {
+			var update shared.PreviewUpdate
+			if err := json.Unmarshal(v.Data, &update); err == nil {
+				if i := findPreviewMessage(m.messages, update); i >= 0 {
+					m.messages[i].Preview = &update.Preview
+					m.refreshMessages()
+				}
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "commands" {
+			m.setServerCommands(v.Data)
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "topic" {
+			var topic shared.Topic
+			if err := json.Unmarshal(v.Data, &topic); err == nil {
+				m.applyTopic(topic)
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "notes" {
+			var notes shared.ChannelNotes
+			if err := json.Unmarshal(v.Data, &notes); err == nil {
+				m.channel = notes.Channel
+				m.applyNotes(notes)
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "stats" {
+			var stats shared.ChatStats
+			if err := json.Unmarshal(v.Data, &stats); err != nil {
+				return m, m.listenWebSocket()
+			}
+			entry := statsEntry(stats, m.twentyFourHour, time.Now())
+			m.placeUnnumbered(&entry)
+			m.messages = append(m.messages, entry)
+			sortMessages(m.messages)
+			m.refreshMessages()
+			m.viewport.GotoBottom()
+			return m, tea.Batch(m.announce(entry), m.listenWebSocket())
+		}
+		if v.Type == "maintenance" {
+			var mw shared.Maintenance
+			if err := json.Unmarshal(v.Data, &mw); err == nil {
+				m.maintenance = mw
+			}
+			if mw.Scheduled() && !m.maintenanceTicking {
+				m.maintenanceTicking = true
+				return m, tea.Batch(maintenanceTick(), m.listenWebSocket())
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "motd" {
+			var motd shared.MOTD
+			if err := json.Unmarshal(v.Data, &motd); err == nil {
+				m.applyMOTD(motd)
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "limits" {
+			var limits struct {
+				MaxMessageBytes      int `json:"max_message_bytes"`
+				MentionLimit         int `json:"mention_limit"`
+				MentionWindowSeconds int `json:"mention_window_seconds"`
+			}
+			if err := json.Unmarshal(v.Data, &limits); err == nil {
+				m.maxMessageBytes = limits.MaxMessageBytes
+				m.mentionLimit = limits.MentionLimit
+				m.mentionWindow = time.Duration(limits.MentionWindowSeconds) * time.Second
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "version" {
+			var info shared.VersionInfo
+			if err := json.Unmarshal(v.Data, &info); err == nil {
+				m.serverVersion = info.ServerVersion
+				if moved, ok := syncServerClock(info, v.receivedAt); ok {
+					// connectedAt is compared with the server's timestamps
+					m.connectedAt = m.connectedAt.Add(moved)
+					if skew := clockSkew(); skew != 0 {
+						m.banner = i18n.T("banner.clock_skew", skewDescription(skew))
+					}
+				}
+				m.incompatibleServer = ""
+				switch shared.CheckVersionCompat(shared.ClientVersion, info.ServerVersion, info.MinClientVersion) {
+				case shared.VersionMajorMismatch:
+					m.incompatibleServer = info.ServerVersion
+					m.banner = i18n.T("banner.version_major_mismatch", info.ServerVersion, shared.ClientVersion)
+				case shared.VersionClientTooOld:
+					m.incompatibleServer = info.ServerVersion
+					m.banner = i18n.T("banner.version_client_too_old", info.MinClientVersion, shared.ClientVersion)
+				}
+				if m.incompatibleServer != "" {
+					log.Printf("Version mismatch: client %s, server %s (min client %s)", shared.ClientVersion, info.ServerVersion, info.MinClientVersion)
+				}
+			}
+			return m, m.listenWebSocket()
+		}
+		if v.Type == "emoji" {
+			var emoji []shared.CustomEmoji
+			if err := json.Unmarshal(v.Data, &emoji); err == nil {
+				setCustomEmoji(emoji)
+				m.refreshMessages()
 			}
 			return m, m.listenWebSocket()
 		}
@@ -1237,6 +1781,8 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if err := json.Unmarshal(v.Data, &authFail); err == nil {
 				log.Printf("Auth failure reason: %s", authFail["reason"])
 			}
+			// Leave the alternate screen so the reason stays visible
+			terminal.Restore()
 			fmt.Printf("❌ Authentication failed: %s\n", authFail["reason"])
 			fmt.Printf("Check your --admin-key matches the server's MARCHAT_ADMIN_KEY\n")
 			os.Exit(1)
@@ -1254,14 +1800,14 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				if err := debugEncryptAndSend(recipients, v.content, m.conn, m.keystore, m.cfg.Username); err != nil {
 					log.Printf("Failed to send code snippet: %v", err)
-					m.banner = "❌ Failed to send code snippet"
+					m.banner = i18n.T("banner.code_snippet_send_failed")
 				}
 			} else {
 				// Send plain text message
 				msg := shared.Message{Sender: m.cfg.Username, Content: v.content}
 				if err := debugWebSocketWrite(m.conn, msg); err != nil {
 					log.Printf("Failed to send code snippet: %v", err)
-					m.banner = "❌ Failed to send code snippet"
+					m.banner = i18n.T("banner.code_snippet_send_failed")
 				}
 			}
 		}
@@ -1275,7 +1821,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Read the file
 			data, err := os.ReadFile(v.filePath)
 			if err != nil {
-				m.banner = "❌ Failed to read file: " + err.Error()
+				m.banner = i18n.T("banner.file_read_failed", err.Error())
 				m.sending = false
 				m.showFilePicker = false
 				return m, nil
@@ -1293,14 +1839,17 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			if int64(len(data)) > maxBytes {
+				m.sending = false
+				m.showFilePicker = false
+				if m.offerDownscaled(filepath.Base(v.filePath), data, maxBytes) {
+					return m, nil
+				}
 				// Try to format friendly message in MB when divisible, else show bytes
 				limitMsg := fmt.Sprintf("%d bytes", maxBytes)
 				if maxBytes%(1024*1024) == 0 {
 					limitMsg = fmt.Sprintf("%dMB", maxBytes/(1024*1024))
 				}
-				m.banner = "❌ File too large (max " + limitMsg + ")"
-				m.sending = false
-				m.showFilePicker = false
+				m.banner = i18n.T("banner.file_too_large", limitMsg)
 				return m, nil
 			}
 
@@ -1316,66 +1865,235 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				},
 			}
 
-			err = m.conn.WriteJSON(msg)
+			err = writeFrame(m.conn, msg)
 			if err != nil {
-				m.banner = "❌ Failed to send file (connection lost)"
+				m.banner = i18n.T("banner.file_send_connection_lost")
 				m.sending = false
 				m.showFilePicker = false
 				return m, m.listenWebSocket()
 			}
 
-			m.banner = "File sent: " + filename
+			m.banner = i18n.T("banner.file_sent", filename)
 		}
 		m.sending = false
 		m.showFilePicker = false
 		return m, m.listenWebSocket()
 	case shared.Message:
+		// Poll updates replace the earlier tally in place instead of adding a message
+		if v.Type == shared.PollMessageType && v.Poll != nil {
+			if i := findPollMessage(m.messages, v.Poll); i >= 0 {
+				// The tally keeps its place in the channel
+				v.Seq = m.messages[i].Seq
+				m.messages[i] = v
+				m.refreshMessages()
+				m.sending = false
+				return m, tea.Batch(m.announce(v), m.listenWebSocket())
+			}
+		}
+
 		// Check if we should notify for this message
-		if shouldNotify, level := m.shouldNotify(v); shouldNotify {
-			m.notificationManager.Notify(v.Sender, v.Content, level)
+		if shouldNotify, level := m.shouldNotify(v); shouldNotify && m.replay == nil {
+			m.notificationManager.NotifyMessage(v, m.notifyChannel(), level)
+		}
+		if !v.CreatedAt.Before(m.connectedAt) {
+			runHooks(m.hooks, v, m.cfg.Username, m.cfg.ServerURL)
 		}
 
 		if len(m.messages) >= maxMessages {
 			m.messages = m.messages[len(m.messages)-maxMessages+1:]
 		}
+		// Payloads go to the file store; the message keeps what is drawn.
+		// A replay has only the names and sizes.
+		if v.Type == shared.FileMessageType && v.File != nil && m.replay == nil {
+			if err := m.files().put(v.File); err != nil {
+				log.Printf("Warning: could not keep %s: %v", v.File.Filename, err)
+			}
+			if banner := m.autoSave(v); banner != "" {
+				m.banner = banner
+			}
+			v.File = &shared.FileMeta{Filename: v.File.Filename, Size: v.File.Size}
+		}
+		// Voice notes can be played with :play and saved like any file
+		if v.Type == shared.AudioMessageType && v.Audio != nil && m.replay == nil {
+			if err := m.files().put(&shared.FileMeta{
+				Filename: v.Audio.Filename,
+				Size:     int64(len(v.Audio.Data)),
+				Data:     v.Audio.Data,
+			}); err != nil {
+				log.Printf("Warning: could not keep %s: %v", v.Audio.Filename, err)
+			}
+			m.lastVoiceNote = v.Audio.Filename
+			audio := *v.Audio
+			audio.Data = nil
+			v.Audio = &audio
+		}
+		m.placeUnnumbered(&v)
 		m.messages = append(m.messages, v)
 
 		// CRITICAL FIX: Sort messages after adding new ones to maintain order
-		sortMessagesByTimestamp(m.messages)
+		sortMessages(m.messages)
 
-		if v.Type == shared.FileMessageType && v.File != nil {
-			if m.receivedFiles == nil {
-				m.receivedFiles = make(map[string]*shared.FileMeta)
-			}
-			m.receivedFiles[v.File.Filename] = v.File
-		}
-		m.viewport.SetContent(renderMessages(m.messages, m.styles, m.cfg.Username, m.users, m.viewport.Width, m.twentyFourHour))
+		m.refreshMessages()
 		m.viewport.GotoBottom()
 		m.sending = false
-		return m, m.listenWebSocket()
+		return m, tea.Batch(m.announce(v), m.listenWebSocket())
+	case replayDoneMsg:
+		m.banner = i18n.T("banner.replay_done", v.events)
+		return m, nil
+	case voiceRecordedMsg:
+		m.voiceRecorder = nil
+		switch {
+		case v.cancelled:
+			m.banner = i18n.T("banner.voice_cancelled")
+		case v.err != nil:
+			m.banner = voiceError(v.err)
+		case m.conn == nil:
+			m.banner = i18n.T("banner.not_connected")
+		default:
+			msg := shared.Message{
+				Sender:    m.cfg.Username,
+				Type:      shared.AudioMessageType,
+				CreatedAt: time.Now(),
+				Audio:     v.audio,
+			}
+			if err := writeFrame(m.conn, msg); err != nil {
+				m.banner = i18n.T("banner.file_send_connection_lost")
+				return m, m.listenWebSocket()
+			}
+			m.banner = i18n.T("banner.voice_sent", formatVoiceDuration(v.audio.Duration()))
+		}
+		return m, nil
+	case voicePlayedMsg:
+		if v.err != nil {
+			m.banner = voiceError(v.err)
+		}
+		return m, nil
+	case translationResultMsg:
+		if v.err != nil {
+			m.banner = "❌ " + v.err.Error()
+			return m, nil
+		}
+		m.messages = insertTranslation(m.messages, v.original, v.translation, v.target)
+		m.refreshMessages()
+		m.banner = ""
+		return m, m.announce(shared.Message{Type: translationMessageType, Content: v.translation.Text})
+	case snippetLoadedMsg:
+		if v.err != nil {
+			m.banner = "❌ " + v.err.Error()
+			return m, nil
+		}
+		width, height := m.width-12, m.height-12
+		if width < 40 {
+			width = 40
+		}
+		if height < 10 {
+			height = 10
+		}
+		m.snippetInfo = v.snippet
+		m.snippetViewer = newSnippetViewer(v.snippet, width, height)
+		m.showSnippetViewer = true
+		m.banner = ""
+		return m, nil
 	case wsUsernameError:
 		log.Printf("Handling wsUsernameError: %s", v.message)
 		m.connected = false
-		m.banner = "❌ " + v.message + " - Please restart with a different username"
 		m.closeWebSocket()
+		if *kioskMode {
+			// Nobody is at a kiosk to pick another name; the old session
+			// usually goes away on its own
+			m.banner = i18n.T("banner.username_error_retrying", v.message)
+			return m, tea.Tick(connectionTimings().ReconnectMax, func(time.Time) tea.Msg {
+				return m.Init()()
+			})
+		}
+		m.banner = i18n.T("banner.username_error", v.message)
 		// Don't attempt to reconnect for username errors
 		return m, nil
+	case connectionRejected:
+		log.Printf("Connection refused by server: code %d (%s)", v.code, v.reason)
+		m.connected = false
+		m.closeWebSocket()
+		m.banner = v.guidance()
+		if v.code == shared.CloseIdle && !*kioskMode {
+			// Coming straight back would defeat the server's idle timeout
+			m.idleDisconnected = true
+			return m, nil
+		}
+		if !v.retry() {
+			return m, nil
+		}
+		delay := m.reconnectDelay
+		if maxDelay := connectionTimings().ReconnectMax; delay < maxDelay {
+			m.reconnectDelay = min(m.reconnectDelay*2, maxDelay)
+		}
+		return m, m.reconnectAfter(delay)
 	case wsErr:
+		var pinErr certPinError
+		if errors.As(v, &pinErr) {
+			// Retrying would only hammer a server we must not talk to
+			m.connected = false
+			m.closeWebSocket()
+			m.banner = i18n.T("banner.cert_pin_mismatch", pinErr.got)
+			return m, nil
+		}
+		if delay, ok := maintenanceReconnectDelay(m.maintenance, time.Now()); ok {
+			// The server is down for planned maintenance: wait it out
+			m.connected = false
+			m.closeWebSocket()
+			m.banner = i18n.T("banner.maintenance_offline")
+			return m, tea.Tick(delay, func(time.Time) tea.Msg {
+				return m.Init()()
+			})
+		}
+		var closeErr *websocket.CloseError
+		if errors.As(v, &closeErr) && closeErr.Code == websocket.CloseServiceRestart {
+			// The server is draining for a redeploy: come straight back
+			m.connected = false
+			m.closeWebSocket()
+			m.banner = i18n.T("banner.server_restarting")
+			m.reconnectDelay = connectionTimings().ReconnectDelay
+			return m, tea.Tick(restartReconnectDelay(), func(time.Time) tea.Msg {
+				return m.Init()()
+			})
+		}
 		m.connected = false
-		m.banner = "🚫 Connection lost. Reconnecting..."
+		m.banner = i18n.T("banner.connection_lost_reconnecting")
 		m.closeWebSocket()
 		delay := m.reconnectDelay
-		if delay < reconnectMaxDelay {
-			m.reconnectDelay *= 2
-			if m.reconnectDelay > reconnectMaxDelay {
-				m.reconnectDelay = reconnectMaxDelay
-			}
+		if maxDelay := connectionTimings().ReconnectMax; delay < maxDelay {
+			m.reconnectDelay = min(m.reconnectDelay*2, maxDelay)
 		}
-		return m, tea.Tick(delay, func(time.Time) tea.Msg {
-			return m.Init()()
-		})
+		return m, m.reconnectAfter(delay)
 	case tea.KeyMsg:
+		// A kiosk has no input; Esc is the only way out
+		if *kioskMode {
+			if key.Matches(v, m.keys.Quit) {
+				m.closeWebSocket()
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		if m.replay != nil {
+			if cmd, handled := m.replayKey(v); handled {
+				return m, cmd
+			}
+		}
+		// After an idle disconnect the first key brings the session back
+		if m.idleDisconnected && !key.Matches(v, m.keys.Quit) {
+			m.idleDisconnected = false
+			m.banner = i18n.T("banner.reconnecting")
+			return m, m.Init()
+		}
 		switch {
+		case m.showNotes:
+			// Shared notes overlay, viewing or editing
+			return m, m.updateNotes(v)
+		case m.showDiagram:
+			// Diagram composer takes every key, ctrl+l and ctrl+a included
+			m.updateDiagram(v)
+			return m, nil
+		case m.showNotifyRules:
+			return m, m.updateNotifyRules(v)
 		case key.Matches(v, m.keys.Help):
 			// Close any open menus first
 			if m.showDBMenu {
@@ -1391,6 +2109,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Set help content when help is shown
 				m.helpViewport.SetContent(m.generateHelpContent())
 				m.helpViewport.GotoTop()
+				m.requestCommands()
 			}
 			return m, nil
 		case m.showCodeSnippet:
@@ -1409,12 +2128,169 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.filePickerModel = fpModel
 			}
 			return m, cmd
+		case m.showSnippetViewer:
+			// Handle snippet viewer overlay
+			switch v.String() {
+			case "esc", "q", "ctrl+c":
+				m.showSnippetViewer = false
+			case "c":
+				if viaTerminal, err := writeClipboard(m.snippetInfo.Content); err != nil {
+					m.banner = i18n.T("banner.snippet_copy_failed", err.Error())
+				} else {
+					m.banner = copiedBanner(i18n.T("banner.snippet_copied", m.snippetInfo.ID), viaTerminal)
+				}
+			default:
+				var cmd tea.Cmd
+				m.snippetViewer, cmd = m.snippetViewer.Update(v)
+				return m, cmd
+			}
+			return m, nil
+		case m.pendingSnippet != "":
+			// Long message: share it as a snippet, send it inline, or keep editing
+			text := m.pendingSnippet
+			switch v.String() {
+			case "y":
+				m.pendingSnippet = ""
+				if m.conn == nil {
+					m.banner = i18n.T("banner.not_connected")
+					return m, nil
+				}
+				if err := debugWebSocketWrite(m.conn, snippetUpload(m.cfg.Username, text)); err != nil {
+					m.banner = i18n.T("banner.send_connection_lost")
+					return m, m.listenWebSocket()
+				}
+				m.banner = ""
+				m.textarea.SetValue("")
+			case "n":
+				if m.pendingOversize {
+					// The server would refuse it inline
+					return m, nil
+				}
+				m.pendingSnippet = ""
+				if m.conn == nil {
+					m.banner = i18n.T("banner.not_connected")
+					return m, nil
+				}
+				if err := debugWebSocketWrite(m.conn, shared.Message{Sender: m.cfg.Username, Content: text}); err != nil {
+					m.banner = i18n.T("banner.send_connection_lost")
+					return m, m.listenWebSocket()
+				}
+				m.banner = ""
+				m.textarea.SetValue("")
+			case "esc", "ctrl+c":
+				m.pendingSnippet = ""
+				m.banner = ""
+			}
+			if m.pendingSnippet == "" {
+				m.pendingOversize = false
+			}
+			return m, nil
+		case m.voiceRecorder != nil:
+			// Recording a voice note: Enter sends it, Esc throws it away
+			switch v.String() {
+			case "enter":
+				m.voiceRecorder.stop()
+				m.banner = i18n.T("banner.voice_encoding")
+			case "esc", "ctrl+c":
+				m.voiceRecorder.cancel()
+			}
+			return m, nil
+		case m.pendingImage != nil:
+			// Pending image: send it as a file message or drop it
+			img := m.pendingImage
+			switch v.String() {
+			case "y":
+				m.pendingImage = nil
+				if m.conn == nil {
+					m.banner = i18n.T("banner.not_connected")
+					return m, nil
+				}
+				msg := shared.Message{
+					Sender:    m.cfg.Username,
+					Type:      shared.FileMessageType,
+					CreatedAt: time.Now(),
+					File: &shared.FileMeta{
+						Filename: img.filename,
+						Size:     int64(len(img.data)),
+						Data:     img.data,
+					},
+				}
+				if err := writeFrame(m.conn, msg); err != nil {
+					m.banner = i18n.T("banner.file_send_connection_lost")
+					return m, m.listenWebSocket()
+				}
+				m.banner = i18n.T("banner.file_sent", img.filename)
+			case "n", "esc", "ctrl+c":
+				m.pendingImage = nil
+				m.banner = i18n.T("banner.image_paste_cancelled")
+			}
+			return m, nil
+		case m.showSpellPopup:
+			// Handle spelling corrections popup
+			target := m.spellPopup.target
+			switch k := v.String(); k {
+			case "esc", "ctrl+c":
+				m.showSpellPopup = false
+			case "a":
+				if err := addToPersonalDictionary(m.spellChecker, filepath.Dir(m.configFilePath), target.Word); err != nil {
+					m.banner = i18n.T("banner.word_save_failed", err.Error())
+				} else {
+					m.banner = i18n.T("banner.word_added", target.Word)
+				}
+				m.showSpellPopup = false
+			case "i":
+				m.spellChecker.AddWord(target.Word)
+				m.showSpellPopup = false
+			default:
+				if n, err := strconv.Atoi(k); err == nil && n >= 1 && n <= len(m.spellPopup.suggestions) {
+					m.textarea.SetValue(replaceMisspelling(m.textarea.Value(), target, m.spellPopup.suggestions[n-1]))
+					m.showSpellPopup = false
+				}
+			}
+			return m, nil
+		case m.showCommandForm:
+			return m, m.updateCommandForm(v)
+		case m.showEmojiPicker:
+			// Emoji picker: typing filters, enter inserts the selection
+			switch v.String() {
+			case "esc", "ctrl+c":
+				m.showEmojiPicker = false
+			case "enter":
+				if e, ok := m.emojiPicker.selected(); ok {
+					m.textarea.InsertString(e.insertText())
+					m.cfg.RecentEmoji = addRecentEmoji(m.cfg.RecentEmoji, e.insertText())
+					_ = config.SaveConfig(m.configFilePath, m.cfg)
+				}
+				m.showEmojiPicker = false
+			case "up":
+				m.emojiPicker.move(-1)
+			case "down":
+				m.emojiPicker.move(1)
+			case "pgup":
+				m.emojiPicker.move(-emojiPickerRows)
+			case "pgdown":
+				m.emojiPicker.move(emojiPickerRows)
+			case "backspace":
+				if q := []rune(m.emojiPicker.query); len(q) > 0 {
+					m.emojiPicker.setQuery(string(q[:len(q)-1]), m.cfg.RecentEmoji)
+				}
+			default:
+				if v.Type == tea.KeyRunes || v.Type == tea.KeySpace {
+					m.emojiPicker.setQuery(m.emojiPicker.query+string(v.Runes), m.cfg.RecentEmoji)
+				}
+			}
+			return m, nil
 		case key.Matches(v, m.keys.Quit):
+			// Leave message selection first
+			if m.selectedMessage() >= 0 {
+				m.clearMessageSelection()
+				return m, nil
+			}
 			// If waiting for plugin input, cancel it
 			if m.pendingPluginAction != "" {
 				m.pendingPluginAction = ""
 				m.textarea.SetValue("")
-				m.banner = "Plugin action cancelled"
+				m.banner = i18n.T("banner.plugin_action_cancelled")
 				return m, nil
 			}
 			// If help is open, close it instead of quitting
@@ -1521,21 +2397,21 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Show theme info in banner
 			themeInfo := GetThemeInfo(m.cfg.Theme)
-			m.banner = fmt.Sprintf("Theme: %s", themeInfo)
+			m.banner = i18n.T("banner.theme_cycled", themeInfo)
 			return m, nil
 		case key.Matches(v, m.keys.TimeFormatHotkey):
-			// Toggle time format
-			m.twentyFourHour = !m.twentyFourHour
-			m.cfg.TwentyFourHour = m.twentyFourHour
-			_ = config.SaveConfig(m.configFilePath, m.cfg)
-			m.banner = "Timestamp format: " + map[bool]string{true: "24h", false: "12h"}[m.twentyFourHour]
-			m.viewport.SetContent(renderMessages(m.messages, m.styles, m.cfg.Username, m.users, m.viewport.Width, m.twentyFourHour))
+			return m, m.cycleTimeFormat()
+		case key.Matches(v, m.keys.SelectOlder):
+			m.selectMessage(-1)
+			return m, nil
+		case key.Matches(v, m.keys.SelectNewer):
+			m.selectMessage(1)
 			return m, nil
 		case key.Matches(v, m.keys.ClearHotkey):
 			// Clear chat history
 			m.messages = nil
 			m.viewport.SetContent("")
-			m.banner = "Chat cleared."
+			m.banner = i18n.T("banner.chat_cleared")
 			return m, nil
 		case key.Matches(v, m.keys.CodeSnippetHotkey):
 			// Launch code snippet interface
@@ -1553,18 +2429,38 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.showCodeSnippet = false
 				})
 			return m, nil
+		case key.Matches(v, m.keys.EmojiPicker):
+			m.emojiPicker = newEmojiPicker(m.cfg.RecentEmoji)
+			m.showEmojiPicker = true
+			return m, nil
+		case key.Matches(v, m.keys.SpellCheckHotkey):
+			if m.spellChecker == nil {
+				m.banner = i18n.T("banner.spellcheck_off")
+				return m, nil
+			}
+			misspellings := m.spellChecker.Check(m.textarea.Value(), m.users)
+			if len(misspellings) == 0 {
+				m.banner = i18n.T("banner.no_spelling_mistakes")
+				return m, nil
+			}
+			m.spellPopup = spellPopup{
+				target:      misspellings[0],
+				suggestions: m.spellChecker.Suggest(misspellings[0].Word, maxSpellSuggestions),
+			}
+			m.showSpellPopup = true
+			return m, nil
 		case key.Matches(v, m.keys.NotifyDesktop):
 			// Toggle desktop notifications (Alt+N)
 			if !m.notificationManager.IsDesktopSupported() {
-				m.banner = "Desktop notifications not supported on this platform"
+				m.banner = i18n.T("banner.desktop_unsupported")
 			} else {
 				enabled := m.notificationManager.ToggleDesktop()
-				status := "disabled"
+				status := i18n.T("state.disabled")
 				if enabled {
-					status = "enabled"
+					status = i18n.T("state.enabled")
 					m.notificationManager.Notify("System", "Desktop notifications enabled", NotificationLevelInfo)
 				}
-				m.banner = fmt.Sprintf("Desktop notifications %s", status)
+				m.banner = i18n.T("banner.desktop_notifications_toggled", status)
 				// Save to config
 				notifCfg := m.notificationManager.GetConfig()
 				notificationConfigToConfig(notifCfg, &m.cfg)
@@ -1579,7 +2475,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedUserIndex = (m.selectedUserIndex + 1) % len(m.users)
 					if m.users[m.selectedUserIndex] != m.cfg.Username {
 						m.selectedUser = m.users[m.selectedUserIndex]
-						m.banner = fmt.Sprintf("Selected user: %s", m.selectedUser)
+						m.banner = i18n.T("banner.selected_user", m.selectedUser)
 						break
 					}
 				}
@@ -1587,7 +2483,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.users[m.selectedUserIndex] == m.cfg.Username {
 					m.selectedUserIndex = -1
 					m.selectedUser = ""
-					m.banner = "No other users to select"
+					m.banner = i18n.T("banner.no_users_to_select")
 				}
 			}
 			return m, nil
@@ -1623,6 +2519,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.helpViewport.ScrollUp(1)
 			} else if m.textarea.Focused() {
 				m.viewport.ScrollUp(1)
+				m.followMessageWindow()
 			} else {
 				m.userListViewport.ScrollUp(1)
 			}
@@ -1632,6 +2529,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.helpViewport.ScrollDown(1)
 			} else if m.textarea.Focused() {
 				m.viewport.ScrollDown(1)
+				m.followMessageWindow()
 			} else {
 				m.userListViewport.ScrollDown(1)
 			}
@@ -1641,6 +2539,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.helpViewport.ScrollUp(m.helpViewport.Height)
 			} else {
 				m.viewport.ScrollUp(m.viewport.Height)
+				m.followMessageWindow()
 			}
 			return m, nil
 		case key.Matches(v, m.keys.PageDown):
@@ -1648,26 +2547,24 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.helpViewport.ScrollDown(m.helpViewport.Height)
 			} else {
 				m.viewport.ScrollDown(m.viewport.Height)
+				m.followMessageWindow()
 			}
 			return m, nil
 		case key.Matches(v, m.keys.Copy): // Custom Copy
 			if m.textarea.Focused() {
 				text := m.textarea.Value()
 				if text != "" {
-					err := safeClipboardOperation(func() error {
-						return clipboard.WriteAll(text)
-					}, 2*time.Second)
-
+					viaTerminal, err := writeClipboard(text)
 					if err != nil {
 						if isTermux() {
-							m.banner = fmt.Sprintf("⚠️ Clipboard unavailable in Termux. Text: %s", text)
+							m.banner = i18n.T("banner.clipboard_termux_copy", text)
 						} else if err == context.DeadlineExceeded {
-							m.banner = "⚠️ Clipboard operation timed out"
+							m.banner = i18n.T("banner.clipboard_timeout")
 						} else {
-							m.banner = "❌ Failed to copy to clipboard: " + err.Error()
+							m.banner = i18n.T("banner.copy_failed", err.Error())
 						}
 					} else {
-						m.banner = "✅ Copied to clipboard"
+						m.banner = copiedBanner(i18n.T("banner.copied"), viaTerminal)
 					}
 				}
 				return m, nil
@@ -1675,24 +2572,33 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case key.Matches(v, m.keys.Paste): // Custom Paste
 			if m.textarea.Focused() {
-				var text string
-				err := safeClipboardOperation(func() error {
+				// Images can't be pasted as text; offer to send them as a file instead
+				var img *clipboardImage
+				if err := safeClipboardOperation(func() error {
 					var readErr error
-					text, readErr = clipboard.ReadAll()
+					img, readErr = readClipboardImage()
 					return readErr
-				}, 2*time.Second)
+				}, 3*time.Second); err == nil {
+					m.offerClipboardImage(img)
+					return m, nil
+				}
 
+				text, err := readClipboard()
 				if err != nil {
 					if isTermux() {
-						m.banner = "⚠️ Clipboard unavailable in Termux. Paste manually or use other methods."
+						m.banner = i18n.T("banner.clipboard_termux_paste")
 					} else if err == context.DeadlineExceeded {
-						m.banner = "⚠️ Clipboard operation timed out"
+						m.banner = i18n.T("banner.clipboard_timeout")
 					} else {
-						m.banner = "❌ Failed to paste from clipboard: " + err.Error()
+						m.banner = i18n.T("banner.paste_failed", err.Error())
 					}
+				} else if img := imageFromPath(text); img != nil {
+					m.offerClipboardImage(img)
+				} else if looksBinary(text) {
+					m.banner = i18n.T("banner.paste_binary")
 				} else {
 					m.textarea.SetValue(m.textarea.Value() + text)
-					m.banner = "✅ Pasted from clipboard"
+					m.banner = i18n.T("banner.pasted")
 				}
 				return m, nil
 			}
@@ -1701,20 +2607,17 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.textarea.Focused() {
 				text := m.textarea.Value()
 				if text != "" {
-					err := safeClipboardOperation(func() error {
-						return clipboard.WriteAll(text)
-					}, 2*time.Second)
-
+					viaTerminal, err := writeClipboard(text)
 					if err != nil {
 						if isTermux() {
-							m.banner = fmt.Sprintf("⚠️ Clipboard unavailable in Termux. Text cleared: %s", text)
+							m.banner = i18n.T("banner.clipboard_termux_cut", text)
 						} else if err == context.DeadlineExceeded {
-							m.banner = "⚠️ Clipboard operation timed out"
+							m.banner = i18n.T("banner.clipboard_timeout")
 						} else {
-							m.banner = "❌ Failed to cut to clipboard: " + err.Error()
+							m.banner = i18n.T("banner.cut_failed", err.Error())
 						}
 					} else {
-						m.banner = "✅ Cut to clipboard"
+						m.banner = copiedBanner(i18n.T("banner.cut"), viaTerminal)
 					}
 					m.textarea.SetValue("")
 				}
@@ -1725,20 +2628,17 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.textarea.Focused() {
 				text := m.textarea.Value()
 				if text != "" {
-					err := safeClipboardOperation(func() error {
-						return clipboard.WriteAll(text)
-					}, 2*time.Second)
-
+					viaTerminal, err := writeClipboard(text)
 					if err != nil {
 						if isTermux() {
-							m.banner = fmt.Sprintf("⚠️ Clipboard unavailable in Termux. Full text: %s", text)
+							m.banner = i18n.T("banner.clipboard_termux_select_all", text)
 						} else if err == context.DeadlineExceeded {
-							m.banner = "⚠️ Clipboard operation timed out"
+							m.banner = i18n.T("banner.clipboard_timeout")
 						} else {
-							m.banner = "❌ Failed to select all: " + err.Error()
+							m.banner = i18n.T("banner.failed_select_all", err.Error())
 						}
 					} else {
-						m.banner = "✅ Selected all and copied to clipboard"
+						m.banner = copiedBanner(i18n.T("banner.selected_all"), viaTerminal)
 					}
 				}
 				return m, nil
@@ -1747,11 +2647,18 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(v, m.keys.Send):
 			text := m.textarea.Value()
 
+			// Spectators can still run local commands, but not chat
+			if *readOnly && text != "" && !strings.HasPrefix(strings.TrimSpace(text), ":") {
+				m.banner = i18n.T("banner.read_only")
+				m.textarea.SetValue("")
+				return m, nil
+			}
+
 			// Check if we're waiting for plugin name input
 			if m.pendingPluginAction != "" {
 				pluginName := strings.TrimSpace(text)
 				if pluginName == "" {
-					m.banner = "❌ Plugin name cannot be empty"
+					m.banner = i18n.T("banner.plugin_name_empty")
 					m.textarea.SetValue("")
 					m.pendingPluginAction = ""
 					return m, nil
@@ -1806,7 +2713,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						// Send file with provided path (existing functionality)
 						data, err := os.ReadFile(path)
 						if err != nil {
-							m.banner = "❌ Failed to read file: " + err.Error()
+							m.banner = i18n.T("banner.file_read_failed", err.Error())
 							m.textarea.SetValue("")
 							return m, nil
 						}
@@ -1822,12 +2729,15 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							}
 						}
 						if int64(len(data)) > maxBytes {
+							m.textarea.SetValue("")
+							if m.offerDownscaled(filepath.Base(path), data, maxBytes) {
+								return m, nil
+							}
 							limitMsg := fmt.Sprintf("%d bytes", maxBytes)
 							if maxBytes%(1024*1024) == 0 {
 								limitMsg = fmt.Sprintf("%dMB", maxBytes/(1024*1024))
 							}
-							m.banner = "❌ File too large (max " + limitMsg + ")"
-							m.textarea.SetValue("")
+							m.banner = i18n.T("banner.file_too_large", limitMsg)
 							return m, nil
 						}
 						filename := filepath.Base(path)
@@ -1842,13 +2752,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							},
 						}
 						if m.conn != nil {
-							err := m.conn.WriteJSON(msg)
+							err := writeFrame(m.conn, msg)
 							if err != nil {
-								m.banner = "❌ Failed to send file (connection lost)"
+								m.banner = i18n.T("banner.file_send_connection_lost")
 								m.textarea.SetValue("")
 								return m, m.listenWebSocket()
 							}
-							m.banner = "File sent: " + filename
+							m.banner = i18n.T("banner.file_sent", filename)
 						}
 						m.textarea.SetValue("")
 						return m, m.listenWebSocket()
@@ -1858,64 +2768,140 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if strings.HasPrefix(text, ":savefile ") {
 				filename := strings.TrimSpace(strings.TrimPrefix(text, ":savefile "))
-				if m.receivedFiles == nil || m.receivedFiles[filename] == nil {
-					m.banner = "❌ No files received yet."
+				file, err := m.files().get(filename)
+				if err != nil {
+					m.banner = i18n.T("banner.file_save_failed", err.Error())
 					m.textarea.SetValue("")
 					return m, nil
 				}
-				file := m.receivedFiles[filename]
-				// Check for duplicate filenames and append suffix if needed
-				saveName := file.Filename
-				base := saveName
-				ext := ""
-				if dot := strings.LastIndex(saveName, "."); dot != -1 {
-					base = saveName[:dot]
-					ext = saveName[dot:]
-				}
-				tryName := saveName
-				for i := 1; ; i++ {
-					if _, err := os.Stat(tryName); os.IsNotExist(err) {
-						saveName = tryName
-						break
-					}
-					tryName = fmt.Sprintf("%s[%d]%s", base, i, ext)
+				if file == nil {
+					m.banner = i18n.T("banner.no_files_received")
+					m.textarea.SetValue("")
+					return m, nil
 				}
-				err := os.WriteFile(saveName, file.Data, 0644)
-				if err != nil {
-					m.banner = "❌ Failed to save file: " + err.Error()
+				if path, renamed, err := saveReceivedFile(m.downloadDir(), file); err != nil {
+					m.banner = i18n.T("banner.file_save_failed", err.Error())
 				} else {
-					m.banner = "✅ File saved as: " + saveName
+					m.banner = saveFileBanner(file, path, renamed)
+				}
+				m.textarea.SetValue("")
+				return m, nil
+			}
+			if text == ":diagram" || text == ":diagram edit" {
+				m.textarea.SetValue("")
+				if text == ":diagram" {
+					m.openDiagramComposer("")
+					return m, nil
+				}
+				diagram, ok := m.latestDiagram()
+				if !ok {
+					m.banner = i18n.T("banner.no_diagram")
+					return m, nil
+				}
+				m.openDiagramComposer(diagram.Content)
+				return m, nil
+			}
+			if text == ":notes" || text == ":notes edit" {
+				m.textarea.SetValue("")
+				if *readOnly {
+					m.banner = i18n.T("banner.read_only")
+					return m, nil
+				}
+				if text == ":notes edit" {
+					m.requestNotesEdit()
+				} else if m.sendNotesCommand("") {
+					m.pendingNotes = true
 				}
+				return m, nil
+			}
+			if text == ":voice" {
+				m.textarea.SetValue("")
+				if m.conn == nil {
+					m.banner = i18n.T("banner.not_connected")
+					return m, nil
+				}
+				recorder, wait, err := startVoiceRecording(m.cfg)
+				if err != nil {
+					m.banner = voiceError(err)
+					return m, nil
+				}
+				m.voiceRecorder = recorder
+				m.banner = i18n.T("banner.voice_recording", formatVoiceDuration(maxVoiceRecording))
+				return m, wait
+			}
+			if text == ":play" || strings.HasPrefix(text, ":play ") {
 				m.textarea.SetValue("")
+				name := strings.TrimSpace(strings.TrimPrefix(text, ":play"))
+				if name == "" {
+					name = m.lastVoiceNote
+				}
+				file, err := m.files().get(name)
+				if err != nil {
+					m.banner = voiceError(err)
+					return m, nil
+				}
+				if file == nil {
+					m.banner = i18n.T("banner.voice_none")
+					return m, nil
+				}
+				m.banner = i18n.T("banner.voice_playing", file.Filename)
+				return m, playVoiceNote(m.cfg, file)
+			}
+			if text == ":files" || strings.HasPrefix(text, ":files ") {
+				m.textarea.SetValue("")
+				banner, err := applyFilesCommand(text, m.files())
+				if err != nil {
+					m.banner = "❌ " + err.Error()
+					return m, nil
+				}
+				m.banner = banner
+				return m, nil
+			}
+			if text == ":downloads" || strings.HasPrefix(text, ":downloads ") {
+				m.textarea.SetValue("")
+				dir, autoSave, banner, err := applyDownloadsCommand(text, m.cfg.DownloadDir, m.cfg.AutoSaveMaxBytes)
+				if err != nil {
+					m.banner = "❌ " + err.Error()
+					return m, nil
+				}
+				m.banner = banner
+				if dir != m.cfg.DownloadDir || autoSave != m.cfg.AutoSaveMaxBytes {
+					m.cfg.DownloadDir, m.cfg.AutoSaveMaxBytes = dir, autoSave
+					_ = config.SaveConfig(m.configFilePath, m.cfg)
+					if loader, err := config.NewInteractiveConfigLoader(); err == nil {
+						_ = loader.SetProfileDownloads(m.cfg.ServerURL, m.cfg.Username, dir, autoSave)
+					}
+				}
 				return m, nil
 			}
 			if text == ":themes" {
 				// List all available themes as a system message
 				themes := ListAllThemes()
 				var themeList strings.Builder
-				themeList.WriteString("📋 Available themes:\n\n")
+				themeList.WriteString(i18n.T("themes.title") + "\n\n")
 				for _, themeName := range themes {
 					themeList.WriteString("  • ")
 					themeList.WriteString(GetThemeInfo(themeName))
 					if themeName == m.cfg.Theme {
-						themeList.WriteString(" ⭐ [current]")
+						themeList.WriteString(" ⭐ " + i18n.T("themes.current"))
 					}
 					themeList.WriteString("\n")
 				}
-				themeList.WriteString("\nUse :theme <name> to switch or Ctrl+T to cycle")
+				themeList.WriteString("\n" + i18n.T("themes.hint"))
 
 				// Add as a system message
 				systemMsg := shared.Message{
 					Sender:    "System",
 					Content:   themeList.String(),
-					CreatedAt: time.Now(),
+					CreatedAt: serverNow(),
 					Type:      shared.TextMessage,
+					Seq:       m.lastSeq,
 				}
 				if len(m.messages) >= maxMessages {
 					m.messages = m.messages[len(m.messages)-maxMessages+1:]
 				}
 				m.messages = append(m.messages, systemMsg)
-				m.viewport.SetContent(renderMessages(m.messages, m.styles, m.cfg.Username, m.users, m.viewport.Width, m.twentyFourHour))
+				m.refreshMessages()
 				m.viewport.GotoBottom()
 
 				m.textarea.SetValue("")
@@ -1937,15 +2923,15 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 
 					if !themeExists {
-						m.banner = fmt.Sprintf("Theme '%s' not found. Use :themes to list available themes.", themeName)
+						m.banner = i18n.T("banner.theme_not_found", themeName)
 					} else {
 						m.cfg.Theme = themeName
 						m.styles = getThemeStyles(m.cfg.Theme)
 						_ = config.SaveConfig(m.configFilePath, m.cfg)
-						m.banner = fmt.Sprintf("Theme changed to: %s", GetThemeInfo(themeName))
+						m.banner = i18n.T("banner.theme_changed", GetThemeInfo(themeName))
 					}
 				} else {
-					m.banner = "Please provide a theme name. Use :themes to list available themes."
+					m.banner = i18n.T("banner.theme_name_missing")
 				}
 				m.textarea.SetValue("")
 				return m, nil
@@ -1953,30 +2939,77 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if text == ":clear" {
 				m.messages = nil
 				m.viewport.SetContent("")
-				m.banner = "Chat cleared."
+				m.banner = i18n.T("banner.chat_cleared")
 				m.textarea.SetValue("")
 				return m, nil
 			}
 			// Individual E2E encryption commands removed - only global E2E encryption supported
 			if text == ":time" {
-				m.twentyFourHour = !m.twentyFourHour
-				m.cfg.TwentyFourHour = m.twentyFourHour
+				m.textarea.SetValue("")
+				cmd := m.cycleTimeFormat()
+				m.viewport.GotoBottom()
+				return m, cmd
+			}
+			if text == ":compact" || strings.HasPrefix(text, ":compact ") {
+				m.textarea.SetValue("")
+				on, minutes, banner, err := applyCompactCommand(text, m.cfg.Compact, m.cfg.CompactMinutes)
+				if err != nil {
+					m.banner = "❌ " + err.Error()
+					return m, nil
+				}
+				m.banner = banner
+				m.cfg.Compact, m.cfg.CompactMinutes = on, minutes
+				setCompactMode(on, minutes)
 				_ = config.SaveConfig(m.configFilePath, m.cfg)
-				m.banner = "Timestamp format: " + map[bool]string{true: "24h", false: "12h"}[m.twentyFourHour]
-				m.viewport.SetContent(renderMessages(m.messages, m.styles, m.cfg.Username, m.users, m.viewport.Width, m.twentyFourHour))
+				m.refreshMessages()
 				m.viewport.GotoBottom()
+				return m, nil
+			}
+			if text == ":clipboard" || strings.HasPrefix(text, ":clipboard ") {
+				m.textarea.SetValue("")
+				mode, banner, err := applyClipboardCommand(text, currentClipboardMode())
+				if err != nil {
+					m.banner = "❌ " + err.Error()
+					return m, nil
+				}
+				m.banner = banner
+				if mode != m.cfg.Clipboard {
+					m.cfg.Clipboard = mode
+					_ = config.SaveConfig(m.configFilePath, m.cfg)
+				}
+				return m, nil
+			}
+			if text == ":lang" || strings.HasPrefix(text, ":lang ") {
 				m.textarea.SetValue("")
+				available := strings.Join(i18n.Locales(), ", ")
+				args := strings.Fields(text)[1:]
+				if len(args) == 0 {
+					m.banner = i18n.T("banner.locale_current", i18n.Locale(), available)
+					return m, nil
+				}
+				if len(args) > 1 || !i18n.SetLocale(args[0]) {
+					m.banner = i18n.T("banner.locale_unknown", args[0], available)
+					return m, nil
+				}
+				m.cfg.Locale = i18n.Locale()
+				_ = config.SaveConfig(m.configFilePath, m.cfg)
+				resetRenderCache()
+				m.textarea.Placeholder = i18n.T("input.placeholder")
+				if *readOnly {
+					m.textarea.Placeholder = i18n.T("input.placeholder_read_only")
+				}
+				m.banner = i18n.T("banner.locale_set", i18n.Locale())
 				return m, nil
 			}
 			if text == ":bell" {
 				enabled := m.notificationManager.ToggleBell()
-				status := "disabled"
+				status := i18n.T("state.disabled")
 				if enabled {
-					status = "enabled"
+					status = i18n.T("state.enabled")
 					// Test notification
 					m.notificationManager.Notify("System", "Bell test", NotificationLevelInfo)
 				}
-				m.banner = fmt.Sprintf("Message bell %s", status)
+				m.banner = i18n.T("banner.message_bell", status)
 				// Save to config
 				notifCfg := m.notificationManager.GetConfig()
 				notificationConfigToConfig(notifCfg, &m.cfg)
@@ -1988,13 +3021,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				enabled := m.notificationManager.ToggleBellOnMention()
 				var status string
 				if enabled {
-					status = "enabled (mention only)"
+					status = i18n.T("state.mention_only")
 					// Test notification
 					m.notificationManager.Notify("System", "Bell test", NotificationLevelMention)
 				} else {
-					status = "enabled (all messages)"
+					status = i18n.T("state.all_messages")
 				}
-				m.banner = fmt.Sprintf("Bell notifications %s", status)
+				m.banner = i18n.T("banner.bell_notifications", status)
 				// Save to config
 				notifCfg := m.notificationManager.GetConfig()
 				notificationConfigToConfig(notifCfg, &m.cfg)
@@ -2009,30 +3042,30 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch mode {
 				case "none":
 					m.notificationManager.SetMode(NotificationModeNone)
-					m.banner = "Notifications disabled"
+					m.banner = i18n.T("banner.notifications_disabled")
 				case "bell":
 					m.notificationManager.SetMode(NotificationModeBell)
-					m.banner = "Notifications: Bell only"
+					m.banner = i18n.T("banner.notifications_bell_only")
 					m.notificationManager.Notify("System", "Bell mode test", NotificationLevelInfo)
 				case "desktop":
 					if m.notificationManager.IsDesktopSupported() {
 						m.notificationManager.SetMode(NotificationModeDesktop)
-						m.banner = "Notifications: Desktop only"
+						m.banner = i18n.T("banner.notifications_desktop_only")
 						m.notificationManager.Notify("System", "Desktop notification test", NotificationLevelInfo)
 					} else {
-						m.banner = "Desktop notifications not supported on this platform"
+						m.banner = i18n.T("banner.desktop_unsupported")
 					}
 				case "both":
 					if m.notificationManager.IsDesktopSupported() {
 						m.notificationManager.SetMode(NotificationModeBoth)
-						m.banner = "Notifications: Bell + Desktop"
+						m.banner = i18n.T("banner.notifications_bell_desktop")
 						m.notificationManager.Notify("System", "Combined notification test", NotificationLevelInfo)
 					} else {
-						m.banner = "Desktop notifications not supported, using bell only"
+						m.banner = i18n.T("banner.desktop_unsupported_bell_only")
 						m.notificationManager.SetMode(NotificationModeBell)
 					}
 				default:
-					m.banner = "Usage: :notify-mode <none|bell|desktop|both>"
+					m.banner = i18n.T("banner.usage_notify_mode")
 					m.textarea.SetValue("")
 					return m, nil
 				}
@@ -2046,15 +3079,15 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			if text == ":notify-desktop" {
 				if !m.notificationManager.IsDesktopSupported() {
-					m.banner = "Desktop notifications not supported on this platform"
+					m.banner = i18n.T("banner.desktop_unsupported")
 				} else {
 					enabled := m.notificationManager.ToggleDesktop()
-					status := "disabled"
+					status := i18n.T("state.disabled")
 					if enabled {
-						status = "enabled"
+						status = i18n.T("state.enabled")
 						m.notificationManager.Notify("System", "Desktop notifications enabled", NotificationLevelInfo)
 					}
-					m.banner = fmt.Sprintf("Desktop notifications %s", status)
+					m.banner = i18n.T("banner.desktop_notifications_toggled", status)
 					// Save to config
 					notifCfg := m.notificationManager.GetConfig()
 					notificationConfigToConfig(notifCfg, &m.cfg)
@@ -2071,16 +3104,16 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					end, err2 := strconv.Atoi(parts[2])
 					if err1 == nil && err2 == nil && start >= 0 && start < 24 && end >= 0 && end < 24 {
 						m.notificationManager.SetQuietHours(true, start, end)
-						m.banner = fmt.Sprintf("Quiet hours enabled: %02d:00 to %02d:00", start, end)
+						m.banner = i18n.T("banner.quiet_hours_enabled", start, end)
 						// Save to config
 						notifCfg := m.notificationManager.GetConfig()
 						notificationConfigToConfig(notifCfg, &m.cfg)
 						_ = config.SaveConfig(m.configFilePath, m.cfg)
 					} else {
-						m.banner = "Invalid hours (use 0-23). Usage: :quiet 22 8"
+						m.banner = i18n.T("banner.quiet_hours_invalid")
 					}
 				} else {
-					m.banner = "Usage: :quiet <start-hour> <end-hour> (e.g., :quiet 22 8)"
+					m.banner = i18n.T("banner.usage_quiet")
 				}
 				m.textarea.SetValue("")
 				return m, nil
@@ -2088,7 +3121,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			if text == ":quiet-off" {
 				m.notificationManager.SetQuietHours(false, 22, 8)
-				m.banner = "Quiet hours disabled"
+				m.banner = i18n.T("banner.quiet_hours_disabled")
 				// Save to config
 				notifCfg := m.notificationManager.GetConfig()
 				notificationConfigToConfig(notifCfg, &m.cfg)
@@ -2097,35 +3130,246 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			if strings.HasPrefix(text, ":focus") {
-				parts := strings.Fields(text)
-				if len(parts) == 1 {
-					// Default to 30 minutes
-					m.notificationManager.EnableFocusMode(30 * time.Minute)
-					m.banner = "Focus mode enabled for 30 minutes"
-				} else if len(parts) == 2 {
-					durationStr := parts[1]
-					duration, err := time.ParseDuration(durationStr)
-					if err != nil {
-						m.banner = "Invalid duration. Examples: 30m, 1h, 2h30m"
-					} else {
-						m.notificationManager.EnableFocusMode(duration)
-						m.banner = fmt.Sprintf("Focus mode enabled for %s", duration)
+			if strings.HasPrefix(text, ":focus") {
+				parts := strings.Fields(text)
+				if len(parts) == 1 {
+					// Default to 30 minutes
+					m.notificationManager.EnableFocusMode(30 * time.Minute)
+					m.banner = i18n.T("banner.focus_mode_enabled_default")
+				} else if len(parts) == 2 {
+					durationStr := parts[1]
+					duration, err := time.ParseDuration(durationStr)
+					if err != nil {
+						m.banner = i18n.T("banner.focus_invalid_duration")
+					} else {
+						m.notificationManager.EnableFocusMode(duration)
+						m.banner = i18n.T("banner.focus_mode_enabled", duration)
+					}
+				} else {
+					m.banner = i18n.T("banner.usage_focus")
+				}
+				m.textarea.SetValue("")
+				return m, nil
+			}
+
+			if text == ":translate" || strings.HasPrefix(text, ":translate ") {
+				m.textarea.SetValue("")
+				if m.translator == nil {
+					m.banner = i18n.T("banner.translation_not_configured")
+					return m, nil
+				}
+				n, target, err := parseTranslateArgs(strings.Fields(text)[1:], m.translateTarget)
+				if err != nil {
+					m.banner = i18n.T("banner.usage_translate", err.Error())
+					return m, nil
+				}
+				original, ok := translatableMessage(m.messages, n)
+				if !ok {
+					m.banner = i18n.T("banner.nothing_to_translate")
+					return m, nil
+				}
+				m.banner = i18n.T("banner.translating")
+				return m, translateCmd(m.translator, original, target)
+			}
+
+			if text == ":open" || strings.HasPrefix(text, ":open ") {
+				m.textarea.SetValue("")
+				n := 1
+				if args := strings.Fields(text)[1:]; len(args) > 0 {
+					parsed, err := strconv.Atoi(args[0])
+					if err != nil || parsed < 1 || len(args) > 1 {
+						m.banner = i18n.T("banner.usage_open")
+						return m, nil
+					}
+					n = parsed
+				}
+				link, ok := linkNewestFirst(m.messages, n)
+				if !ok {
+					m.banner = i18n.T("banner.no_such_link")
+					return m, nil
+				}
+				if err := openURL(link); err != nil {
+					m.banner = i18n.T("banner.open_url_failed", err.Error())
+				} else {
+					m.banner = i18n.T("banner.opening_url", link)
+				}
+				return m, nil
+			}
+
+			if text == ":help" {
+				m.textarea.SetValue("")
+				m.showHelp = true
+				m.helpViewport.SetContent(m.generateHelpContent())
+				m.helpViewport.GotoTop()
+				m.requestCommands()
+				return m, nil
+			}
+
+			if cmd, ok := m.formFor(text); ok {
+				m.openCommandForm(cmd)
+				return m, nil
+			}
+
+			if text == ":who" {
+				m.textarea.SetValue("")
+				names := make([]string, len(m.users))
+				for i, u := range m.users {
+					names[i] = displayName(u)
+				}
+				m.banner = i18n.T("banner.who", len(names), strings.Join(names, ", "))
+				return m, nil
+			}
+
+			if text == ":whois" || strings.HasPrefix(text, ":whois ") {
+				m.textarea.SetValue("")
+				if strings.TrimSpace(strings.TrimPrefix(text, ":whois")) != "server" {
+					m.banner = i18n.T("banner.whois_usage")
+					return m, nil
+				}
+				m.banner = serverClockStatus(m.serverVersion)
+				return m, nil
+			}
+
+			if text == ":copycode" || strings.HasPrefix(text, ":copycode ") {
+				m.textarea.SetValue("")
+				n := 1
+				if args := strings.Fields(text)[1:]; len(args) > 0 {
+					parsed, err := strconv.Atoi(args[0])
+					if err != nil || parsed < 1 || len(args) > 1 {
+						m.banner = i18n.T("banner.usage_copycode")
+						return m, nil
+					}
+					n = parsed
+				}
+				blocks := codeBlocksNewestFirst(m.messages)
+				if n > len(blocks) {
+					m.banner = i18n.T("banner.too_few_code_blocks", len(blocks))
+					return m, nil
+				}
+				code := blocks[n-1]
+				if viaTerminal, err := writeClipboard(code); err != nil {
+					m.banner = i18n.T("banner.code_copy_failed", err.Error())
+				} else {
+					m.banner = copiedBanner(i18n.T("banner.code_copied", n), viaTerminal)
+				}
+				return m, nil
+			}
+
+			if art, ok, err := artCommand(text, filepath.Dir(m.configFilePath)); ok {
+				if err != nil {
+					if text == ":figlet" || text == ":figlet -f" {
+						err = fmt.Errorf("fonts: %s", strings.Join(figletFonts(filepath.Dir(m.configFilePath)), ", "))
+					}
+					m.banner = i18n.T("banner.usage_figlet", err.Error())
+					return m, nil
+				}
+				m.textarea.SetValue("")
+				if m.useE2E {
+					m.banner = i18n.T("banner.art_encrypted")
+					return m, nil
+				}
+				if m.conn != nil {
+					msg := shared.Message{Sender: m.cfg.Username, Content: art, Type: shared.ArtMessageType}
+					if err := writeFrame(m.conn, msg); err != nil {
+						m.banner = i18n.T("banner.send_connection_lost")
+						return m, m.listenWebSocket()
+					}
+				}
+				return m, nil
+			}
+
+			if text == ":snippet" || strings.HasPrefix(text, ":snippet ") {
+				m.textarea.SetValue("")
+				args := strings.Fields(text)[1:]
+				if len(args) != 1 || !snippetIDRegex.MatchString(args[0]) {
+					m.banner = i18n.T("banner.usage_snippet")
+					return m, nil
+				}
+				m.banner = i18n.T("banner.loading_snippet")
+				tlsConfig, err := serverTLSConfig(m.cfg)
+				if err != nil {
+					m.banner = err.Error()
+					return m, nil
+				}
+				return m, fetchSnippetCmd(m.cfg.ServerURL, args[0], tlsConfig)
+			}
+
+			if text == ":spellcheck" || strings.HasPrefix(text, ":spellcheck ") {
+				m.textarea.SetValue("")
+				enabled := m.spellChecker == nil
+				switch strings.TrimSpace(strings.TrimPrefix(text, ":spellcheck")) {
+				case "":
+				case "on":
+					enabled = true
+				case "off":
+					enabled = false
+				default:
+					m.banner = i18n.T("banner.usage_spellcheck")
+					return m, nil
+				}
+				m.cfg.SpellCheck = enabled
+				_ = config.SaveConfig(m.configFilePath, m.cfg)
+				if loader, err := config.NewInteractiveConfigLoader(); err == nil {
+					_ = loader.SetProfileSpellCheck(m.cfg.ServerURL, m.cfg.Username, enabled)
+				}
+				m.spellChecker = nil
+				if enabled {
+					m.spellChecker = newSpellCheckerFromConfig(m.cfg, filepath.Dir(m.configFilePath))
+				}
+				m.banner = i18n.T("banner.spellcheck_toggled", map[bool]string{true: i18n.T("state.on"), false: i18n.T("state.off")}[m.spellChecker != nil])
+				return m, nil
+			}
+
+			if text == ":ignore" || strings.HasPrefix(text, ":ignore ") || text == ":unignore" || strings.HasPrefix(text, ":unignore ") {
+				m.textarea.SetValue("")
+				ignored, banner, err := applyIgnoreCommand(text, m.cfg.Username, m.cfg.Ignored, m.users)
+				if err != nil {
+					m.banner = "❌ " + err.Error()
+					return m, nil
+				}
+				m.banner = banner
+				m.cfg.Ignored = ignored
+				setIgnoredUsers(ignored)
+				_ = config.SaveConfig(m.configFilePath, m.cfg)
+				if loader, err := config.NewInteractiveConfigLoader(); err == nil {
+					_ = loader.SetProfileIgnored(m.cfg.ServerURL, m.cfg.Username, ignored)
+				}
+				m.refreshMessages()
+				return m, nil
+			}
+
+			if text == ":tz" || strings.HasPrefix(text, ":tz ") {
+				m.textarea.SetValue("")
+				zone, withServer, banner, err := applyTZCommand(text, m.cfg.TimeZone, m.cfg.ShowServerTime)
+				if err != nil {
+					m.banner = "❌ " + err.Error()
+					return m, nil
+				}
+				m.banner = banner
+				if zone != m.cfg.TimeZone || withServer != m.cfg.ShowServerTime {
+					m.cfg.TimeZone, m.cfg.ShowServerTime = zone, withServer
+					_ = config.SaveConfig(m.configFilePath, m.cfg)
+					if loader, err := config.NewInteractiveConfigLoader(); err == nil {
+						_ = loader.SetProfileTimeZone(m.cfg.ServerURL, m.cfg.Username, zone, withServer)
 					}
-				} else {
-					m.banner = "Usage: :focus [duration] (e.g., :focus 30m, :focus 1h)"
 				}
-				m.textarea.SetValue("")
+				m.refreshMessages()
 				return m, nil
 			}
 
 			if text == ":focus-off" {
 				m.notificationManager.DisableFocusMode()
-				m.banner = "Focus mode disabled"
+				m.banner = i18n.T("banner.focus_mode_disabled")
 				m.textarea.SetValue("")
 				return m, nil
 			}
 
+			if text == ":notify rules" {
+				m.textarea.SetValue("")
+				m.notifyRules = newNotifyRulesOverlay(m.notificationManager.GetConfig().Rules)
+				m.showNotifyRules = true
+				return m, nil
+			}
 			if text == ":notify-status" {
 				notifCfg := m.notificationManager.GetConfig()
 				var mode string
@@ -2140,17 +3384,17 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					mode = "both"
 				}
 				statusLines := []string{
-					fmt.Sprintf("Mode: %s", mode),
-					fmt.Sprintf("Bell: %t (mention-only: %t)", notifCfg.BellEnabled, notifCfg.BellOnMention),
-					fmt.Sprintf("Desktop: %t (supported: %t)", notifCfg.DesktopEnabled, m.notificationManager.IsDesktopSupported()),
+					i18n.T("notify_status.mode", mode),
+					i18n.T("notify_status.bell", notifCfg.BellEnabled, notifCfg.BellOnMention),
+					i18n.T("notify_status.desktop", notifCfg.DesktopEnabled, m.notificationManager.IsDesktopSupported()),
 				}
 				if notifCfg.QuietHoursEnabled {
-					statusLines = append(statusLines, fmt.Sprintf("Quiet hours: %02d:00 - %02d:00", notifCfg.QuietHoursStart, notifCfg.QuietHoursEnd))
+					statusLines = append(statusLines, i18n.T("notify_status.quiet_hours", notifCfg.QuietHoursStart, notifCfg.QuietHoursEnd))
 				}
 				if notifCfg.FocusModeEnabled {
 					remaining := time.Until(notifCfg.FocusModeUntil)
 					if remaining > 0 {
-						statusLines = append(statusLines, fmt.Sprintf("Focus mode: active (%s remaining)", remaining.Round(time.Minute)))
+						statusLines = append(statusLines, i18n.T("notify_status.focus", remaining.Round(time.Minute)))
 					}
 				}
 				m.banner = strings.Join(statusLines, " | ")
@@ -2183,7 +3427,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.conn != nil {
 					// Check if this is a server-side command (admin/plugin) that should bypass encryption
 					// Client-side commands are handled above and never reach this point
-					clientOnlyCommands := []string{":theme", ":time", ":clear", ":bell", ":bell-mention", ":code", ":sendfile", ":savefile"}
+					clientOnlyCommands := []string{":theme", ":time", ":compact", ":clipboard", ":clear", ":bell", ":bell-mention", ":code", ":sendfile", ":savefile", ":files", ":downloads", ":voice", ":play", ":diagram", ":notify", ":translate", ":spellcheck", ":copycode", ":open", ":who", ":whois", ":lang", ":tz", ":snippet", ":figlet", ":cowsay", ":cowthink", ":ignore", ":unignore"}
 					isClientCommand := false
 					for _, cmd := range clientOnlyCommands {
 						// Check if text is exactly the command or starts with "command "
@@ -2193,31 +3437,83 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 
+					// Server-side commands available to every user (not just admins)
+					userServerCommands := []string{":sessions", ":poll", ":vote", ":schedule", ":scheduled", ":remind", ":reminders", ":emoji", ":nick", ":topic", ":motd", ":group", ":notes"}
+					isUserServerCommand := false
+					for _, cmd := range userServerCommands {
+						if text == cmd || strings.HasPrefix(text, cmd+" ") {
+							isUserServerCommand = true
+							break
+						}
+					}
+
 					// If it starts with : and is NOT a client command, it's a server command
 					// This includes both built-in admin commands and dynamic plugin commands
-					isServerCommand := *isAdmin && strings.HasPrefix(text, ":") && !isClientCommand
+					// Commands the server listed in reply to :help, such as plugin commands
+					isServerCommand := (*isAdmin && strings.HasPrefix(text, ":") && !isClientCommand) || isUserServerCommand || m.isServerCommand(text)
+
+					// Show the slow mode cooldown here rather than waiting for the server to refuse
+					if !isServerCommand && !*isAdmin {
+						if wait := slowModeRemaining(m.slowMode, m.lastPostAt, time.Now()); wait > 0 {
+							m.banner = i18n.T("banner.slow_mode_wait", int(wait.Seconds()+0.999))
+							m.sending = false
+							return m, nil
+						}
+						// Likewise hold back mentions the server would refuse as spam
+						if name, wait := mentionLimitWait(m.mentionLog, mentionTargets(text), m.mentionLimit, m.mentionWindow, time.Now()); name != "" {
+							m.banner = i18n.T("banner.mention_limit", name, int(wait.Seconds()+0.999))
+							m.sending = false
+							return m, nil
+						}
+					}
+
+					// Over the server's size limit: offer a snippet rather than have it refused
+					if m.maxMessageBytes > 0 && len(text) > m.maxMessageBytes {
+						m.sending = false
+						size, limit := formatByteSize(int64(len(text))), formatByteSize(int64(m.maxMessageBytes))
+						if isServerCommand || m.useE2E {
+							// Snippets are stored in plaintext, so encrypted chat cannot use them
+							m.banner = i18n.T("banner.message_too_long", size, limit)
+							return m, nil
+						}
+						m.pendingSnippet = text
+						m.pendingOversize = true
+						m.banner = i18n.T("banner.oversize_message_prompt", size, limit)
+						return m, nil
+					}
 
 					if isServerCommand {
+						// :emoji add may name a PNG to upload with the command
+						command, file, err := emojiCommandFile(text)
+						if err != nil {
+							m.banner = "❌ " + err.Error()
+							m.sending = false
+							return m, nil
+						}
 						// Send as admin command type to bypass encryption
 						msg := shared.Message{
 							Sender:  m.cfg.Username,
-							Content: text,
+							Content: command,
 							Type:    shared.AdminCommandType,
+							File:    file,
 						}
-						err := m.conn.WriteJSON(msg)
+						err = writeAdminCommand(m.conn, msg)
 						if err != nil {
-							m.banner = "❌ Failed to send admin command (connection lost)"
+							m.banner = i18n.T("banner.admin_command_connection_lost")
 							m.sending = false
 							return m, m.listenWebSocket()
 						}
 						m.banner = ""
+						if text == ":nick" || strings.HasPrefix(text, ":nick ") {
+							m.pendingNick = true
+						}
 					} else if m.useE2E {
 						// Use E2E encryption for global chat
 						log.Printf("DEBUG: Attempting to send global encrypted message: '%s'", text)
 
 						// Validate keystore is unlocked
 						if err := verifyKeystoreUnlocked(m.keystore); err != nil {
-							m.banner = fmt.Sprintf("❌ Keystore not unlocked: %v", err)
+							m.banner = i18n.T("banner.keystore_locked", err)
 							m.sending = false
 							m.textarea.SetValue("")
 							return m, nil
@@ -2232,7 +3528,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 						// Use the debug encryption function for global chat
 						if err := debugEncryptAndSend(recipients, text, m.conn, m.keystore, m.cfg.Username); err != nil {
-							m.banner = fmt.Sprintf("❌ Global encryption failed: %v", err)
+							m.banner = i18n.T("banner.encryption_failed", err)
 							m.sending = false
 							m.textarea.SetValue("")
 							return m, nil
@@ -2241,15 +3537,26 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						log.Printf("DEBUG: Global encrypted message sent successfully")
 						m.banner = ""
 					} else {
+						if shouldOfferSnippet(text, snippetThreshold(m.cfg)) {
+							// Leave the text in the composer until the user chooses
+							m.pendingSnippet = text
+							m.sending = false
+							m.banner = i18n.T("banner.long_message_prompt", strings.Count(text, "\n")+1)
+							return m, nil
+						}
 						// Send plain text message
 						msg := shared.Message{Sender: m.cfg.Username, Content: text}
 						if err := debugWebSocketWrite(m.conn, msg); err != nil {
-							m.banner = "❌ Failed to send (connection lost)"
+							m.banner = i18n.T("banner.send_connection_lost")
 							m.sending = false
 							return m, m.listenWebSocket()
 						}
 						m.banner = ""
 					}
+					if !isServerCommand {
+						m.lastPostAt = time.Now()
+						m.recordMentions(text, m.lastPostAt)
+					}
 				}
 				m.textarea.SetValue("")
 				return m, m.listenWebSocket()
@@ -2277,14 +3584,23 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = v.Height
 		m.help.Width = v.Width
 		chatWidth := m.width - userListWidth - 4
+		if *kioskMode {
+			chatWidth = m.width - 4
+		}
 		if chatWidth < 20 {
 			chatWidth = 20
 		}
 		m.viewport.Width = chatWidth
 		m.viewport.Height = m.height - m.textarea.Height() - 6
+		if *kioskMode {
+			m.viewport.Height = m.height - 4
+		}
+		// A terminal can report a size of 0x0 (some ptys do); a negative
+		// height makes the viewport slice out of range
+		m.viewport.Height = max(m.viewport.Height, 0)
 		m.textarea.SetWidth(chatWidth)
 		m.userListViewport.Width = userListWidth
-		m.userListViewport.Height = m.height - m.textarea.Height() - 6
+		m.userListViewport.Height = max(m.height-m.textarea.Height()-6, 0)
 
 		// Update help viewport dimensions to be responsive
 		helpWidth := m.width - 8   // Leave reasonable margins
@@ -2307,7 +3623,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.helpViewport.Width = helpWidth
 		m.helpViewport.Height = helpHeight
 
-		m.viewport.SetContent(renderMessages(m.messages, m.styles, m.cfg.Username, m.users, m.viewport.Width, m.twentyFourHour))
+		m.refreshMessages()
 		m.viewport.GotoBottom()
 		m.userListViewport.SetContent(renderUserList(m.users, m.cfg.Username, m.styles, userListWidth, *isAdmin, m.selectedUserIndex))
 		return m, nil
@@ -2324,9 +3640,9 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					clickedURL := m.findURLAtClickPosition(v.X, v.Y)
 					if clickedURL != "" {
 						if err := openURL(clickedURL); err != nil {
-							m.banner = "❌ Failed to open URL: " + err.Error()
+							m.banner = i18n.T("banner.open_url_failed", err.Error())
 						} else {
-							m.banner = "✅ Opening URL: " + clickedURL
+							m.banner = i18n.T("banner.opening_url", clickedURL)
 						}
 					}
 				}
@@ -2382,75 +3698,132 @@ func (m *model) findURLAtClickPosition(clickX, clickY int) string {
 	return ""
 }
 
+// helpEntry is one line of the help overlay: the keys or command, and the
+// catalog key of what it does
+type helpEntry struct {
+	keys string
+	desc string
+}
+
+var helpShortcuts = []helpEntry{
+	{"Ctrl+H", "help.key.ctrl_h"},
+	{"Esc", "help.key.esc"},
+	{"Enter", "help.key.enter"},
+	{"↑/↓", "help.key.arrows"},
+	{"PgUp/PgDn", "help.key.pgup_pgdn"},
+	{"Ctrl+C/V/X/A", "help.key.ctrl_c_v_x_a"},
+	{"Alt+F", "help.key.alt_f"},
+	{"Ctrl+V (image)", "help.key.ctrl_v_image"},
+	{"Alt+C", "help.key.alt_c"},
+	{"Alt+E", "help.key.alt_e"},
+	{"Ctrl+T", "help.key.ctrl_t"},
+	{"Alt+T", "help.key.alt_t"},
+	{"Alt+↑/↓", "help.key.alt_up_down"},
+	{"Alt+N", "help.key.alt_n"},
+	{"Ctrl+L", "help.key.ctrl_l"},
+}
+
+var helpCommands = []helpEntry{
+	{":help", "help.cmd.help"},
+	{":sendfile [path]", "help.cmd.sendfile"},
+	{":savefile <name>", "help.cmd.savefile"},
+	{":downloads [dir <path>|autosave <size|off>]", "help.cmd.downloads"},
+	{":files [clear]", "help.cmd.files"},
+	{":voice", "help.cmd.voice"},
+	{":diagram [edit]", "help.cmd.diagram"},
+	{":play [name]", "help.cmd.play"},
+	{":theme <name>", "help.cmd.theme"},
+	{":themes", "help.cmd.themes"},
+	{":time", "help.cmd.time"},
+	{":compact [on|off|minutes]", "help.cmd.compact"},
+	{":clipboard [auto|osc52|system]", "help.cmd.clipboard"},
+	{":lang [code]", "help.cmd.lang"},
+	{":tz [Area/City|local]", "help.cmd.tz"},
+	{":tz server on|off", "help.cmd.tz_server"},
+	{":clear", "help.cmd.clear"},
+	{":code", "help.cmd.code"},
+	{":translate [n] [lang]", "help.cmd.translate"},
+	{":copycode [n]", "help.cmd.copycode"},
+	{":open [n]", "help.cmd.open"},
+	{":who", "help.cmd.who"},
+	{":whois server", "help.cmd.whois_server"},
+	{":snippet <id>", "help.cmd.snippet"},
+	{":figlet [-f font] <text>", "help.cmd.figlet"},
+	{":spellcheck [on|off]", "help.cmd.spellcheck"},
+	{":ignore [user]", "help.cmd.ignore"},
+	{":unignore <user>", "help.cmd.unignore"},
+}
+
+var helpNotifications = []helpEntry{
+	{":bell", "help.cmd.bell"},
+	{":bell-mention", "help.cmd.bell_mention"},
+	{":notify-mode <mode>", "help.cmd.notify_mode"},
+	{":notify-desktop", "help.cmd.notify_desktop"},
+	{":notify-status", "help.cmd.notify_status"},
+	{":notify rules", "help.cmd.notify_rules"},
+	{":quiet <start> <end>", "help.cmd.quiet"},
+	{":quiet-off", "help.cmd.quiet_off"},
+	{":focus [duration]", "help.cmd.focus"},
+	{":focus-off", "help.cmd.focus_off"},
+}
+
+var helpUserManagement = []helpEntry{
+	{"Ctrl+U", "help.key.ctrl_u"},
+	{"Ctrl+K", "help.key.ctrl_k"},
+	{"Ctrl+B", "help.key.ctrl_b"},
+	{"Ctrl+F", "help.key.ctrl_f"},
+	{"Ctrl+Shift+B", "help.key.ctrl_shift_b"},
+	{"Ctrl+Shift+A", "help.key.ctrl_shift_a"},
+}
+
+var helpPlugins = []helpEntry{
+	{"Alt+P", "help.key.alt_p"},
+	{"Alt+S", "help.key.alt_s"},
+	{"Alt+R", "help.key.alt_r"},
+	{"Alt+I", "help.key.alt_i"},
+	{"Alt+U", "help.key.alt_u"},
+	{"Alt+O", "help.key.alt_o"},
+	{"Alt+D", "help.key.alt_d"},
+}
+
+var helpDatabase = []helpEntry{
+	{"Ctrl+D", "help.key.ctrl_d"},
+}
+
+// helpLines formats entries as an indented two-column list
+func helpLines(entries []helpEntry, indent string, width int) string {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s%-*s %s\n", indent, width, e.keys, i18n.T(e.desc))
+	}
+	return b.String()
+}
+
 func (m *model) generateHelpContent() string {
-	title := m.styles.HelpTitle.Render("marchat help")
+	title := m.styles.HelpTitle.Render(i18n.T("help.title"))
 
 	// Session status first
 	var sessionInfo string
 	if m.useE2E {
-		sessionInfo = "Session: 🔒 E2E Encrypted (messages are encrypted for privacy)\n"
+		sessionInfo = i18n.T("help.session_encrypted") + "\n"
 	} else {
-		sessionInfo = "Session: 🔓 Unencrypted (messages are sent in plain text)\n"
-	}
-
-	// Basic keyboard shortcuts
-	shortcuts := "\nKeyboard Shortcuts:\n"
-	shortcuts += "  Ctrl+H               Toggle this help\n"
-	shortcuts += "  Esc                  Quit / Close menus\n"
-	shortcuts += "  Enter                Send message\n"
-	shortcuts += "  ↑/↓                  Scroll chat\n"
-	shortcuts += "  PgUp/PgDn            Page through chat\n"
-	shortcuts += "  Ctrl+C/V/X/A         Copy/Paste/Cut/Select all\n"
-	shortcuts += "  Alt+F                Send file (file picker)\n"
-	shortcuts += "  Alt+C                Create code snippet\n"
-	shortcuts += "  Ctrl+T               Cycle themes\n"
-	shortcuts += "  Alt+T                Toggle 12/24h time\n"
-	shortcuts += "  Alt+N                Toggle desktop notifications\n"
-	shortcuts += "  Ctrl+L               Clear chat history\n"
-
-	// Text commands
-	commands := "\nText Commands:\n"
-	commands += "  :sendfile [path]     Send a file (or Alt+F)\n"
-	commands += "  :savefile <name>     Save received file\n"
-	commands += "  :theme <name>        Change theme (or Ctrl+T to cycle)\n"
-	commands += "  :themes              List all available themes\n"
-	commands += "  :time                Toggle 12/24h time (or Alt+T)\n"
-	commands += "  :clear               Clear chat history (or Ctrl+L)\n"
-	commands += "  :code                Create code snippet (or Alt+C)\n"
-	commands += "\nNotifications:\n"
-	commands += "  :bell                Toggle message bell\n"
-	commands += "  :bell-mention        Bell on mentions only\n"
-	commands += "  :notify-mode <mode>  Set notification mode (none/bell/desktop/both)\n"
-	commands += "  :notify-desktop      Toggle desktop notifications\n"
-	commands += "  :notify-status       Show notification settings\n"
-	commands += "  :quiet <start> <end> Enable quiet hours (e.g., :quiet 22 8)\n"
-	commands += "  :quiet-off           Disable quiet hours\n"
-	commands += "  :focus [duration]    Enable focus mode (e.g., :focus 30m)\n"
-	commands += "  :focus-off           Disable focus mode\n"
+		sessionInfo = i18n.T("help.session_unencrypted") + "\n"
+	}
+
+	shortcuts := "\n" + i18n.T("help.shortcuts") + "\n" + helpLines(helpShortcuts, "  ", 20)
+	commands := "\n" + i18n.T("help.commands") + "\n" + helpLines(helpCommands, "  ", 20)
+	commands += "\n" + i18n.T("help.server_commands") + "\n" + m.serverCommandLines(false, "  ", 20)
+	commands += "\n" + i18n.T("help.notifications") + "\n" + helpLines(helpNotifications, "  ", 20)
 
 	// Admin section
 	var adminSection string
 	if *isAdmin {
-		adminSection = "\nAdmin Features:\n"
-		adminSection += "\n  User Management:\n"
-		adminSection += "    Ctrl+U             Select/cycle user\n"
-		adminSection += "    Ctrl+K             Kick selected user (or :kick <user>)\n"
-		adminSection += "    Ctrl+B             Ban selected user (or :ban <user>)\n"
-		adminSection += "    Ctrl+F             Force disconnect (or :forcedisconnect <user>)\n"
-		adminSection += "    Ctrl+Shift+B       Unban user (or :unban <user>)\n"
-		adminSection += "    Ctrl+Shift+A       Allow user (or :allow <user>)\n"
-		adminSection += "    :cleanup           Clean stale connections\n"
-		adminSection += "\n  Plugin Management:\n"
-		adminSection += "    Alt+P              List plugins (or :list)\n"
-		adminSection += "    Alt+S              Plugin store (or :store)\n"
-		adminSection += "    Alt+R              Refresh plugins (or :refresh)\n"
-		adminSection += "    Alt+I              Install plugin (or :install <name>)\n"
-		adminSection += "    Alt+U              Uninstall plugin (or :uninstall <name>)\n"
-		adminSection += "    Alt+E              Enable plugin (or :enable <name>)\n"
-		adminSection += "    Alt+D              Disable plugin (or :disable <name>)\n"
-		adminSection += "\n  Database:\n"
-		adminSection += "    Ctrl+D             Database menu (or :cleardb, :backup, :stats)\n"
-		adminSection += "\n  Note: Both hotkeys and text commands work in encrypted sessions.\n"
+		adminSection = "\n" + i18n.T("help.admin") + "\n"
+		adminSection += "\n  " + i18n.T("help.user_management") + "\n" + helpLines(helpUserManagement, "    ", 18)
+		adminSection += "\n  " + i18n.T("help.admin_commands") + "\n" + m.serverCommandLines(true, "    ", 18)
+		adminSection += "\n  " + i18n.T("help.plugin_management") + "\n" + helpLines(helpPlugins, "    ", 18)
+		adminSection += "\n  " + i18n.T("help.database") + "\n" + helpLines(helpDatabase, "    ", 18)
+		adminSection += "\n  " + i18n.T("help.admin_note") + "\n"
 	}
 
 	return title + "\n\n" + sessionInfo + shortcuts + commands + adminSection
@@ -2469,6 +3842,15 @@ func (m *model) generateDBMenuContent() string {
 	return title + content
 }
 
+// writeAdminCommand signs an admin command with the admin key and sends it.
+// The server refuses unsigned or replayed commands from admin connections.
+func writeAdminCommand(conn *websocket.Conn, msg shared.Message) error {
+	if err := shared.SignAdminCommand(shared.AdminSigningKey(*adminKey), &msg); err != nil {
+		return err
+	}
+	return writeFrame(conn, msg)
+}
+
 // executeAdminAction performs the selected admin action
 func (m *model) executeAdminAction(action, targetUser string) (tea.Model, tea.Cmd) {
 	if !*isAdmin || targetUser == "" {
@@ -2498,11 +3880,11 @@ func (m *model) executeAdminAction(action, targetUser string) (tea.Model, tea.Cm
 			Content: command,
 			Type:    shared.AdminCommandType, // Special type for admin commands
 		}
-		err := m.conn.WriteJSON(msg)
+		err := writeAdminCommand(m.conn, msg)
 		if err != nil {
-			m.banner = "❌ Failed to send admin command"
+			m.banner = i18n.T("banner.admin_command_failed")
 		} else {
-			m.banner = fmt.Sprintf("✅ %s action sent for %s", action, targetUser)
+			m.banner = i18n.T("banner.admin_action_sent", action, targetUser)
 			// Clear selection after successful action
 			if action == "kick" || action == "ban" || action == "forcedisconnect" {
 				m.selectedUserIndex = -1
@@ -2520,9 +3902,9 @@ func (m *model) promptForUsername(action string) (tea.Model, tea.Cmd) {
 	// This is a simple implementation - could be improved with a dedicated prompt
 	switch action {
 	case "unban":
-		m.banner = "Type username to unban in chat and press Enter (prefix with :unban)"
+		m.banner = i18n.T("banner.prompt_unban")
 	case "allow":
-		m.banner = "Type username to allow in chat and press Enter (prefix with :allow)"
+		m.banner = i18n.T("banner.prompt_allow")
 	}
 	return m, nil
 }
@@ -2533,13 +3915,13 @@ func (m *model) promptForPluginName(action string) (tea.Model, tea.Cmd) {
 	m.pendingPluginAction = action
 	switch action {
 	case "install":
-		m.banner = "Enter plugin name to install (press Enter to confirm, Esc to cancel)"
+		m.banner = i18n.T("banner.prompt_plugin_install")
 	case "uninstall":
-		m.banner = "Enter plugin name to uninstall (press Enter to confirm, Esc to cancel)"
+		m.banner = i18n.T("banner.prompt_plugin_uninstall")
 	case "enable":
-		m.banner = "Enter plugin name to enable (press Enter to confirm, Esc to cancel)"
+		m.banner = i18n.T("banner.prompt_plugin_enable")
 	case "disable":
-		m.banner = "Enter plugin name to disable (press Enter to confirm, Esc to cancel)"
+		m.banner = i18n.T("banner.prompt_plugin_disable")
 	}
 	// Focus the textarea for input
 	m.textarea.Focus()
@@ -2559,11 +3941,11 @@ func (m *model) executePluginCommand(command string) (tea.Model, tea.Cmd) {
 			Content: command,
 			Type:    shared.AdminCommandType, // Use admin command type to bypass encryption
 		}
-		err := m.conn.WriteJSON(msg)
+		err := writeAdminCommand(m.conn, msg)
 		if err != nil {
-			m.banner = "❌ Failed to send plugin command (connection lost)"
+			m.banner = i18n.T("banner.plugin_command_connection_lost")
 		} else {
-			m.banner = fmt.Sprintf("✅ Sent: %s", command)
+			m.banner = i18n.T("banner.plugin_command_sent", command)
 		}
 	}
 
@@ -2585,11 +3967,11 @@ func (m *model) executeDBAction(action string) (tea.Model, tea.Cmd) {
 				Content: ":cleardb",
 				Type:    shared.AdminCommandType,
 			}
-			err := m.conn.WriteJSON(msg)
+			err := writeAdminCommand(m.conn, msg)
 			if err != nil {
-				m.banner = "❌ Failed to send cleardb command"
+				m.banner = i18n.T("banner.cleardb_failed")
 			} else {
-				m.banner = "✅ Database clear command sent"
+				m.banner = i18n.T("banner.cleardb_sent")
 			}
 		}
 	case "backup":
@@ -2599,11 +3981,11 @@ func (m *model) executeDBAction(action string) (tea.Model, tea.Cmd) {
 				Content: ":backup",
 				Type:    shared.AdminCommandType,
 			}
-			err := m.conn.WriteJSON(msg)
+			err := writeAdminCommand(m.conn, msg)
 			if err != nil {
-				m.banner = "❌ Failed to send backup command"
+				m.banner = i18n.T("banner.backup_failed")
 			} else {
-				m.banner = "✅ Database backup command sent"
+				m.banner = i18n.T("banner.backup_sent")
 			}
 		}
 	case "stats":
@@ -2613,11 +3995,11 @@ func (m *model) executeDBAction(action string) (tea.Model, tea.Cmd) {
 				Content: ":stats",
 				Type:    shared.AdminCommandType,
 			}
-			err := m.conn.WriteJSON(msg)
+			err := writeAdminCommand(m.conn, msg)
 			if err != nil {
-				m.banner = "❌ Failed to send stats command"
+				m.banner = i18n.T("banner.stats_failed")
 			} else {
-				m.banner = "✅ Database stats command sent"
+				m.banner = i18n.T("banner.stats_sent")
 			}
 		}
 	}
@@ -2626,33 +4008,83 @@ func (m *model) executeDBAction(action string) (tea.Model, tea.Cmd) {
 	return m, m.listenWebSocket()
 }
 
+// offerClipboardImage asks whether to send a pasted image as a file
+func (m *model) offerClipboardImage(img *clipboardImage) {
+	size := int64(len(img.data))
+	if limit := maxFileBytes(); size > limit {
+		if !m.offerDownscaled(img.filename, img.data, limit) {
+			m.banner = i18n.T("banner.clipboard_image_too_large", formatByteSize(size), formatByteSize(limit))
+		}
+		return
+	}
+	m.pendingImage = img
+	m.banner = i18n.T("banner.clipboard_image_prompt", img.filename, formatByteSize(size))
+}
+
 func (m *model) View() string {
+	if *a11yMode {
+		return m.a11yView()
+	}
 	// Header with version
 	headerText := fmt.Sprintf(" marchat %s ", shared.ClientVersion)
-	header := m.styles.Header.Width(m.viewport.Width + userListWidth + 4).Render(headerText)
+	fullWidth := m.viewport.Width + userListWidth + 4
+	if *kioskMode {
+		fullWidth = m.viewport.Width + 4
+	}
+	if m.topic != "" {
+		topic := i18n.T("header.topic", m.topic)
+		if room := fullWidth - lipgloss.Width(headerText) - 4; room > 10 {
+			headerText += "| " + truncateRunes(topic, room) + " "
+		}
+	}
+	header := m.styles.Header.Width(fullWidth).Render(headerText)
 
 	// Footer with encryption status
-	footerText := "Press Ctrl+H for help"
-	if m.showHelp {
-		footerText = "Press Ctrl+H to close help"
+	footerText := i18n.T("footer.help")
+	if *kioskMode {
+		footerText = i18n.T("footer.kiosk")
+	} else if m.showHelp {
+		footerText = i18n.T("footer.close_help")
+	} else if m.spellChecker != nil {
+		// Underline misspelled words from the composer while typing
+		if misspellings := m.spellChecker.Check(m.textarea.Value(), m.users); len(misspellings) > 0 {
+			footerText = renderSpellPreview(m.textarea.Value(), misspellings, m.viewport.Width)
+		}
+	}
+	if m.slowMode > 0 && !*isAdmin {
+		footerText += " | " + i18n.T("footer.slow_mode", m.slowMode)
+	}
+	if m.incompatibleServer != "" {
+		footerText += " | " + i18n.T("footer.version_warning", m.incompatibleServer)
+	}
+	if n := hiddenMessageCount(m.messages); n > 0 {
+		footerText += " | " + i18n.T("footer.hidden", n)
 	}
 	// Add encryption status indicator
 	if m.useE2E {
-		footerText += " | 🔒 E2E Encrypted"
+		footerText += " | " + i18n.T("footer.encrypted")
 	} else {
-		footerText += " | 🔓 Unencrypted"
+		footerText += " | " + i18n.T("footer.unencrypted")
 	}
-	footer := m.styles.Footer.Width(m.viewport.Width + userListWidth + 4).Render(footerText)
+	footer := m.styles.Footer.Width(fullWidth).Render(footerText)
 
 	// Banner
 	var bannerBox string
-	if m.banner != "" || m.sending {
+	notice := maintenanceNotice(m.maintenance, time.Now(), m.twentyFourHour)
+	if m.banner != "" || m.sending || notice != "" {
 		bannerText := m.banner
+		if notice != "" {
+			if bannerText != "" {
+				bannerText = notice + " | " + bannerText
+			} else {
+				bannerText = notice
+			}
+		}
 		if m.sending {
 			if bannerText != "" {
-				bannerText += " ⏳ Sending..."
+				bannerText += " " + i18n.T("banner.sending")
 			} else {
-				bannerText = "⏳ Sending..."
+				bannerText = i18n.T("banner.sending")
 			}
 		}
 		bannerBox = m.styles.Banner.
@@ -2681,6 +4113,10 @@ func (m *model) View() string {
 		inputPanel,
 		footer,
 	)
+	if *kioskMode {
+		ui = lipgloss.JoinVertical(lipgloss.Left, header, bannerBox, chatPanel, footer)
+		return m.styles.Background.Render(ui)
+	}
 
 	// Show code snippet interface as full-screen if shown
 	if m.showCodeSnippet {
@@ -2740,6 +4176,56 @@ func (m *model) View() string {
 		return m.styles.Background.Render(ui)
 	}
 
+	// Show a shared snippet in a scrollable overlay
+	if m.showSnippetViewer {
+		content := m.styles.HelpOverlay.Render(
+			m.styles.User.Render(snippetViewerTitle(m.snippetInfo)) + "\n\n" +
+				m.snippetViewer.View() + "\n\n" +
+				m.styles.Time.Render("↑/↓/PgUp/PgDn scroll • c copy • esc close"))
+		ui = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+		return m.styles.Background.Render(ui)
+	}
+
+	// Show the diagram composer as a centered overlay
+	if m.showDiagram {
+		ui = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.styles.HelpOverlay.Render(m.diagram.View(m.styles)))
+		return m.styles.Background.Render(ui)
+	}
+
+	// Show the notification rules as a centered popup
+	if m.showNotifyRules {
+		popup := m.styles.HelpOverlay.Render(m.notifyRules.View(m.styles))
+		ui = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, popup)
+		return m.styles.Background.Render(ui)
+	}
+
+	// Show the channel's shared notes, read-only or in the editor
+	if m.showNotes {
+		ui = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.notesView())
+		return m.styles.Background.Render(ui)
+	}
+
+	// Show spelling corrections as a small centered popup
+	if m.showSpellPopup {
+		popup := m.styles.HelpOverlay.Render(m.spellPopup.View())
+		ui = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, popup)
+		return m.styles.Background.Render(ui)
+	}
+
+	// Show a command's argument form as a centered popup
+	if m.showCommandForm {
+		popup := m.styles.HelpOverlay.Render(m.commandForm.View(m.styles))
+		ui = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, popup)
+		return m.styles.Background.Render(ui)
+	}
+
+	// Show the emoji picker as a centered popup
+	if m.showEmojiPicker {
+		popup := m.styles.HelpOverlay.Render(m.emojiPicker.View(m.styles))
+		ui = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, popup)
+		return m.styles.Background.Render(ui)
+	}
+
 	// Show help as full-screen modal if shown
 	if m.showHelp {
 		// Use most of the available screen space for help
@@ -2818,20 +4304,6 @@ func (m *model) View() string {
 	return m.styles.Background.Render(ui)
 }
 
-func renderEmojis(s string) string {
-	emojis := map[string]string{
-		":)": "😊",
-		":(": "🙁",
-		":D": "😃",
-		"<3": "❤️",
-		":P": "😛",
-	}
-	for k, v := range emojis {
-		s = strings.ReplaceAll(s, k, v)
-	}
-	return s
-}
-
 // renderHyperlinks detects and formats URLs in text
 func renderHyperlinks(content string, styles themeStyles) string {
 	return urlRegex.ReplaceAllStringFunc(content, func(url string) string {
@@ -2846,18 +4318,17 @@ func openURL(url string) error {
 		url = "https://" + url
 	}
 
+	// Android hands links to the browser itself
+	if isTermux() {
+		return termux.Open(url)
+	}
+
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
 	case "windows":
-		// Try multiple methods for Windows
-		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
-		if err := cmd.Start(); err != nil {
-			// Fallback to start command
-			cmd = exec.Command("cmd", "/c", "start", url)
-			return cmd.Start()
-		}
-		return nil
+		// The shell opens it in the default browser without a console window
+		return shellOpen(url)
 	case "darwin":
 		cmd = exec.Command("open", url)
 	case "linux":
@@ -2880,12 +4351,11 @@ func openURL(url string) error {
 	return cmd.Start()
 }
 
-// renderCodeBlocks detects and renders syntax highlighted code blocks in messages
-func renderCodeBlocks(content string) string {
-	// Look for markdown code blocks
-	codeBlockRegex := regexp.MustCompile("```([a-zA-Z0-9+]*)\n([\\s\\S]*?)```")
-
-	return codeBlockRegex.ReplaceAllStringFunc(content, func(match string) string {
+// renderCodeBlocks detects and renders syntax highlighted code blocks in
+// messages. It reports false while any block is still being highlighted.
+func renderCodeBlocks(content string) (string, bool) {
+	ready := true
+	out := codeBlockRegex.ReplaceAllStringFunc(content, func(match string) string {
 		// Extract language and code
 		parts := codeBlockRegex.FindStringSubmatch(match)
 		if len(parts) < 3 {
@@ -2894,16 +4364,15 @@ func renderCodeBlocks(content string) string {
 
 		language := parts[1]
 		code := parts[2]
-
-		// Use Chroma directly for syntax highlighting
-		var sb strings.Builder
-		err := quick.Highlight(&sb, code, language, "terminal256", "monokai")
-		if err != nil {
-			return match // Return original if highlighting fails
+		if language == "" {
+			language = detectLanguage(code)
 		}
 
-		return sb.String()
+		block, done := highlighter.code(highlightKey{language: language, code: code})
+		ready = ready && done
+		return block
 	})
+	return out, ready
 }
 
 func renderUserList(users []string, me string, styles themeStyles, width int, isAdmin bool, selectedUserIndex int) string {
@@ -2934,7 +4403,15 @@ func renderUserList(users []string, me string, styles themeStyles, width int, is
 			}
 		}
 
-		b.WriteString(userStyle.Render(prefix+u) + "\n")
+		// Idle users stay listed, dimmed with a hollow bullet
+		if isAway(u) {
+			userStyle = userStyle.Faint(true)
+			if prefix == "• " {
+				prefix = "○ "
+			}
+		}
+
+		b.WriteString(userStyle.Render(prefix+userListLabel(u)) + "\n")
 	}
 	return b.String()
 }
@@ -2945,6 +4422,74 @@ type quitMsg struct{}
 func main() {
 	flag.Parse()
 
+	if flag.Arg(0) == "replay" {
+		if err := runReplay(flag.Args()[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *registerHandler {
+		if err := registerURLHandler(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✅ marchat:// links now open marchat-client")
+		return
+	}
+
+	// A marchat:// link (from the OS URL handler or --invite) supplies the
+	// server and username; an invite token rides on the handshake
+	var link chatLink
+	var linkErr error
+	rawLink := flag.Arg(0)
+	if *scan {
+		if rawLink, linkErr = scanChatLink(); linkErr != nil {
+			fmt.Printf("Error: %v\n", linkErr)
+			os.Exit(1)
+		}
+	}
+	if isChatLinkArg(rawLink) {
+		link, linkErr = parseChatLink(rawLink)
+	}
+	if *inviteFlag != "" && linkErr == nil {
+		var inv chatLink
+		inv, linkErr = parseInviteFlag(*inviteFlag)
+		if inv.server != "" {
+			link.server, link.user = inv.server, inv.user
+		}
+		link.invite = inv.invite
+	}
+	if linkErr != nil {
+		fmt.Printf("Error: %v\n", linkErr)
+		os.Exit(1)
+	}
+	pendingInvite = link.invite
+	invitedServer := ""
+	if *serverURL == "" && link.server != "" {
+		invitedServer = link.server
+		*serverURL = link.server
+	}
+	if *username == "" {
+		*username = link.user
+	}
+
+	// Links to a server and username with a saved profile open it directly
+	if invitedServer != "" && *username != "" {
+		if loader, err := config.NewInteractiveConfigLoader(); err == nil {
+			if cfg, ok := loader.ConnectLink(*serverURL, *username); ok {
+				adminKey, keystorePass, err := loader.PromptSensitiveData(cfg.IsAdmin, cfg.UseE2E)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				initializeClient(cfg, adminKey, keystorePass)
+				return
+			}
+		}
+	}
+
 	// Auto-connect to most recent profile
 	if *autoConnect {
 		loader, err := config.NewInteractiveConfigLoader()
@@ -2995,11 +4540,17 @@ func main() {
 		return
 	}
 
+	var discovered []config.ConnectionProfile
+	if *discover && invitedServer == "" {
+		discovered = discoverServers()
+	}
+
 	var cfg *config.Config
 	var err error
 
-	// Check if all required flags are provided for non-interactive mode
-	if *nonInteractive || (allFlagsProvided(*serverURL, *username, *isAdmin, *adminKey, *useE2E, *keystorePassphrase)) {
+	// Check if all required flags are provided for non-interactive mode.
+	// Links to a server without a saved profile prompt to create one.
+	if *nonInteractive || (invitedServer == "" && !*discover && allFlagsProvided(*serverURL, *username, *isAdmin, *adminKey, *useE2E, *keystorePassphrase)) {
 		// Use traditional flag-based configuration
 		cfg, err = loadConfigFromFlags(*configPath, *serverURL, *username, *theme, *isAdmin, *useE2E, *skipTLSVerify)
 		if err != nil {
@@ -3027,6 +4578,10 @@ func main() {
 
 		profiles, err := loader.LoadProfiles()
 		isFirstTime := err != nil || len(profiles.Profiles) == 0
+		// Servers found on the LAN are offered even before any profile exists
+		if isFirstTime && err == nil && len(discovered) > 0 {
+			isFirstTime = false
+		}
 
 		var cfg *config.Config
 		var adminKeyFromConfig, keystorePassFromConfig string
@@ -3035,7 +4590,7 @@ func main() {
 			// First time user - show welcome and go straight to config creation
 			fmt.Println("🎉 Welcome to marchat! Let's get you set up...")
 
-			configResult, keystorePass, err := config.RunInteractiveConfig()
+			configResult, keystorePass, err := config.RunInteractiveConfigPrefilled(invitedServer, *username)
 			if err != nil {
 				fmt.Printf("Configuration error: %v\n", err)
 				os.Exit(1)
@@ -3046,13 +4601,23 @@ func main() {
 
 			// Save as the default profile
 			profile := &config.ConnectionProfile{
-				Name:      "Default",
-				ServerURL: cfg.ServerURL,
-				Username:  cfg.Username,
-				IsAdmin:   cfg.IsAdmin,
-				UseE2E:    cfg.UseE2E,
-				Theme:     cfg.Theme,
-				LastUsed:  time.Now().Unix(),
+				Name:              "Default",
+				ServerURL:         cfg.ServerURL,
+				Username:          cfg.Username,
+				IsAdmin:           cfg.IsAdmin,
+				UseE2E:            cfg.UseE2E,
+				Theme:             cfg.Theme,
+				SpellCheck:        cfg.SpellCheck,
+				Ignored:           cfg.Ignored,
+				TimeZone:          cfg.TimeZone,
+				ShowServerTime:    cfg.ShowServerTime,
+				DownloadDir:       cfg.DownloadDir,
+				AutoSaveMaxBytes:  cfg.AutoSaveMaxBytes,
+				CertFingerprint:   cfg.CertFingerprint,
+				ClientCert:        cfg.ClientCert,
+				ClientKey:         cfg.ClientKey,
+				ConnectionTimings: cfg.ConnectionTimings,
+				LastUsed:          time.Now().Unix(),
 			}
 			profiles.Profiles = append(profiles.Profiles, *profile)
 			if err := loader.SaveProfiles(profiles); err != nil {
@@ -3069,17 +4634,27 @@ func main() {
 				return profiles.Profiles[i].LastUsed > profiles.Profiles[j].LastUsed
 			})
 
-			selectedProfile, isCreateNew, err := config.RunProfileSelectionWithNew(profiles.Profiles, loader)
-			if err != nil {
-				fmt.Printf("Profile selection error: %v\n", err)
-				os.Exit(1)
+			// An invite to a new server goes straight to creating a profile for it
+			var selectedProfile *config.ConnectionProfile
+			isCreateNew := invitedServer != ""
+			if !isCreateNew {
+				selectedProfile, isCreateNew, err = config.RunProfileSelectionWithDiscovered(profiles.Profiles, discovered, loader)
+				if err != nil {
+					fmt.Printf("Profile selection error: %v\n", err)
+					os.Exit(1)
+				}
 			}
 
 			if isCreateNew {
-				// User chose to create a new profile
+				// User chose to create a new profile, possibly for a
+				// discovered server
 				fmt.Println("Creating a new connection profile...")
 
-				configResult, keystorePass, err := config.RunInteractiveConfig()
+				prefillServer := invitedServer
+				if selectedProfile != nil {
+					prefillServer = selectedProfile.ServerURL
+				}
+				configResult, keystorePass, err := config.RunInteractiveConfigPrefilled(prefillServer, *username)
 				if err != nil {
 					fmt.Printf("Configuration error: %v\n", err)
 					os.Exit(1)
@@ -3091,13 +4666,23 @@ func main() {
 				// Save as a new profile
 				profileName := fmt.Sprintf("Profile-%d", len(profiles.Profiles)+1)
 				profile := &config.ConnectionProfile{
-					Name:      profileName,
-					ServerURL: cfg.ServerURL,
-					Username:  cfg.Username,
-					IsAdmin:   cfg.IsAdmin,
-					UseE2E:    cfg.UseE2E,
-					Theme:     cfg.Theme,
-					LastUsed:  time.Now().Unix(),
+					Name:              profileName,
+					ServerURL:         cfg.ServerURL,
+					Username:          cfg.Username,
+					IsAdmin:           cfg.IsAdmin,
+					UseE2E:            cfg.UseE2E,
+					Theme:             cfg.Theme,
+					SpellCheck:        cfg.SpellCheck,
+					Ignored:           cfg.Ignored,
+					TimeZone:          cfg.TimeZone,
+					ShowServerTime:    cfg.ShowServerTime,
+					DownloadDir:       cfg.DownloadDir,
+					AutoSaveMaxBytes:  cfg.AutoSaveMaxBytes,
+					CertFingerprint:   cfg.CertFingerprint,
+					ClientCert:        cfg.ClientCert,
+					ClientKey:         cfg.ClientKey,
+					ConnectionTimings: cfg.ConnectionTimings,
+					LastUsed:          time.Now().Unix(),
 				}
 				profiles.Profiles = append(profiles.Profiles, *profile)
 				if err := loader.SaveProfiles(profiles); err != nil {
@@ -3143,12 +4728,22 @@ func main() {
 
 				// Convert profile to config
 				cfg = &config.Config{
-					Username:       profile.Username,
-					ServerURL:      profile.ServerURL,
-					IsAdmin:        profile.IsAdmin,
-					UseE2E:         profile.UseE2E,
-					Theme:          profile.Theme,
-					TwentyFourHour: true, // Default value
+					Username:          profile.Username,
+					ServerURL:         profile.ServerURL,
+					IsAdmin:           profile.IsAdmin,
+					UseE2E:            profile.UseE2E,
+					Theme:             profile.Theme,
+					SpellCheck:        profile.SpellCheck,
+					Ignored:           profile.Ignored,
+					TimeZone:          profile.TimeZone,
+					ShowServerTime:    profile.ShowServerTime,
+					DownloadDir:       profile.DownloadDir,
+					AutoSaveMaxBytes:  profile.AutoSaveMaxBytes,
+					CertFingerprint:   profile.CertFingerprint,
+					ClientCert:        profile.ClientCert,
+					ClientKey:         profile.ClientKey,
+					ConnectionTimings: profile.ConnectionTimings,
+					TwentyFourHour:    true, // Default value
 				}
 
 				// Get sensitive data
@@ -3238,6 +4833,11 @@ func validateFlags(isAdmin bool, adminKey string, useE2E bool, keystorePassphras
 }
 
 func initializeClient(cfg *config.Config, adminKeyParam, keystorePassphraseParam string) {
+	// Keep the secrets out of the debug log however they end up in a line
+	for _, secret := range []string{adminKeyParam, keystorePassphraseParam, *adminKey, *keystorePassphrase, *joinPassphrase, os.Getenv("MARCHAT_JOIN_PASSPHRASE")} {
+		shared.RegisterSecret(secret)
+	}
+
 	// Your existing client initialization code here...
 	fmt.Printf("Connecting to %s as %s...\n", cfg.ServerURL, cfg.Username)
 
@@ -3258,6 +4858,60 @@ func initializeClient(cfg *config.Config, adminKeyParam, keystorePassphraseParam
 		configFilePath = "config.json" // fallback
 	}
 
+	// Hooks, the UI language and connection tuning live in config.json
+	// whichever profile is in use
+	if base, err := config.LoadConfig(configFilePath); err == nil {
+		if len(cfg.Hooks) == 0 {
+			cfg.Hooks = base.Hooks
+		}
+		if cfg.Locale == "" {
+			cfg.Locale = base.Locale
+		}
+		if cfg.WireFormat == "" {
+			cfg.WireFormat = base.WireFormat
+		}
+		if cfg.CompressionLevel == 0 {
+			cfg.CompressionLevel = base.CompressionLevel
+		}
+		if cfg.CompressionThreshold == 0 {
+			cfg.CompressionThreshold = base.CompressionThreshold
+		}
+		// Connection timings are also per profile, and a profile's win
+		if cfg.PingInterval == 0 {
+			cfg.PingInterval = base.PingInterval
+		}
+		if cfg.WriteTimeout == 0 {
+			cfg.WriteTimeout = base.WriteTimeout
+		}
+		if cfg.ReconnectDelay == 0 {
+			cfg.ReconnectDelay = base.ReconnectDelay
+		}
+		if cfg.ReconnectMaxDelay == 0 {
+			cfg.ReconnectMaxDelay = base.ReconnectMaxDelay
+		}
+	}
+	i18n.SetLocale(i18n.Detect(cfg.Locale))
+	setConnectionTimings(*cfg)
+	setCompression(*cfg)
+	hooks := setupHooks(cfg, configFilePath, term.IsTerminal(os.Stdin.Fd()))
+	if term.IsTerminal(os.Stdin.Fd()) {
+		trustOnFirstUse(cfg, configFilePath, os.Stdin, os.Stdout)
+	}
+
+	// Headless mode: the daemon only relays ciphertext, so it needs no keystore
+	if *daemonMode {
+		*isAdmin = cfg.IsAdmin
+		*skipTLSVerify = cfg.SkipTLSVerify
+		if len(adminKeyParam) > 0 {
+			*adminKey = adminKeyParam
+		}
+		if err := runDaemon(cfg, hooks); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize keystore if E2E is enabled
 	var keystore *crypto.KeyStore
 	if cfg.UseE2E {
@@ -3276,26 +4930,6 @@ func initializeClient(cfg *config.Config, adminKeyParam, keystorePassphraseParam
 		fmt.Printf("E2E encryption enabled\n")
 	}
 
-	// Setup textarea
-	ta := textarea.New()
-	ta.Placeholder = "Type your message..."
-	ta.Focus()
-	ta.Prompt = "┃ "
-	ta.CharLimit = 2000
-	ta.SetHeight(3)
-	ta.ShowLineNumbers = false
-	ta.KeyMap.InsertNewline.SetEnabled(false)
-
-	vp := viewport.New(80, 20)
-
-	userListVp := viewport.New(18, 10) // height will be set on resize
-	userListVp.SetContent(renderUserList([]string{cfg.Username}, cfg.Username, getThemeStyles(cfg.Theme), 18, cfg.IsAdmin, -1))
-
-	helpVp := viewport.New(70, 20) // initial size, will be adjusted on resize
-
-	// Initialize admin menu viewports
-	dbMenuVp := viewport.New(60, 15)
-
 	// Additional keystore initialization if E2E is enabled
 	if cfg.UseE2E && keystore != nil {
 		// Check environment variable status
@@ -3343,8 +4977,50 @@ func initializeClient(cfg *config.Config, adminKeyParam, keystorePassphraseParam
 		*keystorePassphrase = keystorePassphraseParam
 	}
 
+	m := newModel(*cfg, configFilePath, keystore, hooks)
+	if *recordFile != "" {
+		recorder, err := openRecorder(*recordFile, cfg.ServerURL, cfg.Username)
+		if err != nil {
+			fmt.Printf("Error: cannot record the session: %v\n", err)
+			os.Exit(1)
+		}
+		defer recorder.Close()
+		m.recorder = recorder
+		fmt.Printf("⏺ Recording this session to %s\n", *recordFile)
+	}
+	runTUI(m)
+}
+
+// newModel sets up the chat UI for cfg
+func newModel(cfg config.Config, configFilePath string, keystore *crypto.KeyStore, hooks []config.Hook) *model {
+	// Setup textarea
+	ta := textarea.New()
+	ta.Placeholder = i18n.T("input.placeholder")
+	if *readOnly {
+		ta.Placeholder = i18n.T("input.placeholder_read_only")
+	}
+	ta.Focus()
+	ta.Prompt = "┃ "
+	if *a11yMode {
+		ta.Prompt = "Message: "
+	}
+	ta.CharLimit = 2000
+	ta.SetHeight(3)
+	ta.ShowLineNumbers = false
+	ta.KeyMap.InsertNewline.SetEnabled(false)
+
+	vp := viewport.New(80, 20)
+
+	userListVp := viewport.New(18, 10) // height will be set on resize
+	userListVp.SetContent(renderUserList([]string{cfg.Username}, cfg.Username, getThemeStyles(cfg.Theme), 18, cfg.IsAdmin, -1))
+
+	helpVp := viewport.New(70, 20) // initial size, will be adjusted on resize
+
+	// Initialize admin menu viewports
+	dbMenuVp := viewport.New(60, 15)
+
 	m := &model{
-		cfg:               *cfg,
+		cfg:               cfg,
 		configFilePath:    configFilePath,
 		textarea:          ta,
 		viewport:          vp,
@@ -3358,25 +5034,54 @@ func initializeClient(cfg *config.Config, adminKeyParam, keystorePassphraseParam
 		useE2E:            cfg.UseE2E,
 		keys:              newKeyMap(),
 		selectedUserIndex: -1, // No user selected initially
+		hooks:             hooks,
 	}
 
 	// Initialize notification manager with config settings
-	notifConfig := configToNotificationConfig(*cfg)
+	notifConfig := configToNotificationConfig(cfg)
 	m.notificationManager = NewNotificationManager(notifConfig)
+	m.translator, m.translateTarget = newTranslatorFromConfig(cfg)
+	m.spellChecker = newSpellCheckerFromConfig(cfg, filepath.Dir(configFilePath))
+	setIgnoredUsers(cfg.Ignored)
+	if err := setDisplayZone(cfg.TimeZone, cfg.ShowServerTime); err != nil {
+		log.Printf("Warning: %v, showing local time", err)
+	}
+	setRelativeTimes(cfg.RelativeTime)
+	setCompactMode(cfg.Compact, cfg.CompactMinutes)
+	if err := setClipboardMode(cfg.Clipboard); err != nil {
+		log.Printf("Warning: clipboard %q: %v, using auto", cfg.Clipboard, err)
+	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	return m
+}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
+// runTUI runs the chat UI until the user quits
+func runTUI(m *model) {
+	var opts []tea.ProgramOption
+	if *a11yMode {
+		// Printed messages need the normal screen; the alternate one drops them
+		lipgloss.SetColorProfile(termenv.Ascii)
+	} else {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(m, opts...)
+	highlighter.start(p.Send)
+
+	stopSignals := terminal.HandleSignals(3*time.Second, func() {
 		m.closeWebSocket()
 		p.Send(quitMsg{})
-	}()
+	})
+	defer stopSignals()
 
-	if _, err := p.Run(); err != nil {
+	// Bubble Tea restores the terminal itself when it exits, even from a
+	// panic in Update or View
+	terminal.Arm()
+	_, err := p.Run()
+	terminal.Disarm()
+	if err != nil {
 		log.Printf("Error running program: %v", err)
 		os.Exit(1)
 	}
 	m.wg.Wait() // Wait for all goroutines to finish
+	m.files().clear()
 }
@@ -205,7 +205,7 @@ func TestErrorHandling(t *testing.T) {
 func TestBasicFunctionality(t *testing.T) {
 	// Test basic functionality without actually running the main function
 
-	// Test sortMessagesByTimestamp function
+	// Test sortMessages function
 	messages := []shared.Message{
 		{
 			Sender:    "user1",
@@ -225,7 +225,7 @@ func TestBasicFunctionality(t *testing.T) {
 	}
 
 	// Sort messages
-	sortMessagesByTimestamp(messages)
+	sortMessages(messages)
 
 	// Verify sorting
 	if messages[0].Content != "first message" {
@@ -400,7 +400,7 @@ func TestRenderFunctions(t *testing.T) {
 
 	// Test renderCodeBlocks function
 	codeContent := "```go\nfunc main() {}\n```"
-	result = renderCodeBlocks(codeContent)
+	result, _ = renderCodeBlocks(codeContent)
 	// The function should return something (either the original or highlighted version)
 	if result == "" {
 		t.Error("renderCodeBlocks should return non-empty result")
@@ -464,7 +464,7 @@ func TestSortMessagesByTimestamp(t *testing.T) {
 	}
 
 	// Sort messages
-	sortMessagesByTimestamp(messages)
+	sortMessages(messages)
 
 	// Verify secondary sort by sender
 	if messages[0].Sender != "user1" {
@@ -490,6 +490,34 @@ func TestSortMessagesByTimestamp(t *testing.T) {
 	}
 }
 
+func TestSortMessagesBySeq(t *testing.T) {
+	now := time.Now()
+	m := &model{}
+	var messages []shared.Message
+	for _, msg := range []shared.Message{
+		{Sender: "bob", Content: "first", CreatedAt: now, Seq: 1},
+		// Sent after the first message from a clock that runs behind
+		{Sender: "alice", Content: "second", CreatedAt: now.Add(-time.Minute), Seq: 3},
+		{Sender: "System", Content: "reply", CreatedAt: now.Add(time.Second)},
+	} {
+		m.placeUnnumbered(&msg)
+		messages = append(messages, msg)
+		sortMessages(messages)
+	}
+	if messages[0].Content != "first" || messages[1].Content != "second" || messages[2].Content != "reply" {
+		t.Errorf("Expected server order with the reply after the latest message, got %+v", messages)
+	}
+
+	// Messages arriving late still slot into their place
+	late := shared.Message{Sender: "carol", Content: "between", CreatedAt: now.Add(time.Hour), Seq: 2}
+	m.placeUnnumbered(&late)
+	messages = append(messages, late)
+	sortMessages(messages)
+	if messages[1].Content != "between" || messages[3].Content != "reply" || m.lastSeq != 3 {
+		t.Errorf("Expected the late message between the first two, got %+v (last %d)", messages, m.lastSeq)
+	}
+}
+
 func TestSafeClipboardOperation(t *testing.T) {
 	// Test safeClipboardOperation with a simple operation
 	err := safeClipboardOperation(func() error {
@@ -858,6 +886,37 @@ func TestRenderMessages(t *testing.T) {
 		t.Error("renderMessages should preserve URLs")
 	}
 
+	// Test announcement banner
+	announceMessages := []shared.Message{
+		{
+			Sender:    "admin",
+			Content:   "Maintenance at noon",
+			CreatedAt: now,
+			Type:      shared.AnnouncementType,
+		},
+	}
+
+	announceResult := renderMessages(announceMessages, styles, username, users, width, twentyFourHour)
+	if !strings.Contains(announceResult, "ANNOUNCEMENT") || !strings.Contains(announceResult, "Maintenance at noon") {
+		t.Error("renderMessages should render announcements as a banner")
+	}
+
+	// Test history gap markers
+	gapMessages := []shared.Message{
+		{
+			Sender:    "System",
+			Content:   "4 messages hidden by moderation",
+			CreatedAt: now,
+			Type:      shared.GapMessageType,
+			Gap:       &shared.HistoryGap{Hidden: 4, From: now, To: now},
+		},
+	}
+
+	gapResult := renderMessages(gapMessages, styles, username, users, width, twentyFourHour)
+	if !strings.Contains(gapResult, "── 4 messages hidden by moderation ──") || strings.Contains(gapResult, "System") {
+		t.Errorf("renderMessages should render gap markers as a divider, got:\n%s", gapResult)
+	}
+
 	// Test 12-hour format
 	twelveHourResult := renderMessages(messages, styles, username, users, width, false)
 	if twelveHourResult == "" {
@@ -881,6 +940,41 @@ func TestRenderMessages(t *testing.T) {
 	}
 }
 
+func TestRenderPoll(t *testing.T) {
+	created := time.Now()
+	poll := &shared.Poll{
+		ID:        3,
+		Question:  "Lunch?",
+		Options:   []shared.PollOption{{Text: "pizza", Votes: 3}, {Text: "sushi", Votes: 1}},
+		Creator:   "alice",
+		CreatedAt: created,
+		ExpiresAt: created.Add(time.Hour),
+	}
+
+	result := renderPoll(poll, baseThemeStyles(), 80, "15:04")
+	for _, want := range []string{"Poll #3", "Lunch?", "pizza", "3 (75%)", "1 (25%)", ":vote 3"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("renderPoll output missing %q", want)
+		}
+	}
+
+	poll.Closed = true
+	if result := renderPoll(poll, baseThemeStyles(), 80, "15:04"); !strings.Contains(result, "Closed") {
+		t.Error("renderPoll should mark closed polls")
+	}
+
+	msgs := []shared.Message{
+		{Sender: "bob", Content: "hi", CreatedAt: created},
+		{Sender: "System", Type: shared.PollMessageType, CreatedAt: created, Poll: &shared.Poll{ID: 3, CreatedAt: created}},
+	}
+	if i := findPollMessage(msgs, poll); i != 1 {
+		t.Errorf("Expected poll at index 1, got %d", i)
+	}
+	if i := findPollMessage(msgs, &shared.Poll{ID: 3, CreatedAt: created.Add(time.Minute)}); i != -1 {
+		t.Errorf("Poll from a different server run should not match, got %d", i)
+	}
+}
+
 func TestRenderUserList(t *testing.T) {
 	// Test the renderUserList function
 	users := []string{"user1", "user2", "user3", "currentuser"}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maintenanceTickMsg redraws the maintenance countdown
+type maintenanceTickMsg struct{}
+
+// maintenanceTick fires on the next second, while a window is planned
+func maintenanceTick() tea.Cmd {
+	return tea.Tick(time.Until(time.Now().Truncate(time.Second).Add(time.Second)), func(time.Time) tea.Msg {
+		return maintenanceTickMsg{}
+	})
+}
+
+// countdown shows d to the second, with the two largest units
+func countdown(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm %02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
+// maintenanceNotice is the banner text for a planned window at now: a
+// countdown to it, the time it ends while it lasts, or nothing when there
+// is no window or it is over
+func maintenanceNotice(mw shared.Maintenance, now time.Time, twentyFourHour bool) string {
+	if !mw.Scheduled() || !now.Before(mw.End) {
+		return ""
+	}
+	clock := "3:04 PM"
+	if twentyFourHour {
+		clock = "15:04"
+	}
+	var notice string
+	if now.Before(mw.Start) {
+		notice = i18n.T("banner.maintenance_in", countdown(mw.Start.Sub(now)), displayTime(mw.Start).Format(clock), displayTime(mw.End).Format(clock))
+	} else {
+		notice = i18n.T("banner.maintenance_now", displayTime(mw.End).Format(clock))
+	}
+	if mw.Message != "" {
+		notice += ": " + mw.Message
+	}
+	return notice
+}
+
+// maintenanceReconnectDelay is how long to wait before reconnecting when
+// the connection drops during a planned window: until it ends, checking
+// back at the longest backoff in case it ends early
+func maintenanceReconnectDelay(mw shared.Maintenance, now time.Time) (time.Duration, bool) {
+	if !mw.Active(now) {
+		return 0, false
+	}
+	return min(mw.End.Sub(now)+restartReconnectDelay(), connectionTimings().ReconnectMax), true
+}
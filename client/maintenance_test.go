@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestCountdown(t *testing.T) {
+	tests := map[time.Duration]string{
+		45 * time.Second:                 "45s",
+		90 * time.Second:                 "1m 30s",
+		2*time.Hour + 5*time.Minute:      "2h 05m",
+		26*time.Hour + 59*time.Second:    "26h 00m",
+		1500 * time.Millisecond:          "2s",
+		59*time.Minute + 59*time.Second:  "59m 59s",
+		time.Hour - 400*time.Millisecond: "1h 00m",
+	}
+	for d, want := range tests {
+		if got := countdown(d); got != want {
+			t.Errorf("countdown(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestMaintenanceNotice(t *testing.T) {
+	start := time.Date(2025, 3, 1, 22, 0, 0, 0, time.Local)
+	mw := shared.Maintenance{Start: start, End: start.Add(30 * time.Minute), Message: "Upgrading"}
+
+	if got := maintenanceNotice(mw, start.Add(-75*time.Minute), true); !strings.Contains(got, "1h 15m") || !strings.Contains(got, "22:00") || !strings.Contains(got, "22:30") || !strings.HasSuffix(got, ": Upgrading") {
+		t.Errorf("Unexpected countdown %q", got)
+	}
+	if got := maintenanceNotice(mw, start.Add(time.Minute), false); !strings.Contains(got, "10:30 PM") {
+		t.Errorf("Expected the end time during the window, got %q", got)
+	}
+	if got := maintenanceNotice(mw, mw.End, true); got != "" {
+		t.Errorf("Expected no notice after the window, got %q", got)
+	}
+	if got := maintenanceNotice(shared.Maintenance{}, start, true); got != "" {
+		t.Errorf("Expected no notice without a window, got %q", got)
+	}
+}
+
+func TestMaintenanceReconnectDelay(t *testing.T) {
+	start := time.Now()
+	mw := shared.Maintenance{Start: start, End: start.Add(time.Hour)}
+	if _, ok := maintenanceReconnectDelay(mw, start.Add(-time.Second)); ok {
+		t.Error("Before the window, reconnects should back off as usual")
+	}
+	delay, ok := maintenanceReconnectDelay(mw, start.Add(time.Minute))
+	if !ok || delay != connectionTimings().ReconnectMax {
+		t.Errorf("A long window should check back at the longest backoff, got %s (%v)", delay, ok)
+	}
+	short := shared.Maintenance{Start: start, End: start.Add(2 * time.Second)}
+	if delay, ok := maintenanceReconnectDelay(short, start); !ok || delay < 2*time.Second || delay > connectionTimings().ReconnectMax {
+		t.Errorf("A short window should reconnect once it ends, got %s (%v)", delay, ok)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// mentionTargetRegex matches @names the way the server counts them
+// against its mention throttle
+var mentionTargetRegex = regexp.MustCompile(`\B@([a-zA-Z0-9_.-]+)`)
+
+// mentionTargets returns the distinct lowercase @names in text
+func mentionTargets(text string) []string {
+	var names []string
+	for _, m := range mentionTargetRegex.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(strings.TrimRight(m[1], "."))
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// mentionLimitWait returns the first of names already mentioned limit times
+// within window, and how long until it can be mentioned again
+func mentionLimitWait(log map[string][]time.Time, names []string, limit int, window time.Duration, now time.Time) (string, time.Duration) {
+	if limit <= 0 {
+		return "", 0
+	}
+	for _, name := range names {
+		var recent []time.Time
+		for _, at := range log[name] {
+			if now.Sub(at) < window {
+				recent = append(recent, at)
+			}
+		}
+		if len(recent) >= limit {
+			return name, window - now.Sub(recent[0])
+		}
+	}
+	return "", 0
+}
+
+// recordMentions notes that text was sent, dropping mentions that have
+// left the throttle window
+func (m *model) recordMentions(text string, now time.Time) {
+	if m.mentionLimit <= 0 {
+		return
+	}
+	if m.mentionLog == nil {
+		m.mentionLog = make(map[string][]time.Time)
+	}
+	for name, times := range m.mentionLog {
+		if len(times) > 0 && now.Sub(times[len(times)-1]) >= m.mentionWindow {
+			delete(m.mentionLog, name)
+		}
+	}
+	for _, name := range mentionTargets(text) {
+		if name != strings.ToLower(m.cfg.Username) {
+			m.mentionLog[name] = append(m.mentionLog[name], now)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+func TestMentionLimitWait(t *testing.T) {
+	if got := mentionTargets("@Bob and @bob. cc @devs, mail me@example.com"); !reflect.DeepEqual(got, []string{"bob", "devs"}) {
+		t.Errorf("Unexpected mention targets %v", got)
+	}
+
+	now := time.Now()
+	m := &model{cfg: config.Config{Username: "alice"}, mentionLimit: 2, mentionWindow: time.Minute}
+	m.recordMentions("@bob hi @alice", now.Add(-50*time.Second))
+	m.recordMentions("@bob @devs", now.Add(-20*time.Second))
+	if _, ok := m.mentionLog["alice"]; ok {
+		t.Error("Mentioning yourself should not be recorded")
+	}
+	if name, wait := mentionLimitWait(m.mentionLog, []string{"devs", "bob"}, m.mentionLimit, m.mentionWindow, now); name != "bob" || wait != 10*time.Second {
+		t.Errorf("Expected bob to be held back for 10s, got %q %v", name, wait)
+	}
+	if name, _ := mentionLimitWait(m.mentionLog, []string{"bob"}, m.mentionLimit, m.mentionWindow, now.Add(10*time.Second)); name != "" {
+		t.Errorf("Expected bob to be free once the oldest mention leaves the window, got %q", name)
+	}
+	if name, _ := mentionLimitWait(m.mentionLog, []string{"bob"}, 0, m.mentionWindow, now); name != "" {
+		t.Error("No limit from the server should never hold messages back")
+	}
+}
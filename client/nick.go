@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// Display names set with :nick, keyed by lowercase username. Entries for users
+// who leave are kept so their earlier messages still show the name.
+var (
+	displayNamesMu sync.RWMutex
+	displayNames   = map[string]string{}
+)
+
+// updateDisplayNames applies the display names from a user list. Connected
+// users without an entry have cleared theirs.
+func updateDisplayNames(users []string, names map[string]string) {
+	displayNamesMu.Lock()
+	defer displayNamesMu.Unlock()
+	for _, u := range users {
+		if name := names[u]; name != "" {
+			displayNames[strings.ToLower(u)] = name
+		} else {
+			delete(displayNames, strings.ToLower(u))
+		}
+	}
+}
+
+// displayName returns the name to show for username in chat
+func displayName(username string) string {
+	displayNamesMu.RLock()
+	defer displayNamesMu.RUnlock()
+	if name := displayNames[strings.ToLower(username)]; name != "" {
+		return name
+	}
+	return username
+}
+
+// userListLabel shows the display name with the canonical username that
+// mentions and admin commands use
+func userListLabel(username string) string {
+	if name := displayName(username); name != username {
+		return name + " (" + username + ")"
+	}
+	return username
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestDisplayNames(t *testing.T) {
+	t.Cleanup(func() {
+		displayNamesMu.Lock()
+		displayNames = map[string]string{}
+		displayNamesMu.Unlock()
+	})
+
+	updateDisplayNames([]string{"alice", "bob"}, map[string]string{"alice": "Cody 🚀"})
+	if got := displayName("Alice"); got != "Cody 🚀" {
+		t.Errorf("displayName = %q", got)
+	}
+	if got := displayName("bob"); got != "bob" {
+		t.Errorf("Users without a display name should show their username, got %q", got)
+	}
+	if got := userListLabel("alice"); got != "Cody 🚀 (alice)" {
+		t.Errorf("userListLabel = %q", got)
+	}
+
+	msgs := []shared.Message{{Sender: "alice", Content: "hi", CreatedAt: time.Now()}}
+	if out := renderMessages(msgs, baseThemeStyles(), "bob", []string{"alice", "bob"}, 80, true); !strings.Contains(out, "Cody 🚀") {
+		t.Errorf("Expected the display name in rendered chat, got %q", out)
+	}
+
+	// alice leaves: her earlier messages keep the name
+	updateDisplayNames([]string{"bob"}, nil)
+	if got := displayName("alice"); got != "Cody 🚀" {
+		t.Errorf("Display name should outlive the session, got %q", got)
+	}
+
+	// alice returns and clears it
+	updateDisplayNames([]string{"alice", "bob"}, nil)
+	if got := displayName("alice"); got != "alice" {
+		t.Errorf("Expected the display name to be cleared, got %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// notesOverlay shows the channel's shared notes full-screen. Everyone sees
+// each save as it arrives; whoever holds the server's edit lock gets an
+// editor instead of the read-only view.
+type notesOverlay struct {
+	notes   shared.ChannelNotes
+	view    viewport.Model
+	editor  textarea.Model
+	editing bool
+	saved   string // text of the last save, to tell whether there is more to send
+	status  string // why an edit was refused, shown under the notes
+}
+
+// newNotesOverlay sizes the viewer and editor to most of the screen
+func newNotesOverlay(width, height int) notesOverlay {
+	width, height = max(width-12, 40), max(height-12, 10)
+	editor := textarea.New()
+	editor.ShowLineNumbers = false
+	editor.CharLimit = 0
+	editor.MaxHeight = 0
+	editor.SetWidth(width)
+	editor.SetHeight(height)
+	return notesOverlay{view: viewport.New(width, height), editor: editor}
+}
+
+// notesTitle describes the notes and who last changed or is editing them
+func notesTitle(n shared.ChannelNotes, editing bool) string {
+	switch {
+	case editing:
+		return i18n.T("notes.title_editing")
+	case n.Editor != "":
+		return i18n.T("notes.title_locked", displayName(n.Editor))
+	case n.Version == 0:
+		return i18n.T("notes.title_empty")
+	default:
+		return i18n.T("notes.title", displayName(n.UpdatedBy), n.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+// applyNotes takes a "notes" update from the server. The overlay opens when
+// the user asked for the notes or for the edit lock; an open editor closes
+// if the lock lapsed.
+func (m *model) applyNotes(n shared.ChannelNotes) {
+	granted := m.pendingNotesEdit && n.Editor == m.cfg.Username
+	if !m.showNotes && !m.pendingNotes && !granted {
+		return
+	}
+	m.pendingNotes = false
+	if granted {
+		m.pendingNotesEdit = false
+	}
+	if !m.showNotes {
+		m.notes = newNotesOverlay(m.width, m.height)
+		m.showNotes = true
+	}
+	m.notes.notes = n
+	m.notes.status = ""
+	switch {
+	case granted && !m.notes.editing:
+		m.notes.editing = true
+		m.notes.saved = n.Text
+		m.notes.editor.SetValue(n.Text)
+		m.notes.editor.Focus()
+	case n.Editor != m.cfg.Username && m.notes.editing:
+		// The lock lapsed or was taken over; unsaved text can't be sent now
+		m.notes.editing = false
+		m.notes.editor.Blur()
+		m.notes.status = i18n.T("notes.lock_lost")
+	}
+	m.notes.view.SetContent(renderEmojis(n.Text))
+}
+
+// sendNotesCommand sends ":notes" with optional arguments to the server
+func (m *model) sendNotesCommand(args string) bool {
+	if m.conn == nil {
+		m.banner = i18n.T("banner.not_connected")
+		return false
+	}
+	command := ":notes"
+	if args != "" {
+		command += " " + args
+	}
+	msg := shared.Message{Sender: m.cfg.Username, Content: command, Type: shared.AdminCommandType}
+	if err := writeAdminCommand(m.conn, msg); err != nil {
+		m.banner = i18n.T("banner.admin_command_connection_lost")
+		return false
+	}
+	return true
+}
+
+// requestNotesEdit asks the server for the edit lock; the editor opens when
+// the server says it is ours
+func (m *model) requestNotesEdit() {
+	if m.sendNotesCommand("edit") {
+		m.pendingNotesEdit = true
+	}
+}
+
+// saveNotes sends the editor's text if it changed since the last save
+func (m *model) saveNotes() {
+	text := m.notes.editor.Value()
+	if text == m.notes.saved || m.conn == nil {
+		return
+	}
+	msg := shared.Message{Sender: m.cfg.Username, Content: text, Type: shared.NotesMessageType}
+	if err := writeFrame(m.conn, msg); err != nil {
+		m.banner = i18n.T("banner.send_connection_lost")
+		return
+	}
+	m.notes.saved = text
+}
+
+// updateNotes handles keys while the notes are open. Viewing: e edits, esc
+// closes. Editing: ctrl+s saves, esc saves, gives up the lock and closes.
+func (m *model) updateNotes(v tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	if m.notes.editing {
+		switch v.String() {
+		case "ctrl+s":
+			m.saveNotes()
+		case "esc":
+			m.saveNotes()
+			m.sendNotesCommand("done")
+			m.notes.editing = false
+			m.showNotes = false
+		default:
+			m.notes.editor, cmd = m.notes.editor.Update(v)
+		}
+		return cmd
+	}
+	switch v.String() {
+	case "esc", "q", "ctrl+c":
+		m.showNotes = false
+	case "e":
+		if editor := m.notes.notes.Editor; editor != "" {
+			m.notes.status = i18n.T("notes.title_locked", displayName(editor))
+			return nil
+		}
+		m.requestNotesEdit()
+	default:
+		m.notes.view, cmd = m.notes.view.Update(v)
+	}
+	return cmd
+}
+
+// notesView renders the overlay's title, body and key hints
+func (m *model) notesView() string {
+	body, hint := m.notes.view.View(), i18n.T("notes.hint_view")
+	if m.notes.editing {
+		body, hint = m.notes.editor.View(), i18n.T("notes.hint_edit")
+	}
+	if m.notes.status != "" {
+		hint = m.notes.status + "\n" + hint
+	}
+	return m.styles.HelpOverlay.Render(
+		m.styles.User.Render(notesTitle(m.notes.notes, m.notes.editing)) + "\n\n" +
+			body + "\n\n" +
+			m.styles.Time.Render(hint))
+}
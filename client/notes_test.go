@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestApplyNotes(t *testing.T) {
+	m := &model{cfg: config.Config{Username: "alice"}, width: 100, height: 40}
+
+	// Updates nobody asked for stay closed
+	m.applyNotes(shared.ChannelNotes{Text: "agenda", Version: 1, Editor: "bob"})
+	if m.showNotes {
+		t.Fatal("Notes opened without being asked for")
+	}
+
+	m.pendingNotes = true
+	m.applyNotes(shared.ChannelNotes{Text: "agenda", Version: 1})
+	if !m.showNotes || m.notes.editing || m.pendingNotes {
+		t.Fatalf("Expected the read-only view, got show=%v editing=%v", m.showNotes, m.notes.editing)
+	}
+	if !strings.Contains(m.notesView(), "agenda") {
+		t.Errorf("Notes view should show the text")
+	}
+
+	// Another session of ours holding the lock doesn't open an editor here
+	m.applyNotes(shared.ChannelNotes{Text: "agenda", Version: 1, Editor: "alice"})
+	if m.notes.editing {
+		t.Error("Editor opened without asking for the lock")
+	}
+
+	m.pendingNotesEdit = true
+	m.applyNotes(shared.ChannelNotes{Text: "agenda", Version: 1, Editor: "alice"})
+	if !m.notes.editing || m.notes.editor.Value() != "agenda" || m.pendingNotesEdit {
+		t.Fatalf("Expected the editor with the notes, got editing=%v %q", m.notes.editing, m.notes.editor.Value())
+	}
+	m.updateNotes(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	if m.notes.editor.Value() != "agenda!" {
+		t.Errorf("Typing should edit the notes, got %q", m.notes.editor.Value())
+	}
+
+	// Losing the lock drops back to the read-only view
+	m.applyNotes(shared.ChannelNotes{Text: "agenda", Version: 2, Editor: "bob"})
+	if m.notes.editing || m.notes.status == "" {
+		t.Errorf("Expected the editor to close with a warning, got editing=%v status=%q", m.notes.editing, m.notes.status)
+	}
+	m.updateNotes(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if m.pendingNotesEdit || !strings.Contains(m.notes.status, "bob") {
+		t.Errorf("Editing should be refused while bob holds the lock, got status %q", m.notes.status)
+	}
+	m.updateNotes(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.showNotes {
+		t.Error("Esc should close the notes")
+	}
+}
+
+func TestNotesTitle(t *testing.T) {
+	tests := []struct {
+		notes   shared.ChannelNotes
+		editing bool
+		want    string
+	}{
+		{shared.ChannelNotes{}, false, "empty"},
+		{shared.ChannelNotes{Version: 3, UpdatedBy: "bob"}, false, "last edited by bob"},
+		{shared.ChannelNotes{Version: 3, Editor: "carol"}, false, "carol is editing"},
+		{shared.ChannelNotes{Version: 3, Editor: "alice"}, true, "you are editing"},
+	}
+	for _, tt := range tests {
+		if got := notesTitle(tt.notes, tt.editing); !strings.Contains(got, tt.want) {
+			t.Errorf("notesTitle(%+v, %v) = %q, want it to contain %q", tt.notes, tt.editing, got, tt.want)
+		}
+	}
+}
@@ -7,6 +7,10 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/client/termux"
+	"github.com/Cod-e-Codes/marchat/shared"
 )
 
 // NotificationLevel defines the priority/type of notification
@@ -55,6 +59,9 @@ type NotificationConfig struct {
 	// Focus mode (temporary mute)
 	FocusModeEnabled bool
 	FocusModeUntil   time.Time
+
+	// Rules that escalate or silence chat messages, first match wins
+	Rules []config.NotifyRule
 }
 
 // DefaultNotificationConfig returns sensible defaults
@@ -103,6 +110,15 @@ func NewNotificationManager(config NotificationConfig) *NotificationManager {
 
 // detectDesktopSupport checks if desktop notifications are available
 func (nm *NotificationManager) detectDesktopSupport() {
+	// Termux: termux-notification from the termux-api package
+	if isTermux() {
+		if termux.Available("termux-notification") {
+			nm.desktopSupported = true
+			nm.notifyCommand = "termux-notification"
+			return
+		}
+	}
+
 	switch runtime.GOOS {
 	case "darwin":
 		// macOS: osascript
@@ -150,8 +166,41 @@ func (nm *NotificationManager) Notify(sender, content string, level Notification
 	}
 
 	if shouldDesktop {
-		nm.sendDesktopNotification(sender, content)
+		nm.sendDesktopNotification(sender, content, level)
+	}
+}
+
+// NotifyMessage notifies about a chat message once the rules have had their
+// say: a matching rule silences it or escalates it to urgent
+func (nm *NotificationManager) NotifyMessage(msg shared.Message, channel string, level NotificationLevel) {
+	switch nm.RuleAction(msg, channel) {
+	case notifySilence:
+		return
+	case notifyEscalate:
+		level = NotificationLevelUrgent
 	}
+	nm.Notify(displayName(msg.Sender), msg.Content, level)
+}
+
+// RuleAction returns the action of the first rule matching msg, or "" when
+// none does
+func (nm *NotificationManager) RuleAction(msg shared.Message, channel string) string {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	for _, rule := range nm.config.Rules {
+		if matchNotifyRule(rule, msg, channel) {
+			return rule.Action
+		}
+	}
+	return ""
+}
+
+// SetRules replaces the notification rules
+func (nm *NotificationManager) SetRules(rules []config.NotifyRule) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	nm.config.Rules = rules
 }
 
 // shouldNotifyBell determines if a bell should be played
@@ -215,7 +264,7 @@ func (nm *NotificationManager) playBell() {
 }
 
 // sendDesktopNotification sends a platform-specific desktop notification
-func (nm *NotificationManager) sendDesktopNotification(title, message string) {
+func (nm *NotificationManager) sendDesktopNotification(title, message string, level NotificationLevel) {
 	nm.lastDesktop = time.Now()
 
 	// Truncate long messages
@@ -226,15 +275,23 @@ func (nm *NotificationManager) sendDesktopNotification(title, message string) {
 	go func() {
 		var cmd *exec.Cmd
 
-		switch runtime.GOOS {
-		case "darwin":
+		switch {
+		case nm.notifyCommand == "termux-notification":
+			_ = termux.Notify(termux.Notification{
+				Title:    title,
+				Content:  message,
+				Group:    "marchat",
+				Priority: termuxPriority(level),
+			})
+
+		case runtime.GOOS == "darwin":
 			// macOS osascript
 			script := fmt.Sprintf(`display notification "%s" with title "%s"`,
 				escapeForAppleScript(message),
 				escapeForAppleScript(title))
 			cmd = exec.Command("osascript", "-e", script)
 
-		case "linux":
+		case runtime.GOOS == "linux":
 			// Linux notify-send
 			cmd = exec.Command("notify-send",
 				title,
@@ -242,7 +299,7 @@ func (nm *NotificationManager) sendDesktopNotification(title, message string) {
 				"-u", "normal",
 				"-t", "5000") // 5 second timeout
 
-		case "windows":
+		case runtime.GOOS == "windows":
 			// Windows PowerShell toast
 			script := fmt.Sprintf(`
 				[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
@@ -273,6 +330,18 @@ func (nm *NotificationManager) sendDesktopNotification(title, message string) {
 	}()
 }
 
+// termuxPriority makes mentions and DMs pop up on Android, and urgent
+// messages above everything else
+func termuxPriority(level NotificationLevel) string {
+	switch level {
+	case NotificationLevelUrgent:
+		return termux.PriorityMax
+	case NotificationLevelMention, NotificationLevelDM:
+		return termux.PriorityHigh
+	}
+	return termux.PriorityDefault
+}
+
 // isQuietHours checks if we're currently in quiet hours
 func (nm *NotificationManager) isQuietHours() bool {
 	if !nm.config.QuietHoursEnabled {
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// What a notification rule matches and what it does
+const (
+	notifyMatchKeyword = "keyword"
+	notifyMatchSender  = "sender"
+	notifyMatchChannel = "channel"
+
+	notifyEscalate = "escalate"
+	notifySilence  = "silence"
+)
+
+// defaultChannel is the channel messages are posted in until the server
+// names it in a topic or notes update; marchat servers have one room
+const defaultChannel = "room"
+
+// matchNotifyRule reports whether msg, posted in channel, matches rule.
+// Keywords are found anywhere in the text, senders by username or display
+// name, all without regard to case.
+func matchNotifyRule(rule config.NotifyRule, msg shared.Message, channel string) bool {
+	switch rule.Match {
+	case notifyMatchKeyword:
+		return rule.Value != "" && strings.Contains(strings.ToLower(msg.Content), strings.ToLower(rule.Value))
+	case notifyMatchSender:
+		return strings.EqualFold(rule.Value, msg.Sender) || strings.EqualFold(rule.Value, displayName(msg.Sender))
+	case notifyMatchChannel:
+		return strings.EqualFold(rule.Value, channel)
+	}
+	return false
+}
+
+// parseNotifyRule reads a rule written as "<escalate|silence>
+// <keyword|sender|channel> <value>"; a keyword may be several words
+func parseNotifyRule(spec string) (config.NotifyRule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 3 {
+		return config.NotifyRule{}, errors.New(i18n.T("notify_rules.usage"))
+	}
+	rule := config.NotifyRule{Action: strings.ToLower(fields[0]), Match: strings.ToLower(fields[1])}
+	if rule.Action != notifyEscalate && rule.Action != notifySilence {
+		return config.NotifyRule{}, errors.New(i18n.T("notify_rules.usage"))
+	}
+	switch rule.Match {
+	case notifyMatchKeyword:
+		rule.Value = strings.Join(fields[2:], " ")
+	case notifyMatchSender:
+		rule.Value = strings.TrimPrefix(fields[2], "@")
+	case notifyMatchChannel:
+		rule.Value = strings.TrimPrefix(fields[2], "#")
+	default:
+		return config.NotifyRule{}, errors.New(i18n.T("notify_rules.usage"))
+	}
+	if rule.Value == "" || (rule.Match != notifyMatchKeyword && len(fields) > 3) {
+		return config.NotifyRule{}, errors.New(i18n.T("notify_rules.usage"))
+	}
+	return rule, nil
+}
+
+// formatNotifyRule writes a rule the way parseNotifyRule reads it
+func formatNotifyRule(rule config.NotifyRule) string {
+	value := rule.Value
+	switch rule.Match {
+	case notifyMatchSender:
+		value = "@" + rule.Value
+	case notifyMatchChannel:
+		value = "#" + rule.Value
+	}
+	return rule.Action + " " + rule.Match + " " + value
+}
+
+// notifyRulesOverlay lists the notification rules in the order they are
+// checked, with a line for typing a new one
+type notifyRulesOverlay struct {
+	rules    []config.NotifyRule
+	selected int
+	adding   bool
+	input    textinput.Model
+	errorMsg string
+}
+
+// newNotifyRulesOverlay shows a copy of rules
+func newNotifyRulesOverlay(rules []config.NotifyRule) notifyRulesOverlay {
+	input := textinput.New()
+	input.Placeholder = i18n.T("notify_rules.placeholder")
+	input.CharLimit = 200
+	input.Width = 50
+	return notifyRulesOverlay{rules: slices.Clone(rules), input: input}
+}
+
+// Update handles a key and reports whether the rules changed. Up and down
+// select, a adds, d deletes, shift+up and shift+down move the selected rule.
+func (o *notifyRulesOverlay) Update(v tea.KeyMsg) (changed bool, cmd tea.Cmd) {
+	o.errorMsg = ""
+	if o.adding {
+		switch v.String() {
+		case "esc":
+			o.adding = false
+			o.input.Blur()
+		case "enter":
+			rule, err := parseNotifyRule(o.input.Value())
+			if err != nil {
+				o.errorMsg = err.Error()
+				return false, nil
+			}
+			o.rules = append(o.rules, rule)
+			o.selected = len(o.rules) - 1
+			o.adding = false
+			o.input.Blur()
+			return true, nil
+		default:
+			o.input, cmd = o.input.Update(v)
+		}
+		return false, cmd
+	}
+	switch v.String() {
+	case "up", "k":
+		o.selected = max(o.selected-1, 0)
+	case "down", "j":
+		o.selected = max(min(o.selected+1, len(o.rules)-1), 0)
+	case "a":
+		o.adding = true
+		o.input.SetValue("")
+		return false, o.input.Focus()
+	case "d", "delete":
+		if o.selected < len(o.rules) {
+			o.rules = slices.Delete(o.rules, o.selected, o.selected+1)
+			o.selected = max(min(o.selected, len(o.rules)-1), 0)
+			return true, nil
+		}
+	case "shift+up", "K":
+		if o.selected > 0 && o.selected < len(o.rules) {
+			o.rules[o.selected-1], o.rules[o.selected] = o.rules[o.selected], o.rules[o.selected-1]
+			o.selected--
+			return true, nil
+		}
+	case "shift+down", "J":
+		if o.selected+1 < len(o.rules) {
+			o.rules[o.selected+1], o.rules[o.selected] = o.rules[o.selected], o.rules[o.selected+1]
+			o.selected++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// View lists the rules with the selected one marked
+func (o *notifyRulesOverlay) View(styles themeStyles) string {
+	var b strings.Builder
+	b.WriteString(styles.User.Render(i18n.T("notify_rules.title")) + "\n\n")
+	if len(o.rules) == 0 {
+		b.WriteString(styles.Time.Render(i18n.T("notify_rules.empty")) + "\n")
+	}
+	for i, rule := range o.rules {
+		line := fmt.Sprintf("%d. %s", i+1, formatNotifyRule(rule))
+		if i == o.selected && !o.adding {
+			b.WriteString(styles.Mention.Render("▶ "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	hint := i18n.T("notify_rules.hint")
+	if o.adding {
+		b.WriteString("\n" + o.input.View() + "\n")
+		hint = i18n.T("notify_rules.hint_add")
+	}
+	if o.errorMsg != "" {
+		hint = o.errorMsg + "\n" + hint
+	}
+	b.WriteString("\n" + styles.Time.Render(hint))
+	return b.String()
+}
+
+// notifyChannel is the channel incoming messages are posted in
+func (m *model) notifyChannel() string {
+	if m.channel != "" {
+		return m.channel
+	}
+	return defaultChannel
+}
+
+// updateNotifyRules handles keys while the rules overlay is open, saving
+// every change to config.json and the profile
+func (m *model) updateNotifyRules(v tea.KeyMsg) tea.Cmd {
+	if !m.notifyRules.adding && (v.String() == "esc" || v.String() == "q") {
+		m.showNotifyRules = false
+		return nil
+	}
+	changed, cmd := m.notifyRules.Update(v)
+	if changed {
+		rules := slices.Clone(m.notifyRules.rules)
+		m.notificationManager.SetRules(rules)
+		m.cfg.NotifyRules = rules
+		_ = config.SaveConfig(m.configFilePath, m.cfg)
+		if loader, err := config.NewInteractiveConfigLoader(); err == nil {
+			_ = loader.SetProfileNotifyRules(m.cfg.ServerURL, m.cfg.Username, rules)
+		}
+	}
+	return cmd
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestParseNotifyRule(t *testing.T) {
+	tests := []struct {
+		spec string
+		want config.NotifyRule
+		ok   bool
+	}{
+		{"escalate keyword prod is down", config.NotifyRule{Match: "keyword", Value: "prod is down", Action: "escalate"}, true},
+		{"Silence Sender @Bot", config.NotifyRule{Match: "sender", Value: "Bot", Action: "silence"}, true},
+		{"silence channel #room", config.NotifyRule{Match: "channel", Value: "room", Action: "silence"}, true},
+		{"silence sender alice bob", config.NotifyRule{}, false},
+		{"mute sender bot", config.NotifyRule{}, false},
+		{"escalate topic deploy", config.NotifyRule{}, false},
+		{"escalate keyword", config.NotifyRule{}, false},
+		{"silence sender @", config.NotifyRule{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseNotifyRule(tt.spec)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseNotifyRule(%q) = %+v, %v", tt.spec, got, err)
+		}
+		if tt.ok {
+			if again, _ := parseNotifyRule(formatNotifyRule(got)); again != got {
+				t.Errorf("formatNotifyRule(%+v) = %q does not parse back", got, formatNotifyRule(got))
+			}
+		}
+	}
+}
+
+func TestNotifyRuleAction(t *testing.T) {
+	updateDisplayNames([]string{"deploybot"}, map[string]string{"deploybot": "Robo"})
+	defer updateDisplayNames([]string{"deploybot"}, nil)
+
+	nm := NewNotificationManager(NotificationConfig{Rules: []config.NotifyRule{
+		{Match: "keyword", Value: "PROD DOWN", Action: "escalate"},
+		{Match: "sender", Value: "robo", Action: "silence"},
+		{Match: "channel", Value: "Random", Action: "silence"},
+	}})
+	tests := []struct {
+		msg     shared.Message
+		channel string
+		want    string
+	}{
+		{shared.Message{Sender: "deploybot", Content: "build passed"}, "room", notifySilence},
+		{shared.Message{Sender: "deploybot", Content: "prod down!"}, "room", notifyEscalate}, // the first match wins
+		{shared.Message{Sender: "alice", Content: "lunch?"}, "random", notifySilence},
+		{shared.Message{Sender: "alice", Content: "lunch?"}, "room", ""},
+	}
+	for _, tt := range tests {
+		if got := nm.RuleAction(tt.msg, tt.channel); got != tt.want {
+			t.Errorf("RuleAction(%+v, %q) = %q, want %q", tt.msg, tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyRulesOverlay(t *testing.T) {
+	o := newNotifyRulesOverlay([]config.NotifyRule{{Match: "sender", Value: "bot", Action: "silence"}})
+	key := func(k string) bool {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "shift+up":
+			msg = tea.KeyMsg{Type: tea.KeyShiftUp}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		changed, _ := o.Update(msg)
+		return changed
+	}
+
+	key("a")
+	key("escalate keyword deploy")
+	if !key("enter") || len(o.rules) != 2 || o.selected != 1 || o.adding {
+		t.Fatalf("Expected the rule to be added and selected, got %+v", o)
+	}
+	if !key("shift+up") || o.rules[0].Value != "deploy" || o.selected != 0 {
+		t.Errorf("Expected the new rule to move first, got %+v", o.rules)
+	}
+
+	key("a")
+	key("louder keyword x")
+	if key("enter") || o.errorMsg == "" || !o.adding {
+		t.Errorf("A bad rule should stay in the input with an error, got %+v", o)
+	}
+	o.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	if !key("d") || !reflect.DeepEqual(o.rules, []config.NotifyRule{{Match: "sender", Value: "bot", Action: "silence"}}) {
+		t.Errorf("Expected the selected rule to be deleted, got %+v", o.rules)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// Users the server reports as away, keyed by lowercase username
+var (
+	awayMu    sync.RWMutex
+	awayUsers = map[string]bool{}
+)
+
+// updatePresence replaces the away set with the one from a user list
+func updatePresence(away []string) {
+	awayMu.Lock()
+	defer awayMu.Unlock()
+	clear(awayUsers)
+	for _, u := range away {
+		awayUsers[strings.ToLower(u)] = true
+	}
+}
+
+// isAway reports whether username has gone idle
+func isAway(username string) bool {
+	awayMu.RLock()
+	defer awayMu.RUnlock()
+	return awayUsers[strings.ToLower(username)]
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPresence(t *testing.T) {
+	t.Cleanup(func() { updatePresence(nil) })
+	updatePresence([]string{"Bob"})
+	if !isAway("bob") || isAway("alice") {
+		t.Error("Expected only bob to be away")
+	}
+
+	list := renderUserList([]string{"alice", "bob"}, "alice", baseThemeStyles(), 18, false, -1)
+	if !strings.Contains(list, "○ bob") || !strings.Contains(list, "• alice") {
+		t.Errorf("Expected bob marked away in the user list:\n%s", list)
+	}
+
+	// Each list replaces the last
+	updatePresence(nil)
+	if isAway("bob") {
+		t.Error("Expected bob to be back")
+	}
+}
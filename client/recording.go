@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// A session journal, written with --record and played back with
+// `marchat-client replay`, is JSON lines: a header naming the session,
+// then every message and event the server sent, as the client saw it,
+// stamped with when it arrived. Encrypted messages are journaled
+// decrypted, so the file is private to its owner. File and voice note
+// contents are left out; their names and sizes are kept.
+
+// journalSession heads a journal
+type journalSession struct {
+	Server string `json:"server"`
+	User   string `json:"user"`
+}
+
+// journalEntry is one line of a journal
+type journalEntry struct {
+	At      time.Time       `json:"at"`
+	Session *journalSession `json:"session,omitempty"`
+	Message *shared.Message `json:"message,omitempty"`
+	Event   *wsMsg          `json:"event,omitempty"`
+}
+
+// sessionRecorder appends a session's events to a journal
+type sessionRecorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// openRecorder starts a journal at path, appending to one already there
+func openRecorder(path, server, user string) (*sessionRecorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	r := &sessionRecorder{f: f, enc: json.NewEncoder(f)}
+	if err := r.enc.Encode(journalEntry{At: time.Now(), Session: &journalSession{Server: server, User: user}}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// record journals a message or event from the server. It does nothing on
+// a nil recorder, so callers needn't check whether recording is on.
+func (r *sessionRecorder) record(v tea.Msg) {
+	if r == nil {
+		return
+	}
+	entry := journalEntry{At: time.Now()}
+	switch v := v.(type) {
+	case shared.Message:
+		if v.File != nil {
+			v.File = &shared.FileMeta{Filename: v.File.Filename, Size: v.File.Size}
+		}
+		if v.Audio != nil {
+			audio := *v.Audio
+			audio.Data = nil
+			v.Audio = &audio
+		}
+		entry.Message = &v
+	case wsMsg:
+		entry.Event = &v
+	default:
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(entry); err != nil {
+		log.Printf("Warning: could not record the session: %v", err)
+	}
+}
+
+// Close finishes the journal
+func (r *sessionRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// Replay speeds, slowest to fastest
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4, 8, 16, 32, 64}
+
+// maxReplayGap is the longest pause a replay keeps between events at 1×;
+// quiet stretches of a session are shortened to it
+const maxReplayGap = 5 * time.Second
+
+// replayDoneMsg says every event in the journal has been played
+type replayDoneMsg struct{ events int }
+
+// replayer plays a journal back into the chat UI
+type replayer struct {
+	path    string
+	session journalSession
+	entries []journalEntry
+
+	mu     sync.Mutex
+	speed  int // index into replaySpeeds
+	paused bool
+}
+
+// loadJournal reads the journal at path. Lines that can't be read, such as
+// the last one of a session that crashed mid-write, are skipped.
+func loadJournal(path string) (*replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := &replayer{path: path, speed: 2}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		switch {
+		case entry.Session != nil:
+			// Journals appended to across sessions keep the first header
+			if r.session.Server == "" {
+				r.session = *entry.Session
+			}
+		case entry.Message != nil || entry.Event != nil:
+			r.entries = append(r.entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(r.entries) == 0 {
+		return nil, fmt.Errorf("%s holds no recorded events", path)
+	}
+	return r, nil
+}
+
+// setSpeed picks a replay speed by how many steps faster (or slower, when
+// negative) than 1× it is, returning it
+func (r *replayer) setSpeed(steps int) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.speed = min(max(r.speed+steps, 0), len(replaySpeeds)-1)
+	return replaySpeeds[r.speed]
+}
+
+// togglePause pauses or resumes playback, returning whether it is paused
+func (r *replayer) togglePause() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = !r.paused
+	return r.paused
+}
+
+// state is the current speed and whether playback is paused
+func (r *replayer) state() (speed float64, paused bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return replaySpeeds[r.speed], r.paused
+}
+
+// wait lets gap of recorded time pass at the current speed, checking
+// often enough that a speed change or pause takes effect at once
+func (r *replayer) wait(gap time.Duration) {
+	const step = 20 * time.Millisecond
+	for gap > 0 {
+		time.Sleep(step)
+		if speed, paused := r.state(); !paused {
+			gap -= time.Duration(float64(step) * speed)
+		}
+	}
+}
+
+// play sends the recorded events to out with their original spacing,
+// then replayDoneMsg
+func (r *replayer) play(out chan<- tea.Msg) {
+	for i, entry := range r.entries {
+		if i > 0 {
+			r.wait(min(entry.At.Sub(r.entries[i-1].At), maxReplayGap))
+		}
+		if entry.Message != nil {
+			out <- *entry.Message
+		} else {
+			out <- *entry.Event
+		}
+	}
+	out <- replayDoneMsg{events: len(r.entries)}
+}
+
+// formatSpeed shows a replay speed as 0.5×, 1× or 16×
+func formatSpeed(speed float64) string {
+	return strconv.FormatFloat(speed, 'f', -1, 64) + "×"
+}
+
+// replayKey handles the keys that control a replay. Other keys reach the
+// chat UI as usual, apart from Enter: a replay can't send anything. While a
+// panel is open its keys are its own.
+func (m *model) replayKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if m.showHelp || m.showCodeSnippet || m.showFilePicker || m.showNotes || m.showDiagram ||
+		m.showNotifyRules || m.showEmojiPicker || m.showSnippetViewer || m.showCommandForm {
+		return nil, false
+	}
+	switch {
+	case msg.String() == "q", msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
+		return tea.Quit, true
+	case msg.String() == " ":
+		if m.replay.togglePause() {
+			m.banner = i18n.T("banner.replay_paused")
+		} else {
+			speed, _ := m.replay.state()
+			m.banner = i18n.T("banner.replay_speed", formatSpeed(speed))
+		}
+		return nil, true
+	case msg.String() == "+" || msg.String() == "=":
+		m.banner = i18n.T("banner.replay_speed", formatSpeed(m.replay.setSpeed(1)))
+		return nil, true
+	case msg.String() == "-":
+		m.banner = i18n.T("banner.replay_speed", formatSpeed(m.replay.setSpeed(-1)))
+		return nil, true
+	case key.Matches(msg, m.keys.Send):
+		m.banner = i18n.T("banner.replay_read_only")
+		return nil, true
+	}
+	return nil, false
+}
+
+// runReplay is `marchat-client replay [--speed N] <journal>`
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	speed := fs.Float64("speed", 1, "Playback speed, e.g. 0.5 or 4 (+ and - change it while playing)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: marchat-client replay [--speed N] <journal>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("replay needs one journal file")
+	}
+	r, err := loadJournal(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	// Start at the listed speed nearest the one asked for
+	for i, s := range replaySpeeds {
+		if s <= *speed {
+			r.speed = i
+		}
+	}
+
+	// The recorded user sees the session as they did, in their saved look
+	cfg := config.Config{Username: r.session.User, ServerURL: r.session.Server, Theme: *theme}
+	if path, err := config.GetConfigPath(); err == nil {
+		if saved, err := config.LoadConfig(path); err == nil {
+			cfg.Theme = cmp.Or(cfg.Theme, saved.Theme)
+			cfg.TwentyFourHour, cfg.TimeZone, cfg.Locale = saved.TwentyFourHour, saved.TimeZone, saved.Locale
+		}
+	}
+	i18n.SetLocale(i18n.Detect(cfg.Locale))
+	m := newModel(cfg, "", nil, nil)
+	m.replay = r
+	m.textarea.Blur()
+	m.textarea.Placeholder = i18n.T("input.placeholder_replay")
+	m.banner = i18n.T("banner.replay_start", fs.Arg(0), formatSpeed(replaySpeeds[r.speed]))
+	runTUI(m)
+	return nil
+}
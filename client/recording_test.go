@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestRecordAndLoadJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	r, err := openRecorder(path, "ws://localhost:8080/ws", "alice")
+	if err != nil {
+		t.Fatalf("openRecorder failed: %v", err)
+	}
+	r.record(shared.Message{Sender: "bob", Content: "hi"})
+	r.record(shared.Message{Sender: "bob", Type: shared.FileMessageType, File: &shared.FileMeta{Filename: "notes.txt", Size: 5, Data: []byte("hello")}})
+	r.record(wsMsg{Type: "userlist"})
+	r.record(wsConnected(true)) // not a server event
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A line cut short by a crash is skipped
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	_, _ = f.WriteString(`{"at":"2026-01-01T00:00:00Z","message":{"sender":"ca`)
+	f.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a private journal, got %v (%v)", info.Mode().Perm(), err)
+	}
+	replay, err := loadJournal(path)
+	if err != nil {
+		t.Fatalf("loadJournal failed: %v", err)
+	}
+	if replay.session.User != "alice" || replay.session.Server != "ws://localhost:8080/ws" {
+		t.Errorf("Unexpected session %+v", replay.session)
+	}
+	if len(replay.entries) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(replay.entries))
+	}
+	if file := replay.entries[1].Message.File; file.Filename != "notes.txt" || file.Size != 5 || file.Data != nil {
+		t.Errorf("Expected the file's name and size without its contents, got %+v", file)
+	}
+	if replay.entries[2].Event == nil || replay.entries[2].Event.Type != "userlist" {
+		t.Errorf("Expected the user list event, got %+v", replay.entries[2])
+	}
+}
+
+func TestLoadEmptyJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.jsonl")
+	r, err := openRecorder(path, "ws://localhost:8080/ws", "alice")
+	if err != nil {
+		t.Fatalf("openRecorder failed: %v", err)
+	}
+	r.Close()
+	if _, err := loadJournal(path); err == nil {
+		t.Error("Expected a journal without events refused")
+	}
+}
+
+func TestReplayPlaysInOrder(t *testing.T) {
+	start := time.Now()
+	r := &replayer{speed: len(replaySpeeds) - 1, entries: []journalEntry{
+		{At: start, Message: &shared.Message{Content: "one"}},
+		{At: start.Add(time.Second), Event: &wsMsg{Type: "userlist"}},
+		{At: start.Add(time.Hour), Message: &shared.Message{Content: "two"}}, // shortened to maxReplayGap
+	}}
+	out := make(chan tea.Msg, 10)
+	done := make(chan struct{})
+	go func() { r.play(out); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the replay to finish without waiting out the quiet hour")
+	}
+	close(out)
+	var got []tea.Msg
+	for msg := range out {
+		got = append(got, msg)
+	}
+	if len(got) != 4 || got[0].(shared.Message).Content != "one" || got[1].(wsMsg).Type != "userlist" ||
+		got[2].(shared.Message).Content != "two" || got[3] != (replayDoneMsg{events: 3}) {
+		t.Errorf("Unexpected playback %+v", got)
+	}
+}
+
+func TestReplaySpeed(t *testing.T) {
+	r := &replayer{speed: 2}
+	if got := r.setSpeed(1); got != 2 {
+		t.Errorf("Expected 2×, got %v", got)
+	}
+	if got := r.setSpeed(-10); got != 0.25 {
+		t.Errorf("Expected the slowest speed, got %v", got)
+	}
+	if got := r.setSpeed(100); got != 64 {
+		t.Errorf("Expected the fastest speed, got %v", got)
+	}
+	if !r.togglePause() {
+		t.Error("Expected the replay paused")
+	}
+	if got := formatSpeed(0.5); got != "0.5×" {
+		t.Errorf("Unexpected speed %q", got)
+	}
+}
+
+func TestReplayOnZeroSizedTerminal(t *testing.T) {
+	m := newModel(config.Config{Username: "alice"}, "", nil, nil)
+	m.Update(tea.WindowSizeMsg{})
+	m.Update(shared.Message{Sender: "bob", Content: "hello", CreatedAt: time.Now()})
+	if m.viewport.Height < 0 || m.userListViewport.Height < 0 {
+		t.Errorf("Expected non-negative viewport heights, got %d and %d", m.viewport.Height, m.userListViewport.Height)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// connectionRejected is a connection the server refused during the
+// handshake, or later closed for idling or a revoked session, with one of
+// the shared.Close* codes
+type connectionRejected struct {
+	code   int
+	reason string
+}
+
+func (e connectionRejected) Error() string {
+	return e.guidance()
+}
+
+// retry reports whether connecting again later may succeed
+func (e connectionRejected) retry() bool {
+	return e.code == shared.CloseServerFull || e.code == shared.CloseIdle
+}
+
+// retryable reports whether err leaves any point in connecting again:
+// everything but a handshake refusal that waiting won't change
+func retryable(err error) bool {
+	var usernameErr wsUsernameError
+	if errors.As(err, &usernameErr) {
+		return false
+	}
+	var rejection connectionRejected
+	return !errors.As(err, &rejection) || rejection.retry()
+}
+
+// guidance explains the refusal and what the user can do about it
+func (e connectionRejected) guidance() string {
+	switch e.code {
+	case shared.CloseBanned:
+		return i18n.T("banner.rejected_banned")
+	case shared.CloseServerFull:
+		return i18n.T("banner.rejected_server_full")
+	case shared.CloseUnsupportedVersion:
+		return i18n.T("banner.rejected_unsupported_version", shared.ClientVersion, e.reason)
+	case shared.CloseNotAllowed:
+		return i18n.T("banner.rejected_not_allowed", e.reason)
+	case shared.CloseSpectatorsRefused:
+		return i18n.T("banner.rejected_spectators")
+	case shared.CloseChallengeFailed:
+		return i18n.T("banner.rejected_challenge", e.reason)
+	case shared.CloseNotAdmin:
+		return i18n.T("banner.rejected_not_admin")
+	case shared.CloseIdle:
+		return i18n.T("banner.idle_disconnected", e.reason)
+	case shared.CloseSessionRevoked:
+		return i18n.T("banner.session_revoked", e.reason)
+	}
+	return i18n.T("banner.rejected", e.reason)
+}
+
+// rejectionFromClose recognises the server's shared.Close* codes. Username
+// codes become a wsUsernameError, which the client already knows not to
+// retry.
+func rejectionFromClose(err error, username string) (error, bool) {
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) {
+		return nil, false
+	}
+	switch ce.Code {
+	case shared.CloseUsernameTaken:
+		return wsUsernameError{message: usernameRejectionText(shared.UsernameRejection{Code: shared.UsernameTaken}, username)}, true
+	case shared.CloseUsernameInvalid:
+		return wsUsernameError{message: ce.Text}, true
+	case shared.CloseInvalidHandshake, shared.CloseBanned, shared.CloseServerFull, shared.CloseUnsupportedVersion,
+		shared.CloseNotAllowed, shared.CloseSpectatorsRefused, shared.CloseChallengeFailed, shared.CloseNotAdmin, shared.CloseIdle,
+		shared.CloseSessionRevoked:
+		return connectionRejected{code: ce.Code, reason: ce.Text}, true
+	}
+	return nil, false
+}
+
+// usernameRejection decodes the "username_rejected" message the server
+// sends before closing with a username close code
+func usernameRejection(ws wsMsg, username string) (wsUsernameError, bool) {
+	if ws.Type != "username_rejected" {
+		return wsUsernameError{}, false
+	}
+	var rejection shared.UsernameRejection
+	if err := json.Unmarshal(ws.Data, &rejection); err != nil {
+		return wsUsernameError{}, false
+	}
+	return wsUsernameError{message: usernameRejectionText(rejection, username)}, true
+}
+
+// usernameRejectionText renders why the server refused username, falling
+// back to the server's own wording for codes this client doesn't know
+func usernameRejectionText(r shared.UsernameRejection, username string) string {
+	switch r.Code {
+	case shared.UsernameEmpty:
+		return i18n.T("username_rejected.empty")
+	case shared.UsernameTooShort:
+		return i18n.T("username_rejected.too_short", r.MinLength)
+	case shared.UsernameTooLong:
+		return i18n.T("username_rejected.too_long", r.MaxLength)
+	case shared.UsernameInvalidChars:
+		return i18n.T("username_rejected.invalid_chars")
+	case shared.UsernamePattern:
+		return i18n.T("username_rejected.pattern")
+	case shared.UsernameReserved:
+		return i18n.T("username_rejected.reserved", username)
+	case shared.UsernameBlocked:
+		return i18n.T("username_rejected.blocked")
+	case shared.UsernameTaken:
+		return i18n.T("username_rejected.taken")
+	}
+	return r.Reason
+}
+
+// readRefusal reads what the server sent before closing a connection it
+// refused, returning its reason or nil if it gave none
+func readRefusal(conn *websocket.Conn, username string) error {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	codec := shared.CodecFor(conn.Subprotocol())
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if rejection, ok := rejectionFromClose(err, username); ok {
+				return rejection
+			}
+			return nil
+		}
+		var ws wsMsg
+		if codec.Unmarshal(raw, &ws) == nil {
+			if rejection, ok := usernameRejection(ws, username); ok {
+				return rejection
+			}
+		}
+	}
+}
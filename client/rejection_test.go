@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestUsernameRejectionText(t *testing.T) {
+	tests := []struct {
+		rejection shared.UsernameRejection
+		want      string
+	}{
+		{shared.UsernameRejection{Code: shared.UsernameReserved}, "System is reserved on this server"},
+		{shared.UsernameRejection{Code: shared.UsernameTooShort, MinLength: 3}, "at least 3 characters"},
+		{shared.UsernameRejection{Code: shared.UsernameTaken}, "already taken"},
+		// Codes from newer servers fall back to their reason
+		{shared.UsernameRejection{Code: "future", Reason: "not today"}, "not today"},
+	}
+	for _, tt := range tests {
+		if got := usernameRejectionText(tt.rejection, "System"); !strings.Contains(got, tt.want) {
+			t.Errorf("usernameRejectionText(%s) = %q, want it to contain %q", tt.rejection.Code, got, tt.want)
+		}
+	}
+}
+
+func TestRejectionFromClose(t *testing.T) {
+	closeErr := func(code int, text string) error {
+		return fmt.Errorf("read: %w", &websocket.CloseError{Code: code, Text: text})
+	}
+
+	err, ok := rejectionFromClose(closeErr(shared.CloseUsernameTaken, ""), "alice")
+	var usernameErr wsUsernameError
+	if !ok || !errors.As(err, &usernameErr) || !strings.Contains(usernameErr.message, "already taken") {
+		t.Errorf("Expected a taken username error, got %v", err)
+	}
+
+	err, ok = rejectionFromClose(closeErr(shared.CloseUnsupportedVersion, "v2.0.0"), "alice")
+	if !ok || !strings.Contains(err.Error(), "v2.0.0 or newer") || retryable(err) {
+		t.Errorf("Expected a final upgrade message, got %v", err)
+	}
+	err, _ = rejectionFromClose(closeErr(shared.CloseBanned, "You are banned from this server"), "alice")
+	if !strings.Contains(err.Error(), "banned") || retryable(err) {
+		t.Errorf("Expected a final ban message, got %v", err)
+	}
+	if err, _ := rejectionFromClose(closeErr(shared.CloseServerFull, ""), "alice"); !retryable(err) {
+		t.Error("Expected a full server to be retried")
+	}
+
+	// Ordinary closes and network errors are not refusals
+	if _, ok := rejectionFromClose(closeErr(websocket.CloseGoingAway, ""), "alice"); ok {
+		t.Error("Expected a going-away close not to be a refusal")
+	}
+	if _, ok := rejectionFromClose(errors.New("connection reset"), "alice"); ok {
+		t.Error("Expected a network error not to be a refusal")
+	}
+	if !retryable(errors.New("connection reset")) {
+		t.Error("Expected network errors to be retried")
+	}
+}
+
+func TestRevokedSessionDoesNotReconnect(t *testing.T) {
+	err, ok := rejectionFromClose(&websocket.CloseError{Code: shared.CloseSessionRevoked, Text: "This session was revoked from another device"}, "alice")
+	if !ok || retryable(err) {
+		t.Fatalf("Expected a revoked session to be final, got %v", err)
+	}
+
+	m := newModel(config.Config{Username: "alice"}, "", nil, nil)
+	m.connected = true
+	_, cmd := m.Update(err)
+	if cmd != nil {
+		t.Error("Expected no reconnect after the session was revoked")
+	}
+	if m.connected || !strings.Contains(m.banner, "revoked from another device") {
+		t.Errorf("Expected a disconnected banner giving the reason, got %q", m.banner)
+	}
+}
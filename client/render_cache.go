@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// renderKey identifies a laid-out message block: what the message shows
+// and everything else its layout depends on. Messages have no IDs on the
+// wire, so they are told apart by a hash of their fields.
+type renderKey struct {
+	message   uint64 // see messageHash
+	styles    uint64 // see stylesHash
+	width     int
+	stamp     string // timestamp as shown: 12/24h, zone, relative time and selection
+	name      string // sender's display name
+	own       bool
+	highlight bool
+	header    bool // drawn with its name and time, off inside compact groups
+}
+
+// renderCache keeps laid-out message blocks between redraws, so a redraw
+// only lays out messages that are new, changed or resized. Blocks a redraw
+// didn't use are dropped after it, which keeps the cache to about the size
+// of the message buffer.
+type renderCache struct {
+	mu   sync.Mutex
+	prev map[renderKey]string
+	cur  map[renderKey]string
+}
+
+// messageBlocks is the cache renderMessages draws from
+var messageBlocks = newRenderCache()
+
+func newRenderCache() *renderCache {
+	return &renderCache{prev: make(map[renderKey]string), cur: make(map[renderKey]string)}
+}
+
+// get returns the block for key if it was laid out in this redraw or the
+// last one
+func (c *renderCache) get(key renderKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	block, ok := c.cur[key]
+	if !ok {
+		if block, ok = c.prev[key]; ok {
+			c.cur[key] = block
+		}
+	}
+	return block, ok
+}
+
+// put stores a block laid out in this redraw
+func (c *renderCache) put(key renderKey, block string) {
+	c.mu.Lock()
+	c.cur[key] = block
+	c.mu.Unlock()
+}
+
+// sweep ends a redraw, forgetting blocks it didn't use
+func (c *renderCache) sweep() {
+	c.mu.Lock()
+	c.prev, c.cur = c.cur, make(map[renderKey]string, len(c.cur))
+	c.mu.Unlock()
+}
+
+// resetRenderCache drops every block, for changes the keys don't cover:
+// the custom emoji set and the UI language
+func resetRenderCache() {
+	messageBlocks.mu.Lock()
+	messageBlocks.prev = make(map[renderKey]string)
+	messageBlocks.cur = make(map[renderKey]string)
+	messageBlocks.mu.Unlock()
+}
+
+// messageHash fingerprints the parts of msg that are drawn. File contents
+// are left out; a file is drawn by its name and size.
+func messageHash(msg shared.Message) uint64 {
+	h := fnv.New64a()
+	field := func(s string) {
+		io.WriteString(h, s)
+		h.Write([]byte{0})
+	}
+	field(msg.Sender)
+	field(msg.Content)
+	field(string(msg.Type))
+	field(strconv.FormatInt(msg.CreatedAt.UnixNano(), 10))
+	if msg.File != nil {
+		field(msg.File.Filename)
+		field(strconv.FormatInt(msg.File.Size, 10))
+	}
+	if msg.Audio != nil {
+		field(msg.Audio.Filename)
+		field(fmt.Sprint(msg.Audio.Duration(), msg.Audio.Waveform))
+	}
+	if msg.Preview != nil {
+		field(msg.Preview.Title)
+		field(msg.Preview.SiteName)
+		field(msg.Preview.Description)
+	}
+	return h.Sum64()
+}
+
+// stylesHash fingerprints a theme by rendering a probe with each style
+// message blocks use, so switching themes lays messages out afresh while
+// themes that look the same in this terminal share blocks
+func stylesHash(styles themeStyles) uint64 {
+	h := fnv.New64a()
+	for _, style := range []lipgloss.Style{styles.User, styles.Time, styles.Msg, styles.Mention, styles.Hyperlink} {
+		io.WriteString(h, style.Render("x"))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
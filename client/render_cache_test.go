@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestRenderCacheReusesBlocks(t *testing.T) {
+	t.Cleanup(resetRenderCache)
+	resetRenderCache()
+
+	base := time.Now().Add(-time.Hour)
+	msgs := []shared.Message{
+		{Sender: "alice", Content: "hello", CreatedAt: base},
+		{Sender: "bob", Content: "see https://example.com", CreatedAt: base.Add(time.Second)},
+		{Sender: "me", Content: "hi all", CreatedAt: base.Add(2 * time.Second)},
+	}
+	render := func(width int) string {
+		return renderMessages(msgs, baseThemeStyles(), "me", []string{"alice", "bob"}, width, true)
+	}
+
+	first := render(80)
+	if n := len(messageBlocks.prev); n != 3 {
+		t.Fatalf("Expected a cached block per message, got %d", n)
+	}
+
+	// A redraw with nothing changed uses the cached blocks as they are
+	messageBlocks.mu.Lock()
+	for key, block := range messageBlocks.prev {
+		messageBlocks.prev[key] = "cached:" + block
+	}
+	messageBlocks.mu.Unlock()
+	if again := render(80); strings.Count(again, "cached:") != 3 || strings.ReplaceAll(again, "cached:", "") != first {
+		t.Errorf("Expected the redraw to come from the cache, got %q", again)
+	}
+
+	// Changes the keys cover are laid out afresh
+	msgs[1].Preview = &shared.LinkPreview{URL: "https://example.com", Title: "Example Domain"}
+	if out := render(80); !strings.Contains(out, "Example Domain") || strings.Count(out, "cached:") != 2 {
+		t.Errorf("Expected only the previewed message to be laid out again, got %q", out)
+	}
+	if out := render(60); strings.Contains(out, "cached:") {
+		t.Errorf("A new width should lay every message out again, got %q", out)
+	}
+
+	// Blocks the last redraw didn't use are dropped
+	if n := len(messageBlocks.prev); n != 3 {
+		t.Errorf("Expected only the current blocks to be kept, got %d", n)
+	}
+}
+
+func TestStylesHash(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(profile)
+
+	if stylesHash(getThemeStyles("retro")) == stylesHash(getThemeStyles("modern")) {
+		t.Error("Different themes should not share cached blocks")
+	}
+	if stylesHash(getThemeStyles("retro")) != stylesHash(getThemeStyles("retro")) {
+		t.Error("The same theme should hash the same")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// messageWindow is the part of the chat the viewport shows, counted in
+// lines from the end so it stays put when messages above it are laid out
+// for the first time. The zero window lays out every message.
+type messageWindow struct {
+	fromEnd int // lines from the top of the viewport to the end
+	height  int // lines the viewport shows
+}
+
+// bounds is the window with a viewport's height of margin on either side,
+// so short scrolls stay within what was laid out
+func (w messageWindow) bounds() (lo, hi int) {
+	return max(w.fromEnd-2*w.height, 0), w.fromEnd + w.height
+}
+
+// lineSpan is a range of lines counted from the end of the chat
+type lineSpan struct {
+	lo, hi int
+}
+
+// messagePiece is one message's share of the chat: the spacing and date
+// header before it, its block and the spacing after it
+type messagePiece struct {
+	msg    shared.Message
+	lead   string
+	tail   string
+	key    *renderKey    // nil for kinds that are not cached
+	layout func() string // lays the block out
+}
+
+// layoutMessageWindow joins pieces into the chat, laying out those in win
+// and standing blank lines in for the rest: as many as their cached block
+// takes, or an estimate for blocks never laid out. Pieces are walked from
+// the end, where win is anchored.
+func layoutMessageWindow(pieces []messagePiece, width int, win messageWindow) (string, lineSpan) {
+	texts := make([]string, len(pieces))
+	lo, hi := win.bounds()
+	var span lineSpan
+	fromEnd := 0
+	for i := len(pieces) - 1; i >= 0; i-- {
+		p := pieces[i]
+		var block string
+		cached := false
+		if p.key != nil {
+			block, cached = messageBlocks.get(*p.key)
+		}
+		lines := strings.Count(p.lead+p.tail, "\n") + estimateLines(p.msg, width) - 1
+		if cached {
+			lines = strings.Count(p.lead+block+p.tail, "\n")
+		}
+		if win.height <= 0 || (fromEnd < hi && fromEnd+lines > lo) {
+			if !cached {
+				block = p.layout()
+			}
+			texts[i] = p.lead + block + p.tail
+			lines = strings.Count(texts[i], "\n")
+			if span.hi == 0 {
+				span.lo = fromEnd
+			}
+			span.hi = fromEnd + lines
+		} else {
+			texts[i] = strings.Repeat("\n", lines)
+		}
+		fromEnd += lines
+	}
+	return strings.Join(texts, ""), span
+}
+
+// estimateLines guesses how many lines msg's block takes at width without
+// laying it out: a header, then the content wrapped to the bubble
+func estimateLines(msg shared.Message, width int) int {
+	inner := max(width-4, 1)
+	lines := 1
+	for _, line := range strings.Split(msg.Content, "\n") {
+		lines += max((lipgloss.Width(line)+inner-1)/inner, 1)
+	}
+	return lines
+}
+
+// refreshMessages redraws the chat, laying out only the messages around
+// what the viewport shows. The view keeps its distance from the end, so it
+// doesn't move when blocks above it turn out taller or shorter than
+// estimated.
+func (m *model) refreshMessages() {
+	win := messageWindow{
+		fromEnd: max(m.viewport.TotalLineCount()-1-m.viewport.YOffset, 0),
+		height:  m.viewport.Height,
+	}
+	content, span := renderMessageWindow(m.messages, m.styles, m.cfg.Username, m.users, m.viewport.Width, m.twentyFourHour, win)
+	m.viewport.SetContent(content)
+	m.viewport.SetYOffset(m.viewport.TotalLineCount() - 1 - win.fromEnd)
+	m.messageSpan = span
+}
+
+// followMessageWindow redraws the chat once scrolling brings lines into
+// view that the last redraw didn't lay out
+func (m *model) followMessageWindow() {
+	top := m.viewport.TotalLineCount() - 1 - m.viewport.YOffset
+	if top > m.messageSpan.hi || max(top-m.viewport.Height, 0) < m.messageSpan.lo {
+		m.refreshMessages()
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// windowTestMessages returns n messages, every fifth long enough to wrap
+func windowTestMessages(n int) []shared.Message {
+	base := time.Now().Add(-time.Hour)
+	msgs := make([]shared.Message, n)
+	for i := range msgs {
+		content := fmt.Sprintf("message %d", i)
+		if i%5 == 0 {
+			content += strings.Repeat(" and more", 30)
+		}
+		msgs[i] = shared.Message{Sender: "alice", Content: content, CreatedAt: base.Add(time.Duration(i) * time.Second)}
+	}
+	return msgs
+}
+
+func TestRenderMessageWindowLaysOutVisibleMessages(t *testing.T) {
+	t.Cleanup(resetRenderCache)
+	resetRenderCache()
+
+	msgs := windowTestMessages(maxMessages)
+	content, span := renderMessageWindow(msgs, baseThemeStyles(), "me", nil, 80, true, messageWindow{fromEnd: 10, height: 10})
+	if !strings.Contains(content, "message 99") {
+		t.Error("Expected the last message to be laid out")
+	}
+	if strings.Contains(content, "message 0") || strings.Contains(content, "message 50") {
+		t.Error("Expected messages far above the window to stand in as blank lines")
+	}
+	if n := len(messageBlocks.prev); n == 0 || n > 20 {
+		t.Errorf("Expected only the messages around the window laid out, got %d", n)
+	}
+	if span.lo != 0 || span.hi < 20 {
+		t.Errorf("Expected the window and its margin laid out, got %+v", span)
+	}
+
+	// Blank lines take the place of what the full render draws
+	full := renderMessages(msgs, baseThemeStyles(), "me", nil, 80, true)
+	if got, want := strings.Count(content, "\n"), strings.Count(full, "\n"); got != want {
+		t.Errorf("Expected the windowed chat as tall as the full one, got %d lines, want %d", got, want)
+	}
+}
+
+func TestScrollingLaysOutMessagesAbove(t *testing.T) {
+	t.Cleanup(resetRenderCache)
+	resetRenderCache()
+
+	m := newModel(config.Config{Username: "me"}, "", nil, nil)
+	m.viewport.Width, m.viewport.Height = 80, 10
+	m.messages = windowTestMessages(maxMessages)
+	m.refreshMessages()
+	m.viewport.GotoBottom()
+	if view := m.viewport.View(); !strings.Contains(view, "message 99") {
+		t.Fatalf("Expected the newest message in view, got %q", view)
+	}
+
+	for i := 0; i < 1000 && !m.viewport.AtTop(); i++ {
+		m.viewport.ScrollUp(m.viewport.Height)
+		m.followMessageWindow()
+		if view := m.viewport.View(); strings.TrimSpace(view) == "" {
+			t.Fatalf("Scrolled onto lines that were not laid out at offset %d", m.viewport.YOffset)
+		}
+	}
+	if view := m.viewport.View(); !strings.Contains(view, "message 0") {
+		t.Errorf("Expected the oldest message in view at the top, got %q", view)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// maxScanWidth bounds the photo size handed to the QR decoder; phone camera
+// photos are far larger than a QR code needs
+const maxScanWidth = 1200
+
+// scanChatLink reads the link from a pairing QR code (see the admin panel's
+// "Pair a phone") with the Termux camera, falling back to a pasted link
+func scanChatLink() (string, error) {
+	if isTermux() {
+		link, err := scanWithTermuxCamera()
+		if err == nil {
+			fmt.Println("✅ QR code read")
+			return link, nil
+		}
+		fmt.Printf("Could not read a QR code: %v\n", err)
+	}
+	fmt.Print("Paste the marchat:// link shown under the QR code: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if !isChatLinkArg(line) {
+		return "", fmt.Errorf("not a marchat:// link: %q", line)
+	}
+	return line, nil
+}
+
+// scanWithTermuxCamera takes a photo with termux-camera-photo (from the
+// termux-api package) and decodes the QR code in it
+func scanWithTermuxCamera() (string, error) {
+	if _, err := exec.LookPath("termux-camera-photo"); err != nil {
+		return "", fmt.Errorf("termux-camera-photo not found (pkg install termux-api)")
+	}
+	f, err := os.CreateTemp("", "marchat-scan-*.jpg")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	fmt.Println("📷 Point the back camera at the QR code; taking a photo in 3 seconds...")
+	time.Sleep(3 * time.Second)
+	if out, err := exec.Command("termux-camera-photo", "-c", "0", path).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("termux-camera-photo failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return decodeQRFile(path)
+}
+
+// decodeQRFile returns the text of the QR code in a JPEG or PNG image
+func decodeQRFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("cannot read image: %w", err)
+	}
+	bmp, err := gozxing.NewBinaryBitmapFromImage(shrinkForScan(img))
+	if err != nil {
+		return "", err
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, hints)
+	if err != nil {
+		return "", fmt.Errorf("no QR code found in the photo")
+	}
+	return result.GetText(), nil
+}
+
+// shrinkForScan downsamples large images to grayscale of at most
+// maxScanWidth pixels wide
+func shrinkForScan(img image.Image) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= maxScanWidth {
+		return img
+	}
+	step := (b.Dx() + maxScanWidth - 1) / maxScanWidth
+	small := image.NewGray(image.Rect(0, 0, b.Dx()/step, b.Dy()/step))
+	for y := 0; y < small.Bounds().Dy(); y++ {
+		for x := 0; x < small.Bounds().Dx(); x++ {
+			small.Set(x, y, color.GrayModel.Convert(img.At(b.Min.X+x*step, b.Min.Y+y*step)))
+		}
+	}
+	return small
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+func TestDecodeQRFile(t *testing.T) {
+	const link = "marchat://192.168.1.5:8080?invite=ABC123&tls=false"
+	// The large image is downsampled before decoding
+	for _, size := range []int{256, 2400} {
+		png, err := qrcode.Encode(link, qrcode.Medium, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "qr.png")
+		if err := os.WriteFile(path, png, 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeQRFile(path)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if got != link {
+			t.Errorf("size %d: got %q, want %q", size, got, link)
+		}
+	}
+}
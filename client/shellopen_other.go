@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// shellOpen is only needed on Windows; elsewhere openURL runs the
+// desktop's opener
+func shellOpen(target string) error {
+	return errors.New("the Windows shell is not available on this platform")
+}
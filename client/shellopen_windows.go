@@ -0,0 +1,19 @@
+package main
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// shellOpen hands target to the Windows shell, which opens a URL in the
+// default browser the way Explorer would
+func shellOpen(target string) error {
+	verb, err := windows.UTF16PtrFromString("open")
+	if err != nil {
+		return err
+	}
+	file, err := windows.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	return windows.ShellExecute(0, verb, file, nil, nil, windows.SW_SHOWNORMAL)
+}
@@ -0,0 +1,14 @@
+package main
+
+import "time"
+
+// slowModeRemaining returns how long until slow mode allows another post
+func slowModeRemaining(interval time.Duration, lastPost, now time.Time) time.Duration {
+	if interval <= 0 || lastPost.IsZero() {
+		return 0
+	}
+	if wait := interval - now.Sub(lastPost); wait > 0 {
+		return wait
+	}
+	return 0
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlowModeRemaining(t *testing.T) {
+	now := time.Now()
+	if got := slowModeRemaining(0, now, now); got != 0 {
+		t.Errorf("Slow mode off should not wait, got %v", got)
+	}
+	if got := slowModeRemaining(10*time.Second, time.Time{}, now); got != 0 {
+		t.Errorf("First post should not wait, got %v", got)
+	}
+	if got := slowModeRemaining(10*time.Second, now.Add(-3*time.Second), now); got != 7*time.Second {
+		t.Errorf("Expected 7s, got %v", got)
+	}
+	if got := slowModeRemaining(10*time.Second, now.Add(-time.Minute), now); got != 0 {
+		t.Errorf("Expected no wait after the interval, got %v", got)
+	}
+}
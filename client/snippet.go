@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/alecthomas/chroma/quick"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultSnippetThreshold is the line count above which a message is offered
+// as a shared snippet instead of being sent inline
+const defaultSnippetThreshold = 20
+
+var (
+	snippetIDRegex    = regexp.MustCompile(`^[0-9a-f]{12}$`)
+	singleFenceRegex  = regexp.MustCompile("^```([a-zA-Z0-9+]*)\\n([\\s\\S]*?)\\n?```$")
+	snippetHTTPClient = &http.Client{Timeout: 15 * time.Second}
+)
+
+// snippetLoadedMsg carries the result of fetching a snippet for the viewer
+type snippetLoadedMsg struct {
+	snippet shared.Snippet
+	err     error
+}
+
+// snippetThreshold returns the configured line threshold; 0 disables offers
+func snippetThreshold(cfg config.Config) int {
+	switch {
+	case cfg.SnippetThreshold < 0:
+		return 0
+	case cfg.SnippetThreshold == 0:
+		return defaultSnippetThreshold
+	default:
+		return cfg.SnippetThreshold
+	}
+}
+
+// shouldOfferSnippet reports whether text is long enough to offer sharing it
+// as a snippet. Commands are never converted.
+func shouldOfferSnippet(text string, threshold int) bool {
+	return threshold > 0 && !strings.HasPrefix(text, ":") && strings.Count(text, "\n")+1 > threshold
+}
+
+// snippetUpload builds the message asking the server to store text as a
+// snippet. A message that is a single fenced code block keeps its language.
+func snippetUpload(sender, text string) shared.Message {
+	language := ""
+	if m := singleFenceRegex.FindStringSubmatch(strings.TrimSpace(text)); m != nil {
+		language, text = m[1], m[2]
+	}
+	if language == "" {
+		language = detectLanguage(text)
+	}
+	return shared.Message{
+		Sender:  sender,
+		Content: text,
+		Type:    shared.SnippetMessageType,
+		Snippet: &shared.Snippet{Language: language},
+	}
+}
+
+// snippetURL maps the WebSocket server URL to the HTTP snippet endpoint
+func snippetURL(serverURL, id string) (string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported server URL scheme %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/ws") + "/snippets/" + id
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
+// fetchSnippetCmd downloads a snippet from the server for the viewer overlay
+func fetchSnippetCmd(serverURL, id string, tlsConfig *tls.Config) tea.Cmd {
+	return func() tea.Msg {
+		endpoint, err := snippetURL(serverURL, id)
+		if err != nil {
+			return snippetLoadedMsg{err: err}
+		}
+		client := snippetHTTPClient
+		switch {
+		case isOnion(serverURL):
+			torDial, err := torDialContext()
+			if err != nil {
+				return snippetLoadedMsg{err: err}
+			}
+			client = &http.Client{
+				Timeout:   onionDialTimeout,
+				Transport: &http.Transport{DialContext: torDial},
+			}
+		case tlsConfig != nil:
+			client = &http.Client{
+				Timeout:   snippetHTTPClient.Timeout,
+				Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), client.Timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return snippetLoadedMsg{err: err}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return snippetLoadedMsg{err: fmt.Errorf("could not fetch snippet: %w", err)}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return snippetLoadedMsg{err: fmt.Errorf("could not fetch snippet %s: %s", id, resp.Status)}
+		}
+		var snip shared.Snippet
+		if err := json.NewDecoder(resp.Body).Decode(&snip); err != nil {
+			return snippetLoadedMsg{err: fmt.Errorf("invalid snippet response: %w", err)}
+		}
+		return snippetLoadedMsg{snippet: snip}
+	}
+}
+
+// newSnippetViewer prepares a scrollable, highlighted view of a snippet
+func newSnippetViewer(snip shared.Snippet, width, height int) viewport.Model {
+	vp := viewport.New(width, height)
+	var sb strings.Builder
+	body := snip.Content
+	if err := quick.Highlight(&sb, snip.Content, snip.Language, "terminal256", "monokai"); err == nil {
+		body = sb.String()
+	}
+	vp.SetContent(numberLines(body))
+	return vp
+}
+
+// snippetViewerTitle describes the snippet shown in the viewer
+func snippetViewerTitle(snip shared.Snippet) string {
+	language := snip.Language
+	if language == "" {
+		language = "text"
+	}
+	return fmt.Sprintf("Snippet %s · %s · %d lines · by %s", snip.ID, language, snip.Lines, snip.Author)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestSnippetThresholdAndOffer(t *testing.T) {
+	if got := snippetThreshold(config.Config{}); got != defaultSnippetThreshold {
+		t.Errorf("Default threshold = %d, want %d", got, defaultSnippetThreshold)
+	}
+	if got := snippetThreshold(config.Config{SnippetThreshold: -1}); got != 0 {
+		t.Errorf("Negative threshold should disable offers, got %d", got)
+	}
+
+	long := strings.Repeat("line\n", 5) + "end"
+	if !shouldOfferSnippet(long, 5) {
+		t.Error("Six lines should exceed a threshold of five")
+	}
+	if shouldOfferSnippet(long, 6) || shouldOfferSnippet(long, 0) {
+		t.Error("Message within the threshold (or with offers disabled) should be sent inline")
+	}
+	if shouldOfferSnippet(":poll "+long, 1) {
+		t.Error("Commands should never be offered as snippets")
+	}
+}
+
+func TestSnippetUpload(t *testing.T) {
+	msg := snippetUpload("alice", "```python\nprint(1)\nprint(2)\n```")
+	if msg.Type != shared.SnippetMessageType || msg.Snippet == nil {
+		t.Fatalf("Expected snippet upload, got %+v", msg)
+	}
+	if msg.Snippet.Language != "python" || msg.Content != "print(1)\nprint(2)" {
+		t.Errorf("Fenced block should keep its language and drop the fences, got %q / %q", msg.Snippet.Language, msg.Content)
+	}
+
+	msg = snippetUpload("alice", "{\"a\": 1,\n\"b\": 2}")
+	if msg.Snippet.Language != "json" {
+		t.Errorf("Expected detected json language, got %q", msg.Snippet.Language)
+	}
+}
+
+func TestSnippetURL(t *testing.T) {
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{"ws://localhost:8080/ws", "http://localhost:8080/snippets/0123456789ab"},
+		{"wss://chat.example.com/ws?x=1", "https://chat.example.com/snippets/0123456789ab"},
+		{"wss://example.com/marchat/ws", "https://example.com/marchat/snippets/0123456789ab"},
+	}
+	for _, tt := range tests {
+		got, err := snippetURL(tt.server, "0123456789ab")
+		if err != nil || got != tt.want {
+			t.Errorf("snippetURL(%q) = %q, %v; want %q", tt.server, got, err, tt.want)
+		}
+	}
+	if _, err := snippetURL("ftp://example.com", "0123456789ab"); err == nil {
+		t.Error("Expected error for unsupported scheme")
+	}
+}
+
+func TestFetchSnippetCmd(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/snippets/0123456789ab" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(shared.Snippet{ID: "0123456789ab", Language: "go", Lines: 1, Author: "bob", Content: "package main"})
+	}))
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	msg := fetchSnippetCmd(wsURL, "0123456789ab", nil)().(snippetLoadedMsg)
+	if msg.err != nil || msg.snippet.Content != "package main" || msg.snippet.Author != "bob" {
+		t.Errorf("Unexpected fetch result %+v", msg)
+	}
+	if title := snippetViewerTitle(msg.snippet); !strings.Contains(title, "0123456789ab") || !strings.Contains(title, "by bob") {
+		t.Errorf("Unexpected viewer title %q", title)
+	}
+
+	msg = fetchSnippetCmd(wsURL, "ffffffffffff", nil)().(snippetLoadedMsg)
+	if msg.err == nil || !strings.Contains(msg.err.Error(), "404") {
+		t.Errorf("Expected 404 error, got %v", msg.err)
+	}
+}
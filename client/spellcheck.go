@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/charmbracelet/lipgloss"
+)
+
+//go:embed dict/en.txt
+var bundledWordList string
+
+// systemDictionaries are merged into the bundled list when present so the
+// checker knows far more words on machines that ship a dictionary
+var systemDictionaries = []string{
+	"/usr/share/hunspell/en_US.dic",
+	"/usr/share/myspell/en_US.dic",
+	"/usr/share/dict/words",
+}
+
+const (
+	personalDictionaryFile = "spellcheck_words.txt"
+	maxSpellSuggestions    = 9 // Picked with the digit keys in the popup
+)
+
+// inflectionSuffixes lets a dictionary entry also accept its common inflected
+// forms, a lightweight stand-in for hunspell affix rules
+var inflectionSuffixes = []string{"'s", "s", "es", "ed", "d", "ing", "ly", "er", "ers", "est", "ness", "ment", "ments", "able", "ful"}
+
+// SpellChecker flags words missing from its dictionary and suggests
+// replacements within a small edit distance
+type SpellChecker struct {
+	words map[string]int // lowercase word -> rank, lower ranks are suggested first
+}
+
+// Misspelling is a flagged word and its byte offsets in the checked text
+type Misspelling struct {
+	Word       string
+	Start, End int
+}
+
+// NewSpellChecker returns a checker loaded with the bundled word list
+func NewSpellChecker() *SpellChecker {
+	s := &SpellChecker{words: make(map[string]int)}
+	s.LoadWords(strings.NewReader(bundledWordList))
+	return s
+}
+
+// LoadWords reads a plain word list or a hunspell .dic file (leading count
+// line, "/FLAGS" suffixes) and returns the number of new words
+func (s *SpellChecker) LoadWords(r io.Reader) int {
+	added := 0
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if first {
+			first = false
+			if _, err := strconv.Atoi(line); err == nil {
+				continue // hunspell word count header
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.IndexAny(line, "/\t "); i >= 0 {
+			line = line[:i]
+		}
+		word := strings.ToLower(line)
+		if word == "" {
+			continue
+		}
+		if _, exists := s.words[word]; !exists {
+			s.words[word] = len(s.words)
+			added++
+		}
+	}
+	return added
+}
+
+// LoadFile merges a word list from disk
+func (s *SpellChecker) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s.LoadWords(f)
+	return nil
+}
+
+// AddWord accepts a word for the rest of the session
+func (s *SpellChecker) AddWord(word string) {
+	word = strings.ToLower(word)
+	if _, exists := s.words[word]; !exists {
+		s.words[word] = len(s.words)
+	}
+}
+
+// Known reports whether the word, or a simple inflection of a known word, is
+// in the dictionary
+func (s *SpellChecker) Known(word string) bool {
+	word = strings.ToLower(strings.ReplaceAll(word, "’", "'"))
+	if _, ok := s.words[word]; ok {
+		return true
+	}
+	for _, suffix := range inflectionSuffixes {
+		stem, ok := strings.CutSuffix(word, suffix)
+		if !ok || len(stem) < 2 {
+			continue
+		}
+		if s.hasStem(stem) {
+			return true
+		}
+	}
+	// carries -> carry, carried -> carry
+	for _, suffix := range []string{"ies", "ied", "ier", "iest", "ily"} {
+		if stem, ok := strings.CutSuffix(word, suffix); ok && len(stem) >= 2 && s.hasStem(stem+"y") {
+			return true
+		}
+	}
+	for _, prefix := range []string{"un", "re"} {
+		if rest, ok := strings.CutPrefix(word, prefix); ok && len(rest) >= 3 {
+			if _, known := s.words[rest]; known {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasStem matches a stripped word against the dictionary, allowing for a
+// dropped trailing "e" (making) and a doubled final consonant (stopped)
+func (s *SpellChecker) hasStem(stem string) bool {
+	if _, ok := s.words[stem]; ok {
+		return true
+	}
+	if _, ok := s.words[stem+"e"]; ok {
+		return true
+	}
+	if n := len(stem); n >= 3 && stem[n-1] == stem[n-2] {
+		if _, ok := s.words[stem[:n-1]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the misspelled words in text. Commands, code, URLs, mentions,
+// words with digits, acronyms and mixed-case identifiers are never flagged,
+// nor are any of the ignore words (e.g. online usernames).
+func (s *SpellChecker) Check(text string, ignore []string) []Misspelling {
+	if strings.HasPrefix(strings.TrimSpace(text), ":") || strings.Contains(text, "```") {
+		return nil
+	}
+	skip := make(map[string]bool, len(ignore))
+	for _, w := range ignore {
+		skip[strings.ToLower(w)] = true
+	}
+
+	var result []Misspelling
+	inCode := false
+	for _, field := range fieldsWithOffsets(text) {
+		token, offset := field.text, field.start
+		ticks := strings.Count(token, "`")
+		wasInCode := inCode
+		if ticks%2 == 1 {
+			inCode = !inCode
+		}
+		if wasInCode || ticks > 0 || strings.HasPrefix(token, "@") || strings.Contains(token, "://") || strings.HasPrefix(token, "www.") {
+			continue
+		}
+		for _, w := range wordsInToken(token) {
+			if !s.shouldCheck(w.text) || skip[strings.ToLower(w.text)] || s.Known(w.text) {
+				continue
+			}
+			result = append(result, Misspelling{Word: w.text, Start: offset + w.start, End: offset + w.start + len(w.text)})
+		}
+	}
+	return result
+}
+
+// shouldCheck filters out tokens a dictionary can't judge
+func (s *SpellChecker) shouldCheck(word string) bool {
+	if utf8.RuneCountInString(word) < 2 {
+		return false
+	}
+	for i, r := range word {
+		if unicode.IsDigit(r) || r == '_' {
+			return false
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			return false // ACRONYM or camelCase
+		}
+	}
+	return true
+}
+
+type textSpan struct {
+	text  string
+	start int
+}
+
+// fieldsWithOffsets splits on whitespace, keeping byte offsets
+func fieldsWithOffsets(text string) []textSpan {
+	var spans []textSpan
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, textSpan{text[start:i], start})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, textSpan{text[start:], start})
+	}
+	return spans
+}
+
+// wordsInToken extracts runs of letters (with inner apostrophes) from a
+// whitespace-delimited token, dropping surrounding punctuation
+func wordsInToken(token string) []textSpan {
+	var spans []textSpan
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			word := strings.TrimRight(token[start:end], "'’")
+			if word != "" {
+				spans = append(spans, textSpan{word, start})
+			}
+			start = -1
+		}
+	}
+	for i, r := range token {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			if start < 0 {
+				start = i
+			}
+		case (r == '\'' || r == '’') && start >= 0:
+			// keep apostrophes inside words (don't, it's)
+		default:
+			flush(i)
+		}
+	}
+	flush(len(token))
+	return spans
+}
+
+// Suggest returns up to max known words closest to word, best first, keeping
+// the capitalization of the original
+func (s *SpellChecker) Suggest(word string, max int) []string {
+	lower := strings.ToLower(word)
+	candidates := s.knownEdits(spellEdits(lower))
+	if len(candidates) == 0 && utf8.RuneCountInString(lower) <= 12 {
+		seen := make(map[string]bool)
+		var second []string
+		for _, e := range spellEdits(lower) {
+			for _, e2 := range spellEdits(e) {
+				if !seen[e2] {
+					seen[e2] = true
+					second = append(second, e2)
+				}
+			}
+		}
+		candidates = s.knownEdits(second)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return s.rank(candidates[i]) < s.rank(candidates[j])
+	})
+	if len(candidates) > max {
+		candidates = candidates[:max]
+	}
+	if r, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(r) {
+		for i, c := range candidates {
+			first, size := utf8.DecodeRuneInString(c)
+			candidates[i] = string(unicode.ToUpper(first)) + c[size:]
+		}
+	}
+	return candidates
+}
+
+// knownEdits keeps the unique edits the dictionary accepts
+func (s *SpellChecker) knownEdits(edits []string) []string {
+	seen := make(map[string]bool)
+	var known []string
+	for _, e := range edits {
+		if e == "" || seen[e] {
+			continue
+		}
+		seen[e] = true
+		if s.Known(e) {
+			known = append(known, e)
+		}
+	}
+	return known
+}
+
+// rank orders suggestions: dictionary words by list position, then
+// inflected forms
+func (s *SpellChecker) rank(word string) int {
+	if r, ok := s.words[word]; ok {
+		return r
+	}
+	return len(s.words) + len(word)
+}
+
+// spellEdits returns every string one deletion, transposition, replacement
+// or insertion away from word
+func spellEdits(word string) []string {
+	const letters = "abcdefghijklmnopqrstuvwxyz'"
+	runes := []rune(word)
+	var edits []string
+	for i := 0; i <= len(runes); i++ {
+		left, right := string(runes[:i]), runes[i:]
+		if len(right) > 0 {
+			edits = append(edits, left+string(right[1:]))
+		}
+		if len(right) > 1 {
+			edits = append(edits, left+string(right[1])+string(right[0])+string(right[2:]))
+		}
+		for _, c := range letters {
+			if len(right) > 0 {
+				edits = append(edits, left+string(c)+string(right[1:]))
+			}
+			edits = append(edits, left+string(c)+string(right))
+		}
+	}
+	return edits
+}
+
+// replaceMisspelling swaps the flagged word in text for replacement
+func replaceMisspelling(text string, m Misspelling, replacement string) string {
+	if m.Start < 0 || m.End > len(text) || text[m.Start:m.End] != m.Word {
+		return text
+	}
+	return text[:m.Start] + replacement + text[m.End:]
+}
+
+// newSpellCheckerFromConfig builds the composer spell checker, or returns nil
+// when spell-check is off. MARCHAT_SPELLCHECK and MARCHAT_SPELLCHECK_DICT
+// override the config file.
+func newSpellCheckerFromConfig(cfg config.Config, configDir string) *SpellChecker {
+	enabled := cfg.SpellCheck
+	if v := os.Getenv("MARCHAT_SPELLCHECK"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			enabled = b
+		}
+	}
+	if !enabled {
+		return nil
+	}
+
+	s := NewSpellChecker()
+	for _, path := range systemDictionaries {
+		_ = s.LoadFile(path)
+	}
+	dict := cfg.SpellCheckDict
+	if v := os.Getenv("MARCHAT_SPELLCHECK_DICT"); v != "" {
+		dict = v
+	}
+	if dict != "" {
+		for _, path := range filepath.SplitList(dict) {
+			_ = s.LoadFile(path)
+		}
+	}
+	if configDir != "" {
+		_ = s.LoadFile(filepath.Join(configDir, personalDictionaryFile))
+	}
+	return s
+}
+
+// addToPersonalDictionary accepts a word now and in future sessions
+func addToPersonalDictionary(s *SpellChecker, configDir, word string) error {
+	s.AddWord(word)
+	if configDir == "" {
+		return nil
+	}
+	f, err := os.OpenFile(filepath.Join(configDir, personalDictionaryFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, strings.ToLower(word))
+	return err
+}
+
+// renderSpellPreview shows the composer text on one line with misspelled
+// words underlined, for display under the textarea
+func renderSpellPreview(text string, misspellings []Misspelling, width int) string {
+	flagged := lipgloss.NewStyle().Underline(true).Foreground(lipgloss.Color("#FF5F5F"))
+	var b strings.Builder
+	b.WriteString("Spelling: ")
+	pos := 0
+	for _, m := range misspellings {
+		b.WriteString(text[pos:m.Start])
+		b.WriteString(flagged.Render(m.Word))
+		pos = m.End
+	}
+	b.WriteString(text[pos:])
+	b.WriteString(" (Alt+W to fix)")
+	line := strings.ReplaceAll(b.String(), "\n", " ")
+	if width > 0 && lipgloss.Width(line) > width {
+		// Keep the first flagged word and the hint visible on narrow terminals
+		line = fmt.Sprintf("Spelling: %s and %d more (Alt+W to fix)", flagged.Render(misspellings[0].Word), len(misspellings)-1)
+		if len(misspellings) == 1 {
+			line = fmt.Sprintf("Spelling: %s (Alt+W to fix)", flagged.Render(misspellings[0].Word))
+		}
+	}
+	return line
+}
+
+// spellPopup holds the corrections popup for one flagged word
+type spellPopup struct {
+	target      Misspelling
+	suggestions []string
+}
+
+// View renders the popup contents
+func (p spellPopup) View() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Spelling: %q\n\n", p.target.Word))
+	if len(p.suggestions) == 0 {
+		b.WriteString("No suggestions\n")
+	}
+	for i, s := range p.suggestions {
+		b.WriteString(fmt.Sprintf("  %d  %s\n", i+1, s))
+	}
+	b.WriteString("\n1-9 replace • a add to dictionary • i ignore • esc close")
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+func TestSpellCheckerKnown(t *testing.T) {
+	s := NewSpellChecker()
+	for _, word := range []string{"hello", "Hello", "don't", "meetings", "stopped", "making", "carried", "friend's", "unclear"} {
+		if !s.Known(word) {
+			t.Errorf("Expected %q to be known", word)
+		}
+	}
+	for _, word := range []string{"helo", "teh", "recieve"} {
+		if s.Known(word) {
+			t.Errorf("Expected %q to be unknown", word)
+		}
+	}
+}
+
+func TestSpellCheckerCheck(t *testing.T) {
+	s := NewSpellChecker()
+	text := "helo @bobb, see https://exmaple.com and `fmt.Prntln` with HTTPS teh alice_bot v2 bobb"
+	got := s.Check(text, []string{"bobb"})
+	want := []Misspelling{
+		{Word: "helo", Start: 0, End: 4},
+		{Word: "teh", Start: strings.Index(text, "teh"), End: strings.Index(text, "teh") + 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %+v, want %+v", got, want)
+	}
+
+	for _, text := range []string{":spellcheck off", "```go\nfnuc main()\n```"} {
+		if got := s.Check(text, nil); len(got) != 0 {
+			t.Errorf("Check(%q) should not flag anything, got %+v", text, got)
+		}
+	}
+}
+
+func TestSpellCheckerSuggest(t *testing.T) {
+	s := NewSpellChecker()
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"teh", "the"},
+		{"helo", "hello"},
+		{"Thnaks", "Thanks"},
+		{"meetign", "meeting"},
+	}
+	for _, tt := range tests {
+		got := s.Suggest(tt.word, maxSpellSuggestions)
+		found := false
+		for _, g := range got {
+			if g == tt.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Suggest(%q) = %v, want it to include %q", tt.word, got, tt.want)
+		}
+		if len(got) > maxSpellSuggestions {
+			t.Errorf("Suggest(%q) returned %d suggestions", tt.word, len(got))
+		}
+	}
+	if got := s.Suggest("teh", 1); len(got) != 1 || got[0] != "the" {
+		t.Errorf("Most common correction should come first, got %v", got)
+	}
+}
+
+func TestSpellCheckerLoadHunspellDic(t *testing.T) {
+	s := NewSpellChecker()
+	added := s.LoadWords(strings.NewReader("3\nmarchat/S\nbubbletea\nthe\n"))
+	if added != 2 {
+		t.Errorf("Expected 2 new words, got %d", added)
+	}
+	if !s.Known("marchat") || !s.Known("bubbletea") {
+		t.Error("Words from the .dic file should be known with flags stripped")
+	}
+	if s.Known("3") {
+		t.Error("The hunspell count header should not be loaded as a word")
+	}
+}
+
+func TestReplaceMisspelling(t *testing.T) {
+	text := "see you tomorow!"
+	m := Misspelling{Word: "tomorow", Start: 8, End: 15}
+	if got := replaceMisspelling(text, m, "tomorrow"); got != "see you tomorrow!" {
+		t.Errorf("replaceMisspelling() = %q", got)
+	}
+	// Stale offsets (text edited since the check) leave the text alone
+	if got := replaceMisspelling("see you", m, "tomorrow"); got != "see you" {
+		t.Errorf("replaceMisspelling() with stale offsets = %q", got)
+	}
+}
+
+func TestNewSpellCheckerFromConfig(t *testing.T) {
+	t.Setenv("MARCHAT_SPELLCHECK", "")
+	t.Setenv("MARCHAT_SPELLCHECK_DICT", "")
+	dir := t.TempDir()
+
+	if s := newSpellCheckerFromConfig(config.Config{}, dir); s != nil {
+		t.Error("Spell-check should be off by default")
+	}
+
+	dict := filepath.Join(dir, "team.dic")
+	if err := os.WriteFile(dict, []byte("kubectl\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := newSpellCheckerFromConfig(config.Config{SpellCheck: true, SpellCheckDict: dict}, dir)
+	if s == nil || !s.Known("kubectl") {
+		t.Fatal("Expected spell checker with the configured dictionary")
+	}
+
+	if err := addToPersonalDictionary(s, dir, "Grafana"); err != nil {
+		t.Fatalf("addToPersonalDictionary failed: %v", err)
+	}
+	reloaded := newSpellCheckerFromConfig(config.Config{SpellCheck: true}, dir)
+	if !reloaded.Known("grafana") {
+		t.Error("Personal dictionary words should persist across sessions")
+	}
+
+	t.Setenv("MARCHAT_SPELLCHECK", "false")
+	if s := newSpellCheckerFromConfig(config.Config{SpellCheck: true}, dir); s != nil {
+		t.Error("MARCHAT_SPELLCHECK=false should override the config")
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statsMessageType marks client-local entries that show the server's
+// answer to :stats me or :stats channel
+const statsMessageType shared.MessageType = "stats"
+
+// statsBarWidth is the longest bar in the top talkers table
+const statsBarWidth = 20
+
+// busiestHours is how many of the busiest hours are named
+const busiestHours = 3
+
+// hourlyInZone folds a week of hourly counts into the 24 hours of the day
+// in the display zone, and counts the messages since midnight there
+func hourlyInZone(s shared.ChatStats, now time.Time) (byHour [24]int, today int) {
+	now = displayTime(now)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i, n := range s.Hourly {
+		at := displayTime(s.From.Add(time.Duration(i) * time.Hour))
+		byHour[at.Hour()] += n
+		if !at.Before(midnight) {
+			today += n
+		}
+	}
+	return byHour, today
+}
+
+// sparkline draws counts as one block character each, scaled to the largest
+func sparkline(counts []int) string {
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	var b strings.Builder
+	for _, n := range counts {
+		if n == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(voiceWaveformBars[(n*len(voiceWaveformBars)-1)/peak])
+	}
+	return b.String()
+}
+
+// renderStats lays out :stats as a small table: today, the week, the
+// busiest hours with a bar per hour of the day, then the rank or the top
+// talkers with bars
+func renderStats(s shared.ChatStats, twentyFourHour bool, now time.Time) string {
+	byHour, today := hourlyInZone(s, now)
+	hourFmt := "15:00"
+	if !twentyFourHour {
+		hourFmt = "3 PM"
+	}
+	hours := make([]int, 24)
+	for h := range hours {
+		hours[h] = h
+	}
+	sort.SliceStable(hours, func(i, j int) bool { return byHour[hours[i]] > byHour[hours[j]] })
+	var busiest []string
+	for _, h := range hours[:busiestHours] {
+		if byHour[h] > 0 {
+			busiest = append(busiest, time.Date(2000, 1, 1, h, 0, 0, 0, time.UTC).Format(hourFmt))
+		}
+	}
+	if len(busiest) == 0 {
+		busiest = []string{"-"}
+	}
+
+	rows := [][2]string{
+		{i18n.T("stats.today"), strconv.Itoa(today)},
+		{i18n.T("stats.week"), strconv.Itoa(s.Total())},
+	}
+	if s.Scope == shared.StatsScopeMe {
+		rank := i18n.T("stats.rank_none", s.Senders)
+		if s.Rank > 0 {
+			rank = i18n.T("stats.rank", s.Rank, s.Senders)
+		}
+		rows = append(rows, [2]string{i18n.T("stats.rank_label"), rank})
+	} else {
+		rows = append(rows, [2]string{i18n.T("stats.people"), strconv.Itoa(s.Senders)})
+	}
+	rows = append(rows, [2]string{i18n.T("stats.busiest"), strings.Join(busiest, ", ")})
+
+	labelWidth := 0
+	for _, row := range rows {
+		labelWidth = max(labelWidth, lipgloss.Width(row[0]))
+	}
+	pad := func(label string, width int) string {
+		return label + strings.Repeat(" ", width-lipgloss.Width(label)+2)
+	}
+
+	var b strings.Builder
+	if s.Scope == shared.StatsScopeMe {
+		b.WriteString(i18n.T("stats.title_me") + "\n")
+	} else {
+		b.WriteString(i18n.T("stats.title_channel", s.Subject) + "\n")
+	}
+	for _, row := range rows {
+		b.WriteString(pad(row[0], labelWidth) + row[1] + "\n")
+	}
+	b.WriteString(strings.Repeat(" ", labelWidth+2) + sparkline(byHour[:]) + "\n")
+	b.WriteString(strings.Repeat(" ", labelWidth+2) + "0     6     12    18")
+
+	if len(s.Talkers) > 0 {
+		b.WriteString("\n\n" + i18n.T("stats.talkers"))
+		nameWidth, peak := 0, s.Talkers[0].Messages
+		for _, t := range s.Talkers {
+			nameWidth = max(nameWidth, lipgloss.Width(displayName(t.Username)))
+		}
+		for _, t := range s.Talkers {
+			filled := max(1, t.Messages*statsBarWidth/peak)
+			b.WriteString(fmt.Sprintf("\n%s%s %d", pad(displayName(t.Username), nameWidth), strings.Repeat("█", filled), t.Messages))
+		}
+	}
+	return b.String()
+}
+
+// statsEntry is the chat entry showing a "stats" message from the server
+func statsEntry(s shared.ChatStats, twentyFourHour bool, now time.Time) shared.Message {
+	return shared.Message{
+		Sender:    "System",
+		Content:   renderStats(s, twentyFourHour, now),
+		CreatedAt: now,
+		Type:      statsMessageType,
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestRenderStats(t *testing.T) {
+	if err := setDisplayZone("Europe/Berlin", false); err != nil {
+		t.Fatal(err)
+	}
+	defer setDisplayZone("", false)
+
+	// 23:30 in Berlin; the week's last hours are 21:00 and 22:00 UTC
+	now := time.Date(2026, 3, 10, 22, 30, 0, 0, time.UTC)
+	stats := shared.ChatStats{
+		Scope:   shared.StatsScopeChannel,
+		Subject: "room",
+		From:    now.Truncate(time.Hour).Add(-167 * time.Hour),
+		Hourly:  make([]int, 168),
+		Talkers: []shared.TalkerStats{{Username: "carol", Messages: 40}, {Username: "alice", Messages: 2}},
+		Senders: 2,
+	}
+	stats.Hourly[167] = 30 // 23:00 in Berlin, today
+	stats.Hourly[144] = 12 // 00:00 in Berlin, also today
+	stats.Hourly[143] = 0
+
+	byHour, today := hourlyInZone(stats, now)
+	if today != 42 || byHour[23] != 30 || byHour[0] != 12 {
+		t.Errorf("Expected today and the hours counted in Berlin, got today %d, hours %v", today, byHour)
+	}
+
+	out := renderStats(stats, true, now)
+	for _, want := range []string{"#room", "Today          42", "This week      42", "Busiest hours  23:00, 00:00", "carol  " + strings.Repeat("█", statsBarWidth) + " 40", "alice  █ 2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+
+	stats.Scope, stats.Talkers, stats.Rank = shared.StatsScopeMe, nil, 0
+	stats.Hourly = make([]int, 168)
+	out = renderStats(stats, false, now)
+	if !strings.Contains(out, "no messages yet (2 people posted)") || !strings.Contains(out, "Busiest hours  -") {
+		t.Errorf("Expected a silent week, got:\n%s", out)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]int{0, 1, 4, 8}); got != " ▁▄█" {
+		t.Errorf("Unexpected sparkline %q", got)
+	}
+}
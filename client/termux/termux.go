@@ -0,0 +1,173 @@
+// Package termux integrates the client with Termux on Android through the
+// Termux:API commands (pkg install termux-api, plus the Termux:API app):
+// notifications, the clipboard, opening links and files, and the battery
+// level. Each command is looked up when used, so a missing one is an error
+// the caller can fall back from rather than a hang.
+package termux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Detected reports whether the process runs in Termux
+func Detected() bool {
+	return os.Getenv("TERMUX_VERSION") != "" ||
+		os.Getenv("PREFIX") == "/data/data/com.termux/files/usr" ||
+		(os.Getenv("ANDROID_DATA") != "" && os.Getenv("ANDROID_ROOT") != "")
+}
+
+// Indirection so tests can stand in for the Termux:API commands
+var (
+	lookPath = exec.LookPath
+	run      = func(stdin, name string, args ...string) ([]byte, error) {
+		cmd := exec.Command(name, args...)
+		if stdin != "" {
+			cmd.Stdin = strings.NewReader(stdin)
+		}
+		return cmd.Output()
+	}
+)
+
+// Available reports whether the Termux:API command name is installed
+func Available(name string) bool {
+	_, err := lookPath(name)
+	return err == nil
+}
+
+// command runs a Termux:API command, explaining how to install it when
+// it is missing
+func command(stdin, name string, args ...string) ([]byte, error) {
+	if !Available(name) {
+		return nil, fmt.Errorf("%s not found (pkg install termux-api)", name)
+	}
+	out, err := run(stdin, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return out, nil
+}
+
+// Notification priorities termux-notification accepts
+const (
+	PriorityDefault = "default"
+	PriorityHigh    = "high"
+	PriorityMax     = "max"
+)
+
+// Notification is an Android notification. Notifications sharing a group
+// are bundled together.
+type Notification struct {
+	Title    string
+	Content  string
+	Group    string
+	Priority string // one of the Priority constants, empty for the default
+}
+
+// Notify shows n with termux-notification. High and max priority
+// notifications pop up over other apps and vibrate.
+func Notify(n Notification) error {
+	args := []string{"--title", n.Title, "--content", n.Content}
+	if n.Group != "" {
+		args = append(args, "--group", n.Group)
+	}
+	if n.Priority != "" && n.Priority != PriorityDefault {
+		args = append(args, "--priority", n.Priority, "--vibrate", "200,100,200")
+	}
+	_, err := command("", "termux-notification", args...)
+	return err
+}
+
+// ClipboardGet returns the text on the Android clipboard
+func ClipboardGet() (string, error) {
+	out, err := command("", "termux-clipboard-get")
+	return string(out), err
+}
+
+// ClipboardSet puts text on the Android clipboard
+func ClipboardSet(text string) error {
+	// Text arrives on stdin so it may be any length and start with a dash
+	_, err := command(text, "termux-clipboard-set")
+	return err
+}
+
+// Open hands target to Android: web links open in the browser and files
+// in the app chosen for their type
+func Open(target string) error {
+	lower := strings.ToLower(target)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		_, err := command("", "termux-open-url", target)
+		return err
+	}
+	_, err := command("", "termux-open", target)
+	return err
+}
+
+// Battery is the state termux-battery-status reports
+type Battery struct {
+	Percentage int    `json:"percentage"`
+	Plugged    string `json:"plugged"` // "UNPLUGGED", "PLUGGED_AC", "PLUGGED_USB", ...
+	Status     string `json:"status"`  // "CHARGING", "DISCHARGING", "FULL", ...
+}
+
+// Charging reports whether the phone is plugged in
+func (b Battery) Charging() bool {
+	return b.Plugged != "" && b.Plugged != "UNPLUGGED"
+}
+
+// batteryMaxAge is how long a battery reading is reused. Each reading
+// wakes the Termux:API app, which takes about a second.
+const batteryMaxAge = time.Minute
+
+var (
+	batteryMu   sync.Mutex
+	battery     Battery
+	batteryRead time.Time
+	batteryErr  error
+)
+
+// BatteryStatus returns the battery state, read at most once a minute
+func BatteryStatus() (Battery, error) {
+	batteryMu.Lock()
+	defer batteryMu.Unlock()
+	if !batteryRead.IsZero() && time.Since(batteryRead) < batteryMaxAge {
+		return battery, batteryErr
+	}
+	battery, batteryErr = Battery{}, nil
+	out, err := command("", "termux-battery-status")
+	if err == nil {
+		err = json.Unmarshal(out, &battery)
+	}
+	if err == nil && battery.Plugged == "" {
+		err = errors.New("termux-battery-status: no battery state")
+	}
+	batteryErr, batteryRead = err, time.Now()
+	return battery, batteryErr
+}
+
+// Battery levels below which reconnects slow down while unplugged
+const (
+	lowBattery      = 30
+	criticalBattery = 15
+)
+
+// ReconnectDelay stretches a reconnect delay to spare a phone's battery:
+// twice as long when it is unplugged and below 30%, four times below 15%.
+// Each attempt wakes the radio, which costs far more than the wait.
+func ReconnectDelay(delay time.Duration, b Battery) time.Duration {
+	switch {
+	case b.Charging():
+		return delay
+	case b.Percentage < criticalBattery:
+		return delay * 4
+	case b.Percentage < lowBattery:
+		return delay * 2
+	}
+	return delay
+}
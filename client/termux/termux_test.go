@@ -0,0 +1,137 @@
+package termux
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// call is one Termux:API command a test saw run
+type call struct {
+	stdin string
+	args  []string
+}
+
+// fakeAPI stands in for the Termux:API commands: installed ones answer
+// with output[name], and each run is recorded
+func fakeAPI(t *testing.T, installed []string, output map[string]string) *[]call {
+	t.Helper()
+	oldLookPath, oldRun := lookPath, run
+	t.Cleanup(func() { lookPath, run = oldLookPath, oldRun })
+	var calls []call
+	lookPath = func(name string) (string, error) {
+		for _, n := range installed {
+			if n == name {
+				return "/data/data/com.termux/files/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	run = func(stdin, name string, args ...string) ([]byte, error) {
+		calls = append(calls, call{stdin, append([]string{name}, args...)})
+		return []byte(output[name]), nil
+	}
+	return &calls
+}
+
+func TestNotify(t *testing.T) {
+	calls := fakeAPI(t, []string{"termux-notification"}, nil)
+	if err := Notify(Notification{Title: "alice", Content: "@bob lunch?", Group: "marchat", Priority: PriorityHigh}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := Notify(Notification{Title: "carol", Content: "hi"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	got := []string{strings.Join((*calls)[0].args, " "), strings.Join((*calls)[1].args, " ")}
+	if got[0] != "termux-notification --title alice --content @bob lunch? --group marchat --priority high --vibrate 200,100,200" {
+		t.Errorf("Unexpected mention notification: %s", got[0])
+	}
+	if got[1] != "termux-notification --title carol --content hi" {
+		t.Errorf("Unexpected plain notification: %s", got[1])
+	}
+}
+
+func TestClipboardAndOpen(t *testing.T) {
+	calls := fakeAPI(t, []string{"termux-clipboard-set", "termux-clipboard-get", "termux-open-url", "termux-open"},
+		map[string]string{"termux-clipboard-get": "pasted"})
+	if err := ClipboardSet("-n not a flag"); err != nil {
+		t.Fatalf("ClipboardSet failed: %v", err)
+	}
+	if c := (*calls)[0]; c.stdin != "-n not a flag" || len(c.args) != 1 {
+		t.Errorf("Expected the text on stdin, got %+v", c)
+	}
+	if text, err := ClipboardGet(); err != nil || text != "pasted" {
+		t.Errorf("Expected the clipboard read, got %q (%v)", text, err)
+	}
+
+	for _, target := range []string{"HTTPS://example.com", "/sdcard/Download/marchat/photo.png"} {
+		if err := Open(target); err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+	}
+	if got := (*calls)[2].args[0]; got != "termux-open-url" {
+		t.Errorf("Expected a link opened in the browser, got %s", got)
+	}
+	if got := (*calls)[3].args; got[0] != "termux-open" || got[1] != "/sdcard/Download/marchat/photo.png" {
+		t.Errorf("Expected a file opened by type, got %v", got)
+	}
+}
+
+func TestMissingCommand(t *testing.T) {
+	calls := fakeAPI(t, nil, nil)
+	if err := ClipboardSet("x"); err == nil || !strings.Contains(err.Error(), "pkg install termux-api") {
+		t.Errorf("Expected install advice, got %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("Expected nothing run, got %+v", *calls)
+	}
+}
+
+func TestBatteryStatusCached(t *testing.T) {
+	t.Cleanup(func() {
+		batteryMu.Lock()
+		batteryRead = time.Time{}
+		batteryMu.Unlock()
+	})
+	calls := fakeAPI(t, []string{"termux-battery-status"}, map[string]string{
+		"termux-battery-status": `{"health":"GOOD","percentage":12,"plugged":"UNPLUGGED","status":"DISCHARGING","temperature":30.5}`,
+	})
+	for range 3 {
+		b, err := BatteryStatus()
+		if err != nil || b.Percentage != 12 || b.Charging() {
+			t.Fatalf("Expected an unplugged battery at 12%%, got %+v (%v)", b, err)
+		}
+	}
+	if len(*calls) != 1 {
+		t.Errorf("Expected the battery read once a minute, got %d reads", len(*calls))
+	}
+
+	batteryMu.Lock()
+	batteryRead = time.Time{}
+	batteryMu.Unlock()
+	run = func(string, string, ...string) ([]byte, error) {
+		return nil, errors.New("Termux:API app not installed")
+	}
+	if _, err := BatteryStatus(); err == nil {
+		t.Error("Expected the failure reported")
+	}
+}
+
+func TestReconnectDelay(t *testing.T) {
+	cases := []struct {
+		battery Battery
+		want    time.Duration
+	}{
+		{Battery{Percentage: 10, Plugged: "PLUGGED_AC"}, 2 * time.Second},
+		{Battery{Percentage: 80, Plugged: "UNPLUGGED"}, 2 * time.Second},
+		{Battery{Percentage: 25, Plugged: "UNPLUGGED"}, 4 * time.Second},
+		{Battery{Percentage: 10, Plugged: "UNPLUGGED"}, 8 * time.Second},
+	}
+	for _, c := range cases {
+		if got := ReconnectDelay(2*time.Second, c.battery); got != c.want {
+			t.Errorf("%+v: got %v, want %v", c.battery, got, c.want)
+		}
+	}
+}
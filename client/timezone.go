@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // zone names work without a system zoneinfo (Windows, Termux)
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Where timestamps are shown. The zone is set per profile with :tz; the
+// server's clock is the offset the server stamped the message with.
+var (
+	zoneMu         sync.RWMutex
+	displayZone    = time.Local
+	showServerTime bool
+)
+
+// loadZone resolves a :tz argument: an IANA name, or "local" or "" for the
+// machine's zone
+func loadZone(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q (use a name like Europe/Berlin)", name)
+	}
+	return loc, nil
+}
+
+// setDisplayZone switches the zone timestamps and date headers use, falling
+// back to the machine's zone when name is unknown
+func setDisplayZone(name string, withServerTime bool) error {
+	loc, err := loadZone(name)
+	if err != nil {
+		loc = time.Local
+	}
+	zoneMu.Lock()
+	displayZone = loc
+	showServerTime = withServerTime
+	zoneMu.Unlock()
+	return err
+}
+
+// displayTime converts t to the display zone
+func displayTime(t time.Time) time.Time {
+	zoneMu.RLock()
+	defer zoneMu.RUnlock()
+	return t.In(displayZone)
+}
+
+// formatTimestamp formats t in the display zone, followed by the server's
+// clock when that is turned on and differs
+func formatTimestamp(t time.Time, layout string) string {
+	zoneMu.RLock()
+	loc, withServer := displayZone, showServerTime
+	zoneMu.RUnlock()
+	shown := t.In(loc).Format(layout)
+	if withServer {
+		if server := t.Format(layout); server != shown {
+			shown += " " + i18n.T("time.server", server)
+		}
+	}
+	return shown
+}
+
+// zoneStatus describes the current settings for :tz
+func zoneStatus() string {
+	zoneMu.RLock()
+	defer zoneMu.RUnlock()
+	name := displayZone.String()
+	if displayZone == time.Local {
+		name = i18n.T("tz.local", time.Now().Format("MST"))
+	}
+	if showServerTime {
+		return i18n.T("tz.with_server_time", name)
+	}
+	return name
+}
+
+// applyTZCommand handles ":tz [zone|local]" and ":tz server on|off", returning
+// the new settings for the profile and a banner
+func applyTZCommand(text, zone string, withServer bool) (string, bool, string, error) {
+	args := strings.Fields(text)[1:]
+	switch {
+	case len(args) == 0:
+		return zone, withServer, i18n.T("banner.tz_status", zoneStatus()), nil
+	case len(args) == 2 && args[0] == "server" && (args[1] == "on" || args[1] == "off"):
+		withServer = args[1] == "on"
+	case len(args) == 1 && args[0] != "server":
+		if _, err := loadZone(args[0]); err != nil {
+			return zone, withServer, "", err
+		}
+		zone = args[0]
+		if strings.EqualFold(zone, "local") {
+			zone = ""
+		}
+	default:
+		return zone, withServer, "", fmt.Errorf("usage: :tz [Area/City|local] or :tz server on|off")
+	}
+	if err := setDisplayZone(zone, withServer); err != nil {
+		return zone, withServer, "", err
+	}
+	return zone, withServer, i18n.T("banner.tz_status", zoneStatus()), nil
+}
+
+// Relative timestamps ("2m ago"), turned on by cycling :time. The message
+// picked in selection mode (Alt+↑/↓) shows its absolute time instead.
+var (
+	relativeTimes bool
+	revealed      *shared.Message
+)
+
+// setRelativeTimes turns relative timestamps on or off
+func setRelativeTimes(on bool) {
+	zoneMu.Lock()
+	relativeTimes = on
+	zoneMu.Unlock()
+}
+
+// usingRelativeTimes reports whether relative timestamps are on
+func usingRelativeTimes() bool {
+	zoneMu.RLock()
+	defer zoneMu.RUnlock()
+	return relativeTimes
+}
+
+// setRevealedMessage picks the message shown with its absolute time, or
+// none for nil
+func setRevealedMessage(msg *shared.Message) {
+	zoneMu.Lock()
+	revealed = msg
+	zoneMu.Unlock()
+}
+
+// isRevealed reports whether msg is the message picked in selection mode
+func isRevealed(msg shared.Message) bool {
+	zoneMu.RLock()
+	defer zoneMu.RUnlock()
+	return revealed != nil && revealed.Sender == msg.Sender && revealed.CreatedAt.Equal(msg.CreatedAt) && revealed.Content == msg.Content
+}
+
+// messageTimestamp is the timestamp shown on msg: relative when that is on,
+// absolute (with the date) for the selected message, otherwise layout
+func messageTimestamp(msg shared.Message, layout string, now time.Time) string {
+	if isRevealed(msg) {
+		return formatTimestamp(msg.CreatedAt, "2006-01-02 "+layout)
+	}
+	if usingRelativeTimes() {
+		return relativeTime(msg.CreatedAt, now)
+	}
+	return formatTimestamp(msg.CreatedAt, layout)
+}
+
+// relativeTime describes how long before now t was, to the minute
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return i18n.T("time.just_now")
+	case d < time.Hour:
+		return i18n.T("time.minutes_ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return i18n.T("time.hours_ago", int(d/time.Hour))
+	default:
+		return i18n.T("time.days_ago", int(d/(24*time.Hour)))
+	}
+}
+
+// relativeTickMsg redraws relative timestamps
+type relativeTickMsg struct{}
+
+// relativeTick fires at the start of the next minute
+func relativeTick() tea.Cmd {
+	return tea.Tick(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)), func(time.Time) tea.Msg {
+		return relativeTickMsg{}
+	})
+}
+
+// cycleTimeFormat steps :time through 12h, 24h and relative timestamps
+func (m *model) cycleTimeFormat() tea.Cmd {
+	var label string
+	switch {
+	case usingRelativeTimes():
+		setRelativeTimes(false)
+		m.twentyFourHour = false
+		label = "12h"
+	case !m.twentyFourHour:
+		m.twentyFourHour = true
+		label = "24h"
+	default:
+		setRelativeTimes(true)
+		label = i18n.T("time.relative")
+	}
+	m.cfg.TwentyFourHour = m.twentyFourHour
+	m.cfg.RelativeTime = usingRelativeTimes()
+	_ = config.SaveConfig(m.configFilePath, m.cfg)
+	m.banner = i18n.T("banner.time_format", label)
+	m.refreshMessages()
+	if usingRelativeTimes() && !m.relativeTicking {
+		m.relativeTicking = true
+		return relativeTick()
+	}
+	return nil
+}
+
+// selectedMessage returns the index in m.messages of the selected message,
+// or -1
+func (m *model) selectedMessage() int {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if isRevealed(m.messages[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectMessage moves the selection dir messages newer (1) or older (-1),
+// starting from the newest; moving past the newest leaves selection mode
+func (m *model) selectMessage(dir int) {
+	i := m.selectedMessage()
+	if i < 0 {
+		if dir > 0 {
+			return
+		}
+		i = len(m.messages)
+	}
+	for i += dir; i >= 0 && i < len(m.messages); i += dir {
+		msg := m.messages[i]
+		if isIgnored(msg.Sender) || msg.Type == translationMessageType {
+			continue
+		}
+		setRevealedMessage(&msg)
+		layout := "15:04:05"
+		if !m.twentyFourHour {
+			layout = "03:04:05 PM"
+		}
+		m.banner = i18n.T("banner.message_selected", displayName(msg.Sender), formatTimestamp(msg.CreatedAt, "Mon 2006-01-02 "+layout+" MST"))
+		m.refreshMessages()
+		return
+	}
+	if dir > 0 {
+		m.clearMessageSelection()
+	}
+}
+
+// clearMessageSelection leaves selection mode
+func (m *model) clearMessageSelection() {
+	setRevealedMessage(nil)
+	m.banner = ""
+	m.refreshMessages()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestFormatTimestampZones(t *testing.T) {
+	t.Cleanup(func() { _ = setDisplayZone("", false) })
+	// Stamped by a server in UTC+2
+	at := time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("", 2*60*60))
+
+	if err := setDisplayZone("America/New_York", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := formatTimestamp(at, "15:04"); got != "16:30" {
+		t.Errorf("Expected New York time, got %q", got)
+	}
+	if err := setDisplayZone("America/New_York", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := formatTimestamp(at, "15:04"); got != "16:30 (server 23:30)" {
+		t.Errorf("Expected both clocks, got %q", got)
+	}
+	if err := setDisplayZone("Not/AZone", false); err == nil {
+		t.Error("Expected an unknown zone to be reported")
+	}
+	if displayTime(at).Location() != time.Local {
+		t.Error("Expected an unknown zone to fall back to local time")
+	}
+}
+
+func TestDateHeadersFollowDisplayZone(t *testing.T) {
+	t.Cleanup(func() { _ = setDisplayZone("", false) })
+	// 23:30 and 00:30 UTC straddle midnight in UTC but not in Tokyo
+	msgs := []shared.Message{
+		{Sender: "alice", Content: "late", CreatedAt: time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)},
+		{Sender: "bob", Content: "early", CreatedAt: time.Date(2024, 3, 2, 0, 30, 0, 0, time.UTC)},
+	}
+	if err := setDisplayZone("UTC", false); err != nil {
+		t.Fatal(err)
+	}
+	out := renderMessages(append([]shared.Message(nil), msgs...), getThemeStyles("system"), "me", nil, 80, true)
+	if !strings.Contains(out, "2024-03-01") || !strings.Contains(out, "2024-03-02") {
+		t.Errorf("Expected two date headers in UTC:\n%s", out)
+	}
+	if err := setDisplayZone("Asia/Tokyo", false); err != nil {
+		t.Fatal(err)
+	}
+	out = renderMessages(append([]shared.Message(nil), msgs...), getThemeStyles("system"), "me", nil, 80, true)
+	if strings.Contains(out, "2024-03-01") || strings.Count(out, "2024-03-02") != 1 {
+		t.Errorf("Expected one date header in Tokyo:\n%s", out)
+	}
+}
+
+func TestApplyTZCommand(t *testing.T) {
+	t.Cleanup(func() { _ = setDisplayZone("", false) })
+	zone, server, banner, err := applyTZCommand(":tz Europe/Berlin", "", false)
+	if err != nil || zone != "Europe/Berlin" || server || !strings.Contains(banner, "Europe/Berlin") {
+		t.Fatalf("applyTZCommand = %q, %v, %q, %v", zone, server, banner, err)
+	}
+	zone, server, _, err = applyTZCommand(":tz server on", zone, server)
+	if err != nil || zone != "Europe/Berlin" || !server {
+		t.Errorf("Expected server time on, got %q, %v, %v", zone, server, err)
+	}
+	zone, _, _, err = applyTZCommand(":tz local", zone, server)
+	if err != nil || zone != "" {
+		t.Errorf("Expected local to clear the zone, got %q, %v", zone, err)
+	}
+	for _, bad := range []string{":tz Mars/Olympus", ":tz server", ":tz server maybe", ":tz a b"} {
+		if _, _, _, err := applyTZCommand(bad, "", false); err == nil {
+			t.Errorf("%q should fail", bad)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{2*time.Minute + 30*time.Second, "2m ago"},
+		{3 * time.Hour, "3h ago"},
+		{50 * time.Hour, "2d ago"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("relativeTime(%s ago) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestMessageTimestampRevealsSelected(t *testing.T) {
+	t.Cleanup(func() {
+		setRelativeTimes(false)
+		setRevealedMessage(nil)
+	})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	older := shared.Message{Sender: "alice", Content: "hi", CreatedAt: now.Add(-5 * time.Minute)}
+	newer := shared.Message{Sender: "bob", Content: "hey", CreatedAt: now.Add(-time.Minute)}
+
+	setRelativeTimes(true)
+	if got := messageTimestamp(older, "15:04", now); got != "5m ago" {
+		t.Errorf("Expected a relative time, got %q", got)
+	}
+	setRevealedMessage(&older)
+	if got := messageTimestamp(older, "15:04", now); got != "2024-03-01 11:55" {
+		t.Errorf("Expected the selected message's full time, got %q", got)
+	}
+	if got := messageTimestamp(newer, "15:04", now); got != "1m ago" {
+		t.Errorf("Expected other messages to stay relative, got %q", got)
+	}
+}
+
+func TestSelectMessageSkipsIgnored(t *testing.T) {
+	t.Cleanup(func() {
+		setRevealedMessage(nil)
+		setIgnoredUsers(nil)
+	})
+	setIgnoredUsers([]string{"spammer"})
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := &model{messages: []shared.Message{
+		{Sender: "alice", Content: "one", CreatedAt: at},
+		{Sender: "spammer", Content: "buy", CreatedAt: at.Add(time.Minute)},
+		{Sender: "bob", Content: "two", CreatedAt: at.Add(2 * time.Minute)},
+	}}
+
+	m.selectMessage(-1)
+	if m.selectedMessage() != 2 {
+		t.Fatalf("Expected the newest message to be selected, got %d", m.selectedMessage())
+	}
+	m.selectMessage(-1)
+	if m.selectedMessage() != 0 {
+		t.Fatalf("Expected the ignored message to be skipped, got %d", m.selectedMessage())
+	}
+	m.selectMessage(1)
+	m.selectMessage(1)
+	if m.selectedMessage() != -1 {
+		t.Errorf("Expected moving past the newest message to leave selection, got %d", m.selectedMessage())
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// applyTopic keeps the channel topic for the header; an empty one clears it
+func (m *model) applyTopic(t shared.Topic) {
+	m.topic = t.Text
+	m.channel = t.Channel
+}
+
+// applyMOTD shows the message of the day in the banner. A cleared one
+// leaves the banner as it is.
+func (m *model) applyMOTD(motd shared.MOTD) {
+	if motd.Text != "" {
+		m.banner = i18n.T("banner.motd", motd.Text)
+	}
+}
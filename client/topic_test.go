@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestTopicAndMOTDMessages(t *testing.T) {
+	ws := func(typ string, v interface{}) wsMsg {
+		data, _ := json.Marshal(v)
+		return wsMsg{Type: typ, Data: data}
+	}
+
+	m := &model{}
+	m.Update(ws("topic", shared.Topic{Channel: "room", Text: "Release day", SetBy: "admin"}))
+	if m.topic != "Release day" {
+		t.Errorf("Expected the topic to be kept for the header, got %q", m.topic)
+	}
+	m.Update(ws("motd", shared.MOTD{Text: "Maintenance at 22:00"}))
+	if !strings.Contains(m.banner, "Maintenance at 22:00") {
+		t.Errorf("Expected the MOTD in the banner, got %q", m.banner)
+	}
+
+	// A cleared MOTD leaves the banner alone; a cleared topic empties the header
+	m.banner = "connected"
+	m.Update(ws("motd", shared.MOTD{}))
+	m.Update(ws("topic", shared.Topic{Channel: "room"}))
+	if m.banner != "connected" || m.topic != "" {
+		t.Errorf("Unexpected state after clearing: banner %q, topic %q", m.banner, m.topic)
+	}
+}
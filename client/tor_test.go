@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestIsOnion(t *testing.T) {
+	tests := map[string]bool{
+		"ws://abcdefghijklmnop.onion/ws":      true,
+		"wss://ABCDEFGHIJKLMNOP.ONION:443/ws": true,
+		"ws://localhost:8080/ws":              false,
+		"wss://onion.example.com/ws":          false,
+	}
+	for serverURL, want := range tests {
+		if got := isOnion(serverURL); got != want {
+			t.Errorf("isOnion(%q) = %v, want %v", serverURL, got, want)
+		}
+	}
+}
+
+func TestCheckOnionTLS(t *testing.T) {
+	if err := checkOnionTLS("wss://abcdefghijklmnop.onion/ws", true); err == nil {
+		t.Error("Expected --skip-tls-verify to be refused for an onion service")
+	}
+	if err := checkOnionTLS("ws://abcdefghijklmnop.onion/ws", false); err != nil {
+		t.Errorf("Expected ws:// to an onion service to be fine, got %v", err)
+	}
+	if err := checkOnionTLS("wss://chat.example.com/ws", true); err != nil {
+		t.Errorf("Expected other servers to be left alone, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// translationMessageType marks client-local entries that hold a translation of
+// the message directly above them. They are never sent to the server.
+const translationMessageType shared.MessageType = "translation"
+
+const defaultTranslateTarget = "en"
+
+// Translation is the result of translating a message
+type Translation struct {
+	Text           string
+	DetectedSource string
+}
+
+// Translator translates text into the target language. It is the integration
+// point for translation services; LibreTranslate is the built-in implementation.
+type Translator interface {
+	Translate(ctx context.Context, text, target string) (Translation, error)
+}
+
+// libreTranslator talks to a LibreTranslate-compatible /translate endpoint
+type libreTranslator struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func (t *libreTranslator) Translate(ctx context.Context, text, target string) (Translation, error) {
+	body, err := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  "auto",
+		"target":  target,
+		"format":  "text",
+		"api_key": t.apiKey,
+	})
+	if err != nil {
+		return Translation{}, err
+	}
+
+	url := strings.TrimRight(t.endpoint, "/")
+	if !strings.HasSuffix(url, "/translate") {
+		url += "/translate"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Translation{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return Translation{}, fmt.Errorf("translation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		TranslatedText   string `json:"translatedText"`
+		Error            string `json:"error"`
+		DetectedLanguage struct {
+			Language string `json:"language"`
+		} `json:"detectedLanguage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Translation{}, fmt.Errorf("invalid translation response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != "" {
+		if result.Error == "" {
+			result.Error = resp.Status
+		}
+		return Translation{}, fmt.Errorf("translation service error: %s", result.Error)
+	}
+	return Translation{Text: result.TranslatedText, DetectedSource: result.DetectedLanguage.Language}, nil
+}
+
+// newTranslatorFromConfig returns the configured translator and target language,
+// or a nil translator when no endpoint is set. MARCHAT_TRANSLATE_* environment
+// variables override the config file.
+func newTranslatorFromConfig(cfg config.Config) (Translator, string) {
+	endpoint, apiKey, target := cfg.TranslateURL, cfg.TranslateAPIKey, cfg.TranslateTarget
+	if v := os.Getenv("MARCHAT_TRANSLATE_URL"); v != "" {
+		endpoint = v
+	}
+	if v := os.Getenv("MARCHAT_TRANSLATE_API_KEY"); v != "" {
+		apiKey = v
+	}
+	if v := os.Getenv("MARCHAT_TRANSLATE_TARGET"); v != "" {
+		target = v
+	}
+	if target == "" {
+		target = defaultTranslateTarget
+	}
+	if endpoint == "" {
+		return nil, target
+	}
+	return &libreTranslator{endpoint: endpoint, apiKey: apiKey, client: &http.Client{Timeout: 15 * time.Second}}, target
+}
+
+// translationResultMsg carries an asynchronous translation back to Update
+type translationResultMsg struct {
+	original    shared.Message
+	translation Translation
+	target      string
+	err         error
+}
+
+// parseTranslateArgs handles ":translate [n] [lang]" where n counts back from the
+// newest translatable message (1 = newest)
+func parseTranslateArgs(args []string, defaultTarget string) (int, string, error) {
+	n, target := 1, defaultTarget
+	for _, arg := range args {
+		if v, err := strconv.Atoi(arg); err == nil {
+			if v < 1 {
+				return 0, "", fmt.Errorf("message number must be 1 or higher")
+			}
+			n = v
+		} else {
+			target = arg
+		}
+	}
+	return n, target, nil
+}
+
+// translatableMessage returns the nth newest chat message that has text to translate
+func translatableMessage(msgs []shared.Message, n int) (shared.Message, bool) {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		if msg.Content == "" || msg.Sender == "System" || msg.Type == translationMessageType ||
+			msg.Type == shared.FileMessageType || msg.Type == shared.PollMessageType {
+			continue
+		}
+		n--
+		if n == 0 {
+			return msg, true
+		}
+	}
+	return shared.Message{}, false
+}
+
+// translateCmd runs the translation off the UI goroutine
+func translateCmd(t Translator, msg shared.Message, target string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		tr, err := t.Translate(ctx, msg.Content, target)
+		return translationResultMsg{original: msg, translation: tr, target: target, err: err}
+	}
+}
+
+// insertTranslation places a translation entry directly beneath the original
+// message. It sorts one nanosecond after the original so ordering is preserved.
+func insertTranslation(msgs []shared.Message, original shared.Message, tr Translation, target string) []shared.Message {
+	label := target
+	if tr.DetectedSource != "" {
+		label = tr.DetectedSource + "→" + target
+	}
+	entry := shared.Message{
+		Sender:    original.Sender,
+		Content:   fmt.Sprintf("[%s] %s", label, tr.Text),
+		CreatedAt: original.CreatedAt.Add(time.Nanosecond),
+		Type:      translationMessageType,
+	}
+	for i, msg := range msgs {
+		if msg.Sender == original.Sender && msg.CreatedAt.Equal(original.CreatedAt) && msg.Content == original.Content {
+			entry.Seq = msg.Seq
+			// Replace an earlier translation of the same message
+			if i+1 < len(msgs) && msgs[i+1].Type == translationMessageType && msgs[i+1].CreatedAt.Equal(entry.CreatedAt) {
+				msgs[i+1] = entry
+				return msgs
+			}
+			msgs = append(msgs, shared.Message{})
+			copy(msgs[i+2:], msgs[i+1:])
+			msgs[i+1] = entry
+			return msgs
+		}
+	}
+	return msgs
+}
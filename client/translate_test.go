@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestLibreTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate" || r.Method != http.MethodPost {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req["q"] != "hola" || req["target"] != "en" || req["api_key"] != "secret" {
+			t.Errorf("Unexpected request body: %v", req)
+		}
+		w.Write([]byte(`{"translatedText":"hello","detectedLanguage":{"language":"es","confidence":90}}`))
+	}))
+	defer server.Close()
+
+	translator, target := newTranslatorFromConfig(config.Config{TranslateURL: server.URL, TranslateAPIKey: "secret"})
+	if translator == nil || target != "en" {
+		t.Fatalf("Expected configured translator with default target, got %v %q", translator, target)
+	}
+	tr, err := translator.Translate(context.Background(), "hola", target)
+	if err != nil {
+		t.Fatalf("Translate failed: %v", err)
+	}
+	if tr.Text != "hello" || tr.DetectedSource != "es" {
+		t.Errorf("Unexpected translation: %+v", tr)
+	}
+
+	if translator, _ := newTranslatorFromConfig(config.Config{}); translator != nil {
+		t.Error("Translator should be nil without an endpoint")
+	}
+}
+
+func TestLibreTranslatorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"en is not supported"}`))
+	}))
+	defer server.Close()
+
+	translator, _ := newTranslatorFromConfig(config.Config{TranslateURL: server.URL + "/translate"})
+	if _, err := translator.Translate(context.Background(), "hola", "en"); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Expected service error to be surfaced, got %v", err)
+	}
+}
+
+func TestParseTranslateArgs(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantN      int
+		wantTarget string
+		wantErr    bool
+	}{
+		{nil, 1, "en", false},
+		{[]string{"3"}, 3, "en", false},
+		{[]string{"de"}, 1, "de", false},
+		{[]string{"2", "fr"}, 2, "fr", false},
+		{[]string{"0"}, 0, "", true},
+	}
+	for _, tt := range tests {
+		n, target, err := parseTranslateArgs(tt.args, "en")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTranslateArgs(%v) error = %v", tt.args, err)
+			continue
+		}
+		if !tt.wantErr && (n != tt.wantN || target != tt.wantTarget) {
+			t.Errorf("parseTranslateArgs(%v) = %d, %q; want %d, %q", tt.args, n, target, tt.wantN, tt.wantTarget)
+		}
+	}
+}
+
+func TestInsertTranslation(t *testing.T) {
+	now := time.Now()
+	msgs := []shared.Message{
+		{Sender: "ana", Content: "hola", CreatedAt: now},
+		{Sender: "System", Content: "welcome", CreatedAt: now.Add(time.Second)},
+		{Sender: "bob", Content: "hi", CreatedAt: now.Add(2 * time.Second)},
+	}
+
+	original, ok := translatableMessage(msgs, 2)
+	if !ok || original.Content != "hola" {
+		t.Fatalf("Expected second newest non-system message, got %+v", original)
+	}
+
+	msgs = insertTranslation(msgs, original, Translation{Text: "hello", DetectedSource: "es"}, "en")
+	if len(msgs) != 4 || msgs[1].Type != translationMessageType || msgs[1].Content != "[es→en] hello" {
+		t.Fatalf("Translation should be inserted beneath the original, got %+v", msgs)
+	}
+
+	// Translating again replaces the earlier translation
+	msgs = insertTranslation(msgs, original, Translation{Text: "hallo"}, "de")
+	if len(msgs) != 4 || msgs[1].Content != "[de] hallo" {
+		t.Errorf("Expected translation to be replaced, got %+v", msgs)
+	}
+
+	sortMessages(msgs)
+	if msgs[1].Type != translationMessageType {
+		t.Error("Translation should stay beneath its original after sorting")
+	}
+	if _, ok := translatableMessage(msgs, 1); !ok {
+		t.Error("Expected newest message to be translatable")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// termuxURLOpenerMarker identifies a termux-url-opener script we wrote
+const termuxURLOpenerMarker = "# marchat URL handler"
+
+// isChatLinkArg reports whether a command-line argument is a marchat:// link
+func isChatLinkArg(arg string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(arg)), "marchat://")
+}
+
+// registerURLHandler makes the OS open marchat:// links with this binary
+func registerURLHandler() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate marchat-client: %w", err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isTermux():
+		// Links shared to Termux are passed to ~/bin/termux-url-opener
+		return writeTermuxURLOpener(filepath.Join(home, "bin", "termux-url-opener"), exe)
+	case runtime.GOOS == "linux" || runtime.GOOS == "freebsd" || runtime.GOOS == "openbsd":
+		dir := filepath.Join(home, ".local", "share", "applications")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		desktop := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=marchat
+Exec=%q %%u
+Terminal=true
+NoDisplay=true
+MimeType=x-scheme-handler/marchat;
+`, exe)
+		if err := os.WriteFile(filepath.Join(dir, "marchat.desktop"), []byte(desktop), 0644); err != nil {
+			return err
+		}
+		if out, err := exec.Command("xdg-mime", "default", "marchat.desktop", "x-scheme-handler/marchat").CombinedOutput(); err != nil {
+			return fmt.Errorf("wrote marchat.desktop but xdg-mime failed: %v %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	case runtime.GOOS == "windows":
+		key := `HKCU\Software\Classes\marchat`
+		for _, args := range [][]string{
+			{"add", key, "/ve", "/d", "URL:marchat", "/f"},
+			{"add", key, "/v", "URL Protocol", "/d", "", "/f"},
+			{"add", key + `\shell\open\command`, "/ve", "/d", fmt.Sprintf(`"%s" "%%1"`, exe), "/f"},
+		} {
+			if out, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+				return fmt.Errorf("reg %s failed: %v %s", args[0], err, strings.TrimSpace(string(out)))
+			}
+		}
+		return nil
+	case runtime.GOOS == "darwin":
+		return fmt.Errorf("macOS only routes URL schemes to app bundles; open links with: marchat-client \"marchat://...\"")
+	default:
+		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+}
+
+// writeTermuxURLOpener installs a termux-url-opener that hands marchat://
+// links to the client, refusing to replace a script someone else wrote
+func writeTermuxURLOpener(path, exe string) error {
+	if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), termuxURLOpenerMarker) {
+		return fmt.Errorf("%s already exists; add this line to it:\n  case \"$1\" in marchat://*) exec %q \"$1\" ;; esac", path, exe)
+	}
+	script := fmt.Sprintf(`#!/data/data/com.termux/files/usr/bin/sh
+%s
+case "$1" in
+  marchat://*) exec %q "$1" ;;
+  *) echo "Not a marchat link: $1" ;;
+esac
+`, termuxURLOpenerMarker, exe)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(script), 0755)
+}
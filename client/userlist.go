@@ -0,0 +1,73 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+// UserListDelta is what changed in the user list since the one numbered
+// Seq-1; the server sends these in place of full lists once it has sent one
+type UserListDelta struct {
+	Seq          uint64            `json:"seq"`
+	Joined       []string          `json:"joined,omitempty"`
+	Left         []string          `json:"left,omitempty"`
+	DisplayNames map[string]string `json:"display_names,omitempty"` // changed names; "" clears one
+	Away         []string          `json:"away,omitempty"`          // went idle
+	Back         []string          `json:"back,omitempty"`          // active again
+}
+
+// applyUserListDelta returns ul with delta applied, or false when delta
+// doesn't follow on from ul
+func applyUserListDelta(ul UserList, delta UserListDelta) (UserList, bool) {
+	if delta.Seq != ul.Seq+1 {
+		return ul, false
+	}
+	gone := func(u string) bool { return slices.Contains(delta.Left, u) }
+	next := UserList{Seq: delta.Seq, DisplayNames: make(map[string]string)}
+	next.Users = slices.DeleteFunc(slices.Clone(ul.Users), gone)
+	for _, u := range delta.Joined {
+		if !slices.Contains(next.Users, u) {
+			next.Users = append(next.Users, u)
+		}
+	}
+	sort.Strings(next.Users) // the server lists users alphabetically
+
+	maps.Copy(next.DisplayNames, ul.DisplayNames)
+	maps.DeleteFunc(next.DisplayNames, func(u, _ string) bool { return gone(u) })
+	for u, name := range delta.DisplayNames {
+		if name == "" {
+			delete(next.DisplayNames, u)
+		} else {
+			next.DisplayNames[u] = name
+		}
+	}
+
+	for _, u := range next.Users {
+		if slices.Contains(delta.Away, u) || (slices.Contains(ul.Away, u) && !slices.Contains(delta.Back, u)) {
+			next.Away = append(next.Away, u)
+		}
+	}
+	return next, true
+}
+
+// setUserList shows a user list from the server, full or built from deltas
+func (m *model) setUserList(ul UserList) {
+	m.userList = ul
+	m.users = ul.Users
+	updateDisplayNames(ul.Users, ul.DisplayNames)
+	updatePresence(ul.Away)
+	// Remember a name accepted by :nick for the next connect
+	if m.pendingNick {
+		m.pendingNick = false
+		if name := ul.DisplayNames[m.cfg.Username]; name != m.cfg.DisplayName {
+			m.cfg.DisplayName = name
+			_ = config.SaveConfig(m.configFilePath, m.cfg)
+		}
+	}
+	m.refreshMessages()
+	userListWidth := 18
+	m.userListViewport.SetContent(renderUserList(m.users, m.cfg.Username, m.styles, userListWidth, *isAdmin, m.selectedUserIndex))
+}
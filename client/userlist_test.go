@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyUserListDelta(t *testing.T) {
+	ul := UserList{Users: []string{"alice", "bob", "carol"}, DisplayNames: map[string]string{"bob": "Bobby"}, Away: []string{"carol"}, Seq: 4}
+	delta := UserListDelta{
+		Seq:          5,
+		Joined:       []string{"dave"},
+		Left:         []string{"bob"},
+		DisplayNames: map[string]string{"alice": "Al"},
+		Away:         []string{"alice"},
+		Back:         []string{"carol"},
+	}
+	got, ok := applyUserListDelta(ul, delta)
+	want := UserList{Users: []string{"alice", "carol", "dave"}, DisplayNames: map[string]string{"alice": "Al"}, Away: []string{"alice"}, Seq: 5}
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("applyUserListDelta() = %+v, %v, want %+v", got, ok, want)
+	}
+
+	// Clearing a name
+	got, _ = applyUserListDelta(got, UserListDelta{Seq: 6, DisplayNames: map[string]string{"alice": ""}})
+	if len(got.DisplayNames) != 0 {
+		t.Errorf("Expected alice's name cleared, got %v", got.DisplayNames)
+	}
+
+	// A delta out of sequence leaves the list alone
+	if same, ok := applyUserListDelta(got, UserListDelta{Seq: 9, Joined: []string{"eve"}}); ok || !reflect.DeepEqual(same, got) {
+		t.Errorf("Expected a skipped delta to be refused, got %+v, %v", same, ok)
+	}
+}
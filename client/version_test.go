@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestVersionMismatchBanner(t *testing.T) {
+	orig := shared.ClientVersion
+	shared.ClientVersion = "v0.9.0"
+	defer func() { shared.ClientVersion = orig }()
+
+	version := func(info shared.VersionInfo) wsMsg {
+		data, _ := json.Marshal(info)
+		return wsMsg{Type: "version", Data: data}
+	}
+
+	m := &model{}
+	m.Update(version(shared.VersionInfo{ServerVersion: "v0.9.3"}))
+	if m.incompatibleServer != "" || m.banner != "" {
+		t.Errorf("Expected no warning for a compatible server, got %q", m.banner)
+	}
+
+	m.Update(version(shared.VersionInfo{ServerVersion: "v1.0.0"}))
+	if m.incompatibleServer != "v1.0.0" || !strings.Contains(m.banner, "major version") {
+		t.Errorf("Expected a major version warning, got %q", m.banner)
+	}
+
+	m = &model{}
+	m.Update(version(shared.VersionInfo{ServerVersion: "v0.9.5", MinClientVersion: "v0.9.4"}))
+	if m.incompatibleServer != "v0.9.5" || !strings.Contains(m.banner, "v0.9.4 or newer") {
+		t.Errorf("Expected an upgrade warning, got %q", m.banner)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+	"github.com/Cod-e-Codes/marchat/client/i18n"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Recording stops on its own after this long
+const maxVoiceRecording = 2 * time.Minute
+
+// Default voice note commands: sox records WAV and plays Opus, opusenc
+// encodes. Termux records Opus itself, but in the background, so a small
+// shell stays in the foreground until it is interrupted.
+const (
+	defaultVoiceRecordCommand = "rec -q -c 1 -r 48000 {file}"
+	defaultVoiceEncodeCommand = "opusenc --quiet --bitrate 24 {in} {out}"
+	defaultVoicePlayCommand   = "play -q {file}"
+	termuxVoiceRecordCommand  = `sh -c 'termux-microphone-record -e opus -f "$1" -l 0 >/dev/null; trap "termux-microphone-record -q >/dev/null; sleep 1; exit 0" INT TERM; while :; do sleep 1; done' voice {file}`
+	termuxVoicePlayCommand    = "termux-media-player play {file}"
+)
+
+// voiceWaveformBars draws waveform levels 0-7
+var voiceWaveformBars = []rune("▁▂▃▄▅▆▇█")
+
+// voiceRecordedMsg carries a finished recording back to Update
+type voiceRecordedMsg struct {
+	audio     *shared.AudioMeta
+	cancelled bool
+	err       error
+}
+
+// voicePlayedMsg reports that a voice note finished playing
+type voicePlayedMsg struct {
+	name string
+	err  error
+}
+
+// voiceCommands returns the record, encode and play commands, falling back
+// to the defaults for this platform
+func voiceCommands(cfg config.Config) (record, encode, play string) {
+	record, encode, play = cfg.VoiceRecordCommand, cfg.VoiceEncodeCommand, cfg.VoicePlayCommand
+	if record == "" {
+		record = defaultVoiceRecordCommand
+		if isTermux() {
+			record = termuxVoiceRecordCommand
+		}
+	}
+	if encode == "" {
+		encode = defaultVoiceEncodeCommand
+	}
+	if play == "" {
+		play = defaultVoicePlayCommand
+		if isTermux() {
+			play = termuxVoicePlayCommand
+		}
+	}
+	return record, encode, play
+}
+
+// splitCommandLine splits a command template into arguments, keeping
+// single- or double-quoted text together. Backslashes are literal so
+// Windows paths survive.
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// voiceCommand builds a command from a template, filling in placeholders
+// such as {file} after splitting so paths with spaces stay one argument
+func voiceCommand(template string, placeholders map[string]string) (*exec.Cmd, error) {
+	args, err := splitCommandLine(template)
+	if err != nil {
+		return nil, err
+	}
+	for i, arg := range args {
+		for name, value := range placeholders {
+			arg = strings.ReplaceAll(arg, "{"+name+"}", value)
+		}
+		args[i] = arg
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// voiceRecorder is a recording in progress
+type voiceRecorder struct {
+	cmd    *exec.Cmd
+	dir    string // temporary directory holding the recording
+	stderr bytes.Buffer
+	timer  *time.Timer
+
+	mu        sync.Mutex
+	cancelled bool
+}
+
+// startVoiceRecording starts the record command and returns the command
+// that waits for it to be stopped and turns the result into a voice note
+func startVoiceRecording(cfg config.Config) (*voiceRecorder, tea.Cmd, error) {
+	recordTemplate, encodeTemplate, _ := voiceCommands(cfg)
+	dir, err := os.MkdirTemp("", "marchat-voice-")
+	if err != nil {
+		return nil, nil, err
+	}
+	raw := filepath.Join(dir, "recording.wav")
+	cmd, err := voiceCommand(recordTemplate, map[string]string{"file": raw})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	r := &voiceRecorder{cmd: cmd, dir: dir}
+	cmd.Stderr = &r.stderr
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	r.timer = time.AfterFunc(maxVoiceRecording, r.stop)
+	return r, func() tea.Msg {
+		waitErr := r.cmd.Wait()
+		r.timer.Stop()
+		defer os.RemoveAll(r.dir)
+		r.mu.Lock()
+		cancelled := r.cancelled
+		r.mu.Unlock()
+		if cancelled {
+			return voiceRecordedMsg{cancelled: true}
+		}
+		audio, err := finishVoiceNote(raw, encodeTemplate)
+		if err != nil && waitErr != nil {
+			// The recorder's own complaint explains more than a missing file
+			if detail := strings.TrimSpace(r.stderr.String()); detail != "" {
+				err = errors.New(detail)
+			}
+		}
+		return voiceRecordedMsg{audio: audio, err: err}
+	}, nil
+}
+
+// stop ends the recording; the voice note is sent
+func (r *voiceRecorder) stop() {
+	// Interrupting lets recorders such as sox finish the file; Windows
+	// can't deliver it, so the process is ended instead
+	if err := r.cmd.Process.Signal(os.Interrupt); err != nil {
+		_ = r.cmd.Process.Kill()
+	}
+}
+
+// cancel ends the recording and throws it away
+func (r *voiceRecorder) cancel() {
+	r.mu.Lock()
+	r.cancelled = true
+	r.mu.Unlock()
+	r.stop()
+}
+
+// finishVoiceNote encodes a recording to Ogg Opus unless the recorder
+// already wrote Opus, and measures it
+func finishVoiceNote(raw, encodeTemplate string) (*shared.AudioMeta, error) {
+	data, err := os.ReadFile(raw)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("nothing was recorded")
+	}
+	if !bytes.HasPrefix(data, []byte("OggS")) {
+		encoded := filepath.Join(filepath.Dir(raw), "voice.opus")
+		cmd, err := voiceCommand(encodeTemplate, map[string]string{"in": raw, "out": encoded})
+		if err != nil {
+			return nil, err
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if detail := strings.TrimSpace(string(out)); detail != "" {
+				return nil, fmt.Errorf("%w: %s", err, detail)
+			}
+			return nil, err
+		}
+		if data, err = os.ReadFile(encoded); err != nil {
+			return nil, err
+		}
+	}
+	duration, waveform, err := shared.ParseOggOpus(data)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.AudioMeta{
+		Filename:   "voice.opus",
+		DurationMs: duration.Milliseconds(),
+		Waveform:   waveform,
+		Data:       data,
+	}, nil
+}
+
+// playVoiceNote plays a received voice note with the configured player
+func playVoiceNote(cfg config.Config, file *shared.FileMeta) tea.Cmd {
+	_, _, playTemplate := voiceCommands(cfg)
+	return func() tea.Msg {
+		dir, err := os.MkdirTemp("", "marchat-voice-")
+		if err != nil {
+			return voicePlayedMsg{name: file.Filename, err: err}
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, filepath.Base(file.Filename))
+		if err := os.WriteFile(path, file.Data, 0600); err != nil {
+			return voicePlayedMsg{name: file.Filename, err: err}
+		}
+		cmd, err := voiceCommand(playTemplate, map[string]string{"file": path})
+		if err == nil {
+			if out, runErr := cmd.CombinedOutput(); runErr != nil {
+				err = runErr
+				if detail := strings.TrimSpace(string(out)); detail != "" {
+					err = fmt.Errorf("%w: %s", runErr, detail)
+				}
+			}
+		}
+		return voicePlayedMsg{name: file.Filename, err: err}
+	}
+}
+
+// voiceError explains a failed recording, pointing at the config when the
+// recorder or encoder isn't installed
+func voiceError(err error) string {
+	var notFound *exec.Error
+	if errors.As(err, &notFound) && errors.Is(notFound.Err, exec.ErrNotFound) {
+		return i18n.T("banner.voice_no_recorder", notFound.Name)
+	}
+	return i18n.T("banner.voice_failed", err.Error())
+}
+
+// formatVoiceDuration renders a voice note's length as m:ss
+func formatVoiceDuration(d time.Duration) string {
+	seconds := int((d + time.Second/2) / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// renderWaveform draws waveform levels as a row of block characters
+func renderWaveform(levels []uint8) string {
+	var b strings.Builder
+	for _, l := range levels {
+		b.WriteRune(voiceWaveformBars[min(int(l), len(voiceWaveformBars)-1)])
+	}
+	return b.String()
+}
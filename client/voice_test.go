@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/client/config"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		"rec -q {file}":                     {"rec", "-q", "{file}"},
+		`sh -c 'echo "a b"; exit' x`:        {"sh", "-c", `echo "a b"; exit`, "x"},
+		`"C:\Program Files\sox\play.exe" x`: {`C:\Program Files\sox\play.exe`, "x"},
+		"  spaced\targs  ":                  {"spaced", "args"},
+		`empty '' arg`:                      {"empty", "", "arg"},
+	}
+	for in, want := range tests {
+		got, err := splitCommandLine(in)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("splitCommandLine(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "   ", `rec 'open`} {
+		if _, err := splitCommandLine(in); err == nil {
+			t.Errorf("splitCommandLine(%q) should fail", in)
+		}
+	}
+}
+
+func TestVoiceCommandPlaceholders(t *testing.T) {
+	cmd, err := voiceCommand("enc --in={in} {out}", map[string]string{"in": "/tmp/my recording.wav", "out": "/tmp/out.opus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"enc", "--in=/tmp/my recording.wav", "/tmp/out.opus"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("Args = %q, want %q", cmd.Args, want)
+	}
+
+	record, encode, play := voiceCommands(config.Config{VoicePlayCommand: "mpv {file}"})
+	if record == "" || encode != defaultVoiceEncodeCommand || play != "mpv {file}" {
+		t.Errorf("voiceCommands = %q, %q, %q", record, encode, play)
+	}
+}
+
+func TestFinishVoiceNote(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "recording.wav")
+
+	if _, err := finishVoiceNote(raw, "true"); err == nil {
+		t.Error("Expected an error when nothing was recorded")
+	}
+
+	// A recorder that already wrote Ogg Opus skips the encoder
+	if err := os.WriteFile(raw, testVoiceNote(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	audio, err := finishVoiceNote(raw, "missing-encoder {in} {out}")
+	if err != nil {
+		t.Fatalf("finishVoiceNote: %v", err)
+	}
+	if audio.Duration() != time.Second || len(audio.Waveform) == 0 {
+		t.Errorf("Unexpected voice note: %v, %v", audio.Duration(), audio.Waveform)
+	}
+
+	// Anything else goes through the encoder
+	if err := os.WriteFile(raw, []byte("RIFF....WAVE"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = finishVoiceNote(raw, "marchat-missing-encoder {in} {out}")
+	if msg := voiceError(err); !strings.Contains(msg, "marchat-missing-encoder") {
+		t.Errorf("voiceError = %q, want it to name the encoder", msg)
+	}
+	var notFound *exec.Error
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected a not-found error, got %v", err)
+	}
+}
+
+func TestVoiceIndicator(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                        "0:00",
+		1400 * time.Millisecond:  "0:01",
+		59600 * time.Millisecond: "1:00",
+		125 * time.Second:        "2:05",
+	} {
+		if got := formatVoiceDuration(d); got != want {
+			t.Errorf("formatVoiceDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+	if got := renderWaveform([]uint8{0, 3, 7, 9}); got != "▁▄██" {
+		t.Errorf("renderWaveform = %q", got)
+	}
+}
+
+// testVoiceNote builds a one-second Ogg Opus stream: the two header pages
+// and one page of 50 audio packets
+func testVoiceNote() []byte {
+	page := func(granule uint64, packets ...[]byte) []byte {
+		header := make([]byte, 27, 27+len(packets))
+		copy(header, "OggS")
+		for i := 0; i < 8; i++ {
+			header[6+i] = byte(granule >> (8 * i))
+		}
+		header[26] = byte(len(packets))
+		var body []byte
+		for _, p := range packets {
+			header = append(header, byte(len(p)))
+			body = append(body, p...)
+		}
+		return append(header, body...)
+	}
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	note := append(page(0, head), page(0, []byte("OpusTags"))...)
+	var audio [][]byte
+	for i := 0; i < 50; i++ {
+		audio = append(audio, make([]byte, 20+i))
+	}
+	return append(note, page(48000, audio...)...)
+}
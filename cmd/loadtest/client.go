@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// options configure a load test run
+type options struct {
+	server        string
+	clients       int
+	duration      time.Duration
+	rate          float64       // messages per client per second
+	sizes         []int         // text message sizes in bytes, picked at random
+	fileEvery     int           // every nth message is a file (0 = none)
+	fileSize      int           // bytes per file
+	churn         time.Duration // average connection lifetime (0 = stay connected)
+	timeout       time.Duration // how long a message may go unanswered
+	prefix        string        // usernames are prefix0, prefix1, ...
+	passphrase    string
+	skipTLSVerify bool
+}
+
+// run connects opts.clients scripted clients, staggered over the first
+// second, until opts.duration passes or ctx ends, and reports how the
+// server kept up
+func run(ctx context.Context, opts options) report {
+	ctx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+	st := newStats()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range opts.clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-time.After(time.Duration(i) * time.Second / time.Duration(opts.clients)):
+			case <-ctx.Done():
+				return
+			}
+			c := &scriptedClient{
+				opts:     opts,
+				stats:    st,
+				username: fmt.Sprintf("%s%d", opts.prefix, i),
+				rng:      rand.New(rand.NewPCG(uint64(i), uint64(start.UnixNano()))),
+				pending:  make(map[string]sentMessage),
+			}
+			c.run(ctx)
+		}()
+	}
+	wg.Wait()
+	return st.report(opts.clients, time.Since(start))
+}
+
+// sentMessage is a message waiting to come back from the server
+type sentMessage struct {
+	kind string
+	at   time.Time
+}
+
+// scriptedClient sends messages at a steady rate and times how long each
+// takes to come back in the server's broadcast, reconnecting whenever its
+// connection ends
+type scriptedClient struct {
+	opts     options
+	stats    *stats
+	username string
+	rng      *rand.Rand
+	seq      int
+
+	mu      sync.Mutex
+	pending map[string]sentMessage // by text token or file name
+}
+
+func (c *scriptedClient) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		conn, err := c.connect(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.stats.fail("connect: " + errorCause(err))
+				sleep(ctx, time.Second)
+			}
+			continue
+		}
+		c.session(ctx, conn)
+	}
+}
+
+// connect dials the server and sends the handshake, answering a join
+// challenge if there is one. The connection counts as made once the
+// server sends its first frame, so a refusal is reported as one.
+func (c *scriptedClient) connect(ctx context.Context) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{
+		HandshakeTimeout: c.opts.timeout,
+		Subprotocols:     shared.Subprotocols,
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: c.opts.skipTLSVerify},
+	}
+	c.stats.attempt("connect")
+	start := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, c.opts.server, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := shared.ParseJoinChallenge(resp.Header)
+	handshake := shared.Handshake{Username: c.username, ClientVersion: shared.ClientVersion, UserListDeltas: true}
+	if challenge.Bits > 0 {
+		handshake.ChallengeSolution = shared.SolveProofOfWork(challenge.Nonce, challenge.Bits)
+	}
+	if challenge.Passphrase {
+		handshake.Passphrase = c.opts.passphrase
+	}
+	if err := c.write(conn, handshake); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(c.opts.timeout))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	c.stats.observe("connect", time.Since(start))
+	return conn, nil
+}
+
+// session sends messages on conn until ctx ends, the connection drops or,
+// with churn, its lifetime is up. It then waits for outstanding messages
+// and closes cleanly so the reconnect isn't refused as a duplicate.
+func (c *scriptedClient) session(ctx context.Context, conn *websocket.Conn) {
+	sessionCtx := ctx
+	if c.opts.churn > 0 {
+		var cancel context.CancelFunc
+		sessionCtx, cancel = context.WithTimeout(ctx, time.Duration(c.rng.ExpFloat64()*float64(c.opts.churn)))
+		defer cancel()
+	}
+
+	var readErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		readErr = c.read(conn)
+	}()
+
+	timer := time.NewTimer(c.nextSend())
+	defer timer.Stop()
+	dropped := false
+sending:
+	for {
+		select {
+		case <-sessionCtx.Done():
+			break sending
+		case <-done:
+			c.stats.fail("disconnected: " + errorCause(readErr))
+			dropped = true
+			break sending
+		case <-timer.C:
+			c.expire(c.opts.timeout)
+			if err := c.send(conn); err != nil {
+				c.stats.fail("send: " + errorCause(err))
+				break sending
+			}
+			timer.Reset(c.nextSend())
+		}
+	}
+
+	if !dropped {
+		deadline := time.Now().Add(c.opts.timeout)
+		for c.outstanding() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		select {
+		case <-done:
+		case <-time.After(c.opts.timeout):
+		}
+	}
+	conn.Close()
+	<-done
+	// Whatever is still outstanding was lost with the connection
+	c.expire(0)
+}
+
+// nextSend is the wait before the next message: the configured rate, with
+// jitter so clients don't send in lockstep
+func (c *scriptedClient) nextSend() time.Duration {
+	return time.Duration((0.5 + c.rng.Float64()) * float64(time.Second) / c.opts.rate)
+}
+
+// send writes the next scripted message: a file every opts.fileEvery
+// messages, otherwise text of one of opts.sizes
+func (c *scriptedClient) send(conn *websocket.Conn) error {
+	c.seq++
+	token := fmt.Sprintf("%s-%d", c.username, c.seq)
+	msg := shared.Message{Sender: c.username, CreatedAt: time.Now()}
+	kind, key := "text", token
+	if c.opts.fileEvery > 0 && c.seq%c.opts.fileEvery == 0 {
+		kind, key = "file", token+".bin"
+		msg.Type = shared.FileMessageType
+		msg.File = &shared.FileMeta{Filename: key, Size: int64(c.opts.fileSize), Data: c.filler(c.opts.fileSize)}
+	} else {
+		size := c.opts.sizes[c.rng.IntN(len(c.opts.sizes))]
+		msg.Type = shared.TextMessage
+		msg.Content = token + " " + string(c.filler(size-len(token)-1))
+	}
+
+	c.stats.attempt(kind)
+	c.mu.Lock()
+	c.pending[key] = sentMessage{kind: kind, at: time.Now()}
+	c.mu.Unlock()
+	return c.write(conn, msg)
+}
+
+// filler is n random letters, so compression doesn't flatter the numbers
+func (c *scriptedClient) filler(n int) []byte {
+	b := make([]byte, max(n, 0))
+	for i := range b {
+		b[i] = 'a' + byte(c.rng.IntN(26))
+	}
+	return b
+}
+
+// read matches this client's messages in the broadcast to the ones it is
+// waiting for, until the connection ends
+func (c *scriptedClient) read(conn *websocket.Conn) error {
+	codec := shared.CodecFor(conn.Subprotocol())
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var msg shared.Message
+		if codec.Unmarshal(raw, &msg) != nil || msg.Sender != c.username {
+			continue
+		}
+		key, _, _ := strings.Cut(msg.Content, " ")
+		if msg.File != nil {
+			key = msg.File.Filename
+		}
+		c.mu.Lock()
+		sent, ok := c.pending[key]
+		delete(c.pending, key)
+		c.mu.Unlock()
+		if ok {
+			c.stats.observe(sent.kind, time.Since(sent.at))
+		}
+	}
+}
+
+// expire gives up on messages unanswered for longer than after
+func (c *scriptedClient) expire(after time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, sent := range c.pending {
+		if time.Since(sent.at) >= after {
+			delete(c.pending, key)
+			c.stats.fail(sent.kind + ": no reply")
+		}
+	}
+}
+
+func (c *scriptedClient) outstanding() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// write sends v in the negotiated wire format
+func (c *scriptedClient) write(conn *websocket.Conn, v any) error {
+	codec := shared.CodecFor(conn.Subprotocol())
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	frameType := websocket.TextMessage
+	if codec.Binary() {
+		frameType = websocket.BinaryMessage
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(c.opts.timeout))
+	return conn.WriteMessage(frameType, data)
+}
+
+// errorCause condenses err into a short label to count errors by: the close
+// code for refusals and drops, the error text otherwise
+func errorCause(err error) string {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return fmt.Sprintf("closed %d", closeErr.Code)
+	}
+	var netErr interface{ Timeout() bool }
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		return "bad handshake"
+	}
+	return err.Error()
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+}
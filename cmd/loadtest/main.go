@@ -0,0 +1,138 @@
+// Command loadtest runs scripted WebSocket clients against a marchat server
+// and reports message latency percentiles and error rates, so performance
+// regressions show up before a release:
+//
+//	loadtest -server ws://localhost:8080/ws -clients 200 -duration 1m
+//
+// Each client sends text messages of mixed sizes, and optionally files, at
+// a steady rate and times how long each takes to come back in the
+// server's broadcast. With -churn, clients also disconnect and reconnect.
+// Use -max-p99 and -max-error-rate to make the run fail past a threshold.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	var (
+		serverURL     = flag.String("server", "ws://localhost:8080/ws", "Server WebSocket URL")
+		clients       = flag.Int("clients", 50, "Number of simulated clients")
+		duration      = flag.Duration("duration", 30*time.Second, "How long to run")
+		rate          = flag.Float64("rate", 1, "Messages per client per second")
+		sizes         = flag.String("sizes", "32,256,2048", "Comma-separated text message sizes in bytes, picked at random")
+		fileEvery     = flag.Int("file-every", 20, "Send a file every this many messages (0 = no files)")
+		fileSize      = flag.Int("file-size", 64*1024, "File size in bytes")
+		churn         = flag.Duration("churn", 0, "Average time before a client reconnects (0 = stay connected)")
+		timeout       = flag.Duration("timeout", 10*time.Second, "How long a message may go unanswered before it counts as an error")
+		prefix        = flag.String("prefix", "loadtest", "Username prefix")
+		passphrase    = flag.String("join-passphrase", "", "Join passphrase, for servers that require one")
+		skipTLSVerify = flag.Bool("skip-tls-verify", false, "Accept self-signed certificates")
+		jsonOut       = flag.Bool("json", false, "Print the report as JSON")
+		maxP99        = flag.Duration("max-p99", 0, "Fail if any message kind's p99 latency is above this (0 = no limit)")
+		maxErrorRate  = flag.Float64("max-error-rate", -1, "Fail if the error rate is above this fraction, e.g. 0.01 (negative = no limit)")
+	)
+	flag.Parse()
+
+	opts := options{
+		server:        *serverURL,
+		clients:       *clients,
+		duration:      *duration,
+		rate:          *rate,
+		fileEvery:     *fileEvery,
+		fileSize:      *fileSize,
+		churn:         *churn,
+		timeout:       *timeout,
+		prefix:        *prefix,
+		passphrase:    *passphrase,
+		skipTLSVerify: *skipTLSVerify,
+	}
+	var err error
+	if opts.sizes, err = parseSizes(*sizes); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.clients < 1 || opts.rate <= 0 || opts.duration <= 0 || opts.timeout <= 0 {
+		fmt.Println("Error: clients, rate, duration and timeout must be positive")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Ctrl+C ends the run early and still reports
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Running %d clients against %s for %s...\n", opts.clients, opts.server, opts.duration)
+	r := run(ctx, opts)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(r)
+	} else {
+		printReport(os.Stdout, r)
+	}
+	if failures := r.check(*maxP99, *maxErrorRate); len(failures) > 0 {
+		for _, f := range failures {
+			fmt.Fprintf(os.Stderr, "FAIL: %s\n", f)
+		}
+		os.Exit(1)
+	}
+}
+
+// parseSizes reads the -sizes list
+func parseSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid message size %q", field)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+// check lists the thresholds r exceeds
+func (r report) check(maxP99 time.Duration, maxErrorRate float64) []string {
+	var failures []string
+	for _, l := range r.Latency {
+		if maxP99 > 0 && l.Kind != "connect" && l.P99Ms > float64(maxP99.Microseconds())/1000 {
+			failures = append(failures, fmt.Sprintf("%s p99 latency %.1fms is above %s", l.Kind, l.P99Ms, maxP99))
+		}
+	}
+	if maxErrorRate >= 0 && r.ErrorRate > maxErrorRate {
+		failures = append(failures, fmt.Sprintf("error rate %.4f is above %.4f", r.ErrorRate, maxErrorRate))
+	}
+	return failures
+}
+
+func printReport(out io.Writer, r report) {
+	fmt.Fprintf(out, "%d clients for %.1fs, %.1f messages/s answered\n\n", r.Clients, r.Seconds, r.Messages)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "kind\tattempts\tanswered\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, l := range r.Latency {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t\n", l.Kind, l.Attempts, l.Answered, l.P50Ms, l.P90Ms, l.P99Ms, l.MaxMs)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(out, "\nerror rate: %.2f%%\n", r.ErrorRate*100)
+	causes := make([]string, 0, len(r.Errors))
+	for cause := range r.Errors {
+		causes = append(causes, cause)
+	}
+	sort.Strings(causes)
+	for _, cause := range causes {
+		fmt.Fprintf(out, "  %6d  %s\n", r.Errors[cause], cause)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/server"
+)
+
+func TestSummarize(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	s := summarize("text", latencies)
+	if s.Answered != 100 || s.P50Ms != 50 || s.P90Ms != 90 || s.P99Ms != 99 || s.MaxMs != 100 {
+		t.Errorf("Expected nearest-rank percentiles of 1..100ms, got %+v", s)
+	}
+	if latencies[0] != 100*time.Millisecond {
+		t.Error("Expected the recorded latencies left unsorted")
+	}
+	if s := summarize("file", nil); s.Answered != 0 || s.MaxMs != 0 {
+		t.Errorf("Expected an empty summary, got %+v", s)
+	}
+}
+
+func TestReportCheck(t *testing.T) {
+	st := newStats()
+	for range 4 {
+		st.attempt("text")
+	}
+	st.observe("text", 20*time.Millisecond)
+	st.fail("text: no reply")
+	r := st.report(1, time.Second)
+	if r.ErrorRate != 0.25 {
+		t.Errorf("Expected 1 error in 4 attempts, got %v", r.ErrorRate)
+	}
+	if failures := r.check(0, -1); len(failures) != 0 {
+		t.Errorf("Expected no thresholds, got %v", failures)
+	}
+	if failures := r.check(10*time.Millisecond, 0.1); len(failures) != 2 {
+		t.Errorf("Expected both thresholds exceeded, got %v", failures)
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := parseSizes("32, 256,2048")
+	if err != nil || len(sizes) != 3 || sizes[1] != 256 {
+		t.Errorf("Expected 3 sizes, got %v: %v", sizes, err)
+	}
+	for _, bad := range []string{"", "32,,64", "0", "big"} {
+		if _, err := parseSizes(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestRunAgainstServer(t *testing.T) {
+	hub, db := server.CreateTestHub(t)
+	defer db.Close()
+	go hub.Run()
+	ts := httptest.NewServer(server.ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+	defer ts.Close()
+
+	r := run(context.Background(), options{
+		server:    "ws" + strings.TrimPrefix(ts.URL, "http"),
+		clients:   4,
+		duration:  2 * time.Second,
+		rate:      10,
+		sizes:     []int{16, 512},
+		fileEvery: 5,
+		fileSize:  4096,
+		churn:     700 * time.Millisecond,
+		timeout:   2 * time.Second,
+		prefix:    "lt",
+	})
+
+	if len(r.Errors) != 0 {
+		t.Errorf("Expected no errors, got %v", r.Errors)
+	}
+	kinds := make(map[string]latencySummary)
+	for _, l := range r.Latency {
+		kinds[l.Kind] = l
+	}
+	for _, kind := range []string{"connect", "text", "file"} {
+		if kinds[kind].Answered == 0 {
+			t.Errorf("Expected %s latencies, got %+v", kind, r.Latency)
+		}
+	}
+	if kinds["connect"].Attempts <= 4 {
+		t.Errorf("Expected churn to reconnect clients, got %d connections", kinds["connect"].Attempts)
+	}
+}
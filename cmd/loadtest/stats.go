@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stats collects round-trip times, attempts and failures from every
+// scripted client
+type stats struct {
+	mu        sync.Mutex
+	attempts  map[string]int             // by kind: connect, text, file
+	latencies map[string][]time.Duration // by kind, answered attempts only
+	errors    map[string]int             // by cause
+}
+
+func newStats() *stats {
+	return &stats{
+		attempts:  make(map[string]int),
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+// attempt counts one connection or message sent
+func (s *stats) attempt(kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[kind]++
+}
+
+// observe records how long an attempt of kind took to be answered
+func (s *stats) observe(kind string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies[kind] = append(s.latencies[kind], d)
+}
+
+// fail counts one error
+func (s *stats) fail(cause string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[cause]++
+}
+
+// latencySummary is the distribution of one kind's round-trip times
+type latencySummary struct {
+	Kind     string  `json:"kind"`
+	Attempts int     `json:"attempts"`
+	Answered int     `json:"answered"`
+	P50Ms    float64 `json:"p50_ms"`
+	P90Ms    float64 `json:"p90_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+	MaxMs    float64 `json:"max_ms"`
+}
+
+// report is the outcome of a run, printed as a table or JSON
+type report struct {
+	Clients   int              `json:"clients"`
+	Seconds   float64          `json:"seconds"`
+	Latency   []latencySummary `json:"latency"`
+	Errors    map[string]int   `json:"errors"`
+	ErrorRate float64          `json:"error_rate"` // errors per attempt
+	Messages  float64          `json:"messages_per_second"`
+}
+
+func (s *stats) report(clients int, elapsed time.Duration) report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := report{Clients: clients, Seconds: elapsed.Seconds(), Errors: make(map[string]int)}
+	kinds := make([]string, 0, len(s.attempts))
+	attempts, answered := 0, 0
+	for kind, n := range s.attempts {
+		kinds = append(kinds, kind)
+		attempts += n
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		summary := summarize(kind, s.latencies[kind])
+		summary.Attempts = s.attempts[kind]
+		r.Latency = append(r.Latency, summary)
+		if kind != "connect" {
+			answered += summary.Answered
+		}
+	}
+	failures := 0
+	for cause, n := range s.errors {
+		r.Errors[cause] = n
+		failures += n
+	}
+	if attempts > 0 {
+		r.ErrorRate = float64(failures) / float64(attempts)
+	}
+	if elapsed > 0 {
+		r.Messages = float64(answered) / elapsed.Seconds()
+	}
+	return r
+}
+
+// summarize works out the percentiles of latencies by nearest rank
+func summarize(kind string, latencies []time.Duration) latencySummary {
+	summary := latencySummary{Kind: kind, Answered: len(latencies)}
+	if len(latencies) == 0 {
+		return summary
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	at := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return float64(sorted[max(i, 0)].Microseconds()) / 1000
+	}
+	summary.P50Ms, summary.P90Ms, summary.P99Ms, summary.MaxMs = at(0.50), at(0.90), at(0.99), at(1)
+	return summary
+}
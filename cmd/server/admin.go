@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Cod-e-Codes/marchat/config"
+	"github.com/Cod-e-Codes/marchat/server"
+)
+
+// Admin panel subcommand
+// Usage: marchat-server admin [--config-dir DIR] [--socket PATH]
+//
+// Attaches this terminal to the admin panel of a server started with
+// --admin-panel on the same host. Press q to detach; the server keeps running.
+
+// runAdminCommand attaches to the admin socket and returns the exit code
+func runAdminCommand(args []string) int {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	cfgDir := fs.String("config-dir", "", "Configuration directory of the running server")
+	socket := fs.String("socket", "", "Admin socket path (default: MARCHAT_ADMIN_SOCKET or CONFIG_DIR/admin.sock)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path := *socket
+	if path == "" {
+		cfg, err := config.LoadConfigWithoutValidation(resolveConfigDir(*cfgDir))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			return 1
+		}
+		path = cfg.AdminSocket
+	}
+
+	if err := server.AttachAdminSocket(path); err != nil {
+		fmt.Fprintf(os.Stderr, "admin: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/config"
+	"github.com/Cod-e-Codes/marchat/server"
+)
+
+// Archive subcommands
+// Usage: marchat-server export --format jsonl --since 2024-01-01 --output archive.jsonl
+//        marchat-server import --format jsonl --input archive.jsonl
+//
+// Both use the regular MARCHAT_DB_* configuration, so migrating between backends
+// is an export with one configuration followed by an import with another.
+// Archives carry messages along with settings, filter rules, custom emoji,
+// mention groups, cron jobs, reminders, scheduled messages, ban history,
+// email opt-ins and metrics history.
+
+// parseSince accepts a date (2006-01-02) or an RFC3339 timestamp; empty means all history
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since value %q (use YYYY-MM-DD or RFC3339)", value)
+	}
+	return t, nil
+}
+
+// runArchiveCommand executes the export or import subcommand and returns the exit code
+func runArchiveCommand(name string, args []string) int {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	format := fs.String("format", server.ArchiveFormatJSONL, "Archive format (jsonl)")
+	cfgDir := fs.String("config-dir", "", "Configuration directory")
+	since := fs.String("since", "", "Only export messages created on or after this date (YYYY-MM-DD or RFC3339)")
+	output := fs.String("output", "", "Write the archive to this file (default: stdout)")
+	input := fs.String("input", "", "Read the archive from this file (default: stdin)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *format != server.ArchiveFormatJSONL {
+		fmt.Fprintf(os.Stderr, "Unsupported archive format: %s (supported: %s)\n", *format, server.ArchiveFormatJSONL)
+		return 2
+	}
+
+	sinceTime, err := parseSince(*since)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cfg, err := config.LoadConfigWithoutValidation(resolveConfigDir(*cfgDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	database, err := server.NewDatabase(server.DatabaseConfig{
+		Type:          cfg.DBType,
+		Host:          cfg.DBHost,
+		Port:          cfg.DBPort,
+		Database:      cfg.DBName,
+		Username:      cfg.DBUser,
+		Password:      cfg.DBPassword,
+		SSLMode:       cfg.DBSSLMode,
+		FilePath:      cfg.DBPath,
+		EncryptionKey: cfg.DBEncryptionKey,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	switch name {
+	case "export":
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			// Archives hold email addresses, bans and settings: owner only
+			f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
+		counts, err := server.ExportArchive(database, w, sinceTime)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Export failed after %s: %v\n", counts, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Exported %s from %s database\n", counts, cfg.DBType)
+	case "import":
+		if *since != "" {
+			fmt.Fprintln(os.Stderr, "[WARNING] --since is ignored for import")
+		}
+		var r io.Reader = os.Stdin
+		if *input != "" {
+			f, err := os.Open(*input)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open input file: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+			r = f
+		}
+		counts, err := server.ImportArchive(database, r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Import failed after %s: %v\n", counts, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Imported %s into %s database\n", counts, cfg.DBType)
+	}
+	return 0
+}
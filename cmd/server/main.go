@@ -9,6 +9,8 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -17,8 +19,6 @@ import (
 	"github.com/Cod-e-Codes/marchat/config"
 	"github.com/Cod-e-Codes/marchat/server"
 	"github.com/Cod-e-Codes/marchat/shared"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/x/term"
 
 	// Database drivers
 	_ "github.com/go-sql-driver/mysql"
@@ -41,8 +41,9 @@ var adminKey = flag.String("admin-key", "", "Admin key for privileged commands (
 var port = flag.Int("port", 0, "Port to listen on (deprecated, use MARCHAT_PORT)")
 var configPath = flag.String("config", "", "Path to server config file (JSON, deprecated)")
 var configDir = flag.String("config-dir", "", "Configuration directory (default: ./config in dev, $XDG_CONFIG_HOME/marchat in prod)")
-var enableAdminPanel = flag.Bool("admin-panel", false, "Enable the built-in admin panel TUI")
+var enableAdminPanel = flag.Bool("admin-panel", false, "Serve the terminal admin panel on a local socket (attach with: marchat-server admin)")
 var enableWebPanel = flag.Bool("web-panel", false, "Enable the built-in web admin panel (served at /admin)")
+var advertise = flag.Bool("advertise", false, "Advertise this server on the local network (mDNS) for clients using --discover")
 var interactiveFlag = flag.Bool("interactive", false, "Enable interactive setup when required configuration is missing")
 
 func printBanner(addr string, admins []string, scheme string, tlsEnabled bool) {
@@ -85,29 +86,44 @@ func printBanner(addr string, admins []string, scheme string, tlsEnabled bool) {
 	fmt.Println("\U0001F4A1 Tip: Use --username <admin> --admin --admin-key <key> to connect as admin")
 }
 
+// resolveConfigDir determines the config directory using the same logic as the config package
+func resolveConfigDir(flagValue string) string {
+	if envConfigDir := os.Getenv("MARCHAT_CONFIG_DIR"); envConfigDir != "" {
+		return envConfigDir
+	}
+	if flagValue != "" {
+		return flagValue
+	}
+	if _, err := os.Stat("go.mod"); err == nil {
+		return "./config" // Development mode
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./config"
+	}
+	return filepath.Join(homeDir, ".config", "marchat")
+}
+
 func main() {
+	// Operator subcommands run instead of the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export", "import":
+			os.Exit(runArchiveCommand(os.Args[1], os.Args[2:]))
+		case "service":
+			os.Exit(runServiceCommand(os.Args[2:]))
+		case "healthcheck":
+			os.Exit(runHealthcheckCommand(os.Args[2:]))
+		case "admin":
+			os.Exit(runAdminCommand(os.Args[2:]))
+		}
+	}
+
 	flag.Var(&adminUsers, "admin", "[DEPRECATED] Admin username (use MARCHAT_USERS env var instead)")
 	flag.Parse()
 
 	// Determine config directory using same logic as config package
-	var actualConfigDir string
-	if envConfigDir := os.Getenv("MARCHAT_CONFIG_DIR"); envConfigDir != "" {
-		actualConfigDir = envConfigDir
-	} else if *configDir != "" {
-		actualConfigDir = *configDir
-	} else {
-		// Use same logic as config package for default directory
-		if _, err := os.Stat("go.mod"); err == nil {
-			actualConfigDir = "./config" // Development mode
-		} else {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				actualConfigDir = "./config"
-			} else {
-				actualConfigDir = filepath.Join(homeDir, ".config", "marchat")
-			}
-		}
-	}
+	actualConfigDir := resolveConfigDir(*configDir)
 
 	// Redirect runtime logs to debug file (but keep startup logs on stdout)
 	debugLogPath := filepath.Join(actualConfigDir, "marchat-debug.log")
@@ -124,14 +140,16 @@ func main() {
 		fmt.Fprintf(os.Stderr, "    MARCHAT_PORT=8080 (default: 8080)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_ADMIN_KEY=your-secret-key (required)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_USERS=user1,user2,user3 (comma-separated, required)\n")
-		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_PATH=/path/to/db (default: $CONFIG_DIR/marchat.db)\n")
-		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_TYPE=sqlite|postgres|mysql (default: sqlite)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_PATH=/path/to/db (default: $CONFIG_DIR/marchat.db, or $CONFIG_DIR/marchat-docstore for document)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_TYPE=sqlite|postgres|mysql|document|memory (default: sqlite)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_MEMORY_TTL=1h (optional, memory only: evict older messages)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_HOST=localhost (default: localhost)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_PORT=5432 (default: 5432 for postgres, 3306 for mysql)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_NAME=marchat (default: marchat)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_USER=username (required for postgres/mysql)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_PASSWORD=password (required for postgres/mysql)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_SSL_MODE=disable|require (default: disable)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_DB_ENCRYPTION_KEY=hex-or-base64 (optional, 32 bytes: encrypt messages at rest)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_LOG_LEVEL=info (default: info)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_JWT_SECRET=your-jwt-secret (default: auto-generated)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_TLS_CERT_FILE=/path/to/cert.pem (optional)\n")
@@ -140,6 +158,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "    MARCHAT_BAN_HISTORY_GAPS=true (optional, default: true)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_PLUGIN_REGISTRY_URL=url (optional, default: GitHub registry)\n")
 		fmt.Fprintf(os.Stderr, "    MARCHAT_GLOBAL_E2E_KEY=base64-key (optional, for global E2E encryption)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_ALLOW_MULTI_SESSION=true (optional, default: false)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_ALLOW_ASCII_ART=false (optional, default: true)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_DRAIN_TIMEOUT=10s (optional, default: 10s; 0 exits without draining clients)\n")
+		fmt.Fprintf(os.Stderr, "    MARCHAT_ADMIN_SOCKET=/path/admin.sock (optional, default: CONFIG_DIR/admin.sock; used with --admin-panel)\n")
 		fmt.Fprintf(os.Stderr, "  .env file: Create %s/.env with the above variables\n", actualConfigDir)
 		fmt.Fprintf(os.Stderr, "  Config directory: Use --config-dir or MARCHAT_CONFIG_DIR to specify custom location\n")
 		fmt.Fprintf(os.Stderr, "  Interactive setup: Use --interactive flag for guided configuration\n")
@@ -245,6 +267,11 @@ func main() {
 		log.Fatal("Port must be between 1 and 65535.")
 	}
 
+	// Mask the configured secrets wherever they would appear in the logs
+	for _, secret := range []string{cfg.AdminKey, cfg.JWTSecret, cfg.DBPassword, cfg.GlobalE2EKey, cfg.JoinPassphrase, cfg.TorControlPassword} {
+		shared.RegisterSecret(secret)
+	}
+
 	// Normalize admin usernames to lowercase and check for duplicates
 	adminSet := make(map[string]struct{})
 	for _, u := range admins {
@@ -261,14 +288,20 @@ func main() {
 
 	// Create database configuration
 	dbConfig := server.DatabaseConfig{
-		Type:     cfg.DBType,
-		Host:     cfg.DBHost,
-		Port:     cfg.DBPort,
-		Database: cfg.DBName,
-		Username: cfg.DBUser,
-		Password: cfg.DBPassword,
-		SSLMode:  cfg.DBSSLMode,
-		FilePath: cfg.DBPath, // For SQLite
+		Type:          cfg.DBType,
+		Host:          cfg.DBHost,
+		Port:          cfg.DBPort,
+		Database:      cfg.DBName,
+		Username:      cfg.DBUser,
+		Password:      cfg.DBPassword,
+		SSLMode:       cfg.DBSSLMode,
+		FilePath:      cfg.DBPath,    // For SQLite
+		MessageTTL:    cfg.MemoryTTL, // For the in-memory database
+		EncryptionKey: cfg.DBEncryptionKey,
+
+		WriteBatchSize:     cfg.DBWriteBatch,
+		WriteBatchInterval: cfg.DBWriteInterval,
+		WriteJournal:       filepath.Join(cfg.ConfigDir, "message-journal.jsonl"),
 	}
 
 	// Initialize database using factory
@@ -303,6 +336,37 @@ func main() {
 	}
 
 	hub := server.NewHub(pluginDir, dataDir, registryURL, database)
+	hub.SetAllowMultiSession(cfg.AllowMultiSession)
+	hub.SetAllowSpectators(cfg.AllowSpectators)
+	hub.SetArtEnabled(cfg.AllowASCIIArt)
+	hub.SetJoinChallenge(cfg.JoinPoWBits, cfg.JoinPassphrase)
+	hub.SetCompression(cfg.CompressionLevel, cfg.CompressionThreshold)
+	hub.SetMinClientVersion(cfg.MinClientVersion)
+	hub.RequireMinClientVersion(cfg.RequireMinClientVersion)
+	hub.SetMaxConnections(cfg.MaxConnections)
+	hub.SetIdlePolicy(cfg.AwayAfter, cfg.IdleTimeout)
+	hub.SetMentionLimit(cfg.MentionLimit, cfg.MentionWindow)
+	hub.SetLinkPreviewDomains(cfg.LinkPreviewDomains)
+	hub.SetFilePolicy(cfg.AllowedFileTypes, cfg.ClamdAddress)
+	hub.SetMaxMessageBytes(cfg.MaxMessageBytes)
+	hub.SetEmailNotifications(server.EmailSettings{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+		Debounce: cfg.EmailDebounce,
+	})
+	usernamePolicy := server.DefaultUsernamePolicy()
+	usernamePolicy.MinLength = cfg.UsernameMinLength
+	usernamePolicy.MaxLength = cfg.UsernameMaxLength
+	if cfg.UsernamePattern != "" {
+		// Already checked when the config was loaded
+		usernamePolicy.Pattern = regexp.MustCompile(cfg.UsernamePattern)
+	}
+	usernamePolicy.Reserved = slices.Concat(usernamePolicy.Reserved, cfg.ReservedUsernames)
+	usernamePolicy.BlockedWords = cfg.BlockedUsernameWords
+	hub.SetUsernamePolicy(usernamePolicy)
 	go hub.Run()
 
 	// Log server startup
@@ -313,13 +377,8 @@ func main() {
 		"db_path":     cfg.DBPath,
 	})
 
-	// Initialize admin panel if enabled (but don't start it yet)
-	var adminPanelReady bool
-	if *enableAdminPanel {
-		adminPanelReady = true
-	}
-
 	http.HandleFunc("/ws", server.ServeWs(hub, database, admins, key, cfg.BanGapsHistory, cfg.MaxFileBytes, cfg.DBPath))
+	http.HandleFunc("/snippets/", server.SnippetHandler(database))
 
 	// Web admin panel routes (optional)
 	if *enableWebPanel {
@@ -335,6 +394,8 @@ func main() {
 	healthChecker := server.NewHealthChecker(hub, database, shared.GetServerVersionInfo())
 	http.HandleFunc("/health", healthChecker.HealthCheckHandler)
 	http.HandleFunc("/health/simple", healthChecker.SimpleHealthHandler)
+	http.HandleFunc("/healthz", healthChecker.LivenessHandler)
+	http.HandleFunc("/readyz", healthChecker.ReadinessHandler)
 
 	addr := fmt.Sprintf(":%d", listenPort)
 	serverAddr := fmt.Sprintf("localhost:%d", listenPort)
@@ -348,17 +409,47 @@ func main() {
 
 	// Print banner
 	printBanner(serverAddr, admins, scheme, cfg.IsTLSEnabled())
-	if adminPanelReady {
-		fmt.Println("\U0001F4BB Admin Panel: Press Ctrl+A to open admin panel, Ctrl+C to shutdown")
+	if cfg.IsEphemeralDB() {
+		fmt.Println("\u26A0\uFE0F  Database: IN-MEMORY - all messages are lost when the server stops")
+		if cfg.MemoryTTL > 0 {
+			fmt.Printf("\u23F3 Message TTL: %s\n", cfg.MemoryTTL)
+		}
+		server.ServerLogger.Warn("Running with ephemeral in-memory database", map[string]interface{}{
+			"message_ttl": cfg.MemoryTTL.String(),
+		})
+	}
+	if len(cfg.DBEncryptionKey) > 0 {
+		fmt.Println("\U0001F512 Database: message content encrypted at rest")
+	}
+	// Terminal admin panel, attached over a local socket
+	if *enableAdminPanel {
+		adminSocket, err := server.ListenAdminSocket(cfg.AdminSocket, func() *server.AdminPanel {
+			return server.NewAdminPanel(hub, dbWrapper, hub.GetPluginManager(), cfg)
+		})
+		if err != nil {
+			log.Fatalf("Admin panel socket: %v", err)
+		}
+		defer adminSocket.Close()
+		go adminSocket.Serve()
+		fmt.Printf("\U0001F4BB Admin Panel: run 'marchat-server admin' to attach (%s)\n", adminSocket.Path())
 	}
 
 	// Create a custom server instance
 	srv := &http.Server{Addr: addr}
+	clientAuth, err := cfg.ClientAuthTLSConfig()
+	if err != nil {
+		log.Fatalf("Mutual TLS: %v", err)
+	}
+	if clientAuth != nil {
+		srv.TLSConfig = clientAuth
+		fmt.Println("\U0001F510 Mutual TLS: clients must present a certificate signed by", cfg.TLSClientCAFile)
+	}
 
 	// Channel to listen for OS signals (Ctrl+C, etc.)
 	stop := make(chan os.Signal, 1)
-	adminShutdown := make(chan bool, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	serviceDone := runUnderServiceManager(stop)
+	defer serviceDone()
 
 	// Run server in a goroutine
 	go func() {
@@ -373,98 +464,58 @@ func main() {
 		}
 	}()
 
-	// Start admin panel hotkey listener
-	if adminPanelReady {
-		go func() {
-			// Check if stdin is a terminal
-			fd := os.Stdin.Fd()
-			if !term.IsTerminal(fd) {
-				server.ServerLogger.Warn("stdin is not a terminal, admin panel hotkeys disabled", nil)
-				return
-			}
-
-			// Set terminal to raw mode for character-by-character input
-			oldState, err := term.MakeRaw(fd)
-			if err != nil {
-				server.ServerLogger.Warn("Could not set terminal to raw mode", map[string]interface{}{
-					"error": err.Error(),
-				})
-				return
-			}
-
-			// Ensure we restore terminal state when the goroutine exits
-			defer func() {
-				if err := term.Restore(fd, oldState); err != nil {
-					server.ServerLogger.Warn("Could not restore terminal state", map[string]interface{}{
-						"error": err.Error(),
-					})
-				}
-			}()
-
-			server.ServerLogger.Info("Admin panel ready", map[string]interface{}{
-				"hotkey": "Ctrl+A",
+	// Publish as an onion service through a local tor
+	if cfg.TorControl != "" {
+		virtPort, onionScheme := 80, "ws"
+		if cfg.IsTLSEnabled() {
+			virtPort, onionScheme = 443, "wss"
+		}
+		onion, err := server.PublishOnion(cfg.TorControl, cfg.TorControlPassword,
+			filepath.Join(cfg.ConfigDir, "onion_service.key"), virtPort, fmt.Sprintf("127.0.0.1:%d", listenPort))
+		if err != nil {
+			server.ServerLogger.Warn("Could not publish onion service", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			defer onion.Close()
+			fmt.Printf("\U0001F9C5 Onion service: %s://%s/ws\n", onionScheme, onion.Address)
+			server.ServerLogger.Info("Onion service published", map[string]interface{}{
+				"address": onion.Address,
+			})
+		}
+	}
+
+	// Answer LAN discovery queries
+	if *advertise {
+		adv, err := shared.NewAdvertiser("", listenPort, cfg.IsTLSEnabled())
+		if err != nil {
+			server.ServerLogger.Warn("Could not advertise on the local network", map[string]interface{}{
+				"error": err.Error(),
 			})
+		} else {
+			defer adv.Close()
+			go adv.Serve()
+			fmt.Println("\U0001F4E1 Advertising on the local network (mDNS)")
+		}
+	}
 
-			// Read input character by character
-			buf := make([]byte, 1)
-			for {
-				n, err := os.Stdin.Read(buf)
-				if err != nil {
-					server.ServerLogger.Error("Error reading from stdin", err)
-					break
-				}
-				if n == 0 {
-					continue
-				}
-
-				// Check for Ctrl+A (ASCII 1) or Ctrl+C (ASCII 3)
-				if buf[0] == 1 {
-					// Temporarily restore terminal state
-					if err := term.Restore(fd, oldState); err != nil {
-						server.ServerLogger.Warn("Could not restore terminal state", map[string]interface{}{
-							"error": err.Error(),
-						})
-					}
-
-					// Launch admin panel
-					pluginManager := hub.GetPluginManager()
-					panel := server.NewAdminPanel(hub, dbWrapper, pluginManager, cfg)
-					p := tea.NewProgram(panel, tea.WithAltScreen())
-					if _, err := p.Run(); err != nil {
-						server.ServerLogger.Error("Admin panel error", err)
-					}
-
-					// Set terminal back to raw mode
-					oldState, err = term.MakeRaw(fd)
-					if err != nil {
-						server.ServerLogger.Warn("Could not reset terminal to raw mode", map[string]interface{}{
-							"error": err.Error(),
-						})
-						break
-					}
-					server.ServerLogger.Info("Admin panel closed", nil)
-				} else if buf[0] == 3 { // Ctrl+C (ASCII 3)
-					// Restore terminal state before shutdown
-					if err := term.Restore(fd, oldState); err != nil {
-						server.ServerLogger.Warn("Could not restore terminal state", map[string]interface{}{
-							"error": err.Error(),
-						})
-					}
-
-					// Signal shutdown via our channel
-					adminShutdown <- true
-					return
-				}
-			}
-		}()
-	}
-
-	// Block until we receive SIGINT (Ctrl+C) or admin shutdown
-	select {
-	case <-stop:
-		server.ServerLogger.Info("Shutdown signal received", nil)
-	case <-adminShutdown:
-		server.ServerLogger.Info("Shutdown initiated from admin panel", nil)
+	// Block until we receive SIGINT (Ctrl+C) or SIGTERM
+	<-stop
+	server.ServerLogger.Info("Shutdown signal received", nil)
+
+	// A second Ctrl+C or SIGTERM skips the drain
+	go func() {
+		<-stop
+		server.ServerLogger.Warn("Second shutdown signal, exiting immediately", nil)
+		os.Exit(1)
+	}()
+
+	// Tell clients to reconnect and let their message writes finish before
+	// the listener closes, so rolling deploys lose nothing
+	if cfg.DrainTimeout > 0 {
+		if !hub.Drain(cfg.DrainTimeout) {
+			server.ServerLogger.Warn("Some clients were still connected when the drain timeout ran out", nil)
+		}
 	}
 
 	// Create a context with timeout for graceful shutdown
@@ -2,9 +2,14 @@ package main
 
 import (
 	"flag"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Cod-e-Codes/marchat/config"
 	"github.com/Cod-e-Codes/marchat/shared"
@@ -688,3 +693,185 @@ type ConfigError struct {
 func (e *ConfigError) Error() string {
 	return e.Message
 }
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "2024-01-01", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-01-01T10:30:00Z", want: time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveCommandRoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	archive := filepath.Join(t.TempDir(), "archive.jsonl")
+
+	if err := os.WriteFile(archive, []byte(`{"sender":"alice","content":"hello","created_at":"2024-01-01T00:00:00Z"}`+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	t.Setenv("MARCHAT_DB_TYPE", "sqlite")
+	if code := runArchiveCommand("import", []string{"--config-dir", srcDir, "--input", archive}); code != 0 {
+		t.Fatalf("import exited with %d", code)
+	}
+
+	exported := filepath.Join(dstDir, "out.jsonl")
+	if code := runArchiveCommand("export", []string{"--config-dir", srcDir, "--since", "2023-12-31", "--output", exported}); code != 0 {
+		t.Fatalf("export exited with %d", code)
+	}
+	data, err := os.ReadFile(exported)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	if !strings.Contains(string(data), `"content":"hello"`) {
+		t.Errorf("Expected exported archive to contain imported message, got %s", data)
+	}
+	if info, err := os.Stat(exported); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0600) {
+		t.Errorf("Expected the export readable by its owner only, got %v (%v)", info.Mode(), err)
+	}
+
+	if code := runArchiveCommand("export", []string{"--config-dir", srcDir, "--format", "csv"}); code != 2 {
+		t.Errorf("Expected exit code 2 for unsupported format, got %d", code)
+	}
+}
+
+func TestServiceEnv(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("MARCHAT_PORT=9000\nMARCHAT_USERS=alice\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env, err := serviceEnv(envFile, []string{
+		"MARCHAT_PORT=8080",
+		"MARCHAT_ADMIN_KEY=with spaces=and equals",
+		"MARCHAT_CONFIG_DIR=/elsewhere",
+		"HOME=/root",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"MARCHAT_PORT":      "8080",
+		"MARCHAT_USERS":     "alice",
+		"MARCHAT_ADMIN_KEY": "with spaces=and equals",
+	}
+	if len(env) != len(want) {
+		t.Fatalf("env = %v, want %v", env, want)
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+
+	if err := writeEnvFile(envFile, env); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("env file mode = %v, want 0600", info.Mode().Perm())
+	}
+	reread, err := serviceEnv(envFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reread["MARCHAT_ADMIN_KEY"] != want["MARCHAT_ADMIN_KEY"] {
+		t.Errorf("admin key did not round-trip: %q", reread["MARCHAT_ADMIN_KEY"])
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	opts := serviceOptions{
+		Name:      "marchat",
+		Exe:       "/opt/mar chat/marchat-server",
+		ConfigDir: "/etc/marchat",
+		EnvFile:   "/etc/marchat/.env",
+		RunAs:     "marchat",
+		System:    true,
+	}
+	unit := systemdUnit(opts)
+	for _, line := range []string{
+		`ExecStart="/opt/mar chat/marchat-server" --config-dir /etc/marchat`,
+		"EnvironmentFile=-/etc/marchat/.env",
+		"User=marchat",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, line+"\n") {
+			t.Errorf("unit missing %q:\n%s", line, unit)
+		}
+	}
+
+	opts.System = false
+	unit = systemdUnit(opts)
+	if strings.Contains(unit, "User=") || !strings.Contains(unit, "WantedBy=default.target\n") {
+		t.Errorf("user unit should not set User= and should target default.target:\n%s", unit)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	opts := serviceOptions{Name: "marchat", Exe: "/usr/local/bin/marchat-server", ConfigDir: "/Users/a/.config/marchat"}
+	plist := launchdPlist(opts, map[string]string{"MARCHAT_ADMIN_KEY": "a<b&c"})
+	for _, want := range []string{
+		"<string>com.marchat.marchat</string>",
+		"<string>/usr/local/bin/marchat-server</string>",
+		"<key>MARCHAT_ADMIN_KEY</key>",
+		"<string>a&lt;b&amp;c</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestHealthcheckURL(t *testing.T) {
+	cfg := &config.Config{Port: 8080}
+	if got := healthcheckURL(cfg, 0, false); got != "http://127.0.0.1:8080/readyz" {
+		t.Errorf("healthcheckURL = %q", got)
+	}
+	cfg.TLSCertFile, cfg.TLSKeyFile = "cert.pem", "key.pem"
+	if got := healthcheckURL(cfg, 9443, true); got != "https://127.0.0.1:9443/healthz" {
+		t.Errorf("healthcheckURL with TLS = %q", got)
+	}
+}
+
+func TestHealthcheckCommand(t *testing.T) {
+	ready := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	if code := runHealthcheckCommand([]string{"--url", srv.URL + "/readyz"}); code != 0 {
+		t.Errorf("exit code = %d for a ready server, want 0", code)
+	}
+	ready = false
+	if code := runHealthcheckCommand([]string{"--url", srv.URL + "/readyz"}); code != 1 {
+		t.Errorf("exit code = %d for an unready server, want 1", code)
+	}
+	srv.Close()
+	if code := runHealthcheckCommand([]string{"--url", srv.URL + "/readyz", "--timeout", "1s"}); code != 1 {
+		t.Errorf("exit code = %d for a stopped server, want 1", code)
+	}
+}
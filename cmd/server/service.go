@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Service subcommands
+// Usage: marchat-server service install [--name marchat] [--config-dir DIR] [--env-file FILE] [--run-as USER] [--dry-run]
+//        marchat-server service start|stop|status|uninstall [--name marchat]
+//
+// install registers the server with the platform's service manager (a
+// systemd unit on Linux, a launchd plist on macOS, a Windows service) and
+// writes the MARCHAT_* variables from the current environment into the env
+// file the service reads, so the same exports used to try the server by hand
+// carry over to the service.
+
+// serviceOptions describes the service to install or manage
+type serviceOptions struct {
+	Name      string
+	Exe       string // absolute path of this binary
+	ConfigDir string
+	EnvFile   string
+	RunAs     string // account for system services (systemd User=), empty = root
+	System    bool   // system-wide service rather than one for the current user
+	DryRun    bool
+}
+
+// runCommand runs a service manager command with its output passed through;
+// tests replace it
+var runCommand = func(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runServiceCommand executes the service subcommand and returns the exit code
+func runServiceCommand(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, "Usage: marchat-server service install|start|stop|status|uninstall [flags]")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("service "+action, flag.ContinueOnError)
+	name := fs.String("name", "marchat", "Service name")
+	cfgDir := fs.String("config-dir", "", "Configuration directory the service runs with")
+	envFile := fs.String("env-file", "", "Env file with the MARCHAT_* variables (default: CONFIG_DIR/.env)")
+	runAs := fs.String("run-as", "", "User account for a system service (Linux)")
+	dryRun := fs.Bool("dry-run", false, "Print what install would write without changing anything")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	opts := serviceOptions{
+		Name:   *name,
+		RunAs:  *runAs,
+		System: os.Geteuid() == 0, // always -1, so false, on Windows where services are system-wide anyway
+		DryRun: *dryRun,
+	}
+
+	var err error
+	switch action {
+	case "install":
+		err = prepareServiceInstall(&opts, *cfgDir, *envFile)
+		if err == nil {
+			err = installService(opts)
+		}
+	case "start":
+		err = startService(opts)
+	case "stop":
+		err = stopService(opts)
+	case "status":
+		err = serviceStatus(opts)
+	case "uninstall":
+		err = uninstallService(opts)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown service command: %s (use install, start, stop, status or uninstall)\n", action)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "service %s: %v\n", action, err)
+		return 1
+	}
+	return 0
+}
+
+// prepareServiceInstall resolves the paths the service will use and writes
+// the env file
+func prepareServiceInstall(opts *serviceOptions, cfgDir, envFile string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the server binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	opts.Exe = exe
+
+	// The service starts elsewhere, so the dev-mode ./config fallback must
+	// become an absolute path now
+	if opts.ConfigDir, err = filepath.Abs(resolveConfigDir(cfgDir)); err != nil {
+		return err
+	}
+	if envFile == "" {
+		envFile = filepath.Join(opts.ConfigDir, ".env")
+	}
+	if opts.EnvFile, err = filepath.Abs(envFile); err != nil {
+		return err
+	}
+
+	env, err := serviceEnv(opts.EnvFile, os.Environ())
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		fmt.Printf("Would write %d MARCHAT_* variables to %s\n", len(env), opts.EnvFile)
+		return nil
+	}
+	if err := os.MkdirAll(opts.ConfigDir, 0755); err != nil {
+		return err
+	}
+	if err := writeEnvFile(opts.EnvFile, env); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d MARCHAT_* variables to %s\n", len(env), opts.EnvFile)
+	return nil
+}
+
+// serviceEnv merges the MARCHAT_* variables in environ over those already in
+// the env file, so reinstalling keeps settings that are not exported now.
+// MARCHAT_CONFIG_DIR is left out: the service passes --config-dir instead.
+func serviceEnv(envFile string, environ []string) (map[string]string, error) {
+	env := make(map[string]string)
+	if _, err := os.Stat(envFile); err == nil {
+		existing, err := godotenv.Read(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", envFile, err)
+		}
+		env = existing
+	}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if ok && strings.HasPrefix(key, "MARCHAT_") && key != "MARCHAT_CONFIG_DIR" {
+			env[key] = value
+		}
+	}
+	return env, nil
+}
+
+// writeEnvFile saves env readable only by its owner: it holds the admin key
+func writeEnvFile(path string, env map[string]string) error {
+	content, err := godotenv.Marshal(env)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600) // WriteFile keeps the mode of an existing file
+}
+
+// systemdUnit renders the unit file for opts
+func systemdUnit(opts serviceOptions) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=marchat chat server\n")
+	b.WriteString("Documentation=https://github.com/Cod-e-Codes/marchat\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s --config-dir %s\n", systemdQuote(opts.Exe), systemdQuote(opts.ConfigDir))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(opts.ConfigDir))
+	fmt.Fprintf(&b, "EnvironmentFile=-%s\n", opts.EnvFile)
+	if opts.System && opts.RunAs != "" {
+		fmt.Fprintf(&b, "User=%s\n", opts.RunAs)
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n")
+	b.WriteString("NoNewPrivileges=true\n\n")
+	b.WriteString("[Install]\n")
+	if opts.System {
+		b.WriteString("WantedBy=multi-user.target\n")
+	} else {
+		b.WriteString("WantedBy=default.target\n")
+	}
+	return b.String()
+}
+
+// systemdQuote quotes a path for ExecStart when it has spaces
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// launchdLabel is the launchd job label for a service name
+func launchdLabel(name string) string {
+	return "com.marchat." + name
+}
+
+// launchdPlist renders the launchd job for opts. launchd cannot read env
+// files, so the variables are copied into the plist.
+func launchdPlist(opts serviceOptions, env map[string]string) string {
+	esc := func(s string) string {
+		var b strings.Builder
+		_ = xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", esc(launchdLabel(opts.Name)))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range []string{opts.Exe, "--config-dir", opts.ConfigDir} {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", esc(arg))
+	}
+	b.WriteString("\t</array>\n")
+	fmt.Fprintf(&b, "\t<key>WorkingDirectory</key>\n\t<string>%s</string>\n", esc(opts.ConfigDir))
+	if len(env) > 0 {
+		keys := make([]string, 0, len(env))
+		for k := range env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", esc(k), esc(env[k]))
+		}
+		b.WriteString("\t</dict>\n")
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	logPath := filepath.Join(opts.ConfigDir, "marchat-service.log")
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", esc(logPath))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", esc(logPath))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
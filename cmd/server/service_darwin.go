@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// launchd: daemons when run as root, agents for the current user otherwise
+
+func launchdPlistPath(opts serviceOptions) (string, error) {
+	file := launchdLabel(opts.Name) + ".plist"
+	if opts.System {
+		return filepath.Join("/Library/LaunchDaemons", file), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", file), nil
+}
+
+func installService(opts serviceOptions) error {
+	path, err := launchdPlistPath(opts)
+	if err != nil {
+		return err
+	}
+	var env map[string]string
+	if opts.DryRun {
+		env, err = serviceEnv(opts.EnvFile, os.Environ())
+	} else {
+		env, err = serviceEnv(opts.EnvFile, nil)
+	}
+	if err != nil {
+		return err
+	}
+	plist := launchdPlist(opts, env)
+	if opts.DryRun {
+		fmt.Printf("Would write %s:\n\n%s", path, plist)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// The plist carries the env file's secrets, so keep it private too
+	if err := os.WriteFile(path, []byte(plist), 0600); err != nil {
+		return err
+	}
+	fmt.Println("Wrote", path)
+	fmt.Printf("Installed. Start it with: marchat-server service start --name %s\n", opts.Name)
+	fmt.Println("Rerun install after editing", opts.EnvFile, "- launchd reads the variables from the plist")
+	return nil
+}
+
+func startService(opts serviceOptions) error {
+	path, err := launchdPlistPath(opts)
+	if err != nil {
+		return err
+	}
+	return runCommand("launchctl", "load", "-w", path)
+}
+
+func stopService(opts serviceOptions) error {
+	path, err := launchdPlistPath(opts)
+	if err != nil {
+		return err
+	}
+	return runCommand("launchctl", "unload", path)
+}
+
+func serviceStatus(opts serviceOptions) error {
+	return runCommand("launchctl", "list", launchdLabel(opts.Name))
+}
+
+func uninstallService(opts serviceOptions) error {
+	path, err := launchdPlistPath(opts)
+	if err != nil {
+		return err
+	}
+	_ = runCommand("launchctl", "unload", "-w", path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Println("Removed", path)
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// runUnderServiceManager is a no-op outside Windows: systemd and launchd stop
+// the server with SIGTERM, which main already handles
+func runUnderServiceManager(stop chan<- os.Signal) (done func()) {
+	return func() {}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// systemd: system units when run as root, user units otherwise
+
+func systemdUnitPath(opts serviceOptions) (string, error) {
+	if opts.System {
+		return filepath.Join("/etc/systemd/system", opts.Name+".service"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "systemd", "user", opts.Name+".service"), nil
+}
+
+func systemctl(opts serviceOptions, args ...string) error {
+	if !opts.System {
+		args = append([]string{"--user"}, args...)
+	}
+	return runCommand("systemctl", args...)
+}
+
+func installService(opts serviceOptions) error {
+	path, err := systemdUnitPath(opts)
+	if err != nil {
+		return err
+	}
+	unit := systemdUnit(opts)
+	if opts.DryRun {
+		fmt.Printf("Would write %s:\n\n%s", path, unit)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return err
+	}
+	fmt.Println("Wrote", path)
+	if err := systemctl(opts, "daemon-reload"); err != nil {
+		return err
+	}
+	if err := systemctl(opts, "enable", opts.Name+".service"); err != nil {
+		return err
+	}
+	fmt.Printf("Installed. Start it with: marchat-server service start --name %s\n", opts.Name)
+	if !opts.System {
+		fmt.Println("User services stop when you log out unless lingering is enabled: loginctl enable-linger")
+	}
+	return nil
+}
+
+func startService(opts serviceOptions) error {
+	return systemctl(opts, "start", opts.Name+".service")
+}
+
+func stopService(opts serviceOptions) error {
+	return systemctl(opts, "stop", opts.Name+".service")
+}
+
+func serviceStatus(opts serviceOptions) error {
+	return systemctl(opts, "status", "--no-pager", opts.Name+".service")
+}
+
+func uninstallService(opts serviceOptions) error {
+	path, err := systemdUnitPath(opts)
+	if err != nil {
+		return err
+	}
+	// Disabling a unit that is not running or enabled is not an error worth stopping for
+	_ = systemctl(opts, "disable", "--now", opts.Name+".service")
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Println("Removed", path)
+	return systemctl(opts, "daemon-reload")
+}
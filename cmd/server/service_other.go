@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func errServiceUnsupported() error {
+	return fmt.Errorf("service management is not supported on %s; run marchat-server under your init system directly", runtime.GOOS)
+}
+
+func installService(serviceOptions) error   { return errServiceUnsupported() }
+func startService(serviceOptions) error     { return errServiceUnsupported() }
+func stopService(serviceOptions) error      { return errServiceUnsupported() }
+func serviceStatus(serviceOptions) error    { return errServiceUnsupported() }
+func uninstallService(serviceOptions) error { return errServiceUnsupported() }
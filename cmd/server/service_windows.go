@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Windows services are registered with the service control manager. The
+// MARCHAT_* variables go in the service's Environment registry value, which
+// the SCM applies when it starts the process.
+
+func installService(opts serviceOptions) error {
+	env, err := serviceEnv(opts.EnvFile, nil)
+	if opts.DryRun {
+		env, err = serviceEnv(opts.EnvFile, os.Environ())
+	}
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		fmt.Printf("Would create service %q running: %s --config-dir %s\n", opts.Name, opts.Exe, opts.ConfigDir)
+		fmt.Printf("with %d MARCHAT_* variables in its environment\n", len(env))
+		return nil
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the service manager (run as Administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.CreateService(opts.Name, opts.Exe, mgr.Config{
+		DisplayName: "marchat chat server",
+		Description: "marchat chat server (" + opts.ConfigDir + ")",
+		StartType:   mgr.StartAutomatic,
+	}, "--config-dir", opts.ConfigDir)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+	}, 0); err != nil {
+		return err
+	}
+	if err := setServiceEnvironment(opts.Name, env); err != nil {
+		return err
+	}
+	fmt.Printf("Installed. Start it with: marchat-server service start --name %s\n", opts.Name)
+	return nil
+}
+
+// setServiceEnvironment writes env as the service's Environment value
+func setServiceEnvironment(name string, env map[string]string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+name, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	if len(env) == 0 {
+		return nil
+	}
+	vars := make([]string, 0, len(env))
+	for key, value := range env {
+		vars = append(vars, key+"="+value)
+	}
+	sort.Strings(vars)
+	return k.SetStringsValue("Environment", vars)
+}
+
+// openService connects to the SCM and opens the named service
+func openService(name string) (*mgr.Mgr, *mgr.Service, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to the service manager: %w", err)
+	}
+	s, err := m.OpenService(name)
+	if err != nil {
+		m.Disconnect()
+		return nil, nil, fmt.Errorf("service %s is not installed: %w", name, err)
+	}
+	return m, s, nil
+}
+
+func startService(opts serviceOptions) error {
+	m, s, err := openService(opts.Name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	return s.Start()
+}
+
+func stopService(opts serviceOptions) error {
+	m, s, err := openService(opts.Name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	_, err = s.Control(svc.Stop)
+	return err
+}
+
+var serviceStateNames = map[svc.State]string{
+	svc.Stopped:         "stopped",
+	svc.StartPending:    "starting",
+	svc.StopPending:     "stopping",
+	svc.Running:         "running",
+	svc.ContinuePending: "resuming",
+	svc.PausePending:    "pausing",
+	svc.Paused:          "paused",
+}
+
+func serviceStatus(opts serviceOptions) error {
+	m, s, err := openService(opts.Name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	status, err := s.Query()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s (pid %d)\n", opts.Name, serviceStateNames[status.State], status.ProcessId)
+	return nil
+}
+
+func uninstallService(opts serviceOptions) error {
+	m, s, err := openService(opts.Name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	_, _ = s.Control(svc.Stop)
+	if err := s.Delete(); err != nil {
+		return err
+	}
+	fmt.Println("Removed service", opts.Name)
+	return nil
+}
+
+// windowsService reports the server's state to the SCM and turns stop
+// requests into the interrupt main already shuts down on
+type windowsService struct {
+	stop     chan<- os.Signal
+	finished <-chan struct{}
+}
+
+func (w windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				w.stop <- os.Interrupt
+				<-w.finished
+				return false, 0
+			}
+		case <-w.finished:
+			return false, 0
+		}
+	}
+}
+
+// runUnderServiceManager connects to the SCM when the server was started as a
+// Windows service. done must be called once the server has shut down.
+func runUnderServiceManager(stop chan<- os.Signal) (done func()) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return func() {}
+	}
+	finished := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		_ = svc.Run("", windowsService{stop: stop, finished: finished})
+	}()
+	return func() {
+		close(finished)
+		<-exited
+	}
+}
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Cod-e-Codes/marchat/shared"
 	"github.com/joho/godotenv"
 )
 
@@ -21,11 +28,15 @@ type Config struct {
 	TLSCertFile string `json:"tls_cert_file"`
 	TLSKeyFile  string `json:"tls_key_file"`
 
+	// CA bundle for mutual TLS; when set, clients must present a certificate
+	// it signed
+	TLSClientCAFile string `json:"tls_client_ca_file"`
+
 	// Database settings
 	DBPath string `json:"db_path"`
 
 	// Multi-database support
-	DBType     string `json:"db_type"` // "sqlite", "postgres", "mysql"
+	DBType     string `json:"db_type"` // "sqlite", "postgres", "mysql", "document", "memory"
 	DBHost     string `json:"db_host"`
 	DBPort     int    `json:"db_port"`
 	DBName     string `json:"db_name"`
@@ -33,6 +44,17 @@ type Config struct {
 	DBPassword string `json:"db_password"`
 	DBSSLMode  string `json:"db_ssl_mode"`
 
+	// In-memory database: evict messages older than this (0 = keep until restart)
+	MemoryTTL time.Duration `json:"memory_ttl"`
+
+	// AES-256 key for message content at rest (nil = plaintext)
+	DBEncryptionKey []byte `json:"-"`
+
+	// Chat messages are written in batches of up to DBWriteBatch (0 or 1 =
+	// one write per message), at least every DBWriteInterval
+	DBWriteBatch    int           `json:"db_write_batch"`
+	DBWriteInterval time.Duration `json:"db_write_interval"`
+
 	// Logging
 	LogLevel string `json:"log_level"`
 
@@ -51,8 +73,83 @@ type Config struct {
 	// File transfer settings
 	MaxFileBytes int64 `json:"max_file_bytes"`
 
+	// Largest chat message or command, in bytes of content
+	MaxMessageBytes int `json:"max_message_bytes"`
+
+	// Username rules enforced at handshake; reserved names and blocked words
+	// add to the server's built-in reserved names
+	UsernameMinLength    int      `json:"username_min_length"`
+	UsernameMaxLength    int      `json:"username_max_length"`
+	UsernamePattern      string   `json:"username_pattern"`
+	ReservedUsernames    []string `json:"reserved_usernames"`
+	BlockedUsernameWords []string `json:"blocked_username_words"`
+
 	// E2E encryption settings
 	GlobalE2EKey string `json:"global_e2e_key"`
+
+	// Session settings
+	AllowMultiSession bool `json:"allow_multi_session"`
+
+	// Allow read-only spectator connections (client --read-only)
+	AllowSpectators bool `json:"allow_spectators"`
+
+	// Allow ASCII art messages from :figlet and :cowsay
+	AllowASCIIArt bool `json:"allow_ascii_art"`
+
+	// Join challenge for public servers (0 bits / empty passphrase = off)
+	JoinPoWBits    int    `json:"join_pow_bits"`
+	JoinPassphrase string `json:"-"`
+
+	// WebSocket permessage-deflate: flate level (0 = off) and the smallest
+	// frame in bytes worth compressing
+	CompressionLevel     int `json:"compression_level"`
+	CompressionThreshold int `json:"compression_threshold"`
+
+	// Publish as a Tor onion service through this control port (empty = off)
+	TorControl         string `json:"tor_control"`
+	TorControlPassword string `json:"-"`
+
+	// Oldest client version supported; older clients are warned, or refused
+	// when RequireMinClientVersion is set (empty = any)
+	MinClientVersion        string `json:"min_client_version"`
+	RequireMinClientVersion bool   `json:"require_min_client_version"`
+
+	// Most connections held at once, admins aside (0 = no limit)
+	MaxConnections int `json:"max_connections"`
+
+	// Presence: users are shown away after AwayAfter without sending
+	// anything and disconnected after IdleTimeout (0 = never)
+	AwayAfter   time.Duration `json:"away_after"`
+	IdleTimeout time.Duration `json:"idle_timeout"`
+
+	// Mention throttle: each user may mention the same user or group
+	// MentionLimit times per MentionWindow (0 = no limit)
+	MentionLimit  int           `json:"mention_limit"`
+	MentionWindow time.Duration `json:"mention_window"`
+
+	// File types users may send, as extensions or MIME types (empty = any),
+	// and the clamd socket every file is scanned with (empty = no scanning)
+	AllowedFileTypes []string `json:"allowed_file_types"`
+	ClamdAddress     string   `json:"clamd_address"`
+
+	// Fetch link previews for URLs on these domains and their subdomains
+	// (empty = no previews)
+	LinkPreviewDomains []string `json:"link_preview_domains"`
+
+	// On SIGTERM, how long to wait for clients to leave before exiting (0 = no drain)
+	DrainTimeout time.Duration `json:"drain_timeout"`
+
+	// Email users who opted in with :email a summary of what they missed
+	// while offline, EmailDebounce after the first mention (empty host = off)
+	SMTPHost      string        `json:"smtp_host"`
+	SMTPPort      int           `json:"smtp_port"`
+	SMTPUsername  string        `json:"smtp_username"`
+	SMTPPassword  string        `json:"-"`
+	SMTPFrom      string        `json:"smtp_from"`
+	EmailDebounce time.Duration `json:"email_debounce"`
+
+	// Unix socket `marchat-server admin` attaches the terminal admin panel to
+	AdminSocket string `json:"admin_socket"`
 }
 
 // LoadConfig loads configuration from environment variables, .env files, and config files
@@ -94,6 +191,11 @@ func LoadConfigWithoutValidation(configDir string) (*Config, error) {
 		return nil, fmt.Errorf("failed to load .env file: %w", err)
 	}
 
+	// Read secrets mounted as files (MARCHAT_ADMIN_KEY_FILE, ...)
+	if err := loadSecretFiles(); err != nil {
+		return nil, err
+	}
+
 	// Load configuration from environment variables
 	if err := cfg.loadFromEnv(); err != nil {
 		return nil, fmt.Errorf("failed to load environment configuration: %w", err)
@@ -136,6 +238,13 @@ func (c *Config) loadFromEnv() error {
 		c.DBPath = filepath.Join(c.ConfigDir, "marchat.db")
 	}
 
+	// Admin panel socket, owner-only like the rest of the config directory
+	if socket := os.Getenv("MARCHAT_ADMIN_SOCKET"); socket != "" {
+		c.AdminSocket = socket
+	} else {
+		c.AdminSocket = filepath.Join(c.ConfigDir, "admin.sock")
+	}
+
 	// Log level configuration
 	if logLevel := os.Getenv("MARCHAT_LOG_LEVEL"); logLevel != "" {
 		c.LogLevel = logLevel
@@ -157,6 +266,7 @@ func (c *Config) loadFromEnv() error {
 	if tlsKeyFile := os.Getenv("MARCHAT_TLS_KEY_FILE"); tlsKeyFile != "" {
 		c.TLSKeyFile = tlsKeyFile
 	}
+	c.TLSClientCAFile = os.Getenv("MARCHAT_TLS_CLIENT_CA_FILE")
 
 	// Ban history gaps configuration
 	if banGapsStr := os.Getenv("MARCHAT_BAN_HISTORY_GAPS"); banGapsStr != "" {
@@ -191,11 +301,193 @@ func (c *Config) loadFromEnv() error {
 		c.MaxFileBytes = oneMB
 	}
 
+	// Chat messages longer than this are refused; clients offer a snippet instead
+	c.MaxMessageBytes = 16 * 1024
+	if bytesStr := os.Getenv("MARCHAT_MAX_MESSAGE_BYTES"); bytesStr != "" {
+		val, err := strconv.Atoi(bytesStr)
+		if err != nil || val <= 0 {
+			return fmt.Errorf("invalid MARCHAT_MAX_MESSAGE_BYTES: %s", bytesStr)
+		}
+		c.MaxMessageBytes = val
+	}
+
+	// Username rules: length bounds, an optional pattern and extra names/words
+	c.UsernameMinLength = 1
+	if minStr := os.Getenv("MARCHAT_USERNAME_MIN_LENGTH"); minStr != "" {
+		val, err := strconv.Atoi(minStr)
+		if err != nil || val < 1 || val > 32 {
+			return fmt.Errorf("invalid MARCHAT_USERNAME_MIN_LENGTH: %s (1-32)", minStr)
+		}
+		c.UsernameMinLength = val
+	}
+	c.UsernameMaxLength = 32
+	if maxStr := os.Getenv("MARCHAT_USERNAME_MAX_LENGTH"); maxStr != "" {
+		val, err := strconv.Atoi(maxStr)
+		if err != nil || val < 1 || val > 32 {
+			return fmt.Errorf("invalid MARCHAT_USERNAME_MAX_LENGTH: %s (1-32)", maxStr)
+		}
+		c.UsernameMaxLength = val
+	}
+	if c.UsernameMinLength > c.UsernameMaxLength {
+		return fmt.Errorf("MARCHAT_USERNAME_MIN_LENGTH (%d) is greater than MARCHAT_USERNAME_MAX_LENGTH (%d)", c.UsernameMinLength, c.UsernameMaxLength)
+	}
+	if pattern := os.Getenv("MARCHAT_USERNAME_PATTERN"); pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid MARCHAT_USERNAME_PATTERN: %w", err)
+		}
+		c.UsernamePattern = pattern
+	}
+	c.ReservedUsernames = splitList(os.Getenv("MARCHAT_RESERVED_USERNAMES"))
+	c.BlockedUsernameWords = splitList(os.Getenv("MARCHAT_BLOCKED_USERNAME_WORDS"))
+
 	// Global E2E key configuration
 	if globalE2EKey := os.Getenv("MARCHAT_GLOBAL_E2E_KEY"); globalE2EKey != "" {
 		c.GlobalE2EKey = globalE2EKey
 	}
 
+	// Multi-session configuration (same username from several devices)
+	if multiSessionStr := os.Getenv("MARCHAT_ALLOW_MULTI_SESSION"); multiSessionStr != "" {
+		c.AllowMultiSession = strings.ToLower(multiSessionStr) == "true"
+	} else {
+		c.AllowMultiSession = false // Default to one session per username
+	}
+
+	// Spectators are refused unless enabled
+	if spectatorsStr := os.Getenv("MARCHAT_ALLOW_SPECTATORS"); spectatorsStr != "" {
+		c.AllowSpectators = strings.ToLower(spectatorsStr) == "true"
+	} else {
+		c.AllowSpectators = false
+	}
+
+	// ASCII art commands are on unless an admin turns them off
+	if artStr := os.Getenv("MARCHAT_ALLOW_ASCII_ART"); artStr != "" {
+		c.AllowASCIIArt = strings.ToLower(artStr) != "false"
+	} else {
+		c.AllowASCIIArt = true
+	}
+
+	// Join challenge: proof-of-work difficulty and/or shared passphrase
+	if bitsStr := os.Getenv("MARCHAT_JOIN_POW_BITS"); bitsStr != "" {
+		bits, err := strconv.Atoi(bitsStr)
+		if err != nil || bits < 0 || bits > 28 {
+			return fmt.Errorf("invalid MARCHAT_JOIN_POW_BITS: %s (0-28)", bitsStr)
+		}
+		c.JoinPoWBits = bits
+	}
+	c.JoinPassphrase = os.Getenv("MARCHAT_JOIN_PASSPHRASE")
+
+	// WebSocket compression: fast by default, 0 turns it off
+	c.CompressionLevel = 1
+	if levelStr := os.Getenv("MARCHAT_WS_COMPRESSION_LEVEL"); levelStr != "" {
+		level, err := strconv.Atoi(levelStr)
+		if err != nil || level < 0 || level > 9 {
+			return fmt.Errorf("invalid MARCHAT_WS_COMPRESSION_LEVEL: %s (0-9)", levelStr)
+		}
+		c.CompressionLevel = level
+	}
+	c.CompressionThreshold = 256
+	if thresholdStr := os.Getenv("MARCHAT_WS_COMPRESSION_THRESHOLD"); thresholdStr != "" {
+		threshold, err := strconv.Atoi(thresholdStr)
+		if err != nil || threshold < 1 {
+			return fmt.Errorf("invalid MARCHAT_WS_COMPRESSION_THRESHOLD: %s", thresholdStr)
+		}
+		c.CompressionThreshold = threshold
+	}
+
+	// Onion service via a local tor, e.g. 127.0.0.1:9051
+	c.TorControl = os.Getenv("MARCHAT_TOR_CONTROL")
+	c.TorControlPassword = os.Getenv("MARCHAT_TOR_CONTROL_PASSWORD")
+
+	// Clients older than this get a warning banner, e.g. v0.9.0
+	if minVersion := os.Getenv("MARCHAT_MIN_CLIENT_VERSION"); minVersion != "" {
+		if _, ok := shared.ParseSemver(minVersion); !ok {
+			return fmt.Errorf("invalid MARCHAT_MIN_CLIENT_VERSION: %s (expected a version such as v0.9.0)", minVersion)
+		}
+		c.MinClientVersion = minVersion
+	}
+	// Turn older clients away instead of only warning them
+	c.RequireMinClientVersion = strings.ToLower(os.Getenv("MARCHAT_REQUIRE_MIN_CLIENT_VERSION")) == "true"
+
+	// Connection cap for small hosts; admins can always connect
+	if maxStr := os.Getenv("MARCHAT_MAX_CONNECTIONS"); maxStr != "" {
+		val, err := strconv.Atoi(maxStr)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid MARCHAT_MAX_CONNECTIONS: %s", maxStr)
+		}
+		c.MaxConnections = val
+	}
+
+	// Idle users are shown away, and optionally disconnected later
+	c.AwayAfter = 15 * time.Minute
+	if awayStr := os.Getenv("MARCHAT_AWAY_AFTER"); awayStr != "" {
+		away, err := time.ParseDuration(awayStr)
+		if err != nil || away < 0 {
+			return fmt.Errorf("invalid MARCHAT_AWAY_AFTER: %s", awayStr)
+		}
+		c.AwayAfter = away
+	}
+	if idleStr := os.Getenv("MARCHAT_IDLE_TIMEOUT"); idleStr != "" {
+		idle, err := time.ParseDuration(idleStr)
+		if err != nil || idle < 0 {
+			return fmt.Errorf("invalid MARCHAT_IDLE_TIMEOUT: %s", idleStr)
+		}
+		c.IdleTimeout = idle
+	}
+
+	// Repeated mentions of the same user or group are refused past the limit
+	c.MentionLimit = 5
+	if limitStr := os.Getenv("MARCHAT_MENTION_LIMIT"); limitStr != "" {
+		val, err := strconv.Atoi(limitStr)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid MARCHAT_MENTION_LIMIT: %s", limitStr)
+		}
+		c.MentionLimit = val
+	}
+	c.MentionWindow = 10 * time.Minute
+	if windowStr := os.Getenv("MARCHAT_MENTION_WINDOW"); windowStr != "" {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("invalid MARCHAT_MENTION_WINDOW: %s", windowStr)
+		}
+		c.MentionWindow = window
+	}
+
+	c.LinkPreviewDomains = splitList(os.Getenv("MARCHAT_LINK_PREVIEW_DOMAINS"))
+	c.AllowedFileTypes = splitList(os.Getenv("MARCHAT_ALLOWED_FILE_TYPES"))
+	c.ClamdAddress = os.Getenv("MARCHAT_CLAMD_ADDRESS")
+
+	// Graceful shutdown: clients are told to reconnect and given this long to go
+	c.DrainTimeout = 10 * time.Second
+	if drainStr := os.Getenv("MARCHAT_DRAIN_TIMEOUT"); drainStr != "" {
+		drain, err := time.ParseDuration(drainStr)
+		if err != nil || drain < 0 {
+			return fmt.Errorf("invalid MARCHAT_DRAIN_TIMEOUT: %s", drainStr)
+		}
+		c.DrainTimeout = drain
+	}
+
+	// Offline mention emails, sent with STARTTLS when the server offers it
+	c.SMTPHost = os.Getenv("MARCHAT_SMTP_HOST")
+	c.SMTPPort = 587
+	if portStr := os.Getenv("MARCHAT_SMTP_PORT"); portStr != "" {
+		val, err := strconv.Atoi(portStr)
+		if err != nil || val < 1 || val > 65535 {
+			return fmt.Errorf("invalid MARCHAT_SMTP_PORT: %s", portStr)
+		}
+		c.SMTPPort = val
+	}
+	c.SMTPUsername = os.Getenv("MARCHAT_SMTP_USERNAME")
+	c.SMTPPassword = os.Getenv("MARCHAT_SMTP_PASSWORD")
+	c.SMTPFrom = os.Getenv("MARCHAT_SMTP_FROM")
+	c.EmailDebounce = 10 * time.Minute
+	if debounceStr := os.Getenv("MARCHAT_EMAIL_DEBOUNCE"); debounceStr != "" {
+		debounce, err := time.ParseDuration(debounceStr)
+		if err != nil || debounce <= 0 {
+			return fmt.Errorf("invalid MARCHAT_EMAIL_DEBOUNCE: %s", debounceStr)
+		}
+		c.EmailDebounce = debounce
+	}
+
 	// Database type configuration
 	if dbType := os.Getenv("MARCHAT_DB_TYPE"); dbType != "" {
 		c.DBType = dbType
@@ -203,6 +495,38 @@ func (c *Config) loadFromEnv() error {
 		c.DBType = "sqlite" // Default to SQLite for backward compatibility
 	}
 
+	// The document store keeps its collections in a directory rather than a single file
+	if c.DBType == "document" && os.Getenv("MARCHAT_DB_PATH") == "" {
+		c.DBPath = filepath.Join(c.ConfigDir, "marchat-docstore")
+	}
+
+	// Message TTL for the in-memory database
+	if ttlStr := os.Getenv("MARCHAT_MEMORY_TTL"); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl < 0 {
+			return fmt.Errorf("invalid MARCHAT_MEMORY_TTL: %s", ttlStr)
+		}
+		c.MemoryTTL = ttl
+	}
+
+	// Write-behind batching for chat messages
+	c.DBWriteBatch = 100
+	if batchStr := os.Getenv("MARCHAT_DB_WRITE_BATCH"); batchStr != "" {
+		val, err := strconv.Atoi(batchStr)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid MARCHAT_DB_WRITE_BATCH: %s", batchStr)
+		}
+		c.DBWriteBatch = val
+	}
+	c.DBWriteInterval = 100 * time.Millisecond
+	if intervalStr := os.Getenv("MARCHAT_DB_WRITE_INTERVAL"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid MARCHAT_DB_WRITE_INTERVAL: %s", intervalStr)
+		}
+		c.DBWriteInterval = interval
+	}
+
 	// Database connection configuration (for PostgreSQL/MySQL)
 	if dbHost := os.Getenv("MARCHAT_DB_HOST"); dbHost != "" {
 		c.DBHost = dbHost
@@ -248,6 +572,15 @@ func (c *Config) loadFromEnv() error {
 		c.DBSSLMode = "disable"
 	}
 
+	// Encrypt message content at rest: 32 bytes, hex or base64
+	if keyStr := os.Getenv("MARCHAT_DB_ENCRYPTION_KEY"); keyStr != "" {
+		key, err := parseEncryptionKey(keyStr)
+		if err != nil {
+			return fmt.Errorf("invalid MARCHAT_DB_ENCRYPTION_KEY: %w", err)
+		}
+		c.DBEncryptionKey = key
+	}
+
 	return nil
 }
 
@@ -261,6 +594,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("MARCHAT_ADMIN_KEY is required")
 	}
 
+	if c.TLSClientCAFile != "" && !c.IsTLSEnabled() {
+		return fmt.Errorf("MARCHAT_TLS_CLIENT_CA_FILE requires TLS (set MARCHAT_TLS_CERT_FILE and MARCHAT_TLS_KEY_FILE)")
+	}
+
 	if len(c.Admins) == 0 {
 		return fmt.Errorf("at least one admin user is required (set MARCHAT_USERS)")
 	}
@@ -286,9 +623,13 @@ func (c *Config) Validate() error {
 	c.Admins = normalizedAdmins
 
 	// Validate database configuration
-	validTypes := map[string]bool{"sqlite": true, "postgres": true, "postgresql": true, "mysql": true}
+	validTypes := map[string]bool{"sqlite": true, "postgres": true, "postgresql": true, "mysql": true, "document": true, "memory": true}
 	if !validTypes[c.DBType] {
-		return fmt.Errorf("invalid database type: %s (must be sqlite, postgres, or mysql)", c.DBType)
+		return fmt.Errorf("invalid database type: %s (must be sqlite, postgres, mysql, document, or memory)", c.DBType)
+	}
+
+	if c.SMTPHost != "" && c.SMTPFrom == "" {
+		return fmt.Errorf("MARCHAT_SMTP_HOST requires a sender address (set MARCHAT_SMTP_FROM)")
 	}
 
 	// Require credentials for PostgreSQL/MySQL
@@ -307,6 +648,30 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// splitList parses a comma-separated setting, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseEncryptionKey decodes a 32-byte key written as hex (openssl rand -hex 32)
+// or base64 (openssl rand -base64 32)
+func parseEncryptionKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("expected 32 bytes as hex or base64 (generate one with: openssl rand -hex 32)")
+}
+
 // getDefaultConfigDir returns the default configuration directory
 func getDefaultConfigDir() string {
 	// Check if we're in development mode (running from project root)
@@ -352,6 +717,47 @@ func loadEnvFile(envPath string) error {
 	return nil
 }
 
+// secretFileVars may instead be read from the file named by VAR_FILE, the
+// convention for Docker and Kubernetes secrets
+var secretFileVars = []string{
+	"MARCHAT_ADMIN_KEY",
+	"MARCHAT_JWT_SECRET",
+	"MARCHAT_DB_PASSWORD",
+	"MARCHAT_DB_ENCRYPTION_KEY",
+	"MARCHAT_GLOBAL_E2E_KEY",
+	"MARCHAT_JOIN_PASSPHRASE",
+	"MARCHAT_TOR_CONTROL_PASSWORD",
+	"MARCHAT_SMTP_PASSWORD",
+}
+
+// loadSecretFiles sets each secret variable from its _FILE counterpart, so
+// everything that reads the environment sees the secret. Setting both is an
+// error rather than a silent preference for one.
+func loadSecretFiles() error {
+	for _, key := range secretFileVars {
+		path := os.Getenv(key + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(key) != "" {
+			return fmt.Errorf("both %s and %s_FILE are set; use one", key, key)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("invalid %s_FILE: %w", key, err)
+		}
+		// Secret files usually end with a newline the value must not include
+		value := strings.TrimRight(string(data), "\r\n")
+		if value == "" {
+			return fmt.Errorf("invalid %s_FILE: %s is empty", key, path)
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetEnvWithDefault returns an environment variable value or a default
 func GetEnvWithDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -370,11 +776,36 @@ func GetEnvIntWithDefault(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// IsEphemeralDB returns true if messages are only kept in memory and lost on restart
+func (c *Config) IsEphemeralDB() bool {
+	return c.DBType == "memory"
+}
+
 // IsTLSEnabled returns true if both TLS certificate and key files are configured
 func (c *Config) IsTLSEnabled() bool {
 	return c.TLSCertFile != "" && c.TLSKeyFile != ""
 }
 
+// ClientAuthTLSConfig returns the TLS settings requiring client certificates
+// signed by TLSClientCAFile, or nil when mutual TLS is off
+func (c *Config) ClientAuthTLSConfig() (*tls.Config, error) {
+	if c.TLSClientCAFile == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(c.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", c.TLSClientCAFile)
+	}
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
 // GetWebSocketScheme returns the appropriate WebSocket scheme based on TLS configuration
 func (c *Config) GetWebSocketScheme() string {
 	if c.IsTLSEnabled() {
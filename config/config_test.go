@@ -1,11 +1,20 @@
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -97,6 +106,379 @@ func TestLoadConfig(t *testing.T) {
 			t.Errorf("Expected config dir '%s', got '%s'", tempDir, cfg.ConfigDir)
 		}
 	})
+
+	t.Run("memory database", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		os.Setenv("MARCHAT_DB_TYPE", "memory")
+		os.Setenv("MARCHAT_MEMORY_TTL", "30m")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_DB_TYPE")
+			os.Unsetenv("MARCHAT_MEMORY_TTL")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if !cfg.IsEphemeralDB() {
+			t.Error("Expected memory database to be ephemeral")
+		}
+		if cfg.MemoryTTL != 30*time.Minute {
+			t.Errorf("Expected TTL 30m, got %v", cfg.MemoryTTL)
+		}
+
+		os.Setenv("MARCHAT_MEMORY_TTL", "soon")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected error for invalid MARCHAT_MEMORY_TTL")
+		}
+	})
+
+	t.Run("multi-session", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_ALLOW_MULTI_SESSION")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.AllowMultiSession {
+			t.Error("Expected multi-session to be disabled by default")
+		}
+
+		os.Setenv("MARCHAT_ALLOW_MULTI_SESSION", "true")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if !cfg.AllowMultiSession {
+			t.Error("Expected multi-session to be enabled")
+		}
+	})
+
+	t.Run("spectators", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_ALLOW_SPECTATORS")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.AllowSpectators {
+			t.Error("Expected spectators to be refused by default")
+		}
+
+		os.Setenv("MARCHAT_ALLOW_SPECTATORS", "true")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if !cfg.AllowSpectators {
+			t.Error("Expected spectators to be allowed")
+		}
+	})
+
+	t.Run("join challenge", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		os.Setenv("MARCHAT_JOIN_POW_BITS", "18")
+		os.Setenv("MARCHAT_JOIN_PASSPHRASE", "open sesame")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_JOIN_POW_BITS")
+			os.Unsetenv("MARCHAT_JOIN_PASSPHRASE")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.JoinPoWBits != 18 || cfg.JoinPassphrase != "open sesame" {
+			t.Errorf("Unexpected join challenge config: %d bits, passphrase %q", cfg.JoinPoWBits, cfg.JoinPassphrase)
+		}
+
+		os.Setenv("MARCHAT_JOIN_POW_BITS", "64")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected an error for an excessive difficulty")
+		}
+	})
+
+	t.Run("websocket compression", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_WS_COMPRESSION_LEVEL")
+			os.Unsetenv("MARCHAT_WS_COMPRESSION_THRESHOLD")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.CompressionLevel != 1 || cfg.CompressionThreshold != 256 {
+			t.Errorf("Expected fast compression above 256 bytes by default, got level %d, threshold %d", cfg.CompressionLevel, cfg.CompressionThreshold)
+		}
+
+		os.Setenv("MARCHAT_WS_COMPRESSION_LEVEL", "0")
+		os.Setenv("MARCHAT_WS_COMPRESSION_THRESHOLD", "1024")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.CompressionLevel != 0 || cfg.CompressionThreshold != 1024 {
+			t.Errorf("Expected compression off with a 1024 byte threshold, got level %d, threshold %d", cfg.CompressionLevel, cfg.CompressionThreshold)
+		}
+
+		os.Setenv("MARCHAT_WS_COMPRESSION_LEVEL", "10")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected an out of range compression level to be rejected")
+		}
+	})
+
+	t.Run("database encryption key", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_DB_ENCRYPTION_KEY")
+		}()
+
+		for _, key := range []string{
+			"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=",
+		} {
+			os.Setenv("MARCHAT_DB_ENCRYPTION_KEY", key)
+			cfg, err := LoadConfig(t.TempDir())
+			if err != nil {
+				t.Fatalf("LoadConfig failed for %q: %v", key, err)
+			}
+			if len(cfg.DBEncryptionKey) != 32 || cfg.DBEncryptionKey[31] != 0x1f {
+				t.Errorf("Expected the 32-byte key from %q, got %x", key, cfg.DBEncryptionKey)
+			}
+		}
+
+		os.Setenv("MARCHAT_DB_ENCRYPTION_KEY", "too-short")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected a key that is not 32 bytes to be rejected")
+		}
+	})
+
+	t.Run("drain timeout", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_DRAIN_TIMEOUT")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.DrainTimeout != 10*time.Second {
+			t.Errorf("Expected a 10s drain by default, got %s", cfg.DrainTimeout)
+		}
+
+		os.Setenv("MARCHAT_DRAIN_TIMEOUT", "25s")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.DrainTimeout != 25*time.Second {
+			t.Errorf("Expected a 25s drain, got %s", cfg.DrainTimeout)
+		}
+
+		os.Setenv("MARCHAT_DRAIN_TIMEOUT", "-1s")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected a negative drain timeout to be rejected")
+		}
+	})
+
+	t.Run("smtp", func(t *testing.T) {
+		t.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		t.Setenv("MARCHAT_USERS", "user1")
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.SMTPHost != "" || cfg.SMTPPort != 587 || cfg.EmailDebounce != 10*time.Minute {
+			t.Errorf("Expected email off with defaults, got %q:%d after %s", cfg.SMTPHost, cfg.SMTPPort, cfg.EmailDebounce)
+		}
+
+		t.Setenv("MARCHAT_SMTP_HOST", "smtp.example.com")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected an SMTP host without a sender to be rejected")
+		}
+
+		t.Setenv("MARCHAT_SMTP_FROM", "marchat@example.com")
+		t.Setenv("MARCHAT_SMTP_PORT", "2525")
+		t.Setenv("MARCHAT_EMAIL_DEBOUNCE", "2m")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.SMTPPort != 2525 || cfg.EmailDebounce != 2*time.Minute || cfg.SMTPFrom != "marchat@example.com" {
+			t.Errorf("Unexpected SMTP settings %d, %s, %q", cfg.SMTPPort, cfg.EmailDebounce, cfg.SMTPFrom)
+		}
+
+		t.Setenv("MARCHAT_EMAIL_DEBOUNCE", "0s")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected a zero debounce to be rejected")
+		}
+	})
+
+	t.Run("mention throttle", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_MENTION_LIMIT")
+			os.Unsetenv("MARCHAT_MENTION_WINDOW")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.MentionLimit != 5 || cfg.MentionWindow != 10*time.Minute {
+			t.Errorf("Expected 5 mentions per 10m by default, got %d per %s", cfg.MentionLimit, cfg.MentionWindow)
+		}
+
+		os.Setenv("MARCHAT_MENTION_LIMIT", "0")
+		os.Setenv("MARCHAT_MENTION_WINDOW", "1m")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.MentionLimit != 0 || cfg.MentionWindow != time.Minute {
+			t.Errorf("Expected the throttle off with a 1m window, got %d per %s", cfg.MentionLimit, cfg.MentionWindow)
+		}
+
+		os.Setenv("MARCHAT_MENTION_WINDOW", "0s")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected an empty mention window to be rejected")
+		}
+	})
+
+	t.Run("write batching", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_DB_WRITE_BATCH")
+			os.Unsetenv("MARCHAT_DB_WRITE_INTERVAL")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.DBWriteBatch != 100 || cfg.DBWriteInterval != 100*time.Millisecond {
+			t.Errorf("Expected batches of 100 every 100ms by default, got %d every %s", cfg.DBWriteBatch, cfg.DBWriteInterval)
+		}
+
+		os.Setenv("MARCHAT_DB_WRITE_BATCH", "0")
+		os.Setenv("MARCHAT_DB_WRITE_INTERVAL", "1s")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.DBWriteBatch != 0 || cfg.DBWriteInterval != time.Second {
+			t.Errorf("Expected batching off with a 1s interval, got %d every %s", cfg.DBWriteBatch, cfg.DBWriteInterval)
+		}
+
+		os.Setenv("MARCHAT_DB_WRITE_BATCH", "-1")
+		if _, err := LoadConfig(t.TempDir()); err == nil {
+			t.Error("Expected a negative batch size to be rejected")
+		}
+	})
+
+	t.Run("file checks", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		os.Setenv("MARCHAT_ALLOWED_FILE_TYPES", ".png,image/*")
+		os.Setenv("MARCHAT_CLAMD_ADDRESS", "unix:/run/clamav/clamd.ctl")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_ALLOWED_FILE_TYPES")
+			os.Unsetenv("MARCHAT_CLAMD_ADDRESS")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if !reflect.DeepEqual(cfg.AllowedFileTypes, []string{".png", "image/*"}) || cfg.ClamdAddress != "unix:/run/clamav/clamd.ctl" {
+			t.Errorf("Unexpected file checks %v %q", cfg.AllowedFileTypes, cfg.ClamdAddress)
+		}
+	})
+
+	t.Run("link previews", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		os.Setenv("MARCHAT_LINK_PREVIEW_DOMAINS", "github.com, ,wikipedia.org")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_LINK_PREVIEW_DOMAINS")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if !reflect.DeepEqual(cfg.LinkPreviewDomains, []string{"github.com", "wikipedia.org"}) {
+			t.Errorf("Unexpected link preview domains %v", cfg.LinkPreviewDomains)
+		}
+	})
+
+	t.Run("ascii-art", func(t *testing.T) {
+		os.Setenv("MARCHAT_ADMIN_KEY", "test-key")
+		os.Setenv("MARCHAT_USERS", "user1")
+		defer func() {
+			os.Unsetenv("MARCHAT_ADMIN_KEY")
+			os.Unsetenv("MARCHAT_USERS")
+			os.Unsetenv("MARCHAT_ALLOW_ASCII_ART")
+		}()
+
+		cfg, err := LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if !cfg.AllowASCIIArt {
+			t.Error("Expected ASCII art to be allowed by default")
+		}
+
+		os.Setenv("MARCHAT_ALLOW_ASCII_ART", "false")
+		cfg, err = LoadConfig(t.TempDir())
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.AllowASCIIArt {
+			t.Error("Expected ASCII art to be disabled")
+		}
+	})
 }
 
 func TestLoadConfigWithEnvFile(t *testing.T) {
@@ -155,6 +537,9 @@ MARCHAT_JWT_SECRET=custom-jwt-secret`
 	if cfg.JWTSecret != "custom-jwt-secret" {
 		t.Errorf("Expected JWT secret 'custom-jwt-secret', got '%s'", cfg.JWTSecret)
 	}
+	if want := filepath.Join(tempDir, "admin.sock"); cfg.AdminSocket != want {
+		t.Errorf("Expected admin socket '%s', got '%s'", want, cfg.AdminSocket)
+	}
 }
 
 func TestEnvironmentVariablePrecedence(t *testing.T) {
@@ -195,6 +580,91 @@ MARCHAT_USERS=envuser1`
 	}
 }
 
+func TestSecretFiles(t *testing.T) {
+	secrets := t.TempDir()
+	keyFile := filepath.Join(secrets, "admin_key")
+	if err := os.WriteFile(keyFile, []byte("file-admin-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pwFile := filepath.Join(secrets, "db_password")
+	if err := os.WriteFile(pwFile, []byte("s3cret\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("MARCHAT_CONFIG_DIR", "")
+	t.Setenv("MARCHAT_ADMIN_KEY", "")
+	t.Setenv("MARCHAT_DB_PASSWORD", "")
+	t.Setenv("MARCHAT_USERS", "admin")
+	t.Setenv("MARCHAT_ADMIN_KEY_FILE", keyFile)
+	t.Setenv("MARCHAT_DB_PASSWORD_FILE", pwFile)
+
+	cfg, err := LoadConfig(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.AdminKey != "file-admin-key" {
+		t.Errorf("Expected admin key from file, got %q", cfg.AdminKey)
+	}
+	if cfg.DBPassword != "s3cret" {
+		t.Errorf("Expected DB password from file, got %q", cfg.DBPassword)
+	}
+
+	t.Run("both set", func(t *testing.T) {
+		t.Setenv("MARCHAT_ADMIN_KEY", "env-key")
+		if _, err := LoadConfigWithoutValidation(t.TempDir()); err == nil {
+			t.Error("Expected an error when both MARCHAT_ADMIN_KEY and MARCHAT_ADMIN_KEY_FILE are set")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("MARCHAT_ADMIN_KEY", "")
+		t.Setenv("MARCHAT_ADMIN_KEY_FILE", filepath.Join(secrets, "missing"))
+		if _, err := LoadConfigWithoutValidation(t.TempDir()); err == nil {
+			t.Error("Expected an error for a missing secret file")
+		}
+	})
+}
+
+func TestUsernameRules(t *testing.T) {
+	t.Setenv("MARCHAT_CONFIG_DIR", "")
+	t.Setenv("MARCHAT_USERNAME_MIN_LENGTH", "3")
+	t.Setenv("MARCHAT_USERNAME_MAX_LENGTH", "")
+	t.Setenv("MARCHAT_USERNAME_PATTERN", "^[a-z]")
+	t.Setenv("MARCHAT_RESERVED_USERNAMES", "staff, ,support")
+	t.Setenv("MARCHAT_BLOCKED_USERNAME_WORDS", "")
+
+	cfg, err := LoadConfigWithoutValidation(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadConfigWithoutValidation failed: %v", err)
+	}
+	if cfg.UsernameMinLength != 3 || cfg.UsernameMaxLength != 32 {
+		t.Errorf("Expected lengths 3-32, got %d-%d", cfg.UsernameMinLength, cfg.UsernameMaxLength)
+	}
+	if cfg.UsernamePattern != "^[a-z]" {
+		t.Errorf("Expected the pattern to be kept, got %q", cfg.UsernamePattern)
+	}
+	if len(cfg.ReservedUsernames) != 2 || cfg.ReservedUsernames[1] != "support" {
+		t.Errorf("Expected two reserved names, got %v", cfg.ReservedUsernames)
+	}
+	if cfg.BlockedUsernameWords != nil {
+		t.Errorf("Expected no blocked words, got %v", cfg.BlockedUsernameWords)
+	}
+
+	for name, env := range map[string][2]string{
+		"max over cap":   {"MARCHAT_USERNAME_MAX_LENGTH", "40"},
+		"min over max":   {"MARCHAT_USERNAME_MAX_LENGTH", "2"},
+		"bad pattern":    {"MARCHAT_USERNAME_PATTERN", "[a-"},
+		"min not number": {"MARCHAT_USERNAME_MIN_LENGTH", "three"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := LoadConfigWithoutValidation(t.TempDir()); err == nil {
+				t.Errorf("Expected %s=%s to be refused", env[0], env[1])
+			}
+		})
+	}
+}
+
 func TestValidation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -255,6 +725,16 @@ func TestValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "client CA without TLS",
+			cfg: &Config{
+				Port:            8080,
+				AdminKey:        "test-key",
+				Admins:          []string{"user1"},
+				TLSClientCAFile: "ca.pem",
+			},
+			wantErr: true,
+		},
 		{
 			name: "empty admin username",
 			cfg: &Config{
@@ -276,6 +756,48 @@ func TestValidation(t *testing.T) {
 	}
 }
 
+func TestClientAuthTLSConfig(t *testing.T) {
+	cfg := &Config{}
+	if tlsCfg, err := cfg.ClientAuthTLSConfig(); tlsCfg != nil || err != nil {
+		t.Errorf("Expected mutual TLS to be off by default, got %v, %v", tlsCfg, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test client CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.TLSClientCAFile = filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(cfg.TLSClientCAFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tlsCfg, err := cfg.ClientAuthTLSConfig()
+	if err != nil {
+		t.Fatalf("ClientAuthTLSConfig: %v", err)
+	}
+	if tlsCfg.ClientAuth != tls.RequireAndVerifyClientCert || tlsCfg.ClientCAs == nil {
+		t.Errorf("Expected client certificates to be required, got %+v", tlsCfg)
+	}
+
+	if err := os.WriteFile(cfg.TLSClientCAFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.ClientAuthTLSConfig(); err == nil {
+		t.Error("Expected a CA file without certificates to be rejected")
+	}
+}
+
 func TestGetDefaultConfigDir(t *testing.T) {
 	// Test development mode (go.mod exists)
 	originalWd, err := os.Getwd()
@@ -63,6 +63,35 @@ type PluginInstance struct {
 	Config   sdk.Config
 	Enabled  bool
 	mu       sync.Mutex
+
+	logMu sync.Mutex
+	logs  []string // recent stderr lines, oldest first
+}
+
+// Running reports whether the plugin process has been started
+func (p *PluginInstance) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Process != nil
+}
+
+// maxPluginLogLines is how many stderr lines each plugin keeps for the admin panels
+const maxPluginLogLines = 50
+
+// RecentLogs returns the plugin's most recent stderr lines, oldest first
+func (p *PluginInstance) RecentLogs() []string {
+	p.logMu.Lock()
+	defer p.logMu.Unlock()
+	return append([]string(nil), p.logs...)
+}
+
+func (p *PluginInstance) addLog(line string) {
+	p.logMu.Lock()
+	defer p.logMu.Unlock()
+	p.logs = append(p.logs, line)
+	if len(p.logs) > maxPluginLogLines {
+		p.logs = p.logs[len(p.logs)-maxPluginLogLines:]
+	}
 }
 
 // NewPluginHost creates a new plugin host
@@ -524,6 +553,7 @@ func (h *PluginHost) handlePluginErrors(instance *PluginInstance) {
 			break
 		}
 		log.Printf("[Plugin %s] %s: %s", instance.Name, logEntry.Level, logEntry.Message)
+		instance.addLog(fmt.Sprintf("%s %s: %s", time.Now().Format("15:04:05"), logEntry.Level, logEntry.Message))
 	}
 }
 
@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/Cod-e-Codes/marchat/plugin/host"
 	"github.com/Cod-e-Codes/marchat/plugin/sdk"
@@ -46,7 +47,8 @@ func validatePluginName(name string) error {
 
 // PluginState represents the persisted state of plugins
 type PluginState struct {
-	Enabled map[string]bool `json:"enabled"` // plugin name -> enabled status
+	Enabled  map[string]bool              `json:"enabled"`            // plugin name -> enabled status
+	Settings map[string]map[string]string `json:"settings,omitempty"` // plugin name -> settings changed from the manifest defaults
 }
 
 // PluginManager manages plugin installation and commands
@@ -57,6 +59,9 @@ type PluginManager struct {
 	dataDir     string
 	registryURL string
 	stateFile   string
+
+	settingsMu sync.Mutex
+	settings   map[string]map[string]string // overrides of manifest settings, by plugin
 }
 
 // NewPluginManager creates a new plugin manager
@@ -71,6 +76,7 @@ func NewPluginManager(pluginDir, dataDir, registryURL string) *PluginManager {
 		dataDir:     dataDir,
 		registryURL: registryURL,
 		stateFile:   filepath.Join(dataDir, "plugin_state.json"),
+		settings:    make(map[string]map[string]string),
 	}
 
 	// Auto-discover and load installed plugins
@@ -107,7 +113,8 @@ func (pm *PluginManager) loadPluginState() *PluginState {
 // savePluginState persists the current plugin state
 func (pm *PluginManager) savePluginState() error {
 	state := &PluginState{
-		Enabled: make(map[string]bool),
+		Enabled:  make(map[string]bool),
+		Settings: make(map[string]map[string]string),
 	}
 
 	// Collect enabled status from all plugins
@@ -115,6 +122,14 @@ func (pm *PluginManager) savePluginState() error {
 		state.Enabled[name] = instance.Enabled
 	}
 
+	pm.settingsMu.Lock()
+	for name, overrides := range pm.settings {
+		if len(overrides) > 0 {
+			state.Settings[name] = overrides
+		}
+	}
+	pm.settingsMu.Unlock()
+
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
@@ -131,6 +146,9 @@ func (pm *PluginManager) savePluginState() error {
 func (pm *PluginManager) discoverInstalledPlugins() {
 	// Load persisted plugin state
 	state := pm.loadPluginState()
+	for name, overrides := range state.Settings {
+		pm.settings[name] = overrides
+	}
 
 	// Read plugin directory
 	entries, err := os.ReadDir(pm.pluginDir)
@@ -173,6 +191,7 @@ func (pm *PluginManager) discoverInstalledPlugins() {
 		instance := pm.host.GetPlugin(pluginName)
 		if instance != nil {
 			instance.Enabled = enabled
+			pm.applySettings(instance)
 
 			// Auto-start enabled plugins
 			if enabled {
@@ -222,6 +241,9 @@ func (pm *PluginManager) InstallPluginWithPlatform(name, osName, arch string) er
 	if err := pm.host.LoadPlugin(name); err != nil {
 		return fmt.Errorf("failed to load plugin: %w", err)
 	}
+	if instance := pm.host.GetPlugin(name); instance != nil {
+		pm.applySettings(instance)
+	}
 
 	// Start plugin
 	if err := pm.host.StartPlugin(name); err != nil {
@@ -258,6 +280,12 @@ func (pm *PluginManager) UninstallPlugin(name string) error {
 		return fmt.Errorf("failed to remove plugin data: %w", err)
 	}
 
+	// Forget its settings along with its data
+	pm.settingsMu.Lock()
+	delete(pm.settings, name)
+	pm.settingsMu.Unlock()
+	_ = pm.savePluginState()
+
 	return nil
 }
 
@@ -650,3 +678,115 @@ func (pm *PluginManager) GetPluginManifest(name string) *sdk.PluginManifest {
 	}
 	return instance.Manifest
 }
+
+// applySettings gives a loaded plugin its manifest defaults with the saved
+// overrides on top; the plugin receives them when it starts
+func (pm *PluginManager) applySettings(instance *host.PluginInstance) {
+	settings := make(map[string]string)
+	if instance.Manifest != nil {
+		for k, v := range instance.Manifest.Settings {
+			settings[k] = v
+		}
+	}
+	pm.settingsMu.Lock()
+	for k, v := range pm.settings[instance.Name] {
+		if _, declared := settings[k]; declared {
+			settings[k] = v
+		}
+	}
+	pm.settingsMu.Unlock()
+	instance.Config.Settings = settings
+}
+
+// GetPluginSettings returns a plugin's current settings: the defaults its
+// manifest declares with any saved changes applied
+func (pm *PluginManager) GetPluginSettings(name string) map[string]string {
+	instance := pm.GetPlugin(name)
+	if instance == nil {
+		return nil
+	}
+	settings := make(map[string]string, len(instance.Config.Settings))
+	for k, v := range instance.Config.Settings {
+		settings[k] = v
+	}
+	return settings
+}
+
+// SetPluginSetting changes one of the settings a plugin's manifest declares
+// and persists it. A running plugin is restarted so it starts with the new
+// value.
+func (pm *PluginManager) SetPluginSetting(name, key, value string) error {
+	// Validate plugin name to prevent path traversal
+	if err := validatePluginName(name); err != nil {
+		return fmt.Errorf("invalid plugin name: %w", err)
+	}
+	instance := pm.host.GetPlugin(name)
+	if instance == nil {
+		return fmt.Errorf("plugin %s not found", name)
+	}
+	if instance.Manifest == nil {
+		return fmt.Errorf("plugin %s has no manifest", name)
+	}
+	def, declared := instance.Manifest.Settings[key]
+	if !declared {
+		return fmt.Errorf("plugin %s has no setting %q", name, key)
+	}
+
+	pm.settingsMu.Lock()
+	overrides := pm.settings[name]
+	if overrides == nil {
+		overrides = make(map[string]string)
+		pm.settings[name] = overrides
+	}
+	if value == def {
+		delete(overrides, key)
+	} else {
+		overrides[key] = value
+	}
+	pm.settingsMu.Unlock()
+
+	if err := pm.savePluginState(); err != nil {
+		return err
+	}
+
+	running := instance.Running()
+	if running {
+		if err := pm.host.StopPlugin(name); err != nil {
+			return fmt.Errorf("failed to stop plugin: %w", err)
+		}
+	}
+	pm.applySettings(instance)
+	if running {
+		if err := pm.host.StartPlugin(name); err != nil {
+			return fmt.Errorf("failed to restart plugin: %w", err)
+		}
+	}
+	return nil
+}
+
+// PluginDataSize returns the total size in bytes of the files in a plugin's
+// data directory
+func (pm *PluginManager) PluginDataSize(name string) (int64, error) {
+	// Validate plugin name to prevent path traversal
+	if err := validatePluginName(name); err != nil {
+		return 0, fmt.Errorf("invalid plugin name: %w", err)
+	}
+	var size int64
+	err := filepath.WalkDir(filepath.Join(pm.dataDir, name), func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -675,3 +675,92 @@ func TestGetPluginManifest(t *testing.T) {
 		t.Errorf("Expected manifest name %s, got %s", pluginName, manifest.Name)
 	}
 }
+
+func TestPluginSettings(t *testing.T) {
+	pluginDir := t.TempDir()
+	dataDir := t.TempDir()
+	manager := NewPluginManager(pluginDir, dataDir, "https://example.com/registry.json")
+
+	pluginName := "test-plugin"
+	pluginPath := filepath.Join(pluginDir, pluginName)
+	if err := os.MkdirAll(pluginPath, 0755); err != nil {
+		t.Fatalf("Failed to create plugin directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginPath, pluginName), []byte("#!/bin/bash\necho 'test'"), 0755); err != nil {
+		t.Fatalf("Failed to create binary: %v", err)
+	}
+	manifest := sdk.PluginManifest{
+		Name:        pluginName,
+		Version:     "1.0.0",
+		Description: "Test plugin",
+		Author:      "Test Author",
+		License:     "MIT",
+		Settings:    map[string]string{"greeting": "hello", "channel": "general"},
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginPath, "plugin.json"), manifestData, 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := manager.host.LoadPlugin(pluginName); err != nil {
+		t.Fatalf("Failed to load plugin: %v", err)
+	}
+	manager.applySettings(manager.host.GetPlugin(pluginName))
+
+	if got := manager.GetPluginSettings(pluginName); got["greeting"] != "hello" || got["channel"] != "general" {
+		t.Fatalf("Expected the manifest defaults, got %v", got)
+	}
+	if err := manager.SetPluginSetting(pluginName, "greeting", "hi there"); err != nil {
+		t.Fatalf("SetPluginSetting failed: %v", err)
+	}
+	if err := manager.SetPluginSetting(pluginName, "undeclared", "x"); err == nil {
+		t.Error("Expected settings the manifest does not declare to be rejected")
+	}
+	if got := manager.GetPluginSettings(pluginName); got["greeting"] != "hi there" {
+		t.Errorf("Expected the changed setting, got %v", got)
+	}
+
+	// A new manager picks the change up from the saved state
+	if err := manager.DisablePlugin(pluginName); err != nil {
+		t.Fatalf("DisablePlugin failed: %v", err)
+	}
+	reloaded := NewPluginManager(pluginDir, dataDir, "https://example.com/registry.json")
+	if got := reloaded.GetPluginSettings(pluginName); got["greeting"] != "hi there" || got["channel"] != "general" {
+		t.Errorf("Expected the saved setting after reload, got %v", got)
+	}
+
+	// Setting the default again drops the override
+	if err := reloaded.SetPluginSetting(pluginName, "greeting", "hello"); err != nil {
+		t.Fatalf("SetPluginSetting failed: %v", err)
+	}
+	if state := reloaded.loadPluginState(); len(state.Settings[pluginName]) != 0 {
+		t.Errorf("Expected no saved overrides, got %v", state.Settings)
+	}
+}
+
+func TestPluginDataSize(t *testing.T) {
+	dataDir := t.TempDir()
+	manager := NewPluginManager(t.TempDir(), dataDir, "https://example.com/registry.json")
+
+	if size, err := manager.PluginDataSize("echo"); err != nil || size != 0 {
+		t.Fatalf("Expected 0 bytes for a plugin without data, got %d (%v)", size, err)
+	}
+	nested := filepath.Join(dataDir, "echo", "cache")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "echo", "a"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "b"), make([]byte, 23), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := manager.PluginDataSize("echo"); err != nil || size != 123 {
+		t.Errorf("Expected 123 bytes, got %d (%v)", size, err)
+	}
+	if _, err := manager.PluginDataSize("../etc"); err == nil {
+		t.Error("Expected an invalid plugin name to be rejected")
+	}
+}
@@ -0,0 +1,138 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Argument types a plugin command can declare
+const (
+	ArgString   = "string"
+	ArgInt      = "int"
+	ArgNumber   = "number"
+	ArgBool     = "bool"
+	ArgDuration = "duration"
+)
+
+// CommandArg describes one argument of a plugin command. The server checks
+// arguments against these before running the command, and clients use them
+// to ask for the arguments in a form.
+type CommandArg struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type,omitempty"` // one of the Arg* types; empty means ArgString
+	Required    bool     `json:"required,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+	// Rest takes all remaining words, so "say hello world" needs no quotes.
+	// Only the last argument may set it.
+	Rest bool `json:"rest,omitempty"`
+}
+
+// validateArgSchema checks that a command's declared arguments make sense:
+// named, of a known type, with required arguments before optional ones
+func validateArgSchema(cmd PluginCommand) error {
+	seen := make(map[string]bool)
+	optional := false
+	for i, arg := range cmd.Args {
+		if arg.Name == "" {
+			return fmt.Errorf("command %s: argument %d has no name", cmd.Name, i+1)
+		}
+		if seen[arg.Name] {
+			return fmt.Errorf("command %s: argument %s is declared twice", cmd.Name, arg.Name)
+		}
+		seen[arg.Name] = true
+		switch arg.Type {
+		case "", ArgString, ArgInt, ArgNumber, ArgBool, ArgDuration:
+		default:
+			return fmt.Errorf("command %s: argument %s has unknown type %q", cmd.Name, arg.Name, arg.Type)
+		}
+		if arg.Required && optional {
+			return fmt.Errorf("command %s: required argument %s follows an optional one", cmd.Name, arg.Name)
+		}
+		optional = !arg.Required
+		if arg.Rest && i != len(cmd.Args)-1 {
+			return fmt.Errorf("command %s: only the last argument can take the rest", cmd.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateArgs checks args against the command's declared arguments.
+// Commands that declare none accept anything.
+func (c PluginCommand) ValidateArgs(args []string) error {
+	if len(c.Args) == 0 {
+		return nil
+	}
+	for i, arg := range c.Args {
+		if i >= len(args) {
+			if arg.Required {
+				return fmt.Errorf("missing %s", arg.Name)
+			}
+			return nil
+		}
+		value := args[i]
+		if arg.Rest {
+			value = strings.Join(args[i:], " ")
+		}
+		if err := arg.check(value); err != nil {
+			return err
+		}
+		if arg.Rest {
+			return nil
+		}
+	}
+	if len(args) > len(c.Args) {
+		return fmt.Errorf("too many arguments: expected at most %d", len(c.Args))
+	}
+	return nil
+}
+
+// check reports whether value suits the argument's type and choices
+func (a CommandArg) check(value string) error {
+	if value == "" {
+		if a.Required {
+			return fmt.Errorf("missing %s", a.Name)
+		}
+		return nil
+	}
+	var err error
+	switch a.Type {
+	case ArgInt:
+		_, err = strconv.Atoi(value)
+	case ArgNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case ArgBool:
+		_, err = strconv.ParseBool(value)
+	case ArgDuration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s must be a %s, got %q", a.Name, a.typeName(), value)
+	}
+	if len(a.Choices) > 0 {
+		for _, choice := range a.Choices {
+			if value == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s", a.Name, strings.Join(a.Choices, ", "))
+	}
+	return nil
+}
+
+// typeName describes the argument's type for error messages
+func (a CommandArg) typeName() string {
+	switch a.Type {
+	case ArgInt:
+		return "whole number"
+	case ArgNumber:
+		return "number"
+	case ArgBool:
+		return "true or false"
+	case ArgDuration:
+		return "duration like 10m"
+	}
+	return "string"
+}
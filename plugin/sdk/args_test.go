@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateArgs(t *testing.T) {
+	cmd := PluginCommand{
+		Name: "remind",
+		Args: []CommandArg{
+			{Name: "user", Required: true},
+			{Name: "delay", Type: ArgDuration, Required: true},
+			{Name: "priority", Choices: []string{"low", "high"}},
+			{Name: "note", Rest: true},
+		},
+	}
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"bob", "10m"}, ""},
+		{[]string{"bob", "10m", "high", "call", "the", "vet"}, ""},
+		{nil, "missing user"},
+		{[]string{"bob"}, "missing delay"},
+		{[]string{"bob", "soon"}, "delay must be a duration"},
+		{[]string{"bob", "10m", "urgent"}, "priority must be one of low, high"},
+	}
+	for _, tt := range tests {
+		err := cmd.ValidateArgs(tt.args)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateArgs(%q) = %v, want nil", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateArgs(%q) = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+
+	count := PluginCommand{Name: "roll", Args: []CommandArg{{Name: "sides", Type: ArgInt}}}
+	if err := count.ValidateArgs([]string{"6", "extra"}); err == nil || !strings.Contains(err.Error(), "too many") {
+		t.Errorf("Expected too many arguments, got %v", err)
+	}
+	if err := (PluginCommand{Name: "free"}).ValidateArgs([]string{"anything", "goes"}); err != nil {
+		t.Errorf("Commands without a schema should accept any arguments, got %v", err)
+	}
+}
+
+func TestValidateManifestArgSchema(t *testing.T) {
+	base := PluginManifest{Name: "p", Version: "1.0.0", Description: "d", Author: "a", License: "MIT"}
+	bad := map[string][]CommandArg{
+		"no name":                   {{Type: ArgInt}},
+		"unknown type":              {{Name: "n", Type: "float"}},
+		"required follows optional": {{Name: "a"}, {Name: "b", Required: true}},
+		"rest before last":          {{Name: "a", Rest: true}, {Name: "b"}},
+		"duplicate name":            {{Name: "a"}, {Name: "a"}},
+	}
+	for name, args := range bad {
+		m := base
+		m.Commands = []PluginCommand{{Name: "cmd", Args: args}}
+		if err := ValidateManifest(&m); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	m := base
+	m.Commands = []PluginCommand{{Name: "cmd", Args: []CommandArg{{Name: "a", Required: true}, {Name: "b", Rest: true}}}}
+	if err := ValidateManifest(&m); err != nil {
+		t.Errorf("Valid schema rejected: %v", err)
+	}
+}
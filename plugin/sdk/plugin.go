@@ -43,6 +43,8 @@ type PluginCommand struct {
 	Description string `json:"description"`
 	Usage       string `json:"usage"`
 	AdminOnly   bool   `json:"admin_only"`
+	// Args, if declared, are checked before the command runs
+	Args []CommandArg `json:"args,omitempty"`
 }
 
 // PluginManifest contains metadata about a plugin
@@ -161,5 +163,10 @@ func ValidateManifest(manifest *PluginManifest) error {
 	if manifest.License == "" {
 		return fmt.Errorf("plugin license is required")
 	}
+	for _, cmd := range manifest.Commands {
+		if err := validateArgSchema(cmd); err != nil {
+			return err
+		}
+	}
 	return nil
 }
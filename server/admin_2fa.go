@@ -0,0 +1,285 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Two-factor authentication for the web admin panel: an RFC 6238 TOTP secret
+// (30 second steps, 6 digits, SHA-1, as every authenticator app expects) and
+// single-use recovery codes, kept in CONFIG_DIR/web_admin_2fa.json. Deleting
+// that file turns 2FA off again for an admin locked out of the panel.
+
+const (
+	totpPeriod         = 30
+	totpDigits         = 6
+	totpSkew           = 1 // steps either side of now that are accepted
+	recoveryCodeCount  = 10
+	twoFactorSetupTTL  = 10 * time.Minute
+	twoFactorStateFile = "web_admin_2fa.json"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// twoFactorState is what is persisted once 2FA is enabled
+type twoFactorState struct {
+	Secret        string    `json:"secret"`         // base32, unpadded
+	RecoveryCodes []string  `json:"recovery_codes"` // sha256 of the unused codes
+	LastStep      int64     `json:"last_step"`      // newest step used, so a code works once
+	EnabledAt     time.Time `json:"enabled_at"`
+}
+
+// twoFactor guards the web admin login with a TOTP code when enabled
+type twoFactor struct {
+	mu      sync.Mutex
+	path    string
+	state   *twoFactorState // nil while 2FA is off
+	broken  bool            // the state file could not be read: refuse every code
+	pending string          // secret being set up, not yet confirmed
+	since   time.Time       // when pending was generated
+}
+
+// loadTwoFactor reads the 2FA state from path. An unreadable file fails
+// closed: 2FA stays on and no code is accepted until the file is fixed or
+// removed.
+func loadTwoFactor(path string) (*twoFactor, error) {
+	t := &twoFactor{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err == nil {
+		var state twoFactorState
+		if err = json.Unmarshal(data, &state); err == nil {
+			if _, err = totpEncoding.DecodeString(state.Secret); err == nil {
+				t.state = &state
+				return t, nil
+			}
+		}
+	}
+	t.broken = true
+	return t, fmt.Errorf("cannot read %s: %w", path, err)
+}
+
+// Enabled reports whether logins need a second factor
+func (t *twoFactor) Enabled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state != nil || t.broken
+}
+
+// RecoveryCodesLeft returns how many unused recovery codes remain
+func (t *twoFactor) RecoveryCodesLeft() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state == nil {
+		return 0
+	}
+	return len(t.state.RecoveryCodes)
+}
+
+// Begin generates a new secret to be confirmed with a code from the
+// authenticator app, returning it with its otpauth:// provisioning URI
+func (t *twoFactor) Begin(issuer, account string, now time.Time) (secret, uri string, err error) {
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	secret = totpEncoding.EncodeToString(raw)
+
+	t.mu.Lock()
+	t.pending, t.since = secret, now
+	t.mu.Unlock()
+
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return secret, "otpauth://totp/" + label + "?" + q.Encode(), nil
+}
+
+// Confirm enables 2FA with the pending secret once code proves the app has
+// it, returning the recovery codes to show the admin once
+func (t *twoFactor) Confirm(code string, now time.Time) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == "" || now.Sub(t.since) > twoFactorSetupTTL {
+		return nil, errors.New("no setup in progress, start again")
+	}
+	step, ok := matchTOTP(t.pending, code, now, 0)
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	state := &twoFactorState{Secret: t.pending, RecoveryCodes: hashes, LastStep: step, EnabledAt: now}
+	if err := t.save(state); err != nil {
+		return nil, err
+	}
+	t.state, t.broken, t.pending = state, false, ""
+	return codes, nil
+}
+
+// Verify checks a TOTP code, or failing that a recovery code, which is then
+// used up. It reports whether a recovery code was used.
+func (t *twoFactor) Verify(code string, now time.Time) (recovery, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state == nil {
+		return false, false
+	}
+	if step, ok := matchTOTP(t.state.Secret, code, now, t.state.LastStep); ok {
+		updated := *t.state
+		updated.LastStep = step
+		if t.save(&updated) == nil {
+			t.state = &updated
+		}
+		return false, true
+	}
+
+	hash := recoveryCodeHash(code)
+	for i, h := range t.state.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			updated := *t.state
+			updated.RecoveryCodes = append(append([]string(nil), t.state.RecoveryCodes[:i]...), t.state.RecoveryCodes[i+1:]...)
+			if t.save(&updated) != nil {
+				return false, false // a code that cannot be used up must not work
+			}
+			t.state = &updated
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// Disable turns 2FA off after checking a current code
+func (t *twoFactor) Disable(code string, now time.Time) error {
+	if _, ok := t.Verify(code, now); !ok {
+		return errors.New("invalid code")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.Remove(t.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	t.state = nil
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after checking a
+// current code
+func (t *twoFactor) RegenerateRecoveryCodes(code string, now time.Time) ([]string, error) {
+	if _, ok := t.Verify(code, now); !ok {
+		return nil, errors.New("invalid code")
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	updated := *t.state
+	updated.RecoveryCodes = hashes
+	if err := t.save(&updated); err != nil {
+		return nil, err
+	}
+	t.state = &updated
+	return codes, nil
+}
+
+// save writes state readable only by the server's user
+func (t *twoFactor) save(state *twoFactorState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0700); err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// totpCode is the RFC 6238 code of secret for a time step
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP finds the step within the allowed skew whose code is code,
+// ignoring steps up to and including lastStep so a code cannot be replayed
+func matchTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// newRecoveryCodes returns fresh codes formatted for the admin and their hashes
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		s := strings.ToLower(totpEncoding.EncodeToString(raw)) // 8 characters
+		code := s[:4] + "-" + s[4:]
+		codes = append(codes, code)
+		hashes = append(hashes, recoveryCodeHash(code))
+	}
+	return codes, hashes, nil
+}
+
+// recoveryCodeHash normalises a recovery code as typed and hashes it
+func recoveryCodeHash(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
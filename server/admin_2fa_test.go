@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B SHA-1 vectors, truncated to 6 digits
+	secret := []byte("12345678901234567890")
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		if got := totpCode(secret, tc.unix/totpPeriod); got != tc.want {
+			t.Errorf("totpCode at %d = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+// currentCode returns the code an authenticator app would show for secret
+func currentCode(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	return totpCode(key, now.Unix()/totpPeriod)
+}
+
+func TestTwoFactorLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), twoFactorStateFile)
+	tf, err := loadTwoFactor(path)
+	if err != nil || tf.Enabled() {
+		t.Fatalf("fresh state: enabled=%v err=%v", tf.Enabled(), err)
+	}
+
+	now := time.Unix(1_800_000_000, 0)
+	secret, uri, err := tf.Begin("marchat", "admin@host", now)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if !bytes.Contains([]byte(uri), []byte("secret="+secret)) {
+		t.Errorf("provisioning URI %q lacks the secret", uri)
+	}
+	if _, err := tf.Confirm("not-a-code", now); err == nil {
+		t.Fatal("Confirm accepted a wrong code")
+	}
+	codes, err := tf.Confirm(currentCode(t, secret, now), now)
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || !tf.Enabled() {
+		t.Fatalf("got %d recovery codes, enabled=%v", len(codes), tf.Enabled())
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("state file: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("state file mode %v is readable by others", info.Mode().Perm())
+	}
+
+	// The confirming code cannot be replayed; the next step's code works
+	if _, ok := tf.Verify(currentCode(t, secret, now), now); ok {
+		t.Error("replayed code accepted")
+	}
+	later := now.Add(totpPeriod * time.Second)
+	if recovery, ok := tf.Verify(currentCode(t, secret, later), later); !ok || recovery {
+		t.Errorf("next code: ok=%v recovery=%v", ok, recovery)
+	}
+
+	// Recovery codes work once, in any case and without the dash
+	typed := "  " + string(bytes.ToUpper([]byte(codes[0][:4]))) + codes[0][5:] + " "
+	if recovery, ok := tf.Verify(typed, later); !ok || !recovery {
+		t.Fatalf("recovery code: ok=%v recovery=%v", ok, recovery)
+	}
+	if _, ok := tf.Verify(codes[0], later); ok {
+		t.Error("recovery code accepted twice")
+	}
+
+	// State survives a restart
+	reloaded, err := loadTwoFactor(path)
+	if err != nil || !reloaded.Enabled() || reloaded.RecoveryCodesLeft() != recoveryCodeCount-1 {
+		t.Fatalf("reloaded: enabled=%v left=%d err=%v", reloaded.Enabled(), reloaded.RecoveryCodesLeft(), err)
+	}
+
+	if err := reloaded.Disable(codes[1], later); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if reloaded.Enabled() {
+		t.Error("still enabled after Disable")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("state file not removed: %v", err)
+	}
+}
+
+func TestTwoFactorCorruptStateFailsClosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), twoFactorStateFile)
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tf, err := loadTwoFactor(path)
+	if err == nil {
+		t.Fatal("expected an error for a corrupt state file")
+	}
+	if !tf.Enabled() {
+		t.Fatal("corrupt state must keep 2FA enabled")
+	}
+	if _, ok := tf.Verify("123456", time.Now()); ok {
+		t.Fatal("corrupt state accepted a code")
+	}
+}
+
+func TestAdminWeb_TwoFactorLogin(t *testing.T) {
+	_, hub, cfg, cleanup := setupTestServerEnv(t)
+	defer cleanup()
+
+	was := NewWebAdminServer(hub, nil, cfg)
+	now := time.Now()
+	secret, _, err := was.twoFactorFor("").Begin("marchat", "admin", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := was.twoFactorFor("").Confirm(currentCode(t, secret, now), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(ip, key, code string) (int, map[string]interface{}, bool) {
+		body, _ := json.Marshal(loginRequest{Key: key, Code: code})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/login", bytes.NewReader(body))
+		req.RemoteAddr = ip
+		rec := httptest.NewRecorder()
+		was.handleLogin(rec, req)
+		var result map[string]interface{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &result)
+		session := false
+		for _, c := range rec.Result().Cookies() {
+			session = session || c.Name == "admin_session"
+		}
+		return rec.Code, result, session
+	}
+
+	// The key alone asks for a code and does not count as a failure
+	for i := 0; i < 6; i++ {
+		_, result, session := login("10.0.0.1", cfg.AdminKey, "")
+		if result["two_factor_required"] != true || session {
+			t.Fatalf("key only: %v session=%v", result, session)
+		}
+	}
+
+	// Wrong codes lock the IP out like wrong keys
+	for i := 0; i < 5; i++ {
+		if _, result, _ := login("10.0.0.2", cfg.AdminKey, "not-a-code"); result["success"] != false {
+			t.Fatalf("wrong code accepted: %v", result)
+		}
+	}
+	if status, _, _ := login("10.0.0.2", cfg.AdminKey, codes[0]); status != http.StatusTooManyRequests {
+		t.Fatalf("expected lockout after wrong codes, got %d", status)
+	}
+
+	// A recovery code from another IP logs in
+	if _, result, session := login("10.0.0.3", cfg.AdminKey, codes[0]); result["success"] != true || !session {
+		t.Fatalf("recovery code login: %v session=%v", result, session)
+	}
+}
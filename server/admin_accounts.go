@@ -0,0 +1,324 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Named web admin accounts, kept in CONFIG_DIR/web_admins.json. While no
+// account exists the admin key signs in as an owner so the first accounts
+// can be created; after that only accounts can sign in, and every web action
+// is attributed to one. Deleting the file brings back admin key login.
+
+const (
+	adminAccountsFile    = "web_admins.json"
+	minAdminPasswordLen  = 10
+	adminKeyActor        = "web-admin" // actions taken from an admin key session
+	adminAccountActorPre = "web-admin:"
+)
+
+// Web admin roles, least privileged first
+const (
+	roleViewer    = "viewer"    // read-only
+	roleModerator = "moderator" // user moderation, filters and pairing
+	roleAdmin     = "admin"     // everything except accounts
+	roleOwner     = "owner"     // everything, including accounts
+)
+
+var roleRank = map[string]int{roleViewer: 1, roleModerator: 2, roleAdmin: 3, roleOwner: 4}
+
+var adminUsernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// adminAccount is one stored account
+type adminAccount struct {
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"` // bcrypt
+	Role         string    `json:"role"`
+	Generation   int       `json:"generation"` // bumped to end the account's sessions
+	CreatedAt    time.Time `json:"created_at"`
+	CreatedBy    string    `json:"created_by,omitempty"`
+	LastLogin    time.Time `json:"last_login,omitempty"`
+}
+
+// adminAccountInfo is an account as listed in the panel, without its hash
+type adminAccountInfo struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	LastLogin time.Time `json:"last_login,omitempty"`
+	TwoFactor bool      `json:"two_factor"`
+}
+
+// adminAccounts is the account store
+type adminAccounts struct {
+	mu       sync.Mutex
+	path     string
+	accounts map[string]*adminAccount
+	broken   bool // the file could not be read: nobody signs in, nothing is saved
+}
+
+// dummyHash is compared against for unknown usernames so they take as long
+// to reject as a wrong password
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("marchat-no-such-account"), bcrypt.DefaultCost)
+	return hash
+})
+
+// loadAdminAccounts reads the accounts at path; a missing file means none.
+// An unreadable file fails closed rather than bringing back admin key login.
+func loadAdminAccounts(path string) (*adminAccounts, error) {
+	a := &adminAccounts{path: path, accounts: make(map[string]*adminAccount)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	var list []*adminAccount
+	if err == nil {
+		err = json.Unmarshal(data, &list)
+	}
+	if err != nil {
+		a.broken = true
+		return a, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	for _, acct := range list {
+		a.accounts[acct.Username] = acct
+	}
+	return a, nil
+}
+
+// Empty reports whether no account exists yet
+func (a *adminAccounts) Empty() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.accounts) == 0 && !a.broken
+}
+
+// Authenticate checks a username and password, returning the account
+func (a *adminAccounts) Authenticate(username, password string) (adminAccount, bool) {
+	a.mu.Lock()
+	acct, ok := a.accounts[username]
+	var hash []byte
+	if ok {
+		hash = []byte(acct.PasswordHash)
+	}
+	a.mu.Unlock()
+	if !ok {
+		hash = dummyHash()
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || !ok {
+		return adminAccount{}, false
+	}
+	return *acct, true
+}
+
+// RecordLogin notes when an account last signed in
+func (a *adminAccounts) RecordLogin(username string, at time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if acct, ok := a.accounts[username]; ok {
+		acct.LastLogin = at
+		_ = a.save()
+	}
+}
+
+// Session returns the role of a still-valid session for username. Sessions
+// end when the account is deleted, or its password or role changes.
+func (a *adminAccounts) Session(username string, generation int) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	acct, ok := a.accounts[username]
+	if !ok || acct.Generation != generation {
+		return "", false
+	}
+	return acct.Role, true
+}
+
+// List returns every account sorted by username
+func (a *adminAccounts) List() []adminAccountInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]adminAccountInfo, 0, len(a.accounts))
+	for _, acct := range a.accounts {
+		list = append(list, adminAccountInfo{
+			Username:  acct.Username,
+			Role:      acct.Role,
+			CreatedAt: acct.CreatedAt,
+			CreatedBy: acct.CreatedBy,
+			LastLogin: acct.LastLogin,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Username < list[j].Username })
+	return list
+}
+
+// Create adds an account
+func (a *adminAccounts) Create(username, password, role, createdBy string) error {
+	if !adminUsernamePattern.MatchString(username) {
+		return errors.New("usernames are 1-32 lowercase letters, digits, '.', '_' or '-'")
+	}
+	if _, ok := roleRank[role]; !ok {
+		return fmt.Errorf("unknown role: %s", role)
+	}
+	hash, err := hashAdminPassword(password)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, exists := a.accounts[username]; exists {
+		return fmt.Errorf("account %s already exists", username)
+	}
+	if len(a.accounts) == 0 && role != roleOwner {
+		return errors.New("the first account must be an owner")
+	}
+	a.accounts[username] = &adminAccount{
+		Username:     username,
+		PasswordHash: hash,
+		Role:         role,
+		CreatedAt:    time.Now(),
+		CreatedBy:    createdBy,
+	}
+	if err := a.save(); err != nil {
+		delete(a.accounts, username)
+		return err
+	}
+	return nil
+}
+
+// SetRole changes an account's role, ending its sessions
+func (a *adminAccounts) SetRole(username, role string) error {
+	if _, ok := roleRank[role]; !ok {
+		return fmt.Errorf("unknown role: %s", role)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	acct, ok := a.accounts[username]
+	if !ok {
+		return fmt.Errorf("no account %s", username)
+	}
+	if acct.Role == roleOwner && role != roleOwner && a.owners() == 1 {
+		return errors.New("cannot demote the last owner")
+	}
+	return a.update(acct, func(acct *adminAccount) {
+		acct.Role = role
+		acct.Generation++
+	})
+}
+
+// SetPassword changes an account's password, ending its sessions
+func (a *adminAccounts) SetPassword(username, password string) error {
+	hash, err := hashAdminPassword(password)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	acct, ok := a.accounts[username]
+	if !ok {
+		return fmt.Errorf("no account %s", username)
+	}
+	return a.update(acct, func(acct *adminAccount) {
+		acct.PasswordHash = hash
+		acct.Generation++
+	})
+}
+
+// Delete removes an account, ending its sessions
+func (a *adminAccounts) Delete(username string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	acct, ok := a.accounts[username]
+	if !ok {
+		return fmt.Errorf("no account %s", username)
+	}
+	if acct.Role == roleOwner && a.owners() == 1 {
+		return errors.New("cannot delete the last owner")
+	}
+	delete(a.accounts, username)
+	if err := a.save(); err != nil {
+		a.accounts[username] = acct
+		return err
+	}
+	return nil
+}
+
+// update applies change to acct and saves, undoing it if saving fails
+func (a *adminAccounts) update(acct *adminAccount, change func(*adminAccount)) error {
+	before := *acct
+	change(acct)
+	if err := a.save(); err != nil {
+		*acct = before
+		return err
+	}
+	return nil
+}
+
+func (a *adminAccounts) owners() int {
+	n := 0
+	for _, acct := range a.accounts {
+		if acct.Role == roleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+// save writes the accounts readable only by the server's user
+func (a *adminAccounts) save() error {
+	if a.broken {
+		return fmt.Errorf("%s could not be read, fix or remove it first", a.path)
+	}
+	list := make([]*adminAccount, 0, len(a.accounts))
+	for _, acct := range a.accounts {
+		list = append(list, acct)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Username < list[j].Username })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0700); err != nil {
+		return err
+	}
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.path)
+}
+
+func hashAdminPassword(password string) (string, error) {
+	if len(password) < minAdminPasswordLen {
+		return "", fmt.Errorf("passwords need at least %d characters", minAdminPasswordLen)
+	}
+	if len(password) > 72 {
+		return "", errors.New("passwords can be at most 72 bytes")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// roleAtLeast reports whether role grants everything min does
+func roleAtLeast(role, min string) bool {
+	return roleRank[role] >= roleRank[min]
+}
+
+// adminActor is how an account is named in logs and moderation records
+func adminActor(username string) string {
+	if username == "" {
+		return adminKeyActor
+	}
+	return adminAccountActorPre + username
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAdminAccounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), adminAccountsFile)
+	accounts, err := loadAdminAccounts(path)
+	if err != nil || !accounts.Empty() {
+		t.Fatalf("fresh store: empty=%v err=%v", accounts.Empty(), err)
+	}
+
+	if err := accounts.Create("alice", "correct horse battery", roleModerator, "web-admin"); err == nil {
+		t.Fatal("first account was not required to be an owner")
+	}
+	if err := accounts.Create("alice", "correct horse battery", roleOwner, "web-admin"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, bad := range []struct{ name, password, role string }{
+		{"Bob", "long enough password", roleViewer},
+		{"bob", "short", roleViewer},
+		{"bob", "long enough password", "root"},
+		{"alice", "long enough password", roleViewer},
+	} {
+		if err := accounts.Create(bad.name, bad.password, bad.role, "alice"); err == nil {
+			t.Errorf("Create(%q, %q, %q) succeeded", bad.name, bad.password, bad.role)
+		}
+	}
+	if err := accounts.Create("bob", "long enough password", roleViewer, "web-admin:alice"); err != nil {
+		t.Fatalf("Create bob: %v", err)
+	}
+
+	if _, ok := accounts.Authenticate("alice", "wrong password!"); ok {
+		t.Error("wrong password accepted")
+	}
+	if _, ok := accounts.Authenticate("nobody", "correct horse battery"); ok {
+		t.Error("unknown account accepted")
+	}
+	bob, ok := accounts.Authenticate("bob", "long enough password")
+	if !ok || bob.Role != roleViewer {
+		t.Fatalf("Authenticate bob: %+v %v", bob, ok)
+	}
+
+	// Role and password changes end existing sessions
+	if err := accounts.SetRole("bob", roleAdmin); err != nil {
+		t.Fatalf("SetRole: %v", err)
+	}
+	if _, ok := accounts.Session("bob", bob.Generation); ok {
+		t.Error("session survived a role change")
+	}
+	bob, _ = accounts.Authenticate("bob", "long enough password")
+	if role, ok := accounts.Session("bob", bob.Generation); !ok || role != roleAdmin {
+		t.Errorf("new session: role=%s ok=%v", role, ok)
+	}
+
+	// The last owner stays
+	if err := accounts.SetRole("alice", roleAdmin); err == nil {
+		t.Error("demoted the last owner")
+	}
+	if err := accounts.Delete("alice"); err == nil {
+		t.Error("deleted the last owner")
+	}
+
+	// Accounts survive a restart, without plaintext passwords
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.Contains(data, []byte("long enough password")) {
+		t.Fatalf("stored file: err=%v plaintext=%v", err, bytes.Contains(data, []byte("long enough password")))
+	}
+	reloaded, err := loadAdminAccounts(path)
+	if err != nil || len(reloaded.List()) != 2 {
+		t.Fatalf("reloaded: %v %v", reloaded.List(), err)
+	}
+	if err := reloaded.Delete("bob"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := reloaded.Session("bob", bob.Generation); ok {
+		t.Error("session survived deleting the account")
+	}
+}
+
+func TestAdminAccountsCorruptFileFailsClosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), adminAccountsFile)
+	if err := os.WriteFile(path, []byte("[{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := loadAdminAccounts(path)
+	if err == nil {
+		t.Fatal("expected an error for a corrupt accounts file")
+	}
+	if accounts.Empty() {
+		t.Fatal("corrupt accounts file brought back admin key login")
+	}
+	if err := accounts.Create("alice", "correct horse battery", roleOwner, ""); err == nil {
+		t.Fatal("created an account over a corrupt file")
+	}
+}
+
+// adminWebClient signs in to a test web admin server and calls its API
+type adminWebClient struct {
+	t      *testing.T
+	ts     *httptest.Server
+	cookie *http.Cookie
+	csrf   string
+}
+
+func (c *adminWebClient) login(body map[string]string) map[string]interface{} {
+	c.t.Helper()
+	data, _ := json.Marshal(body)
+	resp, err := c.ts.Client().Post(c.ts.URL+"/admin/api/login", "application/json", bytes.NewReader(data))
+	if err != nil {
+		c.t.Fatalf("login: %v", err)
+	}
+	defer resp.Body.Close()
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	c.cookie, c.csrf = nil, ""
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "admin_session" {
+			c.cookie = cookie
+		}
+	}
+	if c.cookie != nil {
+		var token struct {
+			Token string `json:"csrfToken"`
+		}
+		c.do(http.MethodGet, "csrf-token", nil, &token)
+		c.csrf = token.Token
+	}
+	return result
+}
+
+func (c *adminWebClient) do(method, endpoint string, body interface{}, out interface{}) int {
+	c.t.Helper()
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, c.ts.URL+"/admin/api/"+endpoint, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", c.csrf)
+	if c.cookie != nil {
+		req.AddCookie(c.cookie)
+	}
+	resp, err := c.ts.Client().Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, endpoint, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		_ = json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode
+}
+
+func TestAdminWeb_Accounts(t *testing.T) {
+	_, hub, cfg, cleanup := setupTestServerEnv(t)
+	defer cleanup()
+
+	was := NewWebAdminServer(hub, nil, cfg)
+	mux := http.NewServeMux()
+	was.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	// Bootstrap with the admin key and create the first owner
+	owner := &adminWebClient{t: t, ts: ts}
+	if result := owner.login(map[string]string{"key": cfg.AdminKey}); result["success"] != true {
+		t.Fatalf("key login: %v", result)
+	}
+	var res map[string]interface{}
+	owner.do(http.MethodPost, "action/account", map[string]string{
+		"action": "create", "username": "alice", "password": "correct horse battery", "role": roleOwner,
+	}, &res)
+	if res["success"] != true {
+		t.Fatalf("create owner: %v", res)
+	}
+
+	// The admin key no longer signs in
+	other := &adminWebClient{t: t, ts: ts}
+	if result := other.login(map[string]string{"key": cfg.AdminKey}); result["success"] == true {
+		t.Fatal("admin key still signs in once accounts exist")
+	}
+
+	if result := owner.login(map[string]string{"username": "alice", "password": "correct horse battery"}); result["role"] != roleOwner {
+		t.Fatalf("owner login: %v", result)
+	}
+	owner.do(http.MethodPost, "action/account", map[string]string{
+		"action": "create", "username": "bob", "password": "long enough password", "role": roleModerator,
+	}, &res)
+	if res["success"] != true {
+		t.Fatalf("create moderator: %v", res)
+	}
+
+	mod := &adminWebClient{t: t, ts: ts}
+	if result := mod.login(map[string]string{"username": "bob", "password": "long enough password"}); result["success"] != true {
+		t.Fatalf("moderator login: %v", result)
+	}
+	if actor := was.actor(requestWithCookie(mod.cookie)); actor != "web-admin:bob" {
+		t.Errorf("actor = %q", actor)
+	}
+
+	// Roles limit what each account can do
+	if status := mod.do(http.MethodPost, "action/user", map[string]string{"action": "kick", "username": "mallory"}, nil); status != http.StatusOK {
+		t.Errorf("moderator kick: %d", status)
+	}
+	if status := mod.do(http.MethodPost, "action/system", map[string]string{"action": "force_gc"}, nil); status != http.StatusForbidden {
+		t.Errorf("moderator system action: %d, want 403", status)
+	}
+	if status := mod.do(http.MethodGet, "accounts", nil, nil); status != http.StatusForbidden {
+		t.Errorf("moderator account list: %d, want 403", status)
+	}
+	var list []adminAccountInfo
+	if status := owner.do(http.MethodGet, "accounts", nil, &list); status != http.StatusOK || len(list) != 2 {
+		t.Fatalf("owner account list: %d %v", status, list)
+	}
+
+	// Deleting an account ends its session at once
+	owner.do(http.MethodPost, "action/account", map[string]string{"action": "delete", "username": "bob"}, &res)
+	if res["success"] != true {
+		t.Fatalf("delete: %v", res)
+	}
+	if status := mod.do(http.MethodGet, "overview", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("deleted account still signed in: %d", status)
+	}
+}
+
+func requestWithCookie(cookie *http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	r.AddCookie(cookie)
+	return r
+}
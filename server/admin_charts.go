@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sparkBlocks are the eighth-height bars a sparkline is drawn with
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as one row of bars at most width cells wide, scaled
+// from zero to the largest value. Longer series are squeezed by keeping the
+// peak of each group of points, so spikes stay visible.
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		squeezed := make([]float64, width)
+		for i := range squeezed {
+			from, to := i*len(values)/width, (i+1)*len(values)/width
+			for _, v := range values[from:to] {
+				squeezed[i] = max(squeezed[i], v)
+			}
+		}
+		values = squeezed
+	}
+
+	top := 0.0
+	for _, v := range values {
+		top = max(top, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if top > 0 && v > 0 {
+			level = min(int(v/top*float64(len(sparkBlocks)-1)+0.5), len(sparkBlocks)-1)
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// chartWidth is how many cells a Metrics tab sparkline gets beside its label
+// and current/peak values
+func (ap *AdminPanel) chartWidth() int {
+	return max(10, ap.width-12-40)
+}
+
+// writeSparkline writes one labelled chart row with its latest and peak value
+func (ap *AdminPanel) writeSparkline(doc *strings.Builder, label string, values []float64, format func(float64) string) {
+	if len(values) == 0 {
+		return
+	}
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	doc.WriteString(fmt.Sprintf("  %-12s %s  now %s  peak %s\n",
+		label, metricValueStyle.Render(sparkline(values, ap.chartWidth())),
+		format(values[len(values)-1]), format(peak)))
+}
+
+// messageRates turns the cumulative message counts into messages per second
+// between samples
+func messageRates(points []messagePoint) []float64 {
+	var rates []float64
+	for i := 1; i < len(points); i++ {
+		secs := points[i].Time.Sub(points[i-1].Time).Seconds()
+		rate := 0.0
+		if secs > 0 {
+			rate = max(0, float64(points[i].Count-points[i-1].Count)/secs)
+		}
+		rates = append(rates, rate)
+	}
+	return rates
+}
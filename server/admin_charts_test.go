@@ -0,0 +1,40 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 1, 2, 4, 8}, 20); got != "▁▂▃▅█" {
+		t.Errorf("sparkline = %q", got)
+	}
+	if got := sparkline([]float64{0, 0, 0}, 20); got != "▁▁▁" {
+		t.Errorf("flat sparkline = %q", got)
+	}
+	if got := sparkline(nil, 20); got != "" {
+		t.Errorf("empty sparkline = %q", got)
+	}
+
+	// A long series fits the width and keeps its spike
+	values := make([]float64, 100)
+	values[57] = 10
+	got := sparkline(values, 10)
+	if utf8.RuneCountInString(got) != 10 || strings.Count(got, "█") != 1 || !strings.HasPrefix(got, "▁▁▁▁▁█") {
+		t.Errorf("squeezed sparkline = %q", got)
+	}
+}
+
+func TestMessageRates(t *testing.T) {
+	now := time.Now()
+	rates := messageRates([]messagePoint{
+		{Time: now, Count: 10},
+		{Time: now.Add(2 * time.Second), Count: 14},
+		{Time: now.Add(3 * time.Second), Count: 0}, // messages cleared
+	})
+	if len(rates) != 2 || rates[0] != 2 || rates[1] != 0 {
+		t.Errorf("rates = %v", rates)
+	}
+}
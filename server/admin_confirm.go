@@ -0,0 +1,64 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// confirmDialog holds a destructive admin panel action until the operator
+// confirms it with y or backs out with n/esc
+type confirmDialog struct {
+	title     string         // the action, e.g. "Ban user"
+	target    string         // what it applies to
+	details   string         // what will happen
+	onConfirm func() tea.Cmd // runs the action
+}
+
+var confirmBoxStyle = lipgloss.NewStyle().
+	Border(lipgloss.ThickBorder()).
+	BorderForeground(errorColor).
+	Padding(1, 3)
+
+// askConfirm shows a confirmation dialog in place of the tab content
+func (ap *AdminPanel) askConfirm(title, target, details string, onConfirm func() tea.Cmd) {
+	ap.pairing = ""
+	ap.confirm = &confirmDialog{
+		title:     title,
+		target:    target,
+		details:   details,
+		onConfirm: onConfirm,
+	}
+}
+
+// handleConfirmKey answers the open dialog; other keys are swallowed so a
+// stray keypress cannot act on the table behind it
+func (ap *AdminPanel) handleConfirmKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, ap.keys.Confirm):
+		dialog := ap.confirm
+		ap.confirm = nil
+		return dialog.onConfirm()
+	case key.Matches(msg, ap.keys.Cancel):
+		ap.message = "↩️ " + ap.confirm.title + " cancelled"
+		ap.messageTimer = 3
+		ap.confirm = nil
+	}
+	return nil
+}
+
+// renderConfirm draws the open dialog
+func (ap *AdminPanel) renderConfirm() string {
+	lines := []string{
+		errorStylePanel.Render("⚠️  " + ap.confirm.title + "?"),
+		"",
+		metricLabelStyle.Render("Target: ") + metricValueStyle.Render(ap.confirm.target),
+	}
+	if ap.confirm.details != "" {
+		lines = append(lines, "", ap.confirm.details)
+	}
+	lines = append(lines, "", statusStyle.Render("[y] confirm")+"   "+warningStylePanel.Render("[n/esc] cancel"))
+	return confirmBoxStyle.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// crowdedGroup is how many sockets from one IP or subnet the Connections tab
+// highlights
+const crowdedGroup = 3
+
+// trafficSample is a connection's byte counters at the previous refresh
+type trafficSample struct {
+	bytesIn  int64
+	bytesOut int64
+	at       time.Time
+}
+
+// loadConnections snapshots every connected socket with its traffic and the
+// rate since the previous refresh
+func (ap *AdminPanel) loadConnections() {
+	now := time.Now()
+	prev := ap.connSamples
+	ap.connSamples = make(map[*Client]trafficSample, ap.hub.clients.len())
+	ap.connections = ap.connections[:0]
+	for client := range ap.hub.clients.all() {
+		c := connectionInfo{
+			Username:    client.username,
+			IP:          client.ipAddr,
+			SessionID:   client.sessionID,
+			ConnectedAt: client.connectedAt,
+			Admin:       client.isAdmin,
+			ReadOnly:    client.readOnly,
+			FramesIn:    client.traffic.framesIn.Load(),
+			FramesOut:   client.traffic.framesOut.Load(),
+			BytesIn:     client.traffic.bytesIn.Load(),
+			BytesOut:    client.traffic.bytesOut.Load(),
+		}
+		if p, ok := prev[client]; ok {
+			if secs := now.Sub(p.at).Seconds(); secs > 0 {
+				c.RateIn = float64(c.BytesIn-p.bytesIn) / secs
+				c.RateOut = float64(c.BytesOut-p.bytesOut) / secs
+			}
+		}
+		ap.connSamples[client] = trafficSample{bytesIn: c.BytesIn, bytesOut: c.BytesOut, at: now}
+		ap.connections = append(ap.connections, c)
+	}
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n float64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", n/1024/1024)
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", n/1024)
+	default:
+		return fmt.Sprintf("%.0f B", n)
+	}
+}
+
+func (ap *AdminPanel) renderConnections() string {
+	doc := strings.Builder{}
+
+	contentWidth := ap.width - 12
+	if contentWidth < 30 {
+		contentWidth = 30
+	}
+
+	doc.WriteString(subtitleStyle.Width(contentWidth).Render("Connections\n"))
+	doc.WriteString(strings.Repeat("─", min(20, contentWidth-2)) + "\n")
+
+	grouping := "IP address"
+	if ap.connBySubnet {
+		grouping = "subnet (/24, /64)"
+	}
+	groups := groupConnections(ap.connections, ap.connBySubnet)
+	doc.WriteString(fmt.Sprintf("%d sockets from %d groups, grouped by %s   [g] toggle grouping\n\n",
+		len(ap.connections), len(groups), grouping))
+
+	if len(groups) == 0 {
+		doc.WriteString("No clients connected.\n")
+	}
+	for _, g := range groups {
+		header := fmt.Sprintf("%s  %d sockets, %d users  ↓ %s/s ↑ %s/s  (%d msgs, %s in / %s out)",
+			g.Key, len(g.Connections), g.Users,
+			formatBytes(g.RateIn), formatBytes(g.RateOut),
+			g.FramesIn, formatBytes(float64(g.BytesIn)), formatBytes(float64(g.BytesOut)))
+		if len(g.Connections) >= crowdedGroup {
+			header = warningStylePanel.Render("⚠ " + header)
+		} else {
+			header = metricValueStyle.Render(header)
+		}
+		doc.WriteString(header + "\n")
+
+		for _, c := range g.Connections {
+			name := c.Username
+			switch {
+			case c.Admin:
+				name += " (admin)"
+			case c.ReadOnly:
+				name += " (spectator)"
+			}
+			ip := ""
+			if ap.connBySubnet {
+				ip = " " + c.IP
+			}
+			doc.WriteString(fmt.Sprintf("  %-22s%s  %s  up %s  ↓ %d msgs %s (%s/s)  ↑ %d msgs %s (%s/s)\n",
+				name, ip, c.SessionID, formatDuration(time.Since(c.ConnectedAt)),
+				c.FramesIn, formatBytes(float64(c.BytesIn)), formatBytes(c.RateIn),
+				c.FramesOut, formatBytes(float64(c.BytesOut)), formatBytes(c.RateOut)))
+		}
+		doc.WriteString("\n")
+	}
+
+	return ap.renderScrollableContent(doc.String(), ap.connectionsScroll)
+}
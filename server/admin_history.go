@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// refreshHistory reloads the history range once a minute, when a new bucket
+// may have been stored
+func (ap *AdminPanel) refreshHistory() {
+	if time.Since(ap.historyLoaded) >= time.Minute {
+		ap.loadHistory()
+	}
+}
+
+// loadHistory reads the stored metrics for the Metrics tab's history range
+func (ap *AdminPanel) loadHistory() {
+	ap.historyLoaded = time.Now()
+	rollups, err := ap.hub.MetricsHistory(metricsRangeNames[ap.historyRange], time.Now())
+	if err != nil {
+		log.Printf("Warning: failed to load metrics history: %v", err)
+		return
+	}
+	ap.history = rollups
+}
+
+// renderHistory writes the totals and peaks of the stored history range
+func (ap *AdminPanel) renderHistory(doc *strings.Builder) {
+	name := metricsRangeNames[ap.historyRange]
+	doc.WriteString(metricLabelStyle.Render(fmt.Sprintf("History (last %s, [t] change):\n", name)))
+	if len(ap.history) == 0 {
+		doc.WriteString("  No history recorded yet\n")
+		return
+	}
+	var peakUsers, conns, messages int
+	var peakMemory uint64
+	for _, b := range ap.history {
+		peakUsers = max(peakUsers, b.PeakUsers)
+		conns += b.Connections
+		messages += b.Messages
+		peakMemory = max(peakMemory, b.PeakMemory)
+	}
+	doc.WriteString(fmt.Sprintf("Peak Users: %s | Connections: %s | Messages: %s | Peak Memory: %s\n",
+		metricValueStyle.Render(fmt.Sprintf("%d", peakUsers)),
+		metricValueStyle.Render(fmt.Sprintf("%d", conns)),
+		metricValueStyle.Render(fmt.Sprintf("%d", messages)),
+		metricValueStyle.Render(fmt.Sprintf("%.1f MB", float64(peakMemory)/1024/1024))))
+	doc.WriteString(fmt.Sprintf("  %d %s buckets since %s\n",
+		len(ap.history), metricsRanges[name].resolution, ap.history[0].Bucket.Format("Jan 2 15:04")))
+
+	users := make([]float64, len(ap.history))
+	posted := make([]float64, len(ap.history))
+	memory := make([]float64, len(ap.history))
+	for i, b := range ap.history {
+		users[i] = float64(b.PeakUsers)
+		posted[i] = float64(b.Messages)
+		memory[i] = float64(b.PeakMemory)
+	}
+	count := func(v float64) string { return fmt.Sprintf("%.0f", v) }
+	ap.writeSparkline(doc, "Users", users, count)
+	ap.writeSparkline(doc, "Messages", posted, count)
+	ap.writeSparkline(doc, "Memory", memory, formatBytes)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// panelLogLimit matches the global log buffer so the Logs tab keeps as much
+// history as a fresh panel starts with
+const panelLogLimit = 200
+
+// logLevels in increasing severity; the level filter shows one and above
+var logLevels = []LogLevel{LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError}
+
+// logTail follows the global log buffer for the Logs tab
+type logTail struct {
+	updates   <-chan LogEntry
+	cancel    func()
+	minLevel  int    // index into logLevels
+	component string // empty shows every component
+	paused    bool
+	pending   []logEntry // arrived while paused, newest first
+}
+
+// logMsg carries one new entry from the log buffer
+type logMsg logEntry
+
+func toPanelLog(e LogEntry) logEntry {
+	return logEntry{
+		Timestamp: e.Timestamp,
+		Level:     string(e.Level),
+		Message:   e.Message,
+		User:      e.UserID,
+		Component: e.Component,
+	}
+}
+
+// startLogTail loads the buffered logs and subscribes to new ones
+func (ap *AdminPanel) startLogTail() {
+	entries, updates, cancel := GetLogBuffer().Subscribe()
+	ap.logs = make([]logEntry, 0, len(entries))
+	for _, e := range entries {
+		ap.logs = append(ap.logs, toPanelLog(e))
+	}
+	ap.logTail.updates = updates
+	ap.logTail.cancel = cancel
+}
+
+// Close stops following the server logs; call it once the panel has exited
+func (ap *AdminPanel) Close() {
+	if ap.logTail.cancel != nil {
+		ap.logTail.cancel()
+	}
+}
+
+// waitForLog delivers the next log entry to Update
+func (ap *AdminPanel) waitForLog() tea.Cmd {
+	updates := ap.logTail.updates
+	if updates == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		e, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return logMsg(toPanelLog(e))
+	}
+}
+
+// receiveLog adds a live entry, holding it back while the tail is paused
+func (ap *AdminPanel) receiveLog(e logEntry) {
+	if ap.logTail.paused {
+		ap.logTail.pending = prependLog(ap.logTail.pending, e)
+		return
+	}
+	ap.logs = prependLog(ap.logs, e)
+	// Keep the lines being read in place when scrolled away from the top
+	if ap.logsScroll > 0 && ap.logVisible(e) {
+		ap.logsScroll++
+	}
+}
+
+func prependLog(logs []logEntry, e logEntry) []logEntry {
+	logs = append([]logEntry{e}, logs...)
+	if len(logs) > panelLogLimit {
+		logs = logs[:panelLogLimit]
+	}
+	return logs
+}
+
+// logVisible reports whether e passes the level and component filters
+func (ap *AdminPanel) logVisible(e logEntry) bool {
+	if ap.logTail.component != "" && e.Component != ap.logTail.component {
+		return false
+	}
+	for i, level := range logLevels {
+		if string(level) == e.Level {
+			return i >= ap.logTail.minLevel
+		}
+	}
+	return true // unknown levels are never hidden
+}
+
+// visibleLogs returns the entries the Logs tab shows, newest first
+func (ap *AdminPanel) visibleLogs() []logEntry {
+	visible := make([]logEntry, 0, len(ap.logs))
+	for _, e := range ap.logs {
+		if ap.logVisible(e) {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
+
+// logComponents lists the components seen in the logs, sorted
+func (ap *AdminPanel) logComponents() []string {
+	seen := make(map[string]bool)
+	for _, e := range ap.logs {
+		seen[e.Component] = true
+	}
+	components := make([]string, 0, len(seen))
+	for c := range seen {
+		components = append(components, c)
+	}
+	sort.Strings(components)
+	return components
+}
+
+// handleLogKey applies the Logs tab's filter and pause keys, reporting
+// whether msg was one of them
+func (ap *AdminPanel) handleLogKey(msg tea.KeyMsg) bool {
+	t := &ap.logTail
+	switch {
+	case key.Matches(msg, ap.keys.LogLevel):
+		t.minLevel = (t.minLevel + 1) % len(logLevels)
+	case key.Matches(msg, ap.keys.LogComponent):
+		// Cycle through every component, then back to all of them
+		components := ap.logComponents()
+		next := ""
+		for i, c := range components {
+			if t.component == "" {
+				next = c
+				break
+			}
+			if c == t.component {
+				if i+1 < len(components) {
+					next = components[i+1]
+				}
+				break
+			}
+		}
+		t.component = next
+	case key.Matches(msg, ap.keys.PauseLogs):
+		t.paused = !t.paused
+		if !t.paused {
+			for i := len(t.pending) - 1; i >= 0; i-- {
+				ap.logs = prependLog(ap.logs, t.pending[i])
+			}
+			t.pending = nil
+		}
+	default:
+		return false
+	}
+	ap.logsScroll = 0
+	return true
+}
+
+// logTailStatus summarises the Logs tab filters above the entries
+func (ap *AdminPanel) logTailStatus() string {
+	t := ap.logTail
+	component := t.component
+	if component == "" {
+		component = "all"
+	}
+	state := "▶ live"
+	if t.paused {
+		state = fmt.Sprintf("⏸ paused (%d new)", len(t.pending))
+	}
+	return fmt.Sprintf("%s   Level [l]: %s+   Component [C]: %s   [p] pause/resume",
+		state, logLevels[t.minLevel], component)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// noticeEditor is the System tab's one-line editor for the room's topic or
+// the message of the day: enter saves and esc discards
+type noticeEditor struct {
+	motd  bool // editing the MOTD rather than the topic
+	input textinput.Model
+}
+
+func newNoticeEditor(motd bool, value string, width int) *noticeEditor {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.CharLimit = maxTopicLen
+	input.Placeholder = "empty clears the topic"
+	if motd {
+		input.CharLimit = maxMOTDLen
+		input.Placeholder = "empty clears the message of the day"
+	}
+	input.Width = max(30, width-6)
+	input.SetValue(value)
+	input.Focus()
+	return &noticeEditor{motd: motd, input: input}
+}
+
+// handleNoticeKey handles the System tab's topic and MOTD keys, and every
+// key while the editor is open
+func (ap *AdminPanel) handleNoticeKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	e := ap.noticeEdit
+	if e == nil {
+		switch {
+		case key.Matches(msg, ap.keys.Topic):
+			ap.noticeEdit = newNoticeEditor(false, ap.hub.Topic(roomChannel).Text, ap.width-12)
+			return true, textinput.Blink
+		case key.Matches(msg, ap.keys.MOTD):
+			ap.noticeEdit = newNoticeEditor(true, ap.hub.MOTD().Text, ap.width-12)
+			return true, textinput.Blink
+		}
+		return false, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return false, nil
+	case "esc":
+		ap.noticeEdit = nil
+	case "enter":
+		ap.noticeEdit = nil
+		ap.saveNotice(e.motd, e.input.Value())
+	default:
+		var cmd tea.Cmd
+		e.input, cmd = e.input.Update(msg)
+		return true, cmd
+	}
+	return true, nil
+}
+
+func (ap *AdminPanel) saveNotice(motd bool, text string) {
+	var err error
+	what := "Topic"
+	if motd {
+		what = "Message of the day"
+		_, err = ap.hub.SetMOTD(text, "admin")
+	} else {
+		_, err = ap.hub.SetTopic(roomChannel, text, "admin")
+	}
+	if err != nil {
+		ap.message = fmt.Sprintf("❌ Could not save: %v", err)
+	} else if strings.TrimSpace(text) == "" {
+		ap.message = "📌 " + what + " cleared"
+	} else {
+		ap.message = "📌 " + what + " updated"
+	}
+	ap.messageTimer = 3
+}
+
+// renderNotices shows the room's topic and the message of the day on the
+// System tab, or the editor while it is open
+func (ap *AdminPanel) renderNotices() string {
+	var b strings.Builder
+	b.WriteString(subtitleStyle.Render("Topic and Message of the Day:\n"))
+	if e := ap.noticeEdit; e != nil {
+		label := "Topic"
+		if e.motd {
+			label = "Message of the day"
+		}
+		b.WriteString("  " + label + ":\n")
+		b.WriteString("  " + e.input.View() + "\n")
+		b.WriteString(infoStylePanel.Render("[enter] save, [esc] discard") + "\n")
+		return b.String()
+	}
+	orNone := func(s string) string {
+		if s == "" {
+			return "(none)"
+		}
+		return s
+	}
+	b.WriteString(fmt.Sprintf("  Topic: %s\n", orNone(ap.hub.Topic(roomChannel).Text)))
+	b.WriteString(fmt.Sprintf("  MOTD: %s\n", orNone(firstLine(ap.hub.MOTD().Text))))
+	return b.String()
+}
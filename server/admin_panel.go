@@ -26,6 +26,7 @@ type tabType int
 const (
 	tabOverview tabType = iota
 	tabUsers
+	tabConnections
 	tabSystem
 	tabLogs
 	tabPlugins
@@ -50,6 +51,7 @@ type userInfo struct {
 	IsAdmin     bool
 	IsBanned    bool
 	IsKicked    bool
+	IsMuted     bool
 }
 
 // System statistics
@@ -58,6 +60,7 @@ type systemStats struct {
 	MemoryUsage    float64
 	CPUUsage       float64
 	ActiveUsers    int
+	AwayUsers      int
 	TotalUsers     int
 	MessagesSent   int
 	PluginsActive  int
@@ -122,6 +125,8 @@ type AdminPanel struct {
 	metricsScroll  int
 	logsScroll     int
 
+	connectionsScroll int
+
 	// Data
 	users      []userInfo
 	plugins    []pluginInfo
@@ -130,6 +135,17 @@ type AdminPanel struct {
 	config     *config.Config
 	logs       []logEntry
 
+	connections  []connectionInfo
+	connSamples  map[*Client]trafficSample
+	connBySubnet bool // Connections tab groups by subnet rather than IP
+
+	usage     UsageDetail
+	usageList usageListing
+
+	history       []MetricsRollup // stored buckets for the Metrics tab's range
+	historyRange  int             // index into metricsRangeNames
+	historyLoaded time.Time
+
 	// Server integration
 	hub           *Hub
 	ServerLogger  *Logger
@@ -145,6 +161,13 @@ type AdminPanel struct {
 	selectedPlugin int
 	message        string
 	messageTimer   int
+	pairing        string         // QR code view shown instead of the tab content
+	confirm        *confirmDialog // destructive action waiting for y/n
+	userList       userListing    // Users tab search, filter, sort and page
+	logTail        logTail        // Logs tab live tail and filters
+	pluginDetail   *pluginDetail  // Plugins tab detail pane, nil for the list
+	welcomeEdit    *welcomeEditor // System tab welcome bot editor, nil when closed
+	noticeEdit     *noticeEditor  // System tab topic or MOTD editor, nil when closed
 
 	// Performance tracking
 	lastMessageCount int
@@ -169,6 +192,7 @@ type keyMap struct {
 	Ban          key.Binding
 	Unban        key.Binding
 	Kick         key.Binding
+	Mute         key.Binding
 	Allow        key.Binding
 	AddAdmin     key.Binding
 	Enable       key.Binding
@@ -180,6 +204,24 @@ type keyMap struct {
 	ExportLogs   key.Binding
 	ResetMetrics key.Binding
 	ForceGC      key.Binding
+	Pair         key.Binding
+	Confirm      key.Binding
+	Cancel       key.Binding
+	Search       key.Binding
+	Filter       key.Binding
+	Sort         key.Binding
+	SortOrder    key.Binding
+	NextPage     key.Binding
+	PrevPage     key.Binding
+	LogLevel     key.Binding
+	LogComponent key.Binding
+	PauseLogs    key.Binding
+	GroupConns   key.Binding
+	HistoryRange key.Binding
+	Welcome      key.Binding
+	WelcomeOnOff key.Binding
+	Topic        key.Binding
+	MOTD         key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -189,10 +231,12 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.TabNext, k.TabPrev, k.Refresh, k.Help},
-		{k.Action, k.Quit, k.ExportLogs, k.ForceGC},
-		{k.Ban, k.Unban, k.Kick, k.Allow, k.AddAdmin},
+		{k.Action, k.Quit, k.ExportLogs, k.ForceGC, k.Pair},
+		{k.Ban, k.Unban, k.Kick, k.Mute, k.Allow, k.AddAdmin},
+		{k.Search, k.Filter, k.Sort, k.SortOrder, k.PrevPage, k.NextPage},
+		{k.LogLevel, k.LogComponent, k.PauseLogs, k.GroupConns, k.HistoryRange},
 		{k.Enable, k.Disable, k.Install, k.Uninstall},
-		{k.ClearDB, k.BackupDB, k.ShowStats, k.ResetMetrics},
+		{k.ClearDB, k.BackupDB, k.ShowStats, k.ResetMetrics, k.Welcome, k.WelcomeOnOff, k.Topic, k.MOTD},
 	}
 }
 
@@ -314,6 +358,10 @@ func NewAdminPanel(hub *Hub, db *DatabaseWrapper, pluginManager *manager.PluginM
 			key.WithKeys("K"),
 			key.WithHelp("K", "kick user"),
 		),
+		Mute: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "shadow mute/unmute (1h)"),
+		),
 		Allow: key.NewBinding(
 			key.WithKeys("A"),
 			key.WithHelp("A", "allow user"),
@@ -358,6 +406,78 @@ func NewAdminPanel(hub *Hub, db *DatabaseWrapper, pluginManager *manager.PluginM
 			key.WithKeys("G"),
 			key.WithHelp("G", "force GC"),
 		),
+		Pair: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "pairing QR"),
+		),
+		Confirm: key.NewBinding(
+			key.WithKeys("y", "Y"),
+			key.WithHelp("y", "confirm"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("n", "N", "esc"),
+			key.WithHelp("n", "cancel"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search users"),
+		),
+		Filter: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "filter users"),
+		),
+		Sort: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "sort column"),
+		),
+		SortOrder: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "sort order"),
+		),
+		NextPage: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next page"),
+		),
+		PrevPage: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous page"),
+		),
+		LogLevel: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "log level"),
+		),
+		LogComponent: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "log component"),
+		),
+		PauseLogs: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause logs"),
+		),
+		GroupConns: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "group by IP/subnet"),
+		),
+		HistoryRange: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "history range"),
+		),
+		Welcome: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "edit welcome bot"),
+		),
+		WelcomeOnOff: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "toggle welcome bot"),
+		),
+		Topic: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "edit topic"),
+		),
+		MOTD: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "edit MOTD"),
+		),
 	}
 
 	// Initialize enhanced table
@@ -420,7 +540,7 @@ func NewAdminPanel(hub *Hub, db *DatabaseWrapper, pluginManager *manager.PluginM
 
 	panel := &AdminPanel{
 		activeTab:     tabOverview,
-		tabs:          []string{"Overview", "Users", "System", "Logs", "Plugins", "Metrics"},
+		tabs:          []string{"Overview", "Users", "Connections", "System", "Logs", "Plugins", "Metrics"},
 		help:          help.New(),
 		userTable:     t,
 		pluginTable:   pluginTable,
@@ -445,9 +565,13 @@ func NewAdminPanel(hub *Hub, db *DatabaseWrapper, pluginManager *manager.PluginM
 		},
 		selectedUser:   -1,
 		selectedPlugin: -1,
+		userList:       newUserListing(),
+		usageList:      usageListing{sort: "messages", desc: true},
+		historyRange:   1, // day
 	}
 
 	// Load initial data
+	panel.startLogTail()
 	panel.refreshData()
 
 	return panel
@@ -456,113 +580,21 @@ func NewAdminPanel(hub *Hub, db *DatabaseWrapper, pluginManager *manager.PluginM
 func (ap *AdminPanel) refreshData() {
 	// Load users from database and hub
 	ap.loadUsers()
+	// Snapshot connections and their traffic
+	ap.loadConnections()
 	// Load plugins
 	ap.loadPlugins()
-	// Load logs
-	ap.loadLogs()
 	// Update system stats
 	ap.updateSystemStats()
 	// Update metrics
 	ap.updateMetrics()
+	// Per-user and per-channel usage
+	ap.loadUsage()
+	ap.refreshHistory()
 	// Update user table
 	ap.updateUserTable()
 }
 
-func (ap *AdminPanel) loadUsers() {
-	// Get message counts per user
-	rows, err := ap.db.Query(`
-		SELECT sender, COUNT(*) as message_count 
-		FROM messages 
-		WHERE sender != 'System' 
-		GROUP BY sender
-	`)
-	if err != nil {
-		log.Printf("Error loading user message counts: %v", err)
-		return
-	}
-	defer rows.Close()
-
-	userMessages := make(map[string]int)
-	for rows.Next() {
-		var username string
-		var count int
-		if err := rows.Scan(&username, &count); err != nil {
-			continue
-		}
-		userMessages[username] = count
-	}
-
-	// Get connected users from hub
-	connectedUsers := make(map[string]*Client)
-	for client := range ap.hub.clients {
-		if client.username != "" {
-			connectedUsers[client.username] = client
-		}
-	}
-
-	// Create user list combining database and live data
-	userMap := make(map[string]*userInfo)
-
-	// Add users from messages
-	for username, msgCount := range userMessages {
-		userMap[username] = &userInfo{
-			Username: username,
-			Status:   "Offline",
-			IP:       "N/A",
-			Messages: msgCount,
-			IsAdmin:  false, // Would need to check against admin list
-		}
-	}
-
-	// Update with connected users
-	for username, client := range connectedUsers {
-		if user, exists := userMap[username]; exists {
-			user.Status = "Online"
-			user.IP = client.ipAddr
-			user.ConnectedAt = time.Now() // Simplified - would need client.connectedAt
-			user.LastSeen = time.Now()
-			user.IsAdmin = client.isAdmin
-		} else {
-			userMap[username] = &userInfo{
-				Username:    username,
-				Status:      "Online",
-				IP:          client.ipAddr,
-				ConnectedAt: time.Now(),
-				LastSeen:    time.Now(),
-				Messages:    0,
-				IsAdmin:     client.isAdmin,
-			}
-		}
-	}
-
-	// Check ban/kick status
-	for username, user := range userMap {
-		user.IsBanned = ap.hub.IsUserBanned(username)
-		if user.IsBanned {
-			user.Status = "Banned"
-		}
-	}
-
-	// Convert map to slice
-	ap.users = make([]userInfo, 0, len(userMap))
-	for _, user := range userMap {
-		ap.users = append(ap.users, *user)
-	}
-
-	// Sort users by status (online first), then by message count
-	sort.Slice(ap.users, func(i, j int) bool {
-		if ap.users[i].Status != ap.users[j].Status {
-			if ap.users[i].Status == "Online" {
-				return true
-			}
-			if ap.users[j].Status == "Online" {
-				return false
-			}
-		}
-		return ap.users[i].Messages > ap.users[j].Messages
-	})
-}
-
 func (ap *AdminPanel) loadPlugins() {
 	// Get available plugins from store
 	storePlugins := ap.pluginManager.GetStore().GetPluginsPreferredForPlatform("", "")
@@ -589,37 +621,29 @@ func (ap *AdminPanel) loadPlugins() {
 			Version: plugin.Version,
 		})
 	}
-}
 
-func (ap *AdminPanel) loadLogs() {
-	// Get real logs from the log buffer
-	logBuffer := GetLogBuffer()
-	serverLogs := logBuffer.GetRecentEntries(100) // Get last 100 entries
-
-	// Convert LogEntry to logEntry format for admin panel
-	ap.logs = make([]logEntry, 0, len(serverLogs))
-	for _, serverLog := range serverLogs {
-		ap.logs = append(ap.logs, logEntry{
-			Timestamp: serverLog.Timestamp,
-			Level:     string(serverLog.Level),
-			Message:   serverLog.Message,
-			User:      serverLog.UserID,
-			Component: serverLog.Component,
-		})
+	// Add installed plugins the store does not list, such as local builds
+	listed := make(map[string]bool, len(ap.plugins))
+	for _, plugin := range ap.plugins {
+		listed[plugin.Name] = true
 	}
-
-	// Debug: Check what logs we're getting
-	if len(ap.logs) > 0 {
-		// Check if we have any Admin component logs
-		adminLogs := 0
-		for _, logEntry := range ap.logs {
-			if logEntry.Component == "Admin" {
-				adminLogs++
-			}
+	var local []pluginInfo
+	for name, installed := range installedPlugins {
+		if listed[name] {
+			continue
+		}
+		status := "Disabled"
+		if installed.Enabled {
+			status = "Active"
+		}
+		version := ""
+		if installed.Manifest != nil {
+			version = installed.Manifest.Version
 		}
-		// This will show up in the debug file, not admin panel
-		log.Printf("DEBUG: Admin panel loaded %d total logs, %d admin logs", len(ap.logs), adminLogs)
+		local = append(local, pluginInfo{Name: name, Status: status, Version: version})
 	}
+	sort.Slice(local, func(i, j int) bool { return local[i].Name < local[j].Name })
+	ap.plugins = append(ap.plugins, local...)
 }
 
 func (ap *AdminPanel) updateSystemStats() {
@@ -628,15 +652,13 @@ func (ap *AdminPanel) updateSystemStats() {
 	runtime.ReadMemStats(&m)
 
 	// Get message count
-	var messageCount int
-	err := ap.db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&messageCount)
+	messageCount, err := ap.db.CountMessages()
 	if err != nil {
 		log.Printf("Error getting message count: %v", err)
 	}
 
 	// Get unique user count
-	var userCount int
-	err = ap.db.QueryRow("SELECT COUNT(DISTINCT sender) FROM messages WHERE sender != 'System'").Scan(&userCount)
+	_, userCount, err := ap.db.QuerySenders(SenderQuery{Limit: 1})
 	if err != nil {
 		log.Printf("Error getting user count: %v", err)
 	}
@@ -660,7 +682,8 @@ func (ap *AdminPanel) updateSystemStats() {
 
 	ap.systemInfo.MessagesSent = messageCount
 	ap.systemInfo.TotalUsers = userCount
-	ap.systemInfo.ActiveUsers = len(ap.hub.clients)
+	ap.systemInfo.ActiveUsers = ap.hub.clients.len()
+	ap.systemInfo.AwayUsers = ap.hub.AwayUsers()
 	ap.systemInfo.PluginsActive = activePlugins
 	ap.systemInfo.Uptime = time.Since(ap.startTime)
 	ap.systemInfo.ServerStatus = "Running"
@@ -732,6 +755,8 @@ func (ap *AdminPanel) updateUserTable() {
 			status = "Banned"
 		} else if user.IsKicked {
 			status = "Kicked"
+		} else if user.IsMuted {
+			status = "Muted"
 		}
 
 		lastSeen := "N/A"
@@ -740,7 +765,7 @@ func (ap *AdminPanel) updateUserTable() {
 		}
 
 		connected := "N/A"
-		if !user.ConnectedAt.IsZero() && user.Status == "Online" {
+		if !user.ConnectedAt.IsZero() && (user.Status == "Online" || user.Status == "Away") {
 			connected = formatDuration(time.Since(user.ConnectedAt))
 		}
 
@@ -761,6 +786,8 @@ func (ap *AdminPanel) updateUserTable() {
 func RunAdminPanel(hub *Hub, db *DatabaseWrapper, pluginManager *manager.PluginManager, liveConfig *config.Config) error {
 	panel := NewAdminPanel(hub, db, pluginManager, liveConfig)
 
+	defer panel.Close()
+
 	p := tea.NewProgram(panel, tea.WithAltScreen())
 	_, err := p.Run()
 	return err
@@ -773,6 +800,7 @@ func (ap *AdminPanel) Init() tea.Cmd {
 		tea.Tick(time.Second, func(t time.Time) tea.Msg {
 			return tickMsg(t)
 		}),
+		ap.waitForLog(),
 	)
 }
 
@@ -795,11 +823,63 @@ func (ap *AdminPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		ap.userTable.SetWidth(availableWidth)
 
 	case tea.KeyMsg:
+		// An open confirmation takes every key except ctrl+c
+		if ap.confirm != nil && msg.String() != "ctrl+c" {
+			return ap, ap.handleConfirmKey(msg)
+		}
+		if ap.activeTab == tabUsers && ap.pairing == "" {
+			if handled, cmd := ap.handleUserListKey(msg); handled {
+				return ap, cmd
+			}
+		}
+		if ap.activeTab == tabSystem && ap.pairing == "" {
+			if ap.welcomeEdit == nil {
+				if handled, cmd := ap.handleNoticeKey(msg); handled {
+					return ap, cmd
+				}
+			}
+			if handled, cmd := ap.handleWelcomeKey(msg); handled {
+				return ap, cmd
+			}
+		}
+		if ap.activeTab == tabLogs && ap.pairing == "" && ap.handleLogKey(msg) {
+			return ap, nil
+		}
+		if ap.activeTab == tabMetrics && ap.handleUsageKey(msg) {
+			return ap, nil
+		}
+		if ap.activeTab == tabMetrics && key.Matches(msg, ap.keys.HistoryRange) {
+			ap.historyRange = (ap.historyRange + 1) % len(metricsRangeNames)
+			ap.loadHistory()
+			return ap, nil
+		}
+		if ap.activeTab == tabConnections && key.Matches(msg, ap.keys.GroupConns) {
+			ap.connBySubnet = !ap.connBySubnet
+			ap.connectionsScroll = 0
+			return ap, nil
+		}
+		if ap.activeTab == tabPlugins && ap.pairing == "" {
+			if ap.pluginDetail != nil {
+				if handled, cmd := ap.handlePluginDetailKey(msg); handled {
+					return ap, cmd
+				}
+			} else if key.Matches(msg, ap.keys.Action) && ap.selectedPlugin >= 0 && ap.selectedPlugin < len(ap.plugins) {
+				ap.openPluginDetail(ap.plugins[ap.selectedPlugin].Name)
+				return ap, nil
+			}
+		}
 		switch {
 		case key.Matches(msg, ap.keys.Quit):
 			ap.quitting = true
 			return ap, tea.Quit
+		case key.Matches(msg, ap.keys.Pair):
+			if ap.pairing != "" {
+				ap.pairing = ""
+			} else {
+				ap.showPairing()
+			}
 		case key.Matches(msg, ap.keys.TabNext):
+			ap.pairing = ""
 			ap.activeTab = tabType((int(ap.activeTab) + 1) % len(ap.tabs))
 			// Focus/blur tables based on active tab
 			switch ap.activeTab {
@@ -814,6 +894,7 @@ func (ap *AdminPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				ap.pluginTable.Blur()
 			}
 		case key.Matches(msg, ap.keys.TabPrev):
+			ap.pairing = ""
 			ap.activeTab = tabType((int(ap.activeTab) - 1 + len(ap.tabs)) % len(ap.tabs))
 			// Focus/blur tables based on active tab
 			switch ap.activeTab {
@@ -839,7 +920,9 @@ func (ap *AdminPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			ap.messageTimer = 3
 		case key.Matches(msg, ap.keys.ClearDB):
 			if ap.activeTab == tabSystem {
-				return ap, ap.clearDatabase()
+				ap.askConfirm("Clear database", "message history",
+					fmt.Sprintf("All %d stored messages will be permanently deleted.", ap.systemInfo.MessagesSent),
+					ap.clearDatabase)
 			}
 		case key.Matches(msg, ap.keys.BackupDB):
 			if ap.activeTab == tabSystem {
@@ -854,7 +937,9 @@ func (ap *AdminPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				selected := ap.userTable.SelectedRow()
 				if len(selected) > 0 {
 					username := selected[0]
-					return ap, ap.banUser(username)
+					ap.askConfirm("Ban user", username,
+						"Every session is disconnected and the user cannot rejoin until unbanned.",
+						func() tea.Cmd { return ap.banUser(username) })
 				}
 			}
 		case key.Matches(msg, ap.keys.Unban):
@@ -870,7 +955,17 @@ func (ap *AdminPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				selected := ap.userTable.SelectedRow()
 				if len(selected) > 0 {
 					username := selected[0]
-					return ap, ap.kickUser(username)
+					ap.askConfirm("Kick user", username,
+						"Every session is disconnected and the user cannot rejoin for 24 hours.",
+						func() tea.Cmd { return ap.kickUser(username) })
+				}
+			}
+		case key.Matches(msg, ap.keys.Mute):
+			if ap.activeTab == tabUsers && ap.userTable.Focused() {
+				selected := ap.userTable.SelectedRow()
+				if len(selected) > 0 {
+					username := selected[0]
+					return ap, ap.toggleMute(username)
 				}
 			}
 		case key.Matches(msg, ap.keys.Allow):
@@ -898,17 +993,24 @@ func (ap *AdminPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case key.Matches(msg, ap.keys.Uninstall):
 			if ap.activeTab == tabPlugins && ap.selectedPlugin >= 0 && ap.selectedPlugin < len(ap.plugins) {
-				pluginName := ap.plugins[ap.selectedPlugin].Name
-				return ap, ap.uninstallPlugin(pluginName)
+				plugin := ap.plugins[ap.selectedPlugin]
+				ap.askConfirm("Uninstall plugin", plugin.Name+" "+plugin.Version,
+					"The plugin's files are removed and its commands stop working.",
+					func() tea.Cmd { return ap.uninstallPlugin(plugin.Name) })
 			}
 		case key.Matches(msg, ap.keys.ForceGC):
 			runtime.GC()
 			ap.message = "🗑️ Garbage collection forced"
 			ap.messageTimer = 3
 		case key.Matches(msg, ap.keys.ResetMetrics):
-			ap.resetMetrics()
-			ap.message = "📊 Metrics reset"
-			ap.messageTimer = 3
+			ap.askConfirm("Reset metrics", "connection, message and memory history",
+				"Collected history and peak values are discarded.",
+				func() tea.Cmd {
+					ap.resetMetrics()
+					ap.message = "📊 Metrics reset"
+					ap.messageTimer = 3
+					return nil
+				})
 		case key.Matches(msg, ap.keys.ExportLogs):
 			return ap, ap.exportLogs()
 		case key.Matches(msg, ap.keys.Up):
@@ -932,6 +1034,10 @@ func (ap *AdminPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return tickMsg(t)
 		})
 
+	case logMsg:
+		ap.receiveLog(logEntry(msg))
+		return ap, ap.waitForLog()
+
 	case clearDBMsg:
 		if msg.success {
 			ap.message = "🗑️ Database cleared successfully!"
@@ -1014,6 +1120,11 @@ func (ap *AdminPanel) handleScroll(direction int) {
 		if ap.logsScroll < 0 {
 			ap.logsScroll = 0
 		}
+	case tabConnections:
+		ap.connectionsScroll += direction
+		if ap.connectionsScroll < 0 {
+			ap.connectionsScroll = 0
+		}
 	}
 }
 
@@ -1114,11 +1225,19 @@ func (ap *AdminPanel) renderTabs() string {
 }
 
 func (ap *AdminPanel) renderContent() string {
+	if ap.confirm != nil {
+		return ap.renderConfirm()
+	}
+	if ap.pairing != "" {
+		return ap.pairing
+	}
 	switch ap.activeTab {
 	case tabOverview:
 		return ap.renderOverview()
 	case tabUsers:
 		return ap.renderUsers()
+	case tabConnections:
+		return ap.renderConnections()
 	case tabSystem:
 		return ap.renderSystem()
 	case tabLogs:
@@ -1147,7 +1266,7 @@ func (ap *AdminPanel) renderOverview() string {
 	statusText := "🟢 " + ap.systemInfo.ServerStatus
 	doc.WriteString(fmt.Sprintf("Status: %s\n", statusStyle.Render(statusText)))
 	doc.WriteString(fmt.Sprintf("Uptime: %s\n", formatDuration(ap.systemInfo.Uptime)))
-	doc.WriteString(fmt.Sprintf("Active Users: %d\n", ap.systemInfo.ActiveUsers))
+	doc.WriteString(fmt.Sprintf("Active Users: %d (%d away)\n", ap.systemInfo.ActiveUsers, ap.systemInfo.AwayUsers))
 	doc.WriteString(fmt.Sprintf("Total Users: %d\n", ap.systemInfo.TotalUsers))
 	doc.WriteString(fmt.Sprintf("Messages Sent: %d\n", ap.systemInfo.MessagesSent))
 	doc.WriteString(fmt.Sprintf("Active Plugins: %d\n", ap.systemInfo.PluginsActive))
@@ -1178,7 +1297,15 @@ func (ap *AdminPanel) renderOverview() string {
 	// Database info
 	doc.WriteString(subtitleStyle.Width(contentWidth).Render("Database Information\n"))
 	doc.WriteString(strings.Repeat("─", min(20, contentWidth-2)) + "\n")
-	doc.WriteString(fmt.Sprintf("Database Path: %s\n", ap.config.DBPath))
+	if ap.config.IsEphemeralDB() {
+		doc.WriteString(warningStylePanel.Render("⚠️  IN-MEMORY DATABASE - messages are lost on shutdown") + "\n")
+		if ap.config.MemoryTTL > 0 {
+			doc.WriteString(fmt.Sprintf("Message TTL: %s\n", ap.config.MemoryTTL))
+		}
+	} else {
+		doc.WriteString(fmt.Sprintf("Database Path: %s\n", ap.config.DBPath))
+	}
+	doc.WriteString(fmt.Sprintf("Database Type: %s\n", ap.config.DBType))
 	doc.WriteString(fmt.Sprintf("Config Directory: %s\n", ap.config.ConfigDir))
 
 	return ap.renderScrollableContent(doc.String(), ap.overviewScroll)
@@ -1210,7 +1337,7 @@ func (ap *AdminPanel) renderUsers() string {
 				statusStyleLocal = lipgloss.NewStyle().Foreground(warningColor).Bold(true)
 			case "Banned":
 				statusStyleLocal = lipgloss.NewStyle().Foreground(errorColor).Bold(true)
-			case "Kicked":
+			case "Kicked", "Muted":
 				statusStyleLocal = lipgloss.NewStyle().Foreground(warningColor).Bold(true)
 			default:
 				statusStyleLocal = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Bold(true)
@@ -1222,7 +1349,8 @@ func (ap *AdminPanel) renderUsers() string {
 		}
 	}
 
-	doc.WriteString("Use ↑/↓ to navigate, [B] Ban, [U] Unban, [K] Kick, [A] Allow\n\n")
+	doc.WriteString(ap.userListingStatus() + "\n")
+	doc.WriteString("Use ↑/↓ to navigate, [B] Ban, [U] Unban, [K] Kick, [S] Mute, [A] Allow\n\n")
 
 	doc.WriteString(ap.userTable.View())
 
@@ -1240,7 +1368,12 @@ func (ap *AdminPanel) renderSystem() string {
 	doc.WriteString(subtitleStyle.Width(contentWidth).Render("System Management\n"))
 	doc.WriteString(strings.Repeat("─", min(20, contentWidth-2)) + "\n")
 
-	doc.WriteString(infoStylePanel.Render("Use [c] Clear Database, [b] Backup Database, [s] Show Stats\n\n"))
+	doc.WriteString(infoStylePanel.Render("Use [c] Clear Database, [b] Backup Database, [s] Show Stats, [w] Edit Welcome Bot, [W] Toggle Welcome Bot, [T] Edit Topic, [m] Edit MOTD\n\n"))
+
+	doc.WriteString(ap.renderNotices())
+	doc.WriteString("\n")
+	doc.WriteString(ap.renderWelcome())
+	doc.WriteString("\n")
 
 	// Live Configuration Details
 	doc.WriteString(subtitleStyle.Render("Live Configuration:\n"))
@@ -1249,6 +1382,7 @@ func (ap *AdminPanel) renderSystem() string {
 	doc.WriteString(fmt.Sprintf("  Config Directory: %s\n", ap.config.ConfigDir))
 	doc.WriteString(fmt.Sprintf("  Log Level: %s\n", ap.config.LogLevel))
 	doc.WriteString(fmt.Sprintf("  Max File Size: %.1f MB\n", float64(ap.config.MaxFileBytes)/1024/1024))
+	doc.WriteString(fmt.Sprintf("  Max Message Size: %d bytes\n", ap.hub.MaxMessageBytes()))
 	doc.WriteString(fmt.Sprintf("  Admin Users: %s\n", strings.Join(ap.config.Admins, ", ")))
 
 	// TLS Configuration with live detection
@@ -1291,8 +1425,10 @@ func (ap *AdminPanel) renderLogs() string {
 	doc.WriteString(subtitleStyle.Width(contentWidth).Render("System Logs\n"))
 	doc.WriteString(strings.Repeat("─", min(20, contentWidth-2)) + "\n")
 
+	doc.WriteString(ap.logTailStatus() + "\n\n")
+
 	// Add logs content
-	for _, logEntry := range ap.logs {
+	for _, logEntry := range ap.visibleLogs() {
 		var levelStyle lipgloss.Style
 		switch logEntry.Level {
 		case "ERROR":
@@ -1320,6 +1456,10 @@ func (ap *AdminPanel) renderPlugins() string {
 		contentWidth = 30
 	}
 
+	if ap.pluginDetail != nil {
+		return ap.renderPluginDetail()
+	}
+
 	doc.WriteString(subtitleStyle.Width(contentWidth).Render("Plugin Management\n"))
 	doc.WriteString(strings.Repeat("─", min(20, contentWidth-2)) + "\n")
 
@@ -1348,7 +1488,7 @@ func (ap *AdminPanel) renderPlugins() string {
 		}
 	}
 
-	doc.WriteString("Use ↑/↓ to navigate, [r] Refresh, [i] Install, [e] Enable, [d] Disable, [n] Uninstall\n\n")
+	doc.WriteString("Use ↑/↓ to navigate, [Enter] Details, [r] Refresh, [i] Install, [e] Enable, [d] Disable, [n] Uninstall\n\n")
 
 	if len(ap.plugins) == 0 {
 		doc.WriteString("No plugins found.\n")
@@ -1403,7 +1543,7 @@ func (ap *AdminPanel) renderMetrics() string {
 	doc.WriteString(subtitleStyle.Width(contentWidth).Render("Performance Metrics\n"))
 	doc.WriteString(strings.Repeat("─", min(20, contentWidth-2)) + "\n")
 
-	doc.WriteString(infoStylePanel.Render("Use [G] Force GC, [R] Reset Metrics, [E] Export Logs\n\n"))
+	doc.WriteString(infoStylePanel.Render("Use [G] Force GC, [R] Reset Metrics, [E] Export Logs, [o/O] Sort Users\n\n"))
 
 	// System Performance - more compact layout
 	doc.WriteString(metricLabelStyle.Render("System Performance:\n"))
@@ -1419,8 +1559,9 @@ func (ap *AdminPanel) renderMetrics() string {
 
 	// Connection Metrics - more compact layout
 	doc.WriteString(metricLabelStyle.Render("Connection Metrics:\n"))
-	doc.WriteString(fmt.Sprintf("Active: %s | Peak: %s | Total: %s | Disconnects: %s\n",
+	doc.WriteString(fmt.Sprintf("Active: %s | Away: %s | Peak: %s | Total: %s | Disconnects: %s\n",
 		metricValueStyle.Render(fmt.Sprintf("%d", ap.systemInfo.ActiveUsers)),
+		metricValueStyle.Render(fmt.Sprintf("%d", ap.systemInfo.AwayUsers)),
 		metricValueStyle.Render(fmt.Sprintf("%d", ap.metrics.PeakUsers)),
 		metricValueStyle.Render(fmt.Sprintf("%d", ap.metrics.TotalConnections)),
 		metricValueStyle.Render(fmt.Sprintf("%d", ap.metrics.TotalDisconnects))))
@@ -1436,34 +1577,27 @@ func (ap *AdminPanel) renderMetrics() string {
 
 	doc.WriteString("\n")
 
-	// Memory History Chart (simplified)
-	if len(ap.metrics.MemoryHistory) > 0 {
-		doc.WriteString(metricLabelStyle.Render("Memory History (last 5):\n"))
-		recent := ap.metrics.MemoryHistory
-		if len(recent) > 5 {
-			recent = recent[len(recent)-5:]
+	// Charts of the samples taken on each refresh
+	if len(ap.metrics.ConnectionHistory) > 1 {
+		doc.WriteString(metricLabelStyle.Render(fmt.Sprintf("Recent (%d samples):\n", len(ap.metrics.ConnectionHistory))))
+		users := make([]float64, len(ap.metrics.ConnectionHistory))
+		for i, point := range ap.metrics.ConnectionHistory {
+			users[i] = float64(point.Count)
 		}
-		for _, point := range recent {
-			value := float64(point.Memory) / 1024 / 1024
-			doc.WriteString(fmt.Sprintf("  %s: %.1f MB\n",
-				point.Time.Format("15:04:05"), value))
+		memory := make([]float64, len(ap.metrics.MemoryHistory))
+		for i, point := range ap.metrics.MemoryHistory {
+			memory[i] = float64(point.Memory)
 		}
+		ap.writeSparkline(&doc, "Connections", users, func(v float64) string { return fmt.Sprintf("%.0f", v) })
+		ap.writeSparkline(&doc, "Msg rate", messageRates(ap.metrics.MessageHistory), func(v float64) string { return fmt.Sprintf("%.2f/s", v) })
+		ap.writeSparkline(&doc, "Memory", memory, formatBytes)
 	}
 
 	doc.WriteString("\n")
+	ap.renderHistory(&doc)
 
-	// Connection History Chart (simplified)
-	if len(ap.metrics.ConnectionHistory) > 0 {
-		doc.WriteString(metricLabelStyle.Render("Connection History (last 5):\n"))
-		recent := ap.metrics.ConnectionHistory
-		if len(recent) > 5 {
-			recent = recent[len(recent)-5:]
-		}
-		for _, point := range recent {
-			doc.WriteString(fmt.Sprintf("  %s: %d users\n",
-				point.Time.Format("15:04:05"), point.Count))
-		}
-	}
+	doc.WriteString("\n")
+	ap.renderUsage(&doc)
 
 	return ap.renderScrollableContent(doc.String(), ap.metricsScroll)
 }
@@ -1521,6 +1655,32 @@ func (ap *AdminPanel) showDatabaseStats() tea.Cmd {
 	}
 }
 
+// showPairing creates an invite and shows it as a QR code for phones to scan
+// with marchat-client --scan
+func (ap *AdminPanel) showPairing() {
+	if ap.config == nil {
+		ap.message = "❌ Pairing needs the server configuration"
+		ap.messageTimer = 5
+		return
+	}
+	link, inv := ap.hub.PairingLink("", ap.config.Port, ap.config.IsTLSEnabled(), "admin")
+	qr, err := pairingQR(link.String())
+	if err != nil {
+		ap.message = "❌ Could not render QR code: " + err.Error()
+		ap.messageTimer = 5
+		return
+	}
+	ap.pairing = strings.Join([]string{
+		titleStyle.Render("📱 Pair a phone"),
+		subtitleStyle.Render("Scan with marchat-client --scan, or paste the link below"),
+		"",
+		qr,
+		link.String(),
+		"",
+		fmt.Sprintf("Single-use invite, valid until %s. Press P to close.", inv.ExpiresAt.Format("2006-01-02 15:04")),
+	}, "\n")
+}
+
 func (ap *AdminPanel) banUser(username string) tea.Cmd {
 	return func() tea.Msg {
 		ap.hub.BanUser(username, "admin")
@@ -1557,6 +1717,24 @@ func (ap *AdminPanel) kickUser(username string) tea.Cmd {
 	}
 }
 
+// toggleMute shadow-mutes the user for the default duration, or lifts an
+// existing mute
+func (ap *AdminPanel) toggleMute(username string) tea.Cmd {
+	return func() tea.Msg {
+		if ap.hub.UnmuteUser(username, "admin") {
+			return actionMsg{
+				success: true,
+				message: fmt.Sprintf("🔊 User '%s' has been unmuted", username),
+			}
+		}
+		until := ap.hub.MuteUser(username, "admin", defaultMuteDuration)
+		return actionMsg{
+			success: true,
+			message: fmt.Sprintf("🔇 User '%s' is shadow-muted until %s", username, until.Format("15:04")),
+		}
+	}
+}
+
 func (ap *AdminPanel) allowUser(username string) tea.Cmd {
 	return func() tea.Msg {
 		success := ap.hub.AllowUser(username, "admin")
@@ -1671,13 +1849,14 @@ func (ap *AdminPanel) resetMetrics() {
 }
 
 func (ap *AdminPanel) exportLogs() tea.Cmd {
+	logs := ap.visibleLogs() // export what the Logs tab shows
 	return func() tea.Msg {
 		// Create a simple log export
 		var logText strings.Builder
 		logText.WriteString("Marchat Admin Panel Log Export\n")
 		logText.WriteString("==============================\n\n")
 
-		for _, logEntry := range ap.logs {
+		for _, logEntry := range logs {
 			logText.WriteString(fmt.Sprintf("[%s] %s %s: %s\n",
 				logEntry.Timestamp.Format("2006-01-02 15:04:05"),
 				logEntry.Level,
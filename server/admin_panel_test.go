@@ -1,10 +1,17 @@
 package server
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	appcfg "github.com/Cod-e-Codes/marchat/config"
+	"github.com/Cod-e-Codes/marchat/plugin/manager"
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func setupPanelEnv(t *testing.T) (*AdminPanel, func()) {
@@ -27,6 +34,7 @@ func setupPanelEnv(t *testing.T) (*AdminPanel, func()) {
 	hub := NewHub(pluginDir, dataDir, "", dbWrapper)
 	panel := NewAdminPanel(hub, dbWrapper, hub.GetPluginManager(), cfg)
 	return panel, func() {
+		panel.Close()
 		_ = dbWrapper.db.Close()
 	}
 }
@@ -49,3 +57,270 @@ func TestAdminPanel_InitAndRefresh(t *testing.T) {
 		t.Errorf("expected user table rows initialized")
 	}
 }
+
+func TestAdminPanel_ConfirmDestructiveActions(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+
+	press := func(k string) tea.Cmd {
+		t.Helper()
+		_, cmd := panel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	panel.activeTab = tabSystem
+	if cmd := press("c"); cmd != nil {
+		t.Fatal("Expected clear DB to wait for confirmation")
+	}
+	if panel.confirm == nil || panel.confirm.title != "Clear database" {
+		t.Fatalf("Expected a clear database dialog, got %+v", panel.confirm)
+	}
+	if !strings.Contains(panel.renderContent(), "[y] confirm") {
+		t.Error("Expected the dialog to replace the tab content")
+	}
+
+	// Other keys are swallowed while the dialog is open
+	press("b")
+	if panel.confirm == nil {
+		t.Fatal("Expected the dialog to stay open for an unrelated key")
+	}
+	_, cmd := panel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if panel.confirm != nil || cmd != nil {
+		t.Error("Expected esc to cancel without running the action or quitting")
+	}
+
+	press("c")
+	cmd = press("y")
+	if panel.confirm != nil || cmd == nil {
+		t.Fatal("Expected y to close the dialog and run the action")
+	}
+	if msg, ok := cmd().(clearDBMsg); !ok || !msg.success {
+		t.Errorf("Expected the database to be cleared, got %+v", msg)
+	}
+}
+
+func TestAdminPanel_UserListing(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+
+	press := func(k string) {
+		t.Helper()
+		panel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	names := func() []string {
+		var out []string
+		for _, u := range panel.users {
+			out = append(out, u.Username)
+		}
+		return out
+	}
+
+	now := time.Now()
+	for i := 0; i < usersPageSize+5; i++ {
+		msg := shared.Message{Sender: fmt.Sprintf("user%02d", i), Content: "hi", CreatedAt: now}
+		if err := panel.db.InsertMessage(msg); err != nil {
+			t.Fatalf("InsertMessage failed: %v", err)
+		}
+	}
+	panel.hub.BanUser("user07", "a")
+	panel.activeTab = tabUsers
+	panel.refreshData()
+
+	if len(panel.users) != usersPageSize || panel.userList.total != usersPageSize+5 {
+		t.Fatalf("Expected a full first page of %d users, got %d of %d",
+			usersPageSize+5, len(panel.users), panel.userList.total)
+	}
+	press("]")
+	if panel.userList.page != 1 || len(panel.users) != 5 {
+		t.Fatalf("Expected 5 users on page 2, got %d on page %d", len(panel.users), panel.userList.page+1)
+	}
+	press("]")
+	if panel.userList.page != 1 {
+		t.Fatal("Expected paging to stop at the last page")
+	}
+
+	press("/")
+	press("user1")
+	if got := names(); len(got) != 10 || panel.userList.page != 0 {
+		t.Fatalf("Expected the ten user1x users on page 1, got %v", got)
+	}
+	panel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if panel.userList.searching || panel.userList.search.Value() != "" || panel.quitting {
+		t.Fatal("Expected esc to clear the search without quitting")
+	}
+
+	press("f") // Online: nobody is connected
+	if len(panel.users) != 0 {
+		t.Fatalf("Expected no online users, got %v", names())
+	}
+	press("f") // Banned
+	if got := names(); len(got) != 1 || got[0] != "user07" || !panel.users[0].IsBanned {
+		t.Fatalf("Expected only user07 as banned, got %v", got)
+	}
+	press("f")
+	press("f") // Admin: configured but never posted
+	if got := names(); len(got) != 1 || got[0] != "a" || !panel.users[0].IsAdmin {
+		t.Fatalf("Expected only admin a, got %v", got)
+	}
+
+	press("f") // back to All
+	press("o") // name, ascending
+	if got := names(); got[0] != "user00" || got[1] != "user01" {
+		t.Fatalf("Expected users sorted by name, got %v", got[:2])
+	}
+	press("O")
+	if got := names(); got[0] != "user54" {
+		t.Fatalf("Expected users sorted by name descending, got %v", got[:2])
+	}
+}
+
+func TestAdminPanel_LogTail(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+
+	panel.activeTab = tabLogs
+	press := func(k string) {
+		t.Helper()
+		panel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	// Feed entries through the subscription the way Update receives them
+	logAndDeliver := func(logger *Logger, level LogLevel, message string) {
+		t.Helper()
+		switch level {
+		case LogLevelWarn:
+			logger.Warn(message)
+		default:
+			logger.Info(message)
+		}
+		// Other tests' goroutines may log too: deliver up to our entry
+		for {
+			msg, ok := panel.waitForLog()().(logMsg)
+			if !ok {
+				t.Fatal("Expected a live log entry")
+			}
+			panel.Update(msg)
+			if msg.Message == message {
+				return
+			}
+		}
+	}
+
+	logAndDeliver(NewLogger("TailA"), LogLevelInfo, "tail info")
+	if got := panel.visibleLogs(); len(got) == 0 || got[0].Message != "tail info" {
+		t.Fatalf("Expected the new entry first, got %+v", got)
+	}
+
+	press("l")
+	press("l") // WARN and above
+	logAndDeliver(NewLogger("TailB"), LogLevelWarn, "tail warn")
+	for _, e := range panel.visibleLogs() {
+		if e.Level != "WARN" && e.Level != "ERROR" {
+			t.Fatalf("Expected only warnings and errors, got %+v", e)
+		}
+	}
+	press("l")
+	press("l") // back to everything
+
+	for panel.logTail.component != "TailA" {
+		press("C")
+	}
+	for _, e := range panel.visibleLogs() {
+		if e.Component != "TailA" {
+			t.Fatalf("Expected only TailA entries, got %+v", e)
+		}
+	}
+	for panel.logTail.component != "" {
+		press("C")
+	}
+
+	press("p")
+	logAndDeliver(NewLogger("TailA"), LogLevelInfo, "while paused")
+	if panel.logs[0].Message == "while paused" || len(panel.logTail.pending) != 1 {
+		t.Fatal("Expected entries to be held back while paused")
+	}
+	if !strings.Contains(panel.renderLogs(), "paused (1 new)") {
+		t.Error("Expected the Logs tab to show the pause state")
+	}
+	press("p")
+	if panel.logs[0].Message != "while paused" || len(panel.logTail.pending) != 0 {
+		t.Fatal("Expected held entries to be shown on resume")
+	}
+
+	panel.Close()
+	if msg := panel.waitForLog()(); msg != nil {
+		t.Errorf("Expected no more entries after Close, got %+v", msg)
+	}
+}
+
+func TestAdminPanel_PluginDetail(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+
+	// Install a disabled local plugin so nothing is started
+	pluginDir := filepath.Join(panel.config.ConfigDir, "plugins")
+	dataDir := filepath.Join(panel.config.ConfigDir, "data")
+	pluginPath := filepath.Join(pluginDir, "greeter")
+	if err := os.MkdirAll(pluginPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginPath, "greeter"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := `{"name":"greeter","version":"1.2.0","description":"Greets people","author":"me","license":"MIT",
+		"commands":[{"name":"greet","description":"Say hello","usage":":greet <user>"}],
+		"settings":{"greeting":"hello"}}`
+	if err := os.WriteFile(filepath.Join(pluginPath, "plugin.json"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "plugin_state.json"), []byte(`{"enabled":{"greeter":false}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	panel.pluginManager = manager.NewPluginManager(pluginDir, dataDir, "")
+	panel.loadPlugins()
+	panel.activeTab = tabPlugins
+	panel.selectedPlugin = -1
+	for i, p := range panel.plugins {
+		if p.Name == "greeter" {
+			panel.selectedPlugin = i
+		}
+	}
+	if panel.selectedPlugin < 0 {
+		t.Fatalf("Expected the local plugin to be listed, got %+v", panel.plugins)
+	}
+
+	panel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if panel.pluginDetail == nil {
+		t.Fatal("Expected enter to open the plugin detail pane")
+	}
+	view := panel.renderPlugins()
+	for _, want := range []string{"greeter v1.2.0", "Greets people", ":greet <user> - Say hello", "greeting = hello"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the detail pane:\n%s", want, view)
+		}
+	}
+
+	panel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !panel.pluginDetail.editing {
+		t.Fatal("Expected enter to edit the selected setting")
+	}
+	panel.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	panel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("hi")})
+	_, cmd := panel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Expected saving the setting to return a command")
+	}
+	if msg, ok := cmd().(actionMsg); !ok || !msg.success {
+		t.Fatalf("Expected the setting to be saved, got %+v", msg)
+	}
+	if got := panel.pluginManager.GetPluginSettings("greeter")["greeting"]; got != "hi" {
+		t.Errorf("Expected greeting to be hi, got %q", got)
+	}
+
+	panel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if panel.pluginDetail != nil || panel.quitting {
+		t.Error("Expected esc to close the detail pane without quitting")
+	}
+}
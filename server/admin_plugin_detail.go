@@ -0,0 +1,205 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pluginDetailLogLines is how many recent plugin log lines the detail pane shows
+const pluginDetailLogLines = 10
+
+// pluginDetail is the Plugins tab pane for one installed plugin
+type pluginDetail struct {
+	name     string
+	settings []string // setting names from the manifest, sorted
+	cursor   int
+	editing  bool
+	input    textinput.Model
+}
+
+// openPluginDetail shows the detail pane for an installed plugin
+func (ap *AdminPanel) openPluginDetail(name string) {
+	instance := ap.pluginManager.GetPlugin(name)
+	if instance == nil {
+		ap.message = fmt.Sprintf("ℹ️ Install '%s' to see its details", name)
+		ap.messageTimer = 3
+		return
+	}
+	var settings []string
+	if instance.Manifest != nil {
+		for k := range instance.Manifest.Settings {
+			settings = append(settings, k)
+		}
+		sort.Strings(settings)
+	}
+	input := textinput.New()
+	input.CharLimit = 256
+	ap.pluginDetail = &pluginDetail{name: name, settings: settings, input: input}
+}
+
+// handlePluginDetailKey drives the detail pane, reporting whether msg was
+// used. Other keys fall through so plugin actions and tabs keep working.
+func (ap *AdminPanel) handlePluginDetailKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	d := ap.pluginDetail
+	if d.editing {
+		switch msg.String() {
+		case "ctrl+c":
+			return false, nil
+		case "enter":
+			d.editing = false
+			d.input.Blur()
+			return true, ap.savePluginSetting(d.name, d.settings[d.cursor], d.input.Value())
+		case "esc":
+			d.editing = false
+			d.input.Blur()
+			return true, nil
+		}
+		var cmd tea.Cmd
+		d.input, cmd = d.input.Update(msg)
+		return true, cmd
+	}
+
+	switch {
+	case key.Matches(msg, ap.keys.Up):
+		d.cursor = max(0, d.cursor-1)
+	case key.Matches(msg, ap.keys.Down):
+		d.cursor = max(0, min(len(d.settings)-1, d.cursor+1))
+	case key.Matches(msg, ap.keys.Action):
+		if len(d.settings) == 0 {
+			return true, nil
+		}
+		d.editing = true
+		d.input.SetValue(ap.pluginManager.GetPluginSettings(d.name)[d.settings[d.cursor]])
+		d.input.CursorEnd()
+		return true, d.input.Focus()
+	case msg.String() == "esc", msg.String() == "backspace":
+		ap.pluginDetail = nil
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// savePluginSetting persists a setting, restarting the plugin if it runs
+func (ap *AdminPanel) savePluginSetting(pluginName, setting, value string) tea.Cmd {
+	return func() tea.Msg {
+		if err := ap.pluginManager.SetPluginSetting(pluginName, setting, value); err != nil {
+			return actionMsg{
+				success: false,
+				message: fmt.Sprintf("❌ Failed to save '%s' setting %s: %v", pluginName, setting, err),
+			}
+		}
+		AdminLogger.Info("Plugin setting changed", map[string]interface{}{
+			"plugin":  pluginName,
+			"setting": setting,
+		})
+		return actionMsg{
+			success: true,
+			message: fmt.Sprintf("✅ Saved '%s' setting %s", pluginName, setting),
+		}
+	}
+}
+
+// renderPluginDetail shows the manifest, commands, data usage, settings and
+// recent log lines of the plugin in the detail pane
+func (ap *AdminPanel) renderPluginDetail() string {
+	d := ap.pluginDetail
+	instance := ap.pluginManager.GetPlugin(d.name)
+	if instance == nil || instance.Manifest == nil {
+		return fmt.Sprintf("Plugin '%s' is no longer installed.\n\n[Esc] Back", d.name)
+	}
+	m := instance.Manifest
+
+	contentWidth := ap.width - 12
+	if contentWidth < 30 {
+		contentWidth = 30
+	}
+	labelStyle := lipgloss.NewStyle().Foreground(accentColor).Bold(true)
+	doc := strings.Builder{}
+
+	doc.WriteString(subtitleStyle.Width(contentWidth).Render(fmt.Sprintf("%s v%s\n", m.Name, m.Version)))
+	doc.WriteString(strings.Repeat("─", min(20, contentWidth-2)) + "\n")
+	if m.Description != "" {
+		doc.WriteString(m.Description + "\n")
+	}
+	status := "Disabled"
+	if instance.Enabled {
+		status = "Enabled"
+		if !instance.Running() {
+			status += " (not running)"
+		}
+	}
+	doc.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Status:"), status))
+	doc.WriteString(fmt.Sprintf("%s %s   %s %s\n", labelStyle.Render("Author:"), m.Author, labelStyle.Render("License:"), m.License))
+	if m.Repository != "" {
+		doc.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Repository:"), m.Repository))
+	}
+	if m.Homepage != "" {
+		doc.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Homepage:"), m.Homepage))
+	}
+	if len(m.Permissions) > 0 {
+		doc.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Permissions:"), strings.Join(m.Permissions, ", ")))
+	}
+	dataSize := "unknown"
+	if size, err := ap.pluginManager.PluginDataSize(d.name); err == nil {
+		dataSize = fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	doc.WriteString(fmt.Sprintf("%s %s (%s)\n", labelStyle.Render("Data:"), instance.Config.DataDir, dataSize))
+
+	doc.WriteString("\n" + labelStyle.Render("Commands:") + "\n")
+	if len(m.Commands) == 0 {
+		doc.WriteString("  none\n")
+	}
+	for _, c := range m.Commands {
+		usage := c.Usage
+		if usage == "" {
+			usage = ":" + c.Name
+		}
+		admin := ""
+		if c.AdminOnly {
+			admin = " [admin]"
+		}
+		doc.WriteString(fmt.Sprintf("  %s - %s%s\n", usage, c.Description, admin))
+	}
+
+	doc.WriteString("\n" + labelStyle.Render("Settings:") + "\n")
+	if len(d.settings) == 0 {
+		doc.WriteString("  none\n")
+	}
+	current := ap.pluginManager.GetPluginSettings(d.name)
+	for i, k := range d.settings {
+		marker := "  "
+		if i == d.cursor {
+			marker = "▶ "
+		}
+		value := current[k]
+		if d.editing && i == d.cursor {
+			value = d.input.View()
+		} else if value != m.Settings[k] {
+			value += fmt.Sprintf(" (default: %s)", m.Settings[k])
+		}
+		doc.WriteString(fmt.Sprintf("%s%s = %s\n", marker, k, value))
+	}
+
+	doc.WriteString("\n" + labelStyle.Render("Recent logs:") + "\n")
+	logs := instance.RecentLogs()
+	if len(logs) == 0 {
+		doc.WriteString("  none\n")
+	}
+	for _, line := range logs[max(0, len(logs)-pluginDetailLogLines):] {
+		doc.WriteString("  " + line + "\n")
+	}
+
+	if d.editing {
+		doc.WriteString("\n[Enter] Save (restarts the plugin)  [Esc] Cancel\n")
+	} else {
+		doc.WriteString("\nUse ↑/↓ to pick a setting, [Enter] Edit, [e] Enable, [d] Disable, [Esc] Back\n")
+	}
+	return doc.String()
+}
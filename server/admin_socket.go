@@ -0,0 +1,318 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+)
+
+// The terminal admin panel is served on a local Unix socket rather than the
+// server's own stdin, so it works under process supervisors, in containers
+// and when stdin is not a TTY. `marchat-server admin` attaches to it: the
+// attaching side puts its terminal in raw mode and sends framed keystrokes
+// and window sizes; the server runs the panel and writes its screen back as
+// raw terminal output. Access is limited by the socket's 0600 permissions.
+
+// Frames from the attaching terminal: a type byte, a big-endian uint16
+// payload length, then the payload
+const (
+	adminFrameHello  = 'h' // JSON adminHello, always first
+	adminFrameInput  = 'd' // raw keyboard input
+	adminFrameResize = 'r' // uint16 columns, uint16 rows
+)
+
+// adminHello describes the attaching terminal
+type adminHello struct {
+	Cols      int    `json:"cols"`
+	Rows      int    `json:"rows"`
+	Term      string `json:"term"`
+	ColorTerm string `json:"colorterm"`
+}
+
+func writeAdminFrame(w io.Writer, kind byte, payload []byte) error {
+	header := []byte{kind, 0, 0}
+	binary.BigEndian.PutUint16(header[1:], uint16(len(payload)))
+	_, err := w.Write(append(header, payload...))
+	return err
+}
+
+func readAdminFrame(r io.Reader) (byte, []byte, error) {
+	var header [3]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[1:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+func resizePayload(cols, rows int) []byte {
+	p := make([]byte, 4)
+	binary.BigEndian.PutUint16(p, uint16(cols))
+	binary.BigEndian.PutUint16(p[2:], uint16(rows))
+	return p
+}
+
+// colorProfile picks the color support of the attaching terminal from its
+// TERM and COLORTERM
+func (h adminHello) colorProfile() termenv.Profile {
+	switch {
+	case h.ColorTerm == "truecolor" || h.ColorTerm == "24bit":
+		return termenv.TrueColor
+	case h.Term == "" || h.Term == "dumb":
+		return termenv.Ascii
+	case strings.Contains(h.Term, "256color"):
+		return termenv.ANSI256
+	default:
+		return termenv.ANSI
+	}
+}
+
+// AdminSocket serves the terminal admin panel to one attached terminal at a time
+type AdminSocket struct {
+	path     string
+	listener *net.UnixListener
+	newPanel func() *AdminPanel
+	attached atomic.Bool
+}
+
+// ListenAdminSocket creates the admin socket at path, readable and writable
+// by the server's user only. newPanel builds the panel for each session.
+func ListenAdminSocket(path string, newPanel func() *AdminPanel) (*AdminSocket, error) {
+	if _, err := os.Stat(path); err == nil {
+		// A live socket means another server owns it; a dead one is left
+		// over from a crash
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bind under a temporary name and only rename it into place once it is
+	// owner-only, so nobody can connect in between
+	tmp := fmt.Sprintf("%s.%d", path, os.Getpid())
+	_ = os.Remove(tmp)
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		listener.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		listener.Close()
+		os.Remove(tmp)
+		return nil, err
+	}
+	return &AdminSocket{path: path, listener: listener, newPanel: newPanel}, nil
+}
+
+// Path returns the socket's file path
+func (s *AdminSocket) Path() string {
+	return s.path
+}
+
+// Serve accepts admin sessions until Close
+func (s *AdminSocket) Serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				ServerLogger.Error("Admin socket accept failed", err)
+			}
+			return
+		}
+		go s.serveSession(conn)
+	}
+}
+
+// Close stops accepting sessions and removes the socket file
+func (s *AdminSocket) Close() error {
+	err := s.listener.Close()
+	if rmErr := os.Remove(s.path); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// serveSession runs one admin panel for the terminal attached on conn
+func (s *AdminSocket) serveSession(conn net.Conn) {
+	defer conn.Close()
+	if !s.attached.CompareAndSwap(false, true) {
+		fmt.Fprint(conn, "Another admin session is already attached.\r\n")
+		return
+	}
+	defer s.attached.Store(false)
+
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	kind, payload, err := readAdminFrame(conn)
+	var hello adminHello
+	if err == nil && kind == adminFrameHello {
+		err = json.Unmarshal(payload, &hello)
+	} else if err == nil {
+		err = errors.New("expected a hello frame")
+	}
+	if err != nil {
+		fmt.Fprintf(conn, "Invalid admin session: %v\r\n", err)
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	// Styles are rendered for the attached terminal, not the server's stdout
+	lipgloss.SetColorProfile(hello.colorProfile())
+
+	panel := s.newPanel()
+	defer panel.Close()
+
+	input, inputWriter := io.Pipe()
+	program := tea.NewProgram(panel,
+		tea.WithInput(input),
+		tea.WithOutput(conn),
+		tea.WithAltScreen(),
+		tea.WithoutSignalHandler(), // Ctrl+C in the panel must not stop the server
+	)
+
+	go func() {
+		defer inputWriter.Close()
+		program.Send(tea.WindowSizeMsg{Width: hello.Cols, Height: hello.Rows})
+		for {
+			kind, payload, err := readAdminFrame(conn)
+			if err != nil {
+				// The terminal went away: end the session
+				program.Quit()
+				return
+			}
+			switch kind {
+			case adminFrameInput:
+				if _, err := inputWriter.Write(payload); err != nil {
+					return
+				}
+			case adminFrameResize:
+				if len(payload) == 4 {
+					program.Send(tea.WindowSizeMsg{
+						Width:  int(binary.BigEndian.Uint16(payload)),
+						Height: int(binary.BigEndian.Uint16(payload[2:])),
+					})
+				}
+			}
+		}
+	}()
+
+	AdminLogger.Info("Admin panel attached", nil)
+	if _, err := program.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		AdminLogger.Error("Admin panel error", err)
+	}
+	AdminLogger.Info("Admin panel detached", nil)
+}
+
+// AttachAdminSocket connects this process's terminal to the admin panel
+// served at path and returns when the panel exits or the server goes away
+func AttachAdminSocket(path string) error {
+	return attachAdminSocket(path, os.Stdin, os.Stdout)
+}
+
+func attachAdminSocket(path string, in, out *os.File) error {
+	inFd, outFd := in.Fd(), out.Fd()
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return errors.New("the admin panel needs an interactive terminal")
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return fmt.Errorf("cannot reach the admin socket (is the server running with --admin-panel?): %w", err)
+	}
+	defer conn.Close()
+
+	cols, rows, err := term.GetSize(outFd)
+	if err != nil {
+		cols, rows = 80, 24
+	}
+	hello, _ := json.Marshal(adminHello{
+		Cols:      cols,
+		Rows:      rows,
+		Term:      os.Getenv("TERM"),
+		ColorTerm: os.Getenv("COLORTERM"),
+	})
+	if err := writeAdminFrame(conn, adminFrameHello, hello); err != nil {
+		return err
+	}
+
+	// The panel's screen arrives as raw output, so the terminal also needs
+	// resetting if the server goes away before the panel has put it back
+	terminal := shared.NewTerminalGuard(in, out)
+	if err := terminal.MakeRaw(); err != nil {
+		return fmt.Errorf("cannot set the terminal to raw mode: %w", err)
+	}
+	defer terminal.Restore()
+	defer terminal.Recover()
+	// Ctrl+C is sent to the panel as a key; other signals detach
+	stopSignals := terminal.HandleSignals(2*time.Second, func() { conn.Close() })
+	defer stopSignals()
+
+	go func() {
+		defer terminal.Recover()
+		buf := make([]byte, 4096)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				if writeAdminFrame(conn, adminFrameInput, buf[:n]) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// Polling works on every platform, unlike SIGWINCH
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer terminal.Recover()
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c, r, err := term.GetSize(outFd)
+				if err != nil || (c == cols && r == rows) {
+					continue
+				}
+				cols, rows = c, r
+				if writeAdminFrame(conn, adminFrameResize, resizePayload(cols, rows)) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	_, err = io.Copy(out, conn)
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	return err
+}
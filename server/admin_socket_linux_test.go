@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a pseudo-terminal, returning the controlling side a test
+// reads the screen from and the terminal side the admin attaches from
+func openPTY(t *testing.T) (ptm, pts *os.File) {
+	t.Helper()
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("No pseudo-terminals here: %v", err)
+	}
+	var n int
+	raw, _ := ptm.SyscallConn()
+	_ = raw.Control(func(fd uintptr) {
+		if err = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); err == nil {
+			n, err = unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to unlock the pseudo-terminal: %v", err)
+	}
+	pts, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open the pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { pts.Close(); ptm.Close() })
+	return ptm, pts
+}
+
+func TestAttachRestoresTerminalWhenServerDies(t *testing.T) {
+	ptm, pts := openPTY(t)
+	path := filepath.Join(t.TempDir(), "admin.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	// A panel that switches to the alternate screen and hides the cursor,
+	// then loses its server before putting them back
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		if kind, _, err := readAdminFrame(conn); err != nil || kind != adminFrameHello {
+			conn.Close()
+			return
+		}
+		_, _ = conn.Write([]byte("\x1b[?1049h\x1b[?25lOverview"))
+		time.Sleep(100 * time.Millisecond)
+		conn.Close()
+	}()
+
+	attached := make(chan error, 1)
+	go func() { attached <- attachAdminSocket(path, pts, pts) }()
+
+	var screen bytes.Buffer
+	buf := make([]byte, 4096)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(screen.String(), shared.TerminalReset) {
+		_ = ptm.SetReadDeadline(deadline)
+		n, err := ptm.Read(buf)
+		screen.Write(buf[:n])
+		if err != nil {
+			t.Fatalf("Expected the screen reset after the panel, got %v after %q", err, screen.String())
+		}
+	}
+	if i := strings.Index(screen.String(), "Overview"); i < 0 || i > strings.Index(screen.String(), shared.TerminalReset) {
+		t.Errorf("Expected the panel shown, then the screen reset, got %q", screen.String())
+	}
+
+	select {
+	case err := <-attached:
+		if err != nil {
+			t.Errorf("Expected a clean detach, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected attach to return when the server went away")
+	}
+	termios, err := unix.IoctlGetTermios(int(pts.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if termios.Lflag&unix.ICANON == 0 {
+		t.Error("Expected the terminal out of raw mode")
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+// readUntil reads from conn until want appears or the deadline passes
+func readUntil(t *testing.T, conn net.Conn, want string) string {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var out bytes.Buffer
+	buf := make([]byte, 4096)
+	for !strings.Contains(out.String(), want) {
+		n, err := conn.Read(buf)
+		out.Write(buf[:n])
+		if err != nil {
+			t.Fatalf("Expected %q in the output, got %v after %q", want, err, out.String())
+		}
+	}
+	return out.String()
+}
+
+func TestAdminSocket(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "admin.sock")
+	// A socket file left behind by a crashed server is replaced
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	sock, err := ListenAdminSocket(path, func() *AdminPanel { return panel })
+	if err != nil {
+		t.Fatalf("ListenAdminSocket failed: %v", err)
+	}
+	go sock.Serve()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected an owner-only socket, got %v (%v)", info.Mode(), err)
+	}
+	if _, err := ListenAdminSocket(path, nil); err == nil {
+		t.Error("Expected a second server to be refused the socket in use")
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	hello, _ := json.Marshal(adminHello{Cols: 100, Rows: 30, Term: "xterm-256color"})
+	if err := writeAdminFrame(conn, adminFrameHello, hello); err != nil {
+		t.Fatal(err)
+	}
+	readUntil(t, conn, "Overview")
+
+	// Only one session at a time
+	second, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	_ = second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if msg, _ := io.ReadAll(second); !strings.Contains(string(msg), "already attached") {
+		t.Errorf("Expected a second session to be turned away, got %q", msg)
+	}
+	second.Close()
+
+	if err := writeAdminFrame(conn, adminFrameResize, resizePayload(120, 40)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeAdminFrame(conn, adminFrameInput, []byte("q")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		t.Fatalf("Expected q to end the session, got %v", err)
+	}
+
+	if err := sock.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected Close to remove the socket file")
+	}
+}
+
+func TestAdminHelloColorProfile(t *testing.T) {
+	cases := []struct {
+		hello adminHello
+		want  termenv.Profile
+	}{
+		{adminHello{Term: "xterm-256color", ColorTerm: "truecolor"}, termenv.TrueColor},
+		{adminHello{Term: "xterm-256color"}, termenv.ANSI256},
+		{adminHello{Term: "xterm"}, termenv.ANSI},
+		{adminHello{Term: "dumb"}, termenv.Ascii},
+	}
+	for _, c := range cases {
+		if got := c.hello.colorProfile(); got != c.want {
+			t.Errorf("%+v: got %v, want %v", c.hello, got, c.want)
+		}
+	}
+}
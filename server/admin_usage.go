@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// usageListing is the Metrics tab's sort over the per-user breakdown
+type usageListing struct {
+	sort string // one of userUsageSorts
+	desc bool
+}
+
+// loadUsage snapshots the per-user and per-channel breakdown in the
+// Metrics tab's order
+func (ap *AdminPanel) loadUsage() {
+	ap.usage = ap.hub.UsageDetail()
+	sortUserUsage(ap.usage.Users, ap.usageList.sort, ap.usageList.desc)
+}
+
+// handleUsageKey applies the Metrics tab's sort keys, reporting whether msg
+// was one of them
+func (ap *AdminPanel) handleUsageKey(msg tea.KeyMsg) bool {
+	l := &ap.usageList
+	switch {
+	case key.Matches(msg, ap.keys.Sort):
+		for i, s := range userUsageSorts {
+			if s == l.sort {
+				l.sort = userUsageSorts[(i+1)%len(userUsageSorts)]
+				break
+			}
+		}
+		// Names read best A-Z, counts and times highest first
+		l.desc = l.sort != "username"
+	case key.Matches(msg, ap.keys.SortOrder):
+		l.desc = !l.desc
+	default:
+		return false
+	}
+	sortUserUsage(ap.usage.Users, l.sort, l.desc)
+	return true
+}
+
+// renderUsage writes the per-user and per-channel tables of the Metrics tab
+func (ap *AdminPanel) renderUsage(doc *strings.Builder) {
+	order := "↑"
+	if ap.usageList.desc {
+		order = "↓"
+	}
+	doc.WriteString(metricLabelStyle.Render(fmt.Sprintf("Per User (sorted by %s %s):\n", ap.usageList.sort, order)))
+	if len(ap.usage.Users) == 0 {
+		doc.WriteString("  No activity yet\n")
+	} else {
+		doc.WriteString(fmt.Sprintf("  %-20s %8s %10s %10s %10s %8s\n", "User", "Msgs", "In", "Out", "Active", "Sessions"))
+		for _, u := range ap.usage.Users {
+			name := u.Username
+			if u.Online {
+				name += " •"
+			}
+			doc.WriteString(fmt.Sprintf("  %-20s %8d %10s %10s %10s %8d\n",
+				name, u.Messages, formatBytes(float64(u.BytesIn)), formatBytes(float64(u.BytesOut)),
+				formatDuration(u.ActiveTime), u.Sessions))
+		}
+	}
+
+	doc.WriteString("\n")
+
+	doc.WriteString(metricLabelStyle.Render("Per Channel:\n"))
+	if len(ap.usage.Channels) == 0 {
+		doc.WriteString("  No messages yet\n")
+		return
+	}
+	doc.WriteString(fmt.Sprintf("  %-20s %8s %10s %6s %10s\n", "Channel", "Msgs", "Bytes", "Users", "Active"))
+	for _, c := range ap.usage.Channels {
+		doc.WriteString(fmt.Sprintf("  %-20s %8d %10s %6d %10s\n",
+			c.Channel, c.Messages, formatBytes(float64(c.Bytes)), c.Users, formatDuration(c.ActiveTime)))
+	}
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// usersPageSize is how many users the Users tab lists at once
+const usersPageSize = 50
+
+// userFilter narrows the Users tab to one status
+type userFilter int
+
+const (
+	userFilterAll userFilter = iota
+	userFilterOnline
+	userFilterBanned
+	userFilterKicked
+	userFilterAdmin
+)
+
+var userFilterNames = []string{"All", "Online", "Banned", "Kicked", "Admin"}
+
+// userSorts are the columns the Users tab cycles through with o
+var userSorts = []SenderSort{SortByMessages, SortByName, SortByLastSeen}
+
+// userListing is the Users tab's search, filter, sort and page
+type userListing struct {
+	search    textinput.Model
+	searching bool // the search box has focus
+	filter    userFilter
+	sort      SenderSort
+	desc      bool
+	page      int
+	total     int // users matching the search and filter
+}
+
+func newUserListing() userListing {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search users"
+	search.CharLimit = 32
+	return userListing{search: search, sort: SortByMessages, desc: true}
+}
+
+// pages is the number of pages for the current total, at least one
+func (l userListing) pages() int {
+	return max(1, (l.total+usersPageSize-1)/usersPageSize)
+}
+
+// loadUsers fetches the current page of users. The database pages through
+// everyone who has posted; users it does not know about (connected, banned or
+// configured admins who never posted) come first and are few.
+func (ap *AdminPanel) loadUsers() {
+	connected := make(map[string]*Client)
+	for client := range ap.hub.clients.all() {
+		if client.username != "" {
+			connected[strings.ToLower(client.username)] = client
+		}
+	}
+	away := ap.hub.awayUsers()
+	banned := toSet(ap.hub.BannedUsers())
+	kicked := toSet(ap.hub.KickedUsers())
+	admins := make(map[string]bool)
+	if ap.config != nil {
+		for _, a := range ap.config.Admins {
+			admins[strings.ToLower(a)] = true
+		}
+	}
+	for name, client := range connected {
+		if client.isAdmin {
+			admins[name] = true
+		}
+	}
+
+	l := &ap.userList
+	q := SenderQuery{Search: strings.TrimSpace(l.search.Value()), Sort: l.sort, Desc: l.desc}
+	var candidates map[string]bool
+	switch l.filter {
+	case userFilterBanned:
+		candidates = toSet(keys(banned))
+	case userFilterKicked:
+		candidates = toSet(keys(kicked))
+	case userFilterAdmin:
+		candidates = toSet(keys(admins))
+	default:
+		// For All only to find online users who never posted
+		candidates = toSet(keys(connected))
+	}
+	if l.filter != userFilterAll {
+		q.Only = keys(candidates)
+	}
+
+	// Candidates with no stored messages
+	posted, _, err := ap.db.QuerySenders(SenderQuery{Only: keys(candidates)})
+	if err != nil {
+		log.Printf("Error loading users: %v", err)
+		return
+	}
+	for _, s := range posted {
+		delete(candidates, strings.ToLower(s.Username))
+	}
+	var extras []string
+	for name := range candidates {
+		if q.matches(name) {
+			extras = append(extras, name)
+		}
+	}
+	sort.Strings(extras)
+
+	var stats []SenderStats
+	for attempt := 0; attempt < 2; attempt++ {
+		offset := l.page * usersPageSize
+		first := min(offset, len(extras))
+		last := min(offset+usersPageSize, len(extras))
+		stats = stats[:0]
+		for _, name := range extras[first:last] {
+			stats = append(stats, SenderStats{Username: name})
+		}
+		page := q
+		page.Offset = max(0, offset-len(extras))
+		page.Limit = usersPageSize - len(stats)
+		rows, dbTotal, err := ap.db.QuerySenders(page)
+		if err != nil {
+			log.Printf("Error loading users: %v", err)
+			return
+		}
+		stats = append(stats, rows...)
+		l.total = len(extras) + dbTotal
+		// The list shrank under the current page: show the last one instead
+		if l.page < l.pages() {
+			break
+		}
+		l.page = l.pages() - 1
+	}
+
+	ap.users = make([]userInfo, 0, len(stats))
+	for _, s := range stats {
+		name := strings.ToLower(s.Username)
+		user := userInfo{
+			Username: s.Username,
+			Status:   "Offline",
+			IP:       "N/A",
+			Messages: s.Messages,
+			LastSeen: s.LastSeen,
+			IsAdmin:  admins[name],
+			IsBanned: banned[name],
+			IsKicked: kicked[name],
+			IsMuted:  ap.hub.IsUserMuted(name),
+		}
+		if client, ok := connected[name]; ok {
+			user.Status = "Online"
+			if away[name] {
+				user.Status = "Away"
+			}
+			user.IP = client.ipAddr
+			user.ConnectedAt = client.connectedAt
+			user.LastSeen = time.Now()
+		}
+		if user.IsBanned {
+			user.Status = "Banned"
+		}
+		ap.users = append(ap.users, user)
+	}
+}
+
+// handleUserListKey applies the Users tab's search, filter, sort and page
+// keys, reporting whether msg was one of them
+func (ap *AdminPanel) handleUserListKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	l := &ap.userList
+	if l.searching {
+		switch msg.String() {
+		case "enter":
+			l.searching = false
+			l.search.Blur()
+		case "esc":
+			l.searching = false
+			l.search.Blur()
+			l.search.SetValue("")
+		case "ctrl+c":
+			return false, nil
+		default:
+			var cmd tea.Cmd
+			l.search, cmd = l.search.Update(msg)
+			l.page = 0
+			ap.reloadUsers()
+			return true, cmd
+		}
+		l.page = 0
+		ap.reloadUsers()
+		return true, nil
+	}
+
+	switch {
+	case key.Matches(msg, ap.keys.Search):
+		l.searching = true
+		return true, l.search.Focus()
+	case key.Matches(msg, ap.keys.Filter):
+		l.filter = (l.filter + 1) % userFilter(len(userFilterNames))
+		l.page = 0
+	case key.Matches(msg, ap.keys.Sort):
+		for i, s := range userSorts {
+			if s == l.sort {
+				l.sort = userSorts[(i+1)%len(userSorts)]
+				break
+			}
+		}
+		// Names read best A-Z, counts and activity highest first
+		l.desc = l.sort != SortByName
+	case key.Matches(msg, ap.keys.SortOrder):
+		l.desc = !l.desc
+	case key.Matches(msg, ap.keys.NextPage):
+		if l.page+1 >= l.pages() {
+			return true, nil
+		}
+		l.page++
+	case key.Matches(msg, ap.keys.PrevPage):
+		if l.page == 0 {
+			return true, nil
+		}
+		l.page--
+	default:
+		return false, nil
+	}
+	ap.reloadUsers()
+	return true, nil
+}
+
+// reloadUsers refreshes the table after the listing changed
+func (ap *AdminPanel) reloadUsers() {
+	ap.loadUsers()
+	ap.updateUserTable()
+	ap.userTable.SetCursor(0)
+}
+
+// userListingStatus summarises the listing above the table
+func (ap *AdminPanel) userListingStatus() string {
+	l := ap.userList
+	search := l.search.View()
+	if !l.searching && l.search.Value() == "" {
+		search = "[/] search"
+	}
+	dir := "↑"
+	if l.desc {
+		dir = "↓"
+	}
+	return fmt.Sprintf("%s   Filter [f]: %s   Sort [o/O]: %s %s   Page [[/]]: %d/%d (%d users)",
+		search, userFilterNames[l.filter], strings.ReplaceAll(string(l.sort), "_", " "), dir,
+		l.page+1, l.pages(), l.total)
+}
+
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
+
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
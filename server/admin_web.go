@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
@@ -9,11 +10,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -42,18 +45,27 @@ type WebAdminServer struct {
 	sessionSecret []byte
 	loginAttempts map[string]*loginAttempt
 	attemptsMutex sync.RWMutex
+	accounts      *adminAccounts
+	twoFactorMu   sync.Mutex
+	twoFactors    map[string]*twoFactor // by account, "" for the admin key
 }
 
 // Session data structure
 type sessionData struct {
-	IsAdmin   bool      `json:"isAdmin"`
-	Expires   time.Time `json:"expires"`
-	CSRFToken string    `json:"csrfToken"`
+	IsAdmin    bool      `json:"isAdmin"`
+	Expires    time.Time `json:"expires"`
+	CSRFToken  string    `json:"csrfToken"`
+	Username   string    `json:"username,omitempty"` // empty for an admin key session
+	Role       string    `json:"role"`
+	Generation int       `json:"generation,omitempty"` // the account's, to revoke sessions
 }
 
 // Login request structure
 type loginRequest struct {
-	Key string `json:"key"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Key      string `json:"key,omitempty"`  // only while no accounts exist
+	Code     string `json:"code,omitempty"` // TOTP or recovery code when 2FA is enabled
 }
 
 // Session management functions
@@ -67,16 +79,19 @@ func (w *WebAdminServer) generateSessionSecret() error {
 	return nil
 }
 
-func (w *WebAdminServer) createSession() (string, error) {
+func (w *WebAdminServer) createSession(username, role string, generation int) (string, error) {
 	csrfToken, err := w.generateCSRFToken()
 	if err != nil {
 		return "", err
 	}
 
 	session := sessionData{
-		IsAdmin:   true,
-		Expires:   time.Now().Add(1 * time.Hour), // 1 hour session
-		CSRFToken: csrfToken,
+		IsAdmin:    true,
+		Expires:    time.Now().Add(1 * time.Hour), // 1 hour session
+		CSRFToken:  csrfToken,
+		Username:   username,
+		Role:       role,
+		Generation: generation,
 	}
 
 	sessionJSON, err := json.Marshal(session)
@@ -95,9 +110,17 @@ func (w *WebAdminServer) createSession() (string, error) {
 }
 
 func (w *WebAdminServer) validateSession(sessionToken string) bool {
+	_, err := w.parseSession(sessionToken)
+	return err == nil
+}
+
+// parseSession verifies a session token and returns its data. Account
+// sessions also end when the account is deleted or its password or role
+// changes, and carry the account's current role.
+func (w *WebAdminServer) parseSession(sessionToken string) (*sessionData, error) {
 	parts := strings.Split(sessionToken, ".")
 	if len(parts) != 2 {
-		return false
+		return nil, fmt.Errorf("invalid session token format")
 	}
 
 	sessionDataB64, signature := parts[0], parts[1]
@@ -105,7 +128,7 @@ func (w *WebAdminServer) validateSession(sessionToken string) bool {
 	// Decode session data
 	sessionJSON, err := base64.StdEncoding.DecodeString(sessionDataB64)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
 	// Verify signature
@@ -114,17 +137,49 @@ func (w *WebAdminServer) validateSession(sessionToken string) bool {
 	expectedSignature := hex.EncodeToString(h.Sum(nil))
 
 	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
-		return false
+		return nil, fmt.Errorf("invalid session signature")
 	}
 
-	// Parse and validate session
+	// Parse session
 	var session sessionData
 	if err := json.Unmarshal(sessionJSON, &session); err != nil {
-		return false
+		return nil, err
 	}
 
 	// Check expiration
-	return session.IsAdmin && time.Now().Before(session.Expires)
+	if !session.IsAdmin || time.Now().After(session.Expires) {
+		return nil, fmt.Errorf("session expired")
+	}
+
+	if session.Username != "" {
+		role, ok := w.accounts.Session(session.Username, session.Generation)
+		if !ok {
+			return nil, fmt.Errorf("session revoked")
+		}
+		session.Role = role
+	}
+	return &session, nil
+}
+
+// requestSession returns the valid session of r, or nil
+func (w *WebAdminServer) requestSession(r *http.Request) *sessionData {
+	cookie, err := r.Cookie("admin_session")
+	if err != nil {
+		return nil
+	}
+	session, err := w.parseSession(cookie.Value)
+	if err != nil {
+		return nil
+	}
+	return session
+}
+
+// actor names whoever made r in logs and moderation records
+func (w *WebAdminServer) actor(r *http.Request) string {
+	if session := w.requestSession(r); session != nil {
+		return adminActor(session.Username)
+	}
+	return adminKeyActor
 }
 
 func (w *WebAdminServer) generateCSRFToken() (string, error) {
@@ -223,39 +278,10 @@ func (w *WebAdminServer) cleanupExpiredSessions() {
 }
 
 func (w *WebAdminServer) getCSRFTokenFromSession(sessionToken string) (string, error) {
-	parts := strings.Split(sessionToken, ".")
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid session token format")
-	}
-
-	sessionDataB64, signature := parts[0], parts[1]
-
-	// Decode session data
-	sessionJSON, err := base64.StdEncoding.DecodeString(sessionDataB64)
+	session, err := w.parseSession(sessionToken)
 	if err != nil {
 		return "", err
 	}
-
-	// Verify signature
-	h := hmac.New(sha256.New, w.sessionSecret)
-	h.Write(sessionJSON)
-	expectedSignature := hex.EncodeToString(h.Sum(nil))
-
-	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
-		return "", fmt.Errorf("invalid session signature")
-	}
-
-	// Parse session
-	var session sessionData
-	if err := json.Unmarshal(sessionJSON, &session); err != nil {
-		return "", err
-	}
-
-	// Check expiration
-	if time.Now().After(session.Expires) {
-		return "", fmt.Errorf("session expired")
-	}
-
 	return session.CSRFToken, nil
 }
 
@@ -293,6 +319,7 @@ type webUserInfo struct {
 	IsAdmin     bool      `json:"is_admin"`
 	IsBanned    bool      `json:"is_banned"`
 	IsKicked    bool      `json:"is_kicked"`
+	IsMuted     bool      `json:"is_muted"`
 }
 
 type webPluginInfo struct {
@@ -306,6 +333,7 @@ type webSystemStats struct {
 	MemoryUsage    float64 `json:"memory_usage"`
 	CPUUsage       float64 `json:"cpu_usage"`
 	ActiveUsers    int     `json:"active_users"`
+	AwayUsers      int     `json:"away_users"`
 	TotalUsers     int     `json:"total_users"`
 	MessagesSent   int     `json:"messages_sent"`
 	PluginsActive  int     `json:"plugins_active"`
@@ -356,6 +384,9 @@ type webConfigInfo struct {
 type webDatabaseInfo struct {
 	Path      string `json:"path"`
 	ConfigDir string `json:"config_dir"`
+	Type      string `json:"type"`
+	Ephemeral bool   `json:"ephemeral"`
+	Warning   string `json:"warning,omitempty"`
 }
 
 // NewWebAdminServer creates a new web admin server with full functionality
@@ -373,6 +404,7 @@ func NewWebAdminServer(hub *Hub, db *DatabaseWrapper, cfg *config.Config) *WebAd
 			LastUpdated:       time.Now(),
 		},
 		loginAttempts: make(map[string]*loginAttempt),
+		twoFactors:    make(map[string]*twoFactor),
 	}
 
 	// Generate session secret
@@ -380,6 +412,12 @@ func NewWebAdminServer(hub *Hub, db *DatabaseWrapper, cfg *config.Config) *WebAd
 		log.Printf("Warning: Failed to generate session secret: %v", err)
 	}
 
+	accounts, err := loadAdminAccounts(filepath.Join(server.configDir(), adminAccountsFile))
+	if err != nil {
+		log.Printf("Warning: Web admin accounts unreadable, refusing web admin logins until %s is fixed or removed: %v", adminAccountsFile, err)
+	}
+	server.accounts = accounts
+
 	// Start cleanup goroutines
 	go server.cleanupRateLimiting()
 	go server.cleanupExpiredSessions()
@@ -387,6 +425,32 @@ func NewWebAdminServer(hub *Hub, db *DatabaseWrapper, cfg *config.Config) *WebAd
 	return server
 }
 
+func (w *WebAdminServer) configDir() string {
+	if w.cfg != nil && w.cfg.ConfigDir != "" {
+		return w.cfg.ConfigDir
+	}
+	return "."
+}
+
+// twoFactorFor returns the 2FA state of an account, or of the admin key for ""
+func (w *WebAdminServer) twoFactorFor(username string) *twoFactor {
+	w.twoFactorMu.Lock()
+	defer w.twoFactorMu.Unlock()
+	if tf, ok := w.twoFactors[username]; ok {
+		return tf
+	}
+	name := twoFactorStateFile
+	if username != "" {
+		name = strings.TrimSuffix(twoFactorStateFile, ".json") + "." + username + ".json"
+	}
+	tf, err := loadTwoFactor(filepath.Join(w.configDir(), name))
+	if err != nil {
+		log.Printf("Warning: Two-factor authentication state unreadable, refusing logins for %s until it is fixed or removed: %v", adminActor(username), err)
+	}
+	w.twoFactors[username] = tf
+	return tf
+}
+
 // RegisterRoutes attaches all web admin routes to mux
 func (w *WebAdminServer) RegisterRoutes(mux *http.ServeMux) {
 	// Login and session routes (no auth required)
@@ -405,12 +469,33 @@ func (w *WebAdminServer) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/admin/api/logs", w.auth(w.handleLogs))
 	mux.HandleFunc("/admin/api/plugins", w.auth(w.handlePlugins))
 	mux.HandleFunc("/admin/api/metrics", w.auth(w.handleMetrics))
-
-	// Action endpoints (CSRF protected)
-	mux.HandleFunc("/admin/api/action/user", w.authWithCSRF(w.handleUserAction))
-	mux.HandleFunc("/admin/api/action/system", w.authWithCSRF(w.handleSystemAction))
-	mux.HandleFunc("/admin/api/action/plugin", w.authWithCSRF(w.handlePluginAction))
-	mux.HandleFunc("/admin/api/action/metrics", w.authWithCSRF(w.handleMetricsAction))
+	mux.HandleFunc("/admin/api/metrics/detail", w.auth(w.handleMetricsDetail))
+	mux.HandleFunc("/admin/api/metrics/history", w.auth(w.handleMetricsHistory))
+	mux.HandleFunc("/admin/api/filters", w.auth(w.handleFilters))
+	mux.HandleFunc("/admin/api/cron", w.auth(w.handleCron))
+	mux.HandleFunc("/admin/api/welcome", w.auth(w.handleWelcome))
+	mux.HandleFunc("/admin/api/notices", w.auth(w.handleNotices))
+
+	// Action endpoints (CSRF protected), each needing at least a role
+	mux.HandleFunc("/admin/api/action/user", w.authWithCSRF(w.requireRole(roleModerator, w.handleUserAction)))
+	mux.HandleFunc("/admin/api/action/system", w.authWithCSRF(w.requireRole(roleAdmin, w.handleSystemAction)))
+	mux.HandleFunc("/admin/api/action/plugin", w.authWithCSRF(w.requireRole(roleAdmin, w.handlePluginAction)))
+	mux.HandleFunc("/admin/api/action/metrics", w.authWithCSRF(w.requireRole(roleAdmin, w.handleMetricsAction)))
+	mux.HandleFunc("/admin/api/action/filter", w.authWithCSRF(w.requireRole(roleModerator, w.handleFilterAction)))
+	mux.HandleFunc("/admin/api/action/cron", w.authWithCSRF(w.requireRole(roleAdmin, w.handleCronAction)))
+	mux.HandleFunc("/admin/api/action/notice", w.authWithCSRF(w.requireRole(roleModerator, w.handleNoticeAction)))
+	mux.HandleFunc("/admin/api/action/maintenance", w.authWithCSRF(w.requireRole(roleAdmin, w.handleMaintenanceAction)))
+	mux.HandleFunc("/admin/api/action/welcome", w.authWithCSRF(w.requireRole(roleAdmin, w.handleWelcomeAction)))
+	mux.HandleFunc("/admin/api/action/pairing", w.authWithCSRF(w.requireRole(roleModerator, w.handlePairingAction)))
+
+	// The signed-in account's own settings
+	mux.HandleFunc("/admin/api/2fa", w.auth(w.handleTwoFactor))
+	mux.HandleFunc("/admin/api/action/2fa", w.authWithCSRF(w.requireRole(roleViewer, w.handleTwoFactorAction)))
+	mux.HandleFunc("/admin/api/action/password", w.authWithCSRF(w.requireRole(roleViewer, w.handlePasswordAction)))
+
+	// Account management
+	mux.HandleFunc("/admin/api/accounts", w.auth(w.requireRole(roleOwner, w.handleAccounts)))
+	mux.HandleFunc("/admin/api/action/account", w.authWithCSRF(w.requireRole(roleOwner, w.handleAccountAction)))
 
 	// Utility endpoints
 	mux.HandleFunc("/admin/api/refresh", w.auth(w.handleRefresh))
@@ -452,6 +537,47 @@ func (w *WebAdminServer) authWithCSRF(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// requireRole refuses sessions whose role is below min and records every
+// state-changing request against the account that made it
+func (w *WebAdminServer) requireRole(min string, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		session := w.requestSession(r)
+		if session == nil {
+			rw.WriteHeader(http.StatusUnauthorized)
+			writeJSON(rw, map[string]string{"error": "Unauthorized"})
+			return
+		}
+		if !roleAtLeast(session.Role, min) {
+			rw.WriteHeader(http.StatusForbidden)
+			writeJSON(rw, map[string]string{"error": fmt.Sprintf("Requires the %s role", min)})
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			var req struct {
+				Action   string `json:"action"`
+				Username string `json:"username"`
+				Plugin   string `json:"plugin"`
+			}
+			_ = json.Unmarshal(body, &req)
+			target := req.Username
+			if target == "" {
+				target = req.Plugin
+			}
+			AdminLogger.Info("Web admin action", map[string]interface{}{
+				"actor":  adminActor(session.Username),
+				"path":   r.URL.Path,
+				"action": req.Action,
+				"target": target,
+				"ip":     r.RemoteAddr,
+			})
+		}
+		next(rw, r)
+	}
+}
+
 func (w *WebAdminServer) handleLogin(rw http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
@@ -477,23 +603,79 @@ func (w *WebAdminServer) handleLogin(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate admin key with constant-time comparison
-	if req.Key == "" || !hmac.Equal([]byte(req.Key), []byte(w.cfg.AdminKey)) {
+	// Named accounts sign in with a password. The admin key only works
+	// while none exist, to create the first ones.
+	var username, role string
+	var generation int
+	switch {
+	case req.Username != "":
+		acct, ok := w.accounts.Authenticate(req.Username, req.Password)
+		if !ok {
+			w.recordFailedAttempt(clientIP)
+			log.Printf("Security: Failed login attempt for %s from IP %s", req.Username, clientIP)
+			writeJSON(rw, map[string]interface{}{
+				"success": false,
+				"message": "Invalid username or password",
+			})
+			return
+		}
+		username, role, generation = acct.Username, acct.Role, acct.Generation
+	case !w.accounts.Empty():
 		w.recordFailedAttempt(clientIP)
-		log.Printf("Security: Failed login attempt from IP %s", clientIP)
+		log.Printf("Security: Admin key login refused from IP %s, accounts are in use", clientIP)
 		writeJSON(rw, map[string]interface{}{
 			"success": false,
-			"message": "Invalid admin key",
+			"message": "Sign in with your admin account",
 		})
 		return
+	default:
+		// Validate admin key with constant-time comparison
+		if req.Key == "" || !hmac.Equal([]byte(req.Key), []byte(w.cfg.AdminKey)) {
+			w.recordFailedAttempt(clientIP)
+			log.Printf("Security: Failed login attempt from IP %s", clientIP)
+			writeJSON(rw, map[string]interface{}{
+				"success": false,
+				"message": "Invalid admin key",
+			})
+			return
+		}
+		role = roleOwner
+	}
+
+	// With 2FA enabled the password alone is not enough. Asking for the code
+	// is not a failure, but a wrong code counts toward the lockout.
+	if tf := w.twoFactorFor(username); tf.Enabled() {
+		if strings.TrimSpace(req.Code) == "" {
+			writeJSON(rw, map[string]interface{}{
+				"success":             false,
+				"two_factor_required": true,
+				"message":             "Enter the code from your authenticator app or a recovery code",
+			})
+			return
+		}
+		recovery, ok := tf.Verify(req.Code, time.Now())
+		if !ok {
+			w.recordFailedAttempt(clientIP)
+			log.Printf("Security: Failed two-factor code for %s from IP %s", adminActor(username), clientIP)
+			writeJSON(rw, map[string]interface{}{
+				"success":             false,
+				"two_factor_required": true,
+				"message":             "Invalid two-factor code",
+			})
+			return
+		}
+		if recovery {
+			log.Printf("Security: Recovery code used by %s from IP %s (%d left)", adminActor(username), clientIP, tf.RecoveryCodesLeft())
+		}
 	}
 
 	// Clear failed attempts on successful login
 	w.clearFailedAttempts(clientIP)
-	log.Printf("Security: Successful admin login from IP %s", clientIP)
+	w.accounts.RecordLogin(username, time.Now())
+	log.Printf("Security: Successful admin login for %s from IP %s", adminActor(username), clientIP)
 
 	// Create session
-	sessionToken, err := w.createSession()
+	sessionToken, err := w.createSession(username, role, generation)
 	if err != nil {
 		log.Printf("Error creating session: %v", err)
 		http.Error(rw, "Internal server error", http.StatusInternalServerError)
@@ -512,8 +694,10 @@ func (w *WebAdminServer) handleLogin(rw http.ResponseWriter, r *http.Request) {
 	})
 
 	writeJSON(rw, map[string]interface{}{
-		"success": true,
-		"message": "Login successful",
+		"success":  true,
+		"message":  "Login successful",
+		"username": username,
+		"role":     role,
 	})
 }
 
@@ -523,14 +707,22 @@ func (w *WebAdminServer) handleSessionCheck(rw http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	cookie, err := r.Cookie("admin_session")
-	if err != nil || !w.validateSession(cookie.Value) {
+	session := w.requestSession(r)
+	if session == nil {
 		rw.WriteHeader(http.StatusUnauthorized)
-		writeJSON(rw, map[string]bool{"authenticated": false})
+		writeJSON(rw, map[string]bool{
+			"authenticated": false,
+			"key_login":     w.accounts.Empty(), // which login form to show
+		})
 		return
 	}
 
-	writeJSON(rw, map[string]bool{"authenticated": true})
+	writeJSON(rw, map[string]interface{}{
+		"authenticated": true,
+		"username":      session.Username,
+		"role":          session.Role,
+		"key_login":     w.accounts.Empty(),
+	})
 }
 
 func (w *WebAdminServer) handleCSRFToken(rw http.ResponseWriter, r *http.Request) {
@@ -592,6 +784,97 @@ func (w *WebAdminServer) handleMetrics(rw http.ResponseWriter, r *http.Request)
 	writeJSON(rw, w.metrics)
 }
 
+// handleMetricsDetail returns per-user and per-channel usage. The user rows
+// can be sorted with ?sort=messages|bytes|active|username&order=asc|desc.
+func (w *WebAdminServer) handleMetricsDetail(rw http.ResponseWriter, r *http.Request) {
+	detail := w.hub.UsageDetail()
+	if by := r.URL.Query().Get("sort"); by != "" {
+		if !slices.Contains(userUsageSorts, by) {
+			http.Error(rw, "Unknown sort column", http.StatusBadRequest)
+			return
+		}
+		sortUserUsage(detail.Users, by, r.URL.Query().Get("order") != "asc")
+	}
+	writeJSON(rw, detail)
+}
+
+// historyPoint is one bucket of stored metrics history
+type historyPoint struct {
+	Time        time.Time `json:"time"`
+	PeakUsers   int       `json:"peak_users"`
+	Connections int       `json:"connections"`
+	Messages    int       `json:"messages"`
+	PeakMemory  uint64    `json:"peak_memory"`
+}
+
+// handleMetricsHistory returns the stored metrics for ?range=hour|day|week
+func (w *WebAdminServer) handleMetricsHistory(rw http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("range")
+	if name == "" {
+		name = "day"
+	}
+	if _, ok := metricsRanges[name]; !ok {
+		http.Error(rw, "Unknown range", http.StatusBadRequest)
+		return
+	}
+	rollups, err := w.hub.MetricsHistory(name, time.Now())
+	if err != nil {
+		http.Error(rw, "Failed to load metrics history", http.StatusInternalServerError)
+		return
+	}
+	points := make([]historyPoint, 0, len(rollups))
+	for _, b := range rollups {
+		points = append(points, historyPoint{
+			Time:        b.Bucket,
+			PeakUsers:   b.PeakUsers,
+			Connections: b.Connections,
+			Messages:    b.Messages,
+			PeakMemory:  b.PeakMemory,
+		})
+	}
+	writeJSON(rw, map[string]interface{}{
+		"range":      name,
+		"resolution": metricsRanges[name].resolution,
+		"points":     points,
+	})
+}
+
+func (w *WebAdminServer) handleFilters(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, w.hub.FilterRules())
+}
+
+// handleCron lists the cron jobs with their next runs
+func (w *WebAdminServer) handleCron(rw http.ResponseWriter, r *http.Request) {
+	jobs, err := w.hub.CronJobs()
+	if err != nil {
+		http.Error(rw, "Failed to load cron jobs", http.StatusInternalServerError)
+		return
+	}
+	if jobs == nil {
+		jobs = []CronJobStatus{}
+	}
+	writeJSON(rw, jobs)
+}
+
+func (w *WebAdminServer) handleWelcome(rw http.ResponseWriter, r *http.Request) {
+	cfg := w.hub.WelcomeConfig()
+	writeJSON(rw, map[string]interface{}{
+		"enabled":    cfg.Enabled,
+		"message":    cfg.Message,
+		"rules":      cfg.Rules,
+		"updated_by": cfg.UpdatedBy,
+		"updated_at": cfg.UpdatedAt,
+	})
+}
+
+func (w *WebAdminServer) handleNotices(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, map[string]interface{}{
+		"topic":       w.hub.Topic(roomChannel),
+		"motd":        w.hub.MOTD(),
+		"maintenance": w.hub.Maintenance(),
+	})
+}
+
 func (w *WebAdminServer) handleUserAction(rw http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		rw.WriteHeader(http.StatusMethodNotAllowed)
@@ -615,27 +898,38 @@ func (w *WebAdminServer) handleUserAction(rw http.ResponseWriter, r *http.Reques
 
 	switch req.Action {
 	case "ban":
-		w.hub.BanUser(req.Username, "web-admin")
+		w.hub.BanUser(req.Username, w.actor(r))
 		message = fmt.Sprintf("User '%s' has been banned", req.Username)
 		success = true
 	case "unban":
-		success = w.hub.UnbanUser(req.Username, "web-admin")
+		success = w.hub.UnbanUser(req.Username, w.actor(r))
 		if success {
 			message = fmt.Sprintf("User '%s' has been unbanned", req.Username)
 		} else {
 			message = fmt.Sprintf("User '%s' was not found in ban list", req.Username)
 		}
 	case "kick":
-		w.hub.KickUser(req.Username, "web-admin")
+		w.hub.KickUser(req.Username, w.actor(r))
 		message = fmt.Sprintf("User '%s' has been kicked (24h)", req.Username)
 		success = true
 	case "allow":
-		success = w.hub.AllowUser(req.Username, "web-admin")
+		success = w.hub.AllowUser(req.Username, w.actor(r))
 		if success {
 			message = fmt.Sprintf("User '%s' has been allowed back", req.Username)
 		} else {
 			message = fmt.Sprintf("User '%s' was not found in kick list", req.Username)
 		}
+	case "mute":
+		until := w.hub.MuteUser(req.Username, w.actor(r), defaultMuteDuration)
+		message = fmt.Sprintf("User '%s' is shadow-muted until %s", req.Username, until.Format("15:04"))
+		success = true
+	case "unmute":
+		success = w.hub.UnmuteUser(req.Username, w.actor(r))
+		if success {
+			message = fmt.Sprintf("User '%s' has been unmuted", req.Username)
+		} else {
+			message = fmt.Sprintf("User '%s' is not muted", req.Username)
+		}
 	case "make_admin":
 		// This would require additional implementation in the hub
 		message = "Make admin functionality not yet implemented"
@@ -873,6 +1167,532 @@ func (w *WebAdminServer) handleMetricsAction(rw http.ResponseWriter, r *http.Req
 	}
 }
 
+func (w *WebAdminServer) handleNoticeAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	type noticeActionReq struct {
+		Action string `json:"action"`
+		Text   string `json:"text"`
+	}
+
+	var req noticeActionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	var err error
+	var message string
+	switch req.Action {
+	case "topic":
+		_, err = w.hub.SetTopic(roomChannel, req.Text, w.actor(r))
+		message = "Topic updated"
+	case "motd":
+		_, err = w.hub.SetMOTD(req.Text, w.actor(r))
+		message = "Message of the day updated"
+	default:
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid action"})
+		return
+	}
+	if err != nil {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("Could not save: %v", err),
+		})
+		return
+	}
+	writeJSON(rw, map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+func (w *WebAdminServer) handleMaintenanceAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	type maintenanceActionReq struct {
+		Action  string    `json:"action"`
+		Start   time.Time `json:"start"`
+		Minutes int       `json:"minutes"`
+		Message string    `json:"message"`
+	}
+
+	var req maintenanceActionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	var err error
+	var message string
+	switch req.Action {
+	case "schedule":
+		m, scheduleErr := w.hub.ScheduleMaintenance(req.Start, req.Start.Add(time.Duration(req.Minutes)*time.Minute), req.Message, w.actor(r))
+		err = scheduleErr
+		message = fmt.Sprintf("Maintenance scheduled for %s", m.Start.Format("2006-01-02 15:04 MST"))
+	case "clear":
+		err = w.hub.ClearMaintenance(w.actor(r))
+		message = "Maintenance window cleared"
+	default:
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid action"})
+		return
+	}
+	if err != nil {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("Could not save: %v", err),
+		})
+		return
+	}
+	writeJSON(rw, map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+func (w *WebAdminServer) handleWelcomeAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	type welcomeActionReq struct {
+		Enabled bool   `json:"enabled"`
+		Message string `json:"message"`
+		Rules   string `json:"rules"`
+	}
+
+	var req welcomeActionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	if _, err := w.hub.SetWelcomeConfig(req.Enabled, req.Message, req.Rules, w.actor(r)); err != nil {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("Could not save welcome bot: %v", err),
+		})
+		return
+	}
+	message := "Welcome bot disabled"
+	if req.Enabled {
+		message = "Welcome bot enabled"
+	}
+	writeJSON(rw, map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+func (w *WebAdminServer) handleFilterAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	type filterActionReq struct {
+		Action     string `json:"action"`
+		Pattern    string `json:"pattern"`
+		IsRegex    bool   `json:"is_regex"`
+		FilterMode string `json:"filter_action"`
+		ID         int64  `json:"id"`
+	}
+
+	var req filterActionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	var message string
+	var success bool
+
+	switch req.Action {
+	case "add":
+		mode := req.FilterMode
+		if mode == "" {
+			mode = filterActionMask
+		}
+		rule, err := w.hub.AddFilterRule(req.Pattern, req.IsRegex, mode, w.actor(r))
+		if err != nil {
+			message = fmt.Sprintf("Could not add filter rule: %v", err)
+		} else {
+			message = fmt.Sprintf("Added filter rule #%d", rule.ID)
+			success = true
+		}
+	case "remove":
+		if err := w.hub.RemoveFilterRule(req.ID, w.actor(r)); err != nil {
+			message = fmt.Sprintf("Could not remove filter rule: %v", err)
+		} else {
+			message = fmt.Sprintf("Removed filter rule #%d", req.ID)
+			success = true
+		}
+	default:
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid action"})
+		return
+	}
+
+	writeJSON(rw, map[string]interface{}{
+		"success": success,
+		"message": message,
+	})
+}
+
+func (w *WebAdminServer) handleCronAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	type cronActionReq struct {
+		Action   string `json:"action"`
+		Schedule string `json:"schedule"`
+		Post     string `json:"post"` // message or announce
+		Message  string `json:"message"`
+		ID       int64  `json:"id"`
+	}
+
+	var req cronActionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid request"})
+		return
+	}
+
+	var message string
+	var success bool
+
+	switch req.Action {
+	case "add":
+		post := req.Post
+		if post == "" {
+			post = cronActionMessage
+		}
+		job, err := w.hub.AddCronJob(req.Schedule, post, req.Message, w.actor(r))
+		if err != nil {
+			message = fmt.Sprintf("Could not add cron job: %v", err)
+		} else {
+			message = fmt.Sprintf("Added cron job #%d", job.ID)
+			success = true
+		}
+	case "remove":
+		if err := w.hub.RemoveCronJob(req.ID, w.actor(r)); err != nil {
+			message = fmt.Sprintf("Could not remove cron job: %v", err)
+		} else {
+			message = fmt.Sprintf("Removed cron job #%d", req.ID)
+			success = true
+		}
+	case "pause", "resume":
+		if _, err := w.hub.SetCronJobEnabled(req.ID, req.Action == "resume", w.actor(r)); err != nil {
+			message = fmt.Sprintf("Could not %s cron job: %v", req.Action, err)
+		} else if req.Action == "resume" {
+			message = fmt.Sprintf("Resumed cron job #%d", req.ID)
+			success = true
+		} else {
+			message = fmt.Sprintf("Paused cron job #%d", req.ID)
+			success = true
+		}
+	case "run":
+		if err := w.hub.RunCronJob(req.ID, w.actor(r)); err != nil {
+			message = fmt.Sprintf("Could not run cron job: %v", err)
+		} else {
+			message = fmt.Sprintf("Ran cron job #%d", req.ID)
+			success = true
+		}
+	default:
+		rw.WriteHeader(http.StatusBadRequest)
+		writeJSON(rw, map[string]string{"error": "Invalid action"})
+		return
+	}
+
+	writeJSON(rw, map[string]interface{}{
+		"success": success,
+		"message": message,
+	})
+}
+
+// handlePairingAction creates an invite and returns it as a link and a PNG
+// QR code for phones to scan
+func (w *WebAdminServer) handlePairingAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	port, tls := 8080, r.TLS != nil
+	if w.cfg != nil {
+		port = w.cfg.Port
+		tls = tls || w.cfg.IsTLSEnabled()
+	}
+	link, inv := w.hub.PairingLink(r.Host, port, tls, w.actor(r))
+	png, err := pairingQRPNG(link.String())
+	if err != nil {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": fmt.Sprintf("Could not render QR code: %v", err),
+		})
+		return
+	}
+
+	writeJSON(rw, map[string]interface{}{
+		"success": true,
+		"message": "Pairing invite created",
+		"link":    link.String(),
+		"qr":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		"expires": inv.ExpiresAt.Format("2006-01-02 15:04"),
+	})
+}
+
+// sessionTwoFactor returns the 2FA state of the account signed in on r
+func (w *WebAdminServer) sessionTwoFactor(r *http.Request) (*twoFactor, string) {
+	username := ""
+	if session := w.requestSession(r); session != nil {
+		username = session.Username
+	}
+	return w.twoFactorFor(username), username
+}
+
+// handleTwoFactor reports whether 2FA is enabled for the signed-in account
+func (w *WebAdminServer) handleTwoFactor(rw http.ResponseWriter, r *http.Request) {
+	tf, _ := w.sessionTwoFactor(r)
+	writeJSON(rw, map[string]interface{}{
+		"enabled":        tf.Enabled(),
+		"recovery_codes": tf.RecoveryCodesLeft(),
+	})
+}
+
+// handleTwoFactorAction sets up, enables and disables 2FA and regenerates
+// recovery codes. Everything but setup needs a current code.
+func (w *WebAdminServer) handleTwoFactorAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		Code   string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	fail := func(err error) {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+	now := time.Now()
+	tf, username := w.sessionTwoFactor(r)
+
+	switch req.Action {
+	case "setup":
+		if tf.Enabled() {
+			fail(fmt.Errorf("two-factor authentication is already enabled"))
+			return
+		}
+		account := "admin@" + r.Host
+		if username != "" {
+			account = username + "@" + r.Host
+		}
+		secret, uri, err := tf.Begin("marchat", account, now)
+		if err != nil {
+			fail(err)
+			return
+		}
+		png, err := pairingQRPNG(uri)
+		if err != nil {
+			fail(fmt.Errorf("could not render QR code: %v", err))
+			return
+		}
+		writeJSON(rw, map[string]interface{}{
+			"success": true,
+			"message": "Scan the QR code, then enter a code to confirm",
+			"secret":  secret,
+			"uri":     uri,
+			"qr":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		})
+
+	case "enable":
+		codes, err := tf.Confirm(req.Code, now)
+		if err != nil {
+			fail(err)
+			return
+		}
+		log.Printf("Security: Two-factor authentication enabled for %s", adminActor(username))
+		writeJSON(rw, map[string]interface{}{
+			"success":        true,
+			"message":        "Two-factor authentication enabled",
+			"recovery_codes": codes,
+		})
+
+	case "disable":
+		if err := tf.Disable(req.Code, now); err != nil {
+			fail(err)
+			return
+		}
+		log.Printf("Security: Two-factor authentication disabled for %s", adminActor(username))
+		writeJSON(rw, map[string]interface{}{
+			"success": true,
+			"message": "Two-factor authentication disabled",
+		})
+
+	case "regenerate":
+		codes, err := tf.RegenerateRecoveryCodes(req.Code, now)
+		if err != nil {
+			fail(err)
+			return
+		}
+		writeJSON(rw, map[string]interface{}{
+			"success":        true,
+			"message":        "New recovery codes generated",
+			"recovery_codes": codes,
+		})
+
+	default:
+		fail(fmt.Errorf("unknown action: %s", req.Action))
+	}
+}
+
+// handlePasswordAction changes the signed-in account's own password, which
+// signs it out everywhere
+func (w *WebAdminServer) handlePasswordAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Current string `json:"current"`
+		New     string `json:"new"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	session := w.requestSession(r)
+	if session == nil || session.Username == "" {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": "The admin key has no password; create an account first",
+		})
+		return
+	}
+	if _, ok := w.accounts.Authenticate(session.Username, req.Current); !ok {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": "Current password is wrong",
+		})
+		return
+	}
+	if err := w.accounts.SetPassword(session.Username, req.New); err != nil {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("Security: %s changed their password", adminActor(session.Username))
+	writeJSON(rw, map[string]interface{}{
+		"success": true,
+		"message": "Password changed, sign in again",
+	})
+}
+
+// handleAccounts lists the web admin accounts
+func (w *WebAdminServer) handleAccounts(rw http.ResponseWriter, r *http.Request) {
+	accounts := w.accounts.List()
+	for i := range accounts {
+		accounts[i].TwoFactor = w.twoFactorFor(accounts[i].Username).Enabled()
+	}
+	writeJSON(rw, accounts)
+}
+
+// handleAccountAction creates and deletes accounts and changes their role or
+// password. Changing either, or deleting the account, ends its sessions.
+func (w *WebAdminServer) handleAccountAction(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Action   string `json:"action"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	var message string
+	actor := w.actor(r)
+
+	switch req.Action {
+	case "create":
+		err = w.accounts.Create(req.Username, req.Password, req.Role, actor)
+		message = fmt.Sprintf("Account '%s' created as %s", req.Username, req.Role)
+	case "delete":
+		if err = w.accounts.Delete(req.Username); err == nil {
+			w.forgetTwoFactor(req.Username)
+		}
+		message = fmt.Sprintf("Account '%s' deleted", req.Username)
+	case "set_role":
+		err = w.accounts.SetRole(req.Username, req.Role)
+		message = fmt.Sprintf("Account '%s' is now %s", req.Username, req.Role)
+	case "set_password":
+		err = w.accounts.SetPassword(req.Username, req.Password)
+		message = fmt.Sprintf("Password of '%s' changed", req.Username)
+	default:
+		err = fmt.Errorf("unknown action: %s", req.Action)
+	}
+
+	if err != nil {
+		writeJSON(rw, map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	log.Printf("Security: %s: %s", actor, message)
+	writeJSON(rw, map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+// forgetTwoFactor removes a deleted account's 2FA state
+func (w *WebAdminServer) forgetTwoFactor(username string) {
+	tf := w.twoFactorFor(username)
+	w.twoFactorMu.Lock()
+	delete(w.twoFactors, username)
+	w.twoFactorMu.Unlock()
+	if err := os.Remove(tf.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Could not remove two-factor state of %s: %v", adminActor(username), err)
+	}
+}
+
 func (w *WebAdminServer) handleRefresh(rw http.ResponseWriter, r *http.Request) {
 	// Force refresh all data
 	w.updateMetrics()
@@ -898,13 +1718,27 @@ func (w *WebAdminServer) getOverviewData() webOverviewData {
 			TLSCertFile:    w.cfg.TLSCertFile,
 			TLSKeyFile:     w.cfg.TLSKeyFile,
 		},
-		Database: webDatabaseInfo{
-			Path:      w.cfg.DBPath,
-			ConfigDir: w.cfg.ConfigDir,
-		},
+		Database: w.getDatabaseInfo(),
 	}
 }
 
+func (w *WebAdminServer) getDatabaseInfo() webDatabaseInfo {
+	info := webDatabaseInfo{
+		Path:      w.cfg.DBPath,
+		ConfigDir: w.cfg.ConfigDir,
+		Type:      w.cfg.DBType,
+		Ephemeral: w.cfg.IsEphemeralDB(),
+	}
+	if info.Ephemeral {
+		info.Path = "(in-memory)"
+		info.Warning = "In-memory database: all messages are lost when the server stops"
+		if w.cfg.MemoryTTL > 0 {
+			info.Warning += fmt.Sprintf(" (messages expire after %s)", w.cfg.MemoryTTL)
+		}
+	}
+	return info
+}
+
 func (w *WebAdminServer) getSystemStats() webSystemStats {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -938,7 +1772,8 @@ func (w *WebAdminServer) getSystemStats() webSystemStats {
 	return webSystemStats{
 		Uptime:         w.formatDuration(uptime),
 		MemoryUsage:    float64(m.Alloc) / 1024 / 1024,
-		ActiveUsers:    len(w.hub.clients),
+		ActiveUsers:    w.hub.clients.len(),
+		AwayUsers:      w.hub.AwayUsers(),
 		TotalUsers:     userCount,
 		MessagesSent:   messageCount,
 		PluginsActive:  activePlugins,
@@ -966,7 +1801,7 @@ func (w *WebAdminServer) getUsersData() []webUserInfo {
 
 	// Get connected users from hub
 	connectedUsers := make(map[string]*Client)
-	for client := range w.hub.clients {
+	for client := range w.hub.clients.all() {
 		if client.username != "" {
 			connectedUsers[client.username] = client
 		}
@@ -987,9 +1822,14 @@ func (w *WebAdminServer) getUsersData() []webUserInfo {
 	}
 
 	// Update with connected users
+	away := w.hub.awayUsers()
 	for username, client := range connectedUsers {
+		status := "Online"
+		if away[strings.ToLower(username)] {
+			status = "Away"
+		}
 		if user, exists := userMap[username]; exists {
-			user.Status = "Online"
+			user.Status = status
 			user.IP = client.ipAddr
 			user.ConnectedAt = time.Now() // Simplified
 			user.LastSeen = time.Now()
@@ -997,7 +1837,7 @@ func (w *WebAdminServer) getUsersData() []webUserInfo {
 		} else {
 			userMap[username] = &webUserInfo{
 				Username:    username,
-				Status:      "Online",
+				Status:      status,
 				IP:          client.ipAddr,
 				ConnectedAt: time.Now(),
 				LastSeen:    time.Now(),
@@ -1010,6 +1850,7 @@ func (w *WebAdminServer) getUsersData() []webUserInfo {
 	// Check ban/kick status
 	for username, user := range userMap {
 		user.IsBanned = w.hub.IsUserBanned(username)
+		user.IsMuted = w.hub.IsUserMuted(username)
 		if user.IsBanned {
 			user.Status = "Banned"
 		}
@@ -1048,6 +1889,7 @@ func (w *WebAdminServer) getSystemData() map[string]interface{} {
 			"config_dir":       w.cfg.ConfigDir,
 			"log_level":        w.cfg.LogLevel,
 			"max_file_size":    fmt.Sprintf("%.1f MB", float64(w.cfg.MaxFileBytes)/1024/1024),
+			"max_message_size": fmt.Sprintf("%d bytes", w.hub.MaxMessageBytes()),
 			"admin_users":      strings.Join(w.cfg.Admins, ", "),
 			"tls_enabled":      w.cfg.IsTLSEnabled(),
 			"tls_cert_file":    w.cfg.TLSCertFile,
@@ -1056,6 +1898,11 @@ func (w *WebAdminServer) getSystemData() map[string]interface{} {
 			"admin_key":        w.maskSecret(w.cfg.AdminKey),
 			"ban_history_gaps": w.cfg.BanGapsHistory,
 			"plugin_registry":  w.cfg.PluginRegistryURL,
+			"multi_session":    w.cfg.AllowMultiSession,
+			"spectators":       w.cfg.AllowSpectators,
+			"ascii_art":        w.cfg.AllowASCIIArt,
+			"join_pow_bits":    w.cfg.JoinPoWBits,
+			"join_passphrase":  w.maskSecret(w.cfg.JoinPassphrase),
 		},
 	}
 }
@@ -1115,7 +1962,7 @@ func (w *WebAdminServer) updateMetrics() {
 	// Add connection point
 	w.metrics.ConnectionHistory = append(w.metrics.ConnectionHistory, connectionPoint{
 		Time:  currentTime,
-		Count: len(w.hub.clients),
+		Count: w.hub.clients.len(),
 	})
 
 	// Get current message count using Database interface
@@ -1152,8 +1999,8 @@ func (w *WebAdminServer) updateMetrics() {
 	}
 
 	// Update peak values
-	if len(w.hub.clients) > w.metrics.PeakUsers {
-		w.metrics.PeakUsers = len(w.hub.clients)
+	if w.hub.clients.len() > w.metrics.PeakUsers {
+		w.metrics.PeakUsers = w.hub.clients.len()
 	}
 	if m.Alloc > w.metrics.PeakMemory {
 		w.metrics.PeakMemory = m.Alloc
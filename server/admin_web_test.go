@@ -60,7 +60,7 @@ func TestAdminWeb_LoginSessionAndProtectedRoutes(t *testing.T) {
 	defer cleanup()
 
 	// Create a database wrapper for the test
-	dbPath := "test_admin_web.db"
+	dbPath := filepath.Join(t.TempDir(), "test_admin_web.db")
 	dbWrapper := NewDatabaseWrapper(NewSQLiteDB())
 	if err := dbWrapper.db.Open(DatabaseConfig{Type: "sqlite", FilePath: dbPath}); err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// welcomeEditor is the System tab's editor for the welcome bot's message
+// and rules: tab switches field, ctrl+s saves and esc discards
+type welcomeEditor struct {
+	fields [2]textarea.Model // message, rules
+	focus  int
+}
+
+func newWelcomeEditor(cfg WelcomeConfig, width int) *welcomeEditor {
+	e := &welcomeEditor{}
+	for i, value := range []string{cfg.Message, cfg.Rules} {
+		ta := textarea.New()
+		ta.CharLimit = maxWelcomeText
+		ta.ShowLineNumbers = false
+		ta.SetWidth(max(30, width-4))
+		ta.SetHeight(4)
+		ta.SetValue(value)
+		e.fields[i] = ta
+	}
+	e.fields[0].Placeholder = defaultWelcomeMessage
+	e.fields[1].Placeholder = "Server rules, one per line"
+	e.fields[0].Focus()
+	return e
+}
+
+// handleWelcomeKey handles the System tab's welcome bot keys, and every key
+// while the editor is open
+func (ap *AdminPanel) handleWelcomeKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	e := ap.welcomeEdit
+	if e == nil {
+		switch {
+		case key.Matches(msg, ap.keys.Welcome):
+			ap.welcomeEdit = newWelcomeEditor(ap.hub.WelcomeConfig(), ap.width-12)
+			return true, textarea.Blink
+		case key.Matches(msg, ap.keys.WelcomeOnOff):
+			cfg := ap.hub.WelcomeConfig()
+			ap.saveWelcome(!cfg.Enabled, cfg.Message, cfg.Rules)
+			return true, nil
+		}
+		return false, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return false, nil
+	case "esc":
+		ap.welcomeEdit = nil
+		ap.message = "Welcome bot changes discarded"
+		ap.messageTimer = 3
+		return true, nil
+	case "ctrl+s":
+		ap.welcomeEdit = nil
+		ap.saveWelcome(ap.hub.WelcomeConfig().Enabled, e.fields[0].Value(), e.fields[1].Value())
+		return true, nil
+	case "tab", "shift+tab":
+		e.fields[e.focus].Blur()
+		e.focus = 1 - e.focus
+		return true, e.fields[e.focus].Focus()
+	}
+	var cmd tea.Cmd
+	e.fields[e.focus], cmd = e.fields[e.focus].Update(msg)
+	return true, cmd
+}
+
+func (ap *AdminPanel) saveWelcome(enabled bool, message, rules string) {
+	if _, err := ap.hub.SetWelcomeConfig(enabled, message, rules, "admin"); err != nil {
+		ap.message = fmt.Sprintf("❌ Could not save welcome bot: %v", err)
+	} else if enabled {
+		ap.message = "👋 Welcome bot enabled"
+	} else {
+		ap.message = "👋 Welcome bot disabled"
+	}
+	ap.messageTimer = 3
+}
+
+// renderWelcome shows the welcome bot's settings on the System tab, or the
+// editor while it is open
+func (ap *AdminPanel) renderWelcome() string {
+	var b strings.Builder
+	b.WriteString(subtitleStyle.Render("Welcome Bot:\n"))
+	if e := ap.welcomeEdit; e != nil {
+		b.WriteString("  Message ({user} is the new user's name):\n")
+		b.WriteString(e.fields[0].View() + "\n")
+		b.WriteString("  Rules:\n")
+		b.WriteString(e.fields[1].View() + "\n")
+		b.WriteString(infoStylePanel.Render("[tab] switch field, [ctrl+s] save, [esc] discard") + "\n")
+		return b.String()
+	}
+	cfg := ap.hub.WelcomeConfig()
+	status := warningStylePanel.Render("Disabled")
+	if cfg.Enabled {
+		status = statusStyle.Render("Enabled")
+	}
+	b.WriteString(fmt.Sprintf("  Status: %s\n", status))
+	message := cfg.Message
+	if message == "" {
+		message = defaultWelcomeMessage + " (default)"
+	}
+	b.WriteString(fmt.Sprintf("  Message: %s\n", firstLine(message)))
+	if cfg.Rules != "" {
+		b.WriteString(fmt.Sprintf("  Rules: %d lines\n", strings.Count(cfg.Rules, "\n")+1))
+	}
+	if cfg.UpdatedBy != "" {
+		b.WriteString(fmt.Sprintf("  Last Changed: %s by %s\n", cfg.UpdatedAt.Format("2006-01-02 15:04"), cfg.UpdatedBy))
+	}
+	return b.String()
+}
+
+// firstLine is s up to its first newline, marked when cut short
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " …"
+	}
+	return s
+}
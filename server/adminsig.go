@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// nonceCache remembers the nonces of admin commands accepted within
+// shared.AdminCommandWindow, so a captured frame cannot be replayed
+type nonceCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // nonce -> when it stops mattering
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time)}
+}
+
+// use records nonce and reports whether it was new. Nonces older than the
+// window are dropped, since their commands fail the timestamp check anyway.
+func (n *nonceCache) use(nonce string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for k, expiry := range n.seen {
+		if now.After(expiry) {
+			delete(n.seen, k)
+		}
+	}
+	if _, ok := n.seen[nonce]; ok {
+		return false
+	}
+	// Timestamps may be ahead of our clock by up to the window as well
+	n.seen[nonce] = now.Add(2 * shared.AdminCommandWindow)
+	return true
+}
+
+// verifyAdminCommand checks that a command from an admin connection was
+// signed with the admin key by this user and has not been seen before
+func (c *Client) verifyAdminCommand(msg shared.Message) error {
+	now := time.Now()
+	if err := shared.VerifyAdminCommand(c.adminSigningKey, msg, now); err != nil {
+		return err
+	}
+	if msg.Sender != c.username {
+		return fmt.Errorf("admin command signed for %q", msg.Sender)
+	}
+	if !c.hub.adminNonces.use(msg.Nonce, now) {
+		return fmt.Errorf("admin command replayed")
+	}
+	return nil
+}
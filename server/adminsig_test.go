@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestNonceCache(t *testing.T) {
+	n := newNonceCache()
+	now := time.Now()
+	if !n.use("a", now) {
+		t.Fatal("Expected a new nonce to be accepted")
+	}
+	if n.use("a", now.Add(time.Minute)) {
+		t.Error("Expected a reused nonce to be refused")
+	}
+	if !n.use("a", now.Add(3*shared.AdminCommandWindow)) {
+		t.Error("Expected the nonce to be forgotten after the window")
+	}
+}
+
+func TestAdminCommandsMustBeSigned(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	ts := httptest.NewServer(ServeWs(hub, db, []string{"admin"}, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(shared.Handshake{Username: "admin", Admin: true, AdminKey: "key"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// reply sends msg and returns the next message from System
+	reply := func(msg shared.Message) string {
+		t.Helper()
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("Expected a reply: %v", err)
+			}
+			var got shared.Message
+			if json.Unmarshal(raw, &got) == nil && got.Sender == "System" && got.Content != "" {
+				return got.Content
+			}
+		}
+	}
+
+	unsigned := shared.Message{Sender: "admin", Content: ":sessions", Type: shared.AdminCommandType}
+	if got := reply(unsigned); !strings.Contains(got, "not signed") {
+		t.Errorf("Expected an unsigned command to be rejected, got %q", got)
+	}
+
+	signed := unsigned
+	if err := shared.SignAdminCommand(shared.AdminSigningKey("key"), &signed); err != nil {
+		t.Fatal(err)
+	}
+	if got := reply(signed); strings.HasPrefix(got, "Command rejected") {
+		t.Errorf("Expected a signed command to run, got %q", got)
+	}
+	if got := reply(signed); !strings.Contains(got, "replayed") {
+		t.Errorf("Expected a replayed command to be rejected, got %q", got)
+	}
+
+	forged := unsigned
+	if err := shared.SignAdminCommand(shared.AdminSigningKey("guess"), &forged); err != nil {
+		t.Fatal(err)
+	}
+	if got := reply(forged); !strings.Contains(got, "signature is invalid") {
+		t.Errorf("Expected a forged command to be rejected, got %q", got)
+	}
+}
@@ -0,0 +1,546 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// ArchiveFormatJSONL is the line-delimited JSON archive format (one record per line)
+const ArchiveFormatJSONL = "jsonl"
+
+// Archive record kinds. Messages are written as bare shared.Message lines,
+// as older archives hold only those; everything else is wrapped in an
+// archiveRecord naming its kind.
+const (
+	archiveKindMessage      = "message"
+	archiveKindWelcome      = "welcome"
+	archiveKindTopic        = "topic"
+	archiveKindMOTD         = "motd"
+	archiveKindMaintenance  = "maintenance"
+	archiveKindNotes        = "notes"
+	archiveKindFilterRule   = "filter_rule"
+	archiveKindCustomEmoji  = "custom_emoji"
+	archiveKindMentionGroup = "mention_group"
+	archiveKindCronJob      = "cron_job"
+	archiveKindReminder     = "reminder"
+	archiveKindScheduled    = "scheduled_message"
+	archiveKindBan          = "ban"
+	archiveKindEmail        = "email"
+	archiveKindMetrics      = "metrics"
+)
+
+type archiveRecord struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// archiveEmail is one user's mention email opt-in
+type archiveEmail struct {
+	Username string
+	Address  string
+}
+
+// ArchiveCounts is how many records of each kind an export or import handled
+type ArchiveCounts map[string]int
+
+// Messages is the number of chat messages
+func (c ArchiveCounts) Messages() int {
+	return c[archiveKindMessage]
+}
+
+// String lists the counts as "3 message, 1 ban", kinds in name order
+func (c ArchiveCounts) String() string {
+	kinds := make([]string, 0, len(c))
+	for kind := range c {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", c[kind], kind))
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExportArchive writes the server's state to w as JSONL: settings, filter
+// rules, custom emoji, mention groups, cron jobs, pending reminders and
+// scheduled messages, ban history, email opt-ins and metrics history, then
+// every message created at or after since. A zero since exports the full
+// history; since never filters the other records. Snippets and the list of
+// welcomed users are not exported.
+func ExportArchive(db Database, w io.Writer, since time.Time) (ArchiveCounts, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	counts := ArchiveCounts{}
+
+	write := func(kind string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", kind, err)
+		}
+		if err := enc.Encode(archiveRecord{Kind: kind, Data: data}); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", kind, err)
+		}
+		counts[kind]++
+		return nil
+	}
+
+	welcome, err := db.GetWelcomeConfig()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read welcome settings: %w", err)
+	}
+	if !welcome.UpdatedAt.IsZero() {
+		if err := write(archiveKindWelcome, welcome); err != nil {
+			return counts, err
+		}
+	}
+	topic, err := db.GetChannelTopic(roomChannel)
+	if err != nil {
+		return counts, fmt.Errorf("failed to read the topic: %w", err)
+	}
+	if topic.Text != "" {
+		if err := write(archiveKindTopic, topic); err != nil {
+			return counts, err
+		}
+	}
+	motd, err := db.GetMOTD()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read the message of the day: %w", err)
+	}
+	if motd.Text != "" {
+		if err := write(archiveKindMOTD, motd); err != nil {
+			return counts, err
+		}
+	}
+	maintenance, err := db.GetMaintenance()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read the maintenance window: %w", err)
+	}
+	if maintenance.Scheduled() {
+		if err := write(archiveKindMaintenance, maintenance); err != nil {
+			return counts, err
+		}
+	}
+	notes, err := db.GetChannelNotes(roomChannel)
+	if err != nil {
+		return counts, fmt.Errorf("failed to read the notes: %w", err)
+	}
+	if notes.Version > 0 {
+		notes.Editor = ""
+		if err := write(archiveKindNotes, notes); err != nil {
+			return counts, err
+		}
+	}
+
+	rules, err := db.GetFilterRules()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read filter rules: %w", err)
+	}
+	for _, rule := range rules {
+		if err := write(archiveKindFilterRule, rule); err != nil {
+			return counts, err
+		}
+	}
+	emoji, err := db.GetCustomEmoji()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read custom emoji: %w", err)
+	}
+	for _, e := range emoji {
+		if err := write(archiveKindCustomEmoji, e); err != nil {
+			return counts, err
+		}
+	}
+	groups, err := db.GetMentionGroups()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read mention groups: %w", err)
+	}
+	for _, g := range groups {
+		if err := write(archiveKindMentionGroup, g); err != nil {
+			return counts, err
+		}
+	}
+	jobs, err := db.GetCronJobs()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read cron jobs: %w", err)
+	}
+	for _, j := range jobs {
+		if err := write(archiveKindCronJob, j); err != nil {
+			return counts, err
+		}
+	}
+	reminders, err := db.GetPendingReminders()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read reminders: %w", err)
+	}
+	for _, r := range reminders {
+		if err := write(archiveKindReminder, r); err != nil {
+			return counts, err
+		}
+	}
+	scheduled, err := db.GetScheduledMessages()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read scheduled messages: %w", err)
+	}
+	for _, sm := range scheduled {
+		if err := write(archiveKindScheduled, sm); err != nil {
+			return counts, err
+		}
+	}
+	bans, err := db.GetBanHistory()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read ban history: %w", err)
+	}
+	for _, b := range bans {
+		if err := write(archiveKindBan, b); err != nil {
+			return counts, err
+		}
+	}
+	emails, err := db.GetEmailAddresses()
+	if err != nil {
+		return counts, fmt.Errorf("failed to read email opt-ins: %w", err)
+	}
+	usernames := make([]string, 0, len(emails))
+	for username := range emails {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	for _, username := range usernames {
+		if err := write(archiveKindEmail, archiveEmail{Username: username, Address: emails[username]}); err != nil {
+			return counts, err
+		}
+	}
+	for _, resolution := range []string{"minute", "hour"} {
+		rollups, err := db.GetMetricsRollups(resolution, time.Time{})
+		if err != nil {
+			return counts, fmt.Errorf("failed to read metrics history: %w", err)
+		}
+		for _, r := range rollups {
+			if err := write(archiveKindMetrics, r); err != nil {
+				return counts, err
+			}
+		}
+	}
+
+	for _, msg := range db.GetMessagesSince(since) {
+		if err := enc.Encode(msg); err != nil {
+			return counts, fmt.Errorf("failed to encode message %d: %w", counts.Messages()+1, err)
+		}
+		counts[archiveKindMessage]++
+	}
+
+	if err := bw.Flush(); err != nil {
+		return counts, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return counts, nil
+}
+
+// archiveDuplicate counts the records an import skipped because the
+// database already had them
+const archiveDuplicate = "duplicate"
+
+// archiveItem is one parsed archive line: a shared.Message, or the value of
+// another record
+type archiveItem struct {
+	kind  string
+	value interface{}
+}
+
+// ImportArchive reads a JSONL archive produced by ExportArchive and stores
+// each record in db. Blank lines are skipped; messages other than text and
+// announcements are ignored. Imported messages are numbered after the
+// existing history, in archive order.
+//
+// The whole archive is read and checked before anything is written, so a
+// bad line or an import the message cap can't hold leaves db untouched.
+// Messages, filter rules, cron jobs, reminders, scheduled messages and bans
+// already in db are skipped, and everything else replaces what is there, so importing the
+// same archive twice changes nothing.
+func ImportArchive(db Database, r io.Reader) (ArchiveCounts, error) {
+	counts := ArchiveCounts{}
+	parsed, err := readArchive(r)
+	if err != nil {
+		return counts, err
+	}
+	items, err := withoutStored(db, parsed)
+	if err != nil {
+		return counts, err
+	}
+	if items.skipped > 0 {
+		counts[archiveDuplicate] = items.skipped
+	}
+
+	before, err := db.CountMessages()
+	if err != nil {
+		return counts, fmt.Errorf("failed to count messages: %w", err)
+	}
+	if before+items.messages > messageCap {
+		return counts, fmt.Errorf("nothing was imported: the archive holds %d new messages and the database %d, but it keeps only the most recent %d", items.messages, before, messageCap)
+	}
+
+	seq := latestSeq(db)
+	for _, item := range items.new {
+		if msg, ok := item.value.(shared.Message); ok {
+			seq++
+			msg.Seq = seq
+			if err := db.InsertMessage(msg); err != nil {
+				return counts, fmt.Errorf("failed to import message: %w", err)
+			}
+		} else if err := storeArchiveRecord(db, item.value); err != nil {
+			return counts, fmt.Errorf("failed to import %s: %w", item.kind, err)
+		}
+		counts[item.kind]++
+	}
+	return counts, nil
+}
+
+// readArchive parses every line of an archive, stopping at the first that
+// is invalid
+func readArchive(r io.Reader) ([]archiveItem, error) {
+	scanner := bufio.NewScanner(r)
+	// Messages can be long (code snippets etc.), allow lines up to 16MB
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var items []archiveItem
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var record archiveRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("invalid archive entry on line %d: %w", line, err)
+		}
+		if record.Kind != "" {
+			value, err := decodeArchiveRecord(record)
+			if err != nil {
+				return nil, fmt.Errorf("invalid archive entry on line %d: %w", line, err)
+			}
+			items = append(items, archiveItem{kind: record.Kind, value: value})
+			continue
+		}
+
+		var msg shared.Message
+		if err := json.Unmarshal([]byte(text), &msg); err != nil {
+			return nil, fmt.Errorf("invalid archive entry on line %d: %w", line, err)
+		}
+		if msg.Type != "" && msg.Type != shared.TextMessage && msg.Type != shared.AnnouncementType {
+			continue
+		}
+		if msg.Sender == "" {
+			return nil, fmt.Errorf("invalid archive entry on line %d: missing sender", line)
+		}
+		if msg.CreatedAt.IsZero() {
+			msg.CreatedAt = time.Now()
+		}
+		items = append(items, archiveItem{kind: archiveKindMessage, value: msg})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	return items, nil
+}
+
+// decodeArchiveRecord decodes the data of a record other than a message
+func decodeArchiveRecord(record archiveRecord) (interface{}, error) {
+	switch record.Kind {
+	case archiveKindWelcome:
+		return decodeArchiveData[WelcomeConfig](record)
+	case archiveKindTopic:
+		return decodeArchiveData[shared.Topic](record)
+	case archiveKindMOTD:
+		return decodeArchiveData[shared.MOTD](record)
+	case archiveKindMaintenance:
+		return decodeArchiveData[shared.Maintenance](record)
+	case archiveKindNotes:
+		n, err := decodeArchiveData[shared.ChannelNotes](record)
+		n.Editor = ""
+		return n, err
+	case archiveKindFilterRule:
+		return decodeArchiveData[FilterRule](record)
+	case archiveKindCustomEmoji:
+		return decodeArchiveData[CustomEmoji](record)
+	case archiveKindMentionGroup:
+		return decodeArchiveData[MentionGroup](record)
+	case archiveKindCronJob:
+		return decodeArchiveData[CronJob](record)
+	case archiveKindReminder:
+		return decodeArchiveData[Reminder](record)
+	case archiveKindScheduled:
+		return decodeArchiveData[ScheduledMessage](record)
+	case archiveKindBan:
+		b, err := decodeArchiveData[BanRecord](record)
+		if err == nil && b.Username == "" {
+			err = fmt.Errorf("invalid %s: missing username", record.Kind)
+		}
+		return b, err
+	case archiveKindEmail:
+		e, err := decodeArchiveData[archiveEmail](record)
+		if err == nil && e.Username == "" {
+			err = fmt.Errorf("invalid %s: missing username", record.Kind)
+		}
+		return e, err
+	case archiveKindMetrics:
+		return decodeArchiveData[MetricsRollup](record)
+	}
+	return nil, fmt.Errorf("unknown record kind %q", record.Kind)
+}
+
+func decodeArchiveData[T any](record archiveRecord) (T, error) {
+	var v T
+	if err := json.Unmarshal(record.Data, &v); err != nil {
+		return v, fmt.Errorf("invalid %s: %w", record.Kind, err)
+	}
+	return v, nil
+}
+
+// archiveItems are the records of an archive still to be imported
+type archiveItems struct {
+	new      []archiveItem
+	messages int // in new
+	skipped  int // already in the database
+}
+
+// withoutStored sets aside the messages, filter rules, cron jobs, reminders,
+// scheduled messages and bans db already holds, which have no key to replace them by. Times
+// are compared to the second, as MySQL DATETIME drops fractions.
+func withoutStored(db Database, items []archiveItem) (archiveItems, error) {
+	var result archiveItems
+	stored := make(map[string]bool)
+	since := time.Time{}
+	for _, item := range items {
+		if msg, ok := item.value.(shared.Message); ok && (since.IsZero() || msg.CreatedAt.Before(since)) {
+			since = msg.CreatedAt
+		}
+	}
+	if !since.IsZero() {
+		for _, msg := range db.GetMessagesSince(since.Add(-time.Second)) {
+			stored[archiveKey(msg)] = true
+		}
+	}
+	rules, err := db.GetFilterRules()
+	if err != nil {
+		return result, fmt.Errorf("failed to read filter rules: %w", err)
+	}
+	for _, r := range rules {
+		stored[archiveKey(r)] = true
+	}
+	jobs, err := db.GetCronJobs()
+	if err != nil {
+		return result, fmt.Errorf("failed to read cron jobs: %w", err)
+	}
+	for _, j := range jobs {
+		stored[archiveKey(j)] = true
+	}
+	reminders, err := db.GetPendingReminders()
+	if err != nil {
+		return result, fmt.Errorf("failed to read reminders: %w", err)
+	}
+	for _, r := range reminders {
+		stored[archiveKey(r)] = true
+	}
+	scheduled, err := db.GetScheduledMessages()
+	if err != nil {
+		return result, fmt.Errorf("failed to read scheduled messages: %w", err)
+	}
+	for _, sm := range scheduled {
+		stored[archiveKey(sm)] = true
+	}
+	bans, err := db.GetBanHistory()
+	if err != nil {
+		return result, fmt.Errorf("failed to read ban history: %w", err)
+	}
+	for _, b := range bans {
+		stored[archiveKey(b)] = true
+	}
+
+	for _, item := range items {
+		key := archiveKey(item.value)
+		if key != "" && stored[key] {
+			result.skipped++
+			continue
+		}
+		if key != "" {
+			// Repeated within the archive itself
+			stored[key] = true
+		}
+		result.new = append(result.new, item)
+		if item.kind == archiveKindMessage {
+			result.messages++
+		}
+	}
+	return result, nil
+}
+
+// archiveKey identifies a record that can only be added, not replaced, or
+// is "" for records that replace what is stored
+func archiveKey(v interface{}) string {
+	second := func(t time.Time) int64 { return t.Unix() }
+	switch v := v.(type) {
+	case shared.Message:
+		return fmt.Sprintf("message\x00%s\x00%s\x00%d", v.Sender, v.Content, second(v.CreatedAt))
+	case FilterRule:
+		return fmt.Sprintf("filter_rule\x00%s\x00%t", v.Pattern, v.IsRegex)
+	case CronJob:
+		return fmt.Sprintf("cron_job\x00%s\x00%s\x00%s", v.Schedule, v.Action, v.Message)
+	case Reminder:
+		return fmt.Sprintf("reminder\x00%s\x00%s\x00%s\x00%d", v.Creator, v.Target, v.Text, second(v.DueAt))
+	case ScheduledMessage:
+		return fmt.Sprintf("scheduled_message\x00%s\x00%s\x00%d", v.Author, v.Content, second(v.DeliverAt))
+	case BanRecord:
+		return fmt.Sprintf("ban\x00%s\x00%d", strings.ToLower(v.Username), second(v.BannedAt))
+	}
+	return ""
+}
+
+// storeArchiveRecord stores one record other than a message
+func storeArchiveRecord(db Database, v interface{}) error {
+	switch v := v.(type) {
+	case WelcomeConfig:
+		return db.SaveWelcomeConfig(v)
+	case shared.Topic:
+		return db.SetChannelTopic(v)
+	case shared.MOTD:
+		return db.SetMOTD(v)
+	case shared.Maintenance:
+		return db.SetMaintenance(v)
+	case shared.ChannelNotes:
+		return db.SaveChannelNotes(v)
+	case FilterRule:
+		_, err := db.InsertFilterRule(v)
+		return err
+	case CustomEmoji:
+		return db.SaveCustomEmoji(v)
+	case MentionGroup:
+		return db.SaveMentionGroup(v)
+	case CronJob:
+		_, err := db.InsertCronJob(v)
+		return err
+	case Reminder:
+		_, err := db.InsertReminder(v)
+		return err
+	case ScheduledMessage:
+		_, err := db.InsertScheduledMessage(v)
+		return err
+	case BanRecord:
+		return db.ImportBanRecord(v)
+	case archiveEmail:
+		return db.SetEmailAddress(v.Username, v.Address)
+	case MetricsRollup:
+		return db.SaveMetricsRollup(v)
+	}
+	return fmt.Errorf("unexpected archive record %T", v)
+}
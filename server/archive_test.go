@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestExportImportArchive(t *testing.T) {
+	src := CreateTestDatabase(t)
+	defer src.Close()
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	messages := []shared.Message{
+		{Sender: "alice", Content: "old news", CreatedAt: base.Add(-48 * time.Hour), Type: shared.TextMessage},
+		{Sender: "bob", Content: "hello", CreatedAt: base, Type: shared.TextMessage},
+		{Sender: "alice", Content: "hi bob", CreatedAt: base.Add(time.Minute), Type: shared.TextMessage},
+	}
+	for _, msg := range messages {
+		if err := src.InsertMessage(msg); err != nil {
+			t.Fatalf("Failed to insert message: %v", err)
+		}
+	}
+
+	// Export only messages since the base time
+	var buf bytes.Buffer
+	counts, err := ExportArchive(src, &buf, base)
+	if err != nil {
+		t.Fatalf("ExportArchive failed: %v", err)
+	}
+	if counts.Messages() != 2 {
+		t.Fatalf("Expected 2 exported messages, got %s", counts)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSONL lines, got %d", len(lines))
+	}
+
+	// Import into a fresh database
+	dst := CreateTestDatabase(t)
+	defer dst.Close()
+
+	archive := buf.String()
+	counts, err = ImportArchive(dst, strings.NewReader(archive))
+	if err != nil {
+		t.Fatalf("ImportArchive failed: %v", err)
+	}
+	if counts.Messages() != 2 {
+		t.Fatalf("Expected 2 imported messages, got %s", counts)
+	}
+
+	imported := dst.GetMessagesSince(time.Time{})
+	if len(imported) != 2 {
+		t.Fatalf("Expected 2 messages in destination, got %d", len(imported))
+	}
+	if imported[0].Content != "hello" || imported[1].Content != "hi bob" {
+		t.Errorf("Unexpected imported messages: %+v", imported)
+	}
+	if !imported[0].CreatedAt.Equal(base) {
+		t.Errorf("Expected timestamp %v to be preserved, got %v", base, imported[0].CreatedAt)
+	}
+
+	// Messages already imported are skipped
+	counts, err = ImportArchive(dst, strings.NewReader(archive))
+	if err != nil || counts.Messages() != 0 || counts[archiveDuplicate] != 2 {
+		t.Errorf("Expected both messages skipped on a second import, got %s (%v)", counts, err)
+	}
+	if n, _ := dst.CountMessages(); n != 2 {
+		t.Errorf("Expected 2 messages after importing twice, got %d", n)
+	}
+}
+
+func TestImportArchiveInvalidLine(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+
+	archive := `{"sender":"alice","content":"ok","created_at":"2024-01-01T00:00:00Z"}
+
+not json
+`
+	counts, err := ImportArchive(db, strings.NewReader(archive))
+	if err == nil {
+		t.Fatal("Expected error for malformed line")
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected error to reference line 3, got %v", err)
+	}
+	if counts.Messages() != 0 {
+		t.Errorf("Expected nothing imported from an invalid archive, got %s", counts)
+	}
+	if n, _ := db.CountMessages(); n != 0 {
+		t.Errorf("Expected the database untouched, got %d messages", n)
+	}
+}
+
+func TestExportImportArchiveState(t *testing.T) {
+	src := CreateTestDatabase(t)
+	defer src.Close()
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := src.RecordBanEvent("mallory", "admin"); err != nil {
+		t.Fatalf("RecordBanEvent failed: %v", err)
+	}
+	if err := src.RecordUnbanEvent("mallory"); err != nil {
+		t.Fatalf("RecordUnbanEvent failed: %v", err)
+	}
+	if err := src.RecordBanEvent("eve", "admin"); err != nil {
+		t.Fatalf("RecordBanEvent failed: %v", err)
+	}
+	if _, err := src.InsertReminder(Reminder{Creator: "alice", Target: "bob", Text: "standup", DueAt: base.Add(time.Hour), CreatedAt: base}); err != nil {
+		t.Fatalf("InsertReminder failed: %v", err)
+	}
+	if _, err := src.InsertScheduledMessage(ScheduledMessage{Author: "alice", Content: "lunch?", DeliverAt: base.Add(2 * time.Hour), CreatedAt: base}); err != nil {
+		t.Fatalf("InsertScheduledMessage failed: %v", err)
+	}
+	if err := src.SaveMentionGroup(MentionGroup{Name: "ops", Members: []string{"alice", "bob"}, CreatedBy: "admin", CreatedAt: base}); err != nil {
+		t.Fatalf("SaveMentionGroup failed: %v", err)
+	}
+	if err := src.SaveChannelNotes(shared.ChannelNotes{Channel: roomChannel, Text: "agenda", Version: 3, UpdatedBy: "alice", UpdatedAt: base}); err != nil {
+		t.Fatalf("SaveChannelNotes failed: %v", err)
+	}
+	if _, err := src.InsertCronJob(CronJob{Schedule: "0 9 * * 1", Action: "message", Message: "weekly", Enabled: true, CreatedBy: "admin", CreatedAt: base}); err != nil {
+		t.Fatalf("InsertCronJob failed: %v", err)
+	}
+	if err := src.SetEmailAddress("bob", "bob@example.com"); err != nil {
+		t.Fatalf("SetEmailAddress failed: %v", err)
+	}
+	if err := src.SetMOTD(shared.MOTD{Text: "be nice", SetBy: "admin", SetAt: base}); err != nil {
+		t.Fatalf("SetMOTD failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	counts, err := ExportArchive(src, &buf, time.Time{})
+	if err != nil {
+		t.Fatalf("ExportArchive failed: %v", err)
+	}
+	for kind, want := range map[string]int{archiveKindBan: 2, archiveKindReminder: 1, archiveKindScheduled: 1, archiveKindMentionGroup: 1, archiveKindNotes: 1, archiveKindCronJob: 1, archiveKindEmail: 1, archiveKindMOTD: 1} {
+		if counts[kind] != want {
+			t.Errorf("Expected %d %s record(s) exported, got %s", want, kind, counts)
+		}
+	}
+
+	archive := buf.Bytes()
+
+	dst := CreateTestDatabase(t)
+	defer dst.Close()
+	if _, err := ImportArchive(dst, bytes.NewReader(archive)); err != nil {
+		t.Fatalf("ImportArchive failed: %v", err)
+	}
+
+	bans, err := dst.GetBanHistory()
+	if err != nil || len(bans) != 2 {
+		t.Fatalf("Expected 2 bans, got %+v (%v)", bans, err)
+	}
+	if bans[0].Username != "mallory" || bans[0].UnbannedAt == nil || bans[1].Username != "eve" || bans[1].UnbannedAt != nil || bans[1].BannedBy != "admin" {
+		t.Errorf("Unexpected ban history %+v", bans)
+	}
+	if reminders, _ := dst.GetPendingReminders(); len(reminders) != 1 || reminders[0].Text != "standup" || !reminders[0].DueAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("Unexpected reminders %+v", reminders)
+	}
+	if scheduled, _ := dst.GetScheduledMessages(); len(scheduled) != 1 || scheduled[0].Content != "lunch?" || !scheduled[0].DeliverAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("Unexpected scheduled messages %+v", scheduled)
+	}
+	if groups, _ := dst.GetMentionGroups(); len(groups) != 1 || groups[0].Name != "ops" || len(groups[0].Members) != 2 {
+		t.Errorf("Unexpected mention groups %+v", groups)
+	}
+	if notes, _ := dst.GetChannelNotes(roomChannel); notes.Text != "agenda" || notes.Version != 3 {
+		t.Errorf("Unexpected notes %+v", notes)
+	}
+	if jobs, _ := dst.GetCronJobs(); len(jobs) != 1 || jobs[0].Message != "weekly" || !jobs[0].Enabled {
+		t.Errorf("Unexpected cron jobs %+v", jobs)
+	}
+	if address, _ := dst.GetEmailAddress("bob"); address != "bob@example.com" {
+		t.Errorf("Expected bob's opt-in to be imported, got %q", address)
+	}
+	if motd, _ := dst.GetMOTD(); motd.Text != "be nice" {
+		t.Errorf("Unexpected MOTD %+v", motd)
+	}
+
+	// Importing the same archive again adds nothing
+	counts, err = ImportArchive(dst, bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Second ImportArchive failed: %v", err)
+	}
+	if counts[archiveDuplicate] != 5 || counts[archiveKindBan] != 0 || counts[archiveKindReminder] != 0 || counts[archiveKindScheduled] != 0 || counts[archiveKindCronJob] != 0 {
+		t.Errorf("Expected the bans, reminder, scheduled message and cron job skipped as duplicates, got %s", counts)
+	}
+	if bans, _ := dst.GetBanHistory(); len(bans) != 2 {
+		t.Errorf("Expected 2 bans after importing twice, got %+v", bans)
+	}
+	if jobs, _ := dst.GetCronJobs(); len(jobs) != 1 {
+		t.Errorf("Expected 1 cron job after importing twice, got %+v", jobs)
+	}
+}
+
+func TestImportArchiveMessageCap(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+
+	var archive strings.Builder
+	for i := 0; i < 1001; i++ {
+		fmt.Fprintf(&archive, `{"sender":"alice","content":"message %d","created_at":"2024-01-01T00:00:00Z"}`+"\n", i)
+	}
+	counts, err := ImportArchive(db, strings.NewReader(archive.String()))
+	if err == nil || !strings.Contains(err.Error(), "keeps only the most recent 1000") {
+		t.Fatalf("Expected the import refused when the message cap can't hold it, got %v", err)
+	}
+	if counts.Messages() != 0 {
+		t.Errorf("Expected nothing imported, got %s", counts)
+	}
+	if n, _ := db.CountMessages(); n != 0 {
+		t.Errorf("Expected the database untouched, got %d messages", n)
+	}
+
+	if _, err := ImportArchive(db, strings.NewReader(`{"kind":"snippet","data":{}}`)); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an unknown kind to fail on line 1, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Limits for ASCII art messages so a single :figlet can't flood the chat
+const (
+	maxArtLines = 40
+	maxArtBytes = 8 * 1024
+)
+
+// shareArt broadcasts ASCII art rendered by the sender's client. Art is not
+// stored in history, like file messages, since it only renders correctly
+// with its message type intact.
+func (c *Client) shareArt(msg shared.Message) {
+	if !c.hub.ArtEnabled() {
+		c.reply("ASCII art commands are disabled on this server.")
+		return
+	}
+	if msg.Encrypted {
+		c.reply("ASCII art cannot be sent in encrypted sessions.")
+		return
+	}
+	content := strings.TrimRight(msg.Content, "\n ")
+	if strings.TrimSpace(content) == "" {
+		return
+	}
+	if len(content) > maxArtBytes || strings.Count(content, "\n")+1 > maxArtLines {
+		c.reply(fmt.Sprintf("ASCII art too large (max %d lines, %d KB).", maxArtLines, maxArtBytes/1024))
+		return
+	}
+	if !c.applyFilter(&content) {
+		return
+	}
+	log.Printf("ASCII art from %s (%d bytes)", c.username, len(content))
+	c.hub.broadcast <- shared.Message{
+		Sender:    c.username,
+		Content:   content,
+		CreatedAt: time.Now(),
+		Type:      shared.ArtMessageType,
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestShareArt(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	alice := &Client{hub: hub, db: NewDatabaseWrapper(db), username: "alice", send: make(chan interface{}, 16)}
+	hub.register <- alice
+
+	art := " ### \n#   #\n#####\n"
+	alice.shareArt(shared.Message{Sender: "mallory", Content: art, Type: shared.ArtMessageType})
+	msg := nextTextMessage(t, alice)
+	if msg.Type != shared.ArtMessageType || msg.Sender != "alice" || msg.Content != " ### \n#   #\n#####" {
+		t.Errorf("Unexpected art broadcast: %+v", msg)
+	}
+	if history := db.GetRecentMessages(); len(history) != 0 {
+		t.Errorf("Art should not be stored in history, got %+v", history)
+	}
+
+	alice.shareArt(shared.Message{Content: strings.Repeat("#\n", maxArtLines+1), Type: shared.ArtMessageType})
+	if msg := nextTextMessage(t, alice); msg.Sender != "System" || !strings.Contains(msg.Content, "too large") {
+		t.Errorf("Expected refusal for oversized art, got %+v", msg)
+	}
+
+	hub.SetArtEnabled(false)
+	alice.shareArt(shared.Message{Content: art, Type: shared.ArtMessageType})
+	if msg := nextTextMessage(t, alice); msg.Sender != "System" || !strings.Contains(msg.Content, "disabled") {
+		t.Errorf("Expected refusal when art is disabled, got %+v", msg)
+	}
+}
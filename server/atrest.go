@@ -0,0 +1,203 @@
+package server
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// atRestPrefix marks message content encrypted with the database key. Rows
+// without it are plaintext from before encryption was turned on.
+const atRestPrefix = "enc:v1:"
+
+// undecryptableContent replaces messages the configured key cannot open
+const undecryptableContent = "[message encrypted with a different MARCHAT_DB_ENCRYPTION_KEY]"
+
+// messageRewriter is implemented by backends that can rewrite stored message
+// content in place, used to encrypt a plaintext database on first start
+type messageRewriter interface {
+	// RewriteMessageContent calls fn for every stored message and saves the
+	// content it returns when ok is true; it returns how many were changed
+	RewriteMessageContent(fn func(content string) (string, bool)) (int, error)
+}
+
+// encryptedDatabase seals message content, including messages still waiting
+// in the schedule, with AES-256-GCM before it reaches the backend and opens
+// it on the way out. Everything else passes through.
+type encryptedDatabase struct {
+	Database
+	aead cipher.AEAD
+}
+
+// newEncryptedDatabase wraps db so message content is encrypted at rest with
+// key (32 bytes), then encrypts any plaintext messages already stored
+func newEncryptedDatabase(db Database, key []byte) (*encryptedDatabase, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("database encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	e := &encryptedDatabase{Database: db, aead: aead}
+	if rw, ok := db.(messageRewriter); ok {
+		n, err := rw.RewriteMessageContent(func(content string) (string, bool) {
+			if content == "" || strings.HasPrefix(content, atRestPrefix) {
+				return content, false
+			}
+			sealed, err := e.seal(content)
+			return sealed, err == nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt existing messages: %w", err)
+		}
+		if n > 0 {
+			log.Printf("Encrypted %d existing messages at rest", n)
+		}
+	}
+	return e, nil
+}
+
+func (e *encryptedDatabase) seal(content string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := e.aead.Seal(nonce, nonce, []byte(content), nil)
+	return atRestPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (e *encryptedDatabase) open(content string) string {
+	encoded, ok := strings.CutPrefix(content, atRestPrefix)
+	if !ok {
+		return content
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < e.aead.NonceSize() {
+		return undecryptableContent
+	}
+	nonce, sealed := data[:e.aead.NonceSize()], data[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return undecryptableContent
+	}
+	return string(plain)
+}
+
+func (e *encryptedDatabase) openAll(msgs []shared.Message) []shared.Message {
+	for i := range msgs {
+		msgs[i].Content = e.open(msgs[i].Content)
+	}
+	return msgs
+}
+
+// InsertMessage stores msg with its content encrypted
+func (e *encryptedDatabase) InsertMessage(msg shared.Message) error {
+	if msg.Content != "" {
+		sealed, err := e.seal(msg.Content)
+		if err != nil {
+			return err
+		}
+		msg.Content = sealed
+	}
+	return e.Database.InsertMessage(msg)
+}
+
+// GetRecentMessages returns the most recent messages, decrypted
+func (e *encryptedDatabase) GetRecentMessages() []shared.Message {
+	return e.openAll(e.Database.GetRecentMessages())
+}
+
+// GetMessagesAfter returns messages with ID > lastMessageID, decrypted
+func (e *encryptedDatabase) GetMessagesAfter(lastMessageID int64, limit int) []shared.Message {
+	return e.openAll(e.Database.GetMessagesAfter(lastMessageID, limit))
+}
+
+// GetMessagesSince returns messages created at or after since, decrypted
+func (e *encryptedDatabase) GetMessagesSince(since time.Time) []shared.Message {
+	return e.openAll(e.Database.GetMessagesSince(since))
+}
+
+// GetRecentMessagesForUser returns a user's history, decrypted
+func (e *encryptedDatabase) GetRecentMessagesForUser(username string, defaultLimit int, banGapsHistory bool) ([]shared.Message, int64) {
+	msgs, lastID := e.Database.GetRecentMessagesForUser(username, defaultLimit, banGapsHistory)
+	return e.openAll(msgs), lastID
+}
+
+// InsertScheduledMessage stores sm with its content encrypted
+func (e *encryptedDatabase) InsertScheduledMessage(sm ScheduledMessage) (int64, error) {
+	sealed, err := e.seal(sm.Content)
+	if err != nil {
+		return 0, err
+	}
+	sm.Content = sealed
+	return e.Database.InsertScheduledMessage(sm)
+}
+
+// GetScheduledMessages returns every pending scheduled message, decrypted
+func (e *encryptedDatabase) GetScheduledMessages() ([]ScheduledMessage, error) {
+	scheduled, err := e.Database.GetScheduledMessages()
+	for i := range scheduled {
+		scheduled[i].Content = e.open(scheduled[i].Content)
+	}
+	return scheduled, err
+}
+
+// rewriteSQLMessageContent is RewriteMessageContent for the SQL backends;
+// update sets content for an id in the backend's placeholder syntax
+func rewriteSQLMessageContent(db *sql.DB, update string, fn func(string) (string, bool)) (int, error) {
+	rows, err := db.Query(`SELECT id, content FROM messages`)
+	if err != nil {
+		return 0, err
+	}
+	type change struct {
+		id      int64
+		content string
+	}
+	var changes []change
+	for rows.Next() {
+		var id int64
+		var content sql.NullString
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if updated, ok := fn(content.String); ok {
+			changes = append(changes, change{id, updated})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range changes {
+		if _, err := tx.Exec(update, c.content, c.id); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(changes), nil
+}
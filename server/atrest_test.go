@@ -0,0 +1,126 @@
+package server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+var testAtRestKey = bytes.Repeat([]byte{7}, 32)
+
+func TestEncryptedDatabaseConformance(t *testing.T) {
+	db, err := NewDatabase(DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(t.TempDir(), "test.db"), EncryptionKey: testAtRestKey})
+	if err != nil {
+		t.Fatalf("Failed to create encrypted sqlite database: %v", err)
+	}
+	defer db.Close()
+
+	runDatabaseConformance(t, db)
+}
+
+func TestEncryptedDatabaseMigratesPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	plain, err := NewDatabase(DatabaseConfig{Type: "sqlite", FilePath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.InsertMessage(shared.Message{Sender: "alice", Content: "before encryption", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	plain.Close()
+
+	db, err := NewDatabase(DatabaseConfig{Type: "sqlite", FilePath: path, EncryptionKey: testAtRestKey})
+	if err != nil {
+		t.Fatalf("Failed to open with a key: %v", err)
+	}
+	if err := db.InsertMessage(shared.Message{Sender: "bob", Content: "after encryption", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	got := db.GetRecentMessages()
+	if len(got) != 2 || got[0].Content != "before encryption" || got[1].Content != "after encryption" {
+		t.Errorf("Expected both messages decrypted, got %+v", got)
+	}
+
+	// Nothing readable is left on disk
+	rows, err := db.GetDB().Query(`SELECT content FROM messages`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(content, atRestPrefix) {
+			t.Errorf("Expected stored content to be encrypted, got %q", content)
+		}
+	}
+	rows.Close()
+	db.Close()
+
+	// Another key cannot read them
+	wrong, err := NewDatabase(DatabaseConfig{Type: "sqlite", FilePath: path, EncryptionKey: bytes.Repeat([]byte{8}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wrong.Close()
+	for _, msg := range wrong.GetRecentMessages() {
+		if msg.Content != undecryptableContent {
+			t.Errorf("Expected the wrong key to fail, got %q", msg.Content)
+		}
+	}
+}
+
+func TestEncryptedDocumentStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	plain, err := NewDatabase(DatabaseConfig{Type: "document", FilePath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.InsertMessage(shared.Message{Sender: "alice", Content: "legacy secret", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	plain.Close()
+
+	db, err := NewDatabase(DatabaseConfig{Type: "document", FilePath: dir, EncryptionKey: testAtRestKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got := db.GetRecentMessages(); len(got) != 1 || got[0].Content != "legacy secret" {
+		t.Errorf("Expected the legacy message decrypted, got %+v", got)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, docCollectionMessages+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("legacy secret")) {
+		t.Error("Expected the message to be encrypted on disk")
+	}
+
+	// Messages waiting in the schedule are sealed too
+	if _, err := db.InsertScheduledMessage(ScheduledMessage{Author: "alice", Content: "scheduled secret", DeliverAt: time.Now().Add(time.Hour), CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.GetScheduledMessages(); err != nil || len(got) != 1 || got[0].Content != "scheduled secret" {
+		t.Errorf("Expected the scheduled message decrypted, got %+v (%v)", got, err)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, docCollectionScheduled+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("scheduled secret")) {
+		t.Error("Expected the scheduled message to be encrypted on disk")
+	}
+}
+
+func TestEncryptedDatabaseRejectsShortKey(t *testing.T) {
+	if _, err := NewDatabase(DatabaseConfig{Type: "memory", EncryptionKey: []byte("short")}); err == nil {
+		t.Error("Expected a short key to be rejected")
+	}
+}
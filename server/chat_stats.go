@@ -0,0 +1,124 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// statsWindow is how far back :stats me and :stats channel look
+const statsWindow = 7 * 24 * time.Hour
+
+// statsTopTalkers is how many talkers :stats channel lists
+const statsTopTalkers = 5
+
+// MessageActivity is who posted a message and when, all :stats needs of it
+type MessageActivity struct {
+	Sender string
+	At     time.Time
+}
+
+// messageActivitySQL is GetMessageActivity for the SQL backends; placeholder
+// is the backend's first parameter marker
+func messageActivitySQL(db *sql.DB, since time.Time, placeholder string) ([]MessageActivity, error) {
+	rows, err := db.Query(`SELECT sender, created_at FROM messages WHERE created_at >= `+placeholder+` AND sender != 'System'`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var activity []MessageActivity
+	for rows.Next() {
+		var a MessageActivity
+		var at interface{}
+		if err := rows.Scan(&a.Sender, &at); err != nil {
+			return nil, err
+		}
+		a.At = scanDBTime(at)
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// buildChatStats counts a week of activity up to now, for one user (scope
+// me) or everyone (scope channel)
+func buildChatStats(activity []MessageActivity, scope, subject string, now time.Time) shared.ChatStats {
+	hours := int(statsWindow / time.Hour)
+	from := now.Truncate(time.Hour).Add(-time.Duration(hours-1) * time.Hour)
+	stats := shared.ChatStats{Scope: scope, Subject: subject, From: from, Hourly: make([]int, hours)}
+
+	self := strings.ToLower(subject)
+	byUser := make(map[string]*shared.TalkerStats)
+	for _, a := range activity {
+		hour := int(a.At.Sub(from) / time.Hour)
+		if a.At.Before(from) || hour >= hours {
+			continue
+		}
+		key := strings.ToLower(a.Sender)
+		t := byUser[key]
+		if t == nil {
+			t = &shared.TalkerStats{Username: a.Sender}
+			byUser[key] = t
+		}
+		t.Messages++
+		if scope == shared.StatsScopeChannel || key == self {
+			stats.Hourly[hour]++
+		}
+	}
+
+	talkers := make([]shared.TalkerStats, 0, len(byUser))
+	for _, t := range byUser {
+		talkers = append(talkers, *t)
+	}
+	sort.Slice(talkers, func(i, j int) bool {
+		if talkers[i].Messages != talkers[j].Messages {
+			return talkers[i].Messages > talkers[j].Messages
+		}
+		return strings.ToLower(talkers[i].Username) < strings.ToLower(talkers[j].Username)
+	})
+	stats.Senders = len(talkers)
+	if scope == shared.StatsScopeChannel {
+		stats.Talkers = talkers[:min(len(talkers), statsTopTalkers)]
+		return stats
+	}
+	for i, t := range talkers {
+		if strings.ToLower(t.Username) == self {
+			stats.Rank = i + 1
+			break
+		}
+	}
+	return stats
+}
+
+// handleStatsCommand handles ":stats me" and ":stats channel": a week of
+// activity from the message history, sent to this client as a "stats"
+// message. Plain :stats is the admin database report.
+func (c *Client) handleStatsCommand(args []string) {
+	if len(args) != 1 || (args[0] != shared.StatsScopeMe && args[0] != shared.StatsScopeChannel) {
+		c.reply("Usage: :stats me | :stats channel")
+		return
+	}
+	if c.db == nil {
+		c.reply("Statistics require a database")
+		return
+	}
+	now := time.Now()
+	activity, err := c.db.GetMessageActivity(now.Add(-statsWindow))
+	if err != nil {
+		c.reply("Could not compute statistics: " + err.Error())
+		return
+	}
+	subject := roomChannel
+	if args[0] == shared.StatsScopeMe {
+		subject = c.username
+	}
+	payload, err := json.Marshal(buildChatStats(activity, args[0], subject, now))
+	if err != nil {
+		c.reply("Could not compute statistics: " + err.Error())
+		return
+	}
+	c.enqueue(WSMessage{Type: "stats", Data: payload})
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestBuildChatStats(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	activity := []MessageActivity{
+		{Sender: "alice", At: now.Add(-10 * time.Minute)},
+		{Sender: "Alice", At: now.Add(-20 * time.Minute)},
+		{Sender: "bob", At: now.Add(-2 * time.Hour)},
+		{Sender: "carol", At: now.Add(-3 * 24 * time.Hour)},
+		{Sender: "carol", At: now.Add(-3*24*time.Hour + time.Minute)},
+		{Sender: "carol", At: now.Add(-3*24*time.Hour + 2*time.Minute)},
+		{Sender: "dave", At: now.Add(-8 * 24 * time.Hour)}, // before the week
+	}
+
+	channel := buildChatStats(activity, shared.StatsScopeChannel, roomChannel, now)
+	if len(channel.Hourly) != 168 || !channel.From.Equal(time.Date(2026, 3, 3, 16, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected 168 hours ending with this one, got %d from %v", len(channel.Hourly), channel.From)
+	}
+	if channel.Total() != 6 || channel.Hourly[167] != 2 || channel.Hourly[165] != 1 {
+		t.Errorf("Unexpected hourly counts (total %d): %v", channel.Total(), channel.Hourly[160:])
+	}
+	if channel.Senders != 3 || len(channel.Talkers) != 3 ||
+		channel.Talkers[0] != (shared.TalkerStats{Username: "carol", Messages: 3}) || channel.Talkers[1].Messages != 2 {
+		t.Errorf("Unexpected talkers %+v (%d senders)", channel.Talkers, channel.Senders)
+	}
+
+	me := buildChatStats(activity, shared.StatsScopeMe, "ALICE", now)
+	if me.Total() != 2 || me.Rank != 2 || me.Talkers != nil {
+		t.Errorf("Expected alice's 2 messages in second place, got %+v", me)
+	}
+	if silent := buildChatStats(activity, shared.StatsScopeMe, "erin", now); silent.Total() != 0 || silent.Rank != 0 {
+		t.Errorf("Expected no rank for someone silent, got %+v", silent)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	for _, sender := range []string{"alice", "bob", "alice"} {
+		if err := db.InsertMessage(shared.Message{Sender: sender, Content: "hi", CreatedAt: time.Now().Add(-time.Minute)}); err != nil {
+			t.Fatalf("InsertMessage failed: %v", err)
+		}
+	}
+	alice := &Client{db: NewDatabaseWrapper(db), username: "alice", send: make(chan interface{}, 16)}
+
+	alice.handleCommand(":stats me")
+	var stats shared.ChatStats
+	if err := json.Unmarshal(nextWSMessage(t, alice, "stats").Data, &stats); err != nil {
+		t.Fatalf("Bad stats payload: %v", err)
+	}
+	if stats.Scope != shared.StatsScopeMe || stats.Subject != "alice" || stats.Total() != 2 || stats.Rank != 1 || stats.Senders != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	alice.handleCommand(":stats week")
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "Usage: :stats me | :stats channel") {
+		t.Errorf("Expected usage, got %q", msg.Content)
+	}
+
+	// Plain :stats is still the admin report
+	alice.handleCommand(":stats")
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "requires admin") {
+		t.Errorf("Expected the admin report refused, got %q", msg.Content)
+	}
+}
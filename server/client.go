@@ -3,7 +3,10 @@ package server
 import (
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Cod-e-Codes/marchat/shared"
@@ -20,6 +23,9 @@ type Client struct {
 	hub                  *Hub
 	conn                 *websocket.Conn
 	send                 chan interface{}
+	sendMu               sync.RWMutex // held to send, and exclusively to close send
+	sendClosed           bool         // guarded by sendMu
+	closeFrame           []byte       // written by writePump once send is closed; guarded by sendMu
 	db                   *DatabaseWrapper
 	username             string
 	isAdmin              bool
@@ -27,10 +33,22 @@ type Client struct {
 	pluginCommandHandler *PluginCommandHandler
 	maxFileBytes         int64
 	dbPath               string // Store database path for backup operations
+	sessionID            string // Identifies this connection among a user's sessions
+	connectedAt          time.Time
+	serverURL            shared.ChatURL // how the client reached us, for :invite links
+	readOnly             bool           // spectator: not listed and cannot post
+	compressMin          int            // smallest frame sent compressed (0 = never)
+	adminSigningKey      []byte         // verifies admin commands, see shared.SignAdminCommand
+	traffic              connTraffic    // frames and bytes both ways, for the admin panel
+	lastActive           atomic.Int64   // unix nanoseconds of the last frame read, see idleSince
+	away                 atomic.Bool    // set by the hub's idle checks
+	userListDeltas       bool           // applies userlist_delta frames, see sendUserList
+	userListSynced       bool           // has had a full user list; guarded by hub.userListMu
 }
 
 func (c *Client) readPump() {
 	defer func() {
+		c.hub.releaseNotesLocks(c)
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
@@ -51,7 +69,8 @@ func (c *Client) readPump() {
 	})
 	for {
 		var msg shared.Message
-		err := c.conn.ReadJSON(&msg)
+		n, err := readFrame(c.conn, &msg)
+		c.traffic.received(n)
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseAbnormalClosure) {
 				log.Printf("Client %s disconnected unexpectedly: %v", c.username, err)
@@ -60,6 +79,45 @@ func (c *Client) readPump() {
 			}
 			break
 		}
+		c.touch(time.Now())
+		// Only the server resolves mentions, fetches previews and numbers messages
+		msg.Mentions, msg.Preview, msg.Seq = nil, nil, 0
+		if c.readOnly {
+			c.reply("This is a read-only connection.")
+			continue
+		}
+		// Shared notes have their own size limit and are not chat
+		if msg.Type == shared.NotesMessageType {
+			c.saveNotes(msg)
+			continue
+		}
+		isCommand := strings.HasPrefix(msg.Content, ":") || msg.Type == shared.AdminCommandType
+		if !c.checkMessageSize(msg) {
+			continue
+		}
+		// Commands on an admin connection must be signed, so a replayed or
+		// injected frame cannot ban users or clear the database
+		if isCommand && c.isAdmin {
+			if err := c.verifyAdminCommand(msg); err != nil {
+				AdminLogger.Warn("Rejected admin command", map[string]interface{}{
+					"user":  c.username,
+					"error": err.Error(),
+				})
+				c.reply("Command rejected: " + err.Error())
+				continue
+			}
+		}
+		if !isCommand && !msg.Encrypted && (msg.Type == "" || msg.Type == shared.TextMessage) && !c.applyFilter(&msg.Content) {
+			continue
+		}
+		if !isCommand && !c.checkSlowMode() {
+			continue
+		}
+		// Shadow-muted users see their own messages; nobody else does
+		if !isCommand && c.hub.IsUserMuted(c.username) {
+			c.echoMuted(msg)
+			continue
+		}
 		if msg.Type == shared.FileMessageType && msg.File != nil {
 			// File message: enforce configured limit
 			maxBytes := c.maxFileBytes
@@ -70,31 +128,58 @@ func (c *Client) readPump() {
 				log.Printf("Rejected file from %s: too large (%d bytes)", c.username, msg.File.Size)
 				continue
 			}
+			if !c.checkFile(msg.File) {
+				continue
+			}
 			// Broadcast file message, do not store in DB
 			msg.CreatedAt = time.Now()
+			c.hub.usage.message(roomChannel, msg)
 			c.hub.broadcast <- msg
 			continue
 		}
+		if msg.Type == shared.AudioMessageType {
+			c.shareVoiceNote(msg)
+			continue
+		}
+		if !isCommand {
+			c.hub.usage.message(roomChannel, msg)
+		}
+		if msg.Type == shared.SnippetMessageType {
+			c.shareSnippet(msg)
+			continue
+		}
+		if msg.Type == shared.ArtMessageType {
+			c.shareArt(msg)
+			continue
+		}
+		if msg.Type == shared.DiagramMessageType {
+			c.shareDiagram(msg)
+			continue
+		}
 		// Handle commands (both plugin and admin commands)
-		if strings.HasPrefix(msg.Content, ":") || msg.Type == shared.AdminCommandType {
+		if isCommand {
 			AdminLogger.Info("Command received", map[string]interface{}{
 				"user":    c.username,
 				"command": msg.Content,
 				"admin":   c.isAdmin,
 				"type":    msg.Type,
 			})
+			// :emoji add can carry an image, so it gets the whole message
+			if fields := strings.Fields(msg.Content); len(fields) > 0 && fields[0] == ":emoji" {
+				c.handleEmojiCommand(msg)
+				continue
+			}
 			// Let handleCommand process both plugin and admin commands
 			// It will check permissions for each command individually
 			c.handleCommand(msg.Content)
 			continue // Don't insert commands as normal messages
 		}
-		msg.CreatedAt = time.Now()
-		if msg.Type == "" || msg.Type == shared.TextMessage {
-			if err := c.db.InsertMessage(msg); err != nil {
-				log.Printf("Failed to insert message: %v", err)
-			}
+		if !msg.Encrypted && (msg.Type == "" || msg.Type == shared.TextMessage) && !c.checkMentionLimit(msg.Content) {
+			continue
+		}
+		if err := c.hub.acceptMessage(msg, c.isAdmin); err != nil {
+			c.reply("Message not sent: " + err.Error())
 		}
-		c.hub.broadcast <- msg
 	}
 }
 
@@ -103,8 +188,8 @@ func validateUsername(username string) error {
 	if username == "" {
 		return fmt.Errorf("username cannot be empty")
 	}
-	if len(username) > 32 {
-		return fmt.Errorf("username too long (max 32 characters)")
+	if len(username) > maxUsernameLength {
+		return fmt.Errorf("username too long (max %d characters)", maxUsernameLength)
 	}
 	// Allow letters, numbers, underscores, hyphens, and periods
 	// This prevents log injection, command injection, and other issues
@@ -169,6 +254,20 @@ func parseCommandWithQuotes(command string) []string {
 	return parts
 }
 
+// commandRemainder returns the raw text after the first n whitespace-separated
+// words of command, preserving quotes and inner spacing for free-text arguments
+func commandRemainder(command string, n int) string {
+	rest := strings.TrimSpace(command)
+	for i := 0; i < n && rest != ""; i++ {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			return ""
+		}
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return rest
+}
+
 // handleCommand processes both plugin commands and built-in admin commands
 func (c *Client) handleCommand(command string) {
 	// Parse command with proper quote handling
@@ -177,7 +276,59 @@ func (c *Client) handleCommand(command string) {
 		return
 	}
 
-	// First, try to handle plugin commands (these have their own permission checks)
+	// Built-in commands available to every user
+	switch parts[0] {
+	case ":sessions":
+		c.handleSessionsCommand(parts[1:])
+		return
+	case ":poll":
+		c.handlePollCommand(parts[1:])
+		return
+	case ":vote":
+		c.handleVoteCommand(parts[1:])
+		return
+	case ":schedule":
+		c.handleScheduleCommand(command, parts[1:])
+		return
+	case ":scheduled":
+		c.handleScheduledCommand(parts[1:])
+		return
+	case ":remind":
+		c.handleRemindCommand(command, parts[1:])
+		return
+	case ":reminders":
+		c.handleRemindersCommand(parts[1:])
+		return
+	case ":nick":
+		c.handleNickCommand(commandRemainder(command, 1))
+		return
+	case ":help":
+		c.handleHelpCommand()
+		return
+	case ":topic":
+		c.handleTopicCommand(command, parts[1:])
+		return
+	case ":motd":
+		c.handleMOTDCommand(command, parts[1:])
+		return
+	case ":notes":
+		c.handleNotesCommand(parts[1:])
+		return
+	case ":group":
+		c.handleGroupCommand(parts[1:])
+		return
+	case ":email":
+		c.handleEmailCommand(parts[1:])
+		return
+	case ":stats":
+		// Plain :stats is the admin database report below
+		if len(parts) > 1 {
+			c.handleStatsCommand(parts[1:])
+			return
+		}
+	}
+
+	// Next, try to handle plugin commands (these have their own permission checks)
 	if c.pluginCommandHandler != nil {
 		cmd := strings.TrimPrefix(parts[0], ":")
 		args := parts[1:]
@@ -201,12 +352,12 @@ func (c *Client) handleCommand(command string) {
 				"user":     c.username,
 				"response": response,
 			})
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   response,
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		} else {
 			AdminLogger.Debug("Plugin command failed", map[string]interface{}{
@@ -227,12 +378,12 @@ func (c *Client) handleCommand(command string) {
 			"user":    c.username,
 			"command": parts[0],
 		})
-		c.send <- shared.Message{
+		c.enqueue(shared.Message{
 			Sender:    "System",
 			Content:   "This command requires admin privileges",
 			CreatedAt: time.Now(),
 			Type:      shared.TextMessage,
-		}
+		})
 		return
 	}
 	switch parts[0] {
@@ -253,179 +404,191 @@ func (c *Client) handleCommand(command string) {
 
 	case ":kick":
 		if len(parts) < 2 {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Usage: :kick <username>",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		targetUsername := parts[1]
 		if err := validateUsername(targetUsername); err != nil {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Invalid username: " + err.Error(),
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		c.hub.KickUser(targetUsername, c.username)
-		c.send <- shared.Message{
+		c.enqueue(shared.Message{
 			Sender:    "System",
 			Content:   "User '" + targetUsername + "' has been kicked (24 hour temporary ban).",
 			CreatedAt: time.Now(),
 			Type:      shared.TextMessage,
-		}
+		})
 
 	case ":ban":
 		if len(parts) < 2 {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Usage: :ban <username>",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		targetUsername := parts[1]
 		if err := validateUsername(targetUsername); err != nil {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Invalid username: " + err.Error(),
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		c.hub.BanUser(targetUsername, c.username)
-		c.send <- shared.Message{
+		c.enqueue(shared.Message{
 			Sender:    "System",
 			Content:   "User '" + targetUsername + "' has been permanently banned.",
 			CreatedAt: time.Now(),
 			Type:      shared.TextMessage,
-		}
+		})
 
 	case ":unban":
 		if len(parts) < 2 {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Usage: :unban <username>",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		targetUsername := parts[1]
 		if err := validateUsername(targetUsername); err != nil {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Invalid username: " + err.Error(),
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		unbanned := c.hub.UnbanUser(targetUsername, c.username)
 		if unbanned {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "User '" + targetUsername + "' has been unbanned.",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		} else {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "User '" + targetUsername + "' was not found in the ban list.",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		}
 
+	case ":mute", ":unmute":
+		c.handleMuteCommand(parts)
+
+	case ":slowmode":
+		c.handleSlowModeCommand(parts[1:])
+
+	case ":filter":
+		c.handleFilterCommand(command)
+
+	case ":invite":
+		c.handleInviteCommand(parts)
+
 	case ":allow":
 		if len(parts) < 2 {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Usage: :allow <username>",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		targetUsername := parts[1]
 		if err := validateUsername(targetUsername); err != nil {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Invalid username: " + err.Error(),
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		allowed := c.hub.AllowUser(targetUsername, c.username)
 		if allowed {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "User '" + targetUsername + "' has been allowed back (kick override).",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		} else {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "User '" + targetUsername + "' was not found in the kick list.",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		}
 
 	case ":cleanup":
 		log.Printf("[ADMIN] Manual stale connection cleanup initiated by %s", c.username)
 		c.hub.CleanupStaleConnections()
-		c.send <- shared.Message{
+		c.enqueue(shared.Message{
 			Sender:    "System",
 			Content:   "Stale connection cleanup completed.",
 			CreatedAt: time.Now(),
 			Type:      shared.TextMessage,
-		}
+		})
 
 	case ":forcedisconnect":
 		if len(parts) < 2 {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Usage: :forcedisconnect <username>",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		targetUsername := parts[1]
 		if err := validateUsername(targetUsername); err != nil {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Invalid username: " + err.Error(),
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 			return
 		}
 		disconnected := c.hub.ForceDisconnectUser(targetUsername, c.username)
 		if disconnected {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "User '" + targetUsername + "' has been forcibly disconnected.",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		} else {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "User '" + targetUsername + "' was not found in active connections.",
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		}
 
 	case ":backup":
@@ -434,20 +597,20 @@ func (c *Client) handleCommand(command string) {
 		backupFilename, err := c.db.BackupDatabase(c.dbPath)
 		if err != nil {
 			log.Printf("Failed to backup database: %v", err)
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Database backup failed: " + err.Error(),
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		} else {
 			log.Printf("Database backup created: %s", backupFilename)
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Database backup created: " + backupFilename,
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		}
 
 	case ":stats":
@@ -455,26 +618,406 @@ func (c *Client) handleCommand(command string) {
 		stats, err := c.db.GetDatabaseStats()
 		if err != nil {
 			log.Printf("Failed to get database stats: %v", err)
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   "Failed to get database stats: " + err.Error(),
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		} else {
-			c.send <- shared.Message{
+			c.enqueue(shared.Message{
 				Sender:    "System",
 				Content:   stats,
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
-			}
+			})
 		}
 
+	case ":cron":
+		c.handleCronCommand(command)
+
+	case ":announce":
+		text := commandRemainder(command, 1)
+		if text == "" {
+			c.enqueue(shared.Message{
+				Sender:    "System",
+				Content:   "Usage: :announce <text>",
+				CreatedAt: time.Now(),
+				Type:      shared.TextMessage,
+			})
+			return
+		}
+		log.Printf("[ADMIN] Announcement broadcast by %s", c.username)
+		announcement := shared.Message{
+			Sender:    c.username,
+			Content:   text,
+			CreatedAt: time.Now(),
+			Type:      shared.AnnouncementType,
+		}
+		c.hub.stamp(&announcement)
+		if err := c.db.InsertMessage(announcement); err != nil {
+			log.Printf("Failed to store announcement: %v", err)
+		}
+		c.hub.broadcast <- announcement
+
 	default:
 		log.Printf("[ADMIN] Unknown admin command by %s: %s", c.username, command)
 	}
 }
 
+// trySend queues msg for the client without waiting, reporting false when
+// its send channel is full or already closed
+func (c *Client) trySend(msg interface{}) bool {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.sendClosed {
+		return false
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueue queues msg for the client without waiting, so neither the caller
+// nor closeSend is held up by a slow client. When its send channel is full
+// the message is dropped; messages for a removed client are dropped too.
+func (c *Client) enqueue(msg interface{}) {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.sendClosed {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		log.Printf("Dropping message for %s: send channel full", c.username)
+	}
+}
+
+// closeSend closes the send channel, which stops writePump. Any goroutine
+// may call it, any number of times; sends after the first call are dropped.
+func (c *Client) closeSend() {
+	c.closeSendWith(nil)
+}
+
+// closeSendWith closes the send channel like closeSend, and has writePump
+// end the connection with closeFrame once it has written what is queued
+func (c *Client) closeSendWith(closeFrame []byte) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.sendClosed {
+		c.sendClosed = true
+		c.closeFrame = closeFrame
+		close(c.send)
+	}
+}
+
+// reply sends a System message to this client only
+func (c *Client) reply(content string) {
+	c.enqueue(shared.Message{
+		Sender:    "System",
+		Content:   content,
+		CreatedAt: time.Now(),
+		Type:      shared.TextMessage,
+	})
+}
+
+// handleSessionsCommand lists or revokes the caller's own active sessions
+func (c *Client) handleSessionsCommand(args []string) {
+	reply := c.reply
+
+	if len(args) == 0 {
+		sessions := c.hub.GetUserSessions(c.username)
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("Active sessions for %s (%d):", c.username, len(sessions)))
+		for _, s := range sessions {
+			b.WriteString(fmt.Sprintf("\n  %s  %s  connected %s", s.sessionID, s.ipAddr, s.connectedAt.Format("2006-01-02 15:04:05")))
+			if s == c {
+				b.WriteString("  (this session)")
+			}
+		}
+		if !c.hub.AllowsMultiSession() {
+			b.WriteString("\nMulti-device sessions are disabled on this server.")
+		}
+		reply(b.String())
+		return
+	}
+
+	if args[0] != "revoke" || len(args) < 2 {
+		reply("Usage: :sessions [revoke <id>|others]")
+		return
+	}
+
+	target := args[1]
+	if target == "others" {
+		revoked := 0
+		for _, s := range c.hub.GetUserSessions(c.username) {
+			if s != c && c.hub.RevokeSession(c.username, s.sessionID) {
+				revoked++
+			}
+		}
+		reply(fmt.Sprintf("Revoked %d other session(s).", revoked))
+		return
+	}
+	if target == c.sessionID {
+		reply("Cannot revoke the current session - disconnect instead.")
+		return
+	}
+	if c.hub.RevokeSession(c.username, target) {
+		reply("Session '" + target + "' has been revoked.")
+	} else {
+		reply("Session '" + target + "' was not found.")
+	}
+}
+
+// handlePollCommand creates, lists or closes polls.
+// Usage: :poll [duration] "Question" "opt1" "opt2" ... | :poll list | :poll close <id>
+func (c *Client) handlePollCommand(args []string) {
+	const usage = `Usage: :poll [duration] "Question" "opt1" "opt2" ... | :poll list | :poll close <id>`
+
+	if len(args) == 0 || args[0] == "list" {
+		polls := c.hub.OpenPolls()
+		if len(polls) == 0 {
+			c.reply("No open polls.")
+			return
+		}
+		for _, p := range polls {
+			c.enqueue(pollMessage(p))
+		}
+		return
+	}
+
+	if args[0] == "close" {
+		if len(args) < 2 {
+			c.reply(usage)
+			return
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			c.reply(usage)
+			return
+		}
+		poll, err := c.hub.ClosePoll(id, c.username, c.isAdmin)
+		if err != nil {
+			c.reply(err.Error())
+			return
+		}
+		log.Printf("Poll #%d closed by %s", poll.ID, c.username)
+		c.hub.broadcast <- pollMessage(poll)
+		return
+	}
+
+	var duration time.Duration
+	if d, err := time.ParseDuration(args[0]); err == nil {
+		duration = d
+		args = args[1:]
+	}
+	if len(args) < 3 {
+		c.reply(usage)
+		return
+	}
+
+	for i := range args {
+		if !c.applyFilter(&args[i]) {
+			return
+		}
+	}
+	poll, err := c.hub.CreatePoll(c.username, args[0], args[1:], duration)
+	if err != nil {
+		c.reply("Could not create poll: " + err.Error())
+		return
+	}
+	log.Printf("Poll #%d created by %s with %d options", poll.ID, c.username, len(poll.Options))
+	c.hub.broadcast <- pollMessage(poll)
+}
+
+// handleVoteCommand records a vote. Usage: :vote <poll id> <option number>
+func (c *Client) handleVoteCommand(args []string) {
+	if len(args) != 2 {
+		c.reply("Usage: :vote <poll id> <option number>")
+		return
+	}
+	id, idErr := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	option, optErr := strconv.Atoi(args[1])
+	if idErr != nil || optErr != nil {
+		c.reply("Usage: :vote <poll id> <option number>")
+		return
+	}
+
+	poll, err := c.hub.VotePoll(id, c.username, option-1)
+	if err != nil {
+		c.reply(err.Error())
+		return
+	}
+	c.hub.broadcast <- pollMessage(poll)
+}
+
+// handleScheduleCommand queues a message for later delivery.
+// Usage: :schedule <delay|HH:MM> <message>
+func (c *Client) handleScheduleCommand(command string, args []string) {
+	text := commandRemainder(command, 2)
+	if len(args) < 2 || text == "" {
+		c.reply("Usage: :schedule <delay|HH:MM> <message> (e.g. :schedule 15m Deploy is done?)")
+		return
+	}
+
+	deliverAt, err := parseScheduleTime(args[0], time.Now())
+	if err != nil {
+		c.reply("Could not schedule message: " + err.Error())
+		return
+	}
+	// The message is checked as if it were sent now
+	if !c.applyFilter(&text) {
+		return
+	}
+	if _, err := c.resolveMentions(text); err != nil {
+		c.reply("Could not schedule message: " + err.Error())
+		return
+	}
+	if !c.checkMentionLimit(text) || !c.checkSlowMode() {
+		return
+	}
+	sm, err := c.hub.ScheduleMessage(c.username, c.isAdmin, text, deliverAt)
+	if err != nil {
+		c.reply("Could not schedule message: " + err.Error())
+		return
+	}
+	log.Printf("Scheduled message #%d by %s for %s", sm.ID, c.username, sm.DeliverAt.Format(time.RFC3339))
+	c.reply(fmt.Sprintf("Message #%d scheduled for %s. Use :scheduled to list or cancel.", sm.ID, sm.DeliverAt.Format("2006-01-02 15:04")))
+}
+
+// handleScheduledCommand lists or cancels the caller's scheduled messages.
+// Usage: :scheduled [cancel <id>]
+func (c *Client) handleScheduledCommand(args []string) {
+	if len(args) == 0 {
+		pending, err := c.hub.ScheduledMessages(c.username)
+		if err != nil {
+			c.reply("Failed to load scheduled messages: " + err.Error())
+			return
+		}
+		if len(pending) == 0 {
+			c.reply("You have no scheduled messages.")
+			return
+		}
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("Scheduled messages (%d):", len(pending)))
+		for _, sm := range pending {
+			b.WriteString(fmt.Sprintf("\n  #%d  %s  %s", sm.ID, sm.DeliverAt.Format("2006-01-02 15:04"), sm.Content))
+		}
+		c.reply(b.String())
+		return
+	}
+
+	if args[0] != "cancel" || len(args) < 2 {
+		c.reply("Usage: :scheduled [cancel <id>]")
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+	if err != nil {
+		c.reply("Usage: :scheduled [cancel <id>]")
+		return
+	}
+	ok, err := c.hub.CancelScheduledMessage(c.username, id)
+	switch {
+	case err != nil:
+		c.reply("Failed to cancel scheduled message: " + err.Error())
+	case ok:
+		c.reply(fmt.Sprintf("Scheduled message #%d cancelled.", id))
+	default:
+		c.reply(fmt.Sprintf("Scheduled message #%d was not found.", id))
+	}
+}
+
+// handleRemindCommand stores a reminder for the caller or another user.
+// Usage: :remind [@user|me] <delay|HH:MM> <text>
+func (c *Client) handleRemindCommand(command string, args []string) {
+	const usage = "Usage: :remind [@user|me] <delay|HH:MM> <text> (e.g. :remind @bob 2h review PR #12)"
+
+	target := c.username
+	skip := 1
+	if len(args) > 0 && (strings.HasPrefix(args[0], "@") || args[0] == "me") {
+		if args[0] != "me" {
+			target = strings.TrimPrefix(args[0], "@")
+		}
+		args = args[1:]
+		skip = 2
+	}
+	text := commandRemainder(command, skip+1)
+	if len(args) < 2 || text == "" {
+		c.reply(usage)
+		return
+	}
+	if err := validateUsername(target); err != nil {
+		c.reply("Invalid reminder target: " + err.Error())
+		return
+	}
+
+	dueAt, err := parseScheduleTime(args[0], time.Now())
+	if err != nil {
+		c.reply("Could not set reminder: " + err.Error())
+		return
+	}
+	if !c.applyFilter(&text) {
+		return
+	}
+	r, err := c.hub.AddReminder(c.username, target, text, dueAt)
+	if err != nil {
+		c.reply("Could not set reminder: " + err.Error())
+		return
+	}
+	log.Printf("Reminder #%d set by %s for %s at %s", r.ID, c.username, r.Target, r.DueAt.Format(time.RFC3339))
+	who := "you"
+	if !strings.EqualFold(r.Target, c.username) {
+		who = "@" + r.Target
+	}
+	c.reply(fmt.Sprintf("Reminder #%d for %s set for %s.", r.ID, who, r.DueAt.Format("2006-01-02 15:04")))
+}
+
+// handleRemindersCommand lists reminders involving the caller or cancels one they created.
+// Usage: :reminders [cancel <id>]
+func (c *Client) handleRemindersCommand(args []string) {
+	if len(args) == 0 {
+		reminders, err := c.hub.RemindersFor(c.username)
+		if err != nil {
+			c.reply("Failed to load reminders: " + err.Error())
+			return
+		}
+		if len(reminders) == 0 {
+			c.reply("You have no pending reminders.")
+			return
+		}
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("Pending reminders (%d):", len(reminders)))
+		for _, r := range reminders {
+			b.WriteString(fmt.Sprintf("\n  #%d  %s  %s -> @%s  %s", r.ID, r.DueAt.Format("2006-01-02 15:04"), r.Creator, r.Target, r.Text))
+		}
+		c.reply(b.String())
+		return
+	}
+
+	if args[0] != "cancel" || len(args) < 2 {
+		c.reply("Usage: :reminders [cancel <id>]")
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+	if err != nil {
+		c.reply("Usage: :reminders [cancel <id>]")
+		return
+	}
+	ok, err := c.hub.CancelReminder(c.username, id)
+	switch {
+	case err != nil:
+		c.reply("Failed to cancel reminder: " + err.Error())
+	case ok:
+		c.reply(fmt.Sprintf("Reminder #%d cancelled.", id))
+	default:
+		c.reply(fmt.Sprintf("Reminder #%d was not found (only its creator can cancel it).", id))
+	}
+}
+
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
@@ -485,7 +1028,12 @@ func (c *Client) writePump() {
 		select {
 		case msg, ok := <-c.send:
 			if !ok {
-				if err := c.conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
+				// closeFrame was set before send was closed
+				closeFrame := c.closeFrame
+				if closeFrame == nil {
+					closeFrame = []byte{}
+				}
+				if err := c.conn.WriteMessage(websocket.CloseMessage, closeFrame); err != nil {
 					if !strings.Contains(err.Error(), "use of closed network connection") {
 						log.Printf("WriteMessage error: %v", err)
 					}
@@ -494,21 +1042,23 @@ func (c *Client) writePump() {
 			}
 			switch v := msg.(type) {
 			case shared.Message:
-				err := c.conn.WriteJSON(v)
+				n, err := writeFrame(c.conn, v, c.compressMin)
 				if err != nil {
 					if !strings.Contains(err.Error(), "use of closed network connection") {
 						log.Printf("Failed to send message to %s: %v", c.username, err)
 					}
 					return
 				}
+				c.traffic.sent(n)
 			case WSMessage:
-				err := c.conn.WriteJSON(v)
+				n, err := writeFrame(c.conn, v, c.compressMin)
 				if err != nil {
 					if !strings.Contains(err.Error(), "use of closed network connection") {
 						log.Printf("Failed to send system message to %s: %v", c.username, err)
 					}
 					return
 				}
+				c.traffic.sent(n)
 			default:
 				log.Printf("Unknown message type for client %s", c.username)
 			}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -167,7 +168,7 @@ func TestClient_SendChannel(t *testing.T) {
 	// Verify message was sent
 	select {
 	case receivedMessage := <-client.send:
-		if receivedMessage != testMessage {
+		if !reflect.DeepEqual(receivedMessage, testMessage) {
 			t.Error("Received message does not match sent message")
 		}
 	case <-time.After(100 * time.Millisecond):
@@ -497,6 +498,24 @@ func TestParseCommandWithQuotes(t *testing.T) {
 	}
 }
 
+func TestCommandRemainder(t *testing.T) {
+	tests := []struct {
+		command string
+		n       int
+		want    string
+	}{
+		{`:announce  Server "restart"  at noon`, 1, `Server "restart"  at noon`},
+		{":schedule 15m Deploy is done?", 2, "Deploy is done?"},
+		{":schedule 15m", 2, ""},
+		{":announce", 1, ""},
+	}
+	for _, tt := range tests {
+		if got := commandRemainder(tt.command, tt.n); got != tt.want {
+			t.Errorf("commandRemainder(%q, %d) = %q, want %q", tt.command, tt.n, got, tt.want)
+		}
+	}
+}
+
 func TestClient_HandleAdminCommand(t *testing.T) {
 	client, _, _, cleanup := setupTestClient(t)
 	defer cleanup()
@@ -523,3 +542,86 @@ func TestClient_HandleAdminCommand(t *testing.T) {
 	client.handleCommand(":stats")
 	// Should not panic or cause issues
 }
+
+func TestClient_SessionsCommand(t *testing.T) {
+	client, hub, _, cleanup := setupTestClient(t)
+	defer cleanup()
+
+	client.sessionID = "abcd1234"
+	client.connectedAt = time.Now()
+	hub.clients.add(client)
+
+	nextReply := func() string {
+		select {
+		case msg := <-client.send:
+			if m, ok := msg.(shared.Message); ok {
+				return m.Content
+			}
+			t.Fatalf("Unexpected message type %T", msg)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for reply")
+		}
+		return ""
+	}
+
+	// Non-admins may list their own sessions
+	client.handleCommand(":sessions")
+	reply := nextReply()
+	if !strings.Contains(reply, "abcd1234") || !strings.Contains(reply, "(this session)") {
+		t.Errorf("Expected current session in listing, got %q", reply)
+	}
+
+	client.handleCommand(":sessions revoke abcd1234")
+	if reply := nextReply(); !strings.Contains(reply, "Cannot revoke the current session") {
+		t.Errorf("Expected refusal to revoke current session, got %q", reply)
+	}
+
+	client.handleCommand(":sessions revoke ffff0000")
+	if reply := nextReply(); !strings.Contains(reply, "was not found") {
+		t.Errorf("Expected not found reply, got %q", reply)
+	}
+
+	client.handleCommand(":sessions bogus")
+	if reply := nextReply(); !strings.Contains(reply, "Usage") {
+		t.Errorf("Expected usage reply, got %q", reply)
+	}
+}
+
+func TestClient_AnnounceCommand(t *testing.T) {
+	client, _, db, cleanup := setupTestClient(t)
+	defer cleanup()
+
+	// Non-admins are refused
+	client.handleCommand(":announce maintenance at noon")
+	select {
+	case msg := <-client.send:
+		if m, ok := msg.(shared.Message); !ok || !strings.Contains(m.Content, "requires admin") {
+			t.Errorf("Expected admin privileges reply, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for reply")
+	}
+
+	client.isAdmin = true
+	client.handleCommand(":announce")
+	select {
+	case msg := <-client.send:
+		if m, ok := msg.(shared.Message); !ok || !strings.Contains(m.Content, "Usage") {
+			t.Errorf("Expected usage reply, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for reply")
+	}
+
+	client.handleCommand(`:announce Server restarts at "noon" UTC`)
+	history := db.GetRecentMessages()
+	if len(history) != 1 {
+		t.Fatalf("Expected announcement in history, got %d messages", len(history))
+	}
+	if history[0].Type != shared.AnnouncementType {
+		t.Errorf("Expected announcement type, got %q", history[0].Type)
+	}
+	if history[0].Content != `Server restarts at "noon" UTC` {
+		t.Errorf("Announcement text should be preserved verbatim, got %q", history[0].Content)
+	}
+}
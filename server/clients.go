@@ -0,0 +1,137 @@
+package server
+
+import (
+	"hash/maphash"
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// clientShards is how many ways a clientSet splits its clients, so that
+// registering, broadcasting and admin queries don't all wait on one lock
+const clientShards = 32
+
+// parallelFanOut is the connection count from which broadcasts are sent
+// from several goroutines instead of a single loop
+const parallelFanOut = 512
+
+// clientShard holds the clients that hash to it
+type clientShard struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+}
+
+// clientSet is the hub's connected clients, sharded by a hash of the
+// client. It is safe for concurrent use: iteration works on a copy of
+// each shard, so clients may be added or removed while it runs.
+type clientSet struct {
+	seed   maphash.Seed
+	shards [clientShards]clientShard
+	count  atomic.Int64
+}
+
+func newClientSet() *clientSet {
+	s := &clientSet{seed: maphash.MakeSeed()}
+	for i := range s.shards {
+		s.shards[i].clients = make(map[*Client]struct{})
+	}
+	return s
+}
+
+func (s *clientSet) shard(c *Client) *clientShard {
+	return &s.shards[maphash.Comparable(s.seed, c)%clientShards]
+}
+
+// add inserts c, reporting false if it was already there
+func (s *clientSet) add(c *Client) bool {
+	sh := s.shard(c)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, ok := sh.clients[c]; ok {
+		return false
+	}
+	sh.clients[c] = struct{}{}
+	s.count.Add(1)
+	return true
+}
+
+// remove deletes c, reporting whether it was there. Only the caller that
+// removed a client should close its send channel.
+func (s *clientSet) remove(c *Client) bool {
+	sh := s.shard(c)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, ok := sh.clients[c]; !ok {
+		return false
+	}
+	delete(sh.clients, c)
+	s.count.Add(-1)
+	return true
+}
+
+// has reports whether c is connected
+func (s *clientSet) has(c *Client) bool {
+	sh := s.shard(c)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	_, ok := sh.clients[c]
+	return ok
+}
+
+// len is the number of connected clients, without taking any lock
+func (s *clientSet) len() int {
+	return int(s.count.Load())
+}
+
+// all yields every client, one shard at a time
+func (s *clientSet) all() iter.Seq[*Client] {
+	return func(yield func(*Client) bool) {
+		for i := range s.shards {
+			for _, c := range s.shards[i].snapshot() {
+				if !yield(c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// fanOut calls fn for every client and waits for it to finish. Past
+// parallelFanOut clients, and with more than one CPU, shards are shared
+// out among a worker per CPU, so fn must be safe to call concurrently for
+// different clients.
+func (s *clientSet) fanOut(fn func(*Client)) {
+	workers := min(runtime.GOMAXPROCS(0), clientShards)
+	if workers == 1 || s.len() < parallelFanOut {
+		for c := range s.all() {
+			fn(c)
+		}
+		return
+	}
+	var next atomic.Int32
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := next.Add(1) - 1; i < clientShards; i = next.Add(1) - 1 {
+				for _, c := range s.shards[i].snapshot() {
+					fn(c)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// snapshot copies the shard's clients so callers can work without the lock
+func (sh *clientShard) snapshot() []*Client {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	clients := make([]*Client, 0, len(sh.clients))
+	for c := range sh.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientSet(t *testing.T) {
+	s := newClientSet()
+	a, b := &Client{username: "alice"}, &Client{username: "bob"}
+	if !s.add(a) || !s.add(b) || s.add(a) {
+		t.Fatal("Expected each client to be added once")
+	}
+	if s.len() != 2 || !s.has(a) {
+		t.Fatalf("Expected 2 clients including alice, got %d", s.len())
+	}
+
+	// Removing while iterating is safe and only succeeds once
+	for c := range s.all() {
+		if !s.remove(c) {
+			t.Errorf("Expected %s to be removed", c.username)
+		}
+	}
+	if s.len() != 0 || s.remove(a) || s.has(a) {
+		t.Error("Expected the set to be empty")
+	}
+}
+
+func TestDeliverFansOutInParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	hub := &Hub{clients: newClientSet()}
+	var full *Client
+	for i := range parallelFanOut + 10 {
+		c := &Client{username: fmt.Sprintf("user%d", i), send: make(chan interface{}, 1)}
+		if i == 0 {
+			c.send <- "backlog"
+			full = c
+		}
+		hub.clients.add(c)
+	}
+
+	hub.deliver("hello")
+	if hub.clients.has(full) || hub.clients.len() != parallelFanOut+9 {
+		t.Errorf("Expected only the client with a full channel dropped, %d left", hub.clients.len())
+	}
+	if _, open := <-full.send; !open {
+		t.Error("Expected the dropped client's backlog to stay readable")
+	}
+	for c := range hub.clients.all() {
+		if msg := <-c.send; msg != "hello" {
+			t.Fatalf("Expected every client to get the message, %s got %v", c.username, msg)
+		}
+	}
+}
+
+func TestRemoveDuringBroadcast(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	var drained sync.WaitGroup
+	for i := range 200 {
+		c := &Client{username: fmt.Sprintf("user%d", i), send: make(chan interface{}, 4)}
+		hub.clients.add(c)
+		drained.Add(1)
+		go func() {
+			defer drained.Done()
+			for range c.send {
+			}
+		}()
+	}
+
+	// Clients are removed and closed, as the stale connection sweep and
+	// :forcedisconnect do, while broadcasts and user lists go out
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for _, send := range []func(){func() { hub.deliver("hello") }, func() { hub.sendUserList(true) }} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					send()
+				}
+			}
+		}()
+	}
+	for c := range hub.clients.all() {
+		if hub.clients.remove(c) {
+			c.closeSend()
+		}
+		c.closeSend()
+	}
+	close(stop)
+	wg.Wait()
+	drained.Wait()
+
+	if hub.clients.len() != 0 {
+		t.Errorf("Expected every client removed, %d left", hub.clients.len())
+	}
+	// Sending to a closed client is a no-op rather than a panic
+	closed := &Client{username: "gone", send: make(chan interface{}, 1)}
+	closed.closeSend()
+	if closed.trySend("late") {
+		t.Error("Expected a send to a closed client to be dropped")
+	}
+	closed.enqueue("late")
+
+	// A full send channel drops the message rather than waiting, even
+	// while closeSend is waiting for the lock
+	full := &Client{username: "slow", send: make(chan interface{}, 1)}
+	full.enqueue("first")
+	done := make(chan struct{})
+	go func() {
+		full.enqueue("second")
+		full.closeSend()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected enqueue on a full channel not to block closeSend")
+	}
+	if got := <-full.send; got != "first" {
+		t.Errorf("Expected the queued message kept, got %v", got)
+	}
+}
+
+// benchmarkHub connects n clients whose send channels are drained the way
+// their writePumps would, returning a function that disconnects them
+func benchmarkHub(b *testing.B, n int) (*Hub, func()) {
+	hub := &Hub{clients: newClientSet()}
+	var wg sync.WaitGroup
+	for i := range n {
+		c := &Client{username: fmt.Sprintf("user%d", i), send: make(chan interface{}, 256)}
+		hub.clients.add(c)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range c.send {
+			}
+		}()
+	}
+	return hub, func() {
+		for c := range hub.clients.all() {
+			close(c.send)
+		}
+		wg.Wait()
+	}
+}
+
+// BenchmarkBroadcast compares sending one message to every client from a
+// single loop, as the hub used to, with deliver
+func BenchmarkBroadcast(b *testing.B) {
+	for _, n := range []int{1000, 5000} {
+		b.Run(fmt.Sprintf("serial/clients=%d", n), func(b *testing.B) {
+			hub, stop := benchmarkHub(b, n)
+			defer stop()
+			b.ResetTimer()
+			for range b.N {
+				for c := range hub.clients.all() {
+					select {
+					case c.send <- "hello":
+					default:
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("sharded/clients=%d", n), func(b *testing.B) {
+			hub, stop := benchmarkHub(b, n)
+			defer stop()
+			b.ResetTimer()
+			for range b.N {
+				hub.deliver("hello")
+			}
+			b.StopTimer()
+			if hub.clients.len() != n {
+				b.Logf("%d clients dropped with full send channels", n-hub.clients.len())
+			}
+		})
+	}
+}
+
+// BenchmarkClientChurn registers and unregisters clients while admin
+// queries count and list them, all at once on 1000 connections
+func BenchmarkClientChurn(b *testing.B) {
+	hub, stop := benchmarkHub(b, 1000)
+	defer stop()
+	var queries atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			switch i % 4 {
+			case 0:
+				c := &Client{username: "churn"}
+				hub.clients.add(c)
+				hub.clients.remove(c)
+			case 1:
+				hub.GetUserSessions("user42")
+				queries.Add(1)
+			default:
+				_ = hub.clients.len()
+				queries.Add(1)
+			}
+		}
+	})
+	b.ReportMetric(float64(queries.Load())/float64(b.N), "queries/op")
+}
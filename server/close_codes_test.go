@@ -0,0 +1,114 @@
+package server
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// handshakeCloseError connects with hs and returns the close the server
+// answers with
+func handshakeCloseError(t *testing.T, hub *Hub, db Database, hs shared.Handshake) *websocket.CloseError {
+	t.Helper()
+	ts := httptest.NewServer(ServeWs(hub, db, []string{"root"}, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(hs); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var ce *websocket.CloseError
+		if !errors.As(err, &ce) {
+			t.Fatalf("Expected a close frame, got %v", err)
+		}
+		return ce
+	}
+}
+
+func TestHandshakeCloseCodes(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	if ce := handshakeCloseError(t, hub, db, shared.Handshake{Username: "wall", ReadOnly: true}); ce.Code != shared.CloseSpectatorsRefused {
+		t.Errorf("Expected spectators refused, got %d %q", ce.Code, ce.Text)
+	}
+	if ce := handshakeCloseError(t, hub, db, shared.Handshake{Username: "a b"}); ce.Code != shared.CloseUsernameInvalid {
+		t.Errorf("Expected an invalid username, got %d %q", ce.Code, ce.Text)
+	}
+	if ce := handshakeCloseError(t, hub, db, shared.Handshake{Username: "bob", Admin: true}); ce.Code != shared.CloseNotAdmin {
+		t.Errorf("Expected not an admin, got %d %q", ce.Code, ce.Text)
+	}
+
+	hub.BanUser("mallory", "root")
+	if ce := handshakeCloseError(t, hub, db, shared.Handshake{Username: "mallory"}); ce.Code != shared.CloseBanned {
+		t.Errorf("Expected banned, got %d %q", ce.Code, ce.Text)
+	}
+
+	hub.SetMinClientVersion("v2.0.0")
+	hub.RequireMinClientVersion(true)
+	ce := handshakeCloseError(t, hub, db, shared.Handshake{Username: "bob", ClientVersion: "v1.9.0"})
+	if ce.Code != shared.CloseUnsupportedVersion || ce.Text != "v2.0.0" {
+		t.Errorf("Expected unsupported version with the minimum, got %d %q", ce.Code, ce.Text)
+	}
+	hub.RequireMinClientVersion(false)
+
+	hub.SetMaxConnections(1)
+	hub.activePumps.Add(1)
+	defer hub.activePumps.Add(-1)
+	if ce := handshakeCloseError(t, hub, db, shared.Handshake{Username: "bob"}); ce.Code != shared.CloseServerFull {
+		t.Errorf("Expected server full, got %d %q", ce.Code, ce.Text)
+	}
+}
+
+func TestRevokeSessionCloseCode(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	ts := httptest.NewServer(ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(shared.Handshake{Username: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected a welcome frame: %v", err)
+	}
+
+	sessions := hub.GetUserSessions("alice")
+	if len(sessions) != 1 || !hub.RevokeSession("alice", sessions[0].sessionID) {
+		t.Fatalf("Expected alice's session revoked, got %d sessions", len(sessions))
+	}
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var ce *websocket.CloseError
+		if !errors.As(err, &ce) || ce.Code != shared.CloseSessionRevoked {
+			t.Fatalf("Expected a session revoked close, got %v", err)
+		}
+		return
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"compress/flate"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultCompressionThreshold is the smallest frame, in bytes, worth
+// compressing; shorter chat lines cost more CPU than they save
+const DefaultCompressionThreshold = 256
+
+// SetCompression enables permessage-deflate for clients that offer it, at
+// the given flate level (1 fastest to 9 smallest, 0 = off). Frames shorter
+// than threshold bytes are sent uncompressed.
+func (h *Hub) SetCompression(level, threshold int) {
+	if level < flate.BestSpeed || level > flate.BestCompression {
+		level = 0
+	}
+	h.compressionLevel = level
+	h.compressionThreshold = max(threshold, 1)
+}
+
+// upgrader returns the WebSocket upgrader for this hub's settings
+func (h *Hub) upgrader() *websocket.Upgrader {
+	up := upgrader
+	up.EnableCompression = h.compressionLevel > 0
+	up.Subprotocols = shared.Subprotocols
+	return &up
+}
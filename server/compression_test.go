@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestCompressionNegotiated(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		level int
+		want  bool
+	}{
+		{"enabled", 6, true},
+		{"disabled", 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db := CreateTestDatabase(t)
+			defer db.Close()
+			hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+			hub.SetCompression(tt.level, 1)
+			go hub.Run()
+
+			ts := httptest.NewServer(ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+			defer ts.Close()
+			dialer := websocket.Dialer{EnableCompression: true}
+			conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer conn.Close()
+			if got := strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate"); got != tt.want {
+				t.Errorf("Expected permessage-deflate negotiated = %v, got header %q", tt.want, resp.Header.Get("Sec-WebSocket-Extensions"))
+			}
+
+			// Frames still decode either way
+			if err := conn.WriteJSON(shared.Handshake{Username: "alice"}); err != nil {
+				t.Fatal(err)
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Expected a readable frame after the handshake: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetCompressionBounds(t *testing.T) {
+	hub := &Hub{}
+	hub.SetCompression(12, 0)
+	if hub.compressionLevel != 0 || hub.compressionThreshold != 1 {
+		t.Errorf("Expected an invalid level to turn compression off and the threshold to be at least 1, got %d, %d", hub.compressionLevel, hub.compressionThreshold)
+	}
+	if hub.upgrader().EnableCompression {
+		t.Error("Expected the upgrader not to offer compression when it is off")
+	}
+}
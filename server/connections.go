@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// connTraffic counts one connection's frames and payload bytes (before
+// compression) in each direction
+type connTraffic struct {
+	framesIn  atomic.Int64
+	framesOut atomic.Int64
+	bytesIn   atomic.Int64
+	bytesOut  atomic.Int64
+}
+
+func (t *connTraffic) received(n int) {
+	if n > 0 {
+		t.framesIn.Add(1)
+		t.bytesIn.Add(int64(n))
+	}
+}
+
+func (t *connTraffic) sent(n int) {
+	t.framesOut.Add(1)
+	t.bytesOut.Add(int64(n))
+}
+
+// connectionInfo is one connected socket as the admin panel shows it
+type connectionInfo struct {
+	Username    string
+	IP          string
+	SessionID   string
+	ConnectedAt time.Time
+	Admin       bool
+	ReadOnly    bool
+	FramesIn    int64
+	FramesOut   int64
+	BytesIn     int64
+	BytesOut    int64
+	RateIn      float64 // bytes per second since the previous sample
+	RateOut     float64
+}
+
+// connectionGroup is the connections sharing an IP address or subnet
+type connectionGroup struct {
+	Key         string
+	Connections []connectionInfo
+	Users       int // distinct usernames
+	FramesIn    int64
+	BytesIn     int64
+	BytesOut    int64
+	RateIn      float64
+	RateOut     float64
+}
+
+// subnetOf returns the /24 (IPv4) or /64 (IPv6) network of ip, or ip itself
+// when it does not parse
+func subnetOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// groupConnections groups conns by IP, or by subnet, busiest groups first:
+// most sockets, then most traffic
+func groupConnections(conns []connectionInfo, bySubnet bool) []connectionGroup {
+	index := make(map[string]int)
+	var groups []connectionGroup
+	for _, c := range conns {
+		key := c.IP
+		if bySubnet {
+			key = subnetOf(c.IP)
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, connectionGroup{Key: key})
+		}
+		g := &groups[i]
+		g.Connections = append(g.Connections, c)
+		g.FramesIn += c.FramesIn
+		g.BytesIn += c.BytesIn
+		g.BytesOut += c.BytesOut
+		g.RateIn += c.RateIn
+		g.RateOut += c.RateOut
+	}
+	for i := range groups {
+		g := &groups[i]
+		users := make(map[string]bool)
+		for _, c := range g.Connections {
+			users[c.Username] = true
+		}
+		g.Users = len(users)
+		sort.Slice(g.Connections, func(a, b int) bool {
+			return g.Connections[a].BytesIn+g.Connections[a].BytesOut > g.Connections[b].BytesIn+g.Connections[b].BytesOut
+		})
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		if len(groups[a].Connections) != len(groups[b].Connections) {
+			return len(groups[a].Connections) > len(groups[b].Connections)
+		}
+		return groups[a].BytesIn+groups[a].BytesOut > groups[b].BytesIn+groups[b].BytesOut
+	})
+	return groups
+}
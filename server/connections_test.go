@@ -0,0 +1,100 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSubnetOf(t *testing.T) {
+	cases := map[string]string{
+		"192.168.1.77":        "192.168.1.0/24",
+		"2001:db8:1:2:3::9":   "2001:db8:1:2::/64",
+		"::ffff:10.0.0.5":     "10.0.0.0/24",
+		"not-an-ip":           "not-an-ip",
+		"unknown":             "unknown",
+		"10.20.30.40":         "10.20.30.0/24",
+		"2001:db8:1:2:ffff::": "2001:db8:1:2::/64",
+	}
+	for ip, want := range cases {
+		if got := subnetOf(ip); got != want {
+			t.Errorf("subnetOf(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
+
+func TestGroupConnections(t *testing.T) {
+	conns := []connectionInfo{
+		{Username: "alice", IP: "10.0.0.1", BytesIn: 10},
+		{Username: "bob", IP: "10.0.0.2", BytesIn: 500},
+		{Username: "bob", IP: "10.0.0.2", BytesIn: 100, RateIn: 5},
+		{Username: "carol", IP: "10.0.0.2", BytesOut: 50, RateIn: 1},
+		{Username: "dave", IP: "10.0.1.9"},
+	}
+
+	groups := groupConnections(conns, false)
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 IP groups, got %d", len(groups))
+	}
+	g := groups[0]
+	if g.Key != "10.0.0.2" || len(g.Connections) != 3 || g.Users != 2 {
+		t.Fatalf("Expected the busiest IP first, got %+v", g)
+	}
+	if g.BytesIn != 600 || g.BytesOut != 50 || g.RateIn != 6 {
+		t.Errorf("Expected summed traffic, got %+v", g)
+	}
+	if g.Connections[0].BytesIn != 500 {
+		t.Errorf("Expected the heaviest connection first, got %+v", g.Connections[0])
+	}
+
+	groups = groupConnections(conns, true)
+	if len(groups) != 2 || groups[0].Key != "10.0.0.0/24" || len(groups[0].Connections) != 4 {
+		t.Fatalf("Expected two subnets with 10.0.0.0/24 first, got %+v", groups)
+	}
+}
+
+func TestAdminPanel_Connections(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+
+	alice := &Client{username: "alice", ipAddr: "10.0.0.1", sessionID: "s1", connectedAt: time.Now()}
+	bot := func(id string) *Client {
+		return &Client{username: "bot", ipAddr: "10.0.0.9", sessionID: id, connectedAt: time.Now()}
+	}
+	for _, c := range []*Client{alice, bot("b1"), bot("b2"), bot("b3")} {
+		panel.hub.clients.add(c)
+	}
+	alice.traffic.received(100)
+	panel.loadConnections()
+	alice.traffic.received(400)
+	alice.traffic.sent(50)
+	panel.connSamples[alice] = trafficSample{bytesIn: 100, at: time.Now().Add(-time.Second)}
+	panel.loadConnections()
+
+	var got connectionInfo
+	for _, c := range panel.connections {
+		if c.SessionID == "s1" {
+			got = c
+		}
+	}
+	if got.FramesIn != 2 || got.BytesIn != 500 || got.FramesOut != 1 || got.BytesOut != 50 {
+		t.Errorf("Expected alice's traffic counters, got %+v", got)
+	}
+	if got.RateIn < 300 || got.RateIn > 400 {
+		t.Errorf("Expected about 400 B/s in, got %.1f", got.RateIn)
+	}
+
+	panel.activeTab = tabConnections
+	panel.height = 60
+	view := panel.renderConnections()
+	if !strings.Contains(view, "⚠") || !strings.Contains(view, "10.0.0.9  3 sockets, 1 users") {
+		t.Errorf("Expected the three bot sockets to be flagged:\n%s", view)
+	}
+	press := func(k string) { panel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+	press("g")
+	if !panel.connBySubnet || !strings.Contains(panel.renderConnections(), "10.0.0.0/24  4 sockets, 2 users") {
+		t.Error("Expected g to group by subnet")
+	}
+}
@@ -0,0 +1,363 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Cron jobs are recurring messages admins set up with :cron or the web admin
+// panel, such as a standup reminder every weekday morning. They are stored in
+// the database; the hub keeps a timer for the next run of each enabled job.
+// Schedules are read in the server's time zone, and runs missed while the
+// server was down are skipped.
+
+// Cron job actions
+const (
+	cronActionMessage  = "message"  // a System message in the chat
+	cronActionAnnounce = "announce" // an announcement banner
+)
+
+const (
+	maxCronJobs       = 50
+	maxCronMessageLen = 1000
+)
+
+type cronScheduler struct {
+	mu     sync.Mutex
+	timers map[int64]*time.Timer
+}
+
+func newCronScheduler() *cronScheduler {
+	return &cronScheduler{timers: make(map[int64]*time.Timer)}
+}
+
+// CronJobStatus is a job with when it next runs, zero when paused
+type CronJobStatus struct {
+	CronJob
+	NextRun time.Time
+}
+
+// validateCronJob checks a job before it is stored
+func validateCronJob(j CronJob) error {
+	if _, err := parseCronSchedule(j.Schedule); err != nil {
+		return err
+	}
+	if j.Action != cronActionMessage && j.Action != cronActionAnnounce {
+		return fmt.Errorf("unknown action %q (message or announce)", j.Action)
+	}
+	if strings.TrimSpace(j.Message) == "" {
+		return fmt.Errorf("message cannot be empty")
+	}
+	if len(j.Message) > maxCronMessageLen {
+		return fmt.Errorf("message too long (max %d characters)", maxCronMessageLen)
+	}
+	return nil
+}
+
+// nextCronRun is when a job next runs after t, zero when it is paused or
+// its schedule never matches
+func nextCronRun(j CronJob, t time.Time) time.Time {
+	if !j.Enabled {
+		return time.Time{}
+	}
+	s, err := parseCronSchedule(j.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return s.next(t)
+}
+
+// LoadCronJobs schedules every enabled job stored in the database
+func (h *Hub) LoadCronJobs() {
+	if h.db == nil {
+		return
+	}
+	jobs, err := h.db.GetCronJobs()
+	if err != nil {
+		log.Printf("Warning: failed to load cron jobs: %v", err)
+		return
+	}
+	for _, j := range jobs {
+		h.scheduleCronJob(j)
+	}
+	if len(jobs) > 0 {
+		log.Printf("Loaded %d cron job(s)", len(jobs))
+	}
+}
+
+// CronJobs lists the stored jobs, oldest first, with their next runs
+func (h *Hub) CronJobs() ([]CronJobStatus, error) {
+	if h.db == nil {
+		return nil, nil
+	}
+	jobs, err := h.db.GetCronJobs()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	list := make([]CronJobStatus, len(jobs))
+	for i, j := range jobs {
+		list[i] = CronJobStatus{CronJob: j, NextRun: nextCronRun(j, now)}
+	}
+	return list, nil
+}
+
+// cronJob finds a stored job by ID
+func (h *Hub) cronJob(id int64) (CronJob, error) {
+	if h.db == nil {
+		return CronJob{}, fmt.Errorf("cron jobs require a database")
+	}
+	jobs, err := h.db.GetCronJobs()
+	if err != nil {
+		return CronJob{}, err
+	}
+	for _, j := range jobs {
+		if j.ID == id {
+			return j, nil
+		}
+	}
+	return CronJob{}, fmt.Errorf("no cron job #%d", id)
+}
+
+// AddCronJob validates, stores and schedules a recurring message
+func (h *Hub) AddCronJob(schedule, action, message, adminUsername string) (CronJob, error) {
+	if h.db == nil {
+		return CronJob{}, fmt.Errorf("cron jobs require a database")
+	}
+	j := CronJob{
+		Schedule:  strings.Join(strings.Fields(schedule), " "),
+		Action:    action,
+		Message:   strings.TrimSpace(message),
+		Enabled:   true,
+		CreatedBy: adminUsername,
+		CreatedAt: time.Now(),
+	}
+	if err := validateCronJob(j); err != nil {
+		return CronJob{}, err
+	}
+	if nextCronRun(j, j.CreatedAt).IsZero() {
+		return CronJob{}, fmt.Errorf("schedule %q never runs", j.Schedule)
+	}
+	jobs, err := h.db.GetCronJobs()
+	if err != nil {
+		return CronJob{}, err
+	}
+	if len(jobs) >= maxCronJobs {
+		return CronJob{}, fmt.Errorf("there are already %d cron jobs", maxCronJobs)
+	}
+	id, err := h.db.InsertCronJob(j)
+	if err != nil {
+		return CronJob{}, fmt.Errorf("failed to store cron job: %w", err)
+	}
+	j.ID = id
+	h.scheduleCronJob(j)
+	AdminLogger.Info("Cron job added", map[string]interface{}{
+		"admin":    adminUsername,
+		"id":       id,
+		"schedule": j.Schedule,
+		"action":   action,
+	})
+	return j, nil
+}
+
+// RemoveCronJob deletes a job and cancels its next run
+func (h *Hub) RemoveCronJob(id int64, adminUsername string) error {
+	if _, err := h.cronJob(id); err != nil {
+		return err
+	}
+	h.unscheduleCronJob(id)
+	if err := h.db.DeleteCronJob(id); err != nil {
+		return err
+	}
+	AdminLogger.Info("Cron job removed", map[string]interface{}{
+		"admin": adminUsername,
+		"id":    id,
+	})
+	return nil
+}
+
+// SetCronJobEnabled pauses or resumes a job
+func (h *Hub) SetCronJobEnabled(id int64, enabled bool, adminUsername string) (CronJob, error) {
+	j, err := h.cronJob(id)
+	if err != nil {
+		return CronJob{}, err
+	}
+	j.Enabled = enabled
+	if err := h.db.UpdateCronJob(j); err != nil {
+		return CronJob{}, err
+	}
+	h.unscheduleCronJob(id)
+	h.scheduleCronJob(j)
+	AdminLogger.Info("Cron job updated", map[string]interface{}{
+		"admin":   adminUsername,
+		"id":      id,
+		"enabled": enabled,
+	})
+	return j, nil
+}
+
+// RunCronJob posts a job's message now, leaving its schedule as it was
+func (h *Hub) RunCronJob(id int64, adminUsername string) error {
+	j, err := h.cronJob(id)
+	if err != nil {
+		return err
+	}
+	log.Printf("[ADMIN] Cron job #%d run by %s", id, adminUsername)
+	h.postCronJob(j)
+	return nil
+}
+
+func (h *Hub) scheduleCronJob(j CronJob) {
+	next := nextCronRun(j, time.Now())
+	if next.IsZero() {
+		return
+	}
+	h.cron.mu.Lock()
+	defer h.cron.mu.Unlock()
+	h.cron.timers[j.ID] = time.AfterFunc(time.Until(next), func() { h.fireCronJob(j.ID) })
+}
+
+func (h *Hub) unscheduleCronJob(id int64) {
+	h.cron.mu.Lock()
+	defer h.cron.mu.Unlock()
+	if t, ok := h.cron.timers[id]; ok {
+		t.Stop()
+		delete(h.cron.timers, id)
+	}
+}
+
+// fireCronJob posts a due job and schedules its next run. The job is read
+// again so a change made since it was scheduled is honored.
+func (h *Hub) fireCronJob(id int64) {
+	h.cron.mu.Lock()
+	_, pending := h.cron.timers[id]
+	delete(h.cron.timers, id)
+	h.cron.mu.Unlock()
+	if !pending {
+		return // removed or paused
+	}
+	j, err := h.cronJob(id)
+	if err != nil {
+		log.Printf("Warning: cron job #%d not run: %v", id, err)
+		return
+	}
+	if j.Enabled {
+		h.postCronJob(j)
+	}
+	h.scheduleCronJob(j)
+}
+
+// postCronJob sends a job's message to the channel and records the run
+func (h *Hub) postCronJob(j CronJob) {
+	msg := shared.Message{
+		Sender:    "System",
+		Content:   j.Message,
+		CreatedAt: time.Now(),
+		Type:      shared.TextMessage,
+	}
+	if j.Action == cronActionAnnounce {
+		msg.Type = shared.AnnouncementType
+	}
+	h.stamp(&msg)
+	if err := h.db.InsertMessage(msg); err != nil {
+		log.Printf("Failed to store cron job #%d message: %v", j.ID, err)
+	}
+	h.broadcast <- msg
+
+	j.LastRun = msg.CreatedAt
+	if err := h.db.UpdateCronJob(j); err != nil {
+		log.Printf("Warning: failed to record cron job #%d run: %v", j.ID, err)
+	}
+}
+
+// formatCronJob renders a job for :cron list
+func formatCronJob(j CronJobStatus) string {
+	status := "paused"
+	switch {
+	case j.Enabled && j.NextRun.IsZero():
+		status = "never runs"
+	case j.Enabled:
+		status = "next " + j.NextRun.Format("Mon Jan 2 15:04")
+	}
+	return fmt.Sprintf("#%d %s [%s] %q (%s, by %s)", j.ID, j.Action, j.Schedule, j.Message, status, j.CreatedBy)
+}
+
+// handleCronCommand handles ":cron list", ":cron add [announce] <schedule>
+// <message>", ":cron remove|pause|resume|run <id>". A schedule is five cron
+// fields or a macro such as @daily, so it needs no quotes.
+func (c *Client) handleCronCommand(command string) {
+	parts := strings.Fields(command)
+	usage := "Usage: :cron list | :cron add [announce] <min hour day month weekday|@daily> <message> | :cron remove|pause|resume|run <id>"
+	if len(parts) < 2 || parts[1] == "list" {
+		jobs, err := c.hub.CronJobs()
+		if err != nil {
+			c.reply("Could not list cron jobs: " + err.Error())
+			return
+		}
+		if len(jobs) == 0 {
+			c.reply("No cron jobs. " + usage)
+			return
+		}
+		lines := []string{fmt.Sprintf("Cron jobs (server time %s):", time.Now().Format("Mon 15:04 MST"))}
+		for _, j := range jobs {
+			lines = append(lines, formatCronJob(j))
+		}
+		c.reply(strings.Join(lines, "\n"))
+		return
+	}
+
+	switch parts[1] {
+	case "add":
+		action, skip := cronActionMessage, 2
+		if len(parts) > 2 && parts[2] == cronActionAnnounce {
+			action, skip = cronActionAnnounce, 3
+		}
+		fields := 5
+		if len(parts) > skip && strings.HasPrefix(parts[skip], "@") {
+			fields = 1
+		}
+		if len(parts) <= skip+fields {
+			c.reply(usage)
+			return
+		}
+		schedule := strings.Join(parts[skip:skip+fields], " ")
+		j, err := c.hub.AddCronJob(schedule, action, commandRemainder(command, skip+fields), c.username)
+		if err != nil {
+			c.reply("Could not add cron job: " + err.Error())
+			return
+		}
+		c.reply(fmt.Sprintf("Added cron job #%d, first run %s", j.ID, nextCronRun(j, time.Now()).Format("Mon Jan 2 15:04")))
+	case "remove", "pause", "resume", "run":
+		if len(parts) != 3 {
+			c.reply(usage)
+			return
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(parts[2], "#"), 10, 64)
+		if err != nil {
+			c.reply("Invalid cron job ID: " + parts[2])
+			return
+		}
+		switch parts[1] {
+		case "remove":
+			err = c.hub.RemoveCronJob(id, c.username)
+		case "pause", "resume":
+			_, err = c.hub.SetCronJobEnabled(id, parts[1] == "resume", c.username)
+		case "run":
+			err = c.hub.RunCronJob(id, c.username)
+		}
+		if err != nil {
+			c.reply(fmt.Sprintf("Could not %s cron job: %v", parts[1], err))
+			return
+		}
+		past := map[string]string{"remove": "Removed", "pause": "Paused", "resume": "Resumed", "run": "Ran"}[parts[1]]
+		c.reply(fmt.Sprintf("%s cron job #%d", past, id))
+	default:
+		c.reply(usage)
+	}
+}
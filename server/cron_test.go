@@ -0,0 +1,172 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// Wednesday 15 January 2025, 10:30
+	now := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * 3", time.Date(2025, 1, 22, 10, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 8-18/4 * * *", time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 0 1 * fri", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@HOURLY", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 0 30 feb *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := parseCronSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("parseCronSchedule(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := s.next(now); !got.Equal(tt.want) {
+			t.Errorf("%q: next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronScheduleRejects(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "@fortnightly", "0 9 * * funday"} {
+		if _, err := parseCronSchedule(expr); err == nil {
+			t.Errorf("parseCronSchedule(%q) should fail", expr)
+		}
+	}
+}
+
+func TestCronCommand(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	admin := &Client{hub: hub, username: "root", isAdmin: true, send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- admin
+	hub.register <- bob
+
+	bob.handleCommand(":cron add @daily hello")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "admin privileges") {
+		t.Errorf("Non-admins should not manage cron jobs, got %q", msg.Content)
+	}
+
+	admin.handleCommand(":cron add 0 9 * * mon-fri Standup in 5 minutes")
+	if msg := nextTextMessage(t, admin); !strings.HasPrefix(msg.Content, "Added cron job #1, first run") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	admin.handleCommand(":cron add announce @weekly Backups verified")
+	if msg := nextTextMessage(t, admin); !strings.HasPrefix(msg.Content, "Added cron job #2") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	for _, bad := range []string{":cron add 0 9 * * Standup", ":cron add 0 25 * * * late", ":cron add @daily"} {
+		admin.handleCommand(bad)
+		if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "cron") {
+			t.Errorf("%q: unexpected reply %q", bad, msg.Content)
+		}
+	}
+
+	admin.handleCommand(":cron list")
+	msg := nextTextMessage(t, admin)
+	if !strings.Contains(msg.Content, `#1 message [0 9 * * mon-fri] "Standup in 5 minutes" (next`) || !strings.Contains(msg.Content, "#2 announce [@weekly]") {
+		t.Errorf("Unexpected list %q", msg.Content)
+	}
+
+	admin.handleCommand(":cron run 2")
+	if msg := nextTextMessage(t, bob); msg.Type != shared.AnnouncementType || msg.Content != "Backups verified" {
+		t.Errorf("Expected the announcement to be broadcast, got %+v", msg)
+	}
+	// The admin sees the broadcast and the reply, in either order
+	if first, second := nextTextMessage(t, admin), nextTextMessage(t, admin); first.Content != "Ran cron job #2" && second.Content != "Ran cron job #2" {
+		t.Errorf("Unexpected replies %q, %q", first.Content, second.Content)
+	}
+	jobs, err := hub.CronJobs()
+	if err != nil || len(jobs) != 2 || jobs[1].LastRun.IsZero() {
+		t.Errorf("Expected the run to be recorded, got %+v (%v)", jobs, err)
+	}
+
+	admin.handleCommand(":cron pause 1")
+	if msg := nextTextMessage(t, admin); msg.Content != "Paused cron job #1" {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	admin.handleCommand(":cron list")
+	if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "(paused, by root)") {
+		t.Errorf("Unexpected list %q", msg.Content)
+	}
+
+	admin.handleCommand(":cron remove 2")
+	if msg := nextTextMessage(t, admin); msg.Content != "Removed cron job #2" {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	admin.handleCommand(":cron remove 2")
+	if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "no cron job #2") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+}
+
+func TestCronJobFires(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- bob
+
+	j, err := hub.AddCronJob("@daily", cronActionMessage, "Good morning", "root")
+	if err != nil {
+		t.Fatalf("AddCronJob failed: %v", err)
+	}
+
+	// Fire the job as its timer would
+	hub.fireCronJob(j.ID)
+	if msg := nextTextMessage(t, bob); msg.Sender != "System" || msg.Content != "Good morning" || msg.Type != shared.TextMessage {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+	hub.cron.mu.Lock()
+	_, rescheduled := hub.cron.timers[j.ID]
+	hub.cron.mu.Unlock()
+	if !rescheduled {
+		t.Error("Expected the job to be scheduled again")
+	}
+
+	// A removed job's pending timer does nothing
+	if err := hub.RemoveCronJob(j.ID, "root"); err != nil {
+		t.Fatalf("RemoveCronJob failed: %v", err)
+	}
+	hub.fireCronJob(j.ID)
+	select {
+	case msg := <-bob.send:
+		if m, ok := msg.(shared.Message); ok {
+			t.Errorf("Removed job should not post, got %+v", m)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Jobs are scheduled again after a restart
+	if _, err := hub.AddCronJob("0 9 * * *", cronActionMessage, "Standup", "root"); err != nil {
+		t.Fatalf("AddCronJob failed: %v", err)
+	}
+	restarted := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	restarted.LoadCronJobs()
+	restarted.cron.mu.Lock()
+	pending := len(restarted.cron.timers)
+	restarted.cron.mu.Unlock()
+	if pending != 1 {
+		t.Errorf("Expected 1 scheduled job after restart, got %d", pending)
+	}
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+const (
+	maxEmojiImageBytes = 64 * 1024
+	maxEmojiGlyphRunes = 8
+)
+
+var customShortcodePattern = regexp.MustCompile(`^[a-z0-9_+-]{2,32}$`)
+
+// emojiRegistryMessage builds the "emoji" WebSocket message carrying every
+// registered custom emoji
+func emojiRegistryMessage(db Database) (WSMessage, error) {
+	stored, err := db.GetCustomEmoji()
+	if err != nil {
+		return WSMessage{}, err
+	}
+	emoji := make([]shared.CustomEmoji, 0, len(stored))
+	for _, e := range stored {
+		emoji = append(emoji, shared.CustomEmoji{Shortcode: e.Shortcode, Glyph: e.Glyph, Image: e.Image})
+	}
+	payload, err := json.Marshal(emoji)
+	if err != nil {
+		return WSMessage{}, err
+	}
+	return WSMessage{Type: "emoji", Data: payload}, nil
+}
+
+// sendEmojiRegistry syncs the custom emoji to a newly connected client
+func (c *Client) sendEmojiRegistry() {
+	msg, err := emojiRegistryMessage(c.db)
+	if err != nil {
+		log.Printf("Failed to load custom emoji: %v", err)
+		return
+	}
+	c.enqueue(msg)
+}
+
+// normalizeShortcode accepts "shipit" or ":shipit:"
+func normalizeShortcode(code string) string {
+	return strings.ToLower(strings.Trim(code, ":"))
+}
+
+// validGlyph allows a short run of printable characters such as an emoji
+// sequence or a kaomoji
+func validGlyph(glyph string) bool {
+	if glyph == "" || !utf8.ValidString(glyph) || utf8.RuneCountInString(glyph) > maxEmojiGlyphRunes {
+		return false
+	}
+	for _, r := range glyph {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleEmojiCommand lists custom emoji for everyone and lets admins add or
+// remove them. An image for :emoji add arrives as the message's file.
+func (c *Client) handleEmojiCommand(msg shared.Message) {
+	parts := parseCommandWithQuotes(msg.Content)
+	usage := "Usage: :emoji list | :emoji add <shortcode> <glyph> [image.png] | :emoji remove <shortcode>"
+	if len(parts) < 2 || parts[1] == "list" {
+		c.listCustomEmoji()
+		return
+	}
+	if !c.isAdmin {
+		c.reply("Only admins can change custom emoji.")
+		return
+	}
+
+	switch parts[1] {
+	case "add":
+		if len(parts) < 3 || len(parts) > 4 || (len(parts) == 3 && msg.File == nil) {
+			c.reply(usage)
+			return
+		}
+		code := normalizeShortcode(parts[2])
+		if !customShortcodePattern.MatchString(code) {
+			c.reply("Shortcodes are 2-32 characters: lowercase letters, digits, _, + or -.")
+			return
+		}
+		e := CustomEmoji{Shortcode: code, CreatedBy: c.username, CreatedAt: time.Now()}
+		if len(parts) == 4 {
+			if !validGlyph(parts[3]) {
+				c.reply(fmt.Sprintf("The glyph must be up to %d characters without spaces.", maxEmojiGlyphRunes))
+				return
+			}
+			e.Glyph = parts[3]
+		}
+		if msg.File != nil {
+			if len(msg.File.Data) > maxEmojiImageBytes {
+				c.reply(fmt.Sprintf("Emoji image too large (max %d KB).", maxEmojiImageBytes/1024))
+				return
+			}
+			if http.DetectContentType(msg.File.Data) != "image/png" {
+				c.reply("Emoji images must be PNG files.")
+				return
+			}
+			e.Image = msg.File.Data
+		}
+		if err := c.db.SaveCustomEmoji(e); err != nil {
+			log.Printf("Failed to save custom emoji %s: %v", code, err)
+			c.reply("Could not save custom emoji.")
+			return
+		}
+		AdminLogger.Info("Custom emoji registered", map[string]interface{}{
+			"admin":     c.username,
+			"shortcode": code,
+			"image":     e.Image != nil,
+		})
+		c.reply(fmt.Sprintf("Registered :%s:", code))
+	case "remove", "rm":
+		if len(parts) != 3 {
+			c.reply(usage)
+			return
+		}
+		code := normalizeShortcode(parts[2])
+		if err := c.db.DeleteCustomEmoji(code); err != nil {
+			log.Printf("Failed to delete custom emoji %s: %v", code, err)
+			c.reply("Could not remove custom emoji.")
+			return
+		}
+		AdminLogger.Info("Custom emoji removed", map[string]interface{}{
+			"admin":     c.username,
+			"shortcode": code,
+		})
+		c.reply(fmt.Sprintf("Removed :%s:", code))
+	default:
+		c.reply(usage)
+		return
+	}
+
+	registry, err := emojiRegistryMessage(c.db)
+	if err != nil {
+		log.Printf("Failed to load custom emoji: %v", err)
+		return
+	}
+	c.hub.broadcast <- registry
+}
+
+// listCustomEmoji replies with the registered shortcodes
+func (c *Client) listCustomEmoji() {
+	emoji, err := c.db.GetCustomEmoji()
+	if err != nil {
+		c.reply("Could not load custom emoji.")
+		return
+	}
+	if len(emoji) == 0 {
+		c.reply("No custom emoji registered.")
+		return
+	}
+	var b strings.Builder
+	b.WriteString("Custom emoji:")
+	for _, e := range emoji {
+		b.WriteString("\n  :" + e.Shortcode + ":")
+		if e.Glyph != "" {
+			b.WriteString(" " + e.Glyph)
+		}
+		if e.Image != nil {
+			b.WriteString(" (image)")
+		}
+	}
+	c.reply(b.String())
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// nextEmojiRegistry waits for the "emoji" registry message on a client
+func nextEmojiRegistry(t *testing.T, c *Client) []shared.CustomEmoji {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case msg := <-c.send:
+			if ws, ok := msg.(WSMessage); ok && ws.Type == "emoji" {
+				var emoji []shared.CustomEmoji
+				if err := json.Unmarshal(ws.Data, &emoji); err != nil {
+					t.Fatalf("Invalid emoji registry: %v", err)
+				}
+				return emoji
+			}
+		case <-deadline:
+			t.Fatal("Timed out waiting for emoji registry")
+			return nil
+		}
+	}
+}
+
+func TestCustomEmojiCommands(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	admin := &Client{hub: hub, db: NewDatabaseWrapper(db), username: "root", isAdmin: true, send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, db: NewDatabaseWrapper(db), username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- admin
+	hub.register <- bob
+
+	bob.handleEmojiCommand(shared.Message{Content: ":emoji add shipit 🐿️"})
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "Only admins") {
+		t.Errorf("Expected refusal for non-admin, got %q", msg.Content)
+	}
+
+	admin.handleEmojiCommand(shared.Message{Content: ":emoji add :shipit: 🐿️"})
+	if msg := nextTextMessage(t, admin); msg.Content != "Registered :shipit:" {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if emoji := nextEmojiRegistry(t, bob); len(emoji) != 1 || emoji[0].Shortcode != "shipit" || emoji[0].Glyph != "🐿️" {
+		t.Errorf("Expected the registry to be broadcast, got %+v", emoji)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	admin.handleEmojiCommand(shared.Message{Content: ":emoji add parrot", File: &shared.FileMeta{Filename: "parrot.png", Data: png}})
+	if msg := nextTextMessage(t, admin); msg.Content != "Registered :parrot:" {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if emoji := nextEmojiRegistry(t, bob); len(emoji) != 2 || string(emoji[0].Image) != string(png) {
+		t.Errorf("Expected image emoji in the registry, got %+v", emoji)
+	}
+
+	for _, bad := range []shared.Message{
+		{Content: ":emoji add x 🙂"},
+		{Content: ":emoji add fine two words here"},
+		{Content: ":emoji add fine"},
+		{Content: ":emoji add gif", File: &shared.FileMeta{Data: []byte("GIF89a")}},
+	} {
+		admin.handleEmojiCommand(bad)
+		if msg := nextTextMessage(t, admin); strings.HasPrefix(msg.Content, "Registered") {
+			t.Errorf("%q should have been rejected", bad.Content)
+		}
+	}
+
+	bob.handleEmojiCommand(shared.Message{Content: ":emoji list"})
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, ":parrot: (image)") || !strings.Contains(msg.Content, ":shipit: 🐿️") {
+		t.Errorf("Unexpected list %q", msg.Content)
+	}
+
+	admin.handleEmojiCommand(shared.Message{Content: ":emoji remove shipit"})
+	if emoji := nextEmojiRegistry(t, bob); len(emoji) != 1 || emoji[0].Shortcode != "parrot" {
+		t.Errorf("Expected shipit to be removed, got %+v", emoji)
+	}
+}
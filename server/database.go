@@ -23,6 +23,7 @@ type Database interface {
 	InsertEncryptedMessage(msg *shared.EncryptedMessage) error
 	GetRecentMessages() []shared.Message
 	GetMessagesAfter(lastMessageID int64, limit int) []shared.Message
+	GetMessagesSince(since time.Time) []shared.Message
 	GetRecentMessagesForUser(username string, defaultLimit int, banGapsHistory bool) ([]shared.Message, int64)
 	ClearMessages() error
 
@@ -36,8 +37,77 @@ type Database interface {
 	RecordBanEvent(username, bannedBy string) error
 	RecordUnbanEvent(username string) error
 	GetUserBanPeriods(username string) ([]BanPeriod, error)
+	GetBanHistory() ([]BanRecord, error) // every user's bans, oldest first
+	ImportBanRecord(b BanRecord) error   // stores a ban with its original times
+
+	// Reminders
+	InsertReminder(r Reminder) (int64, error)
+	GetPendingReminders() ([]Reminder, error)
+	DeleteReminder(id int64) error
+
+	// Messages queued with :schedule
+	InsertScheduledMessage(sm ScheduledMessage) (int64, error)
+	GetScheduledMessages() ([]ScheduledMessage, error) // soonest first
+	DeleteScheduledMessage(id int64) error
+
+	// Shared snippets
+	InsertSnippet(s Snippet) error
+	GetSnippet(id string) (Snippet, error) // sql.ErrNoRows when missing
+
+	// Server-level custom emoji shortcodes
+	SaveCustomEmoji(e CustomEmoji) error // replaces an existing shortcode
+	DeleteCustomEmoji(shortcode string) error
+	GetCustomEmoji() ([]CustomEmoji, error) // sorted by shortcode
+
+	// Content filter rules (:filter)
+	InsertFilterRule(r FilterRule) (int64, error)
+	DeleteFilterRule(id int64) error
+	GetFilterRules() ([]FilterRule, error) // oldest first
+
+	// Recurring messages (:cron)
+	InsertCronJob(j CronJob) (int64, error)
+	UpdateCronJob(j CronJob) error // saves Enabled and LastRun
+	DeleteCronJob(id int64) error
+	GetCronJobs() ([]CronJob, error) // oldest first
+
+	// Addresses users opted in to offline mention emails with (:email)
+	GetEmailAddress(username string) (string, error) // "" when not opted in
+	SetEmailAddress(username, address string) error  // "" opts out
+	GetEmailAddresses() (map[string]string, error)   // every opt-in, by lowercase username
+
+	// Metrics history rollups, kept across restarts
+	SaveMetricsRollup(r MetricsRollup) error                                       // replaces the bucket's existing row
+	GetMetricsRollups(resolution string, since time.Time) ([]MetricsRollup, error) // oldest first
+	PruneMetricsRollups(resolution string, before time.Time) error
+
+	// Welcome bot settings and the users it has greeted
+	GetWelcomeConfig() (WelcomeConfig, error) // the zero value until first saved
+	SaveWelcomeConfig(c WelcomeConfig) error
+	MarkWelcomed(username string) (bool, error) // true only the first time for a username
+
+	// Channel topics and the message of the day; both are empty until set
+	GetChannelTopic(channel string) (shared.Topic, error)
+	SetChannelTopic(t shared.Topic) error
+	GetMOTD() (shared.MOTD, error)
+	SetMOTD(m shared.MOTD) error
+	GetMaintenance() (shared.Maintenance, error)
+	SetMaintenance(m shared.Maintenance) error // a zero Start clears it
+
+	// Shared notes per channel; empty until first saved. Editor is not stored.
+	GetChannelNotes(channel string) (shared.ChannelNotes, error)
+	SaveChannelNotes(n shared.ChannelNotes) error
+
+	// Mention groups; saving a group replaces its members
+	GetMentionGroups() ([]MentionGroup, error)
+	SaveMentionGroup(g MentionGroup) error
+	DeleteMentionGroup(name string) error
+	GetKnownUsers() ([]string, error) // everyone who has posted or connected, for offline @mentions
 
 	// Statistics
+	CountMessages() (int, error)
+	GetMessageCountsBySender() (map[string]int, error)
+	QuerySenders(q SenderQuery) ([]SenderStats, int, error)        // a page of senders and the total matching
+	GetMessageActivity(since time.Time) ([]MessageActivity, error) // who posted when, System excluded
 	GetDatabaseStats() (string, error)
 	BackupDatabase(dbPath string) (string, error)
 
@@ -45,9 +115,13 @@ type Database interface {
 	GetDB() *sql.DB
 }
 
+// messageCap is how many messages every backend keeps; inserting more
+// trims the oldest
+const messageCap = 1000
+
 // DatabaseConfig holds configuration for database connections
 type DatabaseConfig struct {
-	Type     string // "sqlite", "postgres", "mysql"
+	Type     string // "sqlite", "postgres", "mysql", "document", "memory"
 	Host     string
 	Port     int
 	Database string
@@ -55,8 +129,21 @@ type DatabaseConfig struct {
 	Password string
 	SSLMode  string
 
-	// SQLite-specific
+	// SQLite database file, or directory for the document store
 	FilePath string
+
+	// In-memory only: messages older than this are evicted (0 keeps them until restart)
+	MessageTTL time.Duration
+
+	// AES-256 key for message content at rest (nil = stored as plaintext)
+	EncryptionKey []byte
+
+	// Chat messages are written in batches of up to WriteBatchSize, at
+	// least every WriteBatchInterval, and journaled to WriteJournal until
+	// then (WriteBatchSize 0 or 1 writes each message straight away)
+	WriteBatchSize     int
+	WriteBatchInterval time.Duration
+	WriteJournal       string
 }
 
 // BanPeriod represents a period when a user was banned
@@ -64,3 +151,110 @@ type BanPeriod struct {
 	BannedAt   time.Time
 	UnbannedAt *time.Time
 }
+
+// BanRecord is one ban_history entry, as archives carry it
+type BanRecord struct {
+	Username   string
+	BannedBy   string
+	BannedAt   time.Time
+	UnbannedAt *time.Time // nil while the ban stands
+}
+
+// Reminder is a pending :remind entry, delivered to Target when DueAt passes
+type Reminder struct {
+	ID        int64
+	Creator   string
+	Target    string
+	Text      string
+	DueAt     time.Time
+	CreatedAt time.Time
+}
+
+// ScheduledMessage is a chat message waiting to be sent on Author's behalf at
+// DeliverAt. AuthorAdmin records whether the author was an admin when
+// scheduling, which decides whether the message may use @here.
+type ScheduledMessage struct {
+	ID          int64
+	Author      string
+	Content     string
+	DeliverAt   time.Time
+	AuthorAdmin bool
+	CreatedAt   time.Time
+}
+
+// Snippet is a long paste stored on the server and shared by reference
+type Snippet struct {
+	ID        string
+	Author    string
+	Language  string
+	Content   string
+	CreatedAt time.Time
+}
+
+// FilterRule is a content filter entry: a whole word or a regular
+// expression, matched case-insensitively. Matches are masked, or the whole
+// message is refused when Action is "block".
+type FilterRule struct {
+	ID        int64
+	Pattern   string
+	IsRegex   bool
+	Action    string // "mask" or "block"
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// CronJob is a message posted on a cron schedule, read in the server's time
+// zone. Action is "message" for a System chat message or "announce" for an
+// announcement banner.
+type CronJob struct {
+	ID        int64
+	Schedule  string
+	Action    string
+	Message   string
+	Enabled   bool
+	CreatedBy string
+	CreatedAt time.Time
+	LastRun   time.Time // zero until it first runs
+}
+
+// MetricsRollup is one bucket of server metrics history. Resolution is
+// "minute" or "hour" and Bucket is the start of the bucket.
+type MetricsRollup struct {
+	Resolution  string
+	Bucket      time.Time
+	PeakUsers   int    // most users online at a sample
+	Connections int    // sessions opened
+	Messages    int    // chat messages posted
+	PeakMemory  uint64 // largest heap allocation at a sample, in bytes
+}
+
+// WelcomeConfig is the welcome bot's greeting for users joining for the
+// first time. Message may use {user} for the new user's name.
+type WelcomeConfig struct {
+	Enabled   bool
+	Message   string
+	Rules     string
+	UpdatedBy string
+	UpdatedAt time.Time
+}
+
+// MentionGroup is an admin-managed group that @name mentions expand to.
+// Members are lowercase usernames; AdminsOnly groups can only be mentioned
+// by admins.
+type MentionGroup struct {
+	Name       string
+	AdminsOnly bool
+	Members    []string
+	CreatedBy  string
+	CreatedAt  time.Time
+}
+
+// CustomEmoji is an admin-registered shortcode rendered as a glyph, or as a
+// small image on terminals that support inline graphics
+type CustomEmoji struct {
+	Shortcode string
+	Glyph     string
+	Image     []byte // PNG, optional
+	CreatedBy string
+	CreatedAt time.Time
+}
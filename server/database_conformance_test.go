@@ -0,0 +1,615 @@
+package server
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// runDatabaseConformance exercises the Database interface contract so every
+// backend can be checked against the same expectations.
+func runDatabaseConformance(t *testing.T, db Database) {
+	t.Helper()
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, sender := range []string{"alice", "bob", "alice", "System"} {
+		msg := shared.Message{Sender: sender, Content: "message " + string(rune('a'+i)), CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := db.InsertMessage(msg); err != nil {
+			t.Fatalf("InsertMessage failed: %v", err)
+		}
+	}
+
+	if count, err := db.CountMessages(); err != nil || count != 4 {
+		t.Errorf("CountMessages = %d, %v; want 4", count, err)
+	}
+	counts, err := db.GetMessageCountsBySender()
+	if err != nil {
+		t.Fatalf("GetMessageCountsBySender failed: %v", err)
+	}
+	if counts["alice"] != 2 || counts["bob"] != 1 || counts["System"] != 0 {
+		t.Errorf("Unexpected sender counts: %v", counts)
+	}
+
+	// Paged sender listing for the admin panel
+	page, total, err := db.QuerySenders(SenderQuery{Sort: SortByMessages, Desc: true})
+	if err != nil {
+		t.Fatalf("QuerySenders failed: %v", err)
+	}
+	if total != 2 || len(page) != 2 || page[0].Username != "alice" || page[0].Messages != 2 {
+		t.Errorf("Unexpected senders by message count: %+v (total %d)", page, total)
+	}
+	if page, total, _ = db.QuerySenders(SenderQuery{Sort: SortByName, Offset: 1, Limit: 1}); total != 2 || len(page) != 1 || page[0].Username != "bob" {
+		t.Errorf("Expected bob on the second page by name, got %+v (total %d)", page, total)
+	}
+	if page, _, _ = db.QuerySenders(SenderQuery{Sort: SortByLastSeen, Desc: true}); len(page) != 2 || page[0].Username != "alice" ||
+		!page[0].LastSeen.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("Expected alice to have posted last at %v, got %+v", base.Add(2*time.Minute), page)
+	}
+	if page, total, _ = db.QuerySenders(SenderQuery{Search: "LI"}); total != 1 || page[0].Username != "alice" {
+		t.Errorf("Expected a case-insensitive search to find alice, got %+v", page)
+	}
+	if _, total, _ = db.QuerySenders(SenderQuery{Search: "%"}); total != 0 {
+		t.Errorf("Expected wildcards in the search to match literally, got %d", total)
+	}
+	if page, total, _ = db.QuerySenders(SenderQuery{Only: []string{"bob", "carol"}}); total != 1 || page[0].Username != "bob" {
+		t.Errorf("Expected only bob, got %+v", page)
+	}
+	if _, total, _ = db.QuerySenders(SenderQuery{Only: []string{}}); total != 0 {
+		t.Errorf("Expected an empty Only list to match nobody, got %d", total)
+	}
+
+	// Who posted when, for :stats
+	activity, err := db.GetMessageActivity(base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetMessageActivity failed: %v", err)
+	}
+	if len(activity) != 2 {
+		t.Fatalf("Expected bob's and alice's later messages, got %+v", activity)
+	}
+	for _, a := range activity {
+		if a.Sender == "bob" && !a.At.Equal(base.Add(time.Minute)) || a.Sender == "alice" && !a.At.Equal(base.Add(2*time.Minute)) {
+			t.Errorf("Unexpected activity %+v", a)
+		}
+	}
+
+	recent := db.GetRecentMessages()
+	if len(recent) != 4 || recent[0].Content != "message a" {
+		t.Errorf("GetRecentMessages should return chronological history, got %+v", recent)
+	}
+
+	latest := db.GetLatestMessageID()
+	if latest == 0 {
+		t.Fatal("GetLatestMessageID should be non-zero after inserts")
+	}
+	if after := db.GetMessagesAfter(latest-1, 10); len(after) != 1 || after[0].Sender != "System" {
+		t.Errorf("GetMessagesAfter should return only the newest message, got %+v", after)
+	}
+	if since := db.GetMessagesSince(base.Add(90 * time.Second)); len(since) != 2 {
+		t.Errorf("GetMessagesSince should return 2 messages, got %d", len(since))
+	}
+
+	// User message state
+	if _, err := db.GetUserLastMessageID("carol"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for unknown user, got %v", err)
+	}
+	if err := db.SetUserLastMessageID("carol", latest); err != nil {
+		t.Fatalf("SetUserLastMessageID failed: %v", err)
+	}
+	if id, err := db.GetUserLastMessageID("carol"); err != nil || id != latest {
+		t.Errorf("GetUserLastMessageID = %d, %v; want %d", id, err, latest)
+	}
+	if err := db.ClearUserMessageState("carol"); err != nil {
+		t.Fatalf("ClearUserMessageState failed: %v", err)
+	}
+
+	// Ban history
+	if err := db.RecordBanEvent("mallory", "admin"); err != nil {
+		t.Fatalf("RecordBanEvent failed: %v", err)
+	}
+	if err := db.RecordUnbanEvent("mallory"); err != nil {
+		t.Fatalf("RecordUnbanEvent failed: %v", err)
+	}
+	periods, err := db.GetUserBanPeriods("mallory")
+	if err != nil || len(periods) != 1 || periods[0].UnbannedAt == nil {
+		t.Errorf("Expected one closed ban period, got %+v (%v)", periods, err)
+	}
+	if err := db.ImportBanRecord(BanRecord{Username: "eve", BannedBy: "admin", BannedAt: base.Add(-time.Hour)}); err != nil {
+		t.Fatalf("ImportBanRecord failed: %v", err)
+	}
+	bans, err := db.GetBanHistory()
+	if err != nil || len(bans) != 2 || bans[0].Username != "eve" || bans[0].UnbannedAt != nil || !bans[0].BannedAt.Equal(base.Add(-time.Hour)) || bans[1].Username != "mallory" {
+		t.Errorf("Expected the imported ban first with its original time, got %+v (%v)", bans, err)
+	}
+
+	// Reminders
+	due := base.Add(2 * time.Hour)
+	remID, err := db.InsertReminder(Reminder{Creator: "alice", Target: "bob", Text: "review PR", DueAt: due, CreatedAt: base})
+	if err != nil {
+		t.Fatalf("InsertReminder failed: %v", err)
+	}
+	if _, err := db.InsertReminder(Reminder{Creator: "bob", Target: "bob", Text: "earlier", DueAt: base, CreatedAt: base}); err != nil {
+		t.Fatalf("InsertReminder failed: %v", err)
+	}
+	reminders, err := db.GetPendingReminders()
+	if err != nil || len(reminders) != 2 || reminders[0].Text != "earlier" {
+		t.Fatalf("GetPendingReminders should return both reminders soonest first, got %+v (%v)", reminders, err)
+	}
+	if reminders[1].ID != remID || reminders[1].Target != "bob" || !reminders[1].DueAt.Equal(due) {
+		t.Errorf("Unexpected stored reminder: %+v", reminders[1])
+	}
+	if err := db.DeleteReminder(remID); err != nil {
+		t.Fatalf("DeleteReminder failed: %v", err)
+	}
+	if reminders, _ := db.GetPendingReminders(); len(reminders) != 1 {
+		t.Errorf("Expected 1 reminder after delete, got %d", len(reminders))
+	}
+
+	// Scheduled messages
+	schedID, err := db.InsertScheduledMessage(ScheduledMessage{Author: "alice", Content: "@here lunch?", DeliverAt: due, AuthorAdmin: true, CreatedAt: base})
+	if err != nil {
+		t.Fatalf("InsertScheduledMessage failed: %v", err)
+	}
+	if _, err := db.InsertScheduledMessage(ScheduledMessage{Author: "bob", Content: "earlier", DeliverAt: base, CreatedAt: base}); err != nil {
+		t.Fatalf("InsertScheduledMessage failed: %v", err)
+	}
+	scheduled, err := db.GetScheduledMessages()
+	if err != nil || len(scheduled) != 2 || scheduled[0].Content != "earlier" {
+		t.Fatalf("GetScheduledMessages should return both messages soonest first, got %+v (%v)", scheduled, err)
+	}
+	if scheduled[1].ID != schedID || scheduled[1].Author != "alice" || !scheduled[1].AuthorAdmin || !scheduled[1].DeliverAt.Equal(due) {
+		t.Errorf("Unexpected stored scheduled message: %+v", scheduled[1])
+	}
+	if err := db.DeleteScheduledMessage(schedID); err != nil {
+		t.Fatalf("DeleteScheduledMessage failed: %v", err)
+	}
+	if scheduled, _ := db.GetScheduledMessages(); len(scheduled) != 1 || scheduled[0].AuthorAdmin {
+		t.Errorf("Expected bob's message alone after delete, got %+v", scheduled)
+	}
+
+	// Snippets
+	snip := Snippet{ID: "a1b2c3d4e5f6", Author: "alice", Language: "go", Content: "package main\n", CreatedAt: base}
+	if err := db.InsertSnippet(snip); err != nil {
+		t.Fatalf("InsertSnippet failed: %v", err)
+	}
+	if got, err := db.GetSnippet(snip.ID); err != nil || got.Content != snip.Content || got.Author != "alice" || got.Language != "go" {
+		t.Errorf("GetSnippet = %+v, %v", got, err)
+	}
+	if _, err := db.GetSnippet("000000000000"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for unknown snippet, got %v", err)
+	}
+
+	// Custom emoji
+	if err := db.SaveCustomEmoji(CustomEmoji{Shortcode: "shipit", Glyph: "🐿️", CreatedBy: "admin", CreatedAt: base}); err != nil {
+		t.Fatalf("SaveCustomEmoji failed: %v", err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	if err := db.SaveCustomEmoji(CustomEmoji{Shortcode: "party_parrot", Image: png, CreatedBy: "admin", CreatedAt: base}); err != nil {
+		t.Fatalf("SaveCustomEmoji failed: %v", err)
+	}
+	if err := db.SaveCustomEmoji(CustomEmoji{Shortcode: "shipit", Glyph: "🚢", CreatedBy: "root", CreatedAt: base}); err != nil {
+		t.Fatalf("SaveCustomEmoji (replace) failed: %v", err)
+	}
+	emoji, err := db.GetCustomEmoji()
+	if err != nil || len(emoji) != 2 {
+		t.Fatalf("Expected 2 custom emoji, got %+v (%v)", emoji, err)
+	}
+	if emoji[0].Shortcode != "party_parrot" || string(emoji[0].Image) != string(png) || emoji[1].Glyph != "🚢" || emoji[1].CreatedBy != "root" {
+		t.Errorf("Unexpected custom emoji %+v", emoji)
+	}
+	if err := db.DeleteCustomEmoji("party_parrot"); err != nil {
+		t.Fatalf("DeleteCustomEmoji failed: %v", err)
+	}
+	if emoji, _ := db.GetCustomEmoji(); len(emoji) != 1 {
+		t.Errorf("Expected 1 custom emoji after delete, got %+v", emoji)
+	}
+
+	wordID, err := db.InsertFilterRule(FilterRule{Pattern: "darn", Action: "mask", CreatedBy: "admin", CreatedAt: base})
+	if err != nil {
+		t.Fatalf("InsertFilterRule failed: %v", err)
+	}
+	if _, err := db.InsertFilterRule(FilterRule{Pattern: `spam\d+`, IsRegex: true, Action: "block", CreatedBy: "admin", CreatedAt: base}); err != nil {
+		t.Fatalf("InsertFilterRule failed: %v", err)
+	}
+	rules, err := db.GetFilterRules()
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("Expected 2 filter rules, got %+v (%v)", rules, err)
+	}
+	if rules[0].ID != wordID || rules[0].Pattern != "darn" || rules[0].IsRegex || !rules[1].IsRegex || rules[1].Action != "block" {
+		t.Errorf("Unexpected filter rules %+v", rules)
+	}
+	if err := db.DeleteFilterRule(wordID); err != nil {
+		t.Fatalf("DeleteFilterRule failed: %v", err)
+	}
+	if rules, _ := db.GetFilterRules(); len(rules) != 1 || rules[0].Pattern != `spam\d+` {
+		t.Errorf("Expected 1 filter rule after delete, got %+v", rules)
+	}
+
+	standupID, err := db.InsertCronJob(CronJob{Schedule: "0 9 * * mon-fri", Action: "message", Message: "Standup", Enabled: true, CreatedBy: "admin", CreatedAt: base})
+	if err != nil {
+		t.Fatalf("InsertCronJob failed: %v", err)
+	}
+	if _, err := db.InsertCronJob(CronJob{Schedule: "@weekly", Action: "announce", Message: "Backups verified", Enabled: true, CreatedBy: "admin", CreatedAt: base}); err != nil {
+		t.Fatalf("InsertCronJob failed: %v", err)
+	}
+	jobs, err := db.GetCronJobs()
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("Expected 2 cron jobs, got %+v (%v)", jobs, err)
+	}
+	if jobs[0].ID != standupID || jobs[0].Schedule != "0 9 * * mon-fri" || !jobs[0].LastRun.IsZero() || jobs[1].Action != "announce" {
+		t.Errorf("Unexpected cron jobs %+v", jobs)
+	}
+	ran := base.Add(time.Hour).Truncate(time.Second)
+	jobs[0].Enabled, jobs[0].LastRun = false, ran
+	if err := db.UpdateCronJob(jobs[0]); err != nil {
+		t.Fatalf("UpdateCronJob failed: %v", err)
+	}
+	if err := db.DeleteCronJob(jobs[1].ID); err != nil {
+		t.Fatalf("DeleteCronJob failed: %v", err)
+	}
+	if jobs, _ := db.GetCronJobs(); len(jobs) != 1 || jobs[0].Enabled || !jobs[0].LastRun.Equal(ran) {
+		t.Errorf("Expected 1 paused cron job that ran at %v, got %+v", ran, jobs)
+	}
+
+	hour := base.Truncate(time.Hour)
+	for i, users := range []int{3, 5, 4} {
+		rollup := MetricsRollup{Resolution: "minute", Bucket: hour.Add(time.Duration(i) * time.Minute), PeakUsers: users, Messages: i, PeakMemory: 1 << 20}
+		if err := db.SaveMetricsRollup(rollup); err != nil {
+			t.Fatalf("SaveMetricsRollup failed: %v", err)
+		}
+	}
+	if err := db.SaveMetricsRollup(MetricsRollup{Resolution: "hour", Bucket: hour, PeakUsers: 5, Messages: 3}); err != nil {
+		t.Fatalf("SaveMetricsRollup failed: %v", err)
+	}
+	if err := db.SaveMetricsRollup(MetricsRollup{Resolution: "minute", Bucket: hour, PeakUsers: 7, Connections: 2}); err != nil {
+		t.Fatalf("SaveMetricsRollup replace failed: %v", err)
+	}
+	rollups, err := db.GetMetricsRollups("minute", hour)
+	if err != nil || len(rollups) != 3 {
+		t.Fatalf("Expected 3 minute rollups, got %+v (%v)", rollups, err)
+	}
+	if !rollups[0].Bucket.Equal(hour) || rollups[0].PeakUsers != 7 || rollups[0].Connections != 2 || rollups[2].PeakMemory != 1<<20 {
+		t.Errorf("Unexpected minute rollups %+v", rollups)
+	}
+	if rollups, _ := db.GetMetricsRollups("minute", hour.Add(90*time.Second)); len(rollups) != 1 || rollups[0].PeakUsers != 4 {
+		t.Errorf("Expected the last minute only, got %+v", rollups)
+	}
+	if err := db.PruneMetricsRollups("minute", hour.Add(2*time.Minute)); err != nil {
+		t.Fatalf("PruneMetricsRollups failed: %v", err)
+	}
+	if rollups, _ := db.GetMetricsRollups("minute", time.Time{}); len(rollups) != 1 {
+		t.Errorf("Expected 1 minute rollup after prune, got %+v", rollups)
+	}
+	if rollups, _ := db.GetMetricsRollups("hour", time.Time{}); len(rollups) != 1 || rollups[0].Messages != 3 {
+		t.Errorf("Pruning minutes should keep hours, got %+v", rollups)
+	}
+
+	// Welcome bot settings and greeted users
+	if cfg, err := db.GetWelcomeConfig(); err != nil || cfg.Enabled || cfg.Message != "" {
+		t.Errorf("Expected an empty welcome config before saving, got %+v (%v)", cfg, err)
+	}
+	saved := WelcomeConfig{Enabled: true, Message: "Hi {user}", Rules: "Be kind", UpdatedBy: "admin", UpdatedAt: base}
+	if err := db.SaveWelcomeConfig(saved); err != nil {
+		t.Fatalf("SaveWelcomeConfig failed: %v", err)
+	}
+	saved.Rules = "Be kinder"
+	if err := db.SaveWelcomeConfig(saved); err != nil {
+		t.Fatalf("SaveWelcomeConfig replace failed: %v", err)
+	}
+	if cfg, err := db.GetWelcomeConfig(); err != nil || !cfg.Enabled || cfg.Message != "Hi {user}" || cfg.Rules != "Be kinder" ||
+		cfg.UpdatedBy != "admin" || !cfg.UpdatedAt.Equal(base) {
+		t.Errorf("Unexpected welcome config %+v (%v)", cfg, err)
+	}
+	if first, err := db.MarkWelcomed("carol"); err != nil || !first {
+		t.Errorf("MarkWelcomed should report the first greeting, got %v (%v)", first, err)
+	}
+	if first, err := db.MarkWelcomed("carol"); err != nil || first {
+		t.Errorf("MarkWelcomed should report a repeat greeting, got %v (%v)", first, err)
+	}
+
+	// Channel topics and the message of the day
+	if topic, err := db.GetChannelTopic("room"); err != nil || topic.Channel != "room" || topic.Text != "" {
+		t.Errorf("Expected an empty topic before setting one, got %+v (%v)", topic, err)
+	}
+	for _, text := range []string{"Release day", "Release day 🎉"} {
+		if err := db.SetChannelTopic(shared.Topic{Channel: "room", Text: text, SetBy: "admin", SetAt: base}); err != nil {
+			t.Fatalf("SetChannelTopic failed: %v", err)
+		}
+	}
+	if topic, err := db.GetChannelTopic("room"); err != nil || topic.Text != "Release day 🎉" || topic.SetBy != "admin" || !topic.SetAt.Equal(base) {
+		t.Errorf("Unexpected topic %+v (%v)", topic, err)
+	}
+	if topic, _ := db.GetChannelTopic("other"); topic.Text != "" {
+		t.Errorf("Topics should be per channel, got %+v", topic)
+	}
+	if motd, err := db.GetMOTD(); err != nil || motd.Text != "" {
+		t.Errorf("Expected no MOTD before setting one, got %+v (%v)", motd, err)
+	}
+	if err := db.SetMOTD(shared.MOTD{Text: "Maintenance at 22:00", SetBy: "admin", SetAt: base}); err != nil {
+		t.Fatalf("SetMOTD failed: %v", err)
+	}
+	if motd, err := db.GetMOTD(); err != nil || motd.Text != "Maintenance at 22:00" || !motd.SetAt.Equal(base) {
+		t.Errorf("Unexpected MOTD %+v (%v)", motd, err)
+	}
+	if mw, err := db.GetMaintenance(); err != nil || mw.Scheduled() {
+		t.Errorf("Expected no maintenance window before setting one, got %+v (%v)", mw, err)
+	}
+	window := shared.Maintenance{Start: base.Add(time.Hour), End: base.Add(2 * time.Hour), Message: "Upgrading 🛠", SetBy: "admin", SetAt: base}
+	if err := db.SetMaintenance(window); err != nil {
+		t.Fatalf("SetMaintenance failed: %v", err)
+	}
+	if mw, err := db.GetMaintenance(); err != nil || !mw.Start.Equal(window.Start) || !mw.End.Equal(window.End) || mw.Message != window.Message || mw.SetBy != "admin" {
+		t.Errorf("Unexpected maintenance window %+v (%v)", mw, err)
+	}
+	if err := db.SetMaintenance(shared.Maintenance{SetBy: "admin", SetAt: base}); err != nil {
+		t.Fatalf("SetMaintenance failed: %v", err)
+	}
+	if mw, _ := db.GetMaintenance(); mw.Scheduled() {
+		t.Errorf("Expected the maintenance window to be cleared, got %+v", mw)
+	}
+
+	// Mention email opt-ins, by case-insensitive username
+	if address, err := db.GetEmailAddress("alice"); err != nil || address != "" {
+		t.Errorf("Expected no email address before opting in, got %q (%v)", address, err)
+	}
+	for _, address := range []string{"alice@example.com", "alice@example.org"} {
+		if err := db.SetEmailAddress("Alice", address); err != nil {
+			t.Fatalf("SetEmailAddress failed: %v", err)
+		}
+	}
+	if address, err := db.GetEmailAddress("alice"); err != nil || address != "alice@example.org" {
+		t.Errorf("Expected the latest address, got %q (%v)", address, err)
+	}
+	if all, err := db.GetEmailAddresses(); err != nil || len(all) != 1 || all["alice"] != "alice@example.org" {
+		t.Errorf("Expected alice's opt-in listed by lowercase username, got %v (%v)", all, err)
+	}
+	if err := db.SetEmailAddress("alice", ""); err != nil {
+		t.Fatalf("SetEmailAddress failed: %v", err)
+	}
+	if address, _ := db.GetEmailAddress("alice"); address != "" {
+		t.Errorf("Expected opting out to forget the address, got %q", address)
+	}
+
+	// Shared notes, per channel
+	if notes, err := db.GetChannelNotes("room"); err != nil || notes.Channel != "room" || notes.Text != "" || notes.Version != 0 {
+		t.Errorf("Expected empty notes before saving, got %+v (%v)", notes, err)
+	}
+	for version, text := range []string{"Agenda", "Agenda\n- ship it 🚀"} {
+		if err := db.SaveChannelNotes(shared.ChannelNotes{Channel: "room", Text: text, Version: int64(version + 1), UpdatedBy: "alice", UpdatedAt: base}); err != nil {
+			t.Fatalf("SaveChannelNotes failed: %v", err)
+		}
+	}
+	if notes, err := db.GetChannelNotes("room"); err != nil || notes.Text != "Agenda\n- ship it 🚀" || notes.Version != 2 || notes.UpdatedBy != "alice" || !notes.UpdatedAt.Equal(base) {
+		t.Errorf("Unexpected notes %+v (%v)", notes, err)
+	}
+	if notes, _ := db.GetChannelNotes("other"); notes.Text != "" {
+		t.Errorf("Notes should be per channel, got %+v", notes)
+	}
+
+	// Mention groups; saving replaces the members but keeps the creator
+	if groups, err := db.GetMentionGroups(); err != nil || len(groups) != 0 {
+		t.Errorf("Expected no mention groups, got %+v (%v)", groups, err)
+	}
+	devs := MentionGroup{Name: "devs", Members: []string{"alice", "bob"}, CreatedBy: "admin", CreatedAt: base}
+	if err := db.SaveMentionGroup(devs); err != nil {
+		t.Fatalf("SaveMentionGroup failed: %v", err)
+	}
+	if err := db.SaveMentionGroup(MentionGroup{Name: "ops", AdminsOnly: true, CreatedBy: "admin", CreatedAt: base}); err != nil {
+		t.Fatalf("SaveMentionGroup failed: %v", err)
+	}
+	devs.Members = []string{"bob", "carol"}
+	if err := db.SaveMentionGroup(devs); err != nil {
+		t.Fatalf("SaveMentionGroup failed: %v", err)
+	}
+	groups, err := db.GetMentionGroups()
+	if err != nil || len(groups) != 2 {
+		t.Fatalf("Expected two mention groups, got %+v (%v)", groups, err)
+	}
+	if g := groups[0]; g.Name != "devs" || g.AdminsOnly || !reflect.DeepEqual(g.Members, []string{"bob", "carol"}) || g.CreatedBy != "admin" || !g.CreatedAt.Equal(base) {
+		t.Errorf("Unexpected group %+v", g)
+	}
+	if g := groups[1]; g.Name != "ops" || !g.AdminsOnly || len(g.Members) != 0 {
+		t.Errorf("Unexpected group %+v", g)
+	}
+	if err := db.DeleteMentionGroup("devs"); err != nil {
+		t.Fatalf("DeleteMentionGroup failed: %v", err)
+	}
+	if groups, _ := db.GetMentionGroups(); len(groups) != 1 || groups[0].Name != "ops" {
+		t.Errorf("Expected only ops after deleting devs, got %+v", groups)
+	}
+
+	// Known users: senders and anyone with history state, for offline mentions
+	if err := db.SetUserLastMessageID("dave", 1); err != nil {
+		t.Fatalf("SetUserLastMessageID failed: %v", err)
+	}
+	known, err := db.GetKnownUsers()
+	slices.Sort(known)
+	if err != nil || !reflect.DeepEqual(known, []string{"alice", "bob", "dave"}) {
+		t.Errorf("GetKnownUsers = %v, %v; want alice, bob and dave", known, err)
+	}
+	if err := db.ClearUserMessageState("dave"); err != nil {
+		t.Fatalf("ClearUserMessageState failed: %v", err)
+	}
+
+	stats, err := db.GetDatabaseStats()
+	if err != nil || !strings.Contains(stats, "Total Messages: 4") {
+		t.Errorf("Unexpected stats %q (%v)", stats, err)
+	}
+
+	// Channel order follows the server's numbering, not the senders' clocks
+	next := latestSeq(db)
+	if next == 0 {
+		t.Error("Expected unnumbered messages to be numbered as they are stored")
+	}
+	for i, content := range []string{"first", "second", "third"} {
+		next++
+		msg := shared.Message{Sender: "dave", Content: content, CreatedAt: base.Add(-time.Duration(i) * time.Hour), Seq: next}
+		if err := db.InsertMessage(msg); err != nil {
+			t.Fatalf("InsertMessage failed: %v", err)
+		}
+	}
+	contents := func(msgs []shared.Message) []string {
+		var out []string
+		for _, msg := range msgs[max(len(msgs)-3, 0):] {
+			out = append(out, msg.Content)
+		}
+		return out
+	}
+	want := []string{"first", "second", "third"}
+	if got := contents(db.GetRecentMessages()); !slices.Equal(got, want) {
+		t.Errorf("GetRecentMessages should be in sequence order, got %v", got)
+	}
+	if got := contents(db.GetMessagesSince(time.Time{})); !slices.Equal(got, want) {
+		t.Errorf("GetMessagesSince should be in sequence order, got %v", got)
+	}
+	if latestSeq(db) != next {
+		t.Errorf("Expected latest sequence number %d, got %d", next, latestSeq(db))
+	}
+
+	if err := db.ClearMessages(); err != nil {
+		t.Fatalf("ClearMessages failed: %v", err)
+	}
+	if count, _ := db.CountMessages(); count != 0 {
+		t.Errorf("Expected no messages after clear, got %d", count)
+	}
+}
+
+func TestSQLiteDatabaseConformance(t *testing.T) {
+	db, err := NewDatabase(DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to create sqlite database: %v", err)
+	}
+	defer db.Close()
+
+	runDatabaseConformance(t, db)
+}
+
+func TestDocumentDatabaseConformance(t *testing.T) {
+	db, err := NewDatabase(DatabaseConfig{Type: "document", FilePath: filepath.Join(t.TempDir(), "docs")})
+	if err != nil {
+		t.Fatalf("Failed to create document database: %v", err)
+	}
+	defer db.Close()
+
+	if db.GetDB() != nil {
+		t.Error("Document store should not expose a SQL connection")
+	}
+	runDatabaseConformance(t, db)
+}
+
+func TestDocumentDBPersistenceAndMigrations(t *testing.T) {
+	dir := t.TempDir()
+
+	// Seed a pre-migration store: messages without IDs and a ban without audit events
+	seed := map[string]string{
+		"messages.json":    `[{"sender":"alice","content":"legacy","created_at":"2024-01-01T00:00:00Z"}]`,
+		"ban_history.json": `[{"id":1,"username":"mallory","banned_at":"2024-01-02T00:00:00Z","banned_by":"admin"}]`,
+	}
+	for name, content := range seed {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to seed %s: %v", name, err)
+		}
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewDatabase(DatabaseConfig{Type: "document", FilePath: dir})
+	if err != nil {
+		t.Fatalf("Failed to open document database: %v", err)
+	}
+	doc := db.(*DocumentDB)
+	if doc.schemaVersion != len(documentMigrations) {
+		t.Errorf("Expected schema version %d, got %d", len(documentMigrations), doc.schemaVersion)
+	}
+	if db.GetLatestMessageID() != 1 {
+		t.Errorf("Legacy message should have been assigned ID 1, got %d", db.GetLatestMessageID())
+	}
+	if latestSeq(db) != 1 {
+		t.Errorf("Legacy message should have been numbered 1, got %d", latestSeq(db))
+	}
+	if len(doc.audit) != 1 || doc.audit[0].Action != "ban" {
+		t.Errorf("Audit collection should be seeded from ban history, got %+v", doc.audit)
+	}
+
+	if err := db.InsertMessage(shared.Message{Sender: "bob", Content: "new", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		// Collections hold emails and snippets: private to the server's user
+		for _, name := range []string{"", "messages.json"} {
+			if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Mode().Perm()&0077 != 0 {
+				t.Errorf("Expected %q private to its owner, got %v (%v)", name, info.Mode(), err)
+			}
+		}
+	}
+	if _, err := db.BackupDatabase(dir); err != nil {
+		t.Errorf("BackupDatabase failed: %v", err)
+	}
+	db.Close()
+
+	// Reopen and confirm data survived
+	reopened, err := NewDatabase(DatabaseConfig{Type: "document", FilePath: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen document database: %v", err)
+	}
+	defer reopened.Close()
+
+	if count, _ := reopened.CountMessages(); count != 2 {
+		t.Errorf("Expected 2 persisted messages, got %d", count)
+	}
+	if reopened.GetLatestMessageID() != 2 {
+		t.Errorf("Expected latest ID 2 after reopen, got %d", reopened.GetLatestMessageID())
+	}
+}
+
+func TestMemoryDatabaseConformance(t *testing.T) {
+	db, err := NewDatabase(DatabaseConfig{Type: "memory"})
+	if err != nil {
+		t.Fatalf("Failed to create memory database: %v", err)
+	}
+	defer db.Close()
+
+	runDatabaseConformance(t, db)
+
+	if _, err := db.BackupDatabase(""); err == nil {
+		t.Error("Backing up an in-memory database should fail")
+	}
+}
+
+func TestMemoryDatabaseTTLEviction(t *testing.T) {
+	db, err := NewDatabase(DatabaseConfig{Type: "memory", MessageTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create memory database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InsertMessage(shared.Message{Sender: "alice", Content: "stale", CreatedAt: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+	if err := db.InsertMessage(shared.Message{Sender: "bob", Content: "fresh", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+
+	recent := db.GetRecentMessages()
+	if len(recent) != 1 || recent[0].Content != "fresh" {
+		t.Errorf("Expected only the fresh message, got %+v", recent)
+	}
+	if count, _ := db.CountMessages(); count != 1 {
+		t.Errorf("Expected 1 live message, got %d", count)
+	}
+	if len(db.(*DocumentDB).messages) != 1 {
+		t.Error("Expired message should be evicted from memory on insert")
+	}
+}
@@ -0,0 +1,1571 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Document store collections. Each collection is persisted as a JSON document
+// named <collection>.json inside the configured directory.
+const (
+	docCollectionMessages  = "messages"
+	docCollectionUserState = "user_message_state"
+	docCollectionBans      = "ban_history"
+	docCollectionAudit     = "audit"
+	docCollectionReminders = "reminders"
+	docCollectionSnippets  = "snippets"
+	docCollectionEmoji     = "custom_emoji"
+	docCollectionFilters   = "filter_rules"
+	docCollectionMetrics   = "metrics_rollups"
+	docCollectionWelcome   = "welcome"
+	docCollectionNotices   = "notices"
+	docCollectionGroups    = "mention_groups"
+	docCollectionCron      = "cron_jobs"
+	docCollectionEmail     = "email_notifications"
+	docCollectionScheduled = "scheduled_messages"
+	docMetaFile            = "meta.json"
+
+	// Keep the same history cap as the SQL backends
+	documentMessageCap = messageCap
+)
+
+type docMessage struct {
+	ID            int64     `json:"id"`
+	Seq           int64     `json:"seq,omitempty"`
+	Sender        string    `json:"sender"`
+	Content       string    `json:"content"`
+	CreatedAt     time.Time `json:"created_at"`
+	Encrypted     bool      `json:"is_encrypted"`
+	MessageType   string    `json:"message_type,omitempty"`
+	EncryptedData []byte    `json:"encrypted_data,omitempty"`
+	Nonce         []byte    `json:"nonce,omitempty"`
+	Recipient     string    `json:"recipient,omitempty"`
+}
+
+type docUserState struct {
+	LastMessageID int64     `json:"last_message_id"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+type docBan struct {
+	ID         int64      `json:"id"`
+	Username   string     `json:"username"`
+	BannedAt   time.Time  `json:"banned_at"`
+	UnbannedAt *time.Time `json:"unbanned_at,omitempty"`
+	BannedBy   string     `json:"banned_by"`
+}
+
+type docAuditEvent struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Target string    `json:"target,omitempty"`
+	Actor  string    `json:"actor,omitempty"`
+}
+
+type docReminder struct {
+	ID        int64     `json:"id"`
+	Creator   string    `json:"creator"`
+	Target    string    `json:"target"`
+	Text      string    `json:"text"`
+	DueAt     time.Time `json:"due_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type docScheduledMessage struct {
+	ID          int64     `json:"id"`
+	Author      string    `json:"author"`
+	Content     string    `json:"content"`
+	DeliverAt   time.Time `json:"deliver_at"`
+	AuthorAdmin bool      `json:"author_admin,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type docSnippet struct {
+	Author    string    `json:"author"`
+	Language  string    `json:"language,omitempty"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type docCustomEmoji struct {
+	Glyph     string    `json:"glyph,omitempty"`
+	Image     []byte    `json:"image,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type docFilterRule struct {
+	ID        int64     `json:"id"`
+	Pattern   string    `json:"pattern"`
+	IsRegex   bool      `json:"is_regex,omitempty"`
+	Action    string    `json:"action"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type docCronJob struct {
+	ID        int64     `json:"id"`
+	Schedule  string    `json:"schedule"`
+	Action    string    `json:"action"`
+	Message   string    `json:"message"`
+	Enabled   bool      `json:"enabled"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+}
+
+type docEmailOptIn struct {
+	Address   string    `json:"address"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type docMetricsRollup struct {
+	Resolution  string    `json:"resolution"`
+	Bucket      time.Time `json:"bucket"`
+	PeakUsers   int       `json:"peak_users"`
+	Connections int       `json:"connections"`
+	Messages    int       `json:"messages"`
+	PeakMemory  uint64    `json:"peak_memory"`
+}
+
+type docWelcome struct {
+	Enabled   bool                 `json:"enabled"`
+	Message   string               `json:"message"`
+	Rules     string               `json:"rules"`
+	UpdatedBy string               `json:"updated_by,omitempty"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Welcomed  map[string]time.Time `json:"welcomed"`
+}
+
+// docNotices holds channel topics and shared notes, keyed by channel, the
+// MOTD and the maintenance window
+type docNotices struct {
+	Topics      map[string]shared.Topic        `json:"topics"`
+	MOTD        shared.MOTD                    `json:"motd"`
+	Notes       map[string]shared.ChannelNotes `json:"notes"`
+	Maintenance shared.Maintenance             `json:"maintenance"`
+}
+
+type docMentionGroup struct {
+	AdminsOnly bool      `json:"admins_only,omitempty"`
+	Members    []string  `json:"members"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type docMeta struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+// documentMigrations are applied in order; the index+1 is the schema version
+var documentMigrations = []func(d *DocumentDB) error{
+	// v1: initial message, user state and ban history collections
+	func(d *DocumentDB) error {
+		if d.userState == nil {
+			d.userState = make(map[string]docUserState)
+		}
+		return nil
+	},
+	// v2: assign IDs to messages written before IDs were tracked
+	func(d *DocumentDB) error {
+		for i := range d.messages {
+			if d.messages[i].ID == 0 {
+				d.nextMessageID++
+				d.messages[i].ID = d.nextMessageID
+			}
+		}
+		return nil
+	},
+	// v3: audit collection, seeded from existing ban history
+	func(d *DocumentDB) error {
+		if len(d.audit) > 0 {
+			return nil
+		}
+		for _, ban := range d.bans {
+			d.audit = append(d.audit, docAuditEvent{Time: ban.BannedAt, Action: "ban", Target: ban.Username, Actor: ban.BannedBy})
+			if ban.UnbannedAt != nil {
+				d.audit = append(d.audit, docAuditEvent{Time: *ban.UnbannedAt, Action: "unban", Target: ban.Username})
+			}
+		}
+		return nil
+	},
+	// v4: reminders collection
+	func(d *DocumentDB) error {
+		if d.reminders == nil {
+			d.reminders = []docReminder{}
+		}
+		return d.save(docCollectionReminders, d.reminders)
+	},
+	// v5: snippets collection, keyed by snippet ID
+	func(d *DocumentDB) error {
+		if d.snippets == nil {
+			d.snippets = make(map[string]docSnippet)
+		}
+		return d.save(docCollectionSnippets, d.snippets)
+	},
+	// v6: custom emoji collection, keyed by shortcode
+	func(d *DocumentDB) error {
+		if d.customEmoji == nil {
+			d.customEmoji = make(map[string]docCustomEmoji)
+		}
+		return d.save(docCollectionEmoji, d.customEmoji)
+	},
+	// v7: content filter rules collection
+	func(d *DocumentDB) error {
+		if d.filterRules == nil {
+			d.filterRules = []docFilterRule{}
+		}
+		return d.save(docCollectionFilters, d.filterRules)
+	},
+	// v8: metrics history rollups
+	func(d *DocumentDB) error {
+		if d.metrics == nil {
+			d.metrics = []docMetricsRollup{}
+		}
+		return d.save(docCollectionMetrics, d.metrics)
+	},
+	// v9: welcome bot settings and greeted users
+	func(d *DocumentDB) error {
+		if d.welcome.Welcomed == nil {
+			d.welcome.Welcomed = make(map[string]time.Time)
+		}
+		return d.save(docCollectionWelcome, d.welcome)
+	},
+	// v10: channel topics and message of the day
+	func(d *DocumentDB) error {
+		if d.notices.Topics == nil {
+			d.notices.Topics = make(map[string]shared.Topic)
+		}
+		return d.save(docCollectionNotices, d.notices)
+	},
+	// v11: mention groups, keyed by name
+	func(d *DocumentDB) error {
+		if d.groups == nil {
+			d.groups = make(map[string]docMentionGroup)
+		}
+		return d.save(docCollectionGroups, d.groups)
+	},
+	// v12: shared notes, kept with the channel topics
+	func(d *DocumentDB) error {
+		if d.notices.Notes == nil {
+			d.notices.Notes = make(map[string]shared.ChannelNotes)
+		}
+		return d.save(docCollectionNotices, d.notices)
+	},
+	// v13: number messages written before sequence numbers, in insertion order
+	func(d *DocumentDB) error {
+		for i := range d.messages {
+			if d.messages[i].Seq == 0 {
+				d.messages[i].Seq = d.messages[i].ID
+			}
+		}
+		return d.save(docCollectionMessages, d.messages)
+	},
+	// v14: recurring messages (:cron)
+	func(d *DocumentDB) error {
+		if d.cronJobs == nil {
+			d.cronJobs = []docCronJob{}
+		}
+		return d.save(docCollectionCron, d.cronJobs)
+	},
+	// v15: offline mention email opt-ins, keyed by lowercase username
+	func(d *DocumentDB) error {
+		if d.emails == nil {
+			d.emails = make(map[string]docEmailOptIn)
+		}
+		return d.save(docCollectionEmail, d.emails)
+	},
+	// v16: messages queued with :schedule
+	func(d *DocumentDB) error {
+		if d.scheduled == nil {
+			d.scheduled = []docScheduledMessage{}
+		}
+		return d.save(docCollectionScheduled, d.scheduled)
+	},
+}
+
+// DocumentDB implements the Database interface on a simple document store.
+// Collections are held in memory and written through to JSON files, so it
+// needs no external service and is easy to inspect or test. Every change
+// rewrites its whole collection file, so a write costs time in proportion
+// to the collection: fine at the 1000 message cap and for small servers,
+// but a SQL backend suits a busy one better. The directory and files are
+// private to the server's user, as they hold email addresses, snippets
+// and, when enabled, encrypted message content.
+type DocumentDB struct {
+	mu  sync.RWMutex
+	dir string
+
+	schemaVersion int
+	messages      []docMessage
+	userState     map[string]docUserState
+	bans          []docBan
+	audit         []docAuditEvent
+	reminders     []docReminder
+	scheduled     []docScheduledMessage
+	snippets      map[string]docSnippet
+	customEmoji   map[string]docCustomEmoji
+	filterRules   []docFilterRule
+	cronJobs      []docCronJob
+	emails        map[string]docEmailOptIn
+	metrics       []docMetricsRollup // sorted by resolution, then bucket
+	welcome       docWelcome
+	notices       docNotices
+	groups        map[string]docMentionGroup
+	nextMessageID int64
+	nextBanID     int64
+	nextRemindID  int64
+	nextSchedID   int64
+	nextFilterID  int64
+	nextCronID    int64
+	open          bool
+
+	// In-memory mode: nothing is written to disk and messages may expire
+	memory     bool
+	messageTTL time.Duration
+}
+
+// NewDocumentDB creates a new document store database instance
+func NewDocumentDB() *DocumentDB {
+	return &DocumentDB{userState: make(map[string]docUserState), snippets: make(map[string]docSnippet), customEmoji: make(map[string]docCustomEmoji)}
+}
+
+// NewMemoryDB creates an ephemeral database that keeps everything in RAM.
+// All data is lost when the server stops.
+func NewMemoryDB() *DocumentDB {
+	return &DocumentDB{userState: make(map[string]docUserState), snippets: make(map[string]docSnippet), customEmoji: make(map[string]docCustomEmoji), memory: true}
+}
+
+// Open loads the collections from the configured directory
+func (d *DocumentDB) Open(config DatabaseConfig) error {
+	if d.memory {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.messageTTL = config.MessageTTL
+		d.open = true
+		return nil
+	}
+	if config.FilePath == "" {
+		return fmt.Errorf("document store requires a directory path")
+	}
+	if err := os.MkdirAll(config.FilePath, 0700); err != nil {
+		return fmt.Errorf("failed to create document store directory: %w", err)
+	}
+	// Stores created before files were private may still be world-readable
+	if err := os.Chmod(config.FilePath, 0700); err != nil {
+		return fmt.Errorf("failed to restrict document store directory: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.dir = config.FilePath
+	var meta docMeta
+	loaders := []struct {
+		name string
+		dst  interface{}
+	}{
+		{docMetaFile, &meta},
+		{docCollectionMessages + ".json", &d.messages},
+		{docCollectionUserState + ".json", &d.userState},
+		{docCollectionBans + ".json", &d.bans},
+		{docCollectionAudit + ".json", &d.audit},
+		{docCollectionReminders + ".json", &d.reminders},
+		{docCollectionSnippets + ".json", &d.snippets},
+		{docCollectionEmoji + ".json", &d.customEmoji},
+		{docCollectionFilters + ".json", &d.filterRules},
+		{docCollectionMetrics + ".json", &d.metrics},
+		{docCollectionWelcome + ".json", &d.welcome},
+		{docCollectionNotices + ".json", &d.notices},
+		{docCollectionGroups + ".json", &d.groups},
+		{docCollectionCron + ".json", &d.cronJobs},
+		{docCollectionEmail + ".json", &d.emails},
+		{docCollectionScheduled + ".json", &d.scheduled},
+	}
+	for _, l := range loaders {
+		if err := d.readJSON(l.name, l.dst); err != nil {
+			return err
+		}
+	}
+	if d.userState == nil {
+		d.userState = make(map[string]docUserState)
+	}
+	if d.snippets == nil {
+		d.snippets = make(map[string]docSnippet)
+	}
+	if d.customEmoji == nil {
+		d.customEmoji = make(map[string]docCustomEmoji)
+	}
+	d.schemaVersion = meta.SchemaVersion
+
+	for _, msg := range d.messages {
+		if msg.ID > d.nextMessageID {
+			d.nextMessageID = msg.ID
+		}
+	}
+	for _, ban := range d.bans {
+		if ban.ID > d.nextBanID {
+			d.nextBanID = ban.ID
+		}
+	}
+	for _, r := range d.reminders {
+		if r.ID > d.nextRemindID {
+			d.nextRemindID = r.ID
+		}
+	}
+	for _, sm := range d.scheduled {
+		if sm.ID > d.nextSchedID {
+			d.nextSchedID = sm.ID
+		}
+	}
+	for _, r := range d.filterRules {
+		if r.ID > d.nextFilterID {
+			d.nextFilterID = r.ID
+		}
+	}
+	for _, j := range d.cronJobs {
+		if j.ID > d.nextCronID {
+			d.nextCronID = j.ID
+		}
+	}
+
+	d.open = true
+	return nil
+}
+
+// Close marks the store closed; all writes are already persisted
+func (d *DocumentDB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.open = false
+	return nil
+}
+
+// Ping reports whether the store is open and its directory is reachable
+func (d *DocumentDB) Ping() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.open {
+		return fmt.Errorf("document store is closed")
+	}
+	if d.memory {
+		return nil
+	}
+	if _, err := os.Stat(d.dir); err != nil {
+		return fmt.Errorf("document store unavailable: %w", err)
+	}
+	return nil
+}
+
+// CreateSchema initializes the collections by running pending migrations
+func (d *DocumentDB) CreateSchema() error {
+	return d.Migrate()
+}
+
+// Migrate applies any document migrations newer than the stored schema version
+func (d *DocumentDB) Migrate() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.schemaVersion >= len(documentMigrations) {
+		return nil
+	}
+	for v := d.schemaVersion; v < len(documentMigrations); v++ {
+		if err := documentMigrations[v](d); err != nil {
+			return fmt.Errorf("document migration %d failed: %w", v+1, err)
+		}
+		log.Printf("Applied document store migration %d", v+1)
+	}
+	d.schemaVersion = len(documentMigrations)
+
+	return d.saveAll()
+}
+
+// InsertMessage inserts a new message into the messages collection
+func (d *DocumentDB) InsertMessage(msg shared.Message) error {
+	return d.insert(docMessage{
+		Seq:         msg.Seq,
+		Sender:      msg.Sender,
+		Content:     msg.Content,
+		CreatedAt:   msg.CreatedAt,
+		Encrypted:   msg.Encrypted,
+		MessageType: string(msg.Type),
+	})
+}
+
+// InsertEncryptedMessage stores an encrypted message in the messages collection
+func (d *DocumentDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
+	return d.insert(docMessage{
+		Sender:        msg.Sender,
+		Content:       msg.Content,
+		CreatedAt:     msg.CreatedAt,
+		Encrypted:     true,
+		EncryptedData: msg.Encrypted,
+		Nonce:         msg.Nonce,
+		Recipient:     msg.Recipient,
+	})
+}
+
+// InsertMessages inserts msgs with a single save, see messageBatcher
+func (d *DocumentDB) InsertMessages(msgs []shared.Message) error {
+	docs := make([]docMessage, len(msgs))
+	for i, msg := range msgs {
+		docs[i] = docMessage{
+			Seq:         msg.Seq,
+			Sender:      msg.Sender,
+			Content:     msg.Content,
+			CreatedAt:   msg.CreatedAt,
+			Encrypted:   msg.Encrypted,
+			MessageType: string(msg.Type),
+		}
+	}
+	return d.insert(docs...)
+}
+
+func (d *DocumentDB) insert(docs ...docMessage) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.open {
+		return fmt.Errorf("document store is closed")
+	}
+
+	for _, doc := range docs {
+		d.nextMessageID++
+		doc.ID = d.nextMessageID
+		if doc.Seq == 0 {
+			doc.Seq = doc.ID
+		}
+		d.messages = append(d.messages, doc)
+	}
+
+	// Drop expired messages before enforcing the cap
+	if d.messageTTL > 0 {
+		kept := d.messages[:0]
+		for _, m := range d.messages {
+			if !d.expired(m) {
+				kept = append(kept, m)
+			}
+		}
+		d.messages = kept
+	}
+
+	// Enforce message cap: keep only the most recent messages
+	if len(d.messages) > documentMessageCap {
+		d.messages = append([]docMessage(nil), d.messages[len(d.messages)-documentMessageCap:]...)
+	}
+
+	return d.save(docCollectionMessages, d.messages)
+}
+
+// GetRecentMessages retrieves the most recent messages
+func (d *DocumentDB) GetRecentMessages() []shared.Message {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	docs := d.sortedBySeqDesc(func(docMessage) bool { return true })
+	if len(docs) > 50 {
+		docs = docs[:50]
+	}
+	messages := toSharedMessages(docs)
+	sortMessages(messages)
+	return messages
+}
+
+// GetMessagesAfter retrieves messages with ID > lastMessageID
+func (d *DocumentDB) GetMessagesAfter(lastMessageID int64, limit int) []shared.Message {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	docs := d.sortedBySeqDesc(func(m docMessage) bool { return m.ID > lastMessageID })
+	if limit >= 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+	messages := toSharedMessages(docs)
+	sortMessages(messages)
+	return messages
+}
+
+// GetMessagesSince retrieves all messages created at or after since, oldest first
+func (d *DocumentDB) GetMessagesSince(since time.Time) []shared.Message {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var docs []docMessage
+	for _, m := range d.messages {
+		if !m.CreatedAt.Before(since) && !d.expired(m) {
+			docs = append(docs, m)
+		}
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		if docs[i].Seq != docs[j].Seq {
+			return docs[i].Seq < docs[j].Seq
+		}
+		return docs[i].ID < docs[j].ID
+	})
+	return toSharedMessages(docs)
+}
+
+// GetRecentMessagesForUser returns personalized message history for a specific user
+func (d *DocumentDB) GetRecentMessagesForUser(username string, defaultLimit int, banGapsHistory bool) ([]shared.Message, int64) {
+	return GetRecentMessagesForUser(d, username, defaultLimit, banGapsHistory)
+}
+
+// CountMessages returns the number of stored messages
+func (d *DocumentDB) CountMessages() (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.open {
+		return 0, fmt.Errorf("document store is closed")
+	}
+	count := 0
+	for _, m := range d.messages {
+		if !d.expired(m) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetMessageActivity returns who posted each message since a time
+func (d *DocumentDB) GetMessageActivity(since time.Time) ([]MessageActivity, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.open {
+		return nil, fmt.Errorf("document store is closed")
+	}
+	var activity []MessageActivity
+	for _, m := range d.messages {
+		if m.Sender != "System" && !m.CreatedAt.Before(since) && !d.expired(m) {
+			activity = append(activity, MessageActivity{Sender: m.Sender, At: m.CreatedAt})
+		}
+	}
+	return activity, nil
+}
+
+// GetMessageCountsBySender returns message counts per sender, excluding System
+func (d *DocumentDB) GetMessageCountsBySender() (map[string]int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.open {
+		return nil, fmt.Errorf("document store is closed")
+	}
+	counts := make(map[string]int)
+	for _, m := range d.messages {
+		if m.Sender != "System" && !d.expired(m) {
+			counts[m.Sender]++
+		}
+	}
+	return counts, nil
+}
+
+// QuerySenders returns a page of senders and how many match in total
+func (d *DocumentDB) QuerySenders(q SenderQuery) ([]SenderStats, int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.open {
+		return nil, 0, fmt.Errorf("document store is closed")
+	}
+	bySender := make(map[string]*SenderStats)
+	for _, m := range d.messages {
+		if m.Sender == "System" || d.expired(m) || !q.matches(m.Sender) {
+			continue
+		}
+		s := bySender[m.Sender]
+		if s == nil {
+			s = &SenderStats{Username: m.Sender}
+			bySender[m.Sender] = s
+		}
+		s.Messages++
+		if m.CreatedAt.After(s.LastSeen) {
+			s.LastSeen = m.CreatedAt
+		}
+	}
+	stats := make([]SenderStats, 0, len(bySender))
+	for _, s := range bySender {
+		stats = append(stats, *s)
+	}
+	page, total := q.page(stats)
+	return page, total, nil
+}
+
+// ClearMessages removes all messages from the messages collection
+func (d *DocumentDB) ClearMessages() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.messages = nil
+	d.audit = append(d.audit, docAuditEvent{Time: time.Now(), Action: "clear_messages"})
+	if err := d.save(docCollectionMessages, d.messages); err != nil {
+		return err
+	}
+	return d.save(docCollectionAudit, d.audit)
+}
+
+// GetUserLastMessageID looks up the user_message_state collection
+func (d *DocumentDB) GetUserLastMessageID(username string) (int64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	state, ok := d.userState[username]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return state.LastMessageID, nil
+}
+
+// SetUserLastMessageID upserts into the user_message_state collection
+func (d *DocumentDB) SetUserLastMessageID(username string, messageID int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.userState[username] = docUserState{LastMessageID: messageID, LastSeen: time.Now()}
+	return d.save(docCollectionUserState, d.userState)
+}
+
+// ClearUserMessageState deletes the user's document from user_message_state
+func (d *DocumentDB) ClearUserMessageState(username string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.userState, username)
+	return d.save(docCollectionUserState, d.userState)
+}
+
+// GetLatestMessageID returns the highest message ID
+func (d *DocumentDB) GetLatestMessageID() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var latestID int64
+	for _, m := range d.messages {
+		if m.ID > latestID && !d.expired(m) {
+			latestID = m.ID
+		}
+	}
+	return latestID
+}
+
+// RecordBanEvent records a ban event in the ban_history and audit collections
+func (d *DocumentDB) RecordBanEvent(username, bannedBy string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.nextBanID++
+	d.bans = append(d.bans, docBan{ID: d.nextBanID, Username: username, BannedAt: now, BannedBy: bannedBy})
+	d.audit = append(d.audit, docAuditEvent{Time: now, Action: "ban", Target: username, Actor: bannedBy})
+
+	if err := d.save(docCollectionBans, d.bans); err != nil {
+		log.Printf("Warning: failed to record ban event for user %s: %v", username, err)
+		return err
+	}
+	return d.save(docCollectionAudit, d.audit)
+}
+
+// RecordUnbanEvent closes open ban periods for the user
+func (d *DocumentDB) RecordUnbanEvent(username string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for i := range d.bans {
+		if d.bans[i].Username == username && d.bans[i].UnbannedAt == nil {
+			unbannedAt := now
+			d.bans[i].UnbannedAt = &unbannedAt
+		}
+	}
+	d.audit = append(d.audit, docAuditEvent{Time: now, Action: "unban", Target: username})
+
+	if err := d.save(docCollectionBans, d.bans); err != nil {
+		log.Printf("Warning: failed to record unban event for user %s: %v", username, err)
+		return err
+	}
+	return d.save(docCollectionAudit, d.audit)
+}
+
+// GetUserBanPeriods retrieves all ban periods for a user
+func (d *DocumentDB) GetUserBanPeriods(username string) ([]BanPeriod, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var periods []BanPeriod
+	for _, ban := range d.bans {
+		if ban.Username == username {
+			periods = append(periods, BanPeriod{BannedAt: ban.BannedAt, UnbannedAt: ban.UnbannedAt})
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].BannedAt.Before(periods[j].BannedAt)
+	})
+	return periods, nil
+}
+
+// GetBanHistory returns every ban, oldest first
+func (d *DocumentDB) GetBanHistory() ([]BanRecord, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	bans := make([]BanRecord, 0, len(d.bans))
+	for _, ban := range d.bans {
+		bans = append(bans, BanRecord{Username: ban.Username, BannedBy: ban.BannedBy, BannedAt: ban.BannedAt, UnbannedAt: ban.UnbannedAt})
+	}
+	sort.SliceStable(bans, func(i, j int) bool { return bans[i].BannedAt.Before(bans[j].BannedAt) })
+	return bans, nil
+}
+
+// ImportBanRecord stores a ban from an archive with its original times,
+// and its ban and unban in the audit collection as RecordBanEvent would
+func (d *DocumentDB) ImportBanRecord(b BanRecord) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nextBanID++
+	d.bans = append(d.bans, docBan{ID: d.nextBanID, Username: b.Username, BannedAt: b.BannedAt, UnbannedAt: b.UnbannedAt, BannedBy: b.BannedBy})
+	d.audit = append(d.audit, docAuditEvent{Time: b.BannedAt, Action: "ban", Target: b.Username, Actor: b.BannedBy})
+	if b.UnbannedAt != nil {
+		d.audit = append(d.audit, docAuditEvent{Time: *b.UnbannedAt, Action: "unban", Target: b.Username})
+	}
+	if err := d.save(docCollectionBans, d.bans); err != nil {
+		return err
+	}
+	return d.save(docCollectionAudit, d.audit)
+}
+
+// InsertReminder stores a reminder and returns its ID
+func (d *DocumentDB) InsertReminder(r Reminder) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nextRemindID++
+	d.reminders = append(d.reminders, docReminder{
+		ID:        d.nextRemindID,
+		Creator:   r.Creator,
+		Target:    r.Target,
+		Text:      r.Text,
+		DueAt:     r.DueAt,
+		CreatedAt: r.CreatedAt,
+	})
+	return d.nextRemindID, d.save(docCollectionReminders, d.reminders)
+}
+
+// GetPendingReminders returns every stored reminder, soonest first
+func (d *DocumentDB) GetPendingReminders() ([]Reminder, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	reminders := make([]Reminder, 0, len(d.reminders))
+	for _, r := range d.reminders {
+		reminders = append(reminders, Reminder{ID: r.ID, Creator: r.Creator, Target: r.Target, Text: r.Text, DueAt: r.DueAt, CreatedAt: r.CreatedAt})
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].DueAt.Before(reminders[j].DueAt)
+	})
+	return reminders, nil
+}
+
+// DeleteReminder removes a delivered or cancelled reminder
+func (d *DocumentDB) DeleteReminder(id int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := d.reminders[:0]
+	for _, r := range d.reminders {
+		if r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	d.reminders = kept
+	return d.save(docCollectionReminders, d.reminders)
+}
+
+// InsertScheduledMessage stores a scheduled message and returns its ID
+func (d *DocumentDB) InsertScheduledMessage(sm ScheduledMessage) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nextSchedID++
+	d.scheduled = append(d.scheduled, docScheduledMessage{
+		ID:          d.nextSchedID,
+		Author:      sm.Author,
+		Content:     sm.Content,
+		DeliverAt:   sm.DeliverAt,
+		AuthorAdmin: sm.AuthorAdmin,
+		CreatedAt:   sm.CreatedAt,
+	})
+	return d.nextSchedID, d.save(docCollectionScheduled, d.scheduled)
+}
+
+// GetScheduledMessages returns every pending scheduled message, soonest first
+func (d *DocumentDB) GetScheduledMessages() ([]ScheduledMessage, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	scheduled := make([]ScheduledMessage, 0, len(d.scheduled))
+	for _, sm := range d.scheduled {
+		scheduled = append(scheduled, ScheduledMessage{ID: sm.ID, Author: sm.Author, Content: sm.Content, DeliverAt: sm.DeliverAt, AuthorAdmin: sm.AuthorAdmin, CreatedAt: sm.CreatedAt})
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return scheduled[i].DeliverAt.Before(scheduled[j].DeliverAt)
+	})
+	return scheduled, nil
+}
+
+// DeleteScheduledMessage removes a delivered or cancelled scheduled message
+func (d *DocumentDB) DeleteScheduledMessage(id int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := d.scheduled[:0]
+	for _, sm := range d.scheduled {
+		if sm.ID != id {
+			kept = append(kept, sm)
+		}
+	}
+	d.scheduled = kept
+	return d.save(docCollectionScheduled, d.scheduled)
+}
+
+// InsertSnippet stores a shared snippet
+func (d *DocumentDB) InsertSnippet(s Snippet) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.snippets[s.ID]; exists {
+		return fmt.Errorf("document: snippet %s already exists", s.ID)
+	}
+	d.snippets[s.ID] = docSnippet{Author: s.Author, Language: s.Language, Content: s.Content, CreatedAt: s.CreatedAt}
+	return d.save(docCollectionSnippets, d.snippets)
+}
+
+// GetSnippet loads a shared snippet by ID
+func (d *DocumentDB) GetSnippet(id string) (Snippet, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	s, ok := d.snippets[id]
+	if !ok {
+		return Snippet{}, sql.ErrNoRows
+	}
+	return Snippet{ID: id, Author: s.Author, Language: s.Language, Content: s.Content, CreatedAt: s.CreatedAt}, nil
+}
+
+// SaveCustomEmoji registers or replaces a custom emoji shortcode
+func (d *DocumentDB) SaveCustomEmoji(e CustomEmoji) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.customEmoji[e.Shortcode] = docCustomEmoji{Glyph: e.Glyph, Image: e.Image, CreatedBy: e.CreatedBy, CreatedAt: e.CreatedAt}
+	return d.save(docCollectionEmoji, d.customEmoji)
+}
+
+// DeleteCustomEmoji removes a custom emoji shortcode
+func (d *DocumentDB) DeleteCustomEmoji(shortcode string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.customEmoji[shortcode]; !ok {
+		return nil
+	}
+	delete(d.customEmoji, shortcode)
+	return d.save(docCollectionEmoji, d.customEmoji)
+}
+
+// GetCustomEmoji lists the registered custom emoji
+func (d *DocumentDB) GetCustomEmoji() ([]CustomEmoji, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	emoji := make([]CustomEmoji, 0, len(d.customEmoji))
+	for code, e := range d.customEmoji {
+		emoji = append(emoji, CustomEmoji{Shortcode: code, Glyph: e.Glyph, Image: e.Image, CreatedBy: e.CreatedBy, CreatedAt: e.CreatedAt})
+	}
+	sort.Slice(emoji, func(i, j int) bool { return emoji[i].Shortcode < emoji[j].Shortcode })
+	return emoji, nil
+}
+
+// InsertFilterRule stores a content filter rule and returns its ID
+func (d *DocumentDB) InsertFilterRule(r FilterRule) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nextFilterID++
+	d.filterRules = append(d.filterRules, docFilterRule{
+		ID:        d.nextFilterID,
+		Pattern:   r.Pattern,
+		IsRegex:   r.IsRegex,
+		Action:    r.Action,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+	})
+	return d.nextFilterID, d.save(docCollectionFilters, d.filterRules)
+}
+
+// DeleteFilterRule removes a content filter rule
+func (d *DocumentDB) DeleteFilterRule(id int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := d.filterRules[:0]
+	for _, r := range d.filterRules {
+		if r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	d.filterRules = kept
+	return d.save(docCollectionFilters, d.filterRules)
+}
+
+// GetFilterRules lists the content filter rules, oldest first
+func (d *DocumentDB) GetFilterRules() ([]FilterRule, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rules := make([]FilterRule, 0, len(d.filterRules))
+	for _, r := range d.filterRules {
+		rules = append(rules, FilterRule{ID: r.ID, Pattern: r.Pattern, IsRegex: r.IsRegex, Action: r.Action, CreatedBy: r.CreatedBy, CreatedAt: r.CreatedAt})
+	}
+	return rules, nil
+}
+
+// InsertCronJob stores a recurring message and returns its ID
+func (d *DocumentDB) InsertCronJob(j CronJob) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nextCronID++
+	d.cronJobs = append(d.cronJobs, docCronJob{
+		ID:        d.nextCronID,
+		Schedule:  j.Schedule,
+		Action:    j.Action,
+		Message:   j.Message,
+		Enabled:   j.Enabled,
+		CreatedBy: j.CreatedBy,
+		CreatedAt: j.CreatedAt,
+		LastRun:   j.LastRun,
+	})
+	return d.nextCronID, d.save(docCollectionCron, d.cronJobs)
+}
+
+// UpdateCronJob saves whether a recurring message is enabled and when it last ran
+func (d *DocumentDB) UpdateCronJob(j CronJob) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i := range d.cronJobs {
+		if d.cronJobs[i].ID == j.ID {
+			d.cronJobs[i].Enabled = j.Enabled
+			d.cronJobs[i].LastRun = j.LastRun
+		}
+	}
+	return d.save(docCollectionCron, d.cronJobs)
+}
+
+// DeleteCronJob removes a recurring message
+func (d *DocumentDB) DeleteCronJob(id int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := d.cronJobs[:0]
+	for _, j := range d.cronJobs {
+		if j.ID != id {
+			kept = append(kept, j)
+		}
+	}
+	d.cronJobs = kept
+	return d.save(docCollectionCron, d.cronJobs)
+}
+
+// GetCronJobs lists the recurring messages, oldest first
+func (d *DocumentDB) GetCronJobs() ([]CronJob, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	jobs := make([]CronJob, 0, len(d.cronJobs))
+	for _, j := range d.cronJobs {
+		jobs = append(jobs, CronJob{ID: j.ID, Schedule: j.Schedule, Action: j.Action, Message: j.Message, Enabled: j.Enabled, CreatedBy: j.CreatedBy, CreatedAt: j.CreatedAt, LastRun: j.LastRun})
+	}
+	return jobs, nil
+}
+
+// GetEmailAddress returns the address username opted in to mention emails
+// with, or "" when they haven't
+func (d *DocumentDB) GetEmailAddress(username string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.emails[strings.ToLower(username)].Address, nil
+}
+
+// SetEmailAddress opts username in to mention emails at address, or out
+// with an empty address
+func (d *DocumentDB) SetEmailAddress(username, address string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.emails == nil {
+		d.emails = make(map[string]docEmailOptIn)
+	}
+	if address == "" {
+		delete(d.emails, strings.ToLower(username))
+	} else {
+		d.emails[strings.ToLower(username)] = docEmailOptIn{Address: address, UpdatedAt: time.Now()}
+	}
+	return d.save(docCollectionEmail, d.emails)
+}
+
+// GetEmailAddresses returns every mention email opt-in, by username
+func (d *DocumentDB) GetEmailAddresses() (map[string]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	addresses := make(map[string]string, len(d.emails))
+	for username, optIn := range d.emails {
+		addresses[username] = optIn.Address
+	}
+	return addresses, nil
+}
+
+// SaveMetricsRollup stores or replaces a metrics history bucket
+func (d *DocumentDB) SaveMetricsRollup(r MetricsRollup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	row := docMetricsRollup{
+		Resolution:  r.Resolution,
+		Bucket:      r.Bucket,
+		PeakUsers:   r.PeakUsers,
+		Connections: r.Connections,
+		Messages:    r.Messages,
+		PeakMemory:  r.PeakMemory,
+	}
+	i := sort.Search(len(d.metrics), func(i int) bool {
+		m := d.metrics[i]
+		return m.Resolution > r.Resolution || m.Resolution == r.Resolution && !m.Bucket.Before(r.Bucket)
+	})
+	if i < len(d.metrics) && d.metrics[i].Resolution == r.Resolution && d.metrics[i].Bucket.Equal(r.Bucket) {
+		d.metrics[i] = row
+	} else {
+		d.metrics = slices.Insert(d.metrics, i, row)
+	}
+	return d.save(docCollectionMetrics, d.metrics)
+}
+
+// GetMetricsRollups lists metrics history buckets since a time, oldest first
+func (d *DocumentDB) GetMetricsRollups(resolution string, since time.Time) ([]MetricsRollup, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var rollups []MetricsRollup
+	for _, m := range d.metrics {
+		if m.Resolution == resolution && !m.Bucket.Before(since) {
+			rollups = append(rollups, MetricsRollup{
+				Resolution:  m.Resolution,
+				Bucket:      m.Bucket,
+				PeakUsers:   m.PeakUsers,
+				Connections: m.Connections,
+				Messages:    m.Messages,
+				PeakMemory:  m.PeakMemory,
+			})
+		}
+	}
+	return rollups, nil
+}
+
+// PruneMetricsRollups deletes metrics history buckets older than before
+func (d *DocumentDB) PruneMetricsRollups(resolution string, before time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := d.metrics[:0]
+	for _, m := range d.metrics {
+		if m.Resolution != resolution || !m.Bucket.Before(before) {
+			kept = append(kept, m)
+		}
+	}
+	d.metrics = kept
+	return d.save(docCollectionMetrics, d.metrics)
+}
+
+// GetWelcomeConfig returns the welcome bot's settings
+func (d *DocumentDB) GetWelcomeConfig() (WelcomeConfig, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	w := d.welcome
+	return WelcomeConfig{Enabled: w.Enabled, Message: w.Message, Rules: w.Rules, UpdatedBy: w.UpdatedBy, UpdatedAt: w.UpdatedAt}, nil
+}
+
+// SaveWelcomeConfig stores the welcome bot's settings
+func (d *DocumentDB) SaveWelcomeConfig(c WelcomeConfig) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.welcome.Enabled = c.Enabled
+	d.welcome.Message = c.Message
+	d.welcome.Rules = c.Rules
+	d.welcome.UpdatedBy = c.UpdatedBy
+	d.welcome.UpdatedAt = c.UpdatedAt
+	return d.save(docCollectionWelcome, d.welcome)
+}
+
+// MarkWelcomed records that username was greeted, reporting whether this is
+// the first time
+func (d *DocumentDB) MarkWelcomed(username string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.welcome.Welcomed[username]; ok {
+		return false, nil
+	}
+	if d.welcome.Welcomed == nil {
+		d.welcome.Welcomed = make(map[string]time.Time)
+	}
+	d.welcome.Welcomed[username] = time.Now()
+	return true, d.save(docCollectionWelcome, d.welcome)
+}
+
+// GetChannelTopic returns a channel's topic
+func (d *DocumentDB) GetChannelTopic(channel string) (shared.Topic, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if t, ok := d.notices.Topics[channel]; ok {
+		return t, nil
+	}
+	return shared.Topic{Channel: channel}, nil
+}
+
+// SetChannelTopic stores a channel's topic
+func (d *DocumentDB) SetChannelTopic(t shared.Topic) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.notices.Topics == nil {
+		d.notices.Topics = make(map[string]shared.Topic)
+	}
+	d.notices.Topics[t.Channel] = t
+	return d.save(docCollectionNotices, d.notices)
+}
+
+// GetMOTD returns the message of the day
+func (d *DocumentDB) GetMOTD() (shared.MOTD, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.notices.MOTD, nil
+}
+
+// SetMOTD stores the message of the day
+func (d *DocumentDB) SetMOTD(m shared.MOTD) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.notices.MOTD = m
+	return d.save(docCollectionNotices, d.notices)
+}
+
+// GetMaintenance returns the planned maintenance window
+func (d *DocumentDB) GetMaintenance() (shared.Maintenance, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.notices.Maintenance, nil
+}
+
+// SetMaintenance stores the planned maintenance window
+func (d *DocumentDB) SetMaintenance(m shared.Maintenance) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.notices.Maintenance = m
+	return d.save(docCollectionNotices, d.notices)
+}
+
+// GetChannelNotes returns a channel's shared notes
+func (d *DocumentDB) GetChannelNotes(channel string) (shared.ChannelNotes, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if n, ok := d.notices.Notes[channel]; ok {
+		return n, nil
+	}
+	return shared.ChannelNotes{Channel: channel}, nil
+}
+
+// SaveChannelNotes stores a channel's shared notes
+func (d *DocumentDB) SaveChannelNotes(n shared.ChannelNotes) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.notices.Notes == nil {
+		d.notices.Notes = make(map[string]shared.ChannelNotes)
+	}
+	n.Editor = ""
+	d.notices.Notes[n.Channel] = n
+	return d.save(docCollectionNotices, d.notices)
+}
+
+// GetMentionGroups lists the mention groups with their members
+func (d *DocumentDB) GetMentionGroups() ([]MentionGroup, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	groups := make([]MentionGroup, 0, len(d.groups))
+	for name, g := range d.groups {
+		groups = append(groups, MentionGroup{Name: name, AdminsOnly: g.AdminsOnly, Members: slices.Clone(g.Members), CreatedBy: g.CreatedBy, CreatedAt: g.CreatedAt})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+// SaveMentionGroup stores a mention group and its members
+func (d *DocumentDB) SaveMentionGroup(g MentionGroup) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.groups == nil {
+		d.groups = make(map[string]docMentionGroup)
+	}
+	row := docMentionGroup{AdminsOnly: g.AdminsOnly, Members: slices.Sorted(slices.Values(g.Members)), CreatedBy: g.CreatedBy, CreatedAt: g.CreatedAt}
+	if existing, ok := d.groups[g.Name]; ok {
+		row.CreatedBy, row.CreatedAt = existing.CreatedBy, existing.CreatedAt
+	}
+	d.groups[g.Name] = row
+	return d.save(docCollectionGroups, d.groups)
+}
+
+// DeleteMentionGroup removes a mention group
+func (d *DocumentDB) DeleteMentionGroup(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.groups, name)
+	return d.save(docCollectionGroups, d.groups)
+}
+
+// GetKnownUsers lists everyone who has posted or connected
+func (d *DocumentDB) GetKnownUsers() ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var usernames []string
+	add := func(username string) {
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	for _, m := range d.messages {
+		if m.Sender != "System" {
+			add(m.Sender)
+		}
+	}
+	for username := range d.userState {
+		add(username)
+	}
+	return usernames, nil
+}
+
+// GetDatabaseStats returns database statistics
+func (d *DocumentDB) GetDatabaseStats() (string, error) {
+	counts, err := d.GetMessageCountsBySender()
+	if err != nil {
+		return "", err
+	}
+
+	total, err := d.CountMessages()
+	if err != nil {
+		return "", err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := fmt.Sprintf("Database Statistics:\nTotal Messages: %d\nUnique Users: %d\nBan Events: %d\nAudit Events: %d",
+		total, len(counts), len(d.bans), len(d.audit))
+	if d.memory {
+		stats += "\nStorage: in-memory (ephemeral)"
+		if d.messageTTL > 0 {
+			stats += fmt.Sprintf("\nMessage TTL: %s", d.messageTTL)
+		}
+	}
+	return stats, nil
+}
+
+// BackupDatabase copies every collection into a timestamped sibling directory
+func (d *DocumentDB) BackupDatabase(dbPath string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.memory {
+		return "", fmt.Errorf("in-memory database cannot be backed up (use the export subcommand instead)")
+	}
+
+	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	backupDir := strings.TrimRight(d.dir, `/\`) + ".backup." + timestamp
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read document store: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if err := copyFile(filepath.Join(d.dir, entry.Name()), filepath.Join(backupDir, entry.Name())); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %v", entry.Name(), err)
+		}
+	}
+
+	return filepath.Base(backupDir), nil
+}
+
+// RewriteMessageContent rewrites stored message content, see messageRewriter
+func (d *DocumentDB) RewriteMessageContent(fn func(string) (string, bool)) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	changed := 0
+	for i := range d.messages {
+		if content, ok := fn(d.messages[i].Content); ok {
+			d.messages[i].Content = content
+			changed++
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, d.save(docCollectionMessages, d.messages)
+}
+
+// GetDB returns nil; the document store has no SQL connection
+func (d *DocumentDB) GetDB() *sql.DB {
+	return nil
+}
+
+// expired reports whether a message has outlived the configured TTL
+func (d *DocumentDB) expired(m docMessage) bool {
+	return d.messageTTL > 0 && time.Since(m.CreatedAt) > d.messageTTL
+}
+
+// sortedBySeqDesc returns matching messages, latest in the channel first
+// (caller holds the lock)
+func (d *DocumentDB) sortedBySeqDesc(match func(docMessage) bool) []docMessage {
+	var docs []docMessage
+	for _, m := range d.messages {
+		if match(m) && !d.expired(m) {
+			docs = append(docs, m)
+		}
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		if docs[i].Seq != docs[j].Seq {
+			return docs[i].Seq > docs[j].Seq
+		}
+		return docs[i].ID > docs[j].ID
+	})
+	return docs
+}
+
+func toSharedMessages(docs []docMessage) []shared.Message {
+	messages := make([]shared.Message, 0, len(docs))
+	for _, m := range docs {
+		messages = append(messages, shared.Message{
+			Sender:    m.Sender,
+			Content:   m.Content,
+			CreatedAt: m.CreatedAt,
+			Encrypted: m.Encrypted,
+			Type:      shared.MessageType(m.MessageType),
+			Seq:       m.Seq,
+		})
+	}
+	return messages
+}
+
+// readJSON loads a collection file; missing files leave dst untouched
+func (d *DocumentDB) readJSON(name string, dst interface{}) error {
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// save atomically writes a collection to disk (caller holds the lock)
+func (d *DocumentDB) save(collection string, v interface{}) error {
+	return d.writeJSON(collection+".json", v)
+}
+
+func (d *DocumentDB) saveAll() error {
+	if err := d.writeJSON(docMetaFile, docMeta{SchemaVersion: d.schemaVersion}); err != nil {
+		return err
+	}
+	collections := map[string]interface{}{
+		docCollectionMessages:  d.messages,
+		docCollectionUserState: d.userState,
+		docCollectionBans:      d.bans,
+		docCollectionAudit:     d.audit,
+	}
+	for name, v := range collections {
+		if err := d.save(name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DocumentDB) writeJSON(name string, v interface{}) error {
+	if d.memory {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	path := filepath.Join(d.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", name, err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
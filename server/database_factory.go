@@ -15,6 +15,10 @@ func NewDatabase(config DatabaseConfig) (Database, error) {
 		db = NewPostgresDB()
 	case "mysql":
 		db = NewMySQLDB()
+	case "document":
+		db = NewDocumentDB()
+	case "memory":
+		db = NewMemoryDB()
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
 	}
@@ -27,5 +31,24 @@ func NewDatabase(config DatabaseConfig) (Database, error) {
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	// Batching sits below encryption so the journal only holds sealed content
+	if config.WriteBatchSize > 1 && config.WriteJournal != "" && config.Type != "memory" {
+		batched, err := newWriteBehindDatabase(db, config.WriteBatchSize, config.WriteBatchInterval, config.WriteJournal)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		db = batched
+	}
+
+	if len(config.EncryptionKey) > 0 {
+		encrypted, err := newEncryptedDatabase(db, config.EncryptionKey)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return encrypted, nil
+	}
+
 	return db, nil
 }
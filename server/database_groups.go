@@ -0,0 +1,109 @@
+package server
+
+import "database/sql"
+
+// Mention storage shared by the SQL backends. Only the upsert and
+// placeholder syntax differ between dialects, so each backend passes its
+// own statements.
+
+// loadKnownUsersSQL lists every username that has sent a message or has
+// saved history state, System excluded
+func loadKnownUsersSQL(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT sender FROM messages WHERE sender != 'System' UNION SELECT username FROM user_message_state`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usernames []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		usernames = append(usernames, username)
+	}
+	return usernames, rows.Err()
+}
+
+// loadMentionGroupsSQL reads every mention group and its members, by name
+func loadMentionGroupsSQL(db *sql.DB) ([]MentionGroup, error) {
+	rows, err := db.Query(`SELECT name, admins_only, created_by, created_at FROM mention_groups ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []MentionGroup
+	index := make(map[string]int)
+	for rows.Next() {
+		var g MentionGroup
+		if err := rows.Scan(&g.Name, &g.AdminsOnly, &g.CreatedBy, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		index[g.Name] = len(groups)
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	members, err := db.Query(`SELECT group_name, username FROM mention_group_members ORDER BY group_name, username`)
+	if err != nil {
+		return nil, err
+	}
+	defer members.Close()
+	for members.Next() {
+		var name, username string
+		if err := members.Scan(&name, &username); err != nil {
+			return nil, err
+		}
+		if i, ok := index[name]; ok {
+			groups[i].Members = append(groups[i].Members, username)
+		}
+	}
+	return groups, members.Err()
+}
+
+// saveMentionGroupSQL upserts a group and rewrites its members in one
+// transaction. upsert takes name, admins_only, created_by and created_at;
+// clearMembers takes the name; addMember takes the name and a username.
+func saveMentionGroupSQL(db *sql.DB, g MentionGroup, upsert, clearMembers, addMember string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(upsert, g.Name, g.AdminsOnly, g.CreatedBy, g.CreatedAt); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(clearMembers, g.Name); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for _, username := range g.Members {
+		if _, err := tx.Exec(addMember, g.Name, username); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteMentionGroupSQL removes a group and its members. Both statements
+// take the name.
+func deleteMentionGroupSQL(db *sql.DB, clearMembers, deleteGroup, name string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(clearMembers, name); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(deleteGroup, name); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
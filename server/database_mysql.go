@@ -69,13 +69,16 @@ func (m *MySQLDB) CreateSchema() error {
 	CREATE TABLE IF NOT EXISTS messages (
 		id INT AUTO_INCREMENT PRIMARY KEY,
 		message_id INT DEFAULT 0,
+		seq BIGINT NOT NULL DEFAULT 0,
 		sender TEXT,
 		content TEXT,
 		created_at DATETIME,
 		is_encrypted BOOLEAN DEFAULT false,
+		message_type VARCHAR(32) DEFAULT '',
 		encrypted_data BLOB,
 		nonce BLOB,
-		recipient TEXT
+		recipient TEXT,
+		INDEX idx_messages_seq (seq)
 	);
 	
 	CREATE TABLE IF NOT EXISTS user_message_state (
@@ -93,6 +96,136 @@ func (m *MySQLDB) CreateSchema() error {
 		INDEX(username, banned_at)
 	);
 	
+	CREATE TABLE IF NOT EXISTS reminders (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		creator VARCHAR(255) NOT NULL,
+		target VARCHAR(255) NOT NULL,
+		text TEXT NOT NULL,
+		due_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		INDEX(due_at)
+	);
+	
+	CREATE TABLE IF NOT EXISTS snippets (
+		id VARCHAR(32) PRIMARY KEY,
+		author VARCHAR(255) NOT NULL,
+		language VARCHAR(64) NOT NULL DEFAULT '',
+		content LONGTEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS custom_emoji (
+		shortcode VARCHAR(64) PRIMARY KEY,
+		glyph VARCHAR(64) NOT NULL DEFAULT '',
+		image MEDIUMBLOB,
+		created_by VARCHAR(255) NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS filter_rules (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		pattern TEXT NOT NULL,
+		is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+		action VARCHAR(16) NOT NULL DEFAULT 'mask',
+		created_by VARCHAR(255) NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS metrics_rollups (
+		resolution VARCHAR(16) NOT NULL,
+		bucket BIGINT NOT NULL,
+		peak_users INT NOT NULL DEFAULT 0,
+		connections INT NOT NULL DEFAULT 0,
+		messages INT NOT NULL DEFAULT 0,
+		peak_memory BIGINT UNSIGNED NOT NULL DEFAULT 0,
+		PRIMARY KEY (resolution, bucket)
+	);
+
+	CREATE TABLE IF NOT EXISTS welcome_config (
+		id INT PRIMARY KEY,
+		enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		message TEXT NOT NULL,
+		rules TEXT NOT NULL,
+		updated_by VARCHAR(255) NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS welcomed_users (
+		username VARCHAR(255) PRIMARY KEY,
+		welcomed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS channel_topics (
+		channel VARCHAR(255) PRIMARY KEY,
+		topic TEXT NOT NULL,
+		set_by VARCHAR(255) NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS motd (
+		id INT PRIMARY KEY,
+		text TEXT NOT NULL,
+		set_by VARCHAR(255) NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS maintenance (
+		id INT PRIMARY KEY,
+		start_at BIGINT NOT NULL DEFAULT 0,
+		end_at BIGINT NOT NULL DEFAULT 0,
+		message TEXT NOT NULL,
+		set_by VARCHAR(255) NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS mention_groups (
+		name VARCHAR(64) PRIMARY KEY,
+		admins_only BOOLEAN NOT NULL DEFAULT FALSE,
+		created_by VARCHAR(255) NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS mention_group_members (
+		group_name VARCHAR(64) NOT NULL,
+		username VARCHAR(255) NOT NULL,
+		PRIMARY KEY (group_name, username)
+	);
+
+	CREATE TABLE IF NOT EXISTS channel_notes (
+		channel VARCHAR(255) PRIMARY KEY,
+		text MEDIUMTEXT NOT NULL,
+		version BIGINT NOT NULL DEFAULT 0,
+		updated_by VARCHAR(255) NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS cron_jobs (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		schedule VARCHAR(255) NOT NULL,
+		action VARCHAR(16) NOT NULL DEFAULT 'message',
+		message TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by VARCHAR(255) NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_run BIGINT NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS email_notifications (
+		username VARCHAR(255) PRIMARY KEY,
+		address VARCHAR(320) NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS scheduled_messages (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		author VARCHAR(255) NOT NULL,
+		content TEXT NOT NULL,
+		deliver_at DATETIME NOT NULL,
+		author_admin BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		INDEX(deliver_at)
+	);
+	
 	CREATE INDEX idx_messages_message_id ON messages(message_id);
 	CREATE INDEX idx_messages_created_at ON messages(created_at);
 	CREATE INDEX idx_user_message_state_username ON user_message_state(username);
@@ -123,6 +256,41 @@ func (m *MySQLDB) CreateSchema() error {
 		}
 	}
 
+	// Check if message_type column exists, if not add it
+	var typeColumnExists int
+	err = m.db.QueryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_name='messages' AND column_name='message_type' AND table_schema=DATABASE()`).Scan(&typeColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for message_type column: %v", err)
+	}
+
+	if typeColumnExists == 0 {
+		_, err = m.db.Exec(`ALTER TABLE messages ADD COLUMN message_type VARCHAR(32) DEFAULT ''`)
+		if err != nil {
+			log.Printf("Warning: failed to add message_type column: %v", err)
+		} else {
+			log.Printf("Added message_type column to messages table")
+		}
+	}
+
+	// Check if seq column exists, if not add it numbered in insertion order
+	var seqColumnExists int
+	err = m.db.QueryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_name='messages' AND column_name='seq' AND table_schema=DATABASE()`).Scan(&seqColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for seq column: %v", err)
+	}
+
+	if seqColumnExists == 0 {
+		_, err = m.db.Exec(`ALTER TABLE messages ADD COLUMN seq BIGINT NOT NULL DEFAULT 0, ADD INDEX idx_messages_seq (seq)`)
+		if err == nil {
+			_, err = m.db.Exec(`UPDATE messages SET seq = id`)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to add seq column: %v", err)
+		} else {
+			log.Printf("Added seq column to messages table")
+		}
+	}
+
 	// Migration: Update existing messages to have message_id = id
 	_, err = m.db.Exec(`UPDATE messages SET message_id = id WHERE message_id = 0 OR message_id IS NULL`)
 	if err != nil {
@@ -143,8 +311,8 @@ func (m *MySQLDB) Migrate() error {
 
 // InsertMessage inserts a new message into the database
 func (m *MySQLDB) InsertMessage(msg shared.Message) error {
-	result, err := m.db.Exec(`INSERT INTO messages (sender, content, created_at, is_encrypted) VALUES (?, ?, ?, ?)`,
-		msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted)
+	result, err := m.db.Exec(`INSERT INTO messages (seq, sender, content, created_at, is_encrypted, message_type) VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.Seq, msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted, string(msg.Type))
 	if err != nil {
 		return fmt.Errorf("mysql: failed to insert message: %w", err)
 	}
@@ -155,7 +323,7 @@ func (m *MySQLDB) InsertMessage(msg shared.Message) error {
 		return fmt.Errorf("mysql: failed to get last insert ID: %w", err)
 	}
 
-	_, err = m.db.Exec(`UPDATE messages SET message_id = ? WHERE id = ?`, id, id)
+	_, err = m.db.Exec(`UPDATE messages SET message_id = ?, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = ?`, id, id)
 	if err != nil {
 		return fmt.Errorf("mysql: failed to update message_id: %w", err)
 	}
@@ -169,6 +337,22 @@ func (m *MySQLDB) InsertMessage(msg shared.Message) error {
 	return nil
 }
 
+// InsertMessages inserts msgs in one transaction, see messageBatcher
+func (m *MySQLDB) InsertMessages(msgs []shared.Message) error {
+	err := insertSQLMessages(m.db, msgs, func(tx *sql.Tx, msg shared.Message) (int64, error) {
+		result, err := tx.Exec(`INSERT INTO messages (seq, sender, content, created_at, is_encrypted, message_type) VALUES (?, ?, ?, ?, ?, ?)`,
+			msg.Seq, msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted, string(msg.Type))
+		if err != nil {
+			return 0, err
+		}
+		return result.LastInsertId()
+	}, `UPDATE messages SET message_id = ?, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = ?`)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to insert messages: %w", err)
+	}
+	return nil
+}
+
 // InsertEncryptedMessage stores an encrypted message in the database
 func (m *MySQLDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
 	result, err := m.db.Exec(`INSERT INTO messages (sender, content, created_at, is_encrypted, encrypted_data, nonce, recipient) VALUES (?, ?, ?, ?, ?, ?, ?)`,
@@ -183,7 +367,7 @@ func (m *MySQLDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
 		return err
 	}
 
-	_, err = m.db.Exec(`UPDATE messages SET message_id = ? WHERE id = ?`, id, id)
+	_, err = m.db.Exec(`UPDATE messages SET message_id = ?, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = ?`, id, id)
 	if err != nil {
 		return err
 	}
@@ -199,7 +383,7 @@ func (m *MySQLDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
 
 // GetRecentMessages retrieves the most recent messages
 func (m *MySQLDB) GetRecentMessages() []shared.Message {
-	rows, err := m.db.Query(`SELECT sender, content, created_at, is_encrypted FROM messages ORDER BY created_at DESC LIMIT 50`)
+	rows, err := m.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages ORDER BY seq DESC, id DESC LIMIT 50`)
 	if err != nil {
 		log.Println("Query error:", err)
 		return nil
@@ -210,21 +394,23 @@ func (m *MySQLDB) GetRecentMessages() []shared.Message {
 	for rows.Next() {
 		var msg shared.Message
 		var isEncrypted bool
-		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted)
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
 		if err == nil {
 			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
 			messages = append(messages, msg)
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
 	return messages
 }
 
 // GetMessagesAfter retrieves messages with ID > lastMessageID
 func (m *MySQLDB) GetMessagesAfter(lastMessageID int64, limit int) []shared.Message {
-	rows, err := m.db.Query(`SELECT sender, content, created_at, is_encrypted FROM messages WHERE message_id > ? ORDER BY created_at DESC LIMIT ?`, lastMessageID, limit)
+	rows, err := m.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages WHERE message_id > ? ORDER BY seq DESC, id DESC LIMIT ?`, lastMessageID, limit)
 	if err != nil {
 		log.Println("Query error in GetMessagesAfter:", err)
 		return nil
@@ -235,15 +421,42 @@ func (m *MySQLDB) GetMessagesAfter(lastMessageID int64, limit int) []shared.Mess
 	for rows.Next() {
 		var msg shared.Message
 		var isEncrypted bool
-		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted)
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
+		if err == nil {
+			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
+			messages = append(messages, msg)
+		}
+	}
+
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
+	return messages
+}
+
+// GetMessagesSince retrieves all messages created at or after since, oldest first
+func (m *MySQLDB) GetMessagesSince(since time.Time) []shared.Message {
+	rows, err := m.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages WHERE created_at >= ? ORDER BY seq ASC, id ASC`, since)
+	if err != nil {
+		log.Println("Query error in GetMessagesSince:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var messages []shared.Message
+	for rows.Next() {
+		var msg shared.Message
+		var isEncrypted bool
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
 		if err == nil {
 			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
 			messages = append(messages, msg)
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
 	return messages
 }
 
@@ -257,7 +470,7 @@ func (m *MySQLDB) GetRecentMessagesForUser(username string, defaultLimit int, ba
 		log.Printf("Error getting last message ID for user %s: %v", username, err)
 		// Fall back to recent messages for new users or on error
 		messages := m.GetRecentMessages()
-		sortMessagesByTimestamp(messages) // Ensure consistent ordering
+		sortMessages(messages) // Ensure consistent ordering
 		return messages, 0
 	}
 
@@ -289,8 +502,8 @@ func (m *MySQLDB) GetRecentMessagesForUser(username string, defaultLimit int, ba
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
 
 	// Filter messages during ban periods if feature is enabled
 	if banGapsHistory {
@@ -298,15 +511,7 @@ func (m *MySQLDB) GetRecentMessagesForUser(username string, defaultLimit int, ba
 		if err != nil {
 			log.Printf("Warning: failed to get ban periods for user %s: %v", username, err)
 		} else if len(banPeriods) > 0 {
-			// Filter out messages sent during ban periods
-			filteredMessages := make([]shared.Message, 0, len(messages))
-			for _, msg := range messages {
-				if !isMessageInBanPeriod(msg.CreatedAt, banPeriods) {
-					filteredMessages = append(filteredMessages, msg)
-				}
-			}
-			messages = filteredMessages
-			log.Printf("Filtered %d messages for user %s due to ban history gaps", len(messages), username)
+			messages = hideBanPeriods(messages, banPeriods)
 		}
 	}
 
@@ -406,6 +611,500 @@ func (m *MySQLDB) GetUserBanPeriods(username string) ([]BanPeriod, error) {
 	return periods, nil
 }
 
+// GetBanHistory returns every ban, oldest first
+func (m *MySQLDB) GetBanHistory() ([]BanRecord, error) {
+	rows, err := m.db.Query(`SELECT username, banned_by, banned_at, unbanned_at FROM ban_history ORDER BY banned_at ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []BanRecord
+	for rows.Next() {
+		var b BanRecord
+		if err := rows.Scan(&b.Username, &b.BannedBy, &b.BannedAt, &b.UnbannedAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// ImportBanRecord stores a ban from an archive with its original times
+func (m *MySQLDB) ImportBanRecord(b BanRecord) error {
+	_, err := m.db.Exec(`INSERT INTO ban_history (username, banned_by, banned_at, unbanned_at) VALUES (?, ?, ?, ?)`,
+		b.Username, b.BannedBy, b.BannedAt, b.UnbannedAt)
+	return err
+}
+
+// CountMessages returns the number of stored messages
+func (m *MySQLDB) CountMessages() (int, error) {
+	var count int
+	err := m.db.QueryRow(`SELECT COUNT(*) FROM messages`).Scan(&count)
+	return count, err
+}
+
+// GetMessageCountsBySender returns message counts per sender, excluding System
+func (m *MySQLDB) GetMessageCountsBySender() (map[string]int, error) {
+	rows, err := m.db.Query(`SELECT sender, COUNT(*) FROM messages WHERE sender != 'System' GROUP BY sender`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var sender string
+		var count int
+		if err := rows.Scan(&sender, &count); err != nil {
+			continue
+		}
+		counts[sender] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetMessageActivity returns who posted each message since a time
+func (m *MySQLDB) GetMessageActivity(since time.Time) ([]MessageActivity, error) {
+	return messageActivitySQL(m.db, since, "?")
+}
+
+// QuerySenders returns a page of senders and how many match in total
+func (m *MySQLDB) QuerySenders(q SenderQuery) ([]SenderStats, int, error) {
+	return querySendersSQL(m.db, q, func(int) string { return "?" })
+}
+
+// InsertReminder stores a reminder and returns its ID
+func (m *MySQLDB) InsertReminder(rem Reminder) (int64, error) {
+	result, err := m.db.Exec(`INSERT INTO reminders (creator, target, text, due_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		rem.Creator, rem.Target, rem.Text, rem.DueAt, rem.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("mysql: failed to insert reminder: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetPendingReminders returns every stored reminder, soonest first
+func (m *MySQLDB) GetPendingReminders() ([]Reminder, error) {
+	rows, err := m.db.Query(`SELECT id, creator, target, text, due_at, created_at FROM reminders ORDER BY due_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		var rem Reminder
+		if err := rows.Scan(&rem.ID, &rem.Creator, &rem.Target, &rem.Text, &rem.DueAt, &rem.CreatedAt); err != nil {
+			log.Printf("Warning: failed to scan reminder: %v", err)
+			continue
+		}
+		reminders = append(reminders, rem)
+	}
+	return reminders, rows.Err()
+}
+
+// InsertSnippet stores a shared snippet
+func (m *MySQLDB) InsertSnippet(snip Snippet) error {
+	_, err := m.db.Exec(`INSERT INTO snippets (id, author, language, content, created_at) VALUES (?, ?, ?, ?, ?)`,
+		snip.ID, snip.Author, snip.Language, snip.Content, snip.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to insert snippet: %w", err)
+	}
+	return nil
+}
+
+// GetSnippet loads a shared snippet by ID
+func (m *MySQLDB) GetSnippet(id string) (Snippet, error) {
+	var snip Snippet
+	err := m.db.QueryRow(`SELECT id, author, language, content, created_at FROM snippets WHERE id = ?`, id).
+		Scan(&snip.ID, &snip.Author, &snip.Language, &snip.Content, &snip.CreatedAt)
+	return snip, err
+}
+
+// SaveCustomEmoji registers or replaces a custom emoji shortcode
+func (m *MySQLDB) SaveCustomEmoji(e CustomEmoji) error {
+	_, err := m.db.Exec(`INSERT INTO custom_emoji (shortcode, glyph, image, created_by, created_at) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE glyph = VALUES(glyph), image = VALUES(image), created_by = VALUES(created_by), created_at = VALUES(created_at)`,
+		e.Shortcode, e.Glyph, e.Image, e.CreatedBy, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to save custom emoji: %w", err)
+	}
+	return nil
+}
+
+// DeleteCustomEmoji removes a custom emoji shortcode
+func (m *MySQLDB) DeleteCustomEmoji(shortcode string) error {
+	_, err := m.db.Exec(`DELETE FROM custom_emoji WHERE shortcode = ?`, shortcode)
+	return err
+}
+
+// GetCustomEmoji lists the registered custom emoji
+func (m *MySQLDB) GetCustomEmoji() ([]CustomEmoji, error) {
+	rows, err := m.db.Query(`SELECT shortcode, glyph, image, created_by, created_at FROM custom_emoji ORDER BY shortcode`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emoji []CustomEmoji
+	for rows.Next() {
+		var e CustomEmoji
+		if err := rows.Scan(&e.Shortcode, &e.Glyph, &e.Image, &e.CreatedBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		emoji = append(emoji, e)
+	}
+	return emoji, rows.Err()
+}
+
+// InsertFilterRule stores a content filter rule and returns its ID
+func (m *MySQLDB) InsertFilterRule(rule FilterRule) (int64, error) {
+	result, err := m.db.Exec(`INSERT INTO filter_rules (pattern, is_regex, action, created_by, created_at) VALUES (?, ?, ?, ?, ?)`,
+		rule.Pattern, rule.IsRegex, rule.Action, rule.CreatedBy, rule.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("mysql: failed to insert filter rule: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// DeleteFilterRule removes a content filter rule
+func (m *MySQLDB) DeleteFilterRule(id int64) error {
+	_, err := m.db.Exec(`DELETE FROM filter_rules WHERE id = ?`, id)
+	return err
+}
+
+// GetFilterRules lists the content filter rules, oldest first
+func (m *MySQLDB) GetFilterRules() ([]FilterRule, error) {
+	rows, err := m.db.Query(`SELECT id, pattern, is_regex, action, created_by, created_at FROM filter_rules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []FilterRule
+	for rows.Next() {
+		var rule FilterRule
+		if err := rows.Scan(&rule.ID, &rule.Pattern, &rule.IsRegex, &rule.Action, &rule.CreatedBy, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// InsertCronJob stores a recurring message and returns its ID
+func (m *MySQLDB) InsertCronJob(j CronJob) (int64, error) {
+	result, err := m.db.Exec(`INSERT INTO cron_jobs (schedule, action, message, enabled, created_by, created_at, last_run) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		j.Schedule, j.Action, j.Message, j.Enabled, j.CreatedBy, j.CreatedAt, unixSeconds(j.LastRun))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateCronJob saves whether a recurring message is enabled and when it last ran
+func (m *MySQLDB) UpdateCronJob(j CronJob) error {
+	_, err := m.db.Exec(`UPDATE cron_jobs SET enabled = ?, last_run = ? WHERE id = ?`, j.Enabled, unixSeconds(j.LastRun), j.ID)
+	return err
+}
+
+// DeleteCronJob removes a recurring message
+func (m *MySQLDB) DeleteCronJob(id int64) error {
+	_, err := m.db.Exec(`DELETE FROM cron_jobs WHERE id = ?`, id)
+	return err
+}
+
+// GetCronJobs lists the recurring messages, oldest first
+func (m *MySQLDB) GetCronJobs() ([]CronJob, error) {
+	rows, err := m.db.Query(`SELECT id, schedule, action, message, enabled, created_by, created_at, last_run FROM cron_jobs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []CronJob
+	for rows.Next() {
+		var j CronJob
+		var lastRun int64
+		if err := rows.Scan(&j.ID, &j.Schedule, &j.Action, &j.Message, &j.Enabled, &j.CreatedBy, &j.CreatedAt, &lastRun); err != nil {
+			return nil, err
+		}
+		j.LastRun = fromUnixSeconds(lastRun)
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// GetEmailAddress returns the address username opted in to mention emails
+// with, or "" when they haven't
+func (m *MySQLDB) GetEmailAddress(username string) (string, error) {
+	var address string
+	err := m.db.QueryRow(`SELECT address FROM email_notifications WHERE username = ?`, strings.ToLower(username)).Scan(&address)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return address, err
+}
+
+// SetEmailAddress opts username in to mention emails at address, or out
+// with an empty address
+func (m *MySQLDB) SetEmailAddress(username, address string) error {
+	if address == "" {
+		_, err := m.db.Exec(`DELETE FROM email_notifications WHERE username = ?`, strings.ToLower(username))
+		return err
+	}
+	_, err := m.db.Exec(`INSERT INTO email_notifications (username, address, updated_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE address = VALUES(address), updated_at = VALUES(updated_at)`, strings.ToLower(username), address, time.Now())
+	return err
+}
+
+// GetEmailAddresses returns every mention email opt-in, by username
+func (m *MySQLDB) GetEmailAddresses() (map[string]string, error) {
+	rows, err := m.db.Query(`SELECT username, address FROM email_notifications`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make(map[string]string)
+	for rows.Next() {
+		var username, address string
+		if err := rows.Scan(&username, &address); err != nil {
+			return nil, err
+		}
+		addresses[username] = address
+	}
+	return addresses, rows.Err()
+}
+
+// SaveMetricsRollup stores or replaces a metrics history bucket
+func (m *MySQLDB) SaveMetricsRollup(rollup MetricsRollup) error {
+	_, err := m.db.Exec(`INSERT INTO metrics_rollups (resolution, bucket, peak_users, connections, messages, peak_memory) VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE peak_users = VALUES(peak_users), connections = VALUES(connections), messages = VALUES(messages), peak_memory = VALUES(peak_memory)`,
+		rollup.Resolution, rollup.Bucket.Unix(), rollup.PeakUsers, rollup.Connections, rollup.Messages, rollup.PeakMemory)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to save metrics rollup: %w", err)
+	}
+	return nil
+}
+
+// GetMetricsRollups lists metrics history buckets since a time, oldest first
+func (m *MySQLDB) GetMetricsRollups(resolution string, since time.Time) ([]MetricsRollup, error) {
+	rows, err := m.db.Query(`SELECT bucket, peak_users, connections, messages, peak_memory FROM metrics_rollups WHERE resolution = ? AND bucket >= ? ORDER BY bucket`,
+		resolution, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []MetricsRollup
+	for rows.Next() {
+		rollup := MetricsRollup{Resolution: resolution}
+		var bucket int64
+		if err := rows.Scan(&bucket, &rollup.PeakUsers, &rollup.Connections, &rollup.Messages, &rollup.PeakMemory); err != nil {
+			return nil, err
+		}
+		rollup.Bucket = time.Unix(bucket, 0)
+		rollups = append(rollups, rollup)
+	}
+	return rollups, rows.Err()
+}
+
+// PruneMetricsRollups deletes metrics history buckets older than before
+func (m *MySQLDB) PruneMetricsRollups(resolution string, before time.Time) error {
+	_, err := m.db.Exec(`DELETE FROM metrics_rollups WHERE resolution = ? AND bucket < ?`, resolution, before.Unix())
+	return err
+}
+
+// GetWelcomeConfig returns the welcome bot's settings
+func (m *MySQLDB) GetWelcomeConfig() (WelcomeConfig, error) {
+	var c WelcomeConfig
+	err := m.db.QueryRow(`SELECT enabled, message, rules, updated_by, updated_at FROM welcome_config WHERE id = 1`).
+		Scan(&c.Enabled, &c.Message, &c.Rules, &c.UpdatedBy, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return WelcomeConfig{}, nil
+	}
+	return c, err
+}
+
+// SaveWelcomeConfig stores the welcome bot's settings
+func (m *MySQLDB) SaveWelcomeConfig(c WelcomeConfig) error {
+	_, err := m.db.Exec(`INSERT INTO welcome_config (id, enabled, message, rules, updated_by, updated_at) VALUES (1, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), message = VALUES(message), rules = VALUES(rules), updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)`,
+		c.Enabled, c.Message, c.Rules, c.UpdatedBy, c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to save welcome config: %w", err)
+	}
+	return nil
+}
+
+// MarkWelcomed records that username was greeted, reporting whether this is
+// the first time
+func (m *MySQLDB) MarkWelcomed(username string) (bool, error) {
+	result, err := m.db.Exec(`INSERT IGNORE INTO welcomed_users (username, welcomed_at) VALUES (?, ?)`, username, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetChannelTopic returns a channel's topic
+func (m *MySQLDB) GetChannelTopic(channel string) (shared.Topic, error) {
+	t := shared.Topic{Channel: channel}
+	err := m.db.QueryRow(`SELECT topic, set_by, set_at FROM channel_topics WHERE channel = ?`, channel).Scan(&t.Text, &t.SetBy, &t.SetAt)
+	if err == sql.ErrNoRows {
+		return shared.Topic{Channel: channel}, nil
+	}
+	return t, err
+}
+
+// SetChannelTopic stores a channel's topic
+func (m *MySQLDB) SetChannelTopic(t shared.Topic) error {
+	_, err := m.db.Exec(`INSERT INTO channel_topics (channel, topic, set_by, set_at) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE topic = VALUES(topic), set_by = VALUES(set_by), set_at = VALUES(set_at)`,
+		t.Channel, t.Text, t.SetBy, t.SetAt)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to set channel topic: %w", err)
+	}
+	return nil
+}
+
+// GetMOTD returns the message of the day
+func (m *MySQLDB) GetMOTD() (shared.MOTD, error) {
+	var motd shared.MOTD
+	err := m.db.QueryRow(`SELECT text, set_by, set_at FROM motd WHERE id = 1`).Scan(&motd.Text, &motd.SetBy, &motd.SetAt)
+	if err == sql.ErrNoRows {
+		return shared.MOTD{}, nil
+	}
+	return motd, err
+}
+
+// SetMOTD stores the message of the day
+func (m *MySQLDB) SetMOTD(motd shared.MOTD) error {
+	_, err := m.db.Exec(`INSERT INTO motd (id, text, set_by, set_at) VALUES (1, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE text = VALUES(text), set_by = VALUES(set_by), set_at = VALUES(set_at)`,
+		motd.Text, motd.SetBy, motd.SetAt)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to set MOTD: %w", err)
+	}
+	return nil
+}
+
+// GetMaintenance returns the planned maintenance window
+func (m *MySQLDB) GetMaintenance() (shared.Maintenance, error) {
+	return getMaintenanceSQL(m.db)
+}
+
+// SetMaintenance stores the planned maintenance window
+func (m *MySQLDB) SetMaintenance(mw shared.Maintenance) error {
+	_, err := m.db.Exec(`INSERT INTO maintenance (id, start_at, end_at, message, set_by, set_at) VALUES (1, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE start_at = VALUES(start_at), end_at = VALUES(end_at), message = VALUES(message), set_by = VALUES(set_by), set_at = VALUES(set_at)`,
+		unixSeconds(mw.Start), unixSeconds(mw.End), mw.Message, mw.SetBy, mw.SetAt)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to set maintenance window: %w", err)
+	}
+	return nil
+}
+
+// GetMentionGroups lists the mention groups with their members
+func (m *MySQLDB) GetMentionGroups() ([]MentionGroup, error) {
+	return loadMentionGroupsSQL(m.db)
+}
+
+// SaveMentionGroup stores a mention group and its members
+func (m *MySQLDB) SaveMentionGroup(g MentionGroup) error {
+	err := saveMentionGroupSQL(m.db, g,
+		`INSERT INTO mention_groups (name, admins_only, created_by, created_at) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE admins_only = VALUES(admins_only)`,
+		`DELETE FROM mention_group_members WHERE group_name = ?`,
+		`INSERT INTO mention_group_members (group_name, username) VALUES (?, ?)`)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to save mention group: %w", err)
+	}
+	return nil
+}
+
+// GetChannelNotes returns a channel's shared notes
+func (m *MySQLDB) GetChannelNotes(channel string) (shared.ChannelNotes, error) {
+	n := shared.ChannelNotes{Channel: channel}
+	err := m.db.QueryRow(`SELECT text, version, updated_by, updated_at FROM channel_notes WHERE channel = ?`, channel).Scan(&n.Text, &n.Version, &n.UpdatedBy, &n.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return shared.ChannelNotes{Channel: channel}, nil
+	}
+	return n, err
+}
+
+// SaveChannelNotes stores a channel's shared notes
+func (m *MySQLDB) SaveChannelNotes(n shared.ChannelNotes) error {
+	_, err := m.db.Exec(`INSERT INTO channel_notes (channel, text, version, updated_by, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE text = VALUES(text), version = VALUES(version), updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)`,
+		n.Channel, n.Text, n.Version, n.UpdatedBy, n.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to save channel notes: %w", err)
+	}
+	return nil
+}
+
+// GetKnownUsers lists everyone who has posted or connected
+func (m *MySQLDB) GetKnownUsers() ([]string, error) {
+	return loadKnownUsersSQL(m.db)
+}
+
+// DeleteMentionGroup removes a mention group
+func (m *MySQLDB) DeleteMentionGroup(name string) error {
+	err := deleteMentionGroupSQL(m.db,
+		`DELETE FROM mention_group_members WHERE group_name = ?`,
+		`DELETE FROM mention_groups WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to delete mention group: %w", err)
+	}
+	return nil
+}
+
+// DeleteReminder removes a delivered or cancelled reminder
+func (m *MySQLDB) DeleteReminder(id int64) error {
+	_, err := m.db.Exec(`DELETE FROM reminders WHERE id = ?`, id)
+	return err
+}
+
+// InsertScheduledMessage stores a scheduled message and returns its ID
+func (m *MySQLDB) InsertScheduledMessage(sm ScheduledMessage) (int64, error) {
+	result, err := m.db.Exec(`INSERT INTO scheduled_messages (author, content, deliver_at, author_admin, created_at) VALUES (?, ?, ?, ?, ?)`,
+		sm.Author, sm.Content, sm.DeliverAt, sm.AuthorAdmin, sm.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("mysql: failed to insert scheduled message: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetScheduledMessages returns every pending scheduled message, soonest first
+func (m *MySQLDB) GetScheduledMessages() ([]ScheduledMessage, error) {
+	rows, err := m.db.Query(`SELECT id, author, content, deliver_at, author_admin, created_at FROM scheduled_messages ORDER BY deliver_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scheduled []ScheduledMessage
+	for rows.Next() {
+		var sm ScheduledMessage
+		if err := rows.Scan(&sm.ID, &sm.Author, &sm.Content, &sm.DeliverAt, &sm.AuthorAdmin, &sm.CreatedAt); err != nil {
+			log.Printf("Warning: failed to scan scheduled message: %v", err)
+			continue
+		}
+		scheduled = append(scheduled, sm)
+	}
+	return scheduled, rows.Err()
+}
+
+// DeleteScheduledMessage removes a delivered or cancelled scheduled message
+func (m *MySQLDB) DeleteScheduledMessage(id int64) error {
+	_, err := m.db.Exec(`DELETE FROM scheduled_messages WHERE id = ?`, id)
+	return err
+}
+
 // GetDatabaseStats returns database statistics
 func (m *MySQLDB) GetDatabaseStats() (string, error) {
 	var messageCount, userCount, banCount int
@@ -440,6 +1139,11 @@ func (m *MySQLDB) BackupDatabase(dbPath string) (string, error) {
 	return backupFilename, fmt.Errorf("MySQL backup requires mysqldump utility - use external backup tools or implement mysqldump integration")
 }
 
+// RewriteMessageContent rewrites stored message content, see messageRewriter
+func (m *MySQLDB) RewriteMessageContent(fn func(string) (string, bool)) (int, error) {
+	return rewriteSQLMessageContent(m.db, `UPDATE messages SET content = ? WHERE id = ?`, fn)
+}
+
 // GetDB returns the raw database connection for compatibility
 func (m *MySQLDB) GetDB() *sql.DB {
 	return m.db
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -69,10 +70,12 @@ func (p *PostgresDB) CreateSchema() error {
 	CREATE TABLE IF NOT EXISTS messages (
 		id SERIAL PRIMARY KEY,
 		message_id INTEGER DEFAULT 0,
+		seq BIGINT NOT NULL DEFAULT 0,
 		sender TEXT,
 		content TEXT,
 		created_at TIMESTAMP,
 		is_encrypted BOOLEAN DEFAULT false,
+		message_type TEXT DEFAULT '',
 		encrypted_data BYTEA,
 		nonce BYTEA,
 		recipient TEXT
@@ -92,11 +95,141 @@ func (p *PostgresDB) CreateSchema() error {
 		banned_by TEXT NOT NULL
 	);
 	
+	CREATE TABLE IF NOT EXISTS reminders (
+		id SERIAL PRIMARY KEY,
+		creator TEXT NOT NULL,
+		target TEXT NOT NULL,
+		text TEXT NOT NULL,
+		due_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS snippets (
+		id TEXT PRIMARY KEY,
+		author TEXT NOT NULL,
+		language TEXT NOT NULL DEFAULT '',
+		content TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS custom_emoji (
+		shortcode TEXT PRIMARY KEY,
+		glyph TEXT NOT NULL DEFAULT '',
+		image BYTEA,
+		created_by TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS filter_rules (
+		id SERIAL PRIMARY KEY,
+		pattern TEXT NOT NULL,
+		is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+		action TEXT NOT NULL DEFAULT 'mask',
+		created_by TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS metrics_rollups (
+		resolution TEXT NOT NULL,
+		bucket BIGINT NOT NULL,
+		peak_users INTEGER NOT NULL DEFAULT 0,
+		connections INTEGER NOT NULL DEFAULT 0,
+		messages INTEGER NOT NULL DEFAULT 0,
+		peak_memory BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (resolution, bucket)
+	);
+
+	CREATE TABLE IF NOT EXISTS welcome_config (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		message TEXT NOT NULL DEFAULT '',
+		rules TEXT NOT NULL DEFAULT '',
+		updated_by TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS welcomed_users (
+		username TEXT PRIMARY KEY,
+		welcomed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS channel_topics (
+		channel TEXT PRIMARY KEY,
+		topic TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS motd (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		text TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS maintenance (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		start_at BIGINT NOT NULL DEFAULT 0,
+		end_at BIGINT NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS mention_groups (
+		name TEXT PRIMARY KEY,
+		admins_only BOOLEAN NOT NULL DEFAULT FALSE,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS mention_group_members (
+		group_name TEXT NOT NULL,
+		username TEXT NOT NULL,
+		PRIMARY KEY (group_name, username)
+	);
+
+	CREATE TABLE IF NOT EXISTS channel_notes (
+		channel TEXT PRIMARY KEY,
+		text TEXT NOT NULL,
+		version BIGINT NOT NULL DEFAULT 0,
+		updated_by TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS cron_jobs (
+		id SERIAL PRIMARY KEY,
+		schedule TEXT NOT NULL,
+		action TEXT NOT NULL DEFAULT 'message',
+		message TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_run BIGINT NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS email_notifications (
+		username TEXT PRIMARY KEY,
+		address TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS scheduled_messages (
+		id SERIAL PRIMARY KEY,
+		author TEXT NOT NULL,
+		content TEXT NOT NULL,
+		deliver_at TIMESTAMP NOT NULL,
+		author_admin BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
 	CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages(message_id);
 	CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at);
 	CREATE INDEX IF NOT EXISTS idx_user_message_state_username ON user_message_state(username);
 	CREATE INDEX IF NOT EXISTS idx_ban_history_username ON ban_history(username);
 	CREATE INDEX IF NOT EXISTS idx_ban_history_banned_at ON ban_history(banned_at);
+	CREATE INDEX IF NOT EXISTS idx_reminders_due_at ON reminders(due_at);
+	CREATE INDEX IF NOT EXISTS idx_scheduled_messages_deliver_at ON scheduled_messages(deliver_at);
 	CREATE INDEX IF NOT EXISTS idx_ban_history_unbanned_at ON ban_history(unbanned_at);
 	`
 
@@ -122,6 +255,44 @@ func (p *PostgresDB) CreateSchema() error {
 		}
 	}
 
+	// Check if message_type column exists, if not add it
+	var typeColumnExists int
+	err = p.db.QueryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_name='messages' AND column_name='message_type'`).Scan(&typeColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for message_type column: %v", err)
+	}
+
+	if typeColumnExists == 0 {
+		_, err = p.db.Exec(`ALTER TABLE messages ADD COLUMN message_type TEXT DEFAULT ''`)
+		if err != nil {
+			log.Printf("Warning: failed to add message_type column: %v", err)
+		} else {
+			log.Printf("Added message_type column to messages table")
+		}
+	}
+
+	// Check if seq column exists, if not add it numbered in insertion order
+	var seqColumnExists int
+	err = p.db.QueryRow(`SELECT COUNT(*) FROM information_schema.columns WHERE table_name='messages' AND column_name='seq'`).Scan(&seqColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for seq column: %v", err)
+	}
+
+	if seqColumnExists == 0 {
+		_, err = p.db.Exec(`ALTER TABLE messages ADD COLUMN seq BIGINT NOT NULL DEFAULT 0`)
+		if err == nil {
+			_, err = p.db.Exec(`UPDATE messages SET seq = id`)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to add seq column: %v", err)
+		} else {
+			log.Printf("Added seq column to messages table")
+		}
+	}
+	if _, err = p.db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_seq ON messages(seq)`); err != nil {
+		log.Printf("Warning: failed to create seq index: %v", err)
+	}
+
 	// Migration: Update existing messages to have message_id = id
 	_, err = p.db.Exec(`UPDATE messages SET message_id = id WHERE message_id = 0 OR message_id IS NULL`)
 	if err != nil {
@@ -143,14 +314,14 @@ func (p *PostgresDB) Migrate() error {
 // InsertMessage inserts a new message into the database
 func (p *PostgresDB) InsertMessage(msg shared.Message) error {
 	var id int64
-	err := p.db.QueryRow(`INSERT INTO messages (sender, content, created_at, is_encrypted) VALUES ($1, $2, $3, $4) RETURNING id`,
-		msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted).Scan(&id)
+	err := p.db.QueryRow(`INSERT INTO messages (seq, sender, content, created_at, is_encrypted, message_type) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		msg.Seq, msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted, string(msg.Type)).Scan(&id)
 	if err != nil {
 		return fmt.Errorf("postgres: failed to insert message: %w", err)
 	}
 
 	// Update message_id to match id
-	_, err = p.db.Exec(`UPDATE messages SET message_id = $1 WHERE id = $1`, id)
+	_, err = p.db.Exec(`UPDATE messages SET message_id = $1, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("postgres: failed to update message_id: %w", err)
 	}
@@ -164,6 +335,20 @@ func (p *PostgresDB) InsertMessage(msg shared.Message) error {
 	return nil
 }
 
+// InsertMessages inserts msgs in one transaction, see messageBatcher
+func (p *PostgresDB) InsertMessages(msgs []shared.Message) error {
+	err := insertSQLMessages(p.db, msgs, func(tx *sql.Tx, msg shared.Message) (int64, error) {
+		var id int64
+		err := tx.QueryRow(`INSERT INTO messages (seq, sender, content, created_at, is_encrypted, message_type) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			msg.Seq, msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted, string(msg.Type)).Scan(&id)
+		return id, err
+	}, `UPDATE messages SET message_id = $1, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = $2`)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to insert messages: %w", err)
+	}
+	return nil
+}
+
 // InsertEncryptedMessage stores an encrypted message in the database
 func (p *PostgresDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
 	var id int64
@@ -174,7 +359,7 @@ func (p *PostgresDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error
 	}
 
 	// Update message_id to match id
-	_, err = p.db.Exec(`UPDATE messages SET message_id = $1 WHERE id = $1`, id)
+	_, err = p.db.Exec(`UPDATE messages SET message_id = $1, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("postgres: failed to update message_id for encrypted message: %w", err)
 	}
@@ -190,7 +375,7 @@ func (p *PostgresDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error
 
 // GetRecentMessages retrieves the most recent messages
 func (p *PostgresDB) GetRecentMessages() []shared.Message {
-	rows, err := p.db.Query(`SELECT sender, content, created_at, is_encrypted FROM messages ORDER BY created_at DESC LIMIT 50`)
+	rows, err := p.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages ORDER BY seq DESC, id DESC LIMIT 50`)
 	if err != nil {
 		log.Printf("postgres: query error in GetRecentMessages: %v", err)
 		return nil
@@ -201,21 +386,23 @@ func (p *PostgresDB) GetRecentMessages() []shared.Message {
 	for rows.Next() {
 		var msg shared.Message
 		var isEncrypted bool
-		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted)
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
 		if err == nil {
 			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
 			messages = append(messages, msg)
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
 	return messages
 }
 
 // GetMessagesAfter retrieves messages with ID > lastMessageID
 func (p *PostgresDB) GetMessagesAfter(lastMessageID int64, limit int) []shared.Message {
-	rows, err := p.db.Query(`SELECT sender, content, created_at, is_encrypted FROM messages WHERE message_id > $1 ORDER BY created_at DESC LIMIT $2`, lastMessageID, limit)
+	rows, err := p.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages WHERE message_id > $1 ORDER BY seq DESC, id DESC LIMIT $2`, lastMessageID, limit)
 	if err != nil {
 		log.Println("Query error in GetMessagesAfter:", err)
 		return nil
@@ -226,15 +413,42 @@ func (p *PostgresDB) GetMessagesAfter(lastMessageID int64, limit int) []shared.M
 	for rows.Next() {
 		var msg shared.Message
 		var isEncrypted bool
-		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted)
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
+		if err == nil {
+			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
+			messages = append(messages, msg)
+		}
+	}
+
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
+	return messages
+}
+
+// GetMessagesSince retrieves all messages created at or after since, oldest first
+func (p *PostgresDB) GetMessagesSince(since time.Time) []shared.Message {
+	rows, err := p.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages WHERE created_at >= $1 ORDER BY seq ASC, id ASC`, since)
+	if err != nil {
+		log.Println("Query error in GetMessagesSince:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var messages []shared.Message
+	for rows.Next() {
+		var msg shared.Message
+		var isEncrypted bool
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
 		if err == nil {
 			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
 			messages = append(messages, msg)
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
 	return messages
 }
 
@@ -248,7 +462,7 @@ func (p *PostgresDB) GetRecentMessagesForUser(username string, defaultLimit int,
 		log.Printf("Error getting last message ID for user %s: %v", username, err)
 		// Fall back to recent messages for new users or on error
 		messages := p.GetRecentMessages()
-		sortMessagesByTimestamp(messages) // Ensure consistent ordering
+		sortMessages(messages) // Ensure consistent ordering
 		return messages, 0
 	}
 
@@ -280,8 +494,8 @@ func (p *PostgresDB) GetRecentMessagesForUser(username string, defaultLimit int,
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
 
 	// Filter messages during ban periods if feature is enabled
 	if banGapsHistory {
@@ -289,15 +503,7 @@ func (p *PostgresDB) GetRecentMessagesForUser(username string, defaultLimit int,
 		if err != nil {
 			log.Printf("Warning: failed to get ban periods for user %s: %v", username, err)
 		} else if len(banPeriods) > 0 {
-			// Filter out messages sent during ban periods
-			filteredMessages := make([]shared.Message, 0, len(messages))
-			for _, msg := range messages {
-				if !isMessageInBanPeriod(msg.CreatedAt, banPeriods) {
-					filteredMessages = append(filteredMessages, msg)
-				}
-			}
-			messages = filteredMessages
-			log.Printf("Filtered %d messages for user %s due to ban history gaps", len(messages), username)
+			messages = hideBanPeriods(messages, banPeriods)
 		}
 	}
 
@@ -397,6 +603,504 @@ func (p *PostgresDB) GetUserBanPeriods(username string) ([]BanPeriod, error) {
 	return periods, nil
 }
 
+// GetBanHistory returns every ban, oldest first
+func (p *PostgresDB) GetBanHistory() ([]BanRecord, error) {
+	rows, err := p.db.Query(`SELECT username, banned_by, banned_at, unbanned_at FROM ban_history ORDER BY banned_at ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []BanRecord
+	for rows.Next() {
+		var b BanRecord
+		if err := rows.Scan(&b.Username, &b.BannedBy, &b.BannedAt, &b.UnbannedAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// ImportBanRecord stores a ban from an archive with its original times
+func (p *PostgresDB) ImportBanRecord(b BanRecord) error {
+	_, err := p.db.Exec(`INSERT INTO ban_history (username, banned_by, banned_at, unbanned_at) VALUES ($1, $2, $3, $4)`,
+		b.Username, b.BannedBy, b.BannedAt, b.UnbannedAt)
+	return err
+}
+
+// CountMessages returns the number of stored messages
+func (p *PostgresDB) CountMessages() (int, error) {
+	var count int
+	err := p.db.QueryRow(`SELECT COUNT(*) FROM messages`).Scan(&count)
+	return count, err
+}
+
+// GetMessageCountsBySender returns message counts per sender, excluding System
+func (p *PostgresDB) GetMessageCountsBySender() (map[string]int, error) {
+	rows, err := p.db.Query(`SELECT sender, COUNT(*) FROM messages WHERE sender != 'System' GROUP BY sender`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var sender string
+		var count int
+		if err := rows.Scan(&sender, &count); err != nil {
+			continue
+		}
+		counts[sender] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetMessageActivity returns who posted each message since a time
+func (p *PostgresDB) GetMessageActivity(since time.Time) ([]MessageActivity, error) {
+	return messageActivitySQL(p.db, since, "$1")
+}
+
+// QuerySenders returns a page of senders and how many match in total
+func (p *PostgresDB) QuerySenders(q SenderQuery) ([]SenderStats, int, error) {
+	return querySendersSQL(p.db, q, func(n int) string { return "$" + strconv.Itoa(n) })
+}
+
+// InsertReminder stores a reminder and returns its ID
+func (p *PostgresDB) InsertReminder(rem Reminder) (int64, error) {
+	var id int64
+	err := p.db.QueryRow(`INSERT INTO reminders (creator, target, text, due_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		rem.Creator, rem.Target, rem.Text, rem.DueAt, rem.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("postgres: failed to insert reminder: %w", err)
+	}
+	return id, nil
+}
+
+// GetPendingReminders returns every stored reminder, soonest first
+func (p *PostgresDB) GetPendingReminders() ([]Reminder, error) {
+	rows, err := p.db.Query(`SELECT id, creator, target, text, due_at, created_at FROM reminders ORDER BY due_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		var rem Reminder
+		if err := rows.Scan(&rem.ID, &rem.Creator, &rem.Target, &rem.Text, &rem.DueAt, &rem.CreatedAt); err != nil {
+			log.Printf("Warning: failed to scan reminder: %v", err)
+			continue
+		}
+		reminders = append(reminders, rem)
+	}
+	return reminders, rows.Err()
+}
+
+// DeleteReminder removes a delivered or cancelled reminder
+func (p *PostgresDB) DeleteReminder(id int64) error {
+	_, err := p.db.Exec(`DELETE FROM reminders WHERE id = $1`, id)
+	return err
+}
+
+// InsertScheduledMessage stores a scheduled message and returns its ID
+func (p *PostgresDB) InsertScheduledMessage(sm ScheduledMessage) (int64, error) {
+	var id int64
+	err := p.db.QueryRow(`INSERT INTO scheduled_messages (author, content, deliver_at, author_admin, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		sm.Author, sm.Content, sm.DeliverAt, sm.AuthorAdmin, sm.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("postgres: failed to insert scheduled message: %w", err)
+	}
+	return id, nil
+}
+
+// GetScheduledMessages returns every pending scheduled message, soonest first
+func (p *PostgresDB) GetScheduledMessages() ([]ScheduledMessage, error) {
+	rows, err := p.db.Query(`SELECT id, author, content, deliver_at, author_admin, created_at FROM scheduled_messages ORDER BY deliver_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scheduled []ScheduledMessage
+	for rows.Next() {
+		var sm ScheduledMessage
+		if err := rows.Scan(&sm.ID, &sm.Author, &sm.Content, &sm.DeliverAt, &sm.AuthorAdmin, &sm.CreatedAt); err != nil {
+			log.Printf("Warning: failed to scan scheduled message: %v", err)
+			continue
+		}
+		scheduled = append(scheduled, sm)
+	}
+	return scheduled, rows.Err()
+}
+
+// DeleteScheduledMessage removes a delivered or cancelled scheduled message
+func (p *PostgresDB) DeleteScheduledMessage(id int64) error {
+	_, err := p.db.Exec(`DELETE FROM scheduled_messages WHERE id = $1`, id)
+	return err
+}
+
+// InsertSnippet stores a shared snippet
+func (p *PostgresDB) InsertSnippet(snip Snippet) error {
+	_, err := p.db.Exec(`INSERT INTO snippets (id, author, language, content, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		snip.ID, snip.Author, snip.Language, snip.Content, snip.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to insert snippet: %w", err)
+	}
+	return nil
+}
+
+// GetSnippet loads a shared snippet by ID
+func (p *PostgresDB) GetSnippet(id string) (Snippet, error) {
+	var snip Snippet
+	err := p.db.QueryRow(`SELECT id, author, language, content, created_at FROM snippets WHERE id = $1`, id).
+		Scan(&snip.ID, &snip.Author, &snip.Language, &snip.Content, &snip.CreatedAt)
+	return snip, err
+}
+
+// SaveCustomEmoji registers or replaces a custom emoji shortcode
+func (p *PostgresDB) SaveCustomEmoji(e CustomEmoji) error {
+	_, err := p.db.Exec(`INSERT INTO custom_emoji (shortcode, glyph, image, created_by, created_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (shortcode) DO UPDATE SET glyph = EXCLUDED.glyph, image = EXCLUDED.image, created_by = EXCLUDED.created_by, created_at = EXCLUDED.created_at`,
+		e.Shortcode, e.Glyph, e.Image, e.CreatedBy, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to save custom emoji: %w", err)
+	}
+	return nil
+}
+
+// DeleteCustomEmoji removes a custom emoji shortcode
+func (p *PostgresDB) DeleteCustomEmoji(shortcode string) error {
+	_, err := p.db.Exec(`DELETE FROM custom_emoji WHERE shortcode = $1`, shortcode)
+	return err
+}
+
+// GetCustomEmoji lists the registered custom emoji
+func (p *PostgresDB) GetCustomEmoji() ([]CustomEmoji, error) {
+	rows, err := p.db.Query(`SELECT shortcode, glyph, image, created_by, created_at FROM custom_emoji ORDER BY shortcode`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emoji []CustomEmoji
+	for rows.Next() {
+		var e CustomEmoji
+		if err := rows.Scan(&e.Shortcode, &e.Glyph, &e.Image, &e.CreatedBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		emoji = append(emoji, e)
+	}
+	return emoji, rows.Err()
+}
+
+// InsertFilterRule stores a content filter rule and returns its ID
+func (p *PostgresDB) InsertFilterRule(r FilterRule) (int64, error) {
+	var id int64
+	err := p.db.QueryRow(`INSERT INTO filter_rules (pattern, is_regex, action, created_by, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		r.Pattern, r.IsRegex, r.Action, r.CreatedBy, r.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("postgres: failed to insert filter rule: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteFilterRule removes a content filter rule
+func (p *PostgresDB) DeleteFilterRule(id int64) error {
+	_, err := p.db.Exec(`DELETE FROM filter_rules WHERE id = $1`, id)
+	return err
+}
+
+// GetFilterRules lists the content filter rules, oldest first
+func (p *PostgresDB) GetFilterRules() ([]FilterRule, error) {
+	rows, err := p.db.Query(`SELECT id, pattern, is_regex, action, created_by, created_at FROM filter_rules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []FilterRule
+	for rows.Next() {
+		var rule FilterRule
+		if err := rows.Scan(&rule.ID, &rule.Pattern, &rule.IsRegex, &rule.Action, &rule.CreatedBy, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// InsertCronJob stores a recurring message and returns its ID
+func (p *PostgresDB) InsertCronJob(j CronJob) (int64, error) {
+	var id int64
+	err := p.db.QueryRow(`INSERT INTO cron_jobs (schedule, action, message, enabled, created_by, created_at, last_run) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		j.Schedule, j.Action, j.Message, j.Enabled, j.CreatedBy, j.CreatedAt, unixSeconds(j.LastRun)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("postgres: failed to insert cron job: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateCronJob saves whether a recurring message is enabled and when it last ran
+func (p *PostgresDB) UpdateCronJob(j CronJob) error {
+	_, err := p.db.Exec(`UPDATE cron_jobs SET enabled = $1, last_run = $2 WHERE id = $3`, j.Enabled, unixSeconds(j.LastRun), j.ID)
+	return err
+}
+
+// DeleteCronJob removes a recurring message
+func (p *PostgresDB) DeleteCronJob(id int64) error {
+	_, err := p.db.Exec(`DELETE FROM cron_jobs WHERE id = $1`, id)
+	return err
+}
+
+// GetCronJobs lists the recurring messages, oldest first
+func (p *PostgresDB) GetCronJobs() ([]CronJob, error) {
+	rows, err := p.db.Query(`SELECT id, schedule, action, message, enabled, created_by, created_at, last_run FROM cron_jobs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []CronJob
+	for rows.Next() {
+		var j CronJob
+		var lastRun int64
+		if err := rows.Scan(&j.ID, &j.Schedule, &j.Action, &j.Message, &j.Enabled, &j.CreatedBy, &j.CreatedAt, &lastRun); err != nil {
+			return nil, err
+		}
+		j.LastRun = fromUnixSeconds(lastRun)
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// GetEmailAddress returns the address username opted in to mention emails
+// with, or "" when they haven't
+func (p *PostgresDB) GetEmailAddress(username string) (string, error) {
+	var address string
+	err := p.db.QueryRow(`SELECT address FROM email_notifications WHERE username = $1`, strings.ToLower(username)).Scan(&address)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return address, err
+}
+
+// SetEmailAddress opts username in to mention emails at address, or out
+// with an empty address
+func (p *PostgresDB) SetEmailAddress(username, address string) error {
+	if address == "" {
+		_, err := p.db.Exec(`DELETE FROM email_notifications WHERE username = $1`, strings.ToLower(username))
+		return err
+	}
+	_, err := p.db.Exec(`INSERT INTO email_notifications (username, address, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (username) DO UPDATE SET address = EXCLUDED.address, updated_at = EXCLUDED.updated_at`, strings.ToLower(username), address, time.Now())
+	return err
+}
+
+// GetEmailAddresses returns every mention email opt-in, by username
+func (p *PostgresDB) GetEmailAddresses() (map[string]string, error) {
+	rows, err := p.db.Query(`SELECT username, address FROM email_notifications`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make(map[string]string)
+	for rows.Next() {
+		var username, address string
+		if err := rows.Scan(&username, &address); err != nil {
+			return nil, err
+		}
+		addresses[username] = address
+	}
+	return addresses, rows.Err()
+}
+
+// SaveMetricsRollup stores or replaces a metrics history bucket
+func (p *PostgresDB) SaveMetricsRollup(rollup MetricsRollup) error {
+	_, err := p.db.Exec(`INSERT INTO metrics_rollups (resolution, bucket, peak_users, connections, messages, peak_memory) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (resolution, bucket) DO UPDATE SET peak_users = EXCLUDED.peak_users, connections = EXCLUDED.connections, messages = EXCLUDED.messages, peak_memory = EXCLUDED.peak_memory`,
+		rollup.Resolution, rollup.Bucket.Unix(), rollup.PeakUsers, rollup.Connections, rollup.Messages, rollup.PeakMemory)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to save metrics rollup: %w", err)
+	}
+	return nil
+}
+
+// GetMetricsRollups lists metrics history buckets since a time, oldest first
+func (p *PostgresDB) GetMetricsRollups(resolution string, since time.Time) ([]MetricsRollup, error) {
+	rows, err := p.db.Query(`SELECT bucket, peak_users, connections, messages, peak_memory FROM metrics_rollups WHERE resolution = $1 AND bucket >= $2 ORDER BY bucket`,
+		resolution, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []MetricsRollup
+	for rows.Next() {
+		rollup := MetricsRollup{Resolution: resolution}
+		var bucket int64
+		if err := rows.Scan(&bucket, &rollup.PeakUsers, &rollup.Connections, &rollup.Messages, &rollup.PeakMemory); err != nil {
+			return nil, err
+		}
+		rollup.Bucket = time.Unix(bucket, 0)
+		rollups = append(rollups, rollup)
+	}
+	return rollups, rows.Err()
+}
+
+// PruneMetricsRollups deletes metrics history buckets older than before
+func (p *PostgresDB) PruneMetricsRollups(resolution string, before time.Time) error {
+	_, err := p.db.Exec(`DELETE FROM metrics_rollups WHERE resolution = $1 AND bucket < $2`, resolution, before.Unix())
+	return err
+}
+
+// GetWelcomeConfig returns the welcome bot's settings
+func (p *PostgresDB) GetWelcomeConfig() (WelcomeConfig, error) {
+	var c WelcomeConfig
+	err := p.db.QueryRow(`SELECT enabled, message, rules, updated_by, updated_at FROM welcome_config WHERE id = 1`).
+		Scan(&c.Enabled, &c.Message, &c.Rules, &c.UpdatedBy, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return WelcomeConfig{}, nil
+	}
+	return c, err
+}
+
+// SaveWelcomeConfig stores the welcome bot's settings
+func (p *PostgresDB) SaveWelcomeConfig(c WelcomeConfig) error {
+	_, err := p.db.Exec(`INSERT INTO welcome_config (id, enabled, message, rules, updated_by, updated_at) VALUES (1, $1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET enabled = EXCLUDED.enabled, message = EXCLUDED.message, rules = EXCLUDED.rules, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		c.Enabled, c.Message, c.Rules, c.UpdatedBy, c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to save welcome config: %w", err)
+	}
+	return nil
+}
+
+// MarkWelcomed records that username was greeted, reporting whether this is
+// the first time
+func (p *PostgresDB) MarkWelcomed(username string) (bool, error) {
+	result, err := p.db.Exec(`INSERT INTO welcomed_users (username, welcomed_at) VALUES ($1, $2) ON CONFLICT (username) DO NOTHING`, username, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetChannelTopic returns a channel's topic
+func (p *PostgresDB) GetChannelTopic(channel string) (shared.Topic, error) {
+	t := shared.Topic{Channel: channel}
+	err := p.db.QueryRow(`SELECT topic, set_by, set_at FROM channel_topics WHERE channel = $1`, channel).Scan(&t.Text, &t.SetBy, &t.SetAt)
+	if err == sql.ErrNoRows {
+		return shared.Topic{Channel: channel}, nil
+	}
+	return t, err
+}
+
+// SetChannelTopic stores a channel's topic
+func (p *PostgresDB) SetChannelTopic(t shared.Topic) error {
+	_, err := p.db.Exec(`INSERT INTO channel_topics (channel, topic, set_by, set_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (channel) DO UPDATE SET topic = EXCLUDED.topic, set_by = EXCLUDED.set_by, set_at = EXCLUDED.set_at`,
+		t.Channel, t.Text, t.SetBy, t.SetAt)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to set channel topic: %w", err)
+	}
+	return nil
+}
+
+// GetMOTD returns the message of the day
+func (p *PostgresDB) GetMOTD() (shared.MOTD, error) {
+	var m shared.MOTD
+	err := p.db.QueryRow(`SELECT text, set_by, set_at FROM motd WHERE id = 1`).Scan(&m.Text, &m.SetBy, &m.SetAt)
+	if err == sql.ErrNoRows {
+		return shared.MOTD{}, nil
+	}
+	return m, err
+}
+
+// SetMOTD stores the message of the day
+func (p *PostgresDB) SetMOTD(m shared.MOTD) error {
+	_, err := p.db.Exec(`INSERT INTO motd (id, text, set_by, set_at) VALUES (1, $1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET text = EXCLUDED.text, set_by = EXCLUDED.set_by, set_at = EXCLUDED.set_at`,
+		m.Text, m.SetBy, m.SetAt)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to set MOTD: %w", err)
+	}
+	return nil
+}
+
+// GetMaintenance returns the planned maintenance window
+func (p *PostgresDB) GetMaintenance() (shared.Maintenance, error) {
+	return getMaintenanceSQL(p.db)
+}
+
+// SetMaintenance stores the planned maintenance window
+func (p *PostgresDB) SetMaintenance(mw shared.Maintenance) error {
+	_, err := p.db.Exec(`INSERT INTO maintenance (id, start_at, end_at, message, set_by, set_at) VALUES (1, $1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET start_at = EXCLUDED.start_at, end_at = EXCLUDED.end_at, message = EXCLUDED.message, set_by = EXCLUDED.set_by, set_at = EXCLUDED.set_at`,
+		unixSeconds(mw.Start), unixSeconds(mw.End), mw.Message, mw.SetBy, mw.SetAt)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to set maintenance window: %w", err)
+	}
+	return nil
+}
+
+// GetMentionGroups lists the mention groups with their members
+func (p *PostgresDB) GetMentionGroups() ([]MentionGroup, error) {
+	return loadMentionGroupsSQL(p.db)
+}
+
+// SaveMentionGroup stores a mention group and its members
+func (p *PostgresDB) SaveMentionGroup(g MentionGroup) error {
+	err := saveMentionGroupSQL(p.db, g,
+		`INSERT INTO mention_groups (name, admins_only, created_by, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET admins_only = EXCLUDED.admins_only`,
+		`DELETE FROM mention_group_members WHERE group_name = $1`,
+		`INSERT INTO mention_group_members (group_name, username) VALUES ($1, $2)`)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to save mention group: %w", err)
+	}
+	return nil
+}
+
+// GetChannelNotes returns a channel's shared notes
+func (p *PostgresDB) GetChannelNotes(channel string) (shared.ChannelNotes, error) {
+	n := shared.ChannelNotes{Channel: channel}
+	err := p.db.QueryRow(`SELECT text, version, updated_by, updated_at FROM channel_notes WHERE channel = $1`, channel).Scan(&n.Text, &n.Version, &n.UpdatedBy, &n.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return shared.ChannelNotes{Channel: channel}, nil
+	}
+	return n, err
+}
+
+// SaveChannelNotes stores a channel's shared notes
+func (p *PostgresDB) SaveChannelNotes(n shared.ChannelNotes) error {
+	_, err := p.db.Exec(`INSERT INTO channel_notes (channel, text, version, updated_by, updated_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (channel) DO UPDATE SET text = EXCLUDED.text, version = EXCLUDED.version, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+		n.Channel, n.Text, n.Version, n.UpdatedBy, n.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to save channel notes: %w", err)
+	}
+	return nil
+}
+
+// GetKnownUsers lists everyone who has posted or connected
+func (p *PostgresDB) GetKnownUsers() ([]string, error) {
+	return loadKnownUsersSQL(p.db)
+}
+
+// DeleteMentionGroup removes a mention group
+func (p *PostgresDB) DeleteMentionGroup(name string) error {
+	err := deleteMentionGroupSQL(p.db,
+		`DELETE FROM mention_group_members WHERE group_name = $1`,
+		`DELETE FROM mention_groups WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to delete mention group: %w", err)
+	}
+	return nil
+}
+
 // GetDatabaseStats returns database statistics
 func (p *PostgresDB) GetDatabaseStats() (string, error) {
 	var messageCount, userCount, banCount int
@@ -431,6 +1135,11 @@ func (p *PostgresDB) BackupDatabase(dbPath string) (string, error) {
 	return backupFilename, fmt.Errorf("PostgreSQL backup requires pg_dump utility - use external backup tools or implement pg_dump integration")
 }
 
+// RewriteMessageContent rewrites stored message content, see messageRewriter
+func (p *PostgresDB) RewriteMessageContent(fn func(string) (string, bool)) (int, error) {
+	return rewriteSQLMessageContent(p.db, `UPDATE messages SET content = $1 WHERE id = $2`, fn)
+}
+
 // GetDB returns the raw database connection for compatibility
 func (p *PostgresDB) GetDB() *sql.DB {
 	return p.db
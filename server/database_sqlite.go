@@ -62,10 +62,12 @@ func (s *SQLiteDB) CreateSchema() error {
 	CREATE TABLE IF NOT EXISTS messages (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		message_id INTEGER DEFAULT 0,
+		seq INTEGER NOT NULL DEFAULT 0,
 		sender TEXT,
 		content TEXT,
 		created_at DATETIME,
 		is_encrypted BOOLEAN DEFAULT 0,
+		message_type TEXT DEFAULT '',
 		encrypted_data BLOB,
 		nonce BLOB,
 		recipient TEXT
@@ -85,11 +87,141 @@ func (s *SQLiteDB) CreateSchema() error {
 		banned_by TEXT NOT NULL
 	);
 	
+	CREATE TABLE IF NOT EXISTS reminders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		creator TEXT NOT NULL,
+		target TEXT NOT NULL,
+		text TEXT NOT NULL,
+		due_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS snippets (
+		id TEXT PRIMARY KEY,
+		author TEXT NOT NULL,
+		language TEXT NOT NULL DEFAULT '',
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS custom_emoji (
+		shortcode TEXT PRIMARY KEY,
+		glyph TEXT NOT NULL DEFAULT '',
+		image BLOB,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS filter_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pattern TEXT NOT NULL,
+		is_regex BOOLEAN NOT NULL DEFAULT 0,
+		action TEXT NOT NULL DEFAULT 'mask',
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS metrics_rollups (
+		resolution TEXT NOT NULL,
+		bucket INTEGER NOT NULL,
+		peak_users INTEGER NOT NULL DEFAULT 0,
+		connections INTEGER NOT NULL DEFAULT 0,
+		messages INTEGER NOT NULL DEFAULT 0,
+		peak_memory INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (resolution, bucket)
+	);
+
+	CREATE TABLE IF NOT EXISTS welcome_config (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		enabled BOOLEAN NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		rules TEXT NOT NULL DEFAULT '',
+		updated_by TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS welcomed_users (
+		username TEXT PRIMARY KEY,
+		welcomed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS channel_topics (
+		channel TEXT PRIMARY KEY,
+		topic TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS motd (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		text TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS maintenance (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		start_at INTEGER NOT NULL DEFAULT 0,
+		end_at INTEGER NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS mention_groups (
+		name TEXT PRIMARY KEY,
+		admins_only BOOLEAN NOT NULL DEFAULT 0,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS mention_group_members (
+		group_name TEXT NOT NULL,
+		username TEXT NOT NULL,
+		PRIMARY KEY (group_name, username)
+	);
+
+	CREATE TABLE IF NOT EXISTS channel_notes (
+		channel TEXT PRIMARY KEY,
+		text TEXT NOT NULL,
+		version INTEGER NOT NULL DEFAULT 0,
+		updated_by TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS cron_jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		schedule TEXT NOT NULL,
+		action TEXT NOT NULL DEFAULT 'message',
+		message TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_run INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS email_notifications (
+		username TEXT PRIMARY KEY,
+		address TEXT NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS scheduled_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		author TEXT NOT NULL,
+		content TEXT NOT NULL,
+		deliver_at DATETIME NOT NULL,
+		author_admin BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
 	CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages(message_id);
 	CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at);
 	CREATE INDEX IF NOT EXISTS idx_user_message_state_username ON user_message_state(username);
 	CREATE INDEX IF NOT EXISTS idx_ban_history_username ON ban_history(username);
 	CREATE INDEX IF NOT EXISTS idx_ban_history_banned_at ON ban_history(banned_at);
+	CREATE INDEX IF NOT EXISTS idx_reminders_due_at ON reminders(due_at);
+	CREATE INDEX IF NOT EXISTS idx_scheduled_messages_deliver_at ON scheduled_messages(deliver_at);
 	CREATE INDEX IF NOT EXISTS idx_ban_history_unbanned_at ON ban_history(unbanned_at);
 	`
 
@@ -115,6 +247,44 @@ func (s *SQLiteDB) CreateSchema() error {
 		}
 	}
 
+	// Check if message_type column exists, if not add it
+	var typeColumnExists int
+	err = s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('messages') WHERE name='message_type'`).Scan(&typeColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for message_type column: %v", err)
+	}
+
+	if typeColumnExists == 0 {
+		_, err = s.db.Exec(`ALTER TABLE messages ADD COLUMN message_type TEXT DEFAULT ''`)
+		if err != nil {
+			log.Printf("Warning: failed to add message_type column: %v", err)
+		} else {
+			log.Printf("Added message_type column to messages table")
+		}
+	}
+
+	// Check if seq column exists, if not add it numbered in insertion order
+	var seqColumnExists int
+	err = s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('messages') WHERE name='seq'`).Scan(&seqColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for seq column: %v", err)
+	}
+
+	if seqColumnExists == 0 {
+		_, err = s.db.Exec(`ALTER TABLE messages ADD COLUMN seq INTEGER NOT NULL DEFAULT 0`)
+		if err == nil {
+			_, err = s.db.Exec(`UPDATE messages SET seq = id`)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to add seq column: %v", err)
+		} else {
+			log.Printf("Added seq column to messages table")
+		}
+	}
+	if _, err = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_seq ON messages(seq)`); err != nil {
+		log.Printf("Warning: failed to create seq index: %v", err)
+	}
+
 	// Migration: Update existing messages to have message_id = id
 	_, err = s.db.Exec(`UPDATE messages SET message_id = id WHERE message_id = 0 OR message_id IS NULL`)
 	if err != nil {
@@ -135,8 +305,8 @@ func (s *SQLiteDB) Migrate() error {
 
 // InsertMessage inserts a new message into the database
 func (s *SQLiteDB) InsertMessage(msg shared.Message) error {
-	result, err := s.db.Exec(`INSERT INTO messages (sender, content, created_at, is_encrypted) VALUES (?, ?, ?, ?)`,
-		msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted)
+	result, err := s.db.Exec(`INSERT INTO messages (seq, sender, content, created_at, is_encrypted, message_type) VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.Seq, msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted, string(msg.Type))
 	if err != nil {
 		return err
 	}
@@ -147,7 +317,7 @@ func (s *SQLiteDB) InsertMessage(msg shared.Message) error {
 		return err
 	}
 
-	_, err = s.db.Exec(`UPDATE messages SET message_id = ? WHERE id = ?`, id, id)
+	_, err = s.db.Exec(`UPDATE messages SET message_id = ?, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = ?`, id, id)
 	if err != nil {
 		return err
 	}
@@ -161,6 +331,18 @@ func (s *SQLiteDB) InsertMessage(msg shared.Message) error {
 	return nil
 }
 
+// InsertMessages inserts msgs in one transaction, see messageBatcher
+func (s *SQLiteDB) InsertMessages(msgs []shared.Message) error {
+	return insertSQLMessages(s.db, msgs, func(tx *sql.Tx, msg shared.Message) (int64, error) {
+		result, err := tx.Exec(`INSERT INTO messages (seq, sender, content, created_at, is_encrypted, message_type) VALUES (?, ?, ?, ?, ?, ?)`,
+			msg.Seq, msg.Sender, msg.Content, msg.CreatedAt, msg.Encrypted, string(msg.Type))
+		if err != nil {
+			return 0, err
+		}
+		return result.LastInsertId()
+	}, `UPDATE messages SET message_id = ?, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = ?`)
+}
+
 // InsertEncryptedMessage stores an encrypted message in the database
 func (s *SQLiteDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
 	result, err := s.db.Exec(`INSERT INTO messages (sender, content, created_at, is_encrypted, encrypted_data, nonce, recipient) VALUES (?, ?, ?, ?, ?, ?, ?)`,
@@ -175,7 +357,7 @@ func (s *SQLiteDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
 		return err
 	}
 
-	_, err = s.db.Exec(`UPDATE messages SET message_id = ? WHERE id = ?`, id, id)
+	_, err = s.db.Exec(`UPDATE messages SET message_id = ?, seq = CASE WHEN seq = 0 THEN id ELSE seq END WHERE id = ?`, id, id)
 	if err != nil {
 		return err
 	}
@@ -191,7 +373,7 @@ func (s *SQLiteDB) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
 
 // GetRecentMessages retrieves the most recent messages
 func (s *SQLiteDB) GetRecentMessages() []shared.Message {
-	rows, err := s.db.Query(`SELECT sender, content, created_at, is_encrypted FROM messages ORDER BY created_at DESC LIMIT 50`)
+	rows, err := s.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages ORDER BY seq DESC, id DESC LIMIT 50`)
 	if err != nil {
 		log.Println("Query error:", err)
 		return nil
@@ -202,21 +384,23 @@ func (s *SQLiteDB) GetRecentMessages() []shared.Message {
 	for rows.Next() {
 		var msg shared.Message
 		var isEncrypted bool
-		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted)
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
 		if err == nil {
 			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
 			messages = append(messages, msg)
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
 	return messages
 }
 
 // GetMessagesAfter retrieves messages with ID > lastMessageID
 func (s *SQLiteDB) GetMessagesAfter(lastMessageID int64, limit int) []shared.Message {
-	rows, err := s.db.Query(`SELECT sender, content, created_at, is_encrypted FROM messages WHERE message_id > ? ORDER BY created_at DESC LIMIT ?`, lastMessageID, limit)
+	rows, err := s.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages WHERE message_id > ? ORDER BY seq DESC, id DESC LIMIT ?`, lastMessageID, limit)
 	if err != nil {
 		log.Println("Query error in GetMessagesAfter:", err)
 		return nil
@@ -227,15 +411,42 @@ func (s *SQLiteDB) GetMessagesAfter(lastMessageID int64, limit int) []shared.Mes
 	for rows.Next() {
 		var msg shared.Message
 		var isEncrypted bool
-		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted)
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
+		if err == nil {
+			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
+			messages = append(messages, msg)
+		}
+	}
+
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
+	return messages
+}
+
+// GetMessagesSince retrieves all messages created at or after since, oldest first
+func (s *SQLiteDB) GetMessagesSince(since time.Time) []shared.Message {
+	rows, err := s.db.Query(`SELECT sender, content, created_at, is_encrypted, COALESCE(message_type, ''), seq FROM messages WHERE created_at >= ? ORDER BY seq ASC, id ASC`, since)
+	if err != nil {
+		log.Println("Query error in GetMessagesSince:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var messages []shared.Message
+	for rows.Next() {
+		var msg shared.Message
+		var isEncrypted bool
+		var msgType string
+		err := rows.Scan(&msg.Sender, &msg.Content, &msg.CreatedAt, &isEncrypted, &msgType, &msg.Seq)
 		if err == nil {
 			msg.Encrypted = isEncrypted
+			msg.Type = shared.MessageType(msgType)
 			messages = append(messages, msg)
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
 	return messages
 }
 
@@ -249,7 +460,7 @@ func (s *SQLiteDB) GetRecentMessagesForUser(username string, defaultLimit int, b
 		log.Printf("Error getting last message ID for user %s: %v", username, err)
 		// Fall back to recent messages for new users or on error
 		messages := s.GetRecentMessages()
-		sortMessagesByTimestamp(messages) // Ensure consistent ordering
+		sortMessages(messages) // Ensure consistent ordering
 		return messages, 0
 	}
 
@@ -281,8 +492,8 @@ func (s *SQLiteDB) GetRecentMessagesForUser(username string, defaultLimit int, b
 		}
 	}
 
-	// Sort messages by timestamp for consistent chronological display
-	sortMessagesByTimestamp(messages)
+	// Sort messages into channel order for consistent display
+	sortMessages(messages)
 
 	// Filter messages during ban periods if feature is enabled
 	if banGapsHistory {
@@ -290,15 +501,7 @@ func (s *SQLiteDB) GetRecentMessagesForUser(username string, defaultLimit int, b
 		if err != nil {
 			log.Printf("Warning: failed to get ban periods for user %s: %v", username, err)
 		} else if len(banPeriods) > 0 {
-			// Filter out messages sent during ban periods
-			filteredMessages := make([]shared.Message, 0, len(messages))
-			for _, msg := range messages {
-				if !isMessageInBanPeriod(msg.CreatedAt, banPeriods) {
-					filteredMessages = append(filteredMessages, msg)
-				}
-			}
-			messages = filteredMessages
-			log.Printf("Filtered %d messages for user %s due to ban history gaps", len(messages), username)
+			messages = hideBanPeriods(messages, banPeriods)
 		}
 	}
 
@@ -397,6 +600,468 @@ func (s *SQLiteDB) GetUserBanPeriods(username string) ([]BanPeriod, error) {
 	return periods, nil
 }
 
+// GetBanHistory returns every ban, oldest first
+func (s *SQLiteDB) GetBanHistory() ([]BanRecord, error) {
+	rows, err := s.db.Query(`SELECT username, banned_by, banned_at, unbanned_at FROM ban_history ORDER BY banned_at ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []BanRecord
+	for rows.Next() {
+		var b BanRecord
+		if err := rows.Scan(&b.Username, &b.BannedBy, &b.BannedAt, &b.UnbannedAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// ImportBanRecord stores a ban from an archive with its original times
+func (s *SQLiteDB) ImportBanRecord(b BanRecord) error {
+	_, err := s.db.Exec(`INSERT INTO ban_history (username, banned_by, banned_at, unbanned_at) VALUES (?, ?, ?, ?)`,
+		b.Username, b.BannedBy, b.BannedAt, b.UnbannedAt)
+	return err
+}
+
+// CountMessages returns the number of stored messages
+func (s *SQLiteDB) CountMessages() (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM messages`).Scan(&count)
+	return count, err
+}
+
+// GetMessageCountsBySender returns message counts per sender, excluding System
+func (s *SQLiteDB) GetMessageCountsBySender() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT sender, COUNT(*) FROM messages WHERE sender != 'System' GROUP BY sender`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var sender string
+		var count int
+		if err := rows.Scan(&sender, &count); err != nil {
+			continue
+		}
+		counts[sender] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetMessageActivity returns who posted each message since a time
+func (s *SQLiteDB) GetMessageActivity(since time.Time) ([]MessageActivity, error) {
+	return messageActivitySQL(s.db, since, "?")
+}
+
+// QuerySenders returns a page of senders and how many match in total
+func (s *SQLiteDB) QuerySenders(q SenderQuery) ([]SenderStats, int, error) {
+	return querySendersSQL(s.db, q, func(int) string { return "?" })
+}
+
+// InsertReminder stores a reminder and returns its ID
+func (s *SQLiteDB) InsertReminder(rem Reminder) (int64, error) {
+	result, err := s.db.Exec(`INSERT INTO reminders (creator, target, text, due_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		rem.Creator, rem.Target, rem.Text, rem.DueAt, rem.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetPendingReminders returns every stored reminder, soonest first
+func (s *SQLiteDB) GetPendingReminders() ([]Reminder, error) {
+	rows, err := s.db.Query(`SELECT id, creator, target, text, due_at, created_at FROM reminders ORDER BY due_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		var rem Reminder
+		if err := rows.Scan(&rem.ID, &rem.Creator, &rem.Target, &rem.Text, &rem.DueAt, &rem.CreatedAt); err != nil {
+			log.Printf("Warning: failed to scan reminder: %v", err)
+			continue
+		}
+		reminders = append(reminders, rem)
+	}
+	return reminders, rows.Err()
+}
+
+// DeleteReminder removes a delivered or cancelled reminder
+func (s *SQLiteDB) DeleteReminder(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM reminders WHERE id = ?`, id)
+	return err
+}
+
+// InsertScheduledMessage stores a scheduled message and returns its ID
+func (s *SQLiteDB) InsertScheduledMessage(sm ScheduledMessage) (int64, error) {
+	result, err := s.db.Exec(`INSERT INTO scheduled_messages (author, content, deliver_at, author_admin, created_at) VALUES (?, ?, ?, ?, ?)`,
+		sm.Author, sm.Content, sm.DeliverAt, sm.AuthorAdmin, sm.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetScheduledMessages returns every pending scheduled message, soonest first
+func (s *SQLiteDB) GetScheduledMessages() ([]ScheduledMessage, error) {
+	rows, err := s.db.Query(`SELECT id, author, content, deliver_at, author_admin, created_at FROM scheduled_messages ORDER BY deliver_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scheduled []ScheduledMessage
+	for rows.Next() {
+		var sm ScheduledMessage
+		if err := rows.Scan(&sm.ID, &sm.Author, &sm.Content, &sm.DeliverAt, &sm.AuthorAdmin, &sm.CreatedAt); err != nil {
+			log.Printf("Warning: failed to scan scheduled message: %v", err)
+			continue
+		}
+		scheduled = append(scheduled, sm)
+	}
+	return scheduled, rows.Err()
+}
+
+// DeleteScheduledMessage removes a delivered or cancelled scheduled message
+func (s *SQLiteDB) DeleteScheduledMessage(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM scheduled_messages WHERE id = ?`, id)
+	return err
+}
+
+// InsertSnippet stores a shared snippet
+func (s *SQLiteDB) InsertSnippet(snip Snippet) error {
+	_, err := s.db.Exec(`INSERT INTO snippets (id, author, language, content, created_at) VALUES (?, ?, ?, ?, ?)`,
+		snip.ID, snip.Author, snip.Language, snip.Content, snip.CreatedAt)
+	return err
+}
+
+// GetSnippet loads a shared snippet by ID
+func (s *SQLiteDB) GetSnippet(id string) (Snippet, error) {
+	var snip Snippet
+	err := s.db.QueryRow(`SELECT id, author, language, content, created_at FROM snippets WHERE id = ?`, id).
+		Scan(&snip.ID, &snip.Author, &snip.Language, &snip.Content, &snip.CreatedAt)
+	return snip, err
+}
+
+// SaveCustomEmoji registers or replaces a custom emoji shortcode
+func (s *SQLiteDB) SaveCustomEmoji(e CustomEmoji) error {
+	_, err := s.db.Exec(`INSERT INTO custom_emoji (shortcode, glyph, image, created_by, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(shortcode) DO UPDATE SET glyph = excluded.glyph, image = excluded.image, created_by = excluded.created_by, created_at = excluded.created_at`,
+		e.Shortcode, e.Glyph, e.Image, e.CreatedBy, e.CreatedAt)
+	return err
+}
+
+// DeleteCustomEmoji removes a custom emoji shortcode
+func (s *SQLiteDB) DeleteCustomEmoji(shortcode string) error {
+	_, err := s.db.Exec(`DELETE FROM custom_emoji WHERE shortcode = ?`, shortcode)
+	return err
+}
+
+// GetCustomEmoji lists the registered custom emoji
+func (s *SQLiteDB) GetCustomEmoji() ([]CustomEmoji, error) {
+	rows, err := s.db.Query(`SELECT shortcode, glyph, image, created_by, created_at FROM custom_emoji ORDER BY shortcode`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emoji []CustomEmoji
+	for rows.Next() {
+		var e CustomEmoji
+		if err := rows.Scan(&e.Shortcode, &e.Glyph, &e.Image, &e.CreatedBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		emoji = append(emoji, e)
+	}
+	return emoji, rows.Err()
+}
+
+// InsertFilterRule stores a content filter rule and returns its ID
+func (s *SQLiteDB) InsertFilterRule(rule FilterRule) (int64, error) {
+	result, err := s.db.Exec(`INSERT INTO filter_rules (pattern, is_regex, action, created_by, created_at) VALUES (?, ?, ?, ?, ?)`,
+		rule.Pattern, rule.IsRegex, rule.Action, rule.CreatedBy, rule.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// DeleteFilterRule removes a content filter rule
+func (s *SQLiteDB) DeleteFilterRule(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM filter_rules WHERE id = ?`, id)
+	return err
+}
+
+// GetFilterRules lists the content filter rules, oldest first
+func (s *SQLiteDB) GetFilterRules() ([]FilterRule, error) {
+	rows, err := s.db.Query(`SELECT id, pattern, is_regex, action, created_by, created_at FROM filter_rules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []FilterRule
+	for rows.Next() {
+		var rule FilterRule
+		if err := rows.Scan(&rule.ID, &rule.Pattern, &rule.IsRegex, &rule.Action, &rule.CreatedBy, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// InsertCronJob stores a recurring message and returns its ID
+func (s *SQLiteDB) InsertCronJob(j CronJob) (int64, error) {
+	result, err := s.db.Exec(`INSERT INTO cron_jobs (schedule, action, message, enabled, created_by, created_at, last_run) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		j.Schedule, j.Action, j.Message, j.Enabled, j.CreatedBy, j.CreatedAt, unixSeconds(j.LastRun))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateCronJob saves whether a recurring message is enabled and when it last ran
+func (s *SQLiteDB) UpdateCronJob(j CronJob) error {
+	_, err := s.db.Exec(`UPDATE cron_jobs SET enabled = ?, last_run = ? WHERE id = ?`, j.Enabled, unixSeconds(j.LastRun), j.ID)
+	return err
+}
+
+// DeleteCronJob removes a recurring message
+func (s *SQLiteDB) DeleteCronJob(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM cron_jobs WHERE id = ?`, id)
+	return err
+}
+
+// GetCronJobs lists the recurring messages, oldest first
+func (s *SQLiteDB) GetCronJobs() ([]CronJob, error) {
+	rows, err := s.db.Query(`SELECT id, schedule, action, message, enabled, created_by, created_at, last_run FROM cron_jobs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []CronJob
+	for rows.Next() {
+		var j CronJob
+		var lastRun int64
+		if err := rows.Scan(&j.ID, &j.Schedule, &j.Action, &j.Message, &j.Enabled, &j.CreatedBy, &j.CreatedAt, &lastRun); err != nil {
+			return nil, err
+		}
+		j.LastRun = fromUnixSeconds(lastRun)
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// GetEmailAddress returns the address username opted in to mention emails
+// with, or "" when they haven't
+func (s *SQLiteDB) GetEmailAddress(username string) (string, error) {
+	var address string
+	err := s.db.QueryRow(`SELECT address FROM email_notifications WHERE username = ?`, strings.ToLower(username)).Scan(&address)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return address, err
+}
+
+// SetEmailAddress opts username in to mention emails at address, or out
+// with an empty address
+func (s *SQLiteDB) SetEmailAddress(username, address string) error {
+	if address == "" {
+		_, err := s.db.Exec(`DELETE FROM email_notifications WHERE username = ?`, strings.ToLower(username))
+		return err
+	}
+	_, err := s.db.Exec(`INSERT INTO email_notifications (username, address, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET address = excluded.address, updated_at = excluded.updated_at`, strings.ToLower(username), address, time.Now())
+	return err
+}
+
+// GetEmailAddresses returns every mention email opt-in, by username
+func (s *SQLiteDB) GetEmailAddresses() (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT username, address FROM email_notifications`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make(map[string]string)
+	for rows.Next() {
+		var username, address string
+		if err := rows.Scan(&username, &address); err != nil {
+			return nil, err
+		}
+		addresses[username] = address
+	}
+	return addresses, rows.Err()
+}
+
+// SaveMetricsRollup stores or replaces a metrics history bucket
+func (s *SQLiteDB) SaveMetricsRollup(rollup MetricsRollup) error {
+	_, err := s.db.Exec(`INSERT INTO metrics_rollups (resolution, bucket, peak_users, connections, messages, peak_memory) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(resolution, bucket) DO UPDATE SET peak_users = excluded.peak_users, connections = excluded.connections, messages = excluded.messages, peak_memory = excluded.peak_memory`,
+		rollup.Resolution, rollup.Bucket.Unix(), rollup.PeakUsers, rollup.Connections, rollup.Messages, rollup.PeakMemory)
+	return err
+}
+
+// GetMetricsRollups lists metrics history buckets since a time, oldest first
+func (s *SQLiteDB) GetMetricsRollups(resolution string, since time.Time) ([]MetricsRollup, error) {
+	rows, err := s.db.Query(`SELECT bucket, peak_users, connections, messages, peak_memory FROM metrics_rollups WHERE resolution = ? AND bucket >= ? ORDER BY bucket`,
+		resolution, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []MetricsRollup
+	for rows.Next() {
+		rollup := MetricsRollup{Resolution: resolution}
+		var bucket int64
+		if err := rows.Scan(&bucket, &rollup.PeakUsers, &rollup.Connections, &rollup.Messages, &rollup.PeakMemory); err != nil {
+			return nil, err
+		}
+		rollup.Bucket = time.Unix(bucket, 0)
+		rollups = append(rollups, rollup)
+	}
+	return rollups, rows.Err()
+}
+
+// PruneMetricsRollups deletes metrics history buckets older than before
+func (s *SQLiteDB) PruneMetricsRollups(resolution string, before time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM metrics_rollups WHERE resolution = ? AND bucket < ?`, resolution, before.Unix())
+	return err
+}
+
+// GetWelcomeConfig returns the welcome bot's settings
+func (s *SQLiteDB) GetWelcomeConfig() (WelcomeConfig, error) {
+	var c WelcomeConfig
+	err := s.db.QueryRow(`SELECT enabled, message, rules, updated_by, updated_at FROM welcome_config WHERE id = 1`).
+		Scan(&c.Enabled, &c.Message, &c.Rules, &c.UpdatedBy, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return WelcomeConfig{}, nil
+	}
+	return c, err
+}
+
+// SaveWelcomeConfig stores the welcome bot's settings
+func (s *SQLiteDB) SaveWelcomeConfig(c WelcomeConfig) error {
+	_, err := s.db.Exec(`INSERT INTO welcome_config (id, enabled, message, rules, updated_by, updated_at) VALUES (1, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET enabled = excluded.enabled, message = excluded.message, rules = excluded.rules, updated_by = excluded.updated_by, updated_at = excluded.updated_at`,
+		c.Enabled, c.Message, c.Rules, c.UpdatedBy, c.UpdatedAt)
+	return err
+}
+
+// MarkWelcomed records that username was greeted, reporting whether this is
+// the first time
+func (s *SQLiteDB) MarkWelcomed(username string) (bool, error) {
+	result, err := s.db.Exec(`INSERT OR IGNORE INTO welcomed_users (username, welcomed_at) VALUES (?, ?)`, username, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetChannelTopic returns a channel's topic
+func (s *SQLiteDB) GetChannelTopic(channel string) (shared.Topic, error) {
+	t := shared.Topic{Channel: channel}
+	err := s.db.QueryRow(`SELECT topic, set_by, set_at FROM channel_topics WHERE channel = ?`, channel).Scan(&t.Text, &t.SetBy, &t.SetAt)
+	if err == sql.ErrNoRows {
+		return shared.Topic{Channel: channel}, nil
+	}
+	return t, err
+}
+
+// SetChannelTopic stores a channel's topic
+func (s *SQLiteDB) SetChannelTopic(t shared.Topic) error {
+	_, err := s.db.Exec(`INSERT INTO channel_topics (channel, topic, set_by, set_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(channel) DO UPDATE SET topic = excluded.topic, set_by = excluded.set_by, set_at = excluded.set_at`,
+		t.Channel, t.Text, t.SetBy, t.SetAt)
+	return err
+}
+
+// GetMOTD returns the message of the day
+func (s *SQLiteDB) GetMOTD() (shared.MOTD, error) {
+	var m shared.MOTD
+	err := s.db.QueryRow(`SELECT text, set_by, set_at FROM motd WHERE id = 1`).Scan(&m.Text, &m.SetBy, &m.SetAt)
+	if err == sql.ErrNoRows {
+		return shared.MOTD{}, nil
+	}
+	return m, err
+}
+
+// SetMOTD stores the message of the day
+func (s *SQLiteDB) SetMOTD(m shared.MOTD) error {
+	_, err := s.db.Exec(`INSERT INTO motd (id, text, set_by, set_at) VALUES (1, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET text = excluded.text, set_by = excluded.set_by, set_at = excluded.set_at`,
+		m.Text, m.SetBy, m.SetAt)
+	return err
+}
+
+// GetMaintenance returns the planned maintenance window
+func (s *SQLiteDB) GetMaintenance() (shared.Maintenance, error) {
+	return getMaintenanceSQL(s.db)
+}
+
+// SetMaintenance stores the planned maintenance window
+func (s *SQLiteDB) SetMaintenance(mw shared.Maintenance) error {
+	_, err := s.db.Exec(`INSERT INTO maintenance (id, start_at, end_at, message, set_by, set_at) VALUES (1, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET start_at = excluded.start_at, end_at = excluded.end_at, message = excluded.message, set_by = excluded.set_by, set_at = excluded.set_at`,
+		unixSeconds(mw.Start), unixSeconds(mw.End), mw.Message, mw.SetBy, mw.SetAt)
+	return err
+}
+
+// GetMentionGroups lists the mention groups with their members
+func (s *SQLiteDB) GetMentionGroups() ([]MentionGroup, error) {
+	return loadMentionGroupsSQL(s.db)
+}
+
+// SaveMentionGroup stores a mention group and its members
+func (s *SQLiteDB) SaveMentionGroup(g MentionGroup) error {
+	return saveMentionGroupSQL(s.db, g,
+		`INSERT INTO mention_groups (name, admins_only, created_by, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET admins_only = excluded.admins_only`,
+		`DELETE FROM mention_group_members WHERE group_name = ?`,
+		`INSERT INTO mention_group_members (group_name, username) VALUES (?, ?)`)
+}
+
+// GetChannelNotes returns a channel's shared notes
+func (s *SQLiteDB) GetChannelNotes(channel string) (shared.ChannelNotes, error) {
+	n := shared.ChannelNotes{Channel: channel}
+	err := s.db.QueryRow(`SELECT text, version, updated_by, updated_at FROM channel_notes WHERE channel = ?`, channel).Scan(&n.Text, &n.Version, &n.UpdatedBy, &n.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return shared.ChannelNotes{Channel: channel}, nil
+	}
+	return n, err
+}
+
+// SaveChannelNotes stores a channel's shared notes
+func (s *SQLiteDB) SaveChannelNotes(n shared.ChannelNotes) error {
+	_, err := s.db.Exec(`INSERT INTO channel_notes (channel, text, version, updated_by, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(channel) DO UPDATE SET text = excluded.text, version = excluded.version, updated_by = excluded.updated_by, updated_at = excluded.updated_at`,
+		n.Channel, n.Text, n.Version, n.UpdatedBy, n.UpdatedAt)
+	return err
+}
+
+// GetKnownUsers lists everyone who has posted or connected
+func (s *SQLiteDB) GetKnownUsers() ([]string, error) {
+	return loadKnownUsersSQL(s.db)
+}
+
+// DeleteMentionGroup removes a mention group
+func (s *SQLiteDB) DeleteMentionGroup(name string) error {
+	return deleteMentionGroupSQL(s.db,
+		`DELETE FROM mention_group_members WHERE group_name = ?`,
+		`DELETE FROM mention_groups WHERE name = ?`, name)
+}
+
 // GetDatabaseStats returns database statistics
 func (s *SQLiteDB) GetDatabaseStats() (string, error) {
 	var messageCount, userCount, banCount int
@@ -445,6 +1110,11 @@ func (s *SQLiteDB) BackupDatabase(dbPath string) (string, error) {
 	return backupFilename, nil
 }
 
+// RewriteMessageContent rewrites stored message content, see messageRewriter
+func (s *SQLiteDB) RewriteMessageContent(fn func(string) (string, bool)) (int, error) {
+	return rewriteSQLMessageContent(s.db, `UPDATE messages SET content = ? WHERE id = ?`, fn)
+}
+
 // GetDB returns the raw database connection for compatibility
 func (s *SQLiteDB) GetDB() *sql.DB {
 	return s.db
@@ -2,6 +2,8 @@ package server
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/Cod-e-Codes/marchat/shared"
 )
@@ -67,6 +69,11 @@ func (w *DatabaseWrapper) GetMessagesAfter(lastMessageID int64, limit int) []sha
 	return w.db.GetMessagesAfter(lastMessageID, limit)
 }
 
+// GetMessagesSince provides access to the full message archive from a point in time
+func (w *DatabaseWrapper) GetMessagesSince(since time.Time) []shared.Message {
+	return w.db.GetMessagesSince(since)
+}
+
 // GetRecentMessagesForUser provides backward compatibility for GetRecentMessagesForUser function
 func (w *DatabaseWrapper) GetRecentMessagesForUser(username string, defaultLimit int, banGapsHistory bool) ([]shared.Message, int64) {
 	return w.db.GetRecentMessagesForUser(username, defaultLimit, banGapsHistory)
@@ -77,6 +84,31 @@ func (w *DatabaseWrapper) ClearMessages() error {
 	return w.db.ClearMessages()
 }
 
+// CountMessages returns the number of stored messages
+func (w *DatabaseWrapper) CountMessages() (int, error) {
+	return w.db.CountMessages()
+}
+
+// GetKnownUsers lists everyone who has posted or connected
+func (w *DatabaseWrapper) GetKnownUsers() ([]string, error) {
+	return w.db.GetKnownUsers()
+}
+
+// GetMessageCountsBySender returns message counts per sender, excluding System
+func (w *DatabaseWrapper) GetMessageCountsBySender() (map[string]int, error) {
+	return w.db.GetMessageCountsBySender()
+}
+
+// QuerySenders returns a page of senders and how many match in total
+func (w *DatabaseWrapper) QuerySenders(q SenderQuery) ([]SenderStats, int, error) {
+	return w.db.QuerySenders(q)
+}
+
+// GetMessageActivity returns who posted each message since a time
+func (w *DatabaseWrapper) GetMessageActivity(since time.Time) ([]MessageActivity, error) {
+	return w.db.GetMessageActivity(since)
+}
+
 // GetDatabaseStats provides backward compatibility for GetDatabaseStats function
 func (w *DatabaseWrapper) GetDatabaseStats() (string, error) {
 	return w.db.GetDatabaseStats()
@@ -102,6 +134,207 @@ func (w *DatabaseWrapper) GetUserBanPeriods(username string) ([]BanPeriod, error
 	return w.db.GetUserBanPeriods(username)
 }
 
+// GetBanHistory returns every ban, oldest first
+func (w *DatabaseWrapper) GetBanHistory() ([]BanRecord, error) {
+	return w.db.GetBanHistory()
+}
+
+// ImportBanRecord stores a ban from an archive with its original times
+func (w *DatabaseWrapper) ImportBanRecord(b BanRecord) error {
+	return w.db.ImportBanRecord(b)
+}
+
+// InsertReminder stores a reminder
+func (w *DatabaseWrapper) InsertReminder(r Reminder) (int64, error) {
+	return w.db.InsertReminder(r)
+}
+
+// GetPendingReminders returns every stored reminder
+func (w *DatabaseWrapper) GetPendingReminders() ([]Reminder, error) {
+	return w.db.GetPendingReminders()
+}
+
+// DeleteReminder removes a reminder
+func (w *DatabaseWrapper) DeleteReminder(id int64) error {
+	return w.db.DeleteReminder(id)
+}
+
+// InsertScheduledMessage stores a scheduled message
+func (w *DatabaseWrapper) InsertScheduledMessage(sm ScheduledMessage) (int64, error) {
+	return w.db.InsertScheduledMessage(sm)
+}
+
+// GetScheduledMessages returns every pending scheduled message
+func (w *DatabaseWrapper) GetScheduledMessages() ([]ScheduledMessage, error) {
+	return w.db.GetScheduledMessages()
+}
+
+// DeleteScheduledMessage removes a scheduled message
+func (w *DatabaseWrapper) DeleteScheduledMessage(id int64) error {
+	return w.db.DeleteScheduledMessage(id)
+}
+
+// InsertSnippet stores a shared snippet
+func (w *DatabaseWrapper) InsertSnippet(s Snippet) error {
+	return w.db.InsertSnippet(s)
+}
+
+// GetSnippet loads a shared snippet by ID
+func (w *DatabaseWrapper) GetSnippet(id string) (Snippet, error) {
+	return w.db.GetSnippet(id)
+}
+
+// SaveCustomEmoji registers or replaces a custom emoji shortcode
+func (w *DatabaseWrapper) SaveCustomEmoji(e CustomEmoji) error {
+	return w.db.SaveCustomEmoji(e)
+}
+
+// DeleteCustomEmoji removes a custom emoji shortcode
+func (w *DatabaseWrapper) DeleteCustomEmoji(shortcode string) error {
+	return w.db.DeleteCustomEmoji(shortcode)
+}
+
+// GetCustomEmoji lists the registered custom emoji
+func (w *DatabaseWrapper) GetCustomEmoji() ([]CustomEmoji, error) {
+	return w.db.GetCustomEmoji()
+}
+
+// InsertFilterRule stores a content filter rule and returns its ID
+func (w *DatabaseWrapper) InsertFilterRule(r FilterRule) (int64, error) {
+	return w.db.InsertFilterRule(r)
+}
+
+// DeleteFilterRule removes a content filter rule
+func (w *DatabaseWrapper) DeleteFilterRule(id int64) error {
+	return w.db.DeleteFilterRule(id)
+}
+
+// GetFilterRules lists the content filter rules, oldest first
+func (w *DatabaseWrapper) GetFilterRules() ([]FilterRule, error) {
+	return w.db.GetFilterRules()
+}
+
+// InsertCronJob stores a recurring message and returns its ID
+func (w *DatabaseWrapper) InsertCronJob(j CronJob) (int64, error) {
+	return w.db.InsertCronJob(j)
+}
+
+// UpdateCronJob saves whether a recurring message is enabled and when it last ran
+func (w *DatabaseWrapper) UpdateCronJob(j CronJob) error {
+	return w.db.UpdateCronJob(j)
+}
+
+// DeleteCronJob removes a recurring message
+func (w *DatabaseWrapper) DeleteCronJob(id int64) error {
+	return w.db.DeleteCronJob(id)
+}
+
+// GetCronJobs lists the recurring messages, oldest first
+func (w *DatabaseWrapper) GetCronJobs() ([]CronJob, error) {
+	return w.db.GetCronJobs()
+}
+
+// GetEmailAddress returns the address username opted in to mention emails with
+func (w *DatabaseWrapper) GetEmailAddress(username string) (string, error) {
+	return w.db.GetEmailAddress(username)
+}
+
+// SetEmailAddress opts username in to mention emails, or out with ""
+func (w *DatabaseWrapper) SetEmailAddress(username, address string) error {
+	return w.db.SetEmailAddress(username, address)
+}
+
+// GetEmailAddresses returns every mention email opt-in, by username
+func (w *DatabaseWrapper) GetEmailAddresses() (map[string]string, error) {
+	return w.db.GetEmailAddresses()
+}
+
+// SaveMetricsRollup stores or replaces a metrics history bucket
+func (w *DatabaseWrapper) SaveMetricsRollup(r MetricsRollup) error {
+	return w.db.SaveMetricsRollup(r)
+}
+
+// GetMetricsRollups lists metrics history buckets since a time, oldest first
+func (w *DatabaseWrapper) GetMetricsRollups(resolution string, since time.Time) ([]MetricsRollup, error) {
+	return w.db.GetMetricsRollups(resolution, since)
+}
+
+// PruneMetricsRollups deletes metrics history buckets older than before
+func (w *DatabaseWrapper) PruneMetricsRollups(resolution string, before time.Time) error {
+	return w.db.PruneMetricsRollups(resolution, before)
+}
+
+// GetWelcomeConfig returns the welcome bot's settings
+func (w *DatabaseWrapper) GetWelcomeConfig() (WelcomeConfig, error) {
+	return w.db.GetWelcomeConfig()
+}
+
+// SaveWelcomeConfig stores the welcome bot's settings
+func (w *DatabaseWrapper) SaveWelcomeConfig(c WelcomeConfig) error {
+	return w.db.SaveWelcomeConfig(c)
+}
+
+// MarkWelcomed records that username was greeted, reporting whether this is
+// the first time
+func (w *DatabaseWrapper) MarkWelcomed(username string) (bool, error) {
+	return w.db.MarkWelcomed(username)
+}
+
+// GetChannelTopic returns a channel's topic
+func (w *DatabaseWrapper) GetChannelTopic(channel string) (shared.Topic, error) {
+	return w.db.GetChannelTopic(channel)
+}
+
+// SetChannelTopic stores a channel's topic
+func (w *DatabaseWrapper) SetChannelTopic(t shared.Topic) error {
+	return w.db.SetChannelTopic(t)
+}
+
+// GetMOTD returns the message of the day
+func (w *DatabaseWrapper) GetMOTD() (shared.MOTD, error) {
+	return w.db.GetMOTD()
+}
+
+// SetMOTD stores the message of the day
+func (w *DatabaseWrapper) SetMOTD(m shared.MOTD) error {
+	return w.db.SetMOTD(m)
+}
+
+// GetMaintenance returns the planned maintenance window
+func (w *DatabaseWrapper) GetMaintenance() (shared.Maintenance, error) {
+	return w.db.GetMaintenance()
+}
+
+// SetMaintenance stores the planned maintenance window
+func (w *DatabaseWrapper) SetMaintenance(m shared.Maintenance) error {
+	return w.db.SetMaintenance(m)
+}
+
+// GetMentionGroups lists the mention groups with their members
+func (w *DatabaseWrapper) GetMentionGroups() ([]MentionGroup, error) {
+	return w.db.GetMentionGroups()
+}
+
+// SaveMentionGroup stores a mention group and its members
+func (w *DatabaseWrapper) SaveMentionGroup(g MentionGroup) error {
+	return w.db.SaveMentionGroup(g)
+}
+
+// GetChannelNotes returns a channel's shared notes
+func (w *DatabaseWrapper) GetChannelNotes(channel string) (shared.ChannelNotes, error) {
+	return w.db.GetChannelNotes(channel)
+}
+
+// SaveChannelNotes stores a channel's shared notes
+func (w *DatabaseWrapper) SaveChannelNotes(n shared.ChannelNotes) error {
+	return w.db.SaveChannelNotes(n)
+}
+
+// DeleteMentionGroup removes a mention group
+func (w *DatabaseWrapper) DeleteMentionGroup(name string) error {
+	return w.db.DeleteMentionGroup(name)
+}
+
 // GetUserLastMessageID provides backward compatibility for getUserLastMessageID function
 func (w *DatabaseWrapper) GetUserLastMessageID(username string) (int64, error) {
 	return w.db.GetUserLastMessageID(username)
@@ -124,7 +357,11 @@ func (w *DatabaseWrapper) GetLatestMessageID() int64 {
 
 // Query provides direct access to the underlying database Query method
 func (w *DatabaseWrapper) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return w.db.GetDB().Query(query, args...)
+	db := w.db.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("raw SQL queries are not supported by this database backend")
+	}
+	return db.Query(query, args...)
 }
 
 // QueryRow provides direct access to the underlying database QueryRow method
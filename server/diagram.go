@@ -0,0 +1,39 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// shareDiagram broadcasts a diagram from the sender's composer and keeps it
+// in history, so it can be reopened and reworked later. The server pads it
+// again rather than trusting the sender to have lined it up.
+func (c *Client) shareDiagram(msg shared.Message) {
+	if msg.Encrypted {
+		c.reply("Diagrams cannot be sent in encrypted sessions.")
+		return
+	}
+	// Masking keeps each word's width, so the diagram still lines up
+	if !c.applyFilter(&msg.Content) {
+		return
+	}
+	content, err := shared.NormalizeDiagram(msg.Content)
+	if err != nil {
+		c.reply("Diagram not sent: " + err.Error())
+		return
+	}
+	diagram := shared.Message{
+		Sender:    c.username,
+		Content:   content,
+		CreatedAt: time.Now(),
+		Type:      shared.DiagramMessageType,
+	}
+	c.hub.stamp(&diagram)
+	if err := c.db.InsertMessage(diagram); err != nil {
+		log.Printf("Failed to insert message: %v", err)
+	}
+	log.Printf("Diagram from %s (%d bytes)", c.username, len(content))
+	c.hub.broadcast <- diagram
+}
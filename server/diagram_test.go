@@ -0,0 +1,43 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestShareDiagram(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	alice := &Client{hub: hub, db: NewDatabaseWrapper(db), username: "alice", send: make(chan interface{}, 16)}
+	hub.register <- alice
+
+	// Diagrams go out padded even when ASCII art is switched off
+	hub.SetArtEnabled(false)
+	alice.shareDiagram(shared.Message{Sender: "mallory", Content: "+--+\n|db|-->\n+--+\n", Type: shared.DiagramMessageType})
+	msg := nextTextMessage(t, alice)
+	if msg.Type != shared.DiagramMessageType || msg.Sender != "alice" || msg.Content != "+--+   \n|db|-->\n+--+   " {
+		t.Errorf("Unexpected diagram broadcast: %+v", msg)
+	}
+	history := db.GetRecentMessages()
+	if len(history) != 1 || history[0].Type != shared.DiagramMessageType || history[0].Content != msg.Content {
+		t.Errorf("Diagram should be kept in history with its type, got %+v", history)
+	}
+
+	refusals := map[string]shared.Message{
+		"wide":      {Content: "| 🚀 |"},
+		"empty":     {Content: "\n  \n"},
+		"encrypted": {Content: "+--+", Encrypted: true},
+	}
+	for name, m := range refusals {
+		m.Type = shared.DiagramMessageType
+		alice.shareDiagram(m)
+		if msg := nextTextMessage(t, alice); msg.Sender != "System" || !strings.Contains(msg.Content, "iagram") {
+			t.Errorf("%s: expected a refusal, got %+v", name, msg)
+		}
+	}
+}
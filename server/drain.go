@@ -0,0 +1,74 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// drainCloseReason accompanies the service-restart close frame
+const drainCloseReason = "Server restarting, reconnect soon"
+
+// Draining reports whether the server is shutting down: new connections are
+// refused and /readyz fails so load balancers stop sending clients here
+func (h *Hub) Draining() bool {
+	return h.draining.Load()
+}
+
+// Drain prepares for a graceful exit. Every client is sent close code 1012
+// (service restart) so it reconnects, to another replica when there is one,
+// and Drain waits up to timeout for their read loops to finish along with
+// any message write they were in the middle of. Connections still open after
+// that are closed. It reports whether all clients left in time.
+func (h *Hub) Drain(timeout time.Duration) bool {
+	h.draining.Store(true)
+	return h.disconnectAll(timeout)
+}
+
+// disconnectAll sends every client the service-restart close frame and
+// waits up to timeout for them to leave, as Drain describes
+func (h *Hub) disconnectAll(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	reply := make(chan []*Client, 1)
+	var clients []*Client
+	select {
+	case h.drain <- reply:
+		clients = <-reply
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseServiceRestart, drainCloseReason)
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// WriteControl may run alongside the client's writePump
+			_ = client.conn.WriteControl(websocket.CloseMessage, closeMsg, deadline)
+		}()
+	}
+	wg.Wait()
+	HubLogger.Info("Draining connections", map[string]interface{}{
+		"clients": len(clients),
+		"timeout": timeout.String(),
+	})
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for h.activePumps.Load() > 0 {
+		if time.Now().After(deadline) {
+			HubLogger.Warn("Drain timeout reached, closing remaining connections", map[string]interface{}{
+				"remaining": h.activePumps.Load(),
+			})
+			for _, client := range clients {
+				client.conn.Close()
+			}
+			return false
+		}
+		<-ticker.C
+	}
+	return true
+}
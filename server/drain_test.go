@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestHubDrain(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	ts := httptest.NewServer(ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(shared.Handshake{Username: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	// The first frame arrives once ServeWs has finished setting up the client
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected a welcome frame: %v", err)
+	}
+
+	drained := make(chan bool, 1)
+	go func() { drained <- hub.Drain(2 * time.Second) }()
+
+	// The client sees a service-restart close, and reading it answers the close
+	var closeErr *websocket.CloseError
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("Expected a close frame, got %v", err)
+		}
+		break
+	}
+	if closeErr.Code != websocket.CloseServiceRestart {
+		t.Errorf("Expected close code %d, got %d", websocket.CloseServiceRestart, closeErr.Code)
+	}
+	if ok := <-drained; !ok {
+		t.Error("Expected the drain to finish before the timeout")
+	}
+
+	if !hub.Draining() {
+		t.Error("Expected the hub to report draining")
+	}
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected new connections to be refused with 503, got %v", err)
+	}
+	readiness := NewHealthChecker(hub, db, "test").CheckReadiness()
+	if readiness.Status != HealthStatusUnhealthy || readiness.Checks["hub"].Message != "draining for shutdown" {
+		t.Errorf("Expected readiness to fail while draining, got %+v", readiness.Checks["hub"])
+	}
+}
+
+func TestHubDrainTimeout(t *testing.T) {
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", nil)
+	go hub.Run()
+
+	// A read loop that never finishes holds the drain until the timeout
+	hub.activePumps.Add(1)
+	start := time.Now()
+	if hub.Drain(100 * time.Millisecond) {
+		t.Error("Expected the drain to time out")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the drain to give up near its timeout, took %s", elapsed)
+	}
+}
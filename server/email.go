@@ -0,0 +1,239 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Offline mention emails: with SMTP configured, users who opt in with
+// :email set <address> are emailed the mentions, and the reminders others
+// set for them, that arrive while they are offline. The first one starts a
+// debounce timer and whatever arrives before it fires joins the same email;
+// nothing is sent if they have connected by then.
+
+const (
+	// maxEmailDigestItems are listed in one email; the rest are counted
+	maxEmailDigestItems = 20
+	// emailSnippetLen is the most of each message an email quotes
+	emailSnippetLen = 300
+)
+
+// EmailSettings configures offline mention emails, see SetEmailNotifications
+type EmailSettings struct {
+	Host     string
+	Port     int
+	Username string // no SMTP login when empty
+	Password string
+	From     string
+	Debounce time.Duration
+}
+
+type emailNotifier struct {
+	settings EmailSettings
+	send     func(to string, body []byte) error // sendSMTP outside tests
+
+	mu      sync.Mutex
+	pending map[string]*emailDigest // by lowercase username
+}
+
+// emailDigest collects what a user missed until their email goes out
+type emailDigest struct {
+	items   []emailItem
+	dropped int // past maxEmailDigestItems
+}
+
+type emailItem struct {
+	from     string
+	text     string
+	at       time.Time
+	reminder bool
+}
+
+// SetEmailNotifications turns offline mention emails on, or off when the
+// host is empty
+func (h *Hub) SetEmailNotifications(s EmailSettings) {
+	if s.Host == "" {
+		h.email = nil
+		return
+	}
+	n := &emailNotifier{settings: s, pending: make(map[string]*emailDigest)}
+	n.send = n.sendSMTP
+	h.email = n
+}
+
+// sendSMTP delivers one email, with STARTTLS when the server offers it
+func (n *emailNotifier) sendSMTP(to string, body []byte) error {
+	var auth smtp.Auth
+	if n.settings.Username != "" {
+		auth = smtp.PlainAuth("", n.settings.Username, n.settings.Password, n.settings.Host)
+	}
+	addr := net.JoinHostPort(n.settings.Host, strconv.Itoa(n.settings.Port))
+	return smtp.SendMail(addr, auth, n.settings.From, []string{to}, body)
+}
+
+// isOnline reports whether username has a session open
+func (h *Hub) isOnline(username string) bool {
+	for client := range h.clients.all() {
+		if strings.EqualFold(client.username, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// emailMentions queues a sent message for each mentioned user who is offline
+func (h *Hub) emailMentions(msg shared.Message) {
+	if h.email == nil {
+		return
+	}
+	for _, username := range msg.Mentions {
+		if !h.isOnline(username) {
+			h.queueEmail(username, emailItem{from: msg.Sender, text: msg.Content, at: msg.CreatedAt})
+		}
+	}
+}
+
+// queueEmail adds item to username's next email if they opted in, starting
+// the debounce timer with the first one
+func (h *Hub) queueEmail(username string, item emailItem) {
+	n := h.email
+	if n == nil || h.db == nil {
+		return
+	}
+	if address, err := h.db.GetEmailAddress(username); err != nil || address == "" {
+		return
+	}
+	key := strings.ToLower(username)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	d, ok := n.pending[key]
+	if !ok {
+		d = &emailDigest{}
+		n.pending[key] = d
+		time.AfterFunc(n.settings.Debounce, func() { h.sendEmailDigest(key) })
+	}
+	if len(d.items) < maxEmailDigestItems {
+		d.items = append(d.items, item)
+	} else {
+		d.dropped++
+	}
+}
+
+// sendEmailDigest emails username what they missed, unless they have since
+// connected or opted out
+func (h *Hub) sendEmailDigest(username string) {
+	n := h.email
+	n.mu.Lock()
+	d := n.pending[username]
+	delete(n.pending, username)
+	n.mu.Unlock()
+	if d == nil || h.isOnline(username) {
+		return
+	}
+	address, err := h.db.GetEmailAddress(username)
+	if err != nil {
+		log.Printf("Warning: failed to look up %s's email address: %v", username, err)
+		return
+	}
+	if address == "" {
+		return
+	}
+	if err := n.send(address, n.compose(username, address, d, time.Now())); err != nil {
+		log.Printf("Warning: failed to email %s their missed mentions: %v", username, err)
+		return
+	}
+	log.Printf("Emailed %s %d missed mention(s)", username, len(d.items)+d.dropped)
+}
+
+// compose writes the email for a digest as a plain text message
+func (n *emailNotifier) compose(username, to string, d *emailDigest, now time.Time) []byte {
+	count := len(d.items) + d.dropped
+	subject := fmt.Sprintf("[marchat] %d message(s) for you while you were away", count)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.settings.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+
+	fmt.Fprintf(&b, "Hi %s,\r\n\r\nThis came for you while you were offline:\r\n\r\n", username)
+	for _, item := range d.items {
+		text := strings.Join(strings.Fields(item.text), " ")
+		if runes := []rune(text); len(runes) > emailSnippetLen {
+			text = string(runes[:emailSnippetLen]) + "…"
+		}
+		from := item.from
+		if item.reminder {
+			from = "Reminder from " + item.from
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\r\n", item.at.Format("2006-01-02 15:04 MST"), from, text)
+	}
+	if d.dropped > 0 {
+		fmt.Fprintf(&b, "...and %d more.\r\n", d.dropped)
+	}
+	b.WriteString("\r\nConnect to catch up, or stop these emails with :email off.\r\n")
+	return []byte(b.String())
+}
+
+// validEmailAddress accepts a bare address such as alice@example.com
+func validEmailAddress(address string) bool {
+	parsed, err := mail.ParseAddress(address)
+	return err == nil && parsed.Address == address && parsed.Name == ""
+}
+
+// handleEmailCommand handles ":email", ":email set <address>" and
+// ":email off": opting in to emails of the mentions that arrive while you
+// are offline
+func (c *Client) handleEmailCommand(args []string) {
+	usage := "Usage: :email | :email set <address> | :email off"
+	if c.hub.email == nil {
+		c.reply("Email notifications are not enabled on this server.")
+		return
+	}
+	if c.db == nil {
+		c.reply("Email notifications require a database")
+		return
+	}
+	switch {
+	case len(args) == 0:
+		address, err := c.db.GetEmailAddress(c.username)
+		switch {
+		case err != nil:
+			c.reply("Could not read your email setting: " + err.Error())
+		case address == "":
+			c.reply("Mention emails are off. " + usage)
+		default:
+			c.reply(fmt.Sprintf("Mentions that arrive while you are offline are emailed to %s after %s.", address, c.hub.email.settings.Debounce))
+		}
+	case len(args) == 2 && args[0] == "set":
+		if !validEmailAddress(args[1]) {
+			c.reply("Invalid email address: " + args[1])
+			return
+		}
+		if err := c.db.SetEmailAddress(c.username, args[1]); err != nil {
+			c.reply("Could not save your email address: " + err.Error())
+			return
+		}
+		c.reply(fmt.Sprintf("Mentions that arrive while you are offline will be emailed to %s.", args[1]))
+	case len(args) == 1 && args[0] == "off":
+		if err := c.db.SetEmailAddress(c.username, ""); err != nil {
+			c.reply("Could not turn mention emails off: " + err.Error())
+			return
+		}
+		c.reply("Mention emails are off.")
+	default:
+		c.reply(usage)
+	}
+}
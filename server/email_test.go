@@ -0,0 +1,130 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+type sentEmail struct {
+	to   string
+	body string
+}
+
+// captureEmails turns on mention emails with a short debounce and records
+// what would be sent
+func captureEmails(hub *Hub) chan sentEmail {
+	sent := make(chan sentEmail, 8)
+	hub.SetEmailNotifications(EmailSettings{Host: "smtp.example.com", Port: 587, From: "marchat@example.com", Debounce: 100 * time.Millisecond})
+	hub.email.send = func(to string, body []byte) error {
+		sent <- sentEmail{to, string(body)}
+		return nil
+	}
+	return sent
+}
+
+func nextEmail(t *testing.T, sent chan sentEmail) sentEmail {
+	t.Helper()
+	select {
+	case e := <-sent:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for an email")
+		return sentEmail{}
+	}
+}
+
+func TestEmailCommand(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16), db: NewDatabaseWrapper(db)}
+
+	bob.handleCommand(":email")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "not enabled") {
+		t.Errorf("Expected emails to be off without SMTP, got %q", msg.Content)
+	}
+
+	captureEmails(hub)
+	for _, bad := range []string{"bob", "<bob@example.com>", "bob@"} {
+		bob.handleCommand(":email set " + bad)
+		if msg := nextTextMessage(t, bob); !strings.HasPrefix(msg.Content, "Invalid email address") {
+			t.Errorf("%q: unexpected reply %q", bad, msg.Content)
+		}
+	}
+	bob.handleCommand(":email set bob@example.com")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "bob@example.com") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if address, _ := db.GetEmailAddress("bob"); address != "bob@example.com" {
+		t.Errorf("Expected the address to be stored, got %q", address)
+	}
+	bob.handleCommand(":email off")
+	if msg := nextTextMessage(t, bob); msg.Content != "Mention emails are off." {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if address, _ := db.GetEmailAddress("bob"); address != "" {
+		t.Errorf("Expected the address to be forgotten, got %q", address)
+	}
+}
+
+func TestMentionEmailDigest(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+	sent := captureEmails(hub)
+
+	if err := db.SetEmailAddress("bob", "bob@example.com"); err != nil {
+		t.Fatalf("SetEmailAddress failed: %v", err)
+	}
+	now := time.Now()
+	hub.emailMentions(shared.Message{Sender: "alice", Content: "@bob can you\nreview this?", CreatedAt: now, Mentions: []string{"bob", "carol"}})
+	hub.emailMentions(shared.Message{Sender: "dave", Content: "@bob ping", CreatedAt: now, Mentions: []string{"bob"}})
+
+	e := nextEmail(t, sent)
+	if e.to != "bob@example.com" {
+		t.Errorf("Expected the email to go to bob, got %q", e.to)
+	}
+	for _, want := range []string{"Subject: [marchat] 2 message(s) for you while you were away", "alice: @bob can you review this?", "dave: @bob ping", ":email off"} {
+		if !strings.Contains(e.body, want) {
+			t.Errorf("Expected %q in the email:\n%s", want, e.body)
+		}
+	}
+	// carol never opted in
+	select {
+	case e := <-sent:
+		t.Errorf("Expected one email, also got one to %q", e.to)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// Nothing is sent once bob has connected
+	hub.emailMentions(shared.Message{Sender: "alice", Content: "@bob again", CreatedAt: now, Mentions: []string{"bob"}})
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- bob
+	select {
+	case e := <-sent:
+		t.Errorf("Expected no email after bob connected, got one to %q", e.to)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestHeldReminderEmailed(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+	sent := captureEmails(hub)
+
+	if err := db.SetEmailAddress("bob", "bob@example.com"); err != nil {
+		t.Fatalf("SetEmailAddress failed: %v", err)
+	}
+	if _, err := hub.AddReminder("alice", "bob", "Submit the report", time.Now().Add(50*time.Millisecond)); err != nil {
+		t.Fatalf("AddReminder failed: %v", err)
+	}
+	if e := nextEmail(t, sent); !strings.Contains(e.body, "Reminder from alice: Submit the report") {
+		t.Errorf("Expected the held reminder in the email:\n%s", e.body)
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// clamdTimeout bounds a whole clamd scan, connection included
+const clamdTimeout = 30 * time.Second
+
+// clamdChunkSize is how much of a file is sent per INSTREAM chunk
+const clamdChunkSize = 64 * 1024
+
+// fileTypePolicy restricts the files users may send. Extensions are
+// checked against the file name; MIME types against the sniffed content,
+// so a renamed executable doesn't pass as an image.
+type fileTypePolicy struct {
+	extensions []string // ".png"
+	mimeTypes  []string // "image/png", or "image/*" for a whole family
+}
+
+// newFileTypePolicy parses a list of extensions (".pdf" or "pdf") and MIME
+// types ("application/pdf", "image/*"); nil means any file is allowed
+func newFileTypePolicy(types []string) *fileTypePolicy {
+	p := &fileTypePolicy{}
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		switch {
+		case t == "":
+		case strings.Contains(t, "/"):
+			p.mimeTypes = append(p.mimeTypes, t)
+		default:
+			p.extensions = append(p.extensions, "."+strings.TrimPrefix(t, "."))
+		}
+	}
+	if len(p.extensions) == 0 && len(p.mimeTypes) == 0 {
+		return nil
+	}
+	return p
+}
+
+// check returns why a file is refused, or "" when it is allowed. When both
+// extensions and MIME types are listed, a file must match both.
+func (p *fileTypePolicy) check(filename string, data []byte) string {
+	if p == nil {
+		return ""
+	}
+	if len(p.extensions) > 0 {
+		ext := strings.ToLower(filepath.Ext(filename))
+		found := false
+		for _, allowed := range p.extensions {
+			if ext == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if ext == "" {
+				return "files without an extension are not allowed"
+			}
+			return fmt.Sprintf("%s files are not allowed", ext)
+		}
+	}
+	if len(p.mimeTypes) > 0 {
+		detected, _, _ := strings.Cut(http.DetectContentType(data), ";")
+		for _, allowed := range p.mimeTypes {
+			if allowed == detected || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(detected, strings.TrimSuffix(allowed, "*"))) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s content is not allowed", detected)
+	}
+	return ""
+}
+
+// String lists the allowed types for users who were refused
+func (p *fileTypePolicy) String() string {
+	return strings.Join(append(append([]string{}, p.extensions...), p.mimeTypes...), ", ")
+}
+
+// clamdScanner scans files with a ClamAV daemon over its INSTREAM command
+type clamdScanner struct {
+	network string // "unix" or "tcp"
+	address string
+}
+
+// newClamdScanner parses a clamd address: "unix:/path/clamd.ctl" or a bare
+// absolute path for a socket, "tcp:host:port" or "host:port" for TCP
+func newClamdScanner(addr string) *clamdScanner {
+	addr = strings.TrimSpace(addr)
+	switch {
+	case addr == "":
+		return nil
+	case strings.HasPrefix(addr, "unix:"):
+		return &clamdScanner{network: "unix", address: strings.TrimPrefix(addr, "unix:")}
+	case strings.HasPrefix(addr, "tcp:"):
+		return &clamdScanner{network: "tcp", address: strings.TrimPrefix(addr, "tcp:")}
+	case strings.HasPrefix(addr, "/"):
+		return &clamdScanner{network: "unix", address: addr}
+	}
+	return &clamdScanner{network: "tcp", address: addr}
+}
+
+// scan streams data to clamd and returns the name of the signature it
+// matched, or "" when the file is clean
+func (s *clamdScanner) scan(data []byte) (string, error) {
+	conn, err := net.DialTimeout(s.network, s.address, clamdTimeout)
+	if err != nil {
+		return "", fmt.Errorf("clamd unreachable: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(clamdTimeout)); err != nil {
+		return "", err
+	}
+
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return "", err
+	}
+	var size [4]byte
+	for len(data) > 0 {
+		chunk := data[:min(len(data), clamdChunkSize)]
+		data = data[len(chunk):]
+		binary.BigEndian.PutUint32(size[:], uint32(len(chunk)))
+		if _, err := w.Write(size[:]); err != nil {
+			return "", err
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return "", err
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return "", fmt.Errorf("reading clamd reply: %w", err)
+	}
+	result := string(bytes.TrimRight(reply, "\x00\n"))
+	result = strings.TrimSpace(strings.TrimPrefix(result, "stream:"))
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return "", fmt.Errorf("clamd: %s", result)
+}
+
+// SetFilePolicy restricts the file types users may send (empty = any) and
+// scans every file with clamd at clamdAddr before it is shared (empty = no
+// scanning). Files that can't be scanned are refused.
+func (h *Hub) SetFilePolicy(types []string, clamdAddr string) {
+	h.fileTypes = newFileTypePolicy(types)
+	h.virusScanner = newClamdScanner(clamdAddr)
+}
+
+// checkFile reports whether a file may be shared, telling the sender why
+// not and recording refusals in the security log
+func (c *Client) checkFile(file *shared.FileMeta) bool {
+	entry := map[string]interface{}{
+		"user":     c.username,
+		"filename": file.Filename,
+		"size":     len(file.Data),
+	}
+	reason := c.hub.fileTypes.check(file.Filename, file.Data)
+	if reason != "" {
+		reason += " (allowed: " + c.hub.fileTypes.String() + ")"
+	} else if scanner := c.hub.virusScanner; scanner != nil {
+		signature, err := scanner.scan(file.Data)
+		switch {
+		case err != nil:
+			reason = "it could not be scanned for viruses, try again later"
+			entry["error"] = err.Error()
+		case signature != "":
+			reason = "it was flagged by the virus scanner"
+			entry["signature"] = signature
+		}
+	}
+	if reason == "" {
+		return true
+	}
+	entry["reason"] = reason
+	SecurityLogger.Warn("File rejected", entry)
+	c.reply(fmt.Sprintf("File %s not sent: %s.", file.Filename, reason))
+	return false
+}
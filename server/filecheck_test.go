@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestFileTypePolicy(t *testing.T) {
+	if newFileTypePolicy([]string{" ", ""}) != nil {
+		t.Error("An empty list should allow any file")
+	}
+	var none *fileTypePolicy
+	if reason := none.check("run.exe", []byte("MZ")); reason != "" {
+		t.Errorf("No policy should allow anything, got %q", reason)
+	}
+
+	exts := newFileTypePolicy([]string{"PNG", ".pdf"})
+	for name, allowed := range map[string]bool{"cat.png": true, "CAT.PNG": true, "doc.pdf": true, "run.exe": false, "README": false} {
+		if got := exts.check(name, nil) == ""; got != allowed {
+			t.Errorf("check(%s) allowed = %v, want %v", name, got, allowed)
+		}
+	}
+
+	// MIME types are sniffed from the content, not taken from the name
+	both := newFileTypePolicy([]string{".png", "image/*"})
+	if reason := both.check("cat.png", pngHeader); reason != "" {
+		t.Errorf("A real PNG should pass, got %q", reason)
+	}
+	if reason := both.check("cat.png", []byte("MZ\x90\x00 not really an image")); !strings.Contains(reason, "application/octet-stream content") {
+		t.Errorf("A renamed binary should be refused, got %q", reason)
+	}
+	if s := both.String(); s != ".png, image/*" {
+		t.Errorf("Unexpected allowed list %q", s)
+	}
+}
+
+// fakeClamd answers INSTREAM scans, flagging data containing "EICAR"
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, _ := r.ReadString(0); cmd != "zINSTREAM\x00" {
+					return
+				}
+				var data bytes.Buffer
+				var size [4]byte
+				for {
+					if _, err := io.ReadFull(r, size[:]); err != nil {
+						return
+					}
+					n := binary.BigEndian.Uint32(size[:])
+					if n == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+						return
+					}
+				}
+				if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+				} else {
+					conn.Write([]byte("stream: OK\x00"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamdScan(t *testing.T) {
+	for addr, want := range map[string]clamdScanner{
+		"unix:/run/clamd.ctl": {"unix", "/run/clamd.ctl"},
+		"/run/clamd.ctl":      {"unix", "/run/clamd.ctl"},
+		"tcp:scanner:3310":    {"tcp", "scanner:3310"},
+		"scanner:3310":        {"tcp", "scanner:3310"},
+	} {
+		if got := newClamdScanner(addr); got == nil || *got != want {
+			t.Errorf("newClamdScanner(%q) = %+v, want %+v", addr, got, want)
+		}
+	}
+	if newClamdScanner(" ") != nil {
+		t.Error("An empty address should turn scanning off")
+	}
+
+	scanner := newClamdScanner(fakeClamd(t))
+	// Larger than one chunk, to exercise the stream framing
+	clean := bytes.Repeat([]byte("a"), clamdChunkSize+10)
+	if signature, err := scanner.scan(clean); err != nil || signature != "" {
+		t.Errorf("Expected a clean result, got %q (%v)", signature, err)
+	}
+	if signature, err := scanner.scan(append(clean, "EICAR"...)); err != nil || signature != "Eicar-Test-Signature" {
+		t.Errorf("Expected the test signature, got %q (%v)", signature, err)
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	hub := &Hub{}
+	hub.SetFilePolicy([]string{"image/png", ".png"}, fakeClamd(t))
+	alice := &Client{hub: hub, username: "alice", send: make(chan interface{}, 4)}
+
+	if !alice.checkFile(&shared.FileMeta{Filename: "cat.png", Data: pngHeader}) {
+		t.Fatal("A clean PNG should be allowed")
+	}
+	if alice.checkFile(&shared.FileMeta{Filename: "notes.txt", Data: []byte("hi")}) {
+		t.Error("A .txt file should be refused")
+	}
+	if msg := nextTextMessage(t, alice); msg.Content != "File notes.txt not sent: .txt files are not allowed (allowed: .png, image/png)." {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if alice.checkFile(&shared.FileMeta{Filename: "cat.png", Data: append(append([]byte{}, pngHeader...), "EICAR"...)}) {
+		t.Error("A flagged file should be refused")
+	}
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "flagged by the virus scanner") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+
+	// Unscannable files are refused rather than let through
+	hub.SetFilePolicy(nil, "unix:"+t.TempDir()+"/missing.sock")
+	if alice.checkFile(&shared.FileMeta{Filename: "cat.png", Data: pngHeader}) {
+		t.Error("Files should be refused while clamd is down")
+	}
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "could not be scanned") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+}
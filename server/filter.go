@@ -0,0 +1,273 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Content filter rule actions
+const (
+	filterActionMask  = "mask"  // replace matches with asterisks
+	filterActionBlock = "block" // refuse the whole message
+)
+
+// maxFilterPatternLen bounds a single filter word or expression
+const maxFilterPatternLen = 200
+
+// compiledFilter is a stored rule ready to match message text
+type compiledFilter struct {
+	rule FilterRule
+	re   *regexp.Regexp
+}
+
+// contentFilter holds the compiled blocklist applied to chat messages. Rules
+// live in the database and are reloaded whenever an admin changes them.
+type contentFilter struct {
+	mu    sync.RWMutex
+	rules []compiledFilter
+}
+
+func newContentFilter() *contentFilter {
+	return &contentFilter{}
+}
+
+// compileFilterRule builds the matcher for a rule. Words match whole words,
+// case-insensitively; regexes are case-insensitive too.
+func compileFilterRule(r FilterRule) (*regexp.Regexp, error) {
+	if r.Pattern == "" {
+		return nil, fmt.Errorf("pattern cannot be empty")
+	}
+	if len(r.Pattern) > maxFilterPatternLen {
+		return nil, fmt.Errorf("pattern too long (max %d characters)", maxFilterPatternLen)
+	}
+	if r.Action != filterActionMask && r.Action != filterActionBlock {
+		return nil, fmt.Errorf("unknown action %q (mask or block)", r.Action)
+	}
+	if r.IsRegex {
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %v", err)
+		}
+		return re, nil
+	}
+	expr := regexp.QuoteMeta(r.Pattern)
+	if first, _ := utf8.DecodeRuneInString(r.Pattern); isWordRune(first) {
+		expr = `\b` + expr
+	}
+	if last, _ := utf8.DecodeLastRuneInString(r.Pattern); isWordRune(last) {
+		expr += `\b`
+	}
+	return regexp.Compile("(?i)" + expr)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// ReloadFilters recompiles the content filter from the database. Rules that
+// no longer compile are skipped with a warning.
+func (h *Hub) ReloadFilters() {
+	if h.db == nil {
+		return
+	}
+	rules, err := h.db.GetFilterRules()
+	if err != nil {
+		log.Printf("Warning: failed to load filter rules: %v", err)
+		return
+	}
+	compiled := make([]compiledFilter, 0, len(rules))
+	for _, r := range rules {
+		re, err := compileFilterRule(r)
+		if err != nil {
+			log.Printf("Warning: skipping filter rule %d: %v", r.ID, err)
+			continue
+		}
+		compiled = append(compiled, compiledFilter{rule: r, re: re})
+	}
+	h.filters.mu.Lock()
+	h.filters.rules = compiled
+	h.filters.mu.Unlock()
+}
+
+// FilterRules lists the active content filter rules, oldest first
+func (h *Hub) FilterRules() []FilterRule {
+	h.filters.mu.RLock()
+	defer h.filters.mu.RUnlock()
+	rules := make([]FilterRule, 0, len(h.filters.rules))
+	for _, f := range h.filters.rules {
+		rules = append(rules, f.rule)
+	}
+	return rules
+}
+
+// AddFilterRule validates, stores and immediately applies a filter rule
+func (h *Hub) AddFilterRule(pattern string, isRegex bool, action, adminUsername string) (FilterRule, error) {
+	if h.db == nil {
+		return FilterRule{}, fmt.Errorf("filter rules require a database")
+	}
+	r := FilterRule{
+		Pattern:   strings.TrimSpace(pattern),
+		IsRegex:   isRegex,
+		Action:    action,
+		CreatedBy: adminUsername,
+		CreatedAt: time.Now(),
+	}
+	if _, err := compileFilterRule(r); err != nil {
+		return FilterRule{}, err
+	}
+	id, err := h.db.InsertFilterRule(r)
+	if err != nil {
+		return FilterRule{}, err
+	}
+	r.ID = id
+	h.ReloadFilters()
+	AdminLogger.Info("Filter rule added", map[string]interface{}{
+		"admin":   adminUsername,
+		"id":      id,
+		"pattern": r.Pattern,
+		"regex":   isRegex,
+		"action":  action,
+	})
+	return r, nil
+}
+
+// RemoveFilterRule deletes a filter rule and stops applying it
+func (h *Hub) RemoveFilterRule(id int64, adminUsername string) error {
+	if h.db == nil {
+		return fmt.Errorf("filter rules require a database")
+	}
+	found := false
+	for _, r := range h.FilterRules() {
+		if r.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no filter rule #%d", id)
+	}
+	if err := h.db.DeleteFilterRule(id); err != nil {
+		return err
+	}
+	h.ReloadFilters()
+	AdminLogger.Info("Filter rule removed", map[string]interface{}{
+		"admin": adminUsername,
+		"id":    id,
+	})
+	return nil
+}
+
+// filterContent applies the blocklist to message text. Masked matches become
+// asterisks of the same length; blocked reports that a block rule matched.
+func (h *Hub) filterContent(content string) (filtered string, blocked bool) {
+	h.filters.mu.RLock()
+	defer h.filters.mu.RUnlock()
+	filtered = content
+	for _, f := range h.filters.rules {
+		if !f.re.MatchString(filtered) {
+			continue
+		}
+		if f.rule.Action == filterActionBlock {
+			return content, true
+		}
+		filtered = f.re.ReplaceAllStringFunc(filtered, func(m string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(m))
+		})
+	}
+	return filtered, false
+}
+
+// applyFilter filters user-written text in place, returning false when it
+// must not be sent. Besides chat messages it covers text that reaches others
+// later or in another form: scheduled messages, reminders, polls, snippets,
+// ASCII art and diagrams.
+func (c *Client) applyFilter(content *string) bool {
+	filtered, blocked := c.hub.filterContent(*content)
+	if blocked {
+		FilterLogger.Info("Message blocked", map[string]interface{}{"user": c.username})
+		c.reply("Your message was not sent: it contains blocked content.")
+		return false
+	}
+	if filtered != *content {
+		FilterLogger.Info("Message masked", map[string]interface{}{"user": c.username})
+		*content = filtered
+	}
+	return true
+}
+
+// formatFilterRule renders a rule for :filter list
+func formatFilterRule(r FilterRule) string {
+	pattern := r.Pattern
+	if r.IsRegex {
+		pattern = "/" + pattern + "/"
+	}
+	return fmt.Sprintf("#%d %s %s (by %s)", r.ID, r.Action, pattern, r.CreatedBy)
+}
+
+// handleFilterCommand handles ":filter list", ":filter add [block] <word|/regex/>"
+// and ":filter remove <id>"
+func (c *Client) handleFilterCommand(command string) {
+	parts := strings.Fields(command)
+	usage := "Usage: :filter list | :filter add [block] <word|/regex/> | :filter remove <id>"
+	if len(parts) < 2 || parts[1] == "list" {
+		rules := c.hub.FilterRules()
+		if len(rules) == 0 {
+			c.reply("No filter rules. " + usage)
+			return
+		}
+		lines := make([]string, 0, len(rules)+1)
+		lines = append(lines, "Filter rules:")
+		for _, r := range rules {
+			lines = append(lines, formatFilterRule(r))
+		}
+		c.reply(strings.Join(lines, "\n"))
+		return
+	}
+
+	switch parts[1] {
+	case "add":
+		action, skip := filterActionMask, 2
+		if len(parts) > 2 && parts[2] == filterActionBlock {
+			action, skip = filterActionBlock, 3
+		}
+		pattern := commandRemainder(command, skip)
+		isRegex := false
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			pattern, isRegex = pattern[1:len(pattern)-1], true
+		}
+		if pattern == "" {
+			c.reply(usage)
+			return
+		}
+		r, err := c.hub.AddFilterRule(pattern, isRegex, action, c.username)
+		if err != nil {
+			c.reply("Could not add filter rule: " + err.Error())
+			return
+		}
+		c.reply("Added filter rule " + formatFilterRule(r))
+	case "remove":
+		if len(parts) != 3 {
+			c.reply(usage)
+			return
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(parts[2], "#"), 10, 64)
+		if err != nil {
+			c.reply("Invalid rule ID: " + parts[2])
+			return
+		}
+		if err := c.hub.RemoveFilterRule(id, c.username); err != nil {
+			c.reply("Could not remove filter rule: " + err.Error())
+			return
+		}
+		c.reply(fmt.Sprintf("Removed filter rule #%d", id))
+	default:
+		c.reply(usage)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestCompileFilterRule(t *testing.T) {
+	re, err := compileFilterRule(FilterRule{Pattern: "darn", Action: filterActionMask})
+	if err != nil {
+		t.Fatalf("compileFilterRule failed: %v", err)
+	}
+	if !re.MatchString("Oh DARN it") || re.MatchString("darning socks") {
+		t.Error("Words should match whole words case-insensitively")
+	}
+	if re, err := compileFilterRule(FilterRule{Pattern: "c++", Action: filterActionMask}); err != nil || !re.MatchString("I like c++!") {
+		t.Errorf("Words ending in punctuation should still match (%v)", err)
+	}
+	for _, bad := range []FilterRule{
+		{Pattern: "", Action: filterActionMask},
+		{Pattern: "([", IsRegex: true, Action: filterActionMask},
+		{Pattern: "word", Action: "delete"},
+		{Pattern: strings.Repeat("a", maxFilterPatternLen+1), Action: filterActionMask},
+	} {
+		if _, err := compileFilterRule(bad); err == nil {
+			t.Errorf("%+v should be rejected", bad)
+		}
+	}
+}
+
+func TestFilterContent(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	if _, err := hub.AddFilterRule("darn", false, filterActionMask, "admin"); err != nil {
+		t.Fatalf("AddFilterRule failed: %v", err)
+	}
+	spam, err := hub.AddFilterRule(`buy\s+now`, true, filterActionBlock, "admin")
+	if err != nil {
+		t.Fatalf("AddFilterRule failed: %v", err)
+	}
+
+	if got, blocked := hub.filterContent("darn, Darn!"); blocked || got != "****, ****!" {
+		t.Errorf("filterContent = %q, %v", got, blocked)
+	}
+	if _, blocked := hub.filterContent("BUY   now"); !blocked {
+		t.Error("Expected block rule to refuse the message")
+	}
+
+	// Removing a rule applies immediately
+	if err := hub.RemoveFilterRule(spam.ID, "admin"); err != nil {
+		t.Fatalf("RemoveFilterRule failed: %v", err)
+	}
+	if _, blocked := hub.filterContent("buy now"); blocked {
+		t.Error("Removed rule should no longer apply")
+	}
+	if err := hub.RemoveFilterRule(spam.ID, "admin"); err == nil {
+		t.Error("Removing a missing rule should fail")
+	}
+
+	// Rules survive a restart
+	restarted := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	restarted.ReloadFilters()
+	if rules := restarted.FilterRules(); len(rules) != 1 || rules[0].Pattern != "darn" {
+		t.Errorf("Expected the stored rule to reload, got %+v", rules)
+	}
+}
+
+func TestFilterCommand(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	admin := &Client{hub: hub, username: "root", isAdmin: true, send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- admin
+	hub.register <- bob
+
+	bob.handleCommand(":filter add darn")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "admin privileges") {
+		t.Errorf("Non-admins should not manage filters, got %q", msg.Content)
+	}
+
+	admin.handleCommand(":filter add block /free\\s+crypto/")
+	if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "block /free\\s+crypto/") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	admin.handleCommand(":filter list")
+	if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "#1 block") {
+		t.Errorf("Unexpected list %q", msg.Content)
+	}
+
+	content := "get FREE crypto"
+	if bob.applyFilter(&content) {
+		t.Error("Expected the message to be blocked")
+	}
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "blocked content") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+
+	admin.handleCommand(":filter remove 1")
+	if msg := nextTextMessage(t, admin); msg.Content != "Removed filter rule #1" {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if !bob.applyFilter(&content) || content != "get FREE crypto" {
+		t.Errorf("Message should pass after removal, got %q", content)
+	}
+}
+
+func TestFilterCoversDeferredText(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+	if _, err := hub.AddFilterRule("darn", false, filterActionMask, "admin"); err != nil {
+		t.Fatalf("AddFilterRule failed: %v", err)
+	}
+	if _, err := hub.AddFilterRule("spam", false, filterActionBlock, "admin"); err != nil {
+		t.Fatalf("AddFilterRule failed: %v", err)
+	}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16), db: NewDatabaseWrapper(db)}
+	hub.register <- bob
+
+	for _, command := range []string{":schedule 1h buy spam", ":remind 1h spam", `:poll "Lunch?" "pizza" "spam"`} {
+		bob.handleCommand(command)
+		if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "blocked content") {
+			t.Errorf("%q: expected it to be blocked, got %q", command, msg.Content)
+		}
+	}
+	if pending, _ := hub.ScheduledMessages("bob"); len(pending) != 0 || len(hub.OpenPolls()) != 0 {
+		t.Error("Blocked text should not be scheduled or polled")
+	}
+
+	bob.handleCommand(":schedule 1h darn it")
+	nextTextMessage(t, bob)
+	if pending, _ := hub.ScheduledMessages("bob"); len(pending) != 1 || pending[0].Content != "**** it" {
+		t.Errorf("Expected the scheduled text masked, got %+v", pending)
+	}
+
+	bob.shareDiagram(shared.Message{Content: "+------+\n| darn |\n+------+", Type: shared.DiagramMessageType})
+	if msg := nextTextMessage(t, bob); msg.Type != shared.DiagramMessageType || strings.Contains(msg.Content, "darn") || !strings.Contains(msg.Content, "| **** |") {
+		t.Errorf("Expected the diagram masked in place, got %+v", msg)
+	}
+}
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -30,7 +32,10 @@ type WSMessage struct {
 }
 
 type UserList struct {
-	Users []string `json:"users"`
+	Users        []string          `json:"users"`
+	DisplayNames map[string]string `json:"display_names,omitempty"` // username -> name set with :nick
+	Away         []string          `json:"away,omitempty"`          // listed users who have gone idle
+	Seq          uint64            `json:"seq,omitempty"`           // numbers the list for userlist_delta frames
 }
 
 // getClientIP extracts the real IP address from the request
@@ -93,6 +98,41 @@ func CreateSchema(db *sql.DB) {
 		}
 	}
 
+	// Check if message_type column exists, if not add it
+	var typeColumnExists int
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('messages') WHERE name='message_type'`).Scan(&typeColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for message_type column: %v", err)
+	}
+
+	if typeColumnExists == 0 {
+		_, err = db.Exec(`ALTER TABLE messages ADD COLUMN message_type TEXT DEFAULT ''`)
+		if err != nil {
+			log.Printf("Warning: failed to add message_type column: %v", err)
+		} else {
+			log.Printf("Added message_type column to messages table")
+		}
+	}
+
+	// Check if seq column exists, if not add it numbered in insertion order
+	var seqColumnExists int
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('messages') WHERE name='seq'`).Scan(&seqColumnExists)
+	if err != nil {
+		log.Printf("Warning: failed to check for seq column: %v", err)
+	}
+
+	if seqColumnExists == 0 {
+		_, err = db.Exec(`ALTER TABLE messages ADD COLUMN seq INTEGER NOT NULL DEFAULT 0`)
+		if err == nil {
+			_, err = db.Exec(`UPDATE messages SET seq = id`)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to add seq column: %v", err)
+		} else {
+			log.Printf("Added seq column to messages table")
+		}
+	}
+
 	// Create user_message_state table
 	userStateSchema := `
 	CREATE TABLE IF NOT EXISTS user_message_state (
@@ -121,10 +161,213 @@ func CreateSchema(db *sql.DB) {
 		log.Printf("Warning: failed to create ban_history table: %v", err)
 	}
 
+	// Create reminders table
+	remindersSchema := `
+	CREATE TABLE IF NOT EXISTS reminders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		creator TEXT NOT NULL,
+		target TEXT NOT NULL,
+		text TEXT NOT NULL,
+		due_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	_, err = db.Exec(remindersSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create reminders table: %v", err)
+	}
+
+	// Create snippets table
+	snippetsSchema := `
+	CREATE TABLE IF NOT EXISTS snippets (
+		id TEXT PRIMARY KEY,
+		author TEXT NOT NULL,
+		language TEXT NOT NULL DEFAULT '',
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	_, err = db.Exec(snippetsSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create snippets table: %v", err)
+	}
+
+	// Create custom emoji table
+	customEmojiSchema := `
+	CREATE TABLE IF NOT EXISTS custom_emoji (
+		shortcode TEXT PRIMARY KEY,
+		glyph TEXT NOT NULL DEFAULT '',
+		image BLOB,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	_, err = db.Exec(customEmojiSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create custom_emoji table: %v", err)
+	}
+
+	// Create content filter rules table
+	filterSchema := `
+	CREATE TABLE IF NOT EXISTS filter_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pattern TEXT NOT NULL,
+		is_regex BOOLEAN NOT NULL DEFAULT 0,
+		action TEXT NOT NULL DEFAULT 'mask',
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	_, err = db.Exec(filterSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create filter_rules table: %v", err)
+	}
+
+	// Create metrics history table, one row per minute or hour bucket
+	metricsSchema := `
+	CREATE TABLE IF NOT EXISTS metrics_rollups (
+		resolution TEXT NOT NULL,
+		bucket INTEGER NOT NULL,
+		peak_users INTEGER NOT NULL DEFAULT 0,
+		connections INTEGER NOT NULL DEFAULT 0,
+		messages INTEGER NOT NULL DEFAULT 0,
+		peak_memory INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (resolution, bucket)
+	);`
+	_, err = db.Exec(metricsSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create metrics_rollups table: %v", err)
+	}
+
+	// Create welcome bot tables: its settings row and who it has greeted
+	welcomeSchema := `
+	CREATE TABLE IF NOT EXISTS welcome_config (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		enabled BOOLEAN NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		rules TEXT NOT NULL DEFAULT '',
+		updated_by TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS welcomed_users (
+		username TEXT PRIMARY KEY,
+		welcomed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	_, err = db.Exec(welcomeSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create welcome bot tables: %v", err)
+	}
+
+	// Create channel topic and message of the day tables
+	noticeSchema := `
+	CREATE TABLE IF NOT EXISTS channel_topics (
+		channel TEXT PRIMARY KEY,
+		topic TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS motd (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		text TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS maintenance (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		start_at INTEGER NOT NULL DEFAULT 0,
+		end_at INTEGER NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	_, err = db.Exec(noticeSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create topic, MOTD and maintenance tables: %v", err)
+	}
+
+	// Create mention group tables
+	groupSchema := `
+	CREATE TABLE IF NOT EXISTS mention_groups (
+		name TEXT PRIMARY KEY,
+		admins_only BOOLEAN NOT NULL DEFAULT 0,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS mention_group_members (
+		group_name TEXT NOT NULL,
+		username TEXT NOT NULL,
+		PRIMARY KEY (group_name, username)
+	);`
+	_, err = db.Exec(groupSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create mention group tables: %v", err)
+	}
+
+	// Create shared notes table
+	notesSchema := `
+	CREATE TABLE IF NOT EXISTS channel_notes (
+		channel TEXT PRIMARY KEY,
+		text TEXT NOT NULL,
+		version INTEGER NOT NULL DEFAULT 0,
+		updated_by TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	_, err = db.Exec(notesSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create shared notes table: %v", err)
+	}
+
+	// Create recurring messages table
+	cronSchema := `
+	CREATE TABLE IF NOT EXISTS cron_jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		schedule TEXT NOT NULL,
+		action TEXT NOT NULL DEFAULT 'message',
+		message TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_run INTEGER NOT NULL DEFAULT 0
+	);`
+	_, err = db.Exec(cronSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create cron_jobs table: %v", err)
+	}
+
+	// Create offline mention email opt-in table
+	emailSchema := `
+	CREATE TABLE IF NOT EXISTS email_notifications (
+		username TEXT PRIMARY KEY,
+		address TEXT NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	_, err = db.Exec(emailSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create email_notifications table: %v", err)
+	}
+
+	// Create scheduled messages table
+	scheduledSchema := `
+	CREATE TABLE IF NOT EXISTS scheduled_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		author TEXT NOT NULL,
+		content TEXT NOT NULL,
+		deliver_at DATETIME NOT NULL,
+		author_admin BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	_, err = db.Exec(scheduledSchema)
+	if err != nil {
+		log.Printf("Warning: failed to create scheduled_messages table: %v", err)
+	}
+
 	// Create indexes for performance
 	indexes := []string{
 		`CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages(message_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_seq ON messages(seq)`,
 		`CREATE INDEX IF NOT EXISTS idx_user_message_state_username ON user_message_state(username)`,
 		`CREATE INDEX IF NOT EXISTS idx_ban_history_username ON ban_history(username)`,
 		`CREATE INDEX IF NOT EXISTS idx_ban_history_banned_at ON ban_history(banned_at)`,
@@ -174,7 +417,7 @@ func GetRecentMessagesForUser(db Database, username string, defaultLimit int, ba
 		log.Printf("Error getting last message ID for user %s: %v", username, err)
 		// Fall back to recent messages for new users or on error
 		messages := db.GetRecentMessages()
-		sortMessagesByTimestamp(messages) // Ensure consistent ordering
+		sortMessages(messages) // Ensure consistent ordering
 		return messages, 0
 	}
 
@@ -208,7 +451,7 @@ func GetRecentMessagesForUser(db Database, username string, defaultLimit int, ba
 
 	// CRITICAL FIX: Always sort messages by timestamp for consistent chronological display
 	// Note: SQL queries fetch newest messages first (DESC), but we sort chronologically (ASC) for display
-	sortMessagesByTimestamp(messages)
+	sortMessages(messages)
 
 	// Filter messages during ban periods if feature is enabled
 	if banGapsHistory {
@@ -216,15 +459,7 @@ func GetRecentMessagesForUser(db Database, username string, defaultLimit int, ba
 		if err != nil {
 			log.Printf("Warning: failed to get ban periods for user %s: %v", username, err)
 		} else if len(banPeriods) > 0 {
-			// Filter out messages sent during ban periods
-			filteredMessages := make([]shared.Message, 0, len(messages))
-			for _, msg := range messages {
-				if !isMessageInBanPeriod(msg.CreatedAt, banPeriods) {
-					filteredMessages = append(filteredMessages, msg)
-				}
-			}
-			messages = filteredMessages
-			log.Printf("Filtered %d messages for user %s due to ban history gaps", len(messages), username)
+			messages = hideBanPeriods(messages, banPeriods)
 		}
 	}
 
@@ -265,20 +500,65 @@ func isMessageInBanPeriod(messageTime time.Time, banPeriods []BanPeriod) bool {
 	return false
 }
 
-// sortMessagesByTimestamp ensures messages are displayed in chronological order
-// This provides server-side protection against ordering issues
-func sortMessagesByTimestamp(messages []shared.Message) {
-	sort.Slice(messages, func(i, j int) bool {
-		// Primary sort: by timestamp
-		if !messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
-			return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+// hideBanPeriods drops the messages sent while the user was banned. Each
+// run of them becomes one gap marker, so the client can show that history
+// was withheld rather than silently skipping it. messages must be sorted.
+func hideBanPeriods(messages []shared.Message, banPeriods []BanPeriod) []shared.Message {
+	kept := make([]shared.Message, 0, len(messages))
+	hidden := 0
+	inGap := false
+	for _, msg := range messages {
+		if !isMessageInBanPeriod(msg.CreatedAt, banPeriods) {
+			inGap = false
+			kept = append(kept, msg)
+			continue
 		}
-		// Secondary sort: by sender for deterministic ordering when timestamps are identical
-		if messages[i].Sender != messages[j].Sender {
-			return messages[i].Sender < messages[j].Sender
+		hidden++
+		if !inGap {
+			inGap = true
+			kept = append(kept, shared.Message{
+				Sender:    "System",
+				CreatedAt: msg.CreatedAt,
+				Type:      shared.GapMessageType,
+				Gap:       &shared.HistoryGap{From: msg.CreatedAt},
+			})
 		}
-		// Tertiary sort: by content for full deterministic ordering
-		return messages[i].Content < messages[j].Content
+		marker := &kept[len(kept)-1]
+		marker.Gap.Hidden++
+		marker.Gap.To = msg.CreatedAt
+		// Clients that don't know gap markers show this instead
+		marker.Content = gapText(marker.Gap.Hidden)
+	}
+	if hidden > 0 {
+		log.Printf("Hid %d messages due to ban history gaps", hidden)
+	}
+	return kept
+}
+
+// gapText describes a gap marker for clients that can't render one
+func gapText(hidden int) string {
+	if hidden == 1 {
+		return "1 message hidden by moderation"
+	}
+	return fmt.Sprintf("%d messages hidden by moderation", hidden)
+}
+
+// sortMessages puts messages in channel order: by the sequence number the
+// server assigned, then for messages without one (imported, or stored
+// before numbering) by timestamp, with sender and content as tiebreakers
+func sortMessages(messages []shared.Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := &messages[i], &messages[j]
+		if a.Seq != b.Seq {
+			return a.Seq < b.Seq
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if a.Sender != b.Sender {
+			return a.Sender < b.Sender
+		}
+		return a.Content < b.Content
 	})
 }
 
@@ -334,53 +614,41 @@ func GetDatabaseStats(db Database) (string, error) {
 	return db.GetDatabaseStats()
 }
 
-func (h *Hub) broadcastUserList() {
-	usernames := []string{}
-	for client := range h.clients {
-		if client.username != "" {
-			usernames = append(usernames, client.username)
-		}
-	}
-	sort.Strings(usernames) // Sort alphabetically
-	userList := UserList{Users: usernames}
-	payload, _ := json.Marshal(userList)
-	msg := WSMessage{Type: "userlist", Data: payload}
-	for client := range h.clients {
-		client.send <- msg
+// newSessionID returns a short random identifier for a client connection
+func newSessionID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
 	}
+	return hex.EncodeToString(b)
 }
 
-type adminAuth struct {
-	admins   map[string]struct{}
-	adminKey string
+// rejectHandshake refuses a connection during the handshake with one of the
+// shared.Close* codes, so the client can tell the cases apart and explain
+// what to do about it
+func rejectHandshake(conn *websocket.Conn, code int, reason string) {
+	if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason)); err != nil {
+		log.Printf("WriteMessage error: %v", err)
+	}
+	conn.Close()
 }
 
-// validateUsernameHandler validates username format (same logic as client.go validateUsername)
-func validateUsernameHandler(username string) error {
-	if username == "" {
-		return fmt.Errorf("username cannot be empty")
-	}
-	if len(username) > 32 {
-		return fmt.Errorf("username too long (max 32 characters)")
-	}
-	// Allow letters, numbers, underscores, hyphens, and periods
-	for _, char := range username {
-		if !((char >= 'a' && char <= 'z') ||
-			(char >= 'A' && char <= 'Z') ||
-			(char >= '0' && char <= '9') ||
-			char == '_' || char == '-' || char == '.') {
-			return fmt.Errorf("username contains invalid characters (only letters, numbers, _, -, . allowed)")
-		}
-	}
-	// Prevent usernames that could be confused with system messages or commands
-	if strings.HasPrefix(username, ":") || strings.HasPrefix(username, ".") {
-		return fmt.Errorf("username cannot start with : or")
+// rejectUsername tells the client why its username was refused, in detail
+// first and then as a close code
+func rejectUsername(conn *websocket.Conn, rejection *shared.UsernameRejection) {
+	if _, err := writeFrame(conn, usernameRejectedMessage(rejection), 0); err != nil {
+		log.Printf("WriteMessage error: %v", err)
 	}
-	// Prevent path traversal attempts
-	if strings.Contains(username, "..") {
-		return fmt.Errorf("username cannot contain '..'")
+	code := shared.CloseUsernameInvalid
+	if rejection.Code == shared.UsernameTaken {
+		code = shared.CloseUsernameTaken
 	}
-	return nil
+	rejectHandshake(conn, code, rejection.Reason)
+}
+
+type adminAuth struct {
+	admins   map[string]struct{}
+	adminKey string
 }
 
 func ServeWs(hub *Hub, database Database, adminList []string, adminKey string, banGapsHistory bool, maxFileBytes int64, dbPath string) http.HandlerFunc {
@@ -403,74 +671,101 @@ func ServeWs(hub *Hub, database Database, adminList []string, adminKey string, b
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
+		// Shutting down: send the client to another replica or back in a moment
+		if hub.Draining() {
+			w.Header().Set("Retry-After", "2")
+			http.Error(w, "Server is restarting", http.StatusServiceUnavailable)
+			return
+		}
+		// Any join challenge travels on the upgrade response headers
+		challenge := hub.newJoinChallenge()
+		header := http.Header{}
+		challenge.SetHeaders(header)
+		conn, err := hub.upgrader().Upgrade(w, r, header)
 		if err != nil {
 			log.Println("WebSocket upgrade error:", err)
 			return
 		}
+		if hub.compressionLevel > 0 {
+			_ = conn.SetCompressionLevel(hub.compressionLevel)
+		}
 		// Expect handshake as first message
 		var hs shared.Handshake
-		err = conn.ReadJSON(&hs)
+		_, err = readFrame(conn, &hs)
 		if err != nil {
-			if err := conn.WriteMessage(websocket.CloseMessage, []byte("Invalid handshake")); err != nil {
-				log.Printf("WriteMessage error: %v", err)
-			}
-			conn.Close()
+			rejectHandshake(conn, shared.CloseInvalidHandshake, "Invalid handshake")
+			return
+		}
+		if err := hub.checkJoinChallenge(challenge, hs); err != nil {
+			SecurityLogger.Warn("Join challenge failed", map[string]interface{}{
+				"username": hs.Username,
+				"error":    err.Error(),
+				"ip":       getClientIP(r),
+			})
+			rejectHandshake(conn, shared.CloseChallengeFailed, err.Error())
+			return
+		}
+		if hub.refusesClientVersion(hs.ClientVersion) {
+			log.Printf("Refused client %s from %s (IP: %s) - older than %s", hs.ClientVersion, hs.Username, getClientIP(r), hub.minClientVersion)
+			rejectHandshake(conn, shared.CloseUnsupportedVersion, hub.minClientVersion)
 			return
 		}
 		username := strings.TrimSpace(hs.Username)
 		if username == "" {
-			if err := conn.WriteMessage(websocket.CloseMessage, []byte("Username required")); err != nil {
-				log.Printf("WriteMessage error: %v", err)
-			}
-			conn.Close()
+			rejectHandshake(conn, shared.CloseUsernameInvalid, "Username required")
 			return
 		}
 
-		// Validate username format
-		if err := validateUsernameHandler(username); err != nil {
+		lu := strings.ToLower(username)
+
+		// Check the name against the server's username policy
+		_, configuredAdmin := auth.admins[lu]
+		if rejection := hub.UsernamePolicy().Check(username, configuredAdmin); rejection != nil {
 			SecurityLogger.Warn("Invalid username attempt", map[string]interface{}{
 				"username": username,
-				"error":    err.Error(),
+				"error":    rejection.Reason,
+				"code":     rejection.Code,
 				"ip":       getClientIP(r),
 			})
-			if err := conn.WriteMessage(websocket.CloseMessage, []byte("Invalid username: "+err.Error())); err != nil {
-				log.Printf("WriteMessage error: %v", err)
-			}
-			conn.Close()
+			rejectUsername(conn, rejection)
 			return
 		}
 
-		lu := strings.ToLower(username)
+		if hs.ReadOnly && !hub.AllowsSpectators() {
+			rejectHandshake(conn, shared.CloseSpectatorsRefused, "Spectators are not allowed on this server")
+			return
+		}
 
-		// Check username allowlist if enabled
+		// Check username allowlist if enabled; a valid invite admits anyone
+		needsInvite := false
 		if allowedUsers != nil {
-			if _, allowed := allowedUsers[lu]; !allowed {
-				SecurityLogger.Warn("Username not in allowlist", map[string]interface{}{
-					"username": username,
-					"ip":       getClientIP(r),
-				})
-				log.Printf("User '%s' (IP: %s) rejected - not in allowed users list", username, getClientIP(r))
-				if err := conn.WriteMessage(websocket.CloseMessage, []byte("Username not allowed on this server")); err != nil {
-					log.Printf("WriteMessage error: %v", err)
-				}
-				conn.Close()
-				return
+			if _, allowed := allowedUsers[lu]; !allowed && !hub.IsInvited(lu) {
+				needsInvite = true
 			}
 		}
+		if needsInvite && !hub.ValidInvite(hs.Invite) {
+			SecurityLogger.Warn("Username not in allowlist", map[string]interface{}{
+				"username": username,
+				"ip":       getClientIP(r),
+			})
+			log.Printf("User '%s' (IP: %s) rejected - not in allowed users list", username, getClientIP(r))
+			reason := "Username not allowed on this server"
+			if hs.Invite != "" {
+				reason = "Invite is invalid or has expired"
+			}
+			rejectHandshake(conn, shared.CloseNotAllowed, reason)
+			return
+		}
 		isAdmin := false
-		if hs.Admin {
+		if hs.Admin && !hs.ReadOnly {
 			if _, ok := auth.admins[lu]; !ok {
-				if err := conn.WriteMessage(websocket.CloseMessage, []byte("Not an admin user")); err != nil {
-					log.Printf("WriteMessage error: %v", err)
-				}
-				conn.Close()
+				rejectHandshake(conn, shared.CloseNotAdmin, "Not an admin user")
 				return
 			}
 			if hs.AdminKey != auth.adminKey {
 				// Send auth_failed message before closing
 				failMsg, _ := json.Marshal(map[string]string{"reason": "invalid admin key"})
-				if err := conn.WriteJSON(WSMessage{Type: "auth_failed", Data: failMsg}); err != nil {
+				if _, err := writeFrame(conn, WSMessage{Type: "auth_failed", Data: failMsg}, 0); err != nil {
 					log.Printf("WriteMessage error: %v", err)
 				}
 				conn.Close()
@@ -479,17 +774,29 @@ func ServeWs(hub *Hub, database Database, adminList []string, adminKey string, b
 			isAdmin = true
 		}
 
+		// A full server still lets admins in to deal with it
+		if !isAdmin && hub.Full() {
+			log.Printf("Refused '%s' (IP: %s) - server full", username, getClientIP(r))
+			rejectHandshake(conn, shared.CloseServerFull, "Server is full, try again later")
+			return
+		}
+
 		// Extract IP address
 		ipAddr := getClientIP(r)
 
-		// Check for duplicate username
-		for client := range hub.clients {
-			if strings.EqualFold(client.username, username) {
-				log.Printf("Duplicate username attempt: '%s' (IP: %s) - username already in use by IP: %s", username, ipAddr, client.ipAddr)
-				if err := conn.WriteMessage(websocket.CloseMessage, []byte("Username already taken - please choose a different username")); err != nil {
-					log.Printf("WriteMessage error: %v", err)
+		// Check for duplicate username (unless multi-device sessions are
+		// allowed); spectators never hold a name
+		for client := range hub.clients.all() {
+			if !hs.ReadOnly && !client.readOnly && strings.EqualFold(client.username, username) {
+				if hub.AllowsMultiSession() {
+					log.Printf("Additional session for '%s' (IP: %s) - existing session from IP: %s", username, ipAddr, client.ipAddr)
+					break
 				}
-				conn.Close()
+				log.Printf("Duplicate username attempt: '%s' (IP: %s) - username already in use by IP: %s", username, ipAddr, client.ipAddr)
+				rejectUsername(conn, &shared.UsernameRejection{
+					Code:   shared.UsernameTaken,
+					Reason: "Username already taken - please choose a different username",
+				})
 				return
 			}
 		}
@@ -497,10 +804,13 @@ func ServeWs(hub *Hub, database Database, adminList []string, adminKey string, b
 		// Check if user is banned
 		if hub.IsUserBanned(username) {
 			log.Printf("Banned user '%s' (IP: %s) attempted to connect", username, ipAddr)
-			if err := conn.WriteMessage(websocket.CloseMessage, []byte("You are banned from this server")); err != nil {
-				log.Printf("WriteMessage error: %v", err)
-			}
-			conn.Close()
+			rejectHandshake(conn, shared.CloseBanned, "You are banned from this server")
+			return
+		}
+
+		// Use up the invite only once every other check has passed
+		if hs.Invite != "" && !hub.RedeemInvite(hs.Invite, username) && needsInvite {
+			rejectHandshake(conn, shared.CloseNotAllowed, "Invite is invalid or has expired")
 			return
 		}
 
@@ -518,19 +828,64 @@ func ServeWs(hub *Hub, database Database, adminList []string, adminKey string, b
 			pluginCommandHandler: hub.pluginCommandHandler,
 			maxFileBytes:         maxFileBytes,
 			dbPath:               dbPath,
+			sessionID:            newSessionID(),
+			connectedAt:          time.Now(),
+			readOnly:             hs.ReadOnly,
+			userListDeltas:       hs.UserListDeltas,
+			compressMin:          hub.compressionThreshold,
+			serverURL: shared.ChatURL{
+				Host: r.Host,
+				TLS:  r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https"),
+			},
+		}
+		if isAdmin {
+			client.adminSigningKey = shared.AdminSigningKey(auth.adminKey)
 		}
-		log.Printf("Client %s connected (admin=%v, IP: %s)", username, isAdmin, ipAddr)
+		log.Printf("Client %s connected (admin=%v, read-only=%v, IP: %s, session: %s)", username, isAdmin, hs.ReadOnly, ipAddr, client.sessionID)
 		hub.register <- client
+		// Restore the display name saved in the client's config; an invalid
+		// or taken name is dropped rather than refusing the connection
+		if name := strings.TrimSpace(hs.DisplayName); name != "" && !hs.ReadOnly {
+			if err := hub.SetDisplayName(username, name); err != nil {
+				log.Printf("Ignoring display name from %s: %v", username, err)
+			}
+		}
+
+		// Users with saved history state have joined before
+		_, err = database.GetUserLastMessageID(strings.ToLower(username))
+		returning := err == nil
 
 		// Send personalized recent messages to new client
 		msgs, _ := database.GetRecentMessagesForUser(username, 50, banGapsHistory)
 		for _, msg := range msgs {
-			client.send <- msg
+			msg.Mentions = hub.historyMentions(msg)
+			client.enqueue(msg)
+		}
+		// Send open polls so late joiners can still vote
+		for _, poll := range hub.OpenPolls() {
+			client.enqueue(pollMessage(poll))
+		}
+		// Deliver reminders that came due while the user was offline
+		if !client.readOnly {
+			hub.DeliverDueReminders(client)
+			hub.welcomeNewUser(client, returning)
+		}
+		client.sendEmojiRegistry()
+		client.sendNotices()
+		client.enqueue(hub.versionMessage(hs.ClientTime))
+		client.enqueue(hub.limitsMessage())
+		hub.warnClientVersion(username, hs.ClientVersion)
+		if d := hub.SlowMode(); d > 0 {
+			client.enqueue(slowModeMessage(d))
 		}
 		hub.broadcastUserList()
 
 		// Start read/write pumps
 		go client.writePump()
-		go client.readPump()
+		hub.activePumps.Add(1)
+		go func() {
+			defer hub.activePumps.Add(-1)
+			client.readPump()
+		}()
 	}
 }
@@ -229,7 +229,7 @@ func TestSortMessagesByTimestamp(t *testing.T) {
 	}
 
 	// Sort the messages
-	sortMessagesByTimestamp(messages)
+	sortMessages(messages)
 
 	// Check order
 	if messages[0].Content != "First" {
@@ -253,7 +253,7 @@ func TestSortMessagesByTimestampWithSameTime(t *testing.T) {
 	}
 
 	// Sort the messages
-	sortMessagesByTimestamp(messages)
+	sortMessages(messages)
 
 	// With same timestamp, should sort by sender alphabetically
 	if messages[0].Sender != "user1" {
@@ -381,3 +381,48 @@ func TestGetDatabaseStats(t *testing.T) {
 		t.Errorf("Expected stats to contain 'Database Statistics:', got: %s", stats)
 	}
 }
+
+// FuzzHandshake feeds arbitrary first frames, as a hostile peer could send,
+// through the handshake decoding and checks ServeWs runs before it accepts
+// a connection, in both wire formats. None may panic, and any username
+// that gets through must be one validateUsername allows.
+func FuzzHandshake(f *testing.F) {
+	jsonCodec, cborCodec := shared.CodecFor(shared.SubprotocolJSON), shared.CodecFor(shared.SubprotocolCBOR)
+	for _, hs := range []shared.Handshake{
+		{Username: "alice", ClientVersion: "v1.0.0", UserListDeltas: true},
+		{Username: " Bob ", Admin: true, AdminKey: "key", ChallengeSolution: "12345", Passphrase: "letmein"},
+		{Username: "viewer", ReadOnly: true, Invite: "abc", ClientVersion: "v0.1.0-beta.1+build"},
+	} {
+		raw, _ := jsonCodec.Marshal(hs)
+		f.Add(raw)
+		raw, _ = cborCodec.Marshal(hs)
+		f.Add(raw)
+	}
+	f.Add([]byte(`{"username":"\u0000admin","client_version":"v99999999999999999999.0.0"}`))
+	f.Add([]byte(`{"username":["alice"]}`))
+	f.Add([]byte{0xa1, 0x68, 'u', 's', 'e', 'r', 'n', 'a', 'm', 'e', 0xf6})
+
+	hub := &Hub{}
+	hub.SetJoinChallenge(4, "letmein")
+	hub.SetMinClientVersion("v0.9.0")
+	hub.RequireMinClientVersion(true)
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		for _, codec := range []shared.Codec{jsonCodec, cborCodec} {
+			var hs shared.Handshake
+			if codec.Unmarshal(raw, &hs) != nil {
+				continue
+			}
+			_ = hub.checkJoinChallenge(hub.newJoinChallenge(), hs)
+			_ = hub.refusesClientVersion(hs.ClientVersion)
+			username := strings.TrimSpace(hs.Username)
+			for _, admin := range []bool{false, true} {
+				if hub.UsernamePolicy().Check(username, admin) != nil {
+					continue
+				}
+				if err := validateUsername(username); err != nil || len(username) > maxUsernameLength {
+					t.Fatalf("Accepted username %q: %v", username, err)
+				}
+			}
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -43,13 +42,16 @@ type SystemMetrics struct {
 	ActiveUsers    int     `json:"active_users"`
 	TotalMessages  int     `json:"total_messages"`
 	DatabaseStatus string  `json:"database_status"`
+
+	// Batched message writes and their flush latency, when enabled
+	WriteBehind *WriteBehindStats `json:"write_behind,omitempty"`
 }
 
 // HealthChecker manages health check functionality
 type HealthChecker struct {
 	startTime  time.Time
 	hub        *Hub
-	db         *sql.DB
+	db         Database
 	version    string
 	components map[string]*ComponentHealth
 	mutex      sync.RWMutex
@@ -60,7 +62,7 @@ func NewHealthChecker(hub *Hub, db Database, version string) *HealthChecker {
 	hc := &HealthChecker{
 		startTime:  time.Now(),
 		hub:        hub,
-		db:         db.GetDB(),
+		db:         db,
 		version:    version,
 		components: make(map[string]*ComponentHealth),
 	}
@@ -124,8 +126,7 @@ func (hc *HealthChecker) checkDatabaseHealth() *ComponentHealth {
 	start := time.Now()
 
 	// Test database connection with a simple query
-	var count int
-	err := hc.db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count)
+	_, err := hc.db.CountMessages()
 
 	responseTime := time.Since(start)
 
@@ -161,7 +162,7 @@ func (hc *HealthChecker) checkWebSocketHealth() *ComponentHealth {
 	}
 
 	// Check if hub is responsive
-	clientCount := len(hc.hub.clients)
+	clientCount := hc.hub.clients.len()
 	if clientCount >= 1000 { // Arbitrary limit
 		health.Status = HealthStatusDegraded
 		health.Message = fmt.Sprintf("High client count: %d", clientCount)
@@ -231,25 +232,29 @@ func (hc *HealthChecker) getSystemMetrics() SystemMetrics {
 
 	activeUsers := 0
 	if hc.hub != nil {
-		activeUsers = len(hc.hub.clients)
+		activeUsers = hc.hub.clients.len()
 	}
 
 	totalMessages := 0
 	if hc.db != nil {
-		_ = hc.db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&totalMessages)
+		totalMessages, _ = hc.db.CountMessages()
 	}
 
 	hc.mutex.RLock()
 	databaseStatus := hc.components["database"].Status.String()
 	hc.mutex.RUnlock()
 
-	return SystemMetrics{
+	metrics := SystemMetrics{
 		MemoryUsage:    float64(m.Alloc) / 1024 / 1024,
 		Goroutines:     runtime.NumGoroutine(),
 		ActiveUsers:    activeUsers,
 		TotalMessages:  totalMessages,
 		DatabaseStatus: databaseStatus,
 	}
+	if stats, ok := writeBehindStats(hc.db); ok {
+		metrics.WriteBehind = &stats
+	}
+	return metrics
 }
 
 // getComponentsMap returns a copy of the components map
@@ -309,3 +314,139 @@ func (hc *HealthChecker) SimpleHealthHandler(w http.ResponseWriter, r *http.Requ
 		_, _ = w.Write([]byte("UNHEALTHY"))
 	}
 }
+
+// readinessTimeout bounds each /readyz check, so a hung dependency reports
+// as not ready instead of hanging the probe
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck is the result of one dependency check in /readyz
+type ReadinessCheck struct {
+	Status    HealthStatus `json:"status"`
+	LatencyMS float64      `json:"latency_ms"`
+	Message   string       `json:"message,omitempty"`
+}
+
+// Readiness is the /readyz response: ready only when every check passes
+type Readiness struct {
+	Status    HealthStatus              `json:"status"`
+	Timestamp time.Time                 `json:"timestamp"`
+	Checks    map[string]ReadinessCheck `json:"checks"`
+}
+
+// Liveness is the /healthz response
+type Liveness struct {
+	Status  HealthStatus `json:"status"`
+	Version string       `json:"version"`
+	Uptime  string       `json:"uptime"`
+}
+
+// runReadinessCheck times check, failing it after readinessTimeout
+func runReadinessCheck(check func() error) ReadinessCheck {
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() { result <- check() }()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-time.After(readinessTimeout):
+		err = fmt.Errorf("no response after %v", readinessTimeout)
+	}
+	rc := ReadinessCheck{
+		Status:    HealthStatusHealthy,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		rc.Status = HealthStatusUnhealthy
+		rc.Message = err.Error()
+	}
+	return rc
+}
+
+// CheckReadiness checks that the server can serve chat: the database
+// answers, the hub's event loop is running and the plugin manager responds.
+// The checks run in parallel.
+func (hc *HealthChecker) CheckReadiness() *Readiness {
+	checks := map[string]func() error{
+		"database": func() error {
+			if hc.db == nil {
+				return fmt.Errorf("database not initialized")
+			}
+			return hc.db.Ping()
+		},
+		"hub": func() error {
+			if hc.hub == nil {
+				return fmt.Errorf("hub not initialized")
+			}
+			if hc.hub.Draining() {
+				return fmt.Errorf("draining for shutdown")
+			}
+			if !hc.hub.Responsive(readinessTimeout) {
+				return fmt.Errorf("hub event loop not responding")
+			}
+			return nil
+		},
+		"plugins": func() error {
+			if hc.hub == nil || hc.hub.pluginManager == nil {
+				return fmt.Errorf("plugin manager not initialized")
+			}
+			hc.hub.pluginManager.ListPlugins()
+			return nil
+		},
+	}
+
+	readiness := &Readiness{
+		Status:    HealthStatusHealthy,
+		Timestamp: time.Now(),
+		Checks:    make(map[string]ReadinessCheck, len(checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rc := runReadinessCheck(check)
+			mu.Lock()
+			readiness.Checks[name] = rc
+			if rc.Status != HealthStatusHealthy {
+				readiness.Status = HealthStatusUnhealthy
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return readiness
+}
+
+// LivenessHandler serves /healthz: the process is up and serving HTTP. It
+// checks no dependencies, so a slow database never gets the server restarted.
+func (hc *HealthChecker) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealthJSON(w, http.StatusOK, Liveness{
+		Status:  HealthStatusHealthy,
+		Version: hc.version,
+		Uptime:  time.Since(hc.startTime).Round(time.Second).String(),
+	})
+}
+
+// ReadinessHandler serves /readyz: 200 when every dependency check passes,
+// 503 otherwise, with each check's status and latency
+func (hc *HealthChecker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	readiness := hc.CheckReadiness()
+	code := http.StatusOK
+	if readiness.Status != HealthStatusHealthy {
+		code = http.StatusServiceUnavailable
+	}
+	writeHealthJSON(w, code, readiness)
+}
+
+func writeHealthJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		ServerLogger.Error("Failed to encode health response", err)
+	}
+}
@@ -394,3 +394,72 @@ func TestHealthChecker_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestHealthChecker_LivenessHandler(t *testing.T) {
+	hc, _, cleanup := setupTestHealthChecker(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	hc.LivenessHandler(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	var live Liveness
+	if err := json.Unmarshal(w.Body.Bytes(), &live); err != nil || live.Status != HealthStatusHealthy {
+		t.Errorf("Unexpected liveness response %q (%v)", w.Body.String(), err)
+	}
+}
+
+func TestHealthChecker_ReadinessHandler(t *testing.T) {
+	hc, db, cleanup := setupTestHealthChecker(t)
+	defer cleanup()
+
+	w := httptest.NewRecorder()
+	hc.ReadinessHandler(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON, got %q", ct)
+	}
+	var ready Readiness
+	if err := json.Unmarshal(w.Body.Bytes(), &ready); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"database", "hub", "plugins"} {
+		check, ok := ready.Checks[name]
+		if !ok || check.Status != HealthStatusHealthy || check.LatencyMS < 0 {
+			t.Errorf("Expected a passing %s check, got %+v", name, check)
+		}
+	}
+
+	// A closed database makes the server not ready
+	_ = db.Close()
+	w = httptest.NewRecorder()
+	hc.ReadinessHandler(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 with the database closed, got %d", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &ready); err != nil || ready.Checks["database"].Status != HealthStatusUnhealthy {
+		t.Errorf("Expected the database check to fail, got %s", w.Body.String())
+	}
+}
+
+func TestHealthChecker_ReadinessHubStopped(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the readiness timeout")
+	}
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	// The hub's event loop is never started
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	hc := NewHealthChecker(hub, db, "test-version")
+
+	ready := hc.CheckReadiness()
+	if ready.Status != HealthStatusUnhealthy || ready.Checks["hub"].Status != HealthStatusUnhealthy {
+		t.Errorf("Expected a stopped hub to fail readiness, got %+v", ready)
+	}
+	if ready.Checks["database"].Status != HealthStatusHealthy {
+		t.Errorf("Expected the database check to pass, got %+v", ready.Checks["database"])
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// builtinCommands are the commands handleCommand implements itself
+var builtinCommands = []shared.CommandInfo{
+	{Name: ":help", Usage: ":help", Description: "List the commands you can run"},
+	{Name: ":sessions", Usage: ":sessions [revoke <id>|others]", Description: "List or end your own sessions"},
+	{Name: ":poll", Usage: `:poll "Question" "A" "B" | :poll list | :poll close <id>`, Description: "Start, list or close a poll"},
+	{Name: ":vote", Usage: ":vote <poll id> <option number>", Description: "Vote in a poll"},
+	{Name: ":schedule", Usage: ":schedule <delay|HH:MM> <message>", Description: "Send a message later"},
+	{Name: ":scheduled", Usage: ":scheduled [cancel <id>]", Description: "List or cancel your scheduled messages"},
+	{Name: ":remind", Usage: ":remind [@user|me] <delay|HH:MM> <text>", Description: "Set a reminder for yourself or someone else"},
+	{Name: ":reminders", Usage: ":reminders [cancel <id>]", Description: "List or cancel your reminders"},
+	{Name: ":nick", Usage: ":nick [name]", Description: "Set or clear your display name"},
+	{Name: ":topic", Usage: ":topic", Description: "Show the channel topic"},
+	{Name: ":motd", Usage: ":motd", Description: "Show the message of the day"},
+	{Name: ":notes", Usage: ":notes | :notes edit | :notes done", Description: "Open, edit or stop editing the channel's shared notes"},
+	{Name: ":group", Usage: ":group list | :group show <name>", Description: "List mention groups such as @admins"},
+	{Name: ":emoji", Usage: ":emoji list", Description: "List custom emoji"},
+	{Name: ":stats", Usage: ":stats me | :stats channel", Description: "Show your or the channel's activity over the past week"},
+	{Name: ":email", Usage: ":email | :email set <address> | :email off", Description: "Get an email of the mentions you miss while offline"},
+	{Name: ":emoji", Usage: ":emoji add <shortcode> <glyph> [image.png] | :emoji remove <shortcode>", Description: "Add or remove custom emoji", AdminOnly: true},
+	{Name: ":kick", Usage: ":kick <username>", Description: "Disconnect a user for 24 hours", AdminOnly: true},
+	{Name: ":ban", Usage: ":ban <username>", Description: "Ban a user until unbanned", AdminOnly: true},
+	{Name: ":unban", Usage: ":unban <username>", Description: "Lift a ban", AdminOnly: true},
+	{Name: ":allow", Usage: ":allow <username>", Description: "Lift a kick early", AdminOnly: true},
+	{Name: ":forcedisconnect", Usage: ":forcedisconnect <username>", Description: "Drop all of a user's connections", AdminOnly: true},
+	{Name: ":mute", Usage: ":mute <username> [duration]", Description: "Shadow-mute a user", AdminOnly: true},
+	{Name: ":unmute", Usage: ":unmute <username>", Description: "Lift a mute", AdminOnly: true},
+	{Name: ":slowmode", Usage: ":slowmode <duration|off>", Description: "Limit how often users can post", AdminOnly: true},
+	{Name: ":filter", Usage: ":filter list | :filter add [block] <word|/regex/> | :filter remove <id>", Description: "Manage the word filter", AdminOnly: true},
+	{Name: ":invite", Usage: ":invite create [duration] | :invite list | :invite revoke <token>", Description: "Manage invite links", AdminOnly: true},
+	{Name: ":announce", Usage: ":announce <text>", Description: "Broadcast an announcement banner", AdminOnly: true},
+	{Name: ":cron", Usage: ":cron list | :cron add [announce] <min hour day month weekday|@daily> <message> | :cron remove|pause|resume|run <id>", Description: "Post a message or announcement on a recurring schedule", AdminOnly: true},
+	{Name: ":topic", Usage: ":topic set <text> | :topic clear", Description: "Change the channel topic", AdminOnly: true},
+	{Name: ":motd", Usage: ":motd set <text> | :motd clear", Description: "Change the message of the day shown on connect", AdminOnly: true},
+	{Name: ":group", Usage: ":group create <name> [admins-only] | :group delete <name> | :group add|remove <name> <users...>", Description: "Manage mention groups", AdminOnly: true},
+	{Name: ":cleanup", Usage: ":cleanup", Description: "Remove stale connections", AdminOnly: true},
+	{Name: ":cleardb", Usage: ":cleardb", Description: "Delete all messages", AdminOnly: true},
+	{Name: ":backup", Usage: ":backup", Description: "Back up the database", AdminOnly: true},
+	{Name: ":stats", Usage: ":stats", Description: "Show database statistics", AdminOnly: true},
+}
+
+// availableCommands lists the commands this client's user may run:
+// built-in commands first, then plugin commands by name
+func (c *Client) availableCommands() []shared.CommandInfo {
+	var commands []shared.CommandInfo
+	for _, cmd := range builtinCommands {
+		if !cmd.AdminOnly || c.isAdmin {
+			commands = append(commands, cmd)
+		}
+	}
+	if c.pluginCommandHandler != nil {
+		commands = append(commands, c.pluginCommandHandler.Commands(c.isAdmin)...)
+	}
+	return commands
+}
+
+// handleHelpCommand sends the client its available commands as a
+// "commands" message, which the client shows in its help overlay
+func (c *Client) handleHelpCommand() {
+	payload, err := json.Marshal(c.availableCommands())
+	if err != nil {
+		c.reply("Failed to list commands: " + err.Error())
+		return
+	}
+	c.enqueue(WSMessage{Type: "commands", Data: payload})
+}
+
+// sortCommands orders commands by name, keeping the given order for
+// commands with the same name
+func sortCommands(commands []shared.CommandInfo) {
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/plugin/manager"
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestHelpCommandListsCommandsByRole(t *testing.T) {
+	commandsFor := func(admin bool) map[string]shared.CommandInfo {
+		t.Helper()
+		c := &Client{
+			username:             "alice",
+			isAdmin:              admin,
+			send:                 make(chan interface{}, 1),
+			pluginCommandHandler: NewPluginCommandHandler(manager.NewPluginManager(t.TempDir(), t.TempDir(), "")),
+		}
+		c.handleCommand(":help")
+		ws, ok := (<-c.send).(WSMessage)
+		if !ok || ws.Type != "commands" {
+			t.Fatalf("Expected a commands message, got %+v", ws)
+		}
+		var list []shared.CommandInfo
+		if err := json.Unmarshal(ws.Data, &list); err != nil {
+			t.Fatalf("Failed to decode commands: %v", err)
+		}
+		byUsage := make(map[string]shared.CommandInfo)
+		for _, cmd := range list {
+			if cmd.Usage == "" || cmd.Description == "" {
+				t.Errorf("Command %s has no usage or description", cmd.Name)
+			}
+			byUsage[cmd.Usage] = cmd
+		}
+		return byUsage
+	}
+
+	user := commandsFor(false)
+	for _, usage := range []string{":nick [name]", ":emoji list", ":list"} {
+		if _, ok := user[usage]; !ok {
+			t.Errorf("Users should see %q", usage)
+		}
+	}
+	for usage, cmd := range user {
+		if cmd.AdminOnly {
+			t.Errorf("Users should not see admin command %q", usage)
+		}
+	}
+
+	admin := commandsFor(true)
+	for _, usage := range []string{":ban <username>", ":install <plugin-name> [--os <goos>] [--arch <goarch>]", ":nick [name]"} {
+		if _, ok := admin[usage]; !ok {
+			t.Errorf("Admins should see %q", usage)
+		}
+	}
+}
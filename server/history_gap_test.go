@@ -0,0 +1,48 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestHideBanPeriods(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return base.Add(time.Duration(min) * time.Minute) }
+	unbanned := at(25)
+	bans := []BanPeriod{
+		{BannedAt: at(5), UnbannedAt: &unbanned},
+		{BannedAt: at(45)}, // still banned
+	}
+	var messages []shared.Message
+	for _, min := range []int{0, 10, 20, 30, 50} {
+		messages = append(messages, shared.Message{Sender: "alice", Content: "hi", CreatedAt: at(min)})
+	}
+
+	got := hideBanPeriods(messages, bans)
+	if len(got) != 4 {
+		t.Fatalf("got %d messages, want 4: %+v", len(got), got)
+	}
+	if !got[0].CreatedAt.Equal(at(0)) || !got[2].CreatedAt.Equal(at(30)) {
+		t.Errorf("visible messages = %v, %v; want 12:00 and 12:30", got[0].CreatedAt, got[2].CreatedAt)
+	}
+
+	gap := got[1]
+	if gap.Type != shared.GapMessageType || gap.Gap == nil {
+		t.Fatalf("got[1] = %+v, want a gap marker", gap)
+	}
+	if gap.Gap.Hidden != 2 || !gap.Gap.From.Equal(at(10)) || !gap.Gap.To.Equal(at(20)) {
+		t.Errorf("gap = %+v, want 2 hidden from 12:10 to 12:20", gap.Gap)
+	}
+	if gap.Content != "2 messages hidden by moderation" || !gap.CreatedAt.Equal(at(10)) {
+		t.Errorf("gap marker = %q at %v", gap.Content, gap.CreatedAt)
+	}
+	if got[3].Gap == nil || got[3].Gap.Hidden != 1 || got[3].Content != "1 message hidden by moderation" {
+		t.Errorf("got[3] = %+v, want a marker for the one message sent while still banned", got[3])
+	}
+
+	if got := hideBanPeriods(messages[:1], bans); len(got) != 1 || got[0].Gap != nil {
+		t.Errorf("nothing to hide: got %+v", got)
+	}
+}
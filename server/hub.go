@@ -2,8 +2,11 @@ package server
 
 import (
 	"log"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Cod-e-Codes/marchat/plugin/manager"
@@ -12,14 +15,16 @@ import (
 )
 
 type Hub struct {
-	clients    map[*Client]bool
-	broadcast  chan interface{}
+	clients    *clientSet
+	broadcast  chan interface{} // frames for every client; requests to the hub have their own channels
+	direct     chan directMessage
 	register   chan *Client
 	unregister chan *Client
 
 	// Ban management
 	bans      map[string]time.Time // username -> expiry time (permanent bans use far future time)
 	tempKicks map[string]time.Time // username -> kick expiry time (24h temporary)
+	mutes     map[string]time.Time // username -> shadow mute expiry (:mute)
 	banMutex  sync.RWMutex
 
 	// Metrics tracking
@@ -33,22 +38,170 @@ type Hub struct {
 
 	// Database reference for message state management
 	db Database
+
+	// Allow the same username to connect from several devices at once
+	allowMultiSession bool
+	allowSpectators   bool
+
+	// Reject :figlet/:cowsay art messages (for serious deployments)
+	artDisabled bool
+
+	// Largest message content accepted, see SetMaxMessageBytes
+	maxMessageBytes int
+
+	// Usernames accepted at handshake, see SetUsernamePolicy
+	usernamePolicy *UsernamePolicy
+
+	// permessage-deflate: flate level (0 = off) and smallest frame compressed
+	compressionLevel     int
+	compressionThreshold int
+
+	// Single-use invites (:invite) and the users admitted with one
+	invites      map[string]Invite
+	invitedUsers map[string]bool
+	inviteMutex  sync.Mutex
+
+	// Join challenge for public servers: proof-of-work difficulty and shared passphrase
+	joinBits       int
+	joinPassphrase string
+
+	// Display names set with :nick, keyed by lowercase username, and every
+	// lowercase username known to have posted or connected
+	displayNames map[string]string
+	knownUsers   map[string]bool
+	namesMutex   sync.RWMutex
+	nickChanges  chan nickChange // checked against the connected clients in Run
+
+	// The user list last sent, which userlist_delta frames are relative to,
+	// and requests for a full resync, which Run serves
+	userList      UserList
+	userListMu    sync.Mutex
+	userListSyncs chan userListSync
+
+	// Minimum interval between posts by non-admins (:slowmode)
+	slowMode *slowMode
+
+	// Numbers room messages in the order they are accepted
+	seq *sequencer
+
+	usage   *usageStats      // per-user and per-channel activity for the admin panels
+	history *metricsRecorder // minute and hour metrics saved to the database
+
+	// Word/regex blocklist applied to chat messages (:filter)
+	filters *contentFilter
+
+	// Greeting sent to users on their first join, editable by admins
+	welcome *welcomeBot
+
+	// Channel topics and the message of the day (:topic, :motd)
+	notices *noticeBoard
+
+	// Shared notes per channel and who is editing them (:notes)
+	sharedNotes *notesBoard
+
+	// Admin-managed groups for @mentions (:group), and the lookups of who a
+	// mention reaches, which Run answers from the connected clients
+	groups         *mentionGroupCache
+	mentionLookups chan mentionLookup
+
+	// How often one user may mention the same user or group
+	mentionThrottle *mentionThrottle
+
+	// Fetches link previews for allowlisted domains (nil = off)
+	previews *unfurler
+
+	// File types users may send and the clamd scanner files go through
+	// (nil = any type, no scanning), see SetFilePolicy
+	fileTypes    *fileTypePolicy
+	virusScanner *clamdScanner
+
+	// In-memory polls created with :poll
+	polls *pollManager
+
+	// Messages queued with :schedule
+	scheduler *messageScheduler
+
+	// Timers for reminders stored in the database
+	reminders *reminderScheduler
+
+	// Timers for the next run of each cron job
+	cron *cronScheduler
+
+	// Emails offline users their mentions (nil = off), see SetEmailNotifications
+	email *emailNotifier
+
+	// Nonces of recently accepted admin commands, for replay protection
+	adminNonces *nonceCache
+
+	// Oldest client version supported; older ones are warned, or refused
+	// when requireMinClientVersion is set (empty = any)
+	minClientVersion        string
+	requireMinClientVersion bool
+
+	// Most connections at once, admins aside (0 = no limit)
+	maxConnections int
+
+	// Presence: mark users away and disconnect them after this long without
+	// sending anything (0 = never), see SetIdlePolicy. Checks run on the hub
+	// goroutine, which Run feeds them to from idleChecks.
+	awayAfter   time.Duration
+	idleTimeout time.Duration
+	idleChecks  chan idleCheck
+
+	// Readiness probes: Run closes each channel it receives, see Responsive
+	probe chan chan struct{}
+
+	// Graceful shutdown, see Drain: Run answers with the connected clients
+	drain       chan chan []*Client
+	draining    atomic.Bool
+	activePumps atomic.Int64 // read loops still running
 }
 
 func NewHub(pluginDir, dataDir, registryURL string, db Database) *Hub {
 	pluginManager := manager.NewPluginManager(pluginDir, dataDir, registryURL)
 	pluginCommandHandler := NewPluginCommandHandler(pluginManager)
+	latest := func() int64 { return 0 }
+	if db != nil {
+		latest = func() int64 { return latestSeq(db) }
+	}
 
 	return &Hub{
-		clients:              make(map[*Client]bool),
+		clients:              newClientSet(),
 		broadcast:            make(chan interface{}),
+		direct:               make(chan directMessage),
 		register:             make(chan *Client),
 		unregister:           make(chan *Client),
 		bans:                 make(map[string]time.Time),
 		tempKicks:            make(map[string]time.Time),
+		mutes:                make(map[string]time.Time),
+		invites:              make(map[string]Invite),
+		invitedUsers:         make(map[string]bool),
+		displayNames:         make(map[string]string),
+		knownUsers:           make(map[string]bool),
 		pluginManager:        pluginManager,
 		pluginCommandHandler: pluginCommandHandler,
 		db:                   db,
+		slowMode:             newSlowMode(),
+		seq:                  newSequencer(latest),
+		usage:                newUsageStats(),
+		history:              &metricsRecorder{},
+		filters:              newContentFilter(),
+		welcome:              &welcomeBot{},
+		notices:              newNoticeBoard(),
+		sharedNotes:          newNotesBoard(),
+		groups:               newMentionGroupCache(),
+		mentionThrottle:      newMentionThrottle(),
+		polls:                newPollManager(),
+		scheduler:            newMessageScheduler(),
+		reminders:            newReminderScheduler(),
+		cron:                 newCronScheduler(),
+		adminNonces:          newNonceCache(),
+		mentionLookups:       make(chan mentionLookup),
+		nickChanges:          make(chan nickChange),
+		userListSyncs:        make(chan userListSync),
+		idleChecks:           make(chan idleCheck),
+		probe:                make(chan chan struct{}),
+		drain:                make(chan chan []*Client),
 	}
 }
 
@@ -125,6 +278,31 @@ func (h *Hub) UnbanUser(username string, adminUsername string) bool {
 	return false
 }
 
+// BannedUsers lists the permanently banned usernames (lowercase)
+func (h *Hub) BannedUsers() []string {
+	h.banMutex.RLock()
+	defer h.banMutex.RUnlock()
+	users := make([]string, 0, len(h.bans))
+	for username := range h.bans {
+		users = append(users, username)
+	}
+	return users
+}
+
+// KickedUsers lists the usernames (lowercase) whose 24h kick has not expired
+func (h *Hub) KickedUsers() []string {
+	h.banMutex.RLock()
+	defer h.banMutex.RUnlock()
+	now := time.Now()
+	users := make([]string, 0, len(h.tempKicks))
+	for username, until := range h.tempKicks {
+		if now.Before(until) {
+			users = append(users, username)
+		}
+	}
+	return users
+}
+
 // IsUserBanned checks if a user is currently banned or kicked
 func (h *Hub) IsUserBanned(username string) bool {
 	h.banMutex.RLock()
@@ -149,10 +327,84 @@ func (h *Hub) IsUserBanned(username string) bool {
 	return false
 }
 
-// kickUser forcibly disconnects a user by username
+// SetAllowMultiSession enables or disables multiple concurrent sessions per username
+func (h *Hub) SetAllowMultiSession(allow bool) {
+	h.allowMultiSession = allow
+}
+
+// AllowsMultiSession reports whether a username may be connected from several devices
+func (h *Hub) AllowsMultiSession() bool {
+	return h.allowMultiSession
+}
+
+// SetAllowSpectators enables or disables read-only spectator connections
+func (h *Hub) SetAllowSpectators(allow bool) {
+	h.allowSpectators = allow
+}
+
+// AllowsSpectators reports whether read-only connections are accepted
+func (h *Hub) AllowsSpectators() bool {
+	return h.allowSpectators
+}
+
+// SetMaxConnections caps how many connections the server holds at once;
+// 0 or less means no limit. Admins can always connect.
+func (h *Hub) SetMaxConnections(n int) {
+	h.maxConnections = max(n, 0)
+}
+
+// Full reports whether the connection limit has been reached
+func (h *Hub) Full() bool {
+	return h.maxConnections > 0 && h.activePumps.Load() >= int64(h.maxConnections)
+}
+
+// SetArtEnabled enables or disables ASCII art messages (:figlet, :cowsay)
+func (h *Hub) SetArtEnabled(enabled bool) {
+	h.artDisabled = !enabled
+}
+
+// ArtEnabled reports whether clients may post ASCII art messages
+func (h *Hub) ArtEnabled() bool {
+	return !h.artDisabled
+}
+
+// GetUserSessions returns all active sessions for a username, oldest first
+func (h *Hub) GetUserSessions(username string) []*Client {
+	var sessions []*Client
+	for client := range h.clients.all() {
+		if strings.EqualFold(client.username, username) {
+			sessions = append(sessions, client)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].connectedAt.Before(sessions[j].connectedAt)
+	})
+	return sessions
+}
+
+// RevokeSession disconnects a single session belonging to username with
+// shared.CloseSessionRevoked
+func (h *Hub) RevokeSession(username string, sessionID string) bool {
+	for client := range h.clients.all() {
+		if strings.EqualFold(client.username, username) && client.sessionID == sessionID {
+			log.Printf("[SESSION] Revoking session %s for user '%s' (IP: %s)", sessionID, username, client.ipAddr)
+
+			// writePump sends the close after anything already queued; the
+			// client treats the code as final instead of reconnecting
+			client.closeSendWith(websocket.FormatCloseMessage(shared.CloseSessionRevoked, "This session was revoked from another device"))
+			return true
+		}
+	}
+	log.Printf("[SESSION] Revoke attempt for session %s of '%s' - session not found", sessionID, username)
+	return false
+}
+
+// kickUser forcibly disconnects every session of a user by username
 func (h *Hub) kickUser(username string, reason string) {
-	for client := range h.clients {
+	found := false
+	for client := range h.clients.all() {
 		if strings.EqualFold(client.username, username) {
+			found = true
 			log.Printf("[ADMIN] Kicking user '%s' (IP: %s) - Reason: %s", username, client.ipAddr, reason)
 
 			// Send kick message to the user
@@ -162,14 +414,15 @@ func (h *Hub) kickUser(username string, reason string) {
 				CreatedAt: time.Now(),
 				Type:      shared.TextMessage,
 			}
-			client.send <- kickMsg
+			client.enqueue(kickMsg)
 
 			// Close the connection
 			client.conn.Close()
-			return
 		}
 	}
-	log.Printf("[ADMIN] Kick attempt for '%s' - user not found", username)
+	if !found {
+		log.Printf("[ADMIN] Kick attempt for '%s' - user not found", username)
+	}
 }
 
 // KickUser temporarily bans a user for 24 hours
@@ -241,7 +494,7 @@ func (h *Hub) AllowUser(username string, adminUsername string) bool {
 	return false
 }
 
-// CleanupExpiredBans removes expired bans and kicks from the lists
+// CleanupExpiredBans removes expired bans, kicks and mutes from the lists
 func (h *Hub) CleanupExpiredBans() {
 	h.banMutex.Lock()
 	defer h.banMutex.Unlock()
@@ -263,6 +516,16 @@ func (h *Hub) CleanupExpiredBans() {
 			log.Printf("[SYSTEM] Expired kick removed for user: %s", username)
 		}
 	}
+
+	// Clean up expired shadow mutes
+	for username, muteTime := range h.mutes {
+		if now.After(muteTime) {
+			delete(h.mutes, username)
+			log.Printf("[SYSTEM] Expired mute removed for user: %s", username)
+		}
+	}
+
+	h.cleanupExpiredInvites()
 }
 
 // CleanupStaleConnections removes clients with broken connections
@@ -270,7 +533,7 @@ func (h *Hub) CleanupStaleConnections() {
 	var staleClients []*Client
 
 	// Check all clients for broken connections
-	for client := range h.clients {
+	for client := range h.clients.all() {
 		// Try to ping the client to check if connection is alive
 		if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 			log.Printf("[CLEANUP] Found stale connection for user '%s' (IP: %s): %v", client.username, client.ipAddr, err)
@@ -281,8 +544,9 @@ func (h *Hub) CleanupStaleConnections() {
 	// Remove stale clients
 	for _, client := range staleClients {
 		log.Printf("[CLEANUP] Removing stale connection for user '%s' (IP: %s)", client.username, client.ipAddr)
-		delete(h.clients, client)
-		close(client.send)
+		if h.clients.remove(client) {
+			client.closeSend()
+		}
 		client.conn.Close()
 	}
 
@@ -292,23 +556,27 @@ func (h *Hub) CleanupStaleConnections() {
 	}
 }
 
-// ForceDisconnectUser forcibly removes a user from the clients map (admin command for stale connections)
+// ForceDisconnectUser forcibly removes all of a user's sessions from the clients map (admin command for stale connections)
 func (h *Hub) ForceDisconnectUser(username string, adminUsername string) bool {
-	for client := range h.clients {
+	found := false
+	for client := range h.clients.all() {
 		if strings.EqualFold(client.username, username) {
+			found = true
 			log.Printf("[ADMIN] Force disconnecting user '%s' (IP: %s) by admin '%s'", username, client.ipAddr, adminUsername)
 
 			// Try to close gracefully first
 			client.conn.Close()
 
 			// Remove from clients map
-			delete(h.clients, client)
-			close(client.send)
-
-			h.broadcastUserList()
-			return true
+			if h.clients.remove(client) {
+				client.closeSend()
+			}
 		}
 	}
+	if found {
+		h.broadcastUserList()
+		return true
+	}
 	log.Printf("[ADMIN] Force disconnect attempt for '%s' by '%s' - user not found", username, adminUsername)
 	return false
 }
@@ -318,6 +586,19 @@ func (h *Hub) Run() {
 		"plugin_manager": h.pluginManager != nil,
 	})
 
+	// Re-arm reminders and scheduled messages persisted before a restart
+	h.LoadReminders()
+	h.LoadScheduledMessages()
+	h.LoadCronJobs()
+	h.ReloadFilters()
+	h.ReloadWelcome()
+	h.ReloadNotices()
+	h.ReloadMentionGroups()
+	h.ReloadKnownUsers()
+	h.startMetricsHistory()
+	h.startIdleChecks()
+	h.startUserListSync()
+
 	// Start ban cleanup goroutine
 	go func() {
 		ticker := time.NewTicker(1 * time.Hour) // Clean up every hour
@@ -348,22 +629,18 @@ func (h *Hub) Run() {
 			}
 
 			// Broadcast plugin message to all clients
-			for client := range h.clients {
-				select {
-				case client.send <- sharedMsg:
-				default:
-					log.Printf("Dropping client %s due to full send channel\n", client.username)
-					close(client.send)
-					delete(h.clients, client)
-				}
-			}
+			h.broadcast <- sharedMsg
 		}
 	}()
 
 	for {
 		select {
 		case client := <-h.register:
-			h.clients[client] = true
+			h.clients.add(client)
+			h.usage.connected(client)
+			if !client.readOnly {
+				h.rememberUser(client.username)
+			}
 			HubLogger.Info("Client registered", map[string]interface{}{
 				"username": client.username,
 				"ip":       client.ipAddr,
@@ -376,9 +653,9 @@ func (h *Hub) Run() {
 
 			h.broadcastUserList() // Broadcast after register
 		case client := <-h.unregister:
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
+			h.usage.disconnected(client)
+			if h.clients.remove(client) {
+				client.closeSend()
 				HubLogger.Info("Client unregistered", map[string]interface{}{
 					"username": client.username,
 					"ip":       client.ipAddr,
@@ -390,18 +667,101 @@ func (h *Hub) Run() {
 				h.metricsMutex.Unlock()
 			}
 			h.broadcastUserList()
-		case message := <-h.broadcast:
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					log.Printf("Dropping client %s due to full send channel\n", client.username)
-					close(client.send)
-					delete(h.clients, client)
+		case done := <-h.probe:
+			close(done)
+		case reply := <-h.drain:
+			reply <- slices.Collect(h.clients.all())
+		case dm := <-h.direct:
+			delivered := false
+			for client := range h.clients.all() {
+				if !client.readOnly && strings.EqualFold(client.username, dm.username) {
+					if client.trySend(dm.msg) {
+						delivered = true
+					} else {
+						log.Printf("Could not deliver direct message to %s: send channel full", client.username)
+					}
 				}
 			}
+			if dm.delivered != nil {
+				dm.delivered <- delivered
+			}
+		case change := <-h.nickChanges:
+			change.result <- h.applyNickChange(change)
+		case check := <-h.idleChecks:
+			h.applyIdleCheck(check.now)
+		case <-h.userListSyncs:
+			h.sendUserList(true)
+		case lookup := <-h.mentionLookups:
+			lookup.result <- h.applyMentionLookup(lookup)
+		case message := <-h.broadcast:
+			h.deliver(message)
+		}
+	}
+}
+
+// deliver sends message to every client, dropping clients whose send
+// channel is full. Chat messages not yet numbered are numbered here. It
+// runs on the hub goroutine; elsewhere, send to h.broadcast.
+func (h *Hub) deliver(message interface{}) {
+	if msg, ok := message.(shared.Message); ok && msg.Seq == 0 {
+		h.stamp(&msg)
+		message = msg
+	}
+	h.clients.fanOut(func(client *Client) {
+		if !client.trySend(message) {
+			log.Printf("Dropping client %s due to full send channel\n", client.username)
+			if h.clients.remove(client) {
+				client.closeSend()
+			}
+		}
+	})
+}
+
+// acceptMessage timestamps, numbers, stores and broadcasts a chat message,
+// resolving the @mentions in plain text for the highlights, previews and
+// offline emails that follow. Live messages and scheduled ones both come
+// through here once the sender's checks have passed.
+func (h *Hub) acceptMessage(msg shared.Message, senderAdmin bool) error {
+	msg.CreatedAt = time.Now()
+	text := msg.Type == "" || msg.Type == shared.TextMessage
+	if text && !msg.Encrypted {
+		mentions, err := h.resolveMentions(msg.Sender, senderAdmin, msg.Content)
+		if err != nil {
+			return err
+		}
+		msg.Mentions = mentions
+	}
+	h.stamp(&msg)
+	if text && h.db != nil {
+		if err := h.db.InsertMessage(msg); err != nil {
+			log.Printf("Failed to insert message: %v", err)
 		}
 	}
+	h.broadcast <- msg
+	if text {
+		h.unfurlLater(msg)
+		h.emailMentions(msg)
+	}
+	return nil
+}
+
+// Responsive reports whether the hub's event loop answers within timeout.
+// A stalled loop accepts connections that then never register.
+func (h *Hub) Responsive(timeout time.Duration) bool {
+	done := make(chan struct{})
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case h.probe <- done:
+	case <-timer.C:
+		return false
+	}
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
 }
 
 // GetPluginManager returns the plugin manager reference
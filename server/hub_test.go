@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -370,3 +371,52 @@ func TestHubConcurrentBanOperations(t *testing.T) {
 		t.Error("User should not be banned after concurrent operations")
 	}
 }
+
+func TestHubUserSessions(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+
+	hub := NewHub("./plugins", "./data", "http://registry.example.com", db)
+	hub.SetAllowMultiSession(true)
+	if !hub.AllowsMultiSession() {
+		t.Fatal("Multi-session should be enabled")
+	}
+
+	now := time.Now()
+	laptop := &Client{username: "alice", sessionID: "aaaa1111", connectedAt: now, send: make(chan interface{}, 10)}
+	phone := &Client{username: "Alice", sessionID: "bbbb2222", connectedAt: now.Add(time.Second), send: make(chan interface{}, 10)}
+	other := &Client{username: "bob", sessionID: "cccc3333", connectedAt: now, send: make(chan interface{}, 10)}
+	hub.clients.add(phone)
+	hub.clients.add(laptop)
+	hub.clients.add(other)
+
+	sessions := hub.GetUserSessions("ALICE")
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions for alice, got %d", len(sessions))
+	}
+	if sessions[0] != laptop || sessions[1] != phone {
+		t.Error("Sessions should be ordered oldest first")
+	}
+
+	// Sessions can only be revoked by their owner
+	if hub.RevokeSession("bob", "aaaa1111") {
+		t.Error("RevokeSession should not revoke another user's session")
+	}
+	if hub.RevokeSession("alice", "missing") {
+		t.Error("RevokeSession should return false for unknown session")
+	}
+
+	// The user list shows each username once
+	hub.broadcastUserList()
+	msg, ok := (<-other.send).(WSMessage)
+	if !ok || msg.Type != "userlist" {
+		t.Fatalf("Expected userlist message, got %v", msg)
+	}
+	var list UserList
+	if err := json.Unmarshal(msg.Data, &list); err != nil {
+		t.Fatalf("Failed to decode user list: %v", err)
+	}
+	if len(list.Users) != 2 {
+		t.Errorf("Expected 2 unique users, got %v", list.Users)
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// idleCheckInterval is how often presence and idle timeouts are checked
+const idleCheckInterval = 30 * time.Second
+
+// idleCheck asks the hub goroutine to update presence: users who have sent
+// nothing for a while are marked away, or disconnected once past the idle
+// timeout
+type idleCheck struct {
+	now time.Time
+}
+
+// SetIdlePolicy marks users away after awayAfter without sending anything
+// and disconnects them after idleTimeout; 0 turns either off. Keepalive
+// pings don't count as activity, and spectators are never disconnected.
+func (h *Hub) SetIdlePolicy(awayAfter, idleTimeout time.Duration) {
+	h.awayAfter = max(awayAfter, 0)
+	h.idleTimeout = max(idleTimeout, 0)
+}
+
+// startIdleChecks runs the idle checks when a policy is set
+func (h *Hub) startIdleChecks() {
+	if h.awayAfter == 0 && h.idleTimeout == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(idleCheckInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			h.idleChecks <- idleCheck{now: now}
+		}
+	}()
+}
+
+// idleSince is when the client last sent a frame, or connected
+func (c *Client) idleSince() time.Time {
+	if n := c.lastActive.Load(); n != 0 {
+		return time.Unix(0, n)
+	}
+	return c.connectedAt
+}
+
+// touch records activity from the client, bringing it back from away
+func (c *Client) touch(now time.Time) {
+	c.lastActive.Store(now.UnixNano())
+	if c.away.Load() {
+		c.hub.idleChecks <- idleCheck{now: now}
+	}
+}
+
+// applyIdleCheck runs on the hub goroutine
+func (h *Hub) applyIdleCheck(now time.Time) {
+	changed := false
+	for client := range h.clients.all() {
+		idle := now.Sub(client.idleSince())
+		if h.idleTimeout > 0 && idle >= h.idleTimeout && !client.readOnly {
+			h.disconnectIdle(client, idle)
+			continue
+		}
+		away := h.awayAfter > 0 && idle >= h.awayAfter
+		if client.away.Swap(away) != away {
+			changed = true
+		}
+	}
+	if changed {
+		h.broadcastUserList()
+	}
+}
+
+// disconnectIdle closes an idle client's connection with shared.CloseIdle
+// so it waits for its user rather than reconnecting straight away
+func (h *Hub) disconnectIdle(client *Client, idle time.Duration) {
+	log.Printf("Disconnecting idle client %s (IP: %s) after %s", client.username, client.ipAddr, idle.Round(time.Second))
+	reason := fmt.Sprintf("Disconnected after %s without activity", h.idleTimeout)
+	// WriteControl may run alongside the client's writePump
+	_ = client.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(shared.CloseIdle, reason), time.Now().Add(time.Second))
+	client.conn.Close()
+}
+
+// awayUsers lists connected users all of whose sessions are away, by
+// lowercase username
+func (h *Hub) awayUsers() map[string]bool {
+	away := make(map[string]bool)
+	for client := range h.clients.all() {
+		if client.readOnly || client.username == "" {
+			continue
+		}
+		lu := strings.ToLower(client.username)
+		if isAway, seen := away[lu]; !seen || isAway {
+			away[lu] = client.away.Load()
+		}
+	}
+	for lu, isAway := range away {
+		if !isAway {
+			delete(away, lu)
+		}
+	}
+	return away
+}
+
+// AwayUsers returns how many connected users are away
+func (h *Hub) AwayUsers() int {
+	return len(h.awayUsers())
+}
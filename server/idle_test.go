@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// lastUserList drains c.send and returns the newest user list in it
+func lastUserList(t *testing.T, c *Client) UserList {
+	t.Helper()
+	var ul UserList
+	found := false
+	for len(c.send) > 0 {
+		if msg, ok := (<-c.send).(WSMessage); ok && msg.Type == "userlist" {
+			if err := json.Unmarshal(msg.Data, &ul); err != nil {
+				t.Fatal(err)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("Expected a user list")
+	}
+	return ul
+}
+
+func TestIdlePresence(t *testing.T) {
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", nil)
+	hub.SetIdlePolicy(10*time.Minute, 0)
+	now := time.Now()
+	earlier := now.Add(-20 * time.Minute)
+
+	alice := &Client{hub: hub, username: "alice", connectedAt: earlier, send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", connectedAt: earlier, send: make(chan interface{}, 16)}
+	bobPhone := &Client{hub: hub, username: "bob", connectedAt: earlier, send: make(chan interface{}, 16)}
+	bobPhone.lastActive.Store(now.UnixNano())
+	wall := &Client{hub: hub, username: "wall", readOnly: true, connectedAt: earlier, send: make(chan interface{}, 16)}
+	for _, c := range []*Client{alice, bob, bobPhone, wall} {
+		hub.clients.add(c)
+	}
+
+	// bob is active on another device, so only alice is away
+	hub.applyIdleCheck(now)
+	if ul := lastUserList(t, alice); !slices.Equal(ul.Away, []string{"alice"}) {
+		t.Errorf("Expected alice away, got %v", ul.Away)
+	}
+	if got := hub.AwayUsers(); got != 1 {
+		t.Errorf("Expected 1 away user, got %d", got)
+	}
+
+	// Nothing changed: no new list
+	hub.applyIdleCheck(now)
+	if len(alice.send) != 0 {
+		t.Error("Expected no user list when presence is unchanged")
+	}
+
+	alice.lastActive.Store(now.UnixNano())
+	hub.applyIdleCheck(now)
+	if ul := lastUserList(t, alice); len(ul.Away) != 0 {
+		t.Errorf("Expected nobody away, got %v", ul.Away)
+	}
+}
+
+func TestIdleTimeoutDisconnects(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	hub.SetIdlePolicy(0, time.Hour)
+	go hub.Run()
+
+	ts := httptest.NewServer(ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(shared.Handshake{Username: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected a welcome frame: %v", err)
+	}
+
+	hub.idleChecks <- idleCheck{now: time.Now().Add(2 * time.Hour)}
+	var closeErr *websocket.CloseError
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("Expected a close frame, got %v", err)
+		}
+		break
+	}
+	if closeErr.Code != shared.CloseIdle {
+		t.Errorf("Expected close code %d, got %d %q", shared.CloseIdle, closeErr.Code, closeErr.Text)
+	}
+}
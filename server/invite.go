@@ -0,0 +1,164 @@
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Invite lifetimes for :invite create
+const (
+	defaultInviteTTL = 24 * time.Hour
+	maxInviteTTL     = 30 * 24 * time.Hour
+)
+
+// Invite is a single-use token that admits one new user, even when the
+// server only accepts usernames from MARCHAT_ALLOWED_USERS. Like bans,
+// invites are kept in memory and do not survive a restart.
+type Invite struct {
+	Token     string
+	CreatedBy string
+	ExpiresAt time.Time
+}
+
+// CreateInvite issues a new invite token valid for ttl
+func (h *Hub) CreateInvite(adminUsername string, ttl time.Duration) Invite {
+	inv := Invite{Token: rand.Text(), CreatedBy: adminUsername, ExpiresAt: time.Now().Add(ttl)}
+	h.inviteMutex.Lock()
+	h.invites[inv.Token] = inv
+	h.inviteMutex.Unlock()
+	AdminLogger.Info("Invite created", map[string]interface{}{
+		"admin":   adminUsername,
+		"expires": inv.ExpiresAt.Format("2006-01-02 15:04:05"),
+	})
+	return inv
+}
+
+// RevokeInvite deletes an unused invite
+func (h *Hub) RevokeInvite(token string) bool {
+	h.inviteMutex.Lock()
+	defer h.inviteMutex.Unlock()
+	if _, ok := h.invites[token]; !ok {
+		return false
+	}
+	delete(h.invites, token)
+	return true
+}
+
+// Invites lists the unexpired invites, soonest expiry first
+func (h *Hub) Invites() []Invite {
+	h.inviteMutex.Lock()
+	defer h.inviteMutex.Unlock()
+	now := time.Now()
+	list := make([]Invite, 0, len(h.invites))
+	for _, inv := range h.invites {
+		if now.Before(inv.ExpiresAt) {
+			list = append(list, inv)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ExpiresAt.Before(list[j].ExpiresAt) })
+	return list
+}
+
+// ValidInvite reports whether token can still be redeemed
+func (h *Hub) ValidInvite(token string) bool {
+	h.inviteMutex.Lock()
+	defer h.inviteMutex.Unlock()
+	inv, ok := h.invites[token]
+	return ok && time.Now().Before(inv.ExpiresAt)
+}
+
+// RedeemInvite uses up token for username, who is then admitted past the
+// username allowlist on later connections too
+func (h *Hub) RedeemInvite(token, username string) bool {
+	h.inviteMutex.Lock()
+	defer h.inviteMutex.Unlock()
+	inv, ok := h.invites[token]
+	if !ok || time.Now().After(inv.ExpiresAt) {
+		return false
+	}
+	delete(h.invites, token)
+	h.invitedUsers[strings.ToLower(username)] = true
+	SecurityLogger.Info("Invite redeemed", map[string]interface{}{
+		"username":   username,
+		"invited_by": inv.CreatedBy,
+	})
+	return true
+}
+
+// IsInvited reports whether username joined with an invite
+func (h *Hub) IsInvited(username string) bool {
+	h.inviteMutex.Lock()
+	defer h.inviteMutex.Unlock()
+	return h.invitedUsers[strings.ToLower(username)]
+}
+
+// cleanupExpiredInvites drops invites that can no longer be redeemed
+func (h *Hub) cleanupExpiredInvites() {
+	h.inviteMutex.Lock()
+	defer h.inviteMutex.Unlock()
+	now := time.Now()
+	for token, inv := range h.invites {
+		if now.After(inv.ExpiresAt) {
+			delete(h.invites, token)
+			log.Printf("[SYSTEM] Expired invite from %s removed", inv.CreatedBy)
+		}
+	}
+}
+
+// handleInviteCommand handles ":invite create [duration]", ":invite list"
+// and ":invite revoke <token>"
+func (c *Client) handleInviteCommand(parts []string) {
+	usage := "Usage: :invite create [duration] | :invite list | :invite revoke <token>"
+	if len(parts) < 2 {
+		c.reply(usage)
+		return
+	}
+	switch parts[1] {
+	case "create":
+		ttl := defaultInviteTTL
+		if len(parts) > 2 {
+			d, err := time.ParseDuration(parts[2])
+			if err != nil || d <= 0 {
+				c.reply("Invalid duration (e.g. 1h, 24h, 168h)")
+				return
+			}
+			if d > maxInviteTTL {
+				c.reply(fmt.Sprintf("Duration too long (max %s)", maxInviteTTL))
+				return
+			}
+			ttl = d
+		}
+		inv := c.hub.CreateInvite(c.username, ttl)
+		link := c.serverURL
+		link.Invite = inv.Token
+		c.reply(fmt.Sprintf("Single-use invite, valid until %s:\n%s\nJoin with: marchat-client --invite \"%s\"",
+			inv.ExpiresAt.Format("2006-01-02 15:04"), link, link))
+	case "list":
+		invites := c.hub.Invites()
+		if len(invites) == 0 {
+			c.reply("No active invites.")
+			return
+		}
+		lines := []string{"Active invites:"}
+		for _, inv := range invites {
+			lines = append(lines, fmt.Sprintf("%s (by %s, expires %s)", inv.Token, inv.CreatedBy, inv.ExpiresAt.Format("2006-01-02 15:04")))
+		}
+		c.reply(strings.Join(lines, "\n"))
+	case "revoke":
+		if len(parts) != 3 {
+			c.reply(usage)
+			return
+		}
+		if !c.hub.RevokeInvite(parts[2]) {
+			c.reply("No such invite.")
+			return
+		}
+		c.reply("Invite revoked.")
+	default:
+		c.reply(usage)
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestHubInvites(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	inv := hub.CreateInvite("root", time.Hour)
+	if !hub.ValidInvite(inv.Token) || hub.ValidInvite("nope") || hub.ValidInvite("") {
+		t.Fatal("Unexpected invite validity")
+	}
+	if !hub.RedeemInvite(inv.Token, "Alice") {
+		t.Fatal("Expected the invite to be redeemed")
+	}
+	if hub.RedeemInvite(inv.Token, "mallory") {
+		t.Error("Invites are single-use")
+	}
+	if !hub.IsInvited("alice") || hub.IsInvited("mallory") {
+		t.Error("Only the redeeming user should be admitted")
+	}
+
+	expired := hub.CreateInvite("root", time.Hour)
+	hub.inviteMutex.Lock()
+	hub.invites[expired.Token] = Invite{Token: expired.Token, CreatedBy: "root", ExpiresAt: time.Now().Add(-time.Minute)}
+	hub.inviteMutex.Unlock()
+	if hub.RedeemInvite(expired.Token, "bob") {
+		t.Error("Expired invites should not be redeemed")
+	}
+	hub.CleanupExpiredBans()
+	if len(hub.invites) != 0 {
+		t.Errorf("Expected expired invites to be cleaned up, got %v", hub.invites)
+	}
+}
+
+func TestInviteCommand(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	admin := &Client{hub: hub, username: "root", isAdmin: true, send: make(chan interface{}, 16),
+		serverURL: shared.ChatURL{Host: "chat.example.com", TLS: true}}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- admin
+	hub.register <- bob
+
+	bob.handleCommand(":invite create")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "admin privileges") {
+		t.Errorf("Non-admins should not create invites, got %q", msg.Content)
+	}
+
+	admin.handleCommand(":invite create 2h")
+	msg := nextTextMessage(t, admin)
+	if !strings.Contains(msg.Content, "marchat://chat.example.com?invite=") {
+		t.Fatalf("Expected an invite link, got %q", msg.Content)
+	}
+	invites := hub.Invites()
+	if len(invites) != 1 || time.Until(invites[0].ExpiresAt) > 2*time.Hour {
+		t.Fatalf("Unexpected invites %+v", invites)
+	}
+
+	admin.handleCommand(":invite create 9999h")
+	if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "too long") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+
+	admin.handleCommand(":invite revoke " + invites[0].Token)
+	if msg := nextTextMessage(t, admin); msg.Content != "Invite revoked." {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	admin.handleCommand(":invite list")
+	if msg := nextTextMessage(t, admin); msg.Content != "No active invites." {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+}
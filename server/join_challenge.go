@@ -0,0 +1,37 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// SetJoinChallenge makes clients solve a proof of work of the given
+// difficulty (0 = off) and/or present a shared passphrase ("" = off) before
+// their handshake is accepted. Meant for servers open to the internet.
+func (h *Hub) SetJoinChallenge(bits int, passphrase string) {
+	h.joinBits = min(max(bits, 0), shared.MaxChallengeBits)
+	h.joinPassphrase = passphrase
+}
+
+// newJoinChallenge creates the challenge for one connection attempt
+func (h *Hub) newJoinChallenge() shared.JoinChallenge {
+	c := shared.JoinChallenge{Bits: h.joinBits, Passphrase: h.joinPassphrase != ""}
+	if c.Bits > 0 {
+		c.Nonce = rand.Text()
+	}
+	return c
+}
+
+// checkJoinChallenge verifies the handshake's answers to challenge
+func (h *Hub) checkJoinChallenge(c shared.JoinChallenge, hs shared.Handshake) error {
+	if c.Bits > 0 && !shared.VerifyProofOfWork(c.Nonce, hs.ChallengeSolution, c.Bits) {
+		return fmt.Errorf("join challenge failed - please update your client")
+	}
+	if c.Passphrase && subtle.ConstantTimeCompare([]byte(hs.Passphrase), []byte(h.joinPassphrase)) != 1 {
+		return fmt.Errorf("invalid join passphrase")
+	}
+	return nil
+}
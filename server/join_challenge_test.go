@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestJoinChallenge(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	hub.SetJoinChallenge(8, "open sesame")
+
+	ts := httptest.NewServer(ServeWs(hub, db, []string{"root"}, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	join := func(answer func(shared.JoinChallenge, *shared.Handshake)) error {
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+		challenge := shared.ParseJoinChallenge(resp.Header)
+		if challenge.Bits != 8 || !challenge.Passphrase || challenge.Nonce == "" {
+			t.Fatalf("Unexpected challenge %+v", challenge)
+		}
+		hs := shared.Handshake{Username: "alice"}
+		answer(challenge, &hs)
+		if err := conn.WriteJSON(hs); err != nil {
+			t.Fatalf("WriteJSON failed: %v", err)
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			t.Fatalf("SetReadDeadline failed: %v", err)
+		}
+		var msg map[string]interface{}
+		return conn.ReadJSON(&msg)
+	}
+
+	if err := join(func(c shared.JoinChallenge, hs *shared.Handshake) {
+		hs.Passphrase = "open sesame"
+	}); err == nil {
+		t.Error("Handshake without proof of work should be refused")
+	}
+	if err := join(func(c shared.JoinChallenge, hs *shared.Handshake) {
+		hs.ChallengeSolution = shared.SolveProofOfWork(c.Nonce, c.Bits)
+		hs.Passphrase = "wrong"
+	}); err == nil {
+		t.Error("Handshake with the wrong passphrase should be refused")
+	}
+
+	c := hub.newJoinChallenge()
+	hs := shared.Handshake{Username: "alice", ChallengeSolution: shared.SolveProofOfWork(c.Nonce, c.Bits), Passphrase: "open sesame"}
+	if err := hub.checkJoinChallenge(c, hs); err != nil {
+		t.Errorf("Answered challenge should be accepted: %v", err)
+	}
+	if other := hub.newJoinChallenge(); other.Nonce == c.Nonce {
+		t.Error("Each connection should get a fresh nonce")
+	}
+}
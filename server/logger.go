@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
 )
 
 // LogLevel represents the severity level of a log entry
@@ -32,9 +35,10 @@ type LogEntry struct {
 
 // LogBuffer stores recent log entries in memory for admin panels
 type LogBuffer struct {
-	entries []LogEntry
-	mutex   sync.RWMutex
-	maxSize int
+	entries     []LogEntry
+	mutex       sync.RWMutex
+	maxSize     int
+	subscribers map[chan LogEntry]struct{}
 }
 
 // Global log buffer for capturing logs
@@ -57,6 +61,44 @@ func (lb *LogBuffer) AddEntry(entry LogEntry) {
 	if len(lb.entries) > lb.maxSize {
 		lb.entries = lb.entries[len(lb.entries)-lb.maxSize:]
 	}
+
+	// Never block logging on a slow reader: it just misses entries
+	for ch := range lb.subscribers {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// Subscribe returns the buffered entries (newest first) together with a
+// channel that receives every entry added afterwards, so a live view neither
+// misses nor repeats one. Call cancel to stop; it closes the channel.
+func (lb *LogBuffer) Subscribe() (entries []LogEntry, updates <-chan LogEntry, cancel func()) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	entries = make([]LogEntry, len(lb.entries))
+	for i, j := 0, len(lb.entries)-1; j >= 0; i, j = i+1, j-1 {
+		entries[i] = lb.entries[j]
+	}
+
+	ch := make(chan LogEntry, 64)
+	if lb.subscribers == nil {
+		lb.subscribers = make(map[chan LogEntry]struct{})
+	}
+	lb.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			lb.mutex.Lock()
+			defer lb.mutex.Unlock()
+			delete(lb.subscribers, ch)
+			close(ch)
+		})
+	}
+	return entries, ch, cancel
 }
 
 // GetEntries returns a copy of all log entries (newest first)
@@ -144,19 +186,19 @@ func (l *Logger) log(level LogLevel, message string, error *string, data ...map[
 		Timestamp: time.Now(),
 		Component: l.component,
 		UserID:    l.userID,
-		Message:   message,
+		Message:   shared.Redact(message),
 	}
 
 	if error != nil {
-		entry.Error = *error
+		entry.Error = shared.Redact(*error)
 	}
 
-	// Merge additional data
+	// Merge additional data, masking secrets so the admin panels never see them
 	if len(data) > 0 {
 		entry.Data = make(map[string]interface{})
 		for _, d := range data {
 			for k, v := range d {
-				entry.Data[k] = v
+				entry.Data[k] = redactValue(k, v)
 			}
 		}
 	}
@@ -181,6 +223,44 @@ func (l *Logger) log(level LogLevel, message string, error *string, data ...map[
 	}
 }
 
+// redactValue masks a structured log field: strings are redacted, and
+// fields named after a secret are dropped whatever their value
+func redactValue(key string, v interface{}) interface{} {
+	if isSecretField(key) {
+		return shared.Redacted
+	}
+	switch v := v.(type) {
+	case string:
+		return shared.Redact(v)
+	case error:
+		return shared.Redact(v.Error())
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = shared.Redact(s)
+		}
+		return out
+	}
+	return v
+}
+
+// isSecretField reports whether a structured log field holds a secret
+func isSecretField(key string) bool {
+	k := strings.ToLower(key)
+	if strings.HasSuffix(k, "_file") || strings.HasSuffix(k, "_path") {
+		return false // where a secret lives, not the secret
+	}
+	if strings.HasSuffix(k, "key") {
+		return true
+	}
+	for _, word := range []string{"passphrase", "password", "secret", "token", "jwt"} {
+		if strings.Contains(k, word) {
+			return true
+		}
+	}
+	return false
+}
+
 // Global logger instances for different components
 var (
 	ServerLogger   = NewLogger("Server")
@@ -221,8 +301,8 @@ func LogToFile(filename string) error {
 	// Store the debug file for structured logger to use
 	debugFile = file
 
-	// Redirect all log.Printf calls to the debug file
-	log.SetOutput(file)
+	// Redirect all log.Printf calls to the debug file, minus any secrets
+	log.SetOutput(shared.RedactWriter(file))
 	return nil
 }
 
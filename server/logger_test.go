@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	shared.RegisterSecret("super-secret-admin-key")
+	logger := NewLogger("Test")
+	logger.Error("Handshake from alice with super-secret-admin-key",
+		errors.New("bad token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhIn0.c2ln"),
+		map[string]interface{}{
+			"admin_key":    "anything",
+			"passphrase":   "opensesame",
+			"command":      ":ban bob",
+			"frame":        `{"content":"Zm9vYmFy","encrypted":true}`,
+			"tls_key_file": "/etc/marchat/key.pem",
+		})
+
+	entry := GetLogBuffer().GetRecentEntries(1)[0]
+	all := entry.Message + entry.Error
+	for _, v := range entry.Data {
+		if s, ok := v.(string); ok {
+			all += s
+		}
+	}
+	for _, secret := range []string{"super-secret-admin-key", "eyJhbGciOiJIUzI1NiJ9", "anything", "opensesame", "Zm9vYmFy"} {
+		if strings.Contains(all, secret) {
+			t.Errorf("Secret %q leaked into the log buffer: %+v", secret, entry)
+		}
+	}
+	if entry.Data["command"] != ":ban bob" || entry.Data["tls_key_file"] != "/etc/marchat/key.pem" {
+		t.Errorf("Expected ordinary fields to be kept, got %+v", entry.Data)
+	}
+}
+
+func TestLogBufferSubscribe(t *testing.T) {
+	lb := &LogBuffer{maxSize: 10}
+	lb.AddEntry(LogEntry{Message: "one"})
+	lb.AddEntry(LogEntry{Message: "two"})
+
+	entries, updates, cancel := lb.Subscribe()
+	if len(entries) != 2 || entries[0].Message != "two" {
+		t.Fatalf("Expected the buffered entries newest first, got %+v", entries)
+	}
+	lb.AddEntry(LogEntry{Message: "three"})
+	if e := <-updates; e.Message != "three" {
+		t.Errorf("Expected the new entry on the channel, got %+v", e)
+	}
+
+	cancel()
+	cancel()
+	lb.AddEntry(LogEntry{Message: "four"})
+	if _, ok := <-updates; ok {
+		t.Error("Expected the channel to be closed after cancel")
+	}
+}
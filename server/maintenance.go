@@ -0,0 +1,192 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// A maintenance window is planned from the web admin panel. Clients count
+// down to it in their banner; when it starts the hub drains, refusing new
+// connections and failing /readyz, and when it ends the hub takes
+// connections again. The window is stored with the MOTD so it survives the
+// restarts it is usually planned for.
+
+const (
+	maxMaintenanceMessageLen = 300
+	maxMaintenanceLength     = 7 * 24 * time.Hour
+
+	// maintenanceDrainTimeout is how long clients get to disconnect when a
+	// window starts
+	maintenanceDrainTimeout = 10 * time.Second
+)
+
+// unixSeconds is t as the Unix seconds the SQL backends store, 0 for the
+// zero time
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// fromUnixSeconds reverses unixSeconds
+func fromUnixSeconds(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(n, 0)
+}
+
+// getMaintenanceSQL is GetMaintenance for the SQL backends
+func getMaintenanceSQL(db *sql.DB) (shared.Maintenance, error) {
+	var m shared.Maintenance
+	var start, end int64
+	err := db.QueryRow(`SELECT start_at, end_at, message, set_by, set_at FROM maintenance WHERE id = 1`).Scan(&start, &end, &m.Message, &m.SetBy, &m.SetAt)
+	if err == sql.ErrNoRows {
+		return shared.Maintenance{}, nil
+	}
+	m.Start, m.End = fromUnixSeconds(start), fromUnixSeconds(end)
+	return m, err
+}
+
+// Maintenance returns the planned maintenance window
+func (h *Hub) Maintenance() shared.Maintenance {
+	h.notices.mu.RLock()
+	defer h.notices.mu.RUnlock()
+	return h.notices.maintenance
+}
+
+// ScheduleMaintenance plans a maintenance window from start to end,
+// replacing any planned before, and tells everyone connected. A start in
+// the past begins it now.
+func (h *Hub) ScheduleMaintenance(start, end time.Time, message, adminUsername string) (shared.Maintenance, error) {
+	if h.db == nil {
+		return shared.Maintenance{}, fmt.Errorf("maintenance windows require a database")
+	}
+	now := time.Now()
+	if start.Before(now) {
+		start = now
+	}
+	m := shared.Maintenance{
+		Start:   start.Truncate(time.Second),
+		End:     end.Truncate(time.Second),
+		Message: strings.Join(strings.Fields(message), " "),
+		SetBy:   adminUsername,
+		SetAt:   now,
+	}
+	switch {
+	case !m.End.After(m.Start):
+		return shared.Maintenance{}, fmt.Errorf("the window must end after it starts")
+	case m.End.Sub(m.Start) > maxMaintenanceLength:
+		return shared.Maintenance{}, fmt.Errorf("a window is limited to %s", maxMaintenanceLength)
+	case len(m.Message) > maxMaintenanceMessageLen:
+		return shared.Maintenance{}, fmt.Errorf("message is limited to %d characters", maxMaintenanceMessageLen)
+	}
+	if err := h.setMaintenance(m); err != nil {
+		return shared.Maintenance{}, err
+	}
+	AdminLogger.Info("Maintenance window scheduled", map[string]interface{}{
+		"admin":   adminUsername,
+		"start":   m.Start.Format(time.RFC3339),
+		"end":     m.End.Format(time.RFC3339),
+		"message": m.Message,
+	})
+	return m, nil
+}
+
+// ClearMaintenance cancels the planned window, or ends one under way so the
+// server takes connections again
+func (h *Hub) ClearMaintenance(adminUsername string) error {
+	if h.db == nil {
+		return fmt.Errorf("maintenance windows require a database")
+	}
+	if !h.Maintenance().Scheduled() {
+		return fmt.Errorf("no maintenance window is scheduled")
+	}
+	if err := h.setMaintenance(shared.Maintenance{SetBy: adminUsername, SetAt: time.Now()}); err != nil {
+		return err
+	}
+	AdminLogger.Info("Maintenance window cleared", map[string]interface{}{
+		"admin": adminUsername,
+	})
+	return nil
+}
+
+// setMaintenance stores a window, tells everyone connected and sets the
+// timers that begin and end it
+func (h *Hub) setMaintenance(m shared.Maintenance) error {
+	if err := h.db.SetMaintenance(m); err != nil {
+		return err
+	}
+	h.notices.mu.Lock()
+	h.notices.maintenance = m
+	h.notices.mu.Unlock()
+	h.broadcast <- maintenanceMessage(m)
+	h.armMaintenance()
+	return nil
+}
+
+// armMaintenance sets the timer for the next step of the stored window:
+// its start, its end, or none. A window that ended while the server was
+// down is forgotten.
+func (h *Hub) armMaintenance() {
+	h.notices.mu.Lock()
+	defer h.notices.mu.Unlock()
+	if h.notices.maintenanceTimer != nil {
+		h.notices.maintenanceTimer.Stop()
+		h.notices.maintenanceTimer = nil
+	}
+	m := h.notices.maintenance
+	now := time.Now()
+	switch {
+	case !m.Scheduled() || !now.Before(m.End):
+		if h.notices.inMaintenance {
+			h.endMaintenance()
+		}
+		if m.Scheduled() {
+			h.notices.maintenance = shared.Maintenance{}
+			if err := h.db.SetMaintenance(shared.Maintenance{}); err != nil {
+				log.Printf("Warning: failed to clear finished maintenance window: %v", err)
+			}
+		}
+	case now.Before(m.Start):
+		if h.notices.inMaintenance {
+			h.endMaintenance()
+		}
+		h.notices.maintenanceTimer = time.AfterFunc(m.Start.Sub(now), h.armMaintenance)
+	default:
+		if !h.notices.inMaintenance {
+			h.beginMaintenance(m)
+		}
+		h.notices.maintenanceTimer = time.AfterFunc(m.End.Sub(now), h.armMaintenance)
+	}
+}
+
+// beginMaintenance drains the hub. The caller holds h.notices.mu.
+func (h *Hub) beginMaintenance(m shared.Maintenance) {
+	h.notices.inMaintenance = true
+	HubLogger.Info("Maintenance window started", map[string]interface{}{
+		"end": m.End.Format(time.RFC3339),
+	})
+	h.draining.Store(true)
+	go h.disconnectAll(maintenanceDrainTimeout)
+}
+
+// endMaintenance takes connections again. The caller holds h.notices.mu.
+func (h *Hub) endMaintenance() {
+	h.notices.inMaintenance = false
+	h.draining.Store(false)
+	HubLogger.Info("Maintenance window ended", nil)
+}
+
+// maintenanceMessage builds the "maintenance" WebSocket message for clients
+func maintenanceMessage(m shared.Maintenance) WSMessage {
+	payload, _ := json.Marshal(m)
+	return WSMessage{Type: "maintenance", Data: payload}
+}
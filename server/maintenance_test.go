@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestScheduleMaintenance(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- bob
+
+	start := time.Now().Add(time.Hour)
+	for _, bad := range []struct {
+		start, end time.Time
+		message    string
+	}{
+		{start, start, ""},
+		{start, start.Add(-time.Minute), ""},
+		{start, start.Add(maxMaintenanceLength + time.Minute), ""},
+		{start, start.Add(time.Hour), strings.Repeat("x", maxMaintenanceMessageLen+1)},
+	} {
+		if _, err := hub.ScheduleMaintenance(bad.start, bad.end, bad.message, "root"); err == nil {
+			t.Errorf("Window %v to %v should be refused", bad.start, bad.end)
+		}
+	}
+
+	if _, err := hub.ScheduleMaintenance(start, start.Add(30*time.Minute), " Upgrading   the database ", "root"); err != nil {
+		t.Fatalf("ScheduleMaintenance failed: %v", err)
+	}
+	var mw shared.Maintenance
+	if err := json.Unmarshal(nextWSMessage(t, bob, "maintenance").Data, &mw); err != nil || mw.Message != "Upgrading the database" || !mw.Start.Equal(start.Truncate(time.Second)) || mw.SetBy != "root" {
+		t.Errorf("Unexpected maintenance payload %+v (%v)", mw, err)
+	}
+	if hub.Draining() {
+		t.Error("The hub should not drain before the window starts")
+	}
+
+	// The window survives a restart and is sent on connect
+	restarted := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	restarted.ReloadNotices()
+	carol := &Client{hub: restarted, username: "carol", send: make(chan interface{}, 16)}
+	carol.sendNotices()
+	if err := json.Unmarshal(nextWSMessage(t, carol, "maintenance").Data, &mw); err != nil || mw.Message != "Upgrading the database" {
+		t.Errorf("Expected the stored window on connect, got %+v (%v)", mw, err)
+	}
+
+	if err := hub.ClearMaintenance("root"); err != nil {
+		t.Fatalf("ClearMaintenance failed: %v", err)
+	}
+	if err := json.Unmarshal(nextWSMessage(t, bob, "maintenance").Data, &mw); err != nil || mw.Scheduled() {
+		t.Errorf("Expected a cleared window, got %+v (%v)", mw, err)
+	}
+	if err := hub.ClearMaintenance("root"); err == nil {
+		t.Error("Clearing with no window should fail")
+	}
+}
+
+func TestMaintenanceWindowDrains(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	// A start in the past begins the window now
+	now := time.Now()
+	if _, err := hub.ScheduleMaintenance(now.Add(-time.Minute), now.Add(2*time.Second), "", "root"); err != nil {
+		t.Fatalf("ScheduleMaintenance failed: %v", err)
+	}
+	if !hub.Draining() {
+		t.Fatal("Expected the hub to drain during the window")
+	}
+
+	deadline := time.Now().Add(4 * time.Second)
+	for hub.Draining() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if hub.Draining() {
+		t.Fatal("Expected the hub to take connections again after the window")
+	}
+	if mw, err := db.GetMaintenance(); err != nil || mw.Scheduled() {
+		t.Errorf("Expected the finished window to be forgotten, got %+v (%v)", mw, err)
+	}
+}
+
+func TestFinishedMaintenanceForgottenOnLoad(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	past := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	if err := db.SetMaintenance(shared.Maintenance{Start: past, End: past.Add(time.Hour), SetBy: "root", SetAt: past}); err != nil {
+		t.Fatalf("SetMaintenance failed: %v", err)
+	}
+
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	hub.ReloadNotices()
+	if hub.Maintenance().Scheduled() || hub.Draining() {
+		t.Errorf("A window that ended while the server was down should be forgotten, got %+v", hub.Maintenance())
+	}
+	if mw, _ := db.GetMaintenance(); mw.Scheduled() {
+		t.Errorf("Expected the stored window to be cleared, got %+v", mw)
+	}
+}
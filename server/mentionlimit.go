@@ -0,0 +1,114 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default mention throttle: a user may mention the same person or group
+// this many times per window
+const (
+	DefaultMentionLimit  = 5
+	DefaultMentionWindow = 10 * time.Minute
+)
+
+// mentionThrottle limits how often one user may mention the same @name, so
+// nobody can be pinged over and over
+type mentionThrottle struct {
+	mu        sync.Mutex
+	limit     int // 0 = off
+	window    time.Duration
+	sent      map[string][]time.Time // sender + "\x00" + @name -> recent mentions
+	lastSweep time.Time
+}
+
+func newMentionThrottle() *mentionThrottle {
+	return &mentionThrottle{
+		limit:  DefaultMentionLimit,
+		window: DefaultMentionWindow,
+		sent:   make(map[string][]time.Time),
+	}
+}
+
+// SetMentionLimit allows limit mentions of the same user or group per
+// window; a limit of 0 turns the throttle off
+func (h *Hub) SetMentionLimit(limit int, window time.Duration) {
+	h.mentionThrottle.mu.Lock()
+	defer h.mentionThrottle.mu.Unlock()
+	if window <= 0 {
+		window = DefaultMentionWindow
+	}
+	h.mentionThrottle.limit = max(limit, 0)
+	h.mentionThrottle.window = window
+	h.mentionThrottle.sent = make(map[string][]time.Time)
+}
+
+// MentionLimit returns the mention throttle; a limit of 0 means off
+func (h *Hub) MentionLimit() (int, time.Duration) {
+	h.mentionThrottle.mu.Lock()
+	defer h.mentionThrottle.mu.Unlock()
+	return h.mentionThrottle.limit, h.mentionThrottle.window
+}
+
+// mentionWait records a mention of each name by sender and returns "" and
+// 0, or returns the first name over the limit and how long until it may be
+// mentioned again, without recording anything
+func (h *Hub) mentionWait(sender string, names []string, now time.Time) (string, time.Duration) {
+	t := h.mentionThrottle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limit <= 0 || len(names) == 0 {
+		return "", 0
+	}
+	if now.Sub(t.lastSweep) > t.window {
+		for key, times := range t.sent {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) >= t.window {
+				delete(t.sent, key)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	sender = strings.ToLower(sender)
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == sender {
+			continue
+		}
+		key := sender + "\x00" + name
+		recent := t.sent[key][:0]
+		for _, at := range t.sent[key] {
+			if now.Sub(at) < t.window {
+				recent = append(recent, at)
+			}
+		}
+		t.sent[key] = recent
+		if len(recent) >= t.limit {
+			return name, t.window - now.Sub(recent[0])
+		}
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		t.sent[key] = append(t.sent[key], now)
+	}
+	return "", 0
+}
+
+// checkMentionLimit reports whether the client may send content's mentions
+// now, telling them politely when to try again if not. Admins are exempt.
+func (c *Client) checkMentionLimit(content string) bool {
+	if c.isAdmin {
+		return true
+	}
+	name, wait := c.hub.mentionWait(c.username, mentionNames(content), time.Now())
+	if name == "" {
+		return true
+	}
+	_, window := c.hub.MentionLimit()
+	seconds := int((wait + time.Second - 1) / time.Second)
+	c.reply(fmt.Sprintf("Message not sent: you've mentioned @%s a lot in the last %s. Please give them a break and try again in %ds.",
+		name, window, seconds))
+	return false
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMentionWait(t *testing.T) {
+	hub := &Hub{mentionThrottle: newMentionThrottle()}
+	hub.SetMentionLimit(2, time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if name, _ := hub.mentionWait("alice", []string{"bob", "devs"}, now.Add(time.Duration(i)*time.Second)); name != "" {
+			t.Fatalf("Mention %d should be allowed, got a wait on %s", i+1, name)
+		}
+	}
+	name, wait := hub.mentionWait("Alice", []string{"carol", "bob"}, now.Add(10*time.Second))
+	if name != "bob" || wait != 50*time.Second {
+		t.Errorf("Expected bob to be throttled for 50s, got %q %v", name, wait)
+	}
+	// A refused message records nothing, so carol is still fresh
+	if name, _ := hub.mentionWait("alice", []string{"carol"}, now.Add(10*time.Second)); name != "" {
+		t.Errorf("A refused message should not count against carol, got %s", name)
+	}
+	if name, _ := hub.mentionWait("dave", []string{"bob"}, now.Add(10*time.Second)); name != "" {
+		t.Errorf("Throttles are per sender, got %s", name)
+	}
+	if name, _ := hub.mentionWait("alice", []string{"alice", "alice"}, now.Add(10*time.Second)); name != "" {
+		t.Errorf("Mentioning yourself should not be throttled, got %s", name)
+	}
+	if name, _ := hub.mentionWait("alice", []string{"bob"}, now.Add(time.Minute)); name != "" {
+		t.Errorf("The oldest mention should leave the window, got %s", name)
+	}
+
+	hub.SetMentionLimit(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if name, _ := hub.mentionWait("alice", []string{"bob"}, now); name != "" {
+			t.Fatal("A limit of 0 should turn the throttle off")
+		}
+	}
+}
+
+func TestCheckMentionLimit(t *testing.T) {
+	hub := &Hub{mentionThrottle: newMentionThrottle()}
+	hub.SetMentionLimit(1, time.Minute)
+	alice := &Client{hub: hub, username: "alice", send: make(chan interface{}, 4)}
+	root := &Client{hub: hub, username: "root", isAdmin: true, send: make(chan interface{}, 4)}
+
+	if !alice.checkMentionLimit("hey @bob") {
+		t.Fatal("The first mention should be allowed")
+	}
+	if alice.checkMentionLimit("@Bob, again?") {
+		t.Fatal("The second mention should be refused")
+	}
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "mentioned @bob a lot in the last 1m0s") || !strings.Contains(msg.Content, "try again in 60s") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	for i := 0; i < 3; i++ {
+		if !root.checkMentionLimit("@bob") {
+			t.Fatal("Admins should not be throttled")
+		}
+	}
+
+	// Clients are told the limit so they can hold messages back themselves
+	var limits MessageLimits
+	if err := json.Unmarshal(hub.limitsMessage().Data, &limits); err != nil || limits.MentionLimit != 1 || limits.MentionWindowSeconds != 60 {
+		t.Errorf("Unexpected limits %+v (%v)", limits, err)
+	}
+}
@@ -0,0 +1,413 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Built-in mention groups: @here reaches everyone connected and only admins
+// may use it; @admins reaches the connected admins and anyone may use it
+const (
+	mentionHere   = "here"
+	mentionAdmins = "admins"
+)
+
+var (
+	// mentionPattern finds @names in a message; usernames may contain
+	// periods, so a trailing one is trimmed as punctuation
+	mentionPattern = regexp.MustCompile(`\B@([a-zA-Z0-9_.-]+)`)
+	// groupNamePattern is what :group create accepts
+	groupNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+)
+
+// mentionGroupCache caches the admin-managed mention groups by name
+type mentionGroupCache struct {
+	mu     sync.RWMutex
+	groups map[string]MentionGroup
+}
+
+func newMentionGroupCache() *mentionGroupCache {
+	return &mentionGroupCache{groups: make(map[string]MentionGroup)}
+}
+
+// mentionLookup asks the hub goroutine which connected users a message's
+// mentions reach
+type mentionLookup struct {
+	sender  string
+	here    bool     // everyone connected
+	admins  bool     // connected admins
+	members []string // mentioned users and group members, reached whether connected or not
+	result  chan []string
+}
+
+// ReloadKnownUsers reads everyone who has posted or connected from the
+// database, so mentions reach users who are offline
+func (h *Hub) ReloadKnownUsers() {
+	if h.db == nil {
+		return
+	}
+	usernames, err := h.db.GetKnownUsers()
+	if err != nil {
+		log.Printf("Warning: failed to load known users: %v", err)
+		return
+	}
+	h.namesMutex.Lock()
+	for _, username := range usernames {
+		h.knownUsers[strings.ToLower(username)] = true
+	}
+	h.namesMutex.Unlock()
+}
+
+// rememberUser adds a connecting user to the known users
+func (h *Hub) rememberUser(username string) {
+	h.namesMutex.Lock()
+	h.knownUsers[strings.ToLower(username)] = true
+	h.namesMutex.Unlock()
+}
+
+// mentionedUsers returns the lowercase usernames @name refers to, matched
+// without regard to case: the user with that username if there is one,
+// otherwise everyone whose display name it is. Offline users count too.
+func (h *Hub) mentionedUsers(name string) []string {
+	name = strings.ToLower(name)
+	h.namesMutex.RLock()
+	defer h.namesMutex.RUnlock()
+	if h.knownUsers[name] {
+		return []string{name}
+	}
+	var usernames []string
+	for username, display := range h.displayNames {
+		if strings.EqualFold(display, name) {
+			usernames = append(usernames, username)
+		}
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// historyMentions resolves the direct @mentions in a stored message, which
+// the database doesn't keep, so history highlights the same way live
+// messages do. Group mentions are left out: who they reached depended on
+// membership and who was online at the time.
+func (h *Hub) historyMentions(msg shared.Message) []string {
+	if msg.Encrypted || (msg.Type != "" && msg.Type != shared.TextMessage) {
+		return nil
+	}
+	var usernames []string
+	for _, name := range mentionNames(msg.Content) {
+		if name == mentionHere || name == mentionAdmins {
+			continue
+		}
+		if _, ok := h.mentionGroup(name); ok {
+			continue
+		}
+		for _, username := range h.mentionedUsers(name) {
+			if username != strings.ToLower(msg.Sender) && !slices.Contains(usernames, username) {
+				usernames = append(usernames, username)
+			}
+		}
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// ReloadMentionGroups reads the mention groups from the database
+func (h *Hub) ReloadMentionGroups() {
+	if h.db == nil {
+		return
+	}
+	groups, err := h.db.GetMentionGroups()
+	if err != nil {
+		log.Printf("Warning: failed to load mention groups: %v", err)
+		return
+	}
+	byName := make(map[string]MentionGroup, len(groups))
+	for _, g := range groups {
+		byName[g.Name] = g
+	}
+	h.groups.mu.Lock()
+	h.groups.groups = byName
+	h.groups.mu.Unlock()
+}
+
+// MentionGroups lists the custom mention groups by name
+func (h *Hub) MentionGroups() []MentionGroup {
+	h.groups.mu.RLock()
+	defer h.groups.mu.RUnlock()
+	groups := make([]MentionGroup, 0, len(h.groups.groups))
+	for _, g := range h.groups.groups {
+		g.Members = slices.Clone(g.Members)
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// mentionGroup returns a custom mention group by name
+func (h *Hub) mentionGroup(name string) (MentionGroup, bool) {
+	h.groups.mu.RLock()
+	defer h.groups.mu.RUnlock()
+	g, ok := h.groups.groups[name]
+	g.Members = slices.Clone(g.Members)
+	return g, ok
+}
+
+// CreateMentionGroup adds an empty mention group. adminsOnly groups can only
+// be mentioned by admins.
+func (h *Hub) CreateMentionGroup(name string, adminsOnly bool, adminUsername string) error {
+	if h.db == nil {
+		return fmt.Errorf("mention groups require a database")
+	}
+	name = strings.ToLower(name)
+	if !groupNamePattern.MatchString(name) {
+		return fmt.Errorf("group names are 1-32 lowercase letters, digits, '-' or '_'")
+	}
+	if name == mentionHere || name == mentionAdmins {
+		return fmt.Errorf("@%s is built in", name)
+	}
+	if _, ok := h.mentionGroup(name); ok {
+		return fmt.Errorf("group %s already exists", name)
+	}
+	g := MentionGroup{Name: name, AdminsOnly: adminsOnly, CreatedBy: adminUsername, CreatedAt: time.Now()}
+	if err := h.saveMentionGroup(g); err != nil {
+		return err
+	}
+	AdminLogger.Info("Mention group created", map[string]interface{}{
+		"admin":       adminUsername,
+		"group":       name,
+		"admins_only": adminsOnly,
+	})
+	return nil
+}
+
+// DeleteMentionGroup removes a mention group
+func (h *Hub) DeleteMentionGroup(name, adminUsername string) error {
+	if h.db == nil {
+		return fmt.Errorf("mention groups require a database")
+	}
+	name = strings.ToLower(name)
+	if _, ok := h.mentionGroup(name); !ok {
+		return fmt.Errorf("no group named %s", name)
+	}
+	if err := h.db.DeleteMentionGroup(name); err != nil {
+		return err
+	}
+	h.groups.mu.Lock()
+	delete(h.groups.groups, name)
+	h.groups.mu.Unlock()
+	AdminLogger.Info("Mention group deleted", map[string]interface{}{
+		"admin": adminUsername,
+		"group": name,
+	})
+	return nil
+}
+
+// SetMentionGroupMembers adds usernames to a mention group, or removes them
+// when remove is set, and returns the updated group
+func (h *Hub) SetMentionGroupMembers(name string, usernames []string, remove bool, adminUsername string) (MentionGroup, error) {
+	if h.db == nil {
+		return MentionGroup{}, fmt.Errorf("mention groups require a database")
+	}
+	g, ok := h.mentionGroup(strings.ToLower(name))
+	if !ok {
+		return MentionGroup{}, fmt.Errorf("no group named %s", strings.ToLower(name))
+	}
+	for _, username := range usernames {
+		username = strings.ToLower(strings.TrimPrefix(username, "@"))
+		if err := validateUsername(username); err != nil {
+			return MentionGroup{}, fmt.Errorf("%s: %v", username, err)
+		}
+		i := slices.Index(g.Members, username)
+		switch {
+		case remove && i >= 0:
+			g.Members = slices.Delete(g.Members, i, i+1)
+		case !remove && i < 0:
+			g.Members = append(g.Members, username)
+		}
+	}
+	sort.Strings(g.Members)
+	if err := h.saveMentionGroup(g); err != nil {
+		return MentionGroup{}, err
+	}
+	AdminLogger.Info("Mention group members changed", map[string]interface{}{
+		"admin":   adminUsername,
+		"group":   g.Name,
+		"members": strings.Join(g.Members, ","),
+	})
+	return g, nil
+}
+
+func (h *Hub) saveMentionGroup(g MentionGroup) error {
+	if err := h.db.SaveMentionGroup(g); err != nil {
+		return err
+	}
+	h.groups.mu.Lock()
+	h.groups.groups[g.Name] = g
+	h.groups.mu.Unlock()
+	return nil
+}
+
+// mentionNames returns the distinct lowercase @names in content
+func mentionNames(content string) []string {
+	var names []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.ToLower(strings.TrimRight(m[1], "."))
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// resolveMentions expands the @mentions in content into the usernames they
+// notify, sender excluded. It refuses groups the sender may not mention.
+func (c *Client) resolveMentions(content string) ([]string, error) {
+	return c.hub.resolveMentions(c.username, c.isAdmin, content)
+}
+
+// resolveMentions is Client.resolveMentions for a sender who may not be
+// connected, such as the author of a scheduled message
+func (h *Hub) resolveMentions(sender string, senderAdmin bool, content string) ([]string, error) {
+	names := mentionNames(content)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	lookup := mentionLookup{sender: strings.ToLower(sender), result: make(chan []string, 1)}
+	for _, name := range names {
+		switch name {
+		case mentionHere:
+			if !senderAdmin {
+				return nil, fmt.Errorf("only admins can mention @%s", mentionHere)
+			}
+			lookup.here = true
+			continue
+		case mentionAdmins:
+			lookup.admins = true
+			continue
+		}
+		if g, ok := h.mentionGroup(name); ok {
+			if g.AdminsOnly && !senderAdmin {
+				return nil, fmt.Errorf("only admins can mention @%s", name)
+			}
+			lookup.members = append(lookup.members, g.Members...)
+			continue
+		}
+		lookup.members = append(lookup.members, h.mentionedUsers(name)...)
+	}
+	h.mentionLookups <- lookup
+	return <-lookup.result, nil
+}
+
+// applyMentionLookup resolves a mentionLookup against the connected
+// clients; it runs on the hub goroutine, which owns h.clients
+func (h *Hub) applyMentionLookup(lookup mentionLookup) []string {
+	reached := make(map[string]bool)
+	for _, member := range lookup.members {
+		reached[member] = true
+	}
+	for client := range h.clients.all() {
+		if client.readOnly {
+			continue
+		}
+		username := strings.ToLower(client.username)
+		if lookup.here || (lookup.admins && client.isAdmin) {
+			reached[username] = true
+		}
+	}
+	delete(reached, lookup.sender)
+	usernames := make([]string, 0, len(reached))
+	for username := range reached {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// handleGroupCommand handles ":group list|show <name>" for everyone and
+// ":group create|delete|add|remove" for admins
+func (c *Client) handleGroupCommand(args []string) {
+	usage := "Usage: :group list | :group show <name> | :group create <name> [admins-only] | :group delete <name> | :group add|remove <name> <users...>"
+	if len(args) == 0 || args[0] == "list" {
+		groups := c.hub.MentionGroups()
+		var b strings.Builder
+		b.WriteString("Mention groups:\n")
+		b.WriteString(fmt.Sprintf("@%s - everyone online (admins only)\n", mentionHere))
+		b.WriteString(fmt.Sprintf("@%s - admins online\n", mentionAdmins))
+		for _, g := range groups {
+			restricted := ""
+			if g.AdminsOnly {
+				restricted = " (admins only)"
+			}
+			b.WriteString(fmt.Sprintf("@%s - %d members%s\n", g.Name, len(g.Members), restricted))
+		}
+		c.reply(strings.TrimRight(b.String(), "\n"))
+		return
+	}
+
+	switch args[0] {
+	case "show":
+		if len(args) != 2 {
+			c.reply(usage)
+			return
+		}
+		g, ok := c.hub.mentionGroup(strings.ToLower(args[1]))
+		if !ok {
+			c.reply("No group named " + args[1])
+			return
+		}
+		members := "(none)"
+		if len(g.Members) > 0 {
+			members = strings.Join(g.Members, ", ")
+		}
+		c.reply(fmt.Sprintf("@%s members: %s", g.Name, members))
+		return
+	case "create", "delete", "add", "remove":
+	default:
+		c.reply(usage)
+		return
+	}
+
+	if !c.isAdmin {
+		c.reply("Only admins can manage mention groups.")
+		return
+	}
+	var err error
+	switch args[0] {
+	case "create":
+		if len(args) < 2 || len(args) > 3 || (len(args) == 3 && args[2] != "admins-only") {
+			c.reply(usage)
+			return
+		}
+		if err = c.hub.CreateMentionGroup(args[1], len(args) == 3, c.username); err == nil {
+			c.reply(fmt.Sprintf("Created @%s. Add members with :group add %s <users...>", strings.ToLower(args[1]), strings.ToLower(args[1])))
+		}
+	case "delete":
+		if len(args) != 2 {
+			c.reply(usage)
+			return
+		}
+		if err = c.hub.DeleteMentionGroup(args[1], c.username); err == nil {
+			c.reply("Deleted @" + strings.ToLower(args[1]))
+		}
+	case "add", "remove":
+		if len(args) < 3 {
+			c.reply(usage)
+			return
+		}
+		var g MentionGroup
+		if g, err = c.hub.SetMentionGroupMembers(args[1], args[2:], args[0] == "remove", c.username); err == nil {
+			c.reply(fmt.Sprintf("@%s now has %d members", g.Name, len(g.Members)))
+		}
+	}
+	if err != nil {
+		c.reply("Could not update group: " + err.Error())
+	}
+}
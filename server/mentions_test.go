@@ -0,0 +1,139 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestMentionNames(t *testing.T) {
+	got := mentionNames("@Here ping @bob, @bob.smith. and @Bob; mail me@example.com")
+	want := []string{"here", "bob", "bob.smith"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mentionNames = %v, want %v", got, want)
+	}
+}
+
+func TestGroupMentions(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	newClient := func(username string, isAdmin, readOnly bool) *Client {
+		c := &Client{hub: hub, username: username, isAdmin: isAdmin, readOnly: readOnly, send: make(chan interface{}, 16)}
+		hub.register <- c
+		return c
+	}
+	root := newClient("root", true, false)
+	alice := newClient("Alice", false, false)
+	newClient("bob", false, false)
+	newClient("watcher", false, true) // spectators aren't pinged
+
+	// Only admins may ping everyone
+	if _, err := alice.resolveMentions("@here lunch?"); err == nil || !strings.Contains(err.Error(), "only admins") {
+		t.Errorf("Expected @here to be refused for users, got %v", err)
+	}
+	if got, err := root.resolveMentions("@here standup"); err != nil || !reflect.DeepEqual(got, []string{"alice", "bob"}) {
+		t.Errorf("@here should reach the other chatters, got %v (%v)", got, err)
+	}
+	if got, _ := alice.resolveMentions("@admins help"); !reflect.DeepEqual(got, []string{"root"}) {
+		t.Errorf("@admins should reach connected admins, got %v", got)
+	}
+	if got, _ := alice.resolveMentions("hi @BOB and @nobody"); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("Direct mentions should reach connected users, got %v", got)
+	}
+
+	// Users can list groups but not manage them
+	alice.handleCommand(":group create devs")
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "Only admins") {
+		t.Errorf("Users should not create groups, got %q", msg.Content)
+	}
+	root.handleCommand(":group create Devs")
+	if msg := nextTextMessage(t, root); !strings.HasPrefix(msg.Content, "Created @devs") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	root.handleCommand(":group add devs @alice dave root")
+	if msg := nextTextMessage(t, root); msg.Content != "@devs now has 3 members" {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	root.handleCommand(":group remove devs root")
+	nextTextMessage(t, root)
+
+	// Members are reached whether they're online or not; the sender never is
+	if got, _ := alice.resolveMentions("@devs deploy is done"); !reflect.DeepEqual(got, []string{"dave"}) {
+		t.Errorf("@devs should reach its other members, got %v", got)
+	}
+	alice.handleCommand(":group show devs")
+	if msg := nextTextMessage(t, alice); msg.Content != "@devs members: alice, dave" {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+
+	root.handleCommand(":group create oncall admins-only")
+	nextTextMessage(t, root)
+	if _, err := alice.resolveMentions("@oncall wake up"); err == nil {
+		t.Error("Admins-only groups should be refused for users")
+	}
+	for _, name := range []string{"here", "admins", "Bad Name", strings.Repeat("a", 33), "oncall"} {
+		if err := hub.CreateMentionGroup(name, false, "root"); err == nil {
+			t.Errorf("Expected creating group %q to fail", name)
+		}
+	}
+
+	// Groups survive a reload from the database
+	hub.groups = newMentionGroupCache()
+	hub.ReloadMentionGroups()
+	groups := hub.MentionGroups()
+	if len(groups) != 2 || groups[0].Name != "devs" || !groups[1].AdminsOnly {
+		t.Errorf("Unexpected reloaded groups %+v", groups)
+	}
+	alice.handleCommand(":group list")
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "@devs - 2 members") || !strings.Contains(msg.Content, "@oncall - 0 members (admins only)") {
+		t.Errorf("Unexpected group list %q", msg.Content)
+	}
+
+	root.handleCommand(":group delete devs")
+	nextTextMessage(t, root)
+	if got, _ := alice.resolveMentions("@devs anyone?"); len(got) != 0 {
+		t.Errorf("A deleted group should reach nobody, got %v", got)
+	}
+}
+
+func TestMentionsReachOfflineUsersAndDisplayNames(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	if err := db.InsertMessage(shared.Message{Sender: "Carol", Content: "back tomorrow", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	alice := &Client{hub: hub, username: "alice", send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- alice
+	hub.register <- bob
+	if err := hub.SetDisplayName("bob", "Bobby"); err != nil {
+		t.Fatalf("SetDisplayName failed: %v", err)
+	}
+	hub.unregister <- bob
+
+	if got, _ := alice.resolveMentions("@CAROL see above"); !reflect.DeepEqual(got, []string{"carol"}) {
+		t.Errorf("Offline users from the database should be reached, got %v", got)
+	}
+	if got, _ := alice.resolveMentions("thanks @bobby and @nobody"); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("Display names should resolve to the user, got %v", got)
+	}
+
+	// History gets the same direct mentions, minus the sender
+	msg := shared.Message{Sender: "carol", Content: "@here @Carol @Bob thanks"}
+	if got := hub.historyMentions(msg); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Errorf("historyMentions = %v, want [bob]", got)
+	}
+	msg.Type = shared.FileMessageType
+	if got := hub.historyMentions(msg); got != nil {
+		t.Errorf("Only text messages carry mentions, got %v", got)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"log"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Metrics history: every minute the hub samples users online, sessions
+// opened, messages posted and heap size into a minute bucket, and folds it
+// into the current hour bucket. Both are saved to the database, so the
+// Metrics tabs can chart the last day or week across restarts.
+
+const (
+	rollupMinute = "minute"
+	rollupHour   = "hour"
+
+	minuteRollupRetention = 48 * time.Hour
+	hourRollupRetention   = 30 * 24 * time.Hour
+)
+
+// metricsRanges maps a history range to the bucket size that charts it
+var metricsRanges = map[string]struct {
+	resolution string
+	span       time.Duration
+}{
+	"hour": {rollupMinute, time.Hour},
+	"day":  {rollupHour, 24 * time.Hour},
+	"week": {rollupHour, 7 * 24 * time.Hour},
+}
+
+// metricsRangeNames are the history ranges in the order the panels offer them
+var metricsRangeNames = []string{"hour", "day", "week"}
+
+// metricsRecorder turns counters into per-minute deltas and keeps the hour
+// bucket being filled
+type metricsRecorder struct {
+	mu           sync.Mutex
+	lastMessages int64
+	lastConns    int
+	hour         MetricsRollup
+}
+
+// startMetricsHistory records a sample every minute
+func (h *Hub) startMetricsHistory() {
+	if h.db == nil {
+		return
+	}
+	h.loadMetricsHistory(time.Now())
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			h.recordMetrics(now)
+		}
+	}()
+}
+
+// loadMetricsHistory seeds the hour being filled from the database, so a
+// restart adds to it rather than replacing it, and prunes old buckets
+func (h *Hub) loadMetricsHistory(now time.Time) {
+	r := h.history
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hour = MetricsRollup{Resolution: rollupHour, Bucket: now.Truncate(time.Hour)}
+	if rows, err := h.db.GetMetricsRollups(rollupHour, r.hour.Bucket); err != nil {
+		log.Printf("Warning: failed to load metrics history: %v", err)
+	} else if len(rows) > 0 && rows[0].Bucket.Equal(r.hour.Bucket) {
+		r.hour = rows[0]
+	}
+	h.pruneMetricsHistory(now)
+}
+
+// recordMetrics saves the minute ending at now and updates its hour
+func (h *Hub) recordMetrics(now time.Time) {
+	online, messages := h.usage.totals()
+	conns := h.GetTotalConnections()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	r := h.history
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	minute := MetricsRollup{
+		Resolution:  rollupMinute,
+		Bucket:      now.Add(-time.Minute).Truncate(time.Minute),
+		PeakUsers:   online,
+		Connections: conns - r.lastConns,
+		Messages:    int(messages - r.lastMessages),
+		PeakMemory:  m.Alloc,
+	}
+	r.lastConns, r.lastMessages = conns, messages
+	if err := h.db.SaveMetricsRollup(minute); err != nil {
+		log.Printf("Warning: failed to save metrics history: %v", err)
+		return
+	}
+
+	if hour := minute.Bucket.Truncate(time.Hour); !hour.Equal(r.hour.Bucket) {
+		r.hour = MetricsRollup{Resolution: rollupHour, Bucket: hour}
+		h.pruneMetricsHistory(now)
+	}
+	r.hour.PeakUsers = max(r.hour.PeakUsers, minute.PeakUsers)
+	r.hour.Connections += minute.Connections
+	r.hour.Messages += minute.Messages
+	r.hour.PeakMemory = max(r.hour.PeakMemory, minute.PeakMemory)
+	if err := h.db.SaveMetricsRollup(r.hour); err != nil {
+		log.Printf("Warning: failed to save metrics history: %v", err)
+	}
+}
+
+// pruneMetricsHistory drops buckets past their retention
+func (h *Hub) pruneMetricsHistory(now time.Time) {
+	if err := h.db.PruneMetricsRollups(rollupMinute, now.Add(-minuteRollupRetention)); err != nil {
+		log.Printf("Warning: failed to prune metrics history: %v", err)
+	}
+	if err := h.db.PruneMetricsRollups(rollupHour, now.Add(-hourRollupRetention)); err != nil {
+		log.Printf("Warning: failed to prune metrics history: %v", err)
+	}
+}
+
+// MetricsHistory returns the stored buckets charting a range ("hour", "day"
+// or "week"), oldest first
+func (h *Hub) MetricsHistory(rangeName string, now time.Time) ([]MetricsRollup, error) {
+	rng, ok := metricsRanges[rangeName]
+	if !ok || h.db == nil {
+		return nil, nil
+	}
+	since := now.Add(-rng.span).Truncate(time.Minute)
+	if rng.resolution == rollupHour {
+		since = now.Add(-rng.span).Truncate(time.Hour)
+	}
+	return h.db.GetMetricsRollups(rng.resolution, since)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestMetricsHistory(t *testing.T) {
+	hub, db := CreateTestHub(t)
+	defer db.Close()
+
+	start := time.Now().Truncate(time.Hour).Add(-time.Hour + 58*time.Minute)
+	hub.usage.connected(&Client{username: "alice", connectedAt: start})
+	hub.usage.message(roomChannel, shared.Message{Sender: "alice", Content: "one"})
+	hub.usage.message(roomChannel, shared.Message{Sender: "alice", Content: "two"})
+	hub.loadMetricsHistory(start)
+	hub.recordMetrics(start.Add(time.Minute))
+	hub.usage.message(roomChannel, shared.Message{Sender: "alice", Content: "three"})
+	hub.recordMetrics(start.Add(2 * time.Minute))
+	// The next minute falls in a new hour
+	hub.recordMetrics(start.Add(3 * time.Minute))
+
+	minutes, err := db.GetMetricsRollups(rollupMinute, start)
+	if err != nil || len(minutes) != 3 {
+		t.Fatalf("minutes = %+v, %v", minutes, err)
+	}
+	if minutes[0].Messages != 2 || minutes[1].Messages != 1 || minutes[2].Messages != 0 || minutes[0].PeakUsers != 1 {
+		t.Errorf("minutes = %+v", minutes)
+	}
+	hours, _ := db.GetMetricsRollups(rollupHour, start.Truncate(time.Hour))
+	if len(hours) != 2 || hours[0].Messages != 3 || hours[1].Messages != 0 {
+		t.Fatalf("hours = %+v", hours)
+	}
+
+	// A restart adds to the stored hour rather than replacing it
+	restarted := NewHub(t.TempDir(), t.TempDir(), "", db)
+	restarted.loadMetricsHistory(start.Add(time.Minute))
+	restarted.usage.message(roomChannel, shared.Message{Sender: "bob", Content: "back"})
+	restarted.recordMetrics(start.Add(time.Minute + 30*time.Second))
+	if hours, _ = db.GetMetricsRollups(rollupHour, start.Truncate(time.Hour)); hours[0].Messages != 4 {
+		t.Errorf("hour after restart = %+v", hours[0])
+	}
+
+	if got, _ := hub.MetricsHistory("week", start.Add(3*time.Minute)); len(got) != 2 {
+		t.Errorf("week history = %+v", got)
+	}
+	if got, _ := hub.MetricsHistory("decade", time.Now()); got != nil {
+		t.Errorf("unknown range = %+v", got)
+	}
+}
+
+func TestAdminWeb_MetricsHistory(t *testing.T) {
+	_, hub, cfg, cleanup := setupTestServerEnv(t)
+	defer cleanup()
+
+	now := time.Now()
+	hub.loadMetricsHistory(now)
+	hub.usage.message(roomChannel, shared.Message{Sender: "alice", Content: "hello"})
+	hub.recordMetrics(now)
+
+	was := NewWebAdminServer(hub, nil, cfg)
+	mux := http.NewServeMux()
+	was.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := &adminWebClient{t: t, ts: ts}
+	c.login(map[string]string{"key": cfg.AdminKey})
+	var res struct {
+		Resolution string         `json:"resolution"`
+		Points     []historyPoint `json:"points"`
+	}
+	if status := c.do(http.MethodGet, "metrics/history?range=hour", nil, &res); status != http.StatusOK {
+		t.Fatalf("history: %d", status)
+	}
+	if res.Resolution != rollupMinute || len(res.Points) != 1 || res.Points[0].Messages != 1 {
+		t.Errorf("history = %+v", res)
+	}
+	if status := c.do(http.MethodGet, "metrics/history?range=year", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("unknown range: %d, want 400", status)
+	}
+}
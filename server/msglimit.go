@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// DefaultMaxMessageBytes caps the content of a chat message or command.
+// Longer pastes belong in a snippet, which clients offer automatically.
+const DefaultMaxMessageBytes = 16 * 1024
+
+// encryptedOverhead is what E2E encryption adds to a message before it is
+// base64 encoded: a 12 byte nonce and a 16 byte tag
+const encryptedOverhead = 28
+
+// MessageLimits is the "limits" WebSocket payload so clients can offer a
+// snippet before the server refuses an oversized message, and hold back
+// mentions the server would refuse
+type MessageLimits struct {
+	MaxMessageBytes      int `json:"max_message_bytes"`
+	MentionLimit         int `json:"mention_limit,omitempty"`
+	MentionWindowSeconds int `json:"mention_window_seconds,omitempty"`
+}
+
+// SetMaxMessageBytes sets the largest message content accepted; 0 or less
+// restores the default
+func (h *Hub) SetMaxMessageBytes(n int) {
+	if n <= 0 {
+		n = DefaultMaxMessageBytes
+	}
+	h.maxMessageBytes = n
+}
+
+// MaxMessageBytes returns the largest message content accepted
+func (h *Hub) MaxMessageBytes() int {
+	if h.maxMessageBytes <= 0 {
+		return DefaultMaxMessageBytes
+	}
+	return h.maxMessageBytes
+}
+
+// limitsMessage builds the "limits" WebSocket message for clients
+func (h *Hub) limitsMessage() WSMessage {
+	limits := MessageLimits{MaxMessageBytes: h.MaxMessageBytes()}
+	if limit, window := h.MentionLimit(); limit > 0 {
+		limits.MentionLimit = limit
+		limits.MentionWindowSeconds = int(window / time.Second)
+	}
+	payload, _ := json.Marshal(limits)
+	return WSMessage{Type: "limits", Data: payload}
+}
+
+// checkMessageSize reports whether msg is within the message size limit,
+// telling the sender why not. Files, snippets, art, voice notes and
+// diagrams have their own limits.
+func (c *Client) checkMessageSize(msg shared.Message) bool {
+	switch msg.Type {
+	case shared.FileMessageType, shared.SnippetMessageType, shared.ArtMessageType, shared.AudioMessageType, shared.DiagramMessageType:
+		return true
+	}
+	limit := c.hub.MaxMessageBytes()
+	if msg.Encrypted {
+		// Ciphertext is larger than the text the sender typed
+		limit = base64.StdEncoding.EncodedLen(limit + encryptedOverhead)
+	}
+	if len(msg.Content) <= limit {
+		return true
+	}
+	log.Printf("Rejected message from %s: too large (%d bytes, limit %d)", c.username, len(msg.Content), limit)
+	c.reply(fmt.Sprintf("Message not sent: it is %d bytes, over this server's %d byte limit. Share long text as a snippet instead.",
+		len(msg.Content), limit))
+	return false
+}
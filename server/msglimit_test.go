@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestCheckMessageSize(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "", db)
+	hub.SetMaxMessageBytes(10)
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+
+	if !bob.checkMessageSize(shared.Message{Content: "short"}) {
+		t.Error("Expected a short message to be accepted")
+	}
+	if bob.checkMessageSize(shared.Message{Content: strings.Repeat("x", 11)}) {
+		t.Error("Expected an oversized message to be refused")
+	}
+	if reply := nextTextMessage(t, bob); !strings.Contains(reply.Content, "over this server's 10 byte limit") {
+		t.Errorf("Unexpected reply: %q", reply.Content)
+	}
+
+	long := strings.Repeat("x", 100)
+	for _, typ := range []shared.MessageType{shared.FileMessageType, shared.SnippetMessageType, shared.ArtMessageType} {
+		if !bob.checkMessageSize(shared.Message{Type: typ, Content: long}) {
+			t.Errorf("Expected %s messages to have their own limit", typ)
+		}
+	}
+
+	// 10 bytes of plaintext encrypts to 52 base64 characters
+	if !bob.checkMessageSize(shared.Message{Content: strings.Repeat("A", 52), Encrypted: true}) {
+		t.Error("Expected encryption overhead to be allowed for")
+	}
+	if bob.checkMessageSize(shared.Message{Content: strings.Repeat("A", 56), Encrypted: true}) {
+		t.Error("Expected oversized ciphertext to be refused")
+	}
+	<-bob.send
+
+	hub.SetMaxMessageBytes(0)
+	if got := hub.MaxMessageBytes(); got != DefaultMaxMessageBytes {
+		t.Errorf("Expected the default limit, got %d", got)
+	}
+	var limits MessageLimits
+	msg := hub.limitsMessage()
+	if err := json.Unmarshal(msg.Data, &limits); err != nil || msg.Type != "limits" {
+		t.Fatalf("Bad limits message: %v %+v", err, msg)
+	}
+	if limits.MaxMessageBytes != DefaultMaxMessageBytes {
+		t.Errorf("Expected %d in limits message, got %d", DefaultMaxMessageBytes, limits.MaxMessageBytes)
+	}
+}
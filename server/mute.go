@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Shadow mute durations for :mute and the admin panel
+const (
+	defaultMuteDuration = time.Hour
+	maxMuteDuration     = 30 * 24 * time.Hour
+)
+
+// MuteUser shadow-mutes a user: their messages are still accepted but only
+// echoed back to their own sessions until the mute expires. Muting again
+// replaces the expiry.
+func (h *Hub) MuteUser(username string, adminUsername string, d time.Duration) time.Time {
+	h.banMutex.Lock()
+	defer h.banMutex.Unlock()
+	until := time.Now().Add(d)
+	h.mutes[strings.ToLower(username)] = until
+	AdminLogger.Info("User muted", map[string]interface{}{
+		"muted_user": username,
+		"admin":      adminUsername,
+		"until":      until.Format("2006-01-02 15:04:05"),
+	})
+	return until
+}
+
+// UnmuteUser lifts a shadow mute early
+func (h *Hub) UnmuteUser(username string, adminUsername string) bool {
+	h.banMutex.Lock()
+	defer h.banMutex.Unlock()
+	lu := strings.ToLower(username)
+	if until, ok := h.mutes[lu]; !ok || time.Now().After(until) {
+		delete(h.mutes, lu)
+		return false
+	}
+	delete(h.mutes, lu)
+	AdminLogger.Info("User unmuted", map[string]interface{}{
+		"unmuted_user": username,
+		"admin":        adminUsername,
+	})
+	return true
+}
+
+// MutedUntil returns when a user's shadow mute expires, if they are muted
+func (h *Hub) MutedUntil(username string) (time.Time, bool) {
+	h.banMutex.RLock()
+	defer h.banMutex.RUnlock()
+	until, ok := h.mutes[strings.ToLower(username)]
+	if !ok || time.Now().After(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// IsUserMuted reports whether a user is currently shadow-muted
+func (h *Hub) IsUserMuted(username string) bool {
+	_, muted := h.MutedUntil(username)
+	return muted
+}
+
+// echoMuted delivers a muted user's message to their own sessions only, so it
+// looks sent to them while nobody else sees it. Nothing is stored.
+func (c *Client) echoMuted(msg shared.Message) {
+	msg.Sender = c.username
+	msg.CreatedAt = time.Now()
+	if msg.Type == shared.SnippetMessageType {
+		msg.Type = shared.TextMessage
+		msg.Snippet = nil
+	}
+	log.Printf("Shadow-muted message from %s not broadcast", c.username)
+	c.hub.direct <- directMessage{username: c.username, msg: msg}
+}
+
+// parseMuteDuration reads the optional duration argument of :mute
+func parseMuteDuration(args []string) (time.Duration, error) {
+	if len(args) == 0 {
+		return defaultMuteDuration, nil
+	}
+	d, err := time.ParseDuration(args[0])
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 30m, 1h, 24h)", args[0])
+	}
+	if d > maxMuteDuration {
+		return 0, fmt.Errorf("duration too long (max %s)", maxMuteDuration)
+	}
+	return d, nil
+}
+
+// handleMuteCommand handles ":mute <user> [duration]" and ":unmute <user>"
+func (c *Client) handleMuteCommand(parts []string) {
+	if len(parts) < 2 {
+		c.reply("Usage: " + parts[0] + " <username> [duration]")
+		return
+	}
+	target := parts[1]
+	if err := validateUsername(target); err != nil {
+		c.reply("Invalid username: " + err.Error())
+		return
+	}
+	if parts[0] == ":unmute" {
+		if c.hub.UnmuteUser(target, c.username) {
+			c.reply("User '" + target + "' has been unmuted.")
+		} else {
+			c.reply("User '" + target + "' is not muted.")
+		}
+		return
+	}
+	if strings.EqualFold(target, c.username) {
+		c.reply("You cannot mute yourself.")
+		return
+	}
+	d, err := parseMuteDuration(parts[2:])
+	if err != nil {
+		c.reply(err.Error())
+		return
+	}
+	until := c.hub.MuteUser(target, c.username, d)
+	c.reply(fmt.Sprintf("User '%s' is shadow-muted until %s. Their messages are only shown to themselves.", target, until.Format("2006-01-02 15:04")))
+}
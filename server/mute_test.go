@@ -0,0 +1,99 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestHubMuteUser(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	until := hub.MuteUser("Bob", "admin", 30*time.Minute)
+	if got, ok := hub.MutedUntil("bob"); !ok || !got.Equal(until) {
+		t.Errorf("MutedUntil = %v, %v; want %v", got, ok, until)
+	}
+	if hub.IsUserBanned("bob") {
+		t.Error("Muting should not ban")
+	}
+
+	// Expired mutes no longer apply and are cleaned up
+	hub.banMutex.Lock()
+	hub.mutes["bob"] = time.Now().Add(-time.Minute)
+	hub.banMutex.Unlock()
+	if hub.IsUserMuted("bob") {
+		t.Error("Expired mute should not apply")
+	}
+	hub.CleanupExpiredBans()
+	if len(hub.mutes) != 0 {
+		t.Errorf("Expected expired mute to be removed, got %v", hub.mutes)
+	}
+
+	hub.MuteUser("bob", "admin", time.Hour)
+	if !hub.UnmuteUser("BOB", "admin") || hub.IsUserMuted("bob") {
+		t.Error("Expected unmute to lift the mute")
+	}
+	if hub.UnmuteUser("bob", "admin") {
+		t.Error("Unmuting a user who is not muted should report false")
+	}
+}
+
+func TestParseMuteDuration(t *testing.T) {
+	if d, err := parseMuteDuration(nil); err != nil || d != defaultMuteDuration {
+		t.Errorf("Default duration = %v, %v", d, err)
+	}
+	if d, err := parseMuteDuration([]string{"90m"}); err != nil || d != 90*time.Minute {
+		t.Errorf("parseMuteDuration(90m) = %v, %v", d, err)
+	}
+	for _, bad := range []string{"soon", "-1h", "0s", "1000h"} {
+		if _, err := parseMuteDuration([]string{bad}); err == nil {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+}
+
+func TestShadowMute(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	admin := &Client{hub: hub, username: "root", isAdmin: true, send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- admin
+	hub.register <- bob
+
+	bob.handleCommand(":mute root")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "admin privileges") {
+		t.Errorf("Non-admins should not mute, got %q", msg.Content)
+	}
+
+	admin.handleCommand(":mute bob 2h")
+	if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "shadow-muted until") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if !hub.IsUserMuted("bob") {
+		t.Fatal("Expected bob to be muted")
+	}
+
+	bob.echoMuted(shared.Message{Sender: "bob", Content: "anyone there?", Type: shared.TextMessage})
+	if msg := nextTextMessage(t, bob); msg.Content != "anyone there?" || msg.Sender != "bob" {
+		t.Errorf("Muted user should see their own message, got %+v", msg)
+	}
+	select {
+	case msg := <-admin.send:
+		if m, ok := msg.(shared.Message); ok {
+			t.Errorf("Others should not see a muted user's message, got %+v", m)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	admin.handleCommand(":unmute bob")
+	if msg := nextTextMessage(t, admin); msg.Content != "User 'bob' has been unmuted." {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+}
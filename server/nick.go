@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDisplayNameRunes caps display names set with :nick
+const maxDisplayNameRunes = 32
+
+// nickChange asks the hub goroutine to set a display name, since checking
+// it is free means looking at every connected client
+type nickChange struct {
+	username string
+	name     string
+	result   chan error
+}
+
+// validateDisplayName checks a display name is printable, single-line and
+// can't be mistaken for a command, mention or system message
+func validateDisplayName(name string) error {
+	if name == "" {
+		return fmt.Errorf("display name cannot be empty")
+	}
+	if utf8.RuneCountInString(name) > maxDisplayNameRunes {
+		return fmt.Errorf("display name too long (max %d characters)", maxDisplayNameRunes)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || unicode.In(r, unicode.Zl, unicode.Zp) {
+			return fmt.Errorf("display name cannot contain control characters")
+		}
+	}
+	if strings.HasPrefix(name, ":") || strings.HasPrefix(name, "@") {
+		return fmt.Errorf("display name cannot start with : or @")
+	}
+	if strings.EqualFold(name, "System") || strings.EqualFold(name, welcomeBotName) {
+		return fmt.Errorf("display name is reserved")
+	}
+	return nil
+}
+
+// DisplayName returns the display name set by username, or "" if none
+func (h *Hub) DisplayName(username string) string {
+	h.namesMutex.RLock()
+	defer h.namesMutex.RUnlock()
+	return h.displayNames[strings.ToLower(username)]
+}
+
+// connectedDisplayNames returns the display names of the given users keyed
+// by canonical username, for the user list
+func (h *Hub) connectedDisplayNames(usernames []string) map[string]string {
+	h.namesMutex.RLock()
+	defer h.namesMutex.RUnlock()
+	names := make(map[string]string)
+	for _, u := range usernames {
+		if name := h.displayNames[strings.ToLower(u)]; name != "" {
+			names[u] = name
+		}
+	}
+	return names
+}
+
+// SetDisplayName sets or, with an empty name, clears username's display name
+// and re-sends the user list. It must not be called from the hub goroutine.
+func (h *Hub) SetDisplayName(username, name string) error {
+	name = strings.TrimSpace(name)
+	if name != "" {
+		if err := validateDisplayName(name); err != nil {
+			return err
+		}
+	}
+	change := nickChange{username: username, name: name, result: make(chan error, 1)}
+	h.nickChanges <- change
+	return <-change.result
+}
+
+// applyNickChange runs on the hub goroutine. A name may not match another
+// connected user's username or display name, so nobody can pass themselves
+// off as someone else.
+func (h *Hub) applyNickChange(change nickChange) error {
+	lu := strings.ToLower(change.username)
+	if change.name != "" {
+		for client := range h.clients.all() {
+			other := strings.ToLower(client.username)
+			if other != lu && (strings.EqualFold(client.username, change.name) || strings.EqualFold(h.DisplayName(other), change.name)) {
+				return fmt.Errorf("%q is already in use", change.name)
+			}
+		}
+	}
+	h.namesMutex.Lock()
+	if change.name == "" {
+		delete(h.displayNames, lu)
+	} else {
+		h.displayNames[lu] = change.name
+	}
+	h.namesMutex.Unlock()
+	h.broadcastUserList()
+	return nil
+}
+
+// handleNickCommand sets the caller's display name: ":nick <name>" sets it,
+// ":nick" on its own clears it. Admin commands and mentions keep using the
+// canonical username.
+func (c *Client) handleNickCommand(name string) {
+	name = strings.TrimSpace(name)
+	if err := c.hub.SetDisplayName(c.username, name); err != nil {
+		c.reply("Could not set display name: " + err.Error())
+		return
+	}
+	if name == "" {
+		log.Printf("User %s cleared their display name", c.username)
+		c.reply("Display name cleared.")
+	} else {
+		log.Printf("User %s set display name to %q", c.username, name)
+		c.reply(fmt.Sprintf("Display name set to %s. Others can still mention you as @%s.", name, c.username))
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextUserList waits for a user list carrying the given display name count
+func nextUserList(t *testing.T, c *Client, names int) UserList {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case msg := <-c.send:
+			if ws, ok := msg.(WSMessage); ok && ws.Type == "userlist" {
+				var ul UserList
+				if err := json.Unmarshal(ws.Data, &ul); err != nil {
+					t.Fatalf("Invalid user list: %v", err)
+				}
+				if len(ul.DisplayNames) == names {
+					return ul
+				}
+			}
+		case <-deadline:
+			t.Fatalf("Timed out waiting for user list with %d display names", names)
+			return UserList{}
+		}
+	}
+}
+
+func TestValidateDisplayName(t *testing.T) {
+	for _, name := range []string{"Cody 🚀", "Zoë", "a"} {
+		if err := validateDisplayName(name); err != nil {
+			t.Errorf("%q should be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ":admin", "@bob", "System", "two\nlines", "tab\there", strings.Repeat("x", 33)} {
+		if err := validateDisplayName(name); err == nil {
+			t.Errorf("%q should be rejected", name)
+		}
+	}
+}
+
+func TestNickCommand(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	alice := &Client{hub: hub, username: "alice", send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- alice
+	hub.register <- bob
+
+	alice.handleCommand(":nick Cody 🚀")
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "Display name set to Cody 🚀") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if ul := nextUserList(t, bob, 1); ul.DisplayNames["alice"] != "Cody 🚀" {
+		t.Errorf("Expected alice's display name in the user list, got %+v", ul.DisplayNames)
+	}
+
+	for _, taken := range []string{":nick alice", ":nick CODY 🚀"} {
+		bob.handleCommand(taken)
+		if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "already in use") {
+			t.Errorf("%s: expected refusal, got %q", taken, msg.Content)
+		}
+	}
+	if hub.DisplayName("bob") != "" {
+		t.Errorf("Refused names should not be stored")
+	}
+
+	alice.handleCommand(":nick")
+	if msg := nextTextMessage(t, alice); msg.Content != "Display name cleared." {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	if hub.DisplayName("alice") != "" {
+		t.Errorf("Expected display name to be cleared")
+	}
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// maxNotesBytes caps a channel's shared notes
+const maxNotesBytes = 64 * 1024
+
+// notesLockTimeout frees the edit lock when its holder stops saving, so a
+// forgotten editor doesn't keep everyone else out
+const notesLockTimeout = 10 * time.Minute
+
+// notesLock is the session allowed to change a channel's notes
+type notesLock struct {
+	username  string
+	sessionID string
+	until     time.Time
+}
+
+// notesBoard caches each channel's shared notes and who is editing them.
+// One session edits at a time; everyone else sees each save as it happens.
+type notesBoard struct {
+	mu    sync.Mutex
+	notes map[string]shared.ChannelNotes
+	locks map[string]notesLock
+}
+
+func newNotesBoard() *notesBoard {
+	return &notesBoard{notes: make(map[string]shared.ChannelNotes), locks: make(map[string]notesLock)}
+}
+
+// load returns a channel's notes, reading them from the database the first
+// time. The caller holds b.mu.
+func (b *notesBoard) load(db Database, channel string) (shared.ChannelNotes, error) {
+	if n, ok := b.notes[channel]; ok {
+		return n, nil
+	}
+	if db == nil {
+		return shared.ChannelNotes{}, fmt.Errorf("shared notes require a database")
+	}
+	n, err := db.GetChannelNotes(channel)
+	if err != nil {
+		return shared.ChannelNotes{}, err
+	}
+	b.notes[channel] = n
+	return n, nil
+}
+
+// editor returns who holds a channel's edit lock, dropping it once it has
+// lapsed. The caller holds b.mu.
+func (b *notesBoard) editor(channel string, now time.Time) (notesLock, bool) {
+	lock, ok := b.locks[channel]
+	if ok && now.After(lock.until) {
+		delete(b.locks, channel)
+		return notesLock{}, false
+	}
+	return lock, ok
+}
+
+// withEditor fills in the current editor. The caller holds b.mu.
+func (b *notesBoard) withEditor(n shared.ChannelNotes, now time.Time) shared.ChannelNotes {
+	n.Editor = ""
+	if lock, ok := b.editor(n.Channel, now); ok {
+		n.Editor = lock.username
+	}
+	return n
+}
+
+// ChannelNotes returns a channel's shared notes and who is editing them
+func (h *Hub) ChannelNotes(channel string) (shared.ChannelNotes, error) {
+	h.sharedNotes.mu.Lock()
+	defer h.sharedNotes.mu.Unlock()
+	n, err := h.sharedNotes.load(h.db, channel)
+	if err != nil {
+		return shared.ChannelNotes{}, err
+	}
+	return h.sharedNotes.withEditor(n, time.Now()), nil
+}
+
+// LockNotes lets a session edit a channel's notes until it is done, it
+// disconnects, or it goes notesLockTimeout without saving. Everyone is told
+// who is editing.
+func (h *Hub) LockNotes(channel string, c *Client) error {
+	now := time.Now()
+	h.sharedNotes.mu.Lock()
+	n, err := h.sharedNotes.load(h.db, channel)
+	if err != nil {
+		h.sharedNotes.mu.Unlock()
+		return err
+	}
+	if lock, ok := h.sharedNotes.editor(channel, now); ok && lock.sessionID != c.sessionID {
+		h.sharedNotes.mu.Unlock()
+		if lock.username == c.username {
+			return fmt.Errorf("you are already editing the notes in another session")
+		}
+		return fmt.Errorf("%s is editing the notes", lock.username)
+	}
+	h.sharedNotes.locks[channel] = notesLock{username: c.username, sessionID: c.sessionID, until: now.Add(notesLockTimeout)}
+	n = h.sharedNotes.withEditor(n, now)
+	h.sharedNotes.mu.Unlock()
+
+	h.broadcast <- notesMessage(n)
+	return nil
+}
+
+// UnlockNotes ends a session's edit of a channel's notes, if it holds the
+// lock, and tells everyone the notes are free
+func (h *Hub) UnlockNotes(channel string, c *Client) {
+	h.sharedNotes.mu.Lock()
+	lock, ok := h.sharedNotes.locks[channel]
+	if !ok || lock.sessionID != c.sessionID || lock.username != c.username {
+		h.sharedNotes.mu.Unlock()
+		return
+	}
+	delete(h.sharedNotes.locks, channel)
+	n := h.sharedNotes.notes[channel]
+	h.sharedNotes.mu.Unlock()
+
+	h.broadcast <- notesMessage(n)
+}
+
+// releaseNotesLocks frees every edit lock a disconnecting session held
+func (h *Hub) releaseNotesLocks(c *Client) {
+	h.sharedNotes.mu.Lock()
+	var channels []string
+	for channel, lock := range h.sharedNotes.locks {
+		if lock.sessionID == c.sessionID && lock.username == c.username {
+			channels = append(channels, channel)
+		}
+	}
+	h.sharedNotes.mu.Unlock()
+	for _, channel := range channels {
+		h.UnlockNotes(channel, c)
+	}
+}
+
+// SaveNotes stores new text for a channel's notes and sends it to everyone.
+// Only the session holding the edit lock may save; saving keeps the lock.
+func (h *Hub) SaveNotes(channel, text string, c *Client) (shared.ChannelNotes, error) {
+	if len(text) > maxNotesBytes {
+		return shared.ChannelNotes{}, fmt.Errorf("notes are limited to %d KB", maxNotesBytes/1024)
+	}
+	now := time.Now()
+	h.sharedNotes.mu.Lock()
+	lock, ok := h.sharedNotes.editor(channel, now)
+	if !ok || lock.sessionID != c.sessionID || lock.username != c.username {
+		h.sharedNotes.mu.Unlock()
+		return shared.ChannelNotes{}, fmt.Errorf("start editing with :notes edit first")
+	}
+	n, err := h.sharedNotes.load(h.db, channel)
+	if err != nil {
+		h.sharedNotes.mu.Unlock()
+		return shared.ChannelNotes{}, err
+	}
+	n.Text = text
+	n.Version++
+	n.UpdatedBy = c.username
+	n.UpdatedAt = now
+	n.Editor = ""
+	if err := h.db.SaveChannelNotes(n); err != nil {
+		h.sharedNotes.mu.Unlock()
+		return shared.ChannelNotes{}, err
+	}
+	h.sharedNotes.notes[channel] = n
+	lock.until = now.Add(notesLockTimeout)
+	h.sharedNotes.locks[channel] = lock
+	n.Editor = lock.username
+	h.sharedNotes.mu.Unlock()
+
+	log.Printf("Shared notes for %s saved by %s (version %d, %d bytes)", channel, c.username, n.Version, len(text))
+	h.broadcast <- notesMessage(n)
+	return n, nil
+}
+
+// notesMessage builds the "notes" WebSocket message for clients
+func notesMessage(n shared.ChannelNotes) WSMessage {
+	payload, _ := json.Marshal(n)
+	return WSMessage{Type: "notes", Data: payload}
+}
+
+// saveNotes handles a NotesMessageType from this client: the new text of
+// the room's notes
+func (c *Client) saveNotes(msg shared.Message) {
+	if msg.Encrypted {
+		c.reply("Encrypted text cannot be saved to the shared notes.")
+		return
+	}
+	if _, err := c.hub.SaveNotes(roomChannel, msg.Content, c); err != nil {
+		c.reply("Notes not saved: " + err.Error())
+	}
+}
+
+// handleNotesCommand handles ":notes [edit|done]". Plain :notes sends this
+// client the room's notes; edit takes the edit lock and done gives it back.
+func (c *Client) handleNotesCommand(args []string) {
+	if len(args) == 0 {
+		n, err := c.hub.ChannelNotes(roomChannel)
+		if err != nil {
+			c.reply("Could not load the notes: " + err.Error())
+			return
+		}
+		c.enqueue(notesMessage(n))
+		return
+	}
+	switch args[0] {
+	case "edit":
+		if err := c.hub.LockNotes(roomChannel, c); err != nil {
+			c.reply("Cannot edit the notes: " + err.Error())
+		}
+	case "done":
+		c.hub.UnlockNotes(roomChannel, c)
+	default:
+		c.reply("Usage: :notes | :notes edit | :notes done")
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// nextNotes waits for the next "notes" message sent to c
+func nextNotes(t *testing.T, c *Client) shared.ChannelNotes {
+	t.Helper()
+	var n shared.ChannelNotes
+	if err := json.Unmarshal(nextWSMessage(t, c, "notes").Data, &n); err != nil {
+		t.Fatalf("Bad notes payload: %v", err)
+	}
+	return n
+}
+
+func TestSharedNotesLocking(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	alice := &Client{hub: hub, username: "alice", sessionID: "a1", send: make(chan interface{}, 32)}
+	bob := &Client{hub: hub, username: "bob", sessionID: "b1", send: make(chan interface{}, 32)}
+	hub.register <- alice
+	hub.register <- bob
+
+	bob.handleCommand(":notes")
+	if n := nextNotes(t, bob); n.Channel != roomChannel || n.Text != "" || n.Editor != "" {
+		t.Errorf("Expected empty notes, got %+v", n)
+	}
+
+	// Saving needs the edit lock
+	bob.saveNotes(shared.Message{Type: shared.NotesMessageType, Content: "mine"})
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, ":notes edit") {
+		t.Errorf("Expected a refusal, got %q", msg.Content)
+	}
+
+	alice.handleCommand(":notes edit")
+	if n := nextNotes(t, bob); n.Editor != "alice" {
+		t.Errorf("Everyone should see who is editing, got %+v", n)
+	}
+	bob.handleCommand(":notes edit")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "alice is editing") {
+		t.Errorf("Expected the lock to be refused, got %q", msg.Content)
+	}
+
+	alice.saveNotes(shared.Message{Type: shared.NotesMessageType, Content: "Agenda\n- demo"})
+	n := nextNotes(t, bob)
+	if n.Text != "Agenda\n- demo" || n.Version != 1 || n.UpdatedBy != "alice" || n.Editor != "alice" {
+		t.Errorf("Unexpected saved notes %+v", n)
+	}
+	if stored, err := db.GetChannelNotes(roomChannel); err != nil || stored.Text != "Agenda\n- demo" || stored.Version != 1 {
+		t.Errorf("Notes should be persisted, got %+v (%v)", stored, err)
+	}
+	alice.saveNotes(shared.Message{Type: shared.NotesMessageType, Content: strings.Repeat("x", maxNotesBytes+1)})
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "limited") {
+		t.Errorf("Expected oversized notes to be refused, got %q", msg.Content)
+	}
+
+	// Disconnecting frees the lock for the next editor
+	hub.releaseNotesLocks(alice)
+	if n := nextNotes(t, bob); n.Editor != "" || n.Version != 1 {
+		t.Errorf("Expected the lock to be released, got %+v", n)
+	}
+	bob.handleCommand(":notes edit")
+	if n := nextNotes(t, bob); n.Editor != "bob" {
+		t.Errorf("Expected bob to take the lock, got %+v", n)
+	}
+	alice.handleCommand(":notes done")
+	if n, _ := hub.ChannelNotes(roomChannel); n.Editor != "bob" {
+		t.Errorf("Only the editor should release the lock, got editor %q", n.Editor)
+	}
+	bob.handleCommand(":notes done")
+	if n := nextNotes(t, bob); n.Editor != "" {
+		t.Errorf("Expected bob to release the lock, got %+v", n)
+	}
+
+	// A lock left alone lapses
+	hub.sharedNotes.mu.Lock()
+	hub.sharedNotes.locks[roomChannel] = notesLock{username: "bob", sessionID: "b1", until: time.Now().Add(-time.Second)}
+	hub.sharedNotes.mu.Unlock()
+	if n, _ := hub.ChannelNotes(roomChannel); n.Editor != "" {
+		t.Errorf("Expected the stale lock to lapse, got editor %q", n.Editor)
+	}
+}
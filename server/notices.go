@@ -0,0 +1,242 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Longest topic and message of the day accepted
+const (
+	maxTopicLen = 300
+	maxMOTDLen  = 1000
+)
+
+// noticeBoard caches channel topics, the message of the day and the
+// maintenance window
+type noticeBoard struct {
+	mu     sync.RWMutex
+	topics map[string]shared.Topic
+	motd   shared.MOTD
+
+	maintenance      shared.Maintenance
+	maintenanceTimer *time.Timer // fires at the window's next start or end
+	inMaintenance    bool        // the hub is drained for the window
+}
+
+func newNoticeBoard() *noticeBoard {
+	return &noticeBoard{topics: make(map[string]shared.Topic)}
+}
+
+// ReloadNotices reads the room's topic, the message of the day and the
+// maintenance window from the database
+func (h *Hub) ReloadNotices() {
+	if h.db == nil {
+		return
+	}
+	topic, err := h.db.GetChannelTopic(roomChannel)
+	if err != nil {
+		log.Printf("Warning: failed to load channel topic: %v", err)
+	}
+	motd, err := h.db.GetMOTD()
+	if err != nil {
+		log.Printf("Warning: failed to load MOTD: %v", err)
+	}
+	maintenance, err := h.db.GetMaintenance()
+	if err != nil {
+		log.Printf("Warning: failed to load maintenance window: %v", err)
+	}
+	h.notices.mu.Lock()
+	h.notices.topics[roomChannel] = topic
+	h.notices.motd = motd
+	h.notices.maintenance = maintenance
+	h.notices.mu.Unlock()
+	h.armMaintenance()
+}
+
+// Topic returns a channel's topic
+func (h *Hub) Topic(channel string) shared.Topic {
+	h.notices.mu.RLock()
+	defer h.notices.mu.RUnlock()
+	if t, ok := h.notices.topics[channel]; ok {
+		return t
+	}
+	return shared.Topic{Channel: channel}
+}
+
+// MOTD returns the message of the day
+func (h *Hub) MOTD() shared.MOTD {
+	h.notices.mu.RLock()
+	defer h.notices.mu.RUnlock()
+	return h.notices.motd
+}
+
+// SetTopic stores a channel's topic and shows it to everyone connected; an
+// empty text clears it. Line breaks are folded into spaces.
+func (h *Hub) SetTopic(channel, text, adminUsername string) (shared.Topic, error) {
+	if h.db == nil {
+		return shared.Topic{}, fmt.Errorf("topics require a database")
+	}
+	t := shared.Topic{
+		Channel: channel,
+		Text:    strings.Join(strings.Fields(text), " "),
+		SetBy:   adminUsername,
+		SetAt:   time.Now(),
+	}
+	if len(t.Text) > maxTopicLen {
+		return shared.Topic{}, fmt.Errorf("topic is limited to %d characters", maxTopicLen)
+	}
+	if err := h.db.SetChannelTopic(t); err != nil {
+		return shared.Topic{}, err
+	}
+	h.notices.mu.Lock()
+	h.notices.topics[channel] = t
+	h.notices.mu.Unlock()
+	AdminLogger.Info("Channel topic changed", map[string]interface{}{
+		"admin":   adminUsername,
+		"channel": channel,
+		"topic":   t.Text,
+	})
+
+	announcement := fmt.Sprintf("%s set the topic: %s", adminUsername, t.Text)
+	if t.Text == "" {
+		announcement = fmt.Sprintf("%s cleared the topic", adminUsername)
+	}
+	h.broadcast <- topicMessage(t)
+	h.broadcast <- shared.Message{
+		Sender:    "System",
+		Content:   announcement,
+		CreatedAt: t.SetAt,
+		Type:      shared.TextMessage,
+	}
+	return t, nil
+}
+
+// SetMOTD stores the message of the day and shows it to everyone connected;
+// an empty text clears it
+func (h *Hub) SetMOTD(text, adminUsername string) (shared.MOTD, error) {
+	if h.db == nil {
+		return shared.MOTD{}, fmt.Errorf("the MOTD requires a database")
+	}
+	m := shared.MOTD{Text: strings.TrimSpace(text), SetBy: adminUsername, SetAt: time.Now()}
+	if len(m.Text) > maxMOTDLen {
+		return shared.MOTD{}, fmt.Errorf("MOTD is limited to %d characters", maxMOTDLen)
+	}
+	if err := h.db.SetMOTD(m); err != nil {
+		return shared.MOTD{}, err
+	}
+	h.notices.mu.Lock()
+	h.notices.motd = m
+	h.notices.mu.Unlock()
+	AdminLogger.Info("MOTD changed", map[string]interface{}{
+		"admin": adminUsername,
+		"motd":  m.Text,
+	})
+	h.broadcast <- motdMessage(m)
+	return m, nil
+}
+
+// topicMessage builds the "topic" WebSocket message for clients
+func topicMessage(t shared.Topic) WSMessage {
+	payload, _ := json.Marshal(t)
+	return WSMessage{Type: "topic", Data: payload}
+}
+
+// motdMessage builds the "motd" WebSocket message for clients
+func motdMessage(m shared.MOTD) WSMessage {
+	payload, _ := json.Marshal(m)
+	return WSMessage{Type: "motd", Data: payload}
+}
+
+// sendNotices sends a newly connected client the room's topic, the message
+// of the day and the maintenance window, when set
+func (c *Client) sendNotices() {
+	if t := c.hub.Topic(roomChannel); t.Text != "" {
+		c.enqueue(topicMessage(t))
+	}
+	if m := c.hub.MOTD(); m.Text != "" {
+		c.enqueue(motdMessage(m))
+	}
+	if m := c.hub.Maintenance(); m.Scheduled() {
+		c.enqueue(maintenanceMessage(m))
+	}
+}
+
+// handleTopicCommand handles ":topic [set <text>|clear]". Anyone may read
+// the topic; only admins may change it.
+func (c *Client) handleTopicCommand(command string, args []string) {
+	usage := "Usage: :topic | :topic set <text> | :topic clear"
+	if len(args) == 0 {
+		t := c.hub.Topic(roomChannel)
+		if t.Text == "" {
+			c.reply("No topic is set.")
+			return
+		}
+		c.reply(fmt.Sprintf("Topic: %s (set by %s, %s)", t.Text, t.SetBy, t.SetAt.Format("2006-01-02 15:04")))
+		return
+	}
+	var text string
+	switch args[0] {
+	case "set":
+		text = commandRemainder(command, 2)
+		if text == "" {
+			c.reply(usage)
+			return
+		}
+	case "clear":
+	default:
+		c.reply(usage)
+		return
+	}
+	if !c.isAdmin {
+		c.reply("Only admins can change the topic.")
+		return
+	}
+	if _, err := c.hub.SetTopic(roomChannel, text, c.username); err != nil {
+		c.reply("Could not set topic: " + err.Error())
+	}
+}
+
+// handleMOTDCommand handles ":motd [set <text>|clear]". Anyone may read the
+// message of the day; only admins may change it.
+func (c *Client) handleMOTDCommand(command string, args []string) {
+	usage := "Usage: :motd | :motd set <text> | :motd clear"
+	if len(args) == 0 {
+		m := c.hub.MOTD()
+		if m.Text == "" {
+			c.reply("No message of the day is set.")
+			return
+		}
+		c.reply("Message of the day: " + m.Text)
+		return
+	}
+	var text string
+	switch args[0] {
+	case "set":
+		text = commandRemainder(command, 2)
+		if text == "" {
+			c.reply(usage)
+			return
+		}
+	case "clear":
+	default:
+		c.reply(usage)
+		return
+	}
+	if !c.isAdmin {
+		c.reply("Only admins can change the message of the day.")
+		return
+	}
+	if _, err := c.hub.SetMOTD(text, c.username); err != nil {
+		c.reply("Could not set MOTD: " + err.Error())
+		return
+	}
+	if text == "" {
+		c.reply("Message of the day cleared.")
+	}
+}
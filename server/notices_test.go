@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// nextWSMessage waits for the next WebSocket message of the given type,
+// skipping anything else sent to the client
+func nextWSMessage(t *testing.T, c *Client, typ string) WSMessage {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case msg := <-c.send:
+			if ws, ok := msg.(WSMessage); ok && ws.Type == typ {
+				return ws
+			}
+		case <-deadline:
+			t.Fatalf("Timed out waiting for a %s message", typ)
+			return WSMessage{}
+		}
+	}
+}
+
+func TestTopicAndMOTDCommands(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	admin := &Client{hub: hub, username: "root", isAdmin: true, send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- admin
+	hub.register <- bob
+
+	bob.handleCommand(":topic")
+	if msg := nextTextMessage(t, bob); msg.Content != "No topic is set." {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	bob.handleCommand(":topic set Mine now")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "Only admins") {
+		t.Errorf("Users should not change the topic, got %q", msg.Content)
+	}
+
+	admin.handleCommand(":topic set Release   day\tparty")
+	var topic shared.Topic
+	if err := json.Unmarshal(nextWSMessage(t, bob, "topic").Data, &topic); err != nil || topic.Text != "Release day party" || topic.SetBy != "root" {
+		t.Errorf("Unexpected topic payload %+v (%v)", topic, err)
+	}
+	if msg := nextTextMessage(t, bob); msg.Content != "root set the topic: Release day party" {
+		t.Errorf("Unexpected announcement %q", msg.Content)
+	}
+	bob.handleCommand(":topic")
+	if msg := nextTextMessage(t, bob); !strings.HasPrefix(msg.Content, "Topic: Release day party (set by root") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+
+	admin.handleCommand(":motd set Maintenance at 22:00")
+	var motd shared.MOTD
+	if err := json.Unmarshal(nextWSMessage(t, bob, "motd").Data, &motd); err != nil || motd.Text != "Maintenance at 22:00" {
+		t.Errorf("Unexpected MOTD payload %+v (%v)", motd, err)
+	}
+
+	// New connections get both, and they survive a reload from the database
+	hub.notices = newNoticeBoard()
+	hub.ReloadNotices()
+	carol := &Client{hub: hub, username: "carol", send: make(chan interface{}, 4)}
+	carol.sendNotices()
+	if ws := nextWSMessage(t, carol, "topic"); !strings.Contains(string(ws.Data), "Release day party") {
+		t.Errorf("Unexpected topic on connect %s", ws.Data)
+	}
+	if ws := nextWSMessage(t, carol, "motd"); !strings.Contains(string(ws.Data), "Maintenance") {
+		t.Errorf("Unexpected MOTD on connect %s", ws.Data)
+	}
+
+	admin.handleCommand(":topic clear")
+	nextWSMessage(t, bob, "topic")
+	if msg := nextTextMessage(t, bob); msg.Content != "root cleared the topic" {
+		t.Errorf("Unexpected announcement %q", msg.Content)
+	}
+	if _, err := hub.SetTopic(roomChannel, strings.Repeat("a", maxTopicLen+1), "root"); err == nil {
+		t.Error("Overlong topics should be rejected")
+	}
+
+	// Nothing is sent on connect once both are cleared
+	admin.handleCommand(":motd clear")
+	dave := &Client{hub: hub, username: "dave", send: make(chan interface{}, 4)}
+	dave.sendNotices()
+	if len(dave.send) != 0 {
+		t.Errorf("Expected no notices, got %d messages", len(dave.send))
+	}
+}
+
+func TestAdminPanel_NoticeEditor(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+	go panel.hub.Run()
+
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	panel.activeTab = tabSystem
+
+	panel.Update(runes("T"))
+	if panel.noticeEdit == nil || panel.noticeEdit.motd {
+		t.Fatal("Expected T to open the topic editor")
+	}
+	panel.Update(runes("Weekly sync"))
+	panel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if panel.noticeEdit != nil || panel.hub.Topic(roomChannel).Text != "Weekly sync" {
+		t.Errorf("Expected enter to save the topic, got %+v", panel.hub.Topic(roomChannel))
+	}
+
+	panel.Update(runes("m"))
+	if panel.noticeEdit == nil || !panel.noticeEdit.motd {
+		t.Fatal("Expected m to open the MOTD editor")
+	}
+	panel.Update(runes("Be excellent"))
+	if !strings.Contains(panel.renderNotices(), "[enter] save") {
+		t.Error("Expected the editor to replace the summary")
+	}
+	panel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if panel.noticeEdit != nil || panel.hub.MOTD().Text != "" {
+		t.Error("Expected esc to close the editor without saving")
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// OnionService is the server published as a Tor onion service through a
+// local tor's control port. Tor removes the service when Close drops the
+// control connection.
+type OnionService struct {
+	// Address is the onion hostname, e.g. "abc...xyz.onion"
+	Address string
+
+	conn net.Conn
+}
+
+// torReply is one reply from the control port: its status code and lines,
+// without the "250-" style prefixes
+type torReply struct {
+	code  string
+	lines []string
+}
+
+// PublishOnion asks the tor at controlAddr to forward virtPort of an onion
+// service to target (host:port of the chat server). The service key is kept
+// in keyPath so the address survives restarts. password is only needed when
+// tor uses HashedControlPassword; cookie authentication is picked up
+// automatically.
+func PublishOnion(controlAddr, password, keyPath string, virtPort int, target string) (*OnionService, error) {
+	conn, err := net.DialTimeout("tcp", controlAddr, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach tor control port %s: %w", controlAddr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	r := bufio.NewReader(conn)
+
+	if err := torAuthenticate(conn, r, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	key := "NEW:ED25519-V3"
+	if data, err := os.ReadFile(keyPath); err == nil {
+		key = strings.TrimSpace(string(data))
+	}
+	reply, err := torCommand(conn, r, fmt.Sprintf("ADD_ONION %s Port=%d,%s", key, virtPort, target))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("tor refused the onion service: %w", err)
+	}
+
+	svc := &OnionService{conn: conn}
+	for _, line := range reply.lines {
+		if id, ok := strings.CutPrefix(line, "ServiceID="); ok {
+			svc.Address = id + ".onion"
+		}
+		if pk, ok := strings.CutPrefix(line, "PrivateKey="); ok {
+			if err := os.WriteFile(keyPath, []byte(pk+"\n"), 0600); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("cannot save onion service key: %w", err)
+			}
+		}
+	}
+	if svc.Address == "" {
+		conn.Close()
+		return nil, fmt.Errorf("tor did not return an onion address")
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return svc, nil
+}
+
+// Close takes the onion service down
+func (s *OnionService) Close() error {
+	return s.conn.Close()
+}
+
+// torAuthenticate logs in to the control port with whichever method tor
+// offers: none, the cookie file, or the password
+func torAuthenticate(conn net.Conn, r *bufio.Reader, password string) error {
+	info, err := torCommand(conn, r, "PROTOCOLINFO 1")
+	if err != nil {
+		return fmt.Errorf("tor control port: %w", err)
+	}
+	var methods, cookieFile string
+	for _, line := range info.lines {
+		if rest, ok := strings.CutPrefix(line, "AUTH METHODS="); ok {
+			methods, rest, _ = strings.Cut(rest, " ")
+			if file, ok := strings.CutPrefix(rest, "COOKIEFILE="); ok {
+				cookieFile = strings.Trim(file, `"`)
+			}
+		}
+	}
+	offered := func(m string) bool {
+		for _, o := range strings.Split(methods, ",") {
+			if o == m {
+				return true
+			}
+		}
+		return false
+	}
+
+	var auth string
+	switch {
+	case offered("NULL"):
+		auth = "AUTHENTICATE"
+	case password != "" && offered("HASHEDPASSWORD"):
+		auth = fmt.Sprintf("AUTHENTICATE %q", password)
+	case offered("COOKIE") && cookieFile != "":
+		cookie, err := os.ReadFile(cookieFile)
+		if err != nil {
+			return fmt.Errorf("cannot read tor auth cookie (is the server in tor's group?): %w", err)
+		}
+		auth = "AUTHENTICATE " + hex.EncodeToString(cookie)
+	case offered("HASHEDPASSWORD"):
+		return fmt.Errorf("tor control port needs a password: set MARCHAT_TOR_CONTROL_PASSWORD")
+	default:
+		return fmt.Errorf("tor control port offers no supported authentication (%s)", methods)
+	}
+	if _, err := torCommand(conn, r, auth); err != nil {
+		return fmt.Errorf("tor control authentication failed: %w", err)
+	}
+	return nil
+}
+
+// torCommand sends one command and reads its reply, returning an error for
+// anything but 250
+func torCommand(conn net.Conn, r *bufio.Reader, cmd string) (torReply, error) {
+	if _, err := fmt.Fprintf(conn, "%s\r\n", cmd); err != nil {
+		return torReply{}, err
+	}
+	var reply torReply
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return reply, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 4 {
+			return reply, fmt.Errorf("malformed reply %q", line)
+		}
+		reply.code = line[:3]
+		reply.lines = append(reply.lines, line[4:])
+		if line[3] == ' ' {
+			break
+		}
+	}
+	if reply.code != "250" {
+		return reply, fmt.Errorf("%s %s", reply.code, strings.Join(reply.lines, "; "))
+	}
+	return reply, nil
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTor answers one control connection the way tor does for a
+// password-protected control port, recording the commands it gets
+func fakeTor(t *testing.T, commands chan<- string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(commands)
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			commands <- cmd
+			switch {
+			case cmd == "PROTOCOLINFO 1":
+				conn.Write([]byte("250-PROTOCOLINFO 1\r\n250-AUTH METHODS=HASHEDPASSWORD\r\n250-VERSION Tor=\"0.4.8.10\"\r\n250 OK\r\n"))
+			case cmd == `AUTHENTICATE "secret"`:
+				conn.Write([]byte("250 OK\r\n"))
+			case strings.HasPrefix(cmd, "AUTHENTICATE"):
+				conn.Write([]byte("515 Authentication failed: Password did not match\r\n"))
+			case strings.HasPrefix(cmd, "ADD_ONION NEW:"):
+				conn.Write([]byte("250-ServiceID=exampleonionaddress\r\n250-PrivateKey=ED25519-V3:c2VjcmV0a2V5\r\n250 OK\r\n"))
+			case strings.HasPrefix(cmd, "ADD_ONION "):
+				conn.Write([]byte("250-ServiceID=exampleonionaddress\r\n250 OK\r\n"))
+			}
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestPublishOnionSavesKey(t *testing.T) {
+	commands := make(chan string, 10)
+	keyPath := filepath.Join(t.TempDir(), "onion_service.key")
+
+	svc, err := PublishOnion(fakeTor(t, commands), "secret", keyPath, 80, "127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("PublishOnion: %v", err)
+	}
+	if svc.Address != "exampleonionaddress.onion" {
+		t.Errorf("Expected the onion address, got %q", svc.Address)
+	}
+	svc.Close()
+
+	var got []string
+	for cmd := range commands {
+		got = append(got, cmd)
+	}
+	if len(got) != 3 || got[2] != "ADD_ONION NEW:ED25519-V3 Port=80,127.0.0.1:8080" {
+		t.Errorf("Unexpected control commands: %q", got)
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil || strings.TrimSpace(string(key)) != "ED25519-V3:c2VjcmV0a2V5" {
+		t.Errorf("Expected the service key to be saved, got %q (%v)", key, err)
+	}
+
+	// The saved key keeps the address on the next start
+	commands = make(chan string, 10)
+	svc, err = PublishOnion(fakeTor(t, commands), "secret", keyPath, 80, "127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("PublishOnion with saved key: %v", err)
+	}
+	svc.Close()
+	got = nil
+	for cmd := range commands {
+		got = append(got, cmd)
+	}
+	if len(got) != 3 || got[2] != "ADD_ONION ED25519-V3:c2VjcmV0a2V5 Port=80,127.0.0.1:8080" {
+		t.Errorf("Expected the saved key to be reused, got %q", got)
+	}
+}
+
+func TestPublishOnionNeedsPassword(t *testing.T) {
+	commands := make(chan string, 10)
+	_, err := PublishOnion(fakeTor(t, commands), "", filepath.Join(t.TempDir(), "key"), 80, "127.0.0.1:8080")
+	if err == nil || !strings.Contains(err.Error(), "MARCHAT_TOR_CONTROL_PASSWORD") {
+		t.Errorf("Expected a hint to set the password, got %v", err)
+	}
+
+	commands = make(chan string, 10)
+	_, err = PublishOnion(fakeTor(t, commands), "wrong", filepath.Join(t.TempDir(), "key"), 80, "127.0.0.1:8080")
+	if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("Expected a wrong password to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// PairingLink issues a fresh invite and returns the marchat:// link a phone
+// scans to join. host is how the admin reached the server (empty for the
+// TUI); loopback names are swapped for a LAN address the phone can reach.
+func (h *Hub) PairingLink(host string, port int, tls bool, adminUsername string) (shared.ChatURL, Invite) {
+	inv := h.CreateInvite(adminUsername, defaultInviteTTL)
+	return shared.ChatURL{Host: pairingHost(host, port), TLS: tls, Invite: inv.Token}, inv
+}
+
+// pairingHost picks the host[:port] to put in a pairing link
+func pairingHost(host string, port int) string {
+	name, hostPort, err := net.SplitHostPort(host)
+	if err != nil {
+		name, hostPort = host, ""
+	}
+	if hostPort == "" {
+		hostPort = strconv.Itoa(port)
+	}
+	if isLoopbackHost(name) {
+		if ips := shared.LocalIPv4s(); len(ips) > 0 {
+			name = ips[0].String()
+		}
+	}
+	if name == "" {
+		name = "localhost"
+	}
+	return net.JoinHostPort(name, hostPort)
+}
+
+func isLoopbackHost(name string) bool {
+	if name == "" || strings.EqualFold(name, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(name)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// pairingQR renders link as a QR code made of half-block characters
+func pairingQR(link string) (string, error) {
+	qr, err := qrcode.New(link, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+	return qr.ToSmallString(false), nil
+}
+
+// pairingQRPNG renders link as a PNG QR code for the web admin panel
+func pairingQRPNG(link string) ([]byte, error) {
+	return qrcode.Encode(link, qrcode.Medium, 256)
+}
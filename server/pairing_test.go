@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestPairingHost(t *testing.T) {
+	if got := pairingHost("chat.example.com:8443", 8080); got != "chat.example.com:8443" {
+		t.Errorf("Expected the admin's host to be kept, got %q", got)
+	}
+	if got := pairingHost("chat.example.com", 8080); got != "chat.example.com:8080" {
+		t.Errorf("Expected the server port to be added, got %q", got)
+	}
+	for _, host := range []string{"", "localhost:8080", "127.0.0.1:8080", "[::1]:8080"} {
+		got := pairingHost(host, 8080)
+		name, port, err := net.SplitHostPort(got)
+		if err != nil || port != "8080" {
+			t.Errorf("pairingHost(%q) = %q", host, got)
+			continue
+		}
+		if ips := shared.LocalIPv4s(); len(ips) > 0 && name != ips[0].String() {
+			t.Errorf("pairingHost(%q) = %q, want a LAN address", host, got)
+		}
+	}
+}
+
+func TestPairingLink(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	link, inv := hub.PairingLink("chat.example.com:8443", 8080, true, "root")
+	if link.Invite != inv.Token || !hub.ValidInvite(inv.Token) {
+		t.Fatalf("Expected a valid invite in the link, got %+v", link)
+	}
+	parsed, err := shared.ParseChatURL(link.String())
+	if err != nil || parsed.WebSocketURL() != "wss://chat.example.com:8443/ws" {
+		t.Errorf("Unexpected link %q (%v)", link.String(), err)
+	}
+
+	qr, err := pairingQR(link.String())
+	if err != nil || !strings.Contains(qr, "█") {
+		t.Errorf("Expected a block-character QR code, got %q (%v)", qr, err)
+	}
+}
@@ -278,6 +278,14 @@ func (h *PluginCommandHandler) handlePluginCommand(cmd string, args []string, is
 		return "This command requires admin privileges", nil
 	}
 
+	// Refuse arguments that don't match the command's declared schema
+	if err := command.ValidateArgs(args); err != nil {
+		if command.Usage != "" {
+			return fmt.Sprintf("Invalid arguments for :%s: %v. Usage: %s", cmd, err, command.Usage), nil
+		}
+		return fmt.Sprintf("Invalid arguments for :%s: %v", cmd, err), nil
+	}
+
 	// Execute the plugin command
 	if err := h.manager.ExecuteCommand(pluginName, cmd, args); err != nil {
 		return fmt.Sprintf("Failed to execute plugin command: %v", err), nil
@@ -286,6 +294,64 @@ func (h *PluginCommandHandler) handlePluginCommand(cmd string, args []string, is
 	return fmt.Sprintf("Command %s executed successfully", cmd), nil
 }
 
+// pluginManagementCommands are the commands that manage plugins themselves
+var pluginManagementCommands = []shared.CommandInfo{
+	{Name: ":plugin", Usage: ":plugin <list|store|refresh|install|uninstall|enable|disable> [args...]", Description: "Manage plugins"},
+	{Name: ":list", Usage: ":list", Description: "List installed plugins"},
+	{Name: ":store", Usage: ":store", Description: "List plugins available in the store"},
+	{Name: ":refresh", Usage: ":refresh", Description: "Refresh the plugin store"},
+	{Name: ":install", Usage: ":install <plugin-name> [--os <goos>] [--arch <goarch>]", Description: "Install a plugin", AdminOnly: true},
+	{Name: ":uninstall", Usage: ":uninstall <plugin-name>", Description: "Uninstall a plugin", AdminOnly: true},
+	{Name: ":enable", Usage: ":enable <plugin-name>", Description: "Enable a plugin", AdminOnly: true},
+	{Name: ":disable", Usage: ":disable <plugin-name>", Description: "Disable a plugin", AdminOnly: true},
+}
+
+// Commands lists the plugin management commands and the commands of
+// enabled plugins that a user may run, by name
+func (h *PluginCommandHandler) Commands(isAdmin bool) []shared.CommandInfo {
+	var commands []shared.CommandInfo
+	for _, cmd := range pluginManagementCommands {
+		if !cmd.AdminOnly || isAdmin {
+			commands = append(commands, cmd)
+		}
+	}
+	var pluginCommands []shared.CommandInfo
+	for name, instance := range h.manager.ListPlugins() {
+		if !instance.Enabled || instance.Manifest == nil {
+			continue
+		}
+		for _, cmd := range instance.Manifest.Commands {
+			if cmd.AdminOnly && !isAdmin {
+				continue
+			}
+			usage := cmd.Usage
+			if usage == "" {
+				usage = ":" + cmd.Name
+			}
+			info := shared.CommandInfo{
+				Name:        ":" + cmd.Name,
+				Usage:       usage,
+				Description: cmd.Description,
+				AdminOnly:   cmd.AdminOnly,
+				Plugin:      name,
+			}
+			for _, arg := range cmd.Args {
+				info.Args = append(info.Args, shared.CommandArg{
+					Name:        arg.Name,
+					Description: arg.Description,
+					Type:        arg.Type,
+					Required:    arg.Required,
+					Choices:     arg.Choices,
+					Rest:        arg.Rest,
+				})
+			}
+			pluginCommands = append(pluginCommands, info)
+		}
+	}
+	sortCommands(pluginCommands)
+	return append(commands, pluginCommands...)
+}
+
 // SendMessageToPlugins sends a message to all enabled plugins
 func (h *PluginCommandHandler) SendMessageToPlugins(msg shared.Message) {
 	pluginMsg := sdk.Message{
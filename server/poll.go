@@ -0,0 +1,174 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Polls live only in hub memory; they are not written to the database and do
+// not survive a server restart.
+
+const (
+	defaultPollDuration = time.Hour
+	maxPollDuration     = 7 * 24 * time.Hour
+	maxPollOptions      = 10
+)
+
+type pollState struct {
+	poll  shared.Poll
+	votes map[string]int // lowercase username -> option index
+	timer *time.Timer
+}
+
+type pollManager struct {
+	mu     sync.Mutex
+	polls  map[int]*pollState
+	nextID int
+}
+
+func newPollManager() *pollManager {
+	return &pollManager{polls: make(map[int]*pollState)}
+}
+
+// snapshot copies the poll so callers can hand it to clients without holding the lock
+func (s *pollState) snapshot() shared.Poll {
+	p := s.poll
+	p.Options = append([]shared.PollOption(nil), s.poll.Options...)
+	return p
+}
+
+// pollMessage wraps a poll in a chat message; Content is a plain-text fallback
+func pollMessage(p shared.Poll) shared.Message {
+	return shared.Message{
+		Sender:    "System",
+		Content:   fmt.Sprintf("Poll #%d: %s", p.ID, p.Question),
+		CreatedAt: p.CreatedAt,
+		Type:      shared.PollMessageType,
+		Poll:      &p,
+	}
+}
+
+// CreatePoll opens a new poll and schedules it to close after duration
+func (h *Hub) CreatePoll(creator, question string, options []string, duration time.Duration) (shared.Poll, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return shared.Poll{}, fmt.Errorf("poll question cannot be empty")
+	}
+	if len(options) < 2 || len(options) > maxPollOptions {
+		return shared.Poll{}, fmt.Errorf("polls need between 2 and %d options", maxPollOptions)
+	}
+	if duration <= 0 {
+		duration = defaultPollDuration
+	}
+	if duration > maxPollDuration {
+		return shared.Poll{}, fmt.Errorf("poll duration cannot exceed %s", maxPollDuration)
+	}
+
+	pollOptions := make([]shared.PollOption, 0, len(options))
+	for _, opt := range options {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			return shared.Poll{}, fmt.Errorf("poll options cannot be empty")
+		}
+		pollOptions = append(pollOptions, shared.PollOption{Text: opt})
+	}
+
+	h.polls.mu.Lock()
+	defer h.polls.mu.Unlock()
+
+	h.polls.nextID++
+	now := time.Now()
+	state := &pollState{
+		poll: shared.Poll{
+			ID:        h.polls.nextID,
+			Question:  question,
+			Options:   pollOptions,
+			Creator:   creator,
+			CreatedAt: now,
+			ExpiresAt: now.Add(duration),
+		},
+		votes: make(map[string]int),
+	}
+	id := state.poll.ID
+	state.timer = time.AfterFunc(duration, func() {
+		if p, err := h.closePoll(id); err == nil {
+			h.broadcast <- pollMessage(p)
+		}
+	})
+	h.polls.polls[id] = state
+
+	return state.snapshot(), nil
+}
+
+// VotePoll records username's vote for the option at index, replacing any earlier vote
+func (h *Hub) VotePoll(id int, username string, index int) (shared.Poll, error) {
+	h.polls.mu.Lock()
+	defer h.polls.mu.Unlock()
+
+	state, ok := h.polls.polls[id]
+	if !ok {
+		return shared.Poll{}, fmt.Errorf("poll #%d not found or already closed", id)
+	}
+	if index < 0 || index >= len(state.poll.Options) {
+		return shared.Poll{}, fmt.Errorf("poll #%d has options 1-%d", id, len(state.poll.Options))
+	}
+
+	voter := strings.ToLower(username)
+	if prev, voted := state.votes[voter]; voted {
+		if prev == index {
+			return state.snapshot(), nil
+		}
+		state.poll.Options[prev].Votes--
+	}
+	state.votes[voter] = index
+	state.poll.Options[index].Votes++
+
+	return state.snapshot(), nil
+}
+
+// ClosePoll closes a poll early; only its creator or an admin may do so
+func (h *Hub) ClosePoll(id int, username string, isAdmin bool) (shared.Poll, error) {
+	h.polls.mu.Lock()
+	state, ok := h.polls.polls[id]
+	h.polls.mu.Unlock()
+	if !ok {
+		return shared.Poll{}, fmt.Errorf("poll #%d not found or already closed", id)
+	}
+	if !isAdmin && !strings.EqualFold(state.poll.Creator, username) {
+		return shared.Poll{}, fmt.Errorf("only the poll creator or an admin can close poll #%d", id)
+	}
+	return h.closePoll(id)
+}
+
+func (h *Hub) closePoll(id int) (shared.Poll, error) {
+	h.polls.mu.Lock()
+	defer h.polls.mu.Unlock()
+
+	state, ok := h.polls.polls[id]
+	if !ok {
+		return shared.Poll{}, fmt.Errorf("poll #%d not found or already closed", id)
+	}
+	state.timer.Stop()
+	state.poll.Closed = true
+	delete(h.polls.polls, id)
+
+	return state.snapshot(), nil
+}
+
+// OpenPolls returns the currently open polls, oldest first
+func (h *Hub) OpenPolls() []shared.Poll {
+	h.polls.mu.Lock()
+	defer h.polls.mu.Unlock()
+
+	polls := make([]shared.Poll, 0, len(h.polls.polls))
+	for id := 1; id <= h.polls.nextID; id++ {
+		if state, ok := h.polls.polls[id]; ok {
+			polls = append(polls, state.snapshot())
+		}
+	}
+	return polls
+}
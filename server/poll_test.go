@@ -0,0 +1,92 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestHubPolls(t *testing.T) {
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", nil)
+
+	if _, err := hub.CreatePoll("alice", "Lunch?", []string{"pizza"}, 0); err == nil {
+		t.Error("Expected error for poll with a single option")
+	}
+	if _, err := hub.CreatePoll("alice", "Lunch?", []string{"pizza", "sushi"}, 30*24*time.Hour); err == nil {
+		t.Error("Expected error for poll duration above the maximum")
+	}
+
+	poll, err := hub.CreatePoll("alice", "Lunch?", []string{"pizza", "sushi", "tacos"}, 0)
+	if err != nil {
+		t.Fatalf("CreatePoll failed: %v", err)
+	}
+	if poll.ID != 1 || len(poll.Options) != 3 {
+		t.Fatalf("Unexpected poll: %+v", poll)
+	}
+	if d := poll.ExpiresAt.Sub(poll.CreatedAt); d != defaultPollDuration {
+		t.Errorf("Expected default duration %v, got %v", defaultPollDuration, d)
+	}
+
+	// One vote per user; voting again moves the vote
+	if _, err := hub.VotePoll(poll.ID, "bob", 0); err != nil {
+		t.Fatalf("VotePoll failed: %v", err)
+	}
+	if _, err := hub.VotePoll(poll.ID, "BOB", 1); err != nil {
+		t.Fatalf("VotePoll failed: %v", err)
+	}
+	poll, err = hub.VotePoll(poll.ID, "carol", 1)
+	if err != nil {
+		t.Fatalf("VotePoll failed: %v", err)
+	}
+	if poll.Options[0].Votes != 0 || poll.Options[1].Votes != 2 || poll.TotalVotes() != 2 {
+		t.Errorf("Unexpected tally: %+v", poll.Options)
+	}
+	if _, err := hub.VotePoll(poll.ID, "dave", 3); err == nil {
+		t.Error("Expected error for out-of-range option")
+	}
+
+	if open := hub.OpenPolls(); len(open) != 1 || open[0].ID != poll.ID {
+		t.Errorf("Expected one open poll, got %+v", open)
+	}
+
+	// Only the creator or an admin may close a poll
+	if _, err := hub.ClosePoll(poll.ID, "bob", false); err == nil {
+		t.Error("Expected non-creator close to be refused")
+	}
+	closed, err := hub.ClosePoll(poll.ID, "Alice", false)
+	if err != nil {
+		t.Fatalf("ClosePoll failed: %v", err)
+	}
+	if !closed.Closed || closed.Options[1].Votes != 2 {
+		t.Errorf("Closed poll should keep its final tally: %+v", closed)
+	}
+	if _, err := hub.VotePoll(poll.ID, "erin", 0); err == nil {
+		t.Error("Expected vote on closed poll to fail")
+	}
+	if len(hub.OpenPolls()) != 0 {
+		t.Error("Closed poll should not be listed as open")
+	}
+}
+
+func TestHubPollExpiry(t *testing.T) {
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", nil)
+
+	poll, err := hub.CreatePoll("alice", "Quick one?", []string{"yes", "no"}, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("CreatePoll failed: %v", err)
+	}
+
+	select {
+	case msg := <-hub.broadcast:
+		m, ok := msg.(shared.Message)
+		if !ok || m.Type != shared.PollMessageType || m.Poll == nil {
+			t.Fatalf("Expected poll message, got %+v", msg)
+		}
+		if m.Poll.ID != poll.ID || !m.Poll.Closed {
+			t.Errorf("Expected poll #%d to be closed on expiry, got %+v", poll.ID, m.Poll)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for poll expiry broadcast")
+	}
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Reminders are stored in the database so they survive restarts. The hub keeps
+// a timer per pending reminder; a reminder whose target is offline when it
+// comes due stays stored and is delivered on the target's next connection.
+
+const (
+	maxReminderDelay    = 30 * 24 * time.Hour
+	maxRemindersPerUser = 50
+)
+
+type reminderScheduler struct {
+	mu     sync.Mutex
+	timers map[int64]*time.Timer
+}
+
+func newReminderScheduler() *reminderScheduler {
+	return &reminderScheduler{timers: make(map[int64]*time.Timer)}
+}
+
+// directMessage asks the hub loop to deliver msg to every session of username
+type directMessage struct {
+	username  string
+	msg       shared.Message
+	delivered chan bool
+}
+
+// reminderMessage mentions the target so clients raise a mention notification
+func reminderMessage(r Reminder) shared.Message {
+	content := fmt.Sprintf("Reminder for @%s: %s", r.Target, r.Text)
+	if !strings.EqualFold(r.Creator, r.Target) {
+		content = fmt.Sprintf("Reminder for @%s from %s: %s", r.Target, r.Creator, r.Text)
+	}
+	return shared.Message{
+		Sender:    "System",
+		Content:   content,
+		CreatedAt: time.Now(),
+		Type:      shared.TextMessage,
+	}
+}
+
+// LoadReminders schedules every reminder stored in the database
+func (h *Hub) LoadReminders() {
+	if h.db == nil {
+		return
+	}
+	reminders, err := h.db.GetPendingReminders()
+	if err != nil {
+		log.Printf("Warning: failed to load reminders: %v", err)
+		return
+	}
+	for _, r := range reminders {
+		h.scheduleReminder(r)
+	}
+	if len(reminders) > 0 {
+		log.Printf("Loaded %d pending reminder(s)", len(reminders))
+	}
+}
+
+// AddReminder stores a reminder for target and schedules its delivery
+func (h *Hub) AddReminder(creator, target, text string, dueAt time.Time) (Reminder, error) {
+	if h.db == nil {
+		return Reminder{}, fmt.Errorf("reminders require a database")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Reminder{}, fmt.Errorf("reminder text cannot be empty")
+	}
+	if time.Until(dueAt) > maxReminderDelay {
+		return Reminder{}, fmt.Errorf("reminders can be set at most %s ahead", maxReminderDelay)
+	}
+
+	mine, err := h.RemindersFor(creator)
+	if err != nil {
+		return Reminder{}, err
+	}
+	created := 0
+	for _, r := range mine {
+		if strings.EqualFold(r.Creator, creator) {
+			created++
+		}
+	}
+	if created >= maxRemindersPerUser {
+		return Reminder{}, fmt.Errorf("you already have %d pending reminders", maxRemindersPerUser)
+	}
+
+	r := Reminder{
+		Creator:   creator,
+		Target:    strings.ToLower(target),
+		Text:      text,
+		DueAt:     dueAt,
+		CreatedAt: time.Now(),
+	}
+	id, err := h.db.InsertReminder(r)
+	if err != nil {
+		return Reminder{}, fmt.Errorf("failed to store reminder: %w", err)
+	}
+	r.ID = id
+	h.scheduleReminder(r)
+	return r, nil
+}
+
+// RemindersFor lists pending reminders created by or addressed to username
+func (h *Hub) RemindersFor(username string) ([]Reminder, error) {
+	if h.db == nil {
+		return nil, nil
+	}
+	all, err := h.db.GetPendingReminders()
+	if err != nil {
+		return nil, err
+	}
+	var list []Reminder
+	for _, r := range all {
+		if strings.EqualFold(r.Creator, username) || strings.EqualFold(r.Target, username) {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+// CancelReminder deletes a reminder; only its creator may cancel it
+func (h *Hub) CancelReminder(username string, id int64) (bool, error) {
+	mine, err := h.RemindersFor(username)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range mine {
+		if r.ID == id && strings.EqualFold(r.Creator, username) {
+			h.reminders.mu.Lock()
+			if t, ok := h.reminders.timers[id]; ok {
+				t.Stop()
+				delete(h.reminders.timers, id)
+			}
+			h.reminders.mu.Unlock()
+			return true, h.db.DeleteReminder(id)
+		}
+	}
+	return false, nil
+}
+
+// DeliverDueReminders hands any overdue reminders for username to client.
+// Called when a user connects so reminders that fired while they were offline arrive.
+func (h *Hub) DeliverDueReminders(client *Client) {
+	if h.db == nil {
+		return
+	}
+	reminders, err := h.db.GetPendingReminders()
+	if err != nil {
+		log.Printf("Warning: failed to load reminders for %s: %v", client.username, err)
+		return
+	}
+	now := time.Now()
+	for _, r := range reminders {
+		if !strings.EqualFold(r.Target, client.username) || r.DueAt.After(now) {
+			continue
+		}
+		h.reminders.mu.Lock()
+		_, pending := h.reminders.timers[r.ID]
+		h.reminders.mu.Unlock()
+		if pending {
+			continue // its timer is about to deliver it
+		}
+		client.enqueue(reminderMessage(r))
+		if err := h.db.DeleteReminder(r.ID); err != nil {
+			log.Printf("Warning: failed to delete delivered reminder %d: %v", r.ID, err)
+		}
+	}
+}
+
+func (h *Hub) scheduleReminder(r Reminder) {
+	delay := time.Until(r.DueAt)
+	if delay < 0 {
+		delay = 0
+	}
+	h.reminders.mu.Lock()
+	defer h.reminders.mu.Unlock()
+	h.reminders.timers[r.ID] = time.AfterFunc(delay, func() { h.fireReminder(r) })
+}
+
+func (h *Hub) fireReminder(r Reminder) {
+	h.reminders.mu.Lock()
+	_, pending := h.reminders.timers[r.ID]
+	delete(h.reminders.timers, r.ID)
+	h.reminders.mu.Unlock()
+	if !pending {
+		return // cancelled
+	}
+
+	delivered := make(chan bool, 1)
+	h.direct <- directMessage{username: r.Target, msg: reminderMessage(r), delivered: delivered}
+	if !<-delivered {
+		log.Printf("Reminder %d for %s is due but they are offline; holding until they connect", r.ID, r.Target)
+		if !strings.EqualFold(r.Creator, r.Target) {
+			h.queueEmail(r.Target, emailItem{from: r.Creator, text: r.Text, at: r.DueAt, reminder: true})
+		}
+		return
+	}
+	if err := h.db.DeleteReminder(r.ID); err != nil {
+		log.Printf("Warning: failed to delete delivered reminder %d: %v", r.ID, err)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// nextTextMessage skips user list updates and returns the next chat message sent to c
+func nextTextMessage(t *testing.T, c *Client) shared.Message {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case msg := <-c.send:
+			if m, ok := msg.(shared.Message); ok {
+				return m
+			}
+		case <-deadline:
+			t.Fatal("Timed out waiting for message")
+			return shared.Message{}
+		}
+	}
+}
+
+func TestHubReminders(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- bob
+
+	if _, err := hub.AddReminder("alice", "bob", "too far", time.Now().Add(60*24*time.Hour)); err == nil {
+		t.Error("Expected error for reminder beyond the maximum delay")
+	}
+
+	// Online target receives the reminder as a mention and it is removed
+	if _, err := hub.AddReminder("alice", "Bob", "review PR #12", time.Now().Add(20*time.Millisecond)); err != nil {
+		t.Fatalf("AddReminder failed: %v", err)
+	}
+	msg := nextTextMessage(t, bob)
+	if !strings.Contains(msg.Content, "@bob") || !strings.Contains(msg.Content, "from alice") || !strings.Contains(msg.Content, "review PR #12") {
+		t.Errorf("Unexpected reminder content: %q", msg.Content)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if pending, _ := db.GetPendingReminders(); len(pending) != 0 {
+		t.Errorf("Delivered reminder should be deleted, got %+v", pending)
+	}
+
+	// Offline target keeps the reminder until they connect
+	if _, err := hub.AddReminder("carol", "carol", "stretch", time.Now().Add(10*time.Millisecond)); err != nil {
+		t.Fatalf("AddReminder failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if pending, _ := db.GetPendingReminders(); len(pending) != 1 {
+		t.Fatalf("Reminder for offline user should be kept, got %+v", pending)
+	}
+	carol := &Client{hub: hub, username: "carol", send: make(chan interface{}, 16)}
+	hub.DeliverDueReminders(carol)
+	if msg := nextTextMessage(t, carol); msg.Content != "Reminder for @carol: stretch" {
+		t.Errorf("Unexpected self reminder content: %q", msg.Content)
+	}
+	if pending, _ := db.GetPendingReminders(); len(pending) != 0 {
+		t.Errorf("Reminder should be deleted after delivery on connect, got %+v", pending)
+	}
+
+	// Only the creator can cancel
+	r, err := hub.AddReminder("alice", "bob", "later", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("AddReminder failed: %v", err)
+	}
+	if list, _ := hub.RemindersFor("bob"); len(list) != 1 {
+		t.Errorf("Target should see reminders addressed to them, got %+v", list)
+	}
+	if ok, _ := hub.CancelReminder("bob", r.ID); ok {
+		t.Error("Target should not be able to cancel someone else's reminder")
+	}
+	if ok, err := hub.CancelReminder("alice", r.ID); !ok || err != nil {
+		t.Errorf("Creator cancel failed: %v, %v", ok, err)
+	}
+}
+
+func TestHubLoadRemindersAfterRestart(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+
+	if _, err := db.InsertReminder(Reminder{Creator: "alice", Target: "alice", Text: "from before restart", DueAt: time.Now().Add(-time.Minute), CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("InsertReminder failed: %v", err)
+	}
+
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	alice := &Client{hub: hub, username: "alice", send: make(chan interface{}, 16)}
+	go hub.Run()
+	hub.register <- alice
+
+	msg := nextTextMessage(t, alice)
+	if !strings.Contains(msg.Content, "from before restart") {
+		t.Errorf("Expected overdue reminder after restart, got %q", msg.Content)
+	}
+}
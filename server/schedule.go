@@ -0,0 +1,191 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// Scheduled messages are stored in the database so they survive restarts,
+// like reminders. The hub keeps a timer per pending message; one that came
+// due while the server was down is sent as soon as the server is back.
+
+const (
+	maxScheduleDelay    = 7 * 24 * time.Hour
+	maxScheduledPerUser = 20
+)
+
+type messageScheduler struct {
+	mu     sync.Mutex
+	timers map[int64]*time.Timer
+}
+
+func newMessageScheduler() *messageScheduler {
+	return &messageScheduler{timers: make(map[int64]*time.Timer)}
+}
+
+// parseScheduleTime accepts a delay ("15m", "2h30m") or a clock time ("17:30").
+// Clock times that have already passed today refer to tomorrow.
+func parseScheduleTime(spec string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("delay must be positive")
+		}
+		return now.Add(d), nil
+	}
+	clock, err := time.ParseInLocation("15:04", spec, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use a delay like 15m or a clock time like 17:30)", spec)
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+// LoadScheduledMessages schedules every message stored in the database
+func (h *Hub) LoadScheduledMessages() {
+	if h.db == nil {
+		return
+	}
+	scheduled, err := h.db.GetScheduledMessages()
+	if err != nil {
+		log.Printf("Warning: failed to load scheduled messages: %v", err)
+		return
+	}
+	for _, sm := range scheduled {
+		h.scheduleDelivery(sm)
+	}
+	if len(scheduled) > 0 {
+		log.Printf("Loaded %d scheduled message(s)", len(scheduled))
+	}
+}
+
+// ScheduleMessage stores content to be sent as author at deliverAt. The
+// author's slow mode and mention limits are checked by the caller when
+// scheduling, not again at delivery.
+func (h *Hub) ScheduleMessage(author string, authorAdmin bool, content string, deliverAt time.Time) (ScheduledMessage, error) {
+	if h.db == nil {
+		return ScheduledMessage{}, fmt.Errorf("scheduled messages require a database")
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ScheduledMessage{}, fmt.Errorf("scheduled message cannot be empty")
+	}
+	if limit := h.MaxMessageBytes(); len(content) > limit {
+		return ScheduledMessage{}, fmt.Errorf("message is %d bytes, over this server's %d byte limit", len(content), limit)
+	}
+	if time.Until(deliverAt) > maxScheduleDelay {
+		return ScheduledMessage{}, fmt.Errorf("messages can be scheduled at most %s ahead", maxScheduleDelay)
+	}
+
+	mine, err := h.ScheduledMessages(author)
+	if err != nil {
+		return ScheduledMessage{}, err
+	}
+	if len(mine) >= maxScheduledPerUser {
+		return ScheduledMessage{}, fmt.Errorf("you already have %d scheduled messages", maxScheduledPerUser)
+	}
+
+	sm := ScheduledMessage{
+		Author:      author,
+		Content:     content,
+		DeliverAt:   deliverAt,
+		AuthorAdmin: authorAdmin,
+		CreatedAt:   time.Now(),
+	}
+	id, err := h.db.InsertScheduledMessage(sm)
+	if err != nil {
+		return ScheduledMessage{}, fmt.Errorf("failed to store scheduled message: %w", err)
+	}
+	sm.ID = id
+	h.scheduleDelivery(sm)
+	return sm, nil
+}
+
+// ScheduledMessages lists author's pending messages, soonest first
+func (h *Hub) ScheduledMessages(author string) ([]ScheduledMessage, error) {
+	if h.db == nil {
+		return nil, nil
+	}
+	all, err := h.db.GetScheduledMessages()
+	if err != nil {
+		return nil, err
+	}
+	var list []ScheduledMessage
+	for _, sm := range all {
+		if strings.EqualFold(sm.Author, author) {
+			list = append(list, sm)
+		}
+	}
+	return list, nil
+}
+
+// CancelScheduledMessage deletes one of author's pending messages
+func (h *Hub) CancelScheduledMessage(author string, id int64) (bool, error) {
+	mine, err := h.ScheduledMessages(author)
+	if err != nil {
+		return false, err
+	}
+	for _, sm := range mine {
+		if sm.ID == id {
+			h.scheduler.mu.Lock()
+			if t, ok := h.scheduler.timers[id]; ok {
+				t.Stop()
+				delete(h.scheduler.timers, id)
+			}
+			h.scheduler.mu.Unlock()
+			return true, h.db.DeleteScheduledMessage(id)
+		}
+	}
+	return false, nil
+}
+
+func (h *Hub) scheduleDelivery(sm ScheduledMessage) {
+	delay := time.Until(sm.DeliverAt)
+	if delay < 0 {
+		delay = 0
+	}
+	h.scheduler.mu.Lock()
+	defer h.scheduler.mu.Unlock()
+	h.scheduler.timers[sm.ID] = time.AfterFunc(delay, func() { h.deliverScheduledMessage(sm) })
+}
+
+func (h *Hub) deliverScheduledMessage(sm ScheduledMessage) {
+	h.scheduler.mu.Lock()
+	_, pending := h.scheduler.timers[sm.ID]
+	delete(h.scheduler.timers, sm.ID)
+	h.scheduler.mu.Unlock()
+	if !pending {
+		return // cancelled
+	}
+	if err := h.db.DeleteScheduledMessage(sm.ID); err != nil {
+		log.Printf("Warning: failed to delete delivered scheduled message %d: %v", sm.ID, err)
+	}
+
+	// Authors banned since scheduling lose their pending messages
+	if h.IsUserBanned(sm.Author) {
+		log.Printf("Dropping scheduled message #%d from banned user %s", sm.ID, sm.Author)
+		return
+	}
+
+	msg := shared.Message{
+		Sender:    sm.Author,
+		Content:   sm.Content,
+		CreatedAt: time.Now(),
+		Type:      shared.TextMessage,
+	}
+	// Authors muted since scheduling only see the message themselves
+	if h.IsUserMuted(sm.Author) {
+		h.direct <- directMessage{username: sm.Author, msg: msg}
+		return
+	}
+	if err := h.acceptMessage(msg, sm.AuthorAdmin); err != nil {
+		log.Printf("Dropping scheduled message #%d from %s: %v", sm.ID, sm.Author, err)
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"15m", now.Add(15 * time.Minute), false},
+		{"2h30m", now.Add(150 * time.Minute), false},
+		{"17:30", time.Date(2024, 5, 1, 17, 30, 0, 0, time.UTC), false},
+		{"09:00", time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC), false},
+		{"-5m", time.Time{}, true},
+		{"soon", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseScheduleTime(tt.spec, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseScheduleTime(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parseScheduleTime(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestHubScheduledMessages(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	if _, err := hub.ScheduleMessage("alice", false, "too late", time.Now().Add(8*24*time.Hour)); err == nil {
+		t.Error("Expected error for message scheduled beyond the maximum delay")
+	}
+
+	later, err := hub.ScheduleMessage("alice", false, "much later", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ScheduleMessage failed: %v", err)
+	}
+	if _, err := hub.ScheduleMessage("alice", false, "soon", time.Now().Add(20*time.Millisecond)); err != nil {
+		t.Fatalf("ScheduleMessage failed: %v", err)
+	}
+
+	pending, err := hub.ScheduledMessages("ALICE")
+	if err != nil || len(pending) != 2 || pending[0].Content != "soon" {
+		t.Fatalf("Expected two pending messages, soonest first; got %+v (%v)", pending, err)
+	}
+	if others, _ := hub.ScheduledMessages("bob"); len(others) != 0 {
+		t.Error("Other users should not see alice's scheduled messages")
+	}
+
+	// Only the author can cancel
+	if ok, _ := hub.CancelScheduledMessage("bob", later.ID); ok {
+		t.Error("Expected bob's cancel of alice's message to fail")
+	}
+	if ok, err := hub.CancelScheduledMessage("alice", later.ID); !ok || err != nil {
+		t.Errorf("Expected alice to cancel her own message, got %v", err)
+	}
+
+	select {
+	case msg := <-hub.broadcast:
+		m, ok := msg.(shared.Message)
+		if !ok || m.Sender != "alice" || m.Content != "soon" {
+			t.Fatalf("Unexpected delivered message: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for scheduled delivery")
+	}
+
+	if pending, _ := hub.ScheduledMessages("alice"); len(pending) != 0 {
+		t.Error("Delivered and cancelled messages should no longer be pending")
+	}
+	if history := db.GetRecentMessages(); len(history) != 1 || history[0].Content != "soon" {
+		t.Errorf("Delivered message should be stored in history, got %+v", history)
+	}
+}
+
+func TestScheduledMessagesSurviveRestart(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	if _, err := hub.ScheduleMessage("alice", true, "@here standup", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ScheduleMessage failed: %v", err)
+	}
+	// Came due while the server was down
+	if _, err := db.InsertScheduledMessage(ScheduledMessage{Author: "alice", Content: "missed", DeliverAt: time.Now().Add(-time.Minute), CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("InsertScheduledMessage failed: %v", err)
+	}
+
+	restarted := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	restarted.LoadScheduledMessages()
+
+	select {
+	case msg := <-restarted.broadcast:
+		if m, ok := msg.(shared.Message); !ok || m.Content != "missed" {
+			t.Fatalf("Expected the overdue message delivered after restart, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the overdue message")
+	}
+
+	pending, err := restarted.ScheduledMessages("alice")
+	if err != nil || len(pending) != 1 || pending[0].Content != "@here standup" || !pending[0].AuthorAdmin {
+		t.Fatalf("Expected the later message still pending with its author's admin flag, got %+v (%v)", pending, err)
+	}
+	restarted.scheduler.mu.Lock()
+	armed := len(restarted.scheduler.timers)
+	restarted.scheduler.mu.Unlock()
+	if armed != 1 {
+		t.Errorf("Expected 1 armed timer after restart, got %d", armed)
+	}
+}
+
+func TestScheduledMessageChecks(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+	alice := &Client{hub: hub, username: "alice", send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- alice
+	hub.register <- bob
+	hub.SetSlowMode(time.Minute)
+
+	alice.handleCommand(":schedule 1h @here lunch?")
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "only admins") {
+		t.Errorf("Expected @here to be refused when scheduling, got %q", msg.Content)
+	}
+	alice.handleCommand(":schedule 50ms ready @bob?")
+	if msg := nextTextMessage(t, alice); !strings.HasPrefix(msg.Content, "Message #1 scheduled") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+	alice.handleCommand(":schedule 1h and again")
+	if msg := nextTextMessage(t, alice); !strings.HasPrefix(msg.Content, "Slow mode is on") {
+		t.Errorf("Expected slow mode to apply when scheduling, got %q", msg.Content)
+	}
+
+	// Delivered like a live message, with its mentions resolved
+	msg := nextTextMessage(t, bob)
+	if msg.Content != "ready @bob?" || !reflect.DeepEqual(msg.Mentions, []string{"bob"}) || msg.Seq == 0 {
+		t.Errorf("Expected the scheduled message to mention bob, got %+v", msg)
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// sequencer numbers messages per channel in the order the server accepts
+// them, so every client shows a channel in the same order whatever the
+// senders' clocks say. Numbering carries on from the stored history.
+type sequencer struct {
+	mu     sync.Mutex
+	last   map[string]int64
+	latest func() int64 // highest stored number, read on first use
+}
+
+func newSequencer(latest func() int64) *sequencer {
+	return &sequencer{last: make(map[string]int64), latest: latest}
+}
+
+// next returns the next number in channel
+func (s *sequencer) next(channel string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.last[channel]
+	// Stored history is all in the room
+	if !ok && channel == roomChannel && s.latest != nil {
+		n = s.latest()
+	}
+	n++
+	s.last[channel] = n
+	return n
+}
+
+// latestSeq is the highest sequence number among stored messages
+func latestSeq(db Database) int64 {
+	var n int64
+	for _, msg := range db.GetRecentMessages() {
+		n = max(n, msg.Seq)
+	}
+	return n
+}
+
+// stamp numbers msg in the room unless it already has a number. Messages
+// are stamped before they are stored so history keeps the live order.
+func (h *Hub) stamp(msg *shared.Message) {
+	if msg.Seq == 0 {
+		msg.Seq = h.seq.next(roomChannel)
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestSequencerContinuesFromHistory(t *testing.T) {
+	loads := 0
+	s := newSequencer(func() int64 { loads++; return 41 })
+	if n := s.next(roomChannel); n != 42 {
+		t.Errorf("Expected numbering to continue after the stored history, got %d", n)
+	}
+	if n := s.next(roomChannel); n != 43 || loads != 1 {
+		t.Errorf("Expected 43 with history read once, got %d after %d reads", n, loads)
+	}
+	if n := s.next("other"); n != 1 {
+		t.Errorf("Expected each channel numbered on its own, got %d", n)
+	}
+}
+
+func TestHubNumbersMessagesInAcceptedOrder(t *testing.T) {
+	hub, db := CreateTestHub(t)
+	defer db.Close()
+	go hub.Run()
+
+	send := make(chan interface{}, 10)
+	client := &Client{hub: hub, username: "alice", send: send}
+	hub.register <- client
+
+	// Clocks disagree: the later message claims to be older
+	now := time.Now()
+	hub.broadcast <- shared.Message{Sender: "bob", Content: "first", CreatedAt: now}
+	hub.broadcast <- shared.Message{Sender: "carol", Content: "second", CreatedAt: now.Add(-time.Minute)}
+	hub.broadcast <- shared.Message{Sender: "dave", Content: "numbered", CreatedAt: now, Seq: 99}
+
+	var got []shared.Message
+	for len(got) < 3 {
+		select {
+		case v := <-send:
+			if msg, ok := v.(shared.Message); ok {
+				got = append(got, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected 3 messages, got %+v", got)
+		}
+	}
+	if got[0].Seq == 0 || got[1].Seq != got[0].Seq+1 {
+		t.Errorf("Expected consecutive numbers in broadcast order, got %d and %d", got[0].Seq, got[1].Seq)
+	}
+	if got[2].Seq != 99 {
+		t.Errorf("Expected an already numbered message to keep its number, got %d", got[2].Seq)
+	}
+
+	sortMessages(got)
+	if got[0].Content != "first" || got[1].Content != "second" {
+		t.Errorf("Expected channel order to ignore the senders' clocks, got %q then %q", got[0].Content, got[1].Content)
+	}
+}
+
+func TestSQLiteNumbersExistingMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// A messages table from before sequence numbers
+	_, err = old.Exec(`CREATE TABLE messages (id INTEGER PRIMARY KEY AUTOINCREMENT, message_id INTEGER DEFAULT 0,
+		sender TEXT, content TEXT, created_at DATETIME, is_encrypted BOOLEAN DEFAULT 0, message_type TEXT DEFAULT '',
+		encrypted_data BLOB, nonce BLOB, recipient TEXT)`)
+	if err != nil {
+		t.Fatalf("Failed to create old table: %v", err)
+	}
+	now := time.Now().UTC()
+	for i, content := range []string{"older", "newer"} {
+		// The second message's sender had a slow clock
+		if _, err := old.Exec(`INSERT INTO messages (message_id, sender, content, created_at) VALUES (?, 'alice', ?, ?)`,
+			i+1, content, now.Add(-time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := NewDatabase(DatabaseConfig{Type: "sqlite", FilePath: path})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	recent := db.GetRecentMessages()
+	if len(recent) != 2 || recent[0].Content != "older" || recent[0].Seq != 1 || recent[1].Seq != 2 {
+		t.Errorf("Expected existing messages numbered in insertion order, got %+v", recent)
+	}
+	if latestSeq(db) != 2 {
+		t.Errorf("Expected latest sequence number 2, got %d", latestSeq(db))
+	}
+}
+
+func TestImportNumbersAfterHistory(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	if err := db.InsertMessage(shared.Message{Sender: "alice", Content: "live", CreatedAt: time.Now(), Seq: 7}); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+
+	// Archived messages arrive numbered by another server
+	archive := `{"sender":"bob","content":"one","created_at":"2024-01-01T00:00:00Z","seq":3}
+{"sender":"bob","content":"two","created_at":"2024-01-01T00:01:00Z","seq":1}
+`
+	if _, err := ImportArchive(db, strings.NewReader(archive)); err != nil {
+		t.Fatalf("ImportArchive failed: %v", err)
+	}
+	recent := db.GetRecentMessages()
+	if len(recent) != 3 || recent[1].Content != "one" || recent[1].Seq != 8 || recent[2].Seq != 9 {
+		t.Errorf("Expected imported messages numbered after the history in archive order, got %+v", recent)
+	}
+}
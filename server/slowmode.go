@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// maxSlowMode bounds the :slowmode interval
+const maxSlowMode = time.Hour
+
+// slowMode limits how often non-admins may post. The server has a single
+// chat room, so the setting applies to everyone.
+type slowMode struct {
+	mu       sync.Mutex
+	interval time.Duration
+	lastPost map[string]time.Time // lowercase username -> last accepted post
+}
+
+func newSlowMode() *slowMode {
+	return &slowMode{lastPost: make(map[string]time.Time)}
+}
+
+// SlowModeStatus is the "slowmode" WebSocket payload so clients can show the
+// cooldown before the server refuses a message
+type SlowModeStatus struct {
+	IntervalSeconds int `json:"interval_seconds"`
+}
+
+// SetSlowMode sets the minimum interval between posts; 0 turns slow mode off
+func (h *Hub) SetSlowMode(d time.Duration) {
+	h.slowMode.mu.Lock()
+	defer h.slowMode.mu.Unlock()
+	h.slowMode.interval = d
+	h.slowMode.lastPost = make(map[string]time.Time)
+}
+
+// SlowMode returns the current slow mode interval, 0 when off
+func (h *Hub) SlowMode() time.Duration {
+	h.slowMode.mu.Lock()
+	defer h.slowMode.mu.Unlock()
+	return h.slowMode.interval
+}
+
+// slowModeWait records a post by username and returns 0, or returns how long
+// they must still wait without recording anything
+func (h *Hub) slowModeWait(username string, now time.Time) time.Duration {
+	h.slowMode.mu.Lock()
+	defer h.slowMode.mu.Unlock()
+	if h.slowMode.interval <= 0 {
+		return 0
+	}
+	lu := strings.ToLower(username)
+	if last, ok := h.slowMode.lastPost[lu]; ok {
+		if wait := h.slowMode.interval - now.Sub(last); wait > 0 {
+			return wait
+		}
+	}
+	h.slowMode.lastPost[lu] = now
+	return 0
+}
+
+// slowModeMessage builds the "slowmode" WebSocket message for clients
+func slowModeMessage(d time.Duration) WSMessage {
+	payload, _ := json.Marshal(SlowModeStatus{IntervalSeconds: int(d / time.Second)})
+	return WSMessage{Type: "slowmode", Data: payload}
+}
+
+// checkSlowMode reports whether the client may post now, telling them how
+// long to wait if not. Admins are exempt.
+func (c *Client) checkSlowMode() bool {
+	if c.isAdmin {
+		return true
+	}
+	wait := c.hub.slowModeWait(c.username, time.Now())
+	if wait <= 0 {
+		return true
+	}
+	seconds := int((wait + time.Second - 1) / time.Second)
+	c.reply(fmt.Sprintf("Slow mode is on: you can post again in %ds.", seconds))
+	return false
+}
+
+// handleSlowModeCommand handles ":slowmode <duration|off>"; with no argument
+// it reports the current setting
+func (c *Client) handleSlowModeCommand(args []string) {
+	if len(args) == 0 {
+		if d := c.hub.SlowMode(); d > 0 {
+			c.reply(fmt.Sprintf("Slow mode is on: one message every %s.", d))
+		} else {
+			c.reply("Slow mode is off. Usage: :slowmode <duration|off>")
+		}
+		return
+	}
+	var d time.Duration
+	if args[0] != "off" && args[0] != "0" {
+		var err error
+		d, err = time.ParseDuration(args[0])
+		if err != nil || d < time.Second {
+			c.reply("Invalid duration (e.g. 10s, 1m, or off)")
+			return
+		}
+		if d > maxSlowMode {
+			c.reply(fmt.Sprintf("Duration too long (max %s)", maxSlowMode))
+			return
+		}
+	}
+	c.hub.SetSlowMode(d)
+	AdminLogger.Info("Slow mode changed", map[string]interface{}{
+		"admin":    c.username,
+		"interval": d.String(),
+	})
+	log.Printf("[ADMIN] Slow mode set to %s by %s", d, c.username)
+
+	announcement := "Slow mode disabled."
+	if d > 0 {
+		announcement = fmt.Sprintf("Slow mode enabled: one message every %s.", d)
+	}
+	c.hub.broadcast <- slowModeMessage(d)
+	c.hub.broadcast <- shared.Message{
+		Sender:    "System",
+		Content:   announcement,
+		CreatedAt: time.Now(),
+		Type:      shared.TextMessage,
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowModeWait(t *testing.T) {
+	hub := &Hub{slowMode: newSlowMode()}
+	now := time.Now()
+	if wait := hub.slowModeWait("bob", now); wait != 0 {
+		t.Errorf("Slow mode off should never wait, got %v", wait)
+	}
+
+	hub.SetSlowMode(10 * time.Second)
+	if wait := hub.slowModeWait("bob", now); wait != 0 {
+		t.Errorf("First post should be allowed, got %v", wait)
+	}
+	if wait := hub.slowModeWait("BOB", now.Add(4*time.Second)); wait != 6*time.Second {
+		t.Errorf("Expected 6s left, got %v", wait)
+	}
+	// A refused post does not restart the cooldown
+	if wait := hub.slowModeWait("bob", now.Add(10*time.Second)); wait != 0 {
+		t.Errorf("Post after the interval should be allowed, got %v", wait)
+	}
+	if wait := hub.slowModeWait("alice", now.Add(10*time.Second)); wait != 0 {
+		t.Errorf("Cooldowns are per user, got %v", wait)
+	}
+
+	hub.SetSlowMode(0)
+	if wait := hub.slowModeWait("bob", now.Add(11*time.Second)); wait != 0 {
+		t.Errorf("Turning slow mode off should clear cooldowns, got %v", wait)
+	}
+}
+
+func TestSlowModeCommand(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	admin := &Client{hub: hub, username: "root", isAdmin: true, send: make(chan interface{}, 16)}
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- admin
+	hub.register <- bob
+
+	admin.handleCommand(":slowmode 2h")
+	if msg := nextTextMessage(t, admin); !strings.Contains(msg.Content, "too long") {
+		t.Errorf("Unexpected reply %q", msg.Content)
+	}
+
+	admin.handleCommand(":slowmode 10s")
+	deadline := time.After(time.Second)
+	for got := false; !got; {
+		select {
+		case msg := <-bob.send:
+			if ws, ok := msg.(WSMessage); ok && ws.Type == "slowmode" {
+				var status SlowModeStatus
+				if err := json.Unmarshal(ws.Data, &status); err != nil || status.IntervalSeconds != 10 {
+					t.Errorf("Unexpected slowmode payload %s", ws.Data)
+				}
+				got = true
+			}
+		case <-deadline:
+			t.Fatal("Timed out waiting for slowmode message")
+		}
+	}
+	if msg := nextTextMessage(t, bob); msg.Content != "Slow mode enabled: one message every 10s." {
+		t.Errorf("Unexpected announcement %q", msg.Content)
+	}
+
+	if !bob.checkSlowMode() {
+		t.Error("First post should be allowed")
+	}
+	if bob.checkSlowMode() {
+		t.Error("Second post should be refused")
+	}
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "you can post again in 10s") {
+		t.Errorf("Unexpected cooldown reply %q", msg.Content)
+	}
+	if !admin.checkSlowMode() || !admin.checkSlowMode() {
+		t.Error("Admins are exempt from slow mode")
+	}
+
+	bob.handleCommand(":slowmode off")
+	if msg := nextTextMessage(t, bob); !strings.Contains(msg.Content, "admin privileges") {
+		t.Errorf("Non-admins should not change slow mode, got %q", msg.Content)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// maxSnippetBytes caps a single shared snippet
+const maxSnippetBytes = 512 * 1024
+
+var (
+	snippetIDPattern       = regexp.MustCompile(`^[0-9a-f]{12}$`)
+	snippetLanguagePattern = regexp.MustCompile(`^[A-Za-z0-9+#._-]{1,32}$`)
+)
+
+// newSnippetID returns a short random ID that is impractical to guess
+func newSnippetID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// snippetReference is the chat message shown in place of a stored snippet
+func snippetReference(s shared.Snippet, preview string) string {
+	kind := "text"
+	if s.Language != "" {
+		kind = s.Language
+	}
+	preview = strings.TrimSpace(preview)
+	if len(preview) > 60 {
+		preview = preview[:60] + "…"
+	}
+	ref := fmt.Sprintf("📎 Snippet %s (%s, %d lines)", s.ID, kind, s.Lines)
+	if preview != "" {
+		ref += ": " + preview
+	}
+	return ref + " · view with :snippet " + s.ID
+}
+
+// shareSnippet stores a long paste from this client and broadcasts a short
+// reference to it instead of the full text
+func (c *Client) shareSnippet(msg shared.Message) {
+	if msg.Encrypted {
+		c.reply("Encrypted messages cannot be stored as snippets.")
+		return
+	}
+	content := strings.TrimRight(msg.Content, "\n")
+	if strings.TrimSpace(content) == "" {
+		c.reply("Snippet is empty.")
+		return
+	}
+	if len(content) > maxSnippetBytes {
+		c.reply(fmt.Sprintf("Snippet too large (max %d KB).", maxSnippetBytes/1024))
+		return
+	}
+	if !c.applyFilter(&content) {
+		return
+	}
+	language := ""
+	if msg.Snippet != nil && snippetLanguagePattern.MatchString(msg.Snippet.Language) {
+		language = msg.Snippet.Language
+	}
+
+	id, err := newSnippetID()
+	if err != nil {
+		c.reply("Could not store snippet: " + err.Error())
+		return
+	}
+	now := time.Now()
+	if err := c.db.InsertSnippet(Snippet{ID: id, Author: c.username, Language: language, Content: content, CreatedAt: now}); err != nil {
+		log.Printf("Failed to store snippet from %s: %v", c.username, err)
+		c.reply("Could not store snippet.")
+		return
+	}
+
+	meta := shared.Snippet{ID: id, Language: language, Lines: strings.Count(content, "\n") + 1, Author: c.username}
+	firstLine, _, _ := strings.Cut(content, "\n")
+	ref := shared.Message{
+		Sender:    c.username,
+		Content:   snippetReference(meta, firstLine),
+		CreatedAt: now,
+		Type:      shared.TextMessage,
+		Snippet:   &meta,
+	}
+	c.hub.stamp(&ref)
+	if err := c.db.InsertMessage(ref); err != nil {
+		log.Printf("Failed to insert message: %v", err)
+	}
+	log.Printf("Snippet %s stored by %s (%d bytes)", id, c.username, len(content))
+	c.hub.broadcast <- ref
+}
+
+// SnippetHandler serves stored snippets at /snippets/{id} as JSON, or as
+// plain text with ?raw=1
+func SnippetHandler(db Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/snippets/")
+		if !snippetIDPattern.MatchString(id) {
+			http.Error(w, "invalid snippet id", http.StatusBadRequest)
+			return
+		}
+		snip, err := db.GetSnippet(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "snippet not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			ServerLogger.Error("Failed to load snippet", err)
+			http.Error(w, "failed to load snippet", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if r.URL.Query().Get("raw") != "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte(snip.Content))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(shared.Snippet{
+			ID:        snip.ID,
+			Language:  snip.Language,
+			Lines:     strings.Count(snip.Content, "\n") + 1,
+			Author:    snip.Author,
+			Content:   snip.Content,
+			CreatedAt: snip.CreatedAt,
+		}); err != nil {
+			ServerLogger.Error("Failed to encode snippet response", err)
+		}
+	}
+}
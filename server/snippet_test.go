@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestShareSnippet(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	alice := &Client{hub: hub, db: NewDatabaseWrapper(db), username: "alice", send: make(chan interface{}, 16)}
+	hub.register <- alice
+
+	code := "package main\n\nfunc main() {\n}\n"
+	alice.shareSnippet(shared.Message{Sender: "mallory", Content: code, Type: shared.SnippetMessageType, Snippet: &shared.Snippet{Language: "go"}})
+
+	ref := nextTextMessage(t, alice)
+	if ref.Snippet == nil || ref.Sender != "alice" {
+		t.Fatalf("Expected a snippet reference from alice, got %+v", ref)
+	}
+	if ref.Snippet.Lines != 4 || ref.Snippet.Language != "go" || !strings.Contains(ref.Content, ":snippet "+ref.Snippet.ID) {
+		t.Errorf("Unexpected reference: %+v (%q)", ref.Snippet, ref.Content)
+	}
+	stored, err := db.GetSnippet(ref.Snippet.ID)
+	if err != nil || stored.Content != strings.TrimRight(code, "\n") || stored.Author != "alice" {
+		t.Errorf("Unexpected stored snippet %+v (%v)", stored, err)
+	}
+	if history := db.GetRecentMessages(); len(history) != 1 || history[0].Content != ref.Content {
+		t.Errorf("Reference should be stored in history, got %+v", history)
+	}
+
+	// Encrypted and oversized uploads are refused with a reply
+	alice.shareSnippet(shared.Message{Content: "secret", Encrypted: true, Type: shared.SnippetMessageType})
+	if msg := nextTextMessage(t, alice); msg.Sender != "System" || !strings.Contains(msg.Content, "Encrypted") {
+		t.Errorf("Expected refusal for encrypted snippet, got %+v", msg)
+	}
+	alice.shareSnippet(shared.Message{Content: strings.Repeat("x", maxSnippetBytes+1), Type: shared.SnippetMessageType})
+	if msg := nextTextMessage(t, alice); !strings.Contains(msg.Content, "too large") {
+		t.Errorf("Expected refusal for oversized snippet, got %+v", msg)
+	}
+}
+
+func TestSnippetHandler(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	if err := db.InsertSnippet(Snippet{ID: "0123456789ab", Author: "bob", Language: "python", Content: "print(1)\nprint(2)"}); err != nil {
+		t.Fatalf("InsertSnippet failed: %v", err)
+	}
+	handler := SnippetHandler(db)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/snippets/0123456789ab", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var snip shared.Snippet
+	if err := json.NewDecoder(rec.Body).Decode(&snip); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if snip.Author != "bob" || snip.Language != "python" || snip.Lines != 2 || snip.Content != "print(1)\nprint(2)" {
+		t.Errorf("Unexpected snippet %+v", snip)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/snippets/0123456789ab?raw=1", nil))
+	if rec.Body.String() != "print(1)\nprint(2)" || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Unexpected raw response %q (%s)", rec.Body.String(), rec.Header().Get("Content-Type"))
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/snippets/ffffffffffff", http.StatusNotFound},
+		{http.MethodGet, "/snippets/../etc", http.StatusBadRequest},
+		{http.MethodPost, "/snippets/0123456789ab", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestSpectatorsRefusedByDefault(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	ts := httptest.NewServer(ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(shared.Handshake{Username: "wall", ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, raw, err := conn.ReadMessage(); err == nil {
+		t.Errorf("Expected spectators to be refused, got %q", raw)
+	}
+}
+
+func TestSpectatorsNotListed(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	alice := &Client{hub: hub, username: "alice", send: make(chan interface{}, 16)}
+	wall := &Client{hub: hub, username: "wall", readOnly: true, send: make(chan interface{}, 16)}
+	hub.clients.add(alice)
+	hub.clients.add(wall)
+	hub.broadcastUserList()
+
+	// The spectator still receives the list, but is not on it
+	msg := (<-wall.send).(WSMessage)
+	var ul UserList
+	if err := json.Unmarshal(msg.Data, &ul); err != nil {
+		t.Fatal(err)
+	}
+	if len(ul.Users) != 1 || ul.Users[0] != "alice" {
+		t.Errorf("Expected only alice to be listed, got %v", ul.Users)
+	}
+}
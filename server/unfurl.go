@@ -0,0 +1,256 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"golang.org/x/net/html"
+)
+
+// Link preview limits: how long a fetch may take, how much of a page is
+// read looking for metadata, how long results are cached, and how many
+const (
+	unfurlTimeout    = 5 * time.Second
+	unfurlMaxBytes   = 512 * 1024
+	unfurlCacheTTL   = time.Hour
+	unfurlCacheSize  = 500
+	maxPreviewTitle  = 200
+	maxPreviewDetail = 300
+)
+
+// unfurlURLPattern finds the first http(s) link in a message
+var unfurlURLPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+type unfurlEntry struct {
+	preview *shared.LinkPreview // nil when the page had nothing to show
+	fetched time.Time
+}
+
+// unfurler fetches OpenGraph metadata for links on allowlisted domains and
+// caches the results, failures included, so a popular link is fetched once
+type unfurler struct {
+	domains []string // lowercase hosts; subdomains match too
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]unfurlEntry
+}
+
+func newUnfurler(domains []string) *unfurler {
+	u := &unfurler{cache: make(map[string]unfurlEntry)}
+	for _, d := range domains {
+		if d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), "."); d != "" {
+			u.domains = append(u.domains, strings.TrimPrefix(d, "*."))
+		}
+	}
+	u.client = &http.Client{
+		Timeout: unfurlTimeout,
+		// Redirects must stay on allowlisted domains too
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 || !u.allowed(req.URL) {
+				return fmt.Errorf("redirect to %s not followed", req.URL.Host)
+			}
+			return nil
+		},
+	}
+	return u
+}
+
+// SetLinkPreviewDomains turns link previews on for links to these domains
+// and their subdomains; an empty list turns them off
+func (h *Hub) SetLinkPreviewDomains(domains []string) {
+	u := newUnfurler(domains)
+	if len(u.domains) == 0 {
+		h.previews = nil
+		return
+	}
+	h.previews = u
+}
+
+// allowed reports whether a URL is http(s) on an allowlisted domain
+func (u *unfurler) allowed(link *url.URL) bool {
+	if link.Scheme != "http" && link.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(link.Hostname())
+	for _, d := range u.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// preview returns the cached or freshly fetched preview for link, or nil
+func (u *unfurler) preview(link string, now time.Time) *shared.LinkPreview {
+	u.mu.Lock()
+	if e, ok := u.cache[link]; ok && now.Sub(e.fetched) < unfurlCacheTTL {
+		u.mu.Unlock()
+		return e.preview
+	}
+	u.mu.Unlock()
+
+	p, err := u.fetch(link)
+	if err != nil {
+		log.Printf("Link preview for %s failed: %v", link, err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.cache) >= unfurlCacheSize {
+		// Make room by dropping the oldest entry
+		var oldest string
+		for k, e := range u.cache {
+			if oldest == "" || e.fetched.Before(u.cache[oldest].fetched) {
+				oldest = k
+			}
+		}
+		delete(u.cache, oldest)
+	}
+	u.cache[link] = unfurlEntry{preview: p, fetched: now}
+	return p
+}
+
+// fetch downloads the start of an HTML page and reads its metadata
+func (u *unfurler) fetch(link string) (*shared.LinkPreview, error) {
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "marchat-link-preview/"+shared.ServerVersion)
+	req.Header.Set("Accept", "text/html")
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" {
+		return nil, nil
+	}
+	p := parsePreview(io.LimitReader(resp.Body, unfurlMaxBytes))
+	if p == nil {
+		return nil, nil
+	}
+	p.URL = link
+	return p, nil
+}
+
+// parsePreview reads OpenGraph tags from an HTML page, falling back to the
+// <title> and description meta tag. It returns nil when there is no title.
+func parsePreview(r io.Reader) *shared.LinkPreview {
+	var p shared.LinkPreview
+	var title, description string
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finishPreview(p, title, description)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = string(v)
+					}
+				}
+				switch key {
+				case "og:title":
+					p.Title = content
+				case "og:description":
+					p.Description = content
+				case "og:site_name":
+					p.SiteName = content
+				case "description":
+					description = content
+				}
+			case "body":
+				// Metadata lives in <head>
+				return finishPreview(p, title, description)
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(z.Text())
+			}
+		case html.EndTagToken:
+			inTitle = false
+		}
+	}
+}
+
+func finishPreview(p shared.LinkPreview, title, description string) *shared.LinkPreview {
+	if p.Title == "" {
+		p.Title = title
+	}
+	if p.Description == "" {
+		p.Description = description
+	}
+	p.Title = clipPreviewText(p.Title, maxPreviewTitle)
+	p.Description = clipPreviewText(p.Description, maxPreviewDetail)
+	p.SiteName = clipPreviewText(p.SiteName, maxPreviewTitle)
+	if p.Title == "" {
+		return nil
+	}
+	return &p
+}
+
+// clipPreviewText folds whitespace and cuts text to at most n runes
+func clipPreviewText(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// unfurlLater fetches a preview for the first allowlisted link in msg and
+// sends it to everyone once it is ready
+func (h *Hub) unfurlLater(msg shared.Message) {
+	u := h.previews
+	if u == nil || msg.Encrypted {
+		return
+	}
+	var link string
+	for _, candidate := range unfurlURLPattern.FindAllString(msg.Content, -1) {
+		candidate = strings.TrimRight(candidate, ".,;:!?)]}'")
+		if parsed, err := url.Parse(candidate); err == nil && u.allowed(parsed) {
+			link = candidate
+			break
+		}
+	}
+	if link == "" {
+		return
+	}
+	go func() {
+		p := u.preview(link, time.Now())
+		if p == nil {
+			return
+		}
+		payload, _ := json.Marshal(shared.PreviewUpdate{Sender: msg.Sender, CreatedAt: msg.CreatedAt, Preview: *p})
+		h.broadcast <- WSMessage{Type: "preview", Data: payload}
+	}()
+}
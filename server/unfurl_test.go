@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestParsePreview(t *testing.T) {
+	page := `<html><head>
+		<title>Fallback title</title>
+		<meta property="og:title" content="  marchat   release notes ">
+		<meta property="og:site_name" content="GitHub">
+		<meta name="description" content="Plain description">
+	</head><body><meta property="og:description" content="ignored, in the body"></body></html>`
+	p := parsePreview(strings.NewReader(page))
+	if p == nil || p.Title != "marchat release notes" || p.SiteName != "GitHub" || p.Description != "Plain description" {
+		t.Errorf("Unexpected preview %+v", p)
+	}
+
+	p = parsePreview(strings.NewReader(`<title>Only a title</title>`))
+	if p == nil || p.Title != "Only a title" {
+		t.Errorf("Expected the <title> fallback, got %+v", p)
+	}
+	if p := parsePreview(strings.NewReader(`<p>no metadata</p>`)); p != nil {
+		t.Errorf("Expected no preview without a title, got %+v", p)
+	}
+	long := parsePreview(strings.NewReader(`<meta property="og:title" content="` + strings.Repeat("a", 500) + `">`))
+	if long == nil || len([]rune(long.Title)) != maxPreviewTitle || !strings.HasSuffix(long.Title, "…") {
+		t.Errorf("Expected a clipped title, got %d runes", len([]rune(long.Title)))
+	}
+}
+
+func TestUnfurlerAllowlist(t *testing.T) {
+	u := newUnfurler([]string{" GitHub.com ", "*.example.org", ""})
+	for link, want := range map[string]bool{
+		"https://github.com/Cod-e-Codes/marchat": true,
+		"https://gist.github.com/x":              true,
+		"https://docs.example.org/page":          true,
+		"https://example.org.evil.com/":          false,
+		"https://notgithub.com/":                 false,
+		"ftp://github.com/file":                  false,
+	} {
+		parsed, _ := url.Parse(link)
+		if got := u.allowed(parsed); got != want {
+			t.Errorf("allowed(%s) = %v, want %v", link, got, want)
+		}
+	}
+
+	hub := &Hub{}
+	hub.SetLinkPreviewDomains([]string{" ", ""})
+	if hub.previews != nil {
+		t.Error("An empty allowlist should leave previews off")
+	}
+}
+
+func TestUnfurlLater(t *testing.T) {
+	var fetches atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<head><meta property="og:title" content="Release notes"><meta property="og:description" content="What changed"></head>`)
+	})
+	mux.HandleFunc("/away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://elsewhere.invalid/", http.StatusFound)
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	host, _ := url.Parse(srv.URL)
+
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+	hub.SetLinkPreviewDomains([]string{host.Hostname()})
+	bob := &Client{hub: hub, username: "bob", send: make(chan interface{}, 16)}
+	hub.register <- bob
+
+	msg := shared.Message{Sender: "alice", Content: "see (" + srv.URL + "/page).", CreatedAt: time.Now(), Type: shared.TextMessage}
+	hub.unfurlLater(msg)
+	var update shared.PreviewUpdate
+	if err := json.Unmarshal(nextWSMessage(t, bob, "preview").Data, &update); err != nil {
+		t.Fatal(err)
+	}
+	if update.Sender != "alice" || !update.CreatedAt.Equal(msg.CreatedAt) || update.Preview.URL != srv.URL+"/page" ||
+		update.Preview.Title != "Release notes" || update.Preview.Description != "What changed" {
+		t.Errorf("Unexpected preview update %+v", update)
+	}
+
+	// Cached: the page is not fetched again
+	if p := hub.previews.preview(srv.URL+"/page", time.Now()); p == nil || fetches.Load() != 1 {
+		t.Errorf("Expected a cached preview after one fetch, got %+v after %d", p, fetches.Load())
+	}
+	// Off-list redirects and non-HTML pages give nothing
+	for _, path := range []string{"/away", "/image"} {
+		if p := hub.previews.preview(srv.URL+path, time.Now()); p != nil {
+			t.Errorf("Expected no preview for %s, got %+v", path, p)
+		}
+	}
+
+	// Encrypted messages and off-list links are never fetched
+	hub.unfurlLater(shared.Message{Sender: "alice", Content: srv.URL + "/page?x", Encrypted: true})
+	hub.unfurlLater(shared.Message{Sender: "alice", Content: "https://unlisted.invalid/page"})
+	if fetches.Load() != 1 {
+		t.Errorf("Expected no further fetches, got %d", fetches.Load())
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// roomChannel names the server's single chat room in the per-channel
+// breakdown
+const roomChannel = "room"
+
+// usageStats counts activity per user and per channel since the server
+// started, for the metrics detail in both admin panels
+type usageStats struct {
+	mu       sync.Mutex
+	since    time.Time
+	users    map[string]*userUsage    // lowercase username
+	channels map[string]*channelUsage // channel name
+	live     map[*Client]struct{}     // connected sessions, counted when read
+}
+
+type userUsage struct {
+	name       string
+	messages   int64
+	bytesIn    int64 // of finished sessions; live ones are added when read
+	bytesOut   int64
+	active     time.Duration
+	sessions   int
+	lastActive time.Time
+}
+
+type channelUsage struct {
+	messages   int64
+	bytes      int64
+	senders    map[string]struct{}
+	active     time.Duration // whole minutes with at least one message
+	lastMinute time.Time
+	lastActive time.Time
+}
+
+// UserUsage is one user's row in the metrics detail. ActiveTime is time
+// connected, including sessions still open.
+type UserUsage struct {
+	Username   string        `json:"username"`
+	Messages   int64         `json:"messages"`
+	BytesIn    int64         `json:"bytes_in"`
+	BytesOut   int64         `json:"bytes_out"`
+	ActiveTime time.Duration `json:"active_time"`
+	Sessions   int           `json:"sessions"`
+	Online     bool          `json:"online"`
+	LastActive time.Time     `json:"last_active"`
+}
+
+// ChannelUsage is one channel's row in the metrics detail. ActiveTime counts
+// the minutes in which anyone posted.
+type ChannelUsage struct {
+	Channel    string        `json:"channel"`
+	Messages   int64         `json:"messages"`
+	Bytes      int64         `json:"bytes"`
+	Users      int           `json:"users"`
+	ActiveTime time.Duration `json:"active_time"`
+	LastActive time.Time     `json:"last_active"`
+}
+
+// UsageDetail is the per-user and per-channel breakdown
+type UsageDetail struct {
+	Since    time.Time      `json:"since"`
+	Users    []UserUsage    `json:"users"`
+	Channels []ChannelUsage `json:"channels"`
+}
+
+func newUsageStats() *usageStats {
+	return &usageStats{
+		since:    time.Now(),
+		users:    make(map[string]*userUsage),
+		channels: make(map[string]*channelUsage),
+		live:     make(map[*Client]struct{}),
+	}
+}
+
+func (u *usageStats) user(name string) *userUsage {
+	key := strings.ToLower(name)
+	uu, ok := u.users[key]
+	if !ok {
+		uu = &userUsage{name: name}
+		u.users[key] = uu
+	}
+	return uu
+}
+
+// connected starts counting a session
+func (u *usageStats) connected(c *Client) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.live[c] = struct{}{}
+	uu := u.user(c.username)
+	uu.sessions++
+	uu.lastActive = c.connectedAt
+}
+
+// disconnected folds a finished session into its user's totals
+func (u *usageStats) disconnected(c *Client) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.live[c]; !ok {
+		return
+	}
+	delete(u.live, c)
+	uu := u.user(c.username)
+	uu.bytesIn += c.traffic.bytesIn.Load()
+	uu.bytesOut += c.traffic.bytesOut.Load()
+	uu.active += time.Since(c.connectedAt)
+	uu.lastActive = time.Now()
+}
+
+// message counts a chat message posted to channel
+func (u *usageStats) message(channel string, msg shared.Message) {
+	size := int64(len(msg.Content))
+	if msg.File != nil {
+		size += msg.File.Size
+	}
+	if msg.Audio != nil {
+		size += int64(len(msg.Audio.Data))
+	}
+	now := time.Now()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	uu := u.user(msg.Sender)
+	uu.messages++
+	uu.lastActive = now
+
+	cu, ok := u.channels[channel]
+	if !ok {
+		cu = &channelUsage{senders: make(map[string]struct{})}
+		u.channels[channel] = cu
+	}
+	cu.messages++
+	cu.bytes += size
+	cu.senders[strings.ToLower(msg.Sender)] = struct{}{}
+	if minute := now.Truncate(time.Minute); !minute.Equal(cu.lastMinute) {
+		cu.active += time.Minute
+		cu.lastMinute = minute
+	}
+	cu.lastActive = now
+}
+
+// totals returns how many sessions are connected and how many messages have
+// been posted since the server started
+func (u *usageStats) totals() (online int, messages int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, cu := range u.channels {
+		messages += cu.messages
+	}
+	return len(u.live), messages
+}
+
+// Detail returns every user and channel, most messages first
+func (u *usageStats) Detail() UsageDetail {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+
+	rows := make(map[string]*UserUsage, len(u.users))
+	for key, uu := range u.users {
+		rows[key] = &UserUsage{
+			Username:   uu.name,
+			Messages:   uu.messages,
+			BytesIn:    uu.bytesIn,
+			BytesOut:   uu.bytesOut,
+			ActiveTime: uu.active,
+			Sessions:   uu.sessions,
+			LastActive: uu.lastActive,
+		}
+	}
+	for c := range u.live {
+		row := rows[strings.ToLower(c.username)]
+		row.BytesIn += c.traffic.bytesIn.Load()
+		row.BytesOut += c.traffic.bytesOut.Load()
+		row.ActiveTime += now.Sub(c.connectedAt)
+		row.Online = true
+		row.LastActive = now
+	}
+
+	detail := UsageDetail{Since: u.since}
+	for _, row := range rows {
+		row.ActiveTime = row.ActiveTime.Truncate(time.Second)
+		detail.Users = append(detail.Users, *row)
+	}
+	for name, cu := range u.channels {
+		detail.Channels = append(detail.Channels, ChannelUsage{
+			Channel:    name,
+			Messages:   cu.messages,
+			Bytes:      cu.bytes,
+			Users:      len(cu.senders),
+			ActiveTime: cu.active,
+			LastActive: cu.lastActive,
+		})
+	}
+	sortUserUsage(detail.Users, "messages", true)
+	sort.Slice(detail.Channels, func(i, j int) bool {
+		return detail.Channels[i].Messages > detail.Channels[j].Messages
+	})
+	return detail
+}
+
+// userUsageSorts are the columns the user breakdown can be sorted by
+var userUsageSorts = []string{"messages", "bytes", "active", "username"}
+
+// sortUserUsage sorts rows by a column from userUsageSorts, ties by name
+func sortUserUsage(rows []UserUsage, by string, desc bool) {
+	compare := func(a, b UserUsage) int {
+		switch by {
+		case "bytes":
+			return cmp.Compare(a.BytesIn+a.BytesOut, b.BytesIn+b.BytesOut)
+		case "active":
+			return cmp.Compare(a.ActiveTime, b.ActiveTime)
+		case "username":
+			return strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username))
+		default:
+			return cmp.Compare(a.Messages, b.Messages)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := compare(rows[i], rows[j])
+		if c == 0 {
+			return strings.ToLower(rows[i].Username) < strings.ToLower(rows[j].Username)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// UsageDetail returns the per-user and per-channel activity breakdown
+func (h *Hub) UsageDetail() UsageDetail {
+	return h.usage.Detail()
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestUsageStats(t *testing.T) {
+	u := newUsageStats()
+	alice := &Client{username: "alice", connectedAt: time.Now().Add(-time.Hour)}
+	bob := &Client{username: "Bob", connectedAt: time.Now()}
+	u.connected(alice)
+	u.connected(bob)
+	alice.traffic.bytesIn.Add(100)
+	alice.traffic.bytesOut.Add(400)
+
+	u.message(roomChannel, shared.Message{Sender: "alice", Content: "hello"})
+	u.message(roomChannel, shared.Message{Sender: "alice", Content: "again"})
+	u.message(roomChannel, shared.Message{Sender: "bob", Content: "hi", File: &shared.FileMeta{Filename: "a.txt", Size: 1000}})
+
+	detail := u.Detail()
+	if len(detail.Users) != 2 || detail.Users[0].Username != "alice" {
+		t.Fatalf("users = %+v", detail.Users)
+	}
+	a := detail.Users[0]
+	if a.Messages != 2 || a.BytesIn != 100 || a.BytesOut != 400 || !a.Online || a.ActiveTime < time.Hour {
+		t.Errorf("alice = %+v", a)
+	}
+	if b := detail.Users[1]; b.Username != "Bob" || b.Messages != 1 {
+		t.Errorf("bob = %+v, want messages counted under the connected name", b)
+	}
+	if len(detail.Channels) != 1 {
+		t.Fatalf("channels = %+v", detail.Channels)
+	}
+	if c := detail.Channels[0]; c.Messages != 3 || c.Bytes != 1012 || c.Users != 2 || c.ActiveTime < time.Minute {
+		t.Errorf("room = %+v", c)
+	}
+
+	// A finished session keeps its totals; a second unregister changes nothing
+	u.disconnected(alice)
+	u.disconnected(alice)
+	a = u.Detail().Users[0]
+	if a.Online || a.Sessions != 1 || a.BytesOut != 400 || a.ActiveTime < time.Hour {
+		t.Errorf("alice after disconnect = %+v", a)
+	}
+	again := &Client{username: "alice", connectedAt: time.Now()}
+	u.connected(again)
+	again.traffic.bytesOut.Add(50)
+	if a = u.Detail().Users[0]; !a.Online || a.Sessions != 2 || a.BytesOut != 450 {
+		t.Errorf("alice reconnected = %+v", a)
+	}
+}
+
+func TestSortUserUsage(t *testing.T) {
+	rows := []UserUsage{
+		{Username: "carol", Messages: 5, BytesIn: 10, ActiveTime: time.Minute},
+		{Username: "alice", Messages: 5, BytesIn: 500, ActiveTime: time.Second},
+		{Username: "Bob", Messages: 9, BytesIn: 1, ActiveTime: time.Hour},
+	}
+	for _, tc := range []struct {
+		by   string
+		desc bool
+		want []string
+	}{
+		{"messages", true, []string{"Bob", "alice", "carol"}},
+		{"messages", false, []string{"alice", "carol", "Bob"}},
+		{"bytes", true, []string{"alice", "carol", "Bob"}},
+		{"active", true, []string{"Bob", "carol", "alice"}},
+		{"username", false, []string{"alice", "Bob", "carol"}},
+	} {
+		sortUserUsage(rows, tc.by, tc.desc)
+		for i, name := range tc.want {
+			if rows[i].Username != name {
+				t.Errorf("sort %s desc=%v: got %v", tc.by, tc.desc, rows)
+				break
+			}
+		}
+	}
+}
+
+func TestAdminWeb_MetricsDetail(t *testing.T) {
+	_, hub, cfg, cleanup := setupTestServerEnv(t)
+	defer cleanup()
+
+	hub.usage.message(roomChannel, shared.Message{Sender: "alice", Content: "hello"})
+	hub.usage.message(roomChannel, shared.Message{Sender: "bob", Content: "hi"})
+	hub.usage.message(roomChannel, shared.Message{Sender: "bob", Content: "hi"})
+
+	was := NewWebAdminServer(hub, nil, cfg)
+	mux := http.NewServeMux()
+	was.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := &adminWebClient{t: t, ts: ts}
+	if status := c.do(http.MethodGet, "metrics/detail", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("signed out: %d, want 401", status)
+	}
+	c.login(map[string]string{"key": cfg.AdminKey})
+
+	var detail UsageDetail
+	if status := c.do(http.MethodGet, "metrics/detail?sort=username&order=asc", nil, &detail); status != http.StatusOK {
+		t.Fatalf("detail: %d", status)
+	}
+	if len(detail.Users) != 2 || detail.Users[0].Username != "alice" || detail.Users[1].Messages != 2 {
+		t.Errorf("users = %+v", detail.Users)
+	}
+	if len(detail.Channels) != 1 || detail.Channels[0].Channel != roomChannel || detail.Channels[0].Messages != 3 {
+		t.Errorf("channels = %+v", detail.Channels)
+	}
+	if status := c.do(http.MethodGet, "metrics/detail?sort=password", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("unknown sort: %d, want 400", status)
+	}
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SenderSort orders QuerySenders results
+type SenderSort string
+
+const (
+	SortByMessages SenderSort = "messages"
+	SortByName     SenderSort = "name"
+	SortByLastSeen SenderSort = "last_seen"
+)
+
+// SenderQuery selects a page of message senders, for user listings that
+// must not load every historical user at once
+type SenderQuery struct {
+	Search string   // case-insensitive substring of the username
+	Only   []string // restrict to these usernames (lowercase); nil means everyone
+	Sort   SenderSort
+	Desc   bool
+	Offset int
+	Limit  int // 0 = no limit
+}
+
+// SenderStats is one sender's activity in the message history
+type SenderStats struct {
+	Username string
+	Messages int
+	LastSeen time.Time // time of the newest message
+}
+
+// matches reports whether username passes the query's search and Only filters
+func (q SenderQuery) matches(username string) bool {
+	lower := strings.ToLower(username)
+	if q.Search != "" && !strings.Contains(lower, strings.ToLower(q.Search)) {
+		return false
+	}
+	if q.Only != nil {
+		for _, u := range q.Only {
+			if u == lower {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// less orders a before b for the query's sort, ties broken by name
+func (q SenderQuery) less(a, b SenderStats) bool {
+	cmp := 0
+	switch q.Sort {
+	case SortByName:
+		cmp = strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username))
+	case SortByLastSeen:
+		cmp = a.LastSeen.Compare(b.LastSeen)
+	default:
+		cmp = a.Messages - b.Messages
+	}
+	if cmp == 0 {
+		return a.Username < b.Username
+	}
+	if q.Desc {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// page sorts stats and returns the requested page and the total count, for
+// backends that filter in memory
+func (q SenderQuery) page(stats []SenderStats) ([]SenderStats, int) {
+	sort.Slice(stats, func(i, j int) bool { return q.less(stats[i], stats[j]) })
+	total := len(stats)
+	if q.Offset >= total {
+		return nil, total
+	}
+	stats = stats[q.Offset:]
+	if q.Limit > 0 && len(stats) > q.Limit {
+		stats = stats[:q.Limit]
+	}
+	return stats, total
+}
+
+// querySendersSQL is QuerySenders for the SQL backends; placeholder returns
+// the nth (1-based) parameter marker in the backend's syntax
+func querySendersSQL(db *sql.DB, q SenderQuery, placeholder func(n int) string) ([]SenderStats, int, error) {
+	if q.Only != nil && len(q.Only) == 0 {
+		return nil, 0, nil
+	}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return placeholder(len(args))
+	}
+
+	where := []string{"sender != 'System'"}
+	if q.Search != "" {
+		escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.ToLower(q.Search))
+		where = append(where, "LOWER(sender) LIKE "+arg("%"+escaped+"%")+" ESCAPE '!'")
+	}
+	if q.Only != nil {
+		marks := make([]string, len(q.Only))
+		for i, u := range q.Only {
+			marks[i] = arg(u)
+		}
+		where = append(where, "LOWER(sender) IN ("+strings.Join(marks, ", ")+")")
+	}
+	cond := strings.Join(where, " AND ")
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(DISTINCT sender) FROM messages WHERE `+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "COUNT(*)"
+	switch q.Sort {
+	case SortByName:
+		order = "LOWER(sender)"
+	case SortByLastSeen:
+		order = "MAX(created_at)"
+	}
+	if q.Desc {
+		order += " DESC"
+	}
+	query := `SELECT sender, COUNT(*), MAX(created_at) FROM messages WHERE ` + cond +
+		` GROUP BY sender ORDER BY ` + order + `, sender`
+	if q.Limit > 0 {
+		query += " LIMIT " + arg(q.Limit) + " OFFSET " + arg(q.Offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var stats []SenderStats
+	for rows.Next() {
+		var s SenderStats
+		var lastSeen interface{}
+		if err := rows.Scan(&s.Username, &s.Messages, &lastSeen); err != nil {
+			return nil, 0, err
+		}
+		s.LastSeen = scanDBTime(lastSeen)
+		stats = append(stats, s)
+	}
+	return stats, total, rows.Err()
+}
+
+// scanDBTime converts an aggregate timestamp, which drivers return as a
+// time.Time or as text depending on the backend, to a time.Time
+func scanDBTime(v interface{}) time.Time {
+	var s string
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	default:
+		return time.Time{}
+	}
+	// SQLite stores time.Time.String(), monotonic clock reading included
+	if i := strings.Index(s, " m="); i >= 0 {
+		s = s[:i]
+	}
+	for _, layout := range []string{
+		"2006-01-02 15:04:05.999999999 -0700 MST",
+		"2006-01-02 15:04:05.999999999-07:00",
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+	} {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// userListSyncInterval is how often clients that apply deltas are sent the
+// full user list anyway, so one that fell out of step catches up
+const userListSyncInterval = 5 * time.Minute
+
+// UserListDelta is what changed in the user list since the one numbered
+// Seq-1. Clients that asked for deltas in their handshake get these in
+// place of full lists once they have had one.
+type UserListDelta struct {
+	Seq          uint64            `json:"seq"`
+	Joined       []string          `json:"joined,omitempty"`
+	Left         []string          `json:"left,omitempty"`
+	DisplayNames map[string]string `json:"display_names,omitempty"` // changed names; "" clears one
+	Away         []string          `json:"away,omitempty"`          // went idle
+	Back         []string          `json:"back,omitempty"`          // active again
+}
+
+// empty reports whether d changes nothing
+func (d UserListDelta) empty() bool {
+	return len(d.Joined) == 0 && len(d.Left) == 0 && len(d.DisplayNames) == 0 && len(d.Away) == 0 && len(d.Back) == 0
+}
+
+// userListSync asks the hub goroutine to send everyone the full user list
+type userListSync struct{}
+
+// startUserListSync sends the full user list every userListSyncInterval
+func (h *Hub) startUserListSync() {
+	go func() {
+		ticker := time.NewTicker(userListSyncInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			h.userListSyncs <- userListSync{}
+		}
+	}()
+}
+
+func (h *Hub) broadcastUserList() {
+	h.sendUserList(false)
+}
+
+// sendUserList sends the current user list: in full to clients that don't
+// apply deltas, that haven't had a full list yet, or to everyone when
+// resync is set, and otherwise only what changed since the last one
+func (h *Hub) sendUserList(resync bool) {
+	current := h.currentUserList()
+	h.userListMu.Lock()
+	defer h.userListMu.Unlock()
+	delta := diffUserLists(h.userList, current)
+	current.Seq = h.userList.Seq
+	if !delta.empty() {
+		current.Seq++
+	}
+	delta.Seq = current.Seq
+	h.userList = current
+
+	fullPayload, _ := json.Marshal(current)
+	full := WSMessage{Type: "userlist", Data: fullPayload}
+	deltaPayload, _ := json.Marshal(delta)
+	changes := WSMessage{Type: "userlist_delta", Data: deltaPayload}
+	for client := range h.clients.all() {
+		switch {
+		case resync || !client.userListDeltas || !client.userListSynced:
+			client.userListSynced = client.trySend(full)
+		case !delta.empty():
+			// A dropped delta is made up with a full list next time
+			client.userListSynced = client.trySend(changes)
+		}
+	}
+}
+
+// currentUserList lists the connected users with their display names and
+// who is away
+func (h *Hub) currentUserList() UserList {
+	usernames := []string{}
+	seen := make(map[string]bool)
+	for client := range h.clients.all() {
+		// Users connected from several devices are listed once;
+		// spectators are not listed at all
+		lu := strings.ToLower(client.username)
+		if client.username != "" && !client.readOnly && !seen[lu] {
+			seen[lu] = true
+			usernames = append(usernames, client.username)
+		}
+	}
+	sort.Strings(usernames) // Sort alphabetically
+	userList := UserList{Users: usernames, DisplayNames: h.connectedDisplayNames(usernames)}
+	away := h.awayUsers()
+	for _, username := range usernames {
+		if away[strings.ToLower(username)] {
+			userList.Away = append(userList.Away, username)
+		}
+	}
+	return userList
+}
+
+// diffUserLists works out the delta that turns prev into next
+func diffUserLists(prev, next UserList) UserListDelta {
+	var d UserListDelta
+	listed, away := make(map[string]bool, len(prev.Users)), make(map[string]bool, len(prev.Away))
+	for _, u := range prev.Users {
+		listed[u] = true
+	}
+	for _, u := range prev.Away {
+		away[u] = true
+	}
+	nowAway := make(map[string]bool, len(next.Away))
+	for _, u := range next.Away {
+		nowAway[u] = true
+	}
+	for _, u := range next.Users {
+		if !listed[u] {
+			d.Joined = append(d.Joined, u)
+		}
+		delete(listed, u)
+		if name := next.DisplayNames[u]; name != prev.DisplayNames[u] {
+			if d.DisplayNames == nil {
+				d.DisplayNames = make(map[string]string)
+			}
+			d.DisplayNames[u] = name
+		}
+		switch {
+		case nowAway[u] && !away[u]:
+			d.Away = append(d.Away, u)
+		case away[u] && !nowAway[u]:
+			d.Back = append(d.Back, u)
+		}
+	}
+	// Whoever is left over is no longer listed
+	for _, u := range prev.Users {
+		if listed[u] {
+			d.Left = append(d.Left, u)
+		}
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffUserLists(t *testing.T) {
+	prev := UserList{Users: []string{"alice", "bob", "carol"}, DisplayNames: map[string]string{"bob": "Bobby"}, Away: []string{"carol"}}
+	next := UserList{Users: []string{"alice", "carol", "dave"}, DisplayNames: map[string]string{"alice": "Al"}, Away: []string{"alice"}}
+	got := diffUserLists(prev, next)
+	want := UserListDelta{
+		Joined:       []string{"dave"},
+		Left:         []string{"bob"},
+		DisplayNames: map[string]string{"alice": "Al"},
+		Away:         []string{"alice"},
+		Back:         []string{"carol"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffUserLists() = %+v, want %+v", got, want)
+	}
+	if d := diffUserLists(next, next); !d.empty() {
+		t.Errorf("Expected no changes between equal lists, got %+v", d)
+	}
+}
+
+func TestSendUserListDeltas(t *testing.T) {
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", nil)
+	now := time.Now()
+	alice := &Client{hub: hub, username: "alice", connectedAt: now, userListDeltas: true, send: make(chan interface{}, 10)}
+	legacy := &Client{hub: hub, username: "bob", connectedAt: now, send: make(chan interface{}, 10)}
+	hub.clients.add(alice)
+	hub.clients.add(legacy)
+
+	next := func(c *Client) WSMessage {
+		t.Helper()
+		select {
+		case msg := <-c.send:
+			return msg.(WSMessage)
+		default:
+			t.Fatalf("Expected a frame for %s", c.username)
+			return WSMessage{}
+		}
+	}
+
+	// Everyone starts with a full list
+	hub.broadcastUserList()
+	if msg := next(alice); msg.Type != "userlist" {
+		t.Fatalf("Expected a full list first, got %s", msg.Type)
+	}
+	next(legacy)
+
+	// Then clients that asked for them get only the changes
+	carol := &Client{hub: hub, username: "carol", connectedAt: now, send: make(chan interface{}, 10)}
+	hub.clients.add(carol)
+	hub.broadcastUserList()
+	msg := next(alice)
+	var delta UserListDelta
+	if err := json.Unmarshal(msg.Data, &delta); err != nil || msg.Type != "userlist_delta" {
+		t.Fatalf("Expected a delta, got %s: %v", msg.Type, err)
+	}
+	if delta.Seq != 2 || !reflect.DeepEqual(delta.Joined, []string{"carol"}) {
+		t.Errorf("Expected carol joining as change 2, got %+v", delta)
+	}
+	if msg := next(legacy); msg.Type != "userlist" {
+		t.Errorf("Clients without deltas should get full lists, got %s", msg.Type)
+	}
+	next(carol)
+
+	// Nothing changed: no delta, but a resync sends the full list
+	hub.broadcastUserList()
+	if len(alice.send) != 0 {
+		t.Error("Expected no delta when nothing changed")
+	}
+	hub.sendUserList(true)
+	var full UserList
+	if msg := next(alice); msg.Type != "userlist" || json.Unmarshal(msg.Data, &full) != nil || full.Seq != 2 || len(full.Users) != 3 {
+		t.Errorf("Expected the full list at change 2 on resync, got %s %+v", msg.Type, full)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// maxUsernameLength is the longest username any policy allows
+const maxUsernameLength = 32
+
+// DefaultReservedUsernames read as the server or its staff, so only a
+// configured admin may take them
+var DefaultReservedUsernames = []string{"system", "admin", "administrator", "moderator", "root", "server", "marchat", "welcomebot", "here", "admins"}
+
+// UsernamePolicy is what the server accepts as a username at handshake. It
+// only ever narrows the safe set validateUsername allows.
+type UsernamePolicy struct {
+	MinLength int
+	MaxLength int
+	// Pattern further restricts usernames (nil = no restriction)
+	Pattern *regexp.Regexp
+	// Reserved names are matched case-insensitively; admins are exempt
+	Reserved []string
+	// Blocked words may not appear anywhere in a username
+	BlockedWords []string
+}
+
+// DefaultUsernamePolicy allows any safe name that isn't reserved
+func DefaultUsernamePolicy() UsernamePolicy {
+	return UsernamePolicy{MinLength: 1, MaxLength: maxUsernameLength, Reserved: DefaultReservedUsernames}
+}
+
+// Check returns why username is refused, or nil if it is allowed. admin is
+// whether the name is one of the server's configured admins.
+func (p UsernamePolicy) Check(username string, admin bool) *shared.UsernameRejection {
+	minLen := max(p.MinLength, 1)
+	maxLen := p.MaxLength
+	if maxLen <= 0 || maxLen > maxUsernameLength {
+		maxLen = maxUsernameLength
+	}
+	reject := func(code, reason string) *shared.UsernameRejection {
+		return &shared.UsernameRejection{Code: code, Reason: reason, MinLength: minLen, MaxLength: maxLen}
+	}
+
+	switch {
+	case username == "":
+		return reject(shared.UsernameEmpty, "username cannot be empty")
+	case len(username) < minLen:
+		return reject(shared.UsernameTooShort, fmt.Sprintf("username too short (min %d characters)", minLen))
+	case len(username) > maxLen:
+		return reject(shared.UsernameTooLong, fmt.Sprintf("username too long (max %d characters)", maxLen))
+	}
+	if err := validateUsername(username); err != nil {
+		return reject(shared.UsernameInvalidChars, err.Error())
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(username) {
+		return reject(shared.UsernamePattern, "username does not follow this server's naming rules")
+	}
+	lu := strings.ToLower(username)
+	if !admin && slices.ContainsFunc(p.Reserved, func(name string) bool { return strings.EqualFold(name, lu) }) {
+		return reject(shared.UsernameReserved, fmt.Sprintf("username %q is reserved", username))
+	}
+	// Don't echo the word back: the list is the operator's business
+	for _, word := range p.BlockedWords {
+		if word != "" && strings.Contains(lu, strings.ToLower(word)) {
+			return reject(shared.UsernameBlocked, "username contains a word that is not allowed")
+		}
+	}
+	return nil
+}
+
+// SetUsernamePolicy sets the usernames accepted at handshake
+func (h *Hub) SetUsernamePolicy(p UsernamePolicy) {
+	h.usernamePolicy = &p
+}
+
+// UsernamePolicy returns the usernames accepted at handshake
+func (h *Hub) UsernamePolicy() UsernamePolicy {
+	if h.usernamePolicy == nil {
+		return DefaultUsernamePolicy()
+	}
+	return *h.usernamePolicy
+}
+
+// usernameRejectedMessage builds the "username_rejected" WebSocket message
+// a client renders before the connection closes
+func usernameRejectedMessage(r *shared.UsernameRejection) WSMessage {
+	payload, _ := json.Marshal(r)
+	return WSMessage{Type: "username_rejected", Data: payload}
+}
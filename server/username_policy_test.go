@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestUsernamePolicyCheck(t *testing.T) {
+	policy := DefaultUsernamePolicy()
+	policy.MinLength = 3
+	policy.MaxLength = 12
+	policy.Pattern = regexp.MustCompile(`^[a-z]`)
+	policy.BlockedWords = []string{"Darn"}
+
+	tests := []struct {
+		username string
+		admin    bool
+		want     string
+	}{
+		{"alice", false, ""},
+		{"", false, shared.UsernameEmpty},
+		{"al", false, shared.UsernameTooShort},
+		{"alice_the_great", false, shared.UsernameTooLong},
+		{"al ice", false, shared.UsernameInvalidChars},
+		{"al..ice", false, shared.UsernameInvalidChars},
+		{"Alice", false, shared.UsernamePattern},
+		{"system", false, shared.UsernameReserved},
+		{"admin", false, shared.UsernameReserved},
+		{"admin", true, ""},
+		{"xdarnx", false, shared.UsernameBlocked},
+	}
+	for _, tt := range tests {
+		got := policy.Check(tt.username, tt.admin)
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("Check(%q) refused: %v", tt.username, got)
+		case tt.want != "" && (got == nil || got.Code != tt.want):
+			t.Errorf("Check(%q) = %v, want code %s", tt.username, got, tt.want)
+		}
+	}
+
+	// Bounds are reported so clients can explain them
+	if got := policy.Check("al", false); got.MinLength != 3 || got.MaxLength != 12 {
+		t.Errorf("Expected bounds 3-12, got %d-%d", got.MinLength, got.MaxLength)
+	}
+	// A zero policy still refuses unsafe names
+	if got := (UsernamePolicy{}).Check(strings.Repeat("a", 40), false); got == nil || got.Code != shared.UsernameTooLong {
+		t.Errorf("Expected the hard length cap, got %v", got)
+	}
+}
+
+func TestHandshakeRejectsReservedUsername(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	ts := httptest.NewServer(ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(shared.Handshake{Username: "System"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg WSMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Expected a rejection message: %v", err)
+	}
+	var rejection shared.UsernameRejection
+	if err := json.Unmarshal(msg.Data, &rejection); err != nil || msg.Type != "username_rejected" {
+		t.Fatalf("Unexpected message %s: %v", msg.Type, err)
+	}
+	if rejection.Code != shared.UsernameReserved {
+		t.Errorf("Expected code %s, got %+v", shared.UsernameReserved, rejection)
+	}
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("Expected the connection to close")
+	}
+}
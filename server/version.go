@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// SetMinClientVersion sets the oldest client version this server supports.
+// Older clients may still connect; they are warned and logged.
+func (h *Hub) SetMinClientVersion(version string) {
+	h.minClientVersion = version
+}
+
+// RequireMinClientVersion refuses clients older than the minimum version
+// instead of only warning them
+func (h *Hub) RequireMinClientVersion(require bool) {
+	h.requireMinClientVersion = require
+}
+
+// refusesClientVersion reports whether a client of this version must be
+// turned away. Clients that do not send a version are let in.
+func (h *Hub) refusesClientVersion(clientVersion string) bool {
+	return h.requireMinClientVersion &&
+		shared.CheckVersionCompat(clientVersion, shared.ServerVersion, h.minClientVersion) == shared.VersionClientTooOld
+}
+
+// versionMessage tells the client which server it reached, so it can show
+// a banner when the versions do not fit together, and what time the server
+// makes it, answering the clientTime its handshake was sent at
+func (h *Hub) versionMessage(clientTime time.Time) WSMessage {
+	payload, _ := json.Marshal(shared.VersionInfo{
+		ServerVersion:    shared.ServerVersion,
+		MinClientVersion: h.minClientVersion,
+		ServerTime:       time.Now(),
+		ClientTime:       clientTime,
+	})
+	return WSMessage{Type: "version", Data: payload}
+}
+
+// warnClientVersion logs clients whose version does not fit this server.
+// Clients that do not send a version predate the check and are not logged.
+func (h *Hub) warnClientVersion(username, clientVersion string) {
+	var reason string
+	switch shared.CheckVersionCompat(clientVersion, shared.ServerVersion, h.minClientVersion) {
+	case shared.VersionMajorMismatch:
+		reason = "major version differs from the server"
+	case shared.VersionClientTooOld:
+		reason = "older than the minimum supported client version"
+	default:
+		return
+	}
+	ServerLogger.Warn("Incompatible client version", map[string]interface{}{
+		"user":               username,
+		"client_version":     clientVersion,
+		"server_version":     shared.ServerVersion,
+		"min_client_version": h.minClientVersion,
+		"reason":             reason,
+	})
+}
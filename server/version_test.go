@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+func TestVersionMessage(t *testing.T) {
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", nil)
+	hub.SetMinClientVersion("v0.9.0")
+
+	sent := time.Now().Add(-time.Second)
+	msg := hub.versionMessage(sent)
+	if msg.Type != "version" {
+		t.Fatalf("Expected a version message, got %q", msg.Type)
+	}
+	var info shared.VersionInfo
+	if err := json.Unmarshal(msg.Data, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.ServerVersion != shared.ServerVersion || info.MinClientVersion != "v0.9.0" {
+		t.Errorf("Unexpected version info: %+v", info)
+	}
+	if !info.ClientTime.Equal(sent) || info.ServerTime.Before(sent) {
+		t.Errorf("Expected the handshake time echoed with the server's clock, got %+v", info)
+	}
+}
+
+func TestWarnClientVersion(t *testing.T) {
+	orig := shared.ServerVersion
+	shared.ServerVersion = "v1.2.0"
+	defer func() { shared.ServerVersion = orig }()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", nil)
+	hub.SetMinClientVersion("v1.1.0")
+
+	hub.warnClientVersion("alice", "v1.0.5")
+	entry := GetLogBuffer().GetRecentEntries(1)[0]
+	if entry.Message != "Incompatible client version" || entry.Data["user"] != "alice" {
+		t.Errorf("Expected an old client to be logged, got %+v", entry)
+	}
+
+	hub.warnClientVersion("bob", "v1.1.0")
+	if entry := GetLogBuffer().GetRecentEntries(1)[0]; entry.Data["user"] == "bob" {
+		t.Errorf("Expected a supported client not to be logged, got %+v", entry)
+	}
+}
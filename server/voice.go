@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// maxVoiceNoteLength caps how long a voice note may play
+const maxVoiceNoteLength = 5 * time.Minute
+
+// shareVoiceNote sends a voice note to everyone once it checks out as Ogg
+// Opus within the file size limit. The server measures the length and
+// waveform itself rather than trusting the sender's, and names the file.
+func (c *Client) shareVoiceNote(msg shared.Message) {
+	if msg.Audio == nil {
+		return
+	}
+	maxBytes := c.maxFileBytes
+	if maxBytes <= 0 {
+		maxBytes = 1024 * 1024
+	}
+	if int64(len(msg.Audio.Data)) > maxBytes {
+		c.reply(fmt.Sprintf("Voice note too large (max %d KB). Try a shorter one.", maxBytes/1024))
+		return
+	}
+	duration, waveform, err := shared.ParseOggOpus(msg.Audio.Data)
+	if err != nil {
+		c.reply("Voice note not sent: it is not Ogg Opus audio.")
+		return
+	}
+	if duration > maxVoiceNoteLength {
+		c.reply(fmt.Sprintf("Voice note too long (max %s).", maxVoiceNoteLength))
+		return
+	}
+	now := time.Now()
+	note := shared.Message{
+		Sender:    c.username,
+		CreatedAt: now,
+		Type:      shared.AudioMessageType,
+		Audio: &shared.AudioMeta{
+			Filename:   fmt.Sprintf("voice-%s-%s.opus", c.username, now.Format("20060102-150405")),
+			DurationMs: duration.Milliseconds(),
+			Waveform:   waveform,
+			Data:       msg.Audio.Data,
+		},
+	}
+	log.Printf("Voice note from %s (%s, %d bytes)", c.username, duration.Round(time.Second), len(msg.Audio.Data))
+	c.hub.usage.message(roomChannel, note)
+	c.hub.broadcast <- note
+}
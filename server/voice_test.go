@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// oggOpusNote builds a minimal Ogg Opus stream of packets audio packets,
+// all on one page, that plays for the given length
+func oggOpusNote(packets int, length time.Duration) []byte {
+	page := func(granule int64, segments ...[]byte) []byte {
+		header := make([]byte, 27)
+		copy(header, "OggS")
+		binary.LittleEndian.PutUint64(header[6:], uint64(granule))
+		header[26] = byte(len(segments))
+		var body []byte
+		for _, s := range segments {
+			header = append(header, byte(len(s)))
+			body = append(body, s...)
+		}
+		return append(header, body...)
+	}
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	data := append(page(0, head), page(0, []byte("OpusTags"))...)
+	var audio [][]byte
+	for i := 0; i < packets; i++ {
+		audio = append(audio, make([]byte, 10+i%50))
+	}
+	return append(data, page(int64(length*48000/time.Second), audio...)...)
+}
+
+func TestShareVoiceNote(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+	go hub.Run()
+
+	alice := &Client{hub: hub, db: NewDatabaseWrapper(db), username: "alice", send: make(chan interface{}, 16), maxFileBytes: 4096}
+	hub.register <- alice
+
+	// The sender's length, waveform and name are replaced with the server's
+	alice.shareVoiceNote(shared.Message{Sender: "mallory", Type: shared.AudioMessageType, Audio: &shared.AudioMeta{
+		Filename: "../../evil.opus", DurationMs: 999999, Waveform: []uint8{9, 9}, Data: oggOpusNote(100, 2*time.Second),
+	}})
+	msg := nextTextMessage(t, alice)
+	if msg.Type != shared.AudioMessageType || msg.Sender != "alice" || msg.Audio == nil {
+		t.Fatalf("Unexpected voice note broadcast: %+v", msg)
+	}
+	if msg.Audio.Duration() != 2*time.Second || len(msg.Audio.Waveform) != shared.WaveformBars {
+		t.Errorf("Expected a measured 2s note with a waveform, got %v and %v", msg.Audio.Duration(), msg.Audio.Waveform)
+	}
+	if !strings.HasPrefix(msg.Audio.Filename, "voice-alice-") || strings.Contains(msg.Audio.Filename, "/") {
+		t.Errorf("Unexpected voice note name %q", msg.Audio.Filename)
+	}
+	if history := db.GetRecentMessages(); len(history) != 0 {
+		t.Errorf("Voice notes should not be stored in history, got %d messages", len(history))
+	}
+
+	refusals := map[string]*shared.AudioMeta{
+		"not Ogg Opus": {Data: []byte("RIFF....WAVE")},
+		"too large":    {Data: append(oggOpusNote(10, time.Second), make([]byte, 4096)...)},
+		"too long":     {Data: oggOpusNote(10, maxVoiceNoteLength+time.Second)},
+	}
+	for name, audio := range refusals {
+		alice.shareVoiceNote(shared.Message{Type: shared.AudioMessageType, Audio: audio})
+		if msg := nextTextMessage(t, alice); msg.Sender != "System" || !strings.Contains(msg.Content, "Voice note") {
+			t.Errorf("%s: expected a refusal, got %+v", name, msg)
+		}
+	}
+}
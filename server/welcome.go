@@ -0,0 +1,131 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// welcomeBotName is the sender of the greeting new users receive; it is
+// reserved so nobody can pose as the bot
+const welcomeBotName = "WelcomeBot"
+
+// defaultWelcomeMessage greets new users when the admins enable the bot
+// without writing a message of their own
+const defaultWelcomeMessage = "Welcome to the chat, {user}!"
+
+// maxWelcomeText is the longest welcome message or rules text accepted
+const maxWelcomeText = 4000
+
+// welcomeBot caches the welcome bot's settings from the database
+type welcomeBot struct {
+	mu  sync.RWMutex
+	cfg WelcomeConfig
+}
+
+// ReloadWelcome reads the welcome bot's settings from the database
+func (h *Hub) ReloadWelcome() {
+	if h.db == nil {
+		return
+	}
+	cfg, err := h.db.GetWelcomeConfig()
+	if err != nil {
+		log.Printf("Warning: failed to load welcome bot settings: %v", err)
+		return
+	}
+	h.welcome.mu.Lock()
+	h.welcome.cfg = cfg
+	h.welcome.mu.Unlock()
+}
+
+// WelcomeConfig returns the welcome bot's current settings
+func (h *Hub) WelcomeConfig() WelcomeConfig {
+	h.welcome.mu.RLock()
+	defer h.welcome.mu.RUnlock()
+	return h.welcome.cfg
+}
+
+// SetWelcomeConfig validates, stores and immediately applies the welcome
+// bot's settings
+func (h *Hub) SetWelcomeConfig(enabled bool, message, rules, adminUsername string) (WelcomeConfig, error) {
+	if h.db == nil {
+		return WelcomeConfig{}, fmt.Errorf("the welcome bot requires a database")
+	}
+	cfg := WelcomeConfig{
+		Enabled:   enabled,
+		Message:   strings.TrimSpace(message),
+		Rules:     strings.TrimSpace(rules),
+		UpdatedBy: adminUsername,
+		UpdatedAt: time.Now(),
+	}
+	if len(cfg.Message) > maxWelcomeText || len(cfg.Rules) > maxWelcomeText {
+		return WelcomeConfig{}, fmt.Errorf("welcome message and rules are limited to %d characters each", maxWelcomeText)
+	}
+	if err := h.db.SaveWelcomeConfig(cfg); err != nil {
+		return WelcomeConfig{}, err
+	}
+	h.welcome.mu.Lock()
+	h.welcome.cfg = cfg
+	h.welcome.mu.Unlock()
+	AdminLogger.Info("Welcome bot updated", map[string]interface{}{
+		"admin":   adminUsername,
+		"enabled": enabled,
+	})
+	return cfg, nil
+}
+
+// welcomeNewUser greets a client's user the first time they join. Users who
+// already have history from before the bot was enabled are recorded as
+// greeted without being sent anything.
+func (h *Hub) welcomeNewUser(client *Client, returning bool) {
+	cfg := h.WelcomeConfig()
+	if !cfg.Enabled || h.db == nil || client.readOnly {
+		return
+	}
+	first, err := h.db.MarkWelcomed(strings.ToLower(client.username))
+	if err != nil {
+		log.Printf("Warning: failed to record welcome for %s: %v", client.username, err)
+		return
+	}
+	if !first || returning {
+		return
+	}
+	client.enqueue(shared.Message{
+		Sender:    welcomeBotName,
+		Content:   welcomeText(cfg, client.username, client.availableCommands()),
+		CreatedAt: time.Now(),
+		Type:      shared.TextMessage,
+	})
+}
+
+// welcomeText builds the greeting: the admins' message, the server rules
+// and the names of the commands the user can run
+func welcomeText(cfg WelcomeConfig, username string, commands []shared.CommandInfo) string {
+	message := cfg.Message
+	if message == "" {
+		message = defaultWelcomeMessage
+	}
+	var b strings.Builder
+	b.WriteString(strings.ReplaceAll(message, "{user}", username))
+	if cfg.Rules != "" {
+		b.WriteString("\n\nServer rules:\n")
+		b.WriteString(cfg.Rules)
+	}
+	var names []string
+	for _, cmd := range commands {
+		if !slices.Contains(names, cmd.Name) {
+			names = append(names, cmd.Name)
+		}
+	}
+	if len(names) > 0 {
+		b.WriteString("\n\nCommands you can run: ")
+		b.WriteString(strings.Join(names, ", "))
+		b.WriteString("\nType :help for details.")
+	}
+	return b.String()
+}
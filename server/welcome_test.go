@@ -0,0 +1,139 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWelcomeNewUser(t *testing.T) {
+	db := CreateTestDatabase(t)
+	defer db.Close()
+	hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+
+	newClient := func(username string) *Client {
+		return &Client{hub: hub, username: username, send: make(chan interface{}, 4)}
+	}
+	received := func(c *Client) (shared.Message, bool) {
+		select {
+		case v := <-c.send:
+			msg, ok := v.(shared.Message)
+			return msg, ok
+		default:
+			return shared.Message{}, false
+		}
+	}
+
+	// Nobody is greeted until an admin enables the bot
+	hub.welcomeNewUser(newClient("alice"), false)
+	if first, _ := db.MarkWelcomed("alice"); !first {
+		t.Error("A disabled bot should not record anyone as greeted")
+	}
+
+	if _, err := hub.SetWelcomeConfig(true, "Hi {user}, glad you're here", "1. Be kind\n2. No spam", "admin"); err != nil {
+		t.Fatalf("SetWelcomeConfig failed: %v", err)
+	}
+	bob := newClient("Bob")
+	hub.welcomeNewUser(bob, false)
+	msg, ok := received(bob)
+	if !ok || msg.Sender != welcomeBotName {
+		t.Fatalf("Expected a greeting from %s, got %+v", welcomeBotName, msg)
+	}
+	for _, want := range []string{"Hi Bob, glad you're here", "Server rules:\n1. Be kind", ":help", ":nick"} {
+		if !strings.Contains(msg.Content, want) {
+			t.Errorf("Greeting should contain %q, got %q", want, msg.Content)
+		}
+	}
+	if strings.Contains(msg.Content, ":ban") {
+		t.Error("Users should not be told about admin commands")
+	}
+
+	// Later joins, on any session, are not greeted again
+	hub.welcomeNewUser(newClient("bob"), false)
+	if _, ok := received(bob); ok {
+		t.Error("Users should only be greeted once")
+	}
+
+	// Users who chatted before the bot was enabled are skipped for good
+	carol := newClient("carol")
+	hub.welcomeNewUser(carol, true)
+	hub.welcomeNewUser(carol, false)
+	if _, ok := received(carol); ok {
+		t.Error("Returning users should not be greeted")
+	}
+
+	// Spectators aren't greeted or recorded
+	spectator := newClient("dave")
+	spectator.readOnly = true
+	hub.welcomeNewUser(spectator, false)
+	if _, ok := received(spectator); ok {
+		t.Error("Spectators should not be greeted")
+	}
+
+	// Settings survive a reload from the database
+	hub.welcome = &welcomeBot{}
+	hub.ReloadWelcome()
+	if cfg := hub.WelcomeConfig(); !cfg.Enabled || cfg.UpdatedBy != "admin" || cfg.Rules != "1. Be kind\n2. No spam" {
+		t.Errorf("Unexpected reloaded config %+v", cfg)
+	}
+
+	if _, err := hub.SetWelcomeConfig(true, strings.Repeat("a", maxWelcomeText+1), "", "admin"); err == nil {
+		t.Error("Overlong welcome messages should be rejected")
+	}
+}
+
+func TestWelcomeTextDefaults(t *testing.T) {
+	text := welcomeText(WelcomeConfig{Enabled: true}, "erin", nil)
+	if text != "Welcome to the chat, erin!" {
+		t.Errorf("Expected the default greeting alone, got %q", text)
+	}
+}
+
+func TestAdminPanel_WelcomeEditor(t *testing.T) {
+	panel, cleanup := setupPanelEnv(t)
+	defer cleanup()
+
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		panel.Update(msg)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	panel.activeTab = tabSystem
+	press(runes("W"))
+	if !panel.hub.WelcomeConfig().Enabled {
+		t.Fatal("Expected W to enable the welcome bot")
+	}
+
+	press(runes("w"))
+	if panel.welcomeEdit == nil {
+		t.Fatal("Expected w to open the welcome editor")
+	}
+	press(runes("Hello {user}"))
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(runes("Be nice"))
+	if !strings.Contains(panel.renderWelcome(), "ctrl+s") {
+		t.Error("Expected the editor to replace the welcome bot summary")
+	}
+	// Keys the panel would otherwise act on go to the editor
+	press(runes("c"))
+	if panel.confirm != nil {
+		t.Error("Typing in the editor should not trigger panel actions")
+	}
+	press(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if panel.welcomeEdit != nil {
+		t.Fatal("Expected ctrl+s to close the editor")
+	}
+	if cfg := panel.hub.WelcomeConfig(); !cfg.Enabled || cfg.Message != "Hello {user}" || cfg.Rules != "Be nicec" {
+		t.Errorf("Unexpected saved config %+v", cfg)
+	}
+
+	press(runes("w"))
+	press(runes("discarded"))
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if panel.welcomeEdit != nil || panel.hub.WelcomeConfig().Message != "Hello {user}" {
+		t.Error("Expected esc to close the editor without saving")
+	}
+}
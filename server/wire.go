@@ -0,0 +1,33 @@
+package server
+
+import (
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+// readFrame reads one frame into v, in the wire format negotiated on conn,
+// and returns the frame's payload size
+func readFrame(conn *websocket.Conn, v interface{}) (int, error) {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return 0, err
+	}
+	return len(data), shared.CodecFor(conn.Subprotocol()).Unmarshal(data, v)
+}
+
+// writeFrame sends v in the wire format negotiated on conn, compressed when
+// it is at least compressMin bytes (0 = never) and compression was agreed.
+// It returns the payload size before compression.
+func writeFrame(conn *websocket.Conn, v interface{}, compressMin int) (int, error) {
+	codec := shared.CodecFor(conn.Subprotocol())
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	frameType := websocket.TextMessage
+	if codec.Binary() {
+		frameType = websocket.BinaryMessage
+	}
+	conn.EnableWriteCompression(compressMin > 0 && len(data) >= compressMin)
+	return len(data), conn.WriteMessage(frameType, data)
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+	"github.com/gorilla/websocket"
+)
+
+func TestWireFormatNegotiation(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		offer []string
+		want  string
+	}{
+		{"cbor", []string{shared.SubprotocolCBOR}, shared.SubprotocolCBOR},
+		{"older client", nil, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db := CreateTestDatabase(t)
+			defer db.Close()
+			hub := NewHub(t.TempDir(), t.TempDir(), "http://localhost:8080", db)
+			go hub.Run()
+
+			ts := httptest.NewServer(ServeWs(hub, db, nil, "key", false, 1024*1024, ""))
+			defer ts.Close()
+			dialer := websocket.Dialer{Subprotocols: tt.offer}
+			conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer conn.Close()
+			if conn.Subprotocol() != tt.want {
+				t.Fatalf("Expected subprotocol %q, got %q", tt.want, conn.Subprotocol())
+			}
+
+			codec := shared.CodecFor(conn.Subprotocol())
+			hs, _ := codec.Marshal(shared.Handshake{Username: "alice"})
+			frameType := websocket.TextMessage
+			if codec.Binary() {
+				frameType = websocket.BinaryMessage
+			}
+			if err := conn.WriteMessage(frameType, hs); err != nil {
+				t.Fatal(err)
+			}
+
+			// The user list arrives in the negotiated format
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			for {
+				gotType, raw, err := conn.ReadMessage()
+				if err != nil {
+					t.Fatalf("Expected the user list: %v", err)
+				}
+				if gotType != frameType {
+					t.Fatalf("Expected frame type %d, got %d", frameType, gotType)
+				}
+				var ws WSMessage
+				if err := codec.Unmarshal(raw, &ws); err != nil || ws.Type != "userlist" {
+					continue
+				}
+				var ul UserList
+				if err := json.Unmarshal(ws.Data, &ul); err != nil {
+					t.Fatal(err)
+				}
+				if len(ul.Users) != 1 || ul.Users[0] != "alice" {
+					t.Errorf("Expected alice in the user list, got %v", ul.Users)
+				}
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package server
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// defaultWriteBatchInterval is how often queued messages are written when
+// no interval is configured
+const defaultWriteBatchInterval = 100 * time.Millisecond
+
+// messageBatcher is implemented by backends that can store several
+// messages at once, in one transaction or save
+type messageBatcher interface {
+	InsertMessages(msgs []shared.Message) error
+}
+
+// insertSQLMessages is InsertMessages for the SQL backends: insertRow adds
+// one row and returns its id, setMessageID copies the id into message_id,
+// and the message cap is enforced once for the whole batch
+func insertSQLMessages(db *sql.DB, msgs []shared.Message, insertRow func(*sql.Tx, shared.Message) (int64, error), setMessageID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		id, err := insertRow(tx, msg)
+		if err == nil {
+			_, err = tx.Exec(setMessageID, id, id)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	// Enforce message cap: keep only the most recent 1000 messages
+	if _, err := tx.Exec(`DELETE FROM messages WHERE id NOT IN (SELECT id FROM messages ORDER BY id DESC LIMIT 1000)`); err != nil {
+		log.Printf("Error enforcing message cap: %v", err)
+	}
+	return tx.Commit()
+}
+
+// WriteBehindStats reports how batched message writes are doing, for the
+// health endpoint
+type WriteBehindStats struct {
+	Pending     int     `json:"pending"`       // queued and not yet written
+	Flushes     int64   `json:"flushes"`       // batches written
+	Messages    int64   `json:"messages"`      // messages written in them
+	Failures    int64   `json:"failures"`      // batches that failed and were retried
+	LastFlushMs float64 `json:"last_flush_ms"` // how long the last batch took
+	AvgFlushMs  float64 `json:"avg_flush_ms"`
+	MaxFlushMs  float64 `json:"max_flush_ms"`
+}
+
+// writeBehindDatabase queues chat messages and writes them in batches:
+// once maxBatch are waiting, or every interval. Each queued message is
+// appended to a journal file first and replayed on the next start, so a
+// crash loses nothing the journal holds. Reads of messages write out the
+// queue first, so they always see every message.
+type writeBehindDatabase struct {
+	Database
+	maxBatch int
+	interval time.Duration
+
+	mu      sync.Mutex // guards pending and journal
+	pending []shared.Message
+	journal *os.File
+
+	flushMu sync.Mutex // one batch at a time, in order
+	kick    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+
+	statsMu   sync.Mutex
+	stats     WriteBehindStats
+	flushTime time.Duration // total, for the average
+}
+
+// newWriteBehindDatabase wraps db, replaying any messages a previous run
+// left in the journal at journalPath before accepting new ones
+func newWriteBehindDatabase(db Database, maxBatch int, interval time.Duration, journalPath string) (*writeBehindDatabase, error) {
+	if interval <= 0 {
+		interval = defaultWriteBatchInterval
+	}
+	journal, err := os.OpenFile(journalPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write journal: %w", err)
+	}
+	w := &writeBehindDatabase{
+		Database: db,
+		maxBatch: max(maxBatch, 1),
+		interval: interval,
+		journal:  journal,
+		kick:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if err := w.replay(); err != nil {
+		journal.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// replay writes out messages journaled by a previous run, skipping any
+// that reached the database before it stopped. Numbered messages are
+// matched by Seq; others by sender, content and time to the second, since
+// MySQL DATETIME drops fractions of a second.
+func (w *writeBehindDatabase) replay() error {
+	var queued []shared.Message
+	scanner := bufio.NewScanner(w.journal)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg shared.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			// A line cut short by the crash
+			log.Printf("Skipping unreadable write journal entry: %v", err)
+			continue
+		}
+		queued = append(queued, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read write journal: %w", err)
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+	stored := w.Database.GetMessagesSince(queued[0].CreatedAt.Add(-time.Second))
+	storedSeqs := make(map[int64]bool, len(stored))
+	for _, s := range stored {
+		if s.Seq != 0 {
+			storedSeqs[s.Seq] = true
+		}
+	}
+	var missing []shared.Message
+	for _, msg := range queued {
+		if !alreadyStored(msg, stored, storedSeqs) {
+			missing = append(missing, msg)
+		}
+	}
+	if len(missing) > 0 {
+		if err := w.insertBatch(missing); err != nil {
+			return fmt.Errorf("failed to replay write journal: %w", err)
+		}
+		log.Printf("Recovered %d messages from the write journal", len(missing))
+	}
+	if err := w.journal.Truncate(0); err != nil {
+		return err
+	}
+	return w.journal.Sync()
+}
+
+// alreadyStored reports whether a journaled message is among those read
+// back from the database
+func alreadyStored(msg shared.Message, stored []shared.Message, storedSeqs map[int64]bool) bool {
+	if msg.Seq != 0 {
+		return storedSeqs[msg.Seq]
+	}
+	at := msg.CreatedAt.Truncate(time.Second)
+	for _, s := range stored {
+		if s.Sender == msg.Sender && s.Content == msg.Content && s.CreatedAt.Truncate(time.Second).Equal(at) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *writeBehindDatabase) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		case <-w.kick:
+		}
+		_ = w.Flush()
+	}
+}
+
+// InsertMessage journals msg, synced to disk so it survives a power loss,
+// and queues it for the next batch
+func (w *writeBehindDatabase) InsertMessage(msg shared.Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	if _, err := w.journal.Write(append(line, '\n')); err != nil {
+		w.mu.Unlock()
+		return fmt.Errorf("failed to journal message: %w", err)
+	}
+	if err := w.journal.Sync(); err != nil {
+		w.mu.Unlock()
+		return fmt.Errorf("failed to sync write journal: %w", err)
+	}
+	w.pending = append(w.pending, msg)
+	full := len(w.pending) >= w.maxBatch
+	w.mu.Unlock()
+	if full {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush writes out every queued message as one batch. On failure the
+// messages stay queued and journaled for the next try.
+func (w *writeBehindDatabase) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	batch := w.pending[:len(w.pending):len(w.pending)]
+	w.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	err := w.insertBatch(batch)
+	took := time.Since(start)
+	w.record(len(batch), took, err)
+	if err != nil {
+		log.Printf("Failed to write %d messages, will retry: %v", len(batch), err)
+		return err
+	}
+
+	// Keep only what was queued meanwhile, in the queue and the journal
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append([]shared.Message(nil), w.pending[len(batch):]...)
+	if err := w.journal.Truncate(0); err != nil {
+		return err
+	}
+	for _, msg := range w.pending {
+		line, _ := json.Marshal(msg)
+		if _, err := w.journal.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return w.journal.Sync()
+}
+
+func (w *writeBehindDatabase) insertBatch(batch []shared.Message) error {
+	if b, ok := w.Database.(messageBatcher); ok {
+		return b.InsertMessages(batch)
+	}
+	for _, msg := range batch {
+		if err := w.Database.InsertMessage(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writeBehindDatabase) record(n int, took time.Duration, err error) {
+	w.statsMu.Lock()
+	defer w.statsMu.Unlock()
+	ms := float64(took.Microseconds()) / 1000
+	w.stats.LastFlushMs = ms
+	if err != nil {
+		w.stats.Failures++
+		return
+	}
+	w.stats.Flushes++
+	w.stats.Messages += int64(n)
+	w.flushTime += took
+	w.stats.AvgFlushMs = float64(w.flushTime.Microseconds()) / 1000 / float64(w.stats.Flushes)
+	w.stats.MaxFlushMs = max(w.stats.MaxFlushMs, ms)
+}
+
+// WriteBehindStats returns the batching counters and flush latency
+func (w *writeBehindDatabase) WriteBehindStats() WriteBehindStats {
+	w.mu.Lock()
+	pending := len(w.pending)
+	w.mu.Unlock()
+	w.statsMu.Lock()
+	defer w.statsMu.Unlock()
+	stats := w.stats
+	stats.Pending = pending
+	return stats
+}
+
+// Close writes out the queue, removes the emptied journal and closes the
+// database
+func (w *writeBehindDatabase) Close() error {
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+		<-w.stopped
+	}
+	if err := w.Flush(); err != nil {
+		log.Printf("Messages left in the write journal for the next start: %v", err)
+		w.journal.Close()
+	} else {
+		w.journal.Close()
+		os.Remove(w.journal.Name())
+	}
+	return w.Database.Close()
+}
+
+// writeBehindStats returns the batching stats of db, when it batches
+func writeBehindStats(db Database) (WriteBehindStats, bool) {
+	switch d := db.(type) {
+	case *writeBehindDatabase:
+		return d.WriteBehindStats(), true
+	case *encryptedDatabase:
+		return writeBehindStats(d.Database)
+	case *DatabaseWrapper:
+		return writeBehindStats(d.db)
+	}
+	return WriteBehindStats{}, false
+}
+
+// Message reads and writes that must come after queued messages write the
+// queue out first
+
+func (w *writeBehindDatabase) InsertEncryptedMessage(msg *shared.EncryptedMessage) error {
+	_ = w.Flush()
+	return w.Database.InsertEncryptedMessage(msg)
+}
+
+func (w *writeBehindDatabase) GetRecentMessages() []shared.Message {
+	_ = w.Flush()
+	return w.Database.GetRecentMessages()
+}
+
+func (w *writeBehindDatabase) GetMessagesAfter(lastMessageID int64, limit int) []shared.Message {
+	_ = w.Flush()
+	return w.Database.GetMessagesAfter(lastMessageID, limit)
+}
+
+func (w *writeBehindDatabase) GetMessagesSince(since time.Time) []shared.Message {
+	_ = w.Flush()
+	return w.Database.GetMessagesSince(since)
+}
+
+func (w *writeBehindDatabase) GetRecentMessagesForUser(username string, defaultLimit int, banGapsHistory bool) ([]shared.Message, int64) {
+	_ = w.Flush()
+	return w.Database.GetRecentMessagesForUser(username, defaultLimit, banGapsHistory)
+}
+
+func (w *writeBehindDatabase) GetLatestMessageID() int64 {
+	_ = w.Flush()
+	return w.Database.GetLatestMessageID()
+}
+
+func (w *writeBehindDatabase) ClearMessages() error {
+	_ = w.Flush()
+	return w.Database.ClearMessages()
+}
+
+func (w *writeBehindDatabase) CountMessages() (int, error) {
+	_ = w.Flush()
+	return w.Database.CountMessages()
+}
+
+func (w *writeBehindDatabase) GetMessageCountsBySender() (map[string]int, error) {
+	_ = w.Flush()
+	return w.Database.GetMessageCountsBySender()
+}
+
+func (w *writeBehindDatabase) QuerySenders(q SenderQuery) ([]SenderStats, int, error) {
+	_ = w.Flush()
+	return w.Database.QuerySenders(q)
+}
+
+func (w *writeBehindDatabase) GetMessageActivity(since time.Time) ([]MessageActivity, error) {
+	_ = w.Flush()
+	return w.Database.GetMessageActivity(since)
+}
+
+func (w *writeBehindDatabase) GetKnownUsers() ([]string, error) {
+	_ = w.Flush()
+	return w.Database.GetKnownUsers()
+}
+
+func (w *writeBehindDatabase) BackupDatabase(dbPath string) (string, error) {
+	_ = w.Flush()
+	return w.Database.BackupDatabase(dbPath)
+}
+
+// RewriteMessageContent passes through to backends that support it, see
+// messageRewriter
+func (w *writeBehindDatabase) RewriteMessageContent(fn func(string) (string, bool)) (int, error) {
+	_ = w.Flush()
+	if rw, ok := w.Database.(messageRewriter); ok {
+		return rw.RewriteMessageContent(fn)
+	}
+	return 0, nil
+}
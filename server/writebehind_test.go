@@ -0,0 +1,164 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Cod-e-Codes/marchat/shared"
+)
+
+// newBatchTestDB opens a SQLite database in a file, so every connection
+// of the pool sees the same data
+func newBatchTestDB(t *testing.T) *SQLiteDB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	CreateSchema(db)
+	sqliteDB := NewSQLiteDB()
+	sqliteDB.db = db
+	t.Cleanup(func() { db.Close() })
+	return sqliteDB
+}
+
+func batchTestMessage(content string, at time.Time) shared.Message {
+	return shared.Message{Sender: "alice", Content: content, CreatedAt: at.UTC(), Type: shared.TextMessage}
+}
+
+func TestWriteBehindBatches(t *testing.T) {
+	inner := newBatchTestDB(t)
+	w, err := newWriteBehindDatabase(inner, 3, time.Hour, filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatalf("newWriteBehindDatabase failed: %v", err)
+	}
+	defer w.Close()
+
+	now := time.Now()
+	for i, content := range []string{"one", "two"} {
+		if err := w.InsertMessage(batchTestMessage(content, now.Add(time.Duration(i)*time.Millisecond))); err != nil {
+			t.Fatalf("InsertMessage failed: %v", err)
+		}
+	}
+	if n, _ := inner.CountMessages(); n != 0 {
+		t.Fatalf("Expected nothing written before the batch fills, got %d", n)
+	}
+	if stats := w.WriteBehindStats(); stats.Pending != 2 {
+		t.Errorf("Expected 2 pending messages, got %d", stats.Pending)
+	}
+
+	// The third message fills the batch
+	if err := w.InsertMessage(batchTestMessage("three", now.Add(2*time.Millisecond))); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for n, _ := inner.CountMessages(); n != 3; n, _ = inner.CountMessages() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the full batch written, got %d messages", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stats := w.WriteBehindStats()
+	if stats.Flushes != 1 || stats.Messages != 3 || stats.Pending != 0 || stats.Failures != 0 {
+		t.Errorf("Expected one flush of 3 messages, got %+v", stats)
+	}
+	if stats.LastFlushMs <= 0 || stats.MaxFlushMs < stats.AvgFlushMs {
+		t.Errorf("Expected flush latency recorded, got %+v", stats)
+	}
+
+	// Reads see queued messages without waiting for the batch
+	if err := w.InsertMessage(batchTestMessage("four", now.Add(3*time.Millisecond))); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+	recent := w.GetRecentMessages()
+	if len(recent) != 4 || recent[len(recent)-1].Content != "four" {
+		t.Errorf("Expected reads to include the queued message, got %d messages", len(recent))
+	}
+	var ids int
+	if err := inner.db.QueryRow(`SELECT COUNT(DISTINCT message_id) FROM messages WHERE message_id > 0`).Scan(&ids); err != nil || ids != 4 {
+		t.Errorf("Expected every batched message to get its own id, got %d: %v", ids, err)
+	}
+}
+
+func TestWriteBehindReplaysJournal(t *testing.T) {
+	inner := newBatchTestDB(t)
+	now := time.Now()
+	written := batchTestMessage("written", now)
+	lost := batchTestMessage("lost", now.Add(time.Millisecond))
+	if err := inner.InsertMessage(written); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+
+	// A crash after the first message was committed but before the journal
+	// was trimmed, while the last line was being written
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	var journal []byte
+	for _, msg := range []shared.Message{written, lost} {
+		line, _ := json.Marshal(msg)
+		journal = append(append(journal, line...), '\n')
+	}
+	journal = append(journal, `{"sender":"alice","cont`...)
+	if err := os.WriteFile(path, journal, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := newWriteBehindDatabase(inner, 10, time.Hour, path)
+	if err != nil {
+		t.Fatalf("newWriteBehindDatabase failed: %v", err)
+	}
+	counts, _ := inner.GetMessageCountsBySender()
+	if counts["alice"] != 2 {
+		t.Errorf("Expected the lost message replayed once, alice has %d messages", counts["alice"])
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("Expected the journal emptied after replay: %v", err)
+	}
+
+	// A clean shutdown writes the queue out and removes the journal
+	if err := w.InsertMessage(batchTestMessage("queued", now.Add(2*time.Millisecond))); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+	w.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the journal removed on close: %v", err)
+	}
+}
+
+func TestWriteBehindReplayMatchesSeq(t *testing.T) {
+	inner := newBatchTestDB(t)
+	now := time.Now()
+	written := batchTestMessage("same text", now.Truncate(time.Second).Add(345*time.Millisecond))
+	written.Seq = 7
+	lost := written
+	lost.Seq = 8
+	// Stored as MySQL DATETIME keeps it: without the fraction of a second
+	stored := written
+	stored.CreatedAt = written.CreatedAt.Truncate(time.Second)
+	if err := inner.InsertMessage(stored); err != nil {
+		t.Fatalf("InsertMessage failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	var journal []byte
+	for _, msg := range []shared.Message{written, lost} {
+		line, _ := json.Marshal(msg)
+		journal = append(append(journal, line...), '\n')
+	}
+	if err := os.WriteFile(path, journal, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := newWriteBehindDatabase(inner, 10, time.Hour, path)
+	if err != nil {
+		t.Fatalf("newWriteBehindDatabase failed: %v", err)
+	}
+	defer w.Close()
+	// The same sender and text at the same second is a different message
+	// when its Seq differs
+	if n, _ := inner.CountMessages(); n != 2 {
+		t.Errorf("Expected only seq 8 replayed, got %d messages", n)
+	}
+}
@@ -0,0 +1,71 @@
+package shared
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdminCommandSignature(t *testing.T) {
+	key := AdminSigningKey("secret")
+	msg := Message{Sender: "admin", Content: ":cleardb", Type: AdminCommandType}
+	if err := SignAdminCommand(key, &msg); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := VerifyAdminCommand(key, msg, now); err != nil {
+		t.Fatalf("Expected a valid signature, got %v", err)
+	}
+
+	tampered := msg
+	tampered.Content = ":ban alice"
+	if err := VerifyAdminCommand(key, tampered, now); !errors.Is(err, ErrAdminCommandSignature) {
+		t.Errorf("Expected a changed command to fail, got %v", err)
+	}
+	retyped := msg
+	retyped.Type = TextMessage
+	if err := VerifyAdminCommand(key, retyped, now); !errors.Is(err, ErrAdminCommandSignature) {
+		t.Errorf("Expected a changed message type to fail, got %v", err)
+	}
+	if err := VerifyAdminCommand(AdminSigningKey("other"), msg, now); !errors.Is(err, ErrAdminCommandSignature) {
+		t.Errorf("Expected another key to fail, got %v", err)
+	}
+	if err := VerifyAdminCommand(key, msg, now.Add(AdminCommandWindow+time.Second)); !errors.Is(err, ErrAdminCommandStale) {
+		t.Errorf("Expected an old command to be stale, got %v", err)
+	}
+	if err := VerifyAdminCommand(key, Message{Sender: "admin", Content: ":cleardb"}, now); !errors.Is(err, ErrAdminCommandUnsigned) {
+		t.Errorf("Expected an unsigned command to be rejected, got %v", err)
+	}
+
+	// An attached file is covered too
+	withFile := Message{Sender: "admin", Content: ":emoji add party", File: &FileMeta{Filename: "party.png", Data: []byte{1, 2}}}
+	if err := SignAdminCommand(key, &withFile); err != nil {
+		t.Fatal(err)
+	}
+	withFile.File = &FileMeta{Filename: "party.png", Data: []byte{3, 4}}
+	if err := VerifyAdminCommand(key, withFile, now); !errors.Is(err, ErrAdminCommandSignature) {
+		t.Errorf("Expected a swapped file to fail, got %v", err)
+	}
+}
+
+func TestAdminCommandSignatureSurvivesCBOR(t *testing.T) {
+	key := AdminSigningKey("secret")
+	msg := Message{Sender: "admin", Content: ":stats", Type: AdminCommandType}
+	if err := SignAdminCommand(key, &msg); err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []string{SubprotocolJSON, SubprotocolCBOR} {
+		codec := CodecFor(sub)
+		data, err := codec.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Message
+		if err := codec.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyAdminCommand(key, got, time.Now()); err != nil {
+			t.Errorf("%s: expected the signature to survive encoding, got %v", sub, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// AudioMeta is a voice note: Ogg Opus audio with its length and a coarse
+// waveform, so clients can show it without decoding anything
+type AudioMeta struct {
+	Filename   string  `json:"filename"`
+	DurationMs int64   `json:"duration_ms"`
+	Waveform   []uint8 `json:"waveform,omitempty"` // levels 0-7, see WaveformLevels
+	Data       []byte  `json:"data"`
+}
+
+// Duration returns how long the voice note plays
+func (a *AudioMeta) Duration() time.Duration {
+	return time.Duration(a.DurationMs) * time.Millisecond
+}
+
+// Waveform shape: at most WaveformBars bars, each 0 to WaveformLevels-1
+const (
+	WaveformBars   = 32
+	WaveformLevels = 8
+)
+
+// Opus always runs its granule position at 48 kHz
+const opusGranuleRate = 48000
+
+var ErrNotOggOpus = errors.New("not an Ogg Opus file")
+
+// ParseOggOpus reads the length of an Ogg Opus stream and sketches its
+// waveform from the size of each packet: Opus spends more bytes on loud,
+// busy audio and almost none on silence, which is enough for an indicator.
+func ParseOggOpus(data []byte) (time.Duration, []uint8, error) {
+	var (
+		serial    uint32
+		packet    []byte
+		packets   int
+		sizes     []int
+		preSkip   int64
+		lastGrain int64 = -1
+	)
+	for len(data) > 0 {
+		if len(data) < 27 || !bytes.Equal(data[:4], []byte("OggS")) || data[4] != 0 {
+			return 0, nil, ErrNotOggOpus
+		}
+		granule := int64(binary.LittleEndian.Uint64(data[6:14]))
+		pageSerial := binary.LittleEndian.Uint32(data[14:18])
+		segments := int(data[26])
+		if len(data) < 27+segments {
+			return 0, nil, ErrNotOggOpus
+		}
+		lacing := data[27 : 27+segments]
+		bodyLen := 0
+		for _, l := range lacing {
+			bodyLen += int(l)
+		}
+		body := data[27+segments:]
+		if len(body) < bodyLen {
+			return 0, nil, ErrNotOggOpus
+		}
+		body, data = body[:bodyLen], body[bodyLen:]
+		if packets == 0 && packet == nil {
+			serial = pageSerial
+		}
+		// Only the first logical stream is read
+		if pageSerial != serial {
+			continue
+		}
+		for _, l := range lacing {
+			packet = append(packet, body[:l]...)
+			body = body[l:]
+			if l == 255 {
+				continue
+			}
+			switch packets {
+			case 0:
+				if len(packet) < 19 || !bytes.HasPrefix(packet, []byte("OpusHead")) {
+					return 0, nil, ErrNotOggOpus
+				}
+				preSkip = int64(binary.LittleEndian.Uint16(packet[10:12]))
+			case 1:
+				if !bytes.HasPrefix(packet, []byte("OpusTags")) {
+					return 0, nil, ErrNotOggOpus
+				}
+			default:
+				sizes = append(sizes, len(packet))
+			}
+			packets++
+			packet = packet[:0]
+		}
+		if granule >= 0 && packets > 2 {
+			lastGrain = granule
+		}
+	}
+	if packets < 2 {
+		return 0, nil, ErrNotOggOpus
+	}
+	var duration time.Duration
+	if lastGrain > preSkip {
+		duration = time.Duration(lastGrain-preSkip) * time.Second / opusGranuleRate
+	}
+	return duration, waveform(sizes), nil
+}
+
+// waveform buckets packet sizes into at most WaveformBars levels, scaled
+// between the quietest and loudest bucket
+func waveform(sizes []int) []uint8 {
+	bars := min(len(sizes), WaveformBars)
+	if bars == 0 {
+		return nil
+	}
+	avg := make([]float64, bars)
+	lo, hi := -1.0, 0.0
+	for i := range avg {
+		from, to := i*len(sizes)/bars, (i+1)*len(sizes)/bars
+		sum := 0
+		for _, s := range sizes[from:to] {
+			sum += s
+		}
+		avg[i] = float64(sum) / float64(to-from)
+		if lo < 0 || avg[i] < lo {
+			lo = avg[i]
+		}
+		hi = max(hi, avg[i])
+	}
+	levels := make([]uint8, bars)
+	for i, a := range avg {
+		if hi == lo {
+			levels[i] = WaveformLevels / 2
+			continue
+		}
+		levels[i] = uint8((a - lo) / (hi - lo) * (WaveformLevels - 1))
+	}
+	return levels
+}
@@ -0,0 +1,89 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// oggPage frames packets as one Ogg page; a packet of 255 bytes or more
+// spans several lacing values
+func oggPage(serial uint32, granule int64, packets ...[]byte) []byte {
+	var lacing, body []byte
+	for _, p := range packets {
+		n := len(p)
+		for n >= 255 {
+			lacing = append(lacing, 255)
+			n -= 255
+		}
+		lacing = append(lacing, byte(n))
+		body = append(body, p...)
+	}
+	page := make([]byte, 27)
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint64(page[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:], serial)
+	page[26] = byte(len(lacing))
+	return append(append(page, lacing...), body...)
+}
+
+// testOggOpus builds a stream with one audio packet of each size, 20 ms
+// apiece, and 312 samples of pre-skip
+func testOggOpus(sizes ...int) []byte {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8], head[9] = 1, 1
+	binary.LittleEndian.PutUint16(head[10:], 312)
+	stream := append(oggPage(7, 0, head), oggPage(7, 0, []byte("OpusTags"))...)
+	var audio [][]byte
+	for _, n := range sizes {
+		audio = append(audio, bytes.Repeat([]byte{0xfc}, n))
+	}
+	granule := int64(312 + 960*len(sizes))
+	return append(stream, oggPage(7, granule, audio...)...)
+}
+
+func TestParseOggOpus(t *testing.T) {
+	sizes := make([]int, 100)
+	for i := range sizes {
+		sizes[i] = 3 // silence
+		if i >= 50 {
+			sizes[i] = 300 // speech, spanning two lacing values
+		}
+	}
+	duration, wave, err := ParseOggOpus(testOggOpus(sizes...))
+	if err != nil {
+		t.Fatalf("ParseOggOpus failed: %v", err)
+	}
+	if duration != 2*time.Second {
+		t.Errorf("duration = %v, want 2s", duration)
+	}
+	if len(wave) != WaveformBars {
+		t.Fatalf("waveform has %d bars, want %d", len(wave), WaveformBars)
+	}
+	if wave[0] != 0 || wave[len(wave)-1] != WaveformLevels-1 {
+		t.Errorf("waveform should rise from silence to speech: %v", wave)
+	}
+
+	// Short notes get one bar per packet
+	if _, wave, _ := ParseOggOpus(testOggOpus(10, 20, 30)); len(wave) != 3 || wave[0] != 0 || wave[2] != 7 {
+		t.Errorf("short waveform = %v", wave)
+	}
+}
+
+func TestParseOggOpusRejects(t *testing.T) {
+	valid := testOggOpus(10, 20)
+	vorbis := bytes.Replace(valid, []byte("OpusHead"), []byte("vorbisxx"), 1)
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"wav":       []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+		"truncated": valid[:len(valid)-5],
+		"vorbis":    vorbis,
+		"no tags":   oggPage(1, 0, append([]byte("OpusHead"), make([]byte, 11)...)),
+	} {
+		if _, _, err := ParseOggOpus(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Response headers announcing the join challenge on the WebSocket upgrade.
+// Servers without a challenge send neither, so clients only solve when asked.
+const (
+	ChallengeNonceHeader      = "X-Marchat-Challenge"
+	ChallengeBitsHeader       = "X-Marchat-Challenge-Bits"
+	ChallengePassphraseHeader = "X-Marchat-Passphrase"
+)
+
+// MaxChallengeBits bounds the proof-of-work difficulty so a misconfigured
+// server cannot make clients spin forever
+const MaxChallengeBits = 28
+
+// JoinChallenge is the per-connection challenge a public server may require
+// before accepting the handshake: a proof of work over Nonce with Bits
+// leading zero bits, a shared passphrase, or both.
+type JoinChallenge struct {
+	Nonce      string
+	Bits       int
+	Passphrase bool
+}
+
+// Required reports whether the client must answer anything
+func (c JoinChallenge) Required() bool {
+	return c.Bits > 0 || c.Passphrase
+}
+
+// SetHeaders writes the challenge onto the upgrade response headers
+func (c JoinChallenge) SetHeaders(h http.Header) {
+	if c.Bits > 0 {
+		h.Set(ChallengeNonceHeader, c.Nonce)
+		h.Set(ChallengeBitsHeader, strconv.Itoa(c.Bits))
+	}
+	if c.Passphrase {
+		h.Set(ChallengePassphraseHeader, "required")
+	}
+}
+
+// ParseJoinChallenge reads a challenge from upgrade response headers
+func ParseJoinChallenge(h http.Header) JoinChallenge {
+	c := JoinChallenge{
+		Nonce:      h.Get(ChallengeNonceHeader),
+		Passphrase: strings.EqualFold(h.Get(ChallengePassphraseHeader), "required"),
+	}
+	if n, err := strconv.Atoi(h.Get(ChallengeBitsHeader)); err == nil && n > 0 && c.Nonce != "" {
+		c.Bits = min(n, MaxChallengeBits)
+	}
+	return c
+}
+
+// leadingZeroBits counts the zero bits at the start of sha256(nonce:solution)
+func leadingZeroBits(nonce, solution string) int {
+	sum := sha256.Sum256([]byte(nonce + ":" + solution))
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// VerifyProofOfWork checks a client's solution to the challenge
+func VerifyProofOfWork(nonce, solution string, difficulty int) bool {
+	return solution != "" && len(solution) <= 20 && leadingZeroBits(nonce, solution) >= difficulty
+}
+
+// SolveProofOfWork finds a counter whose hash with nonce has at least
+// difficulty leading zero bits. Expect about 2^difficulty hashes.
+func SolveProofOfWork(nonce string, difficulty int) string {
+	for i := uint64(0); ; i++ {
+		solution := strconv.FormatUint(i, 10)
+		if leadingZeroBits(nonce, solution) >= difficulty {
+			return solution
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package shared
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestJoinChallengeHeaders(t *testing.T) {
+	h := http.Header{}
+	JoinChallenge{}.SetHeaders(h)
+	if c := ParseJoinChallenge(h); c.Required() {
+		t.Errorf("No challenge should be announced when disabled, got %+v", c)
+	}
+
+	h = http.Header{}
+	JoinChallenge{Nonce: "abc", Bits: 12, Passphrase: true}.SetHeaders(h)
+	if c := ParseJoinChallenge(h); c.Nonce != "abc" || c.Bits != 12 || !c.Passphrase {
+		t.Errorf("Round trip lost data: %+v", c)
+	}
+
+	h.Set(ChallengeBitsHeader, "64")
+	if c := ParseJoinChallenge(h); c.Bits != MaxChallengeBits {
+		t.Errorf("Difficulty should be capped, got %d", c.Bits)
+	}
+}
+
+func TestProofOfWork(t *testing.T) {
+	solution := SolveProofOfWork("nonce", 10)
+	if !VerifyProofOfWork("nonce", solution, 10) {
+		t.Fatalf("Solution %q does not verify", solution)
+	}
+	if VerifyProofOfWork("other-nonce", solution, 10) && VerifyProofOfWork("third-nonce", solution, 10) {
+		t.Error("A solution should not carry over to other nonces")
+	}
+	if VerifyProofOfWork("nonce", "", 0) {
+		t.Error("An empty solution should never verify")
+	}
+}
@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ChatURLScheme is the scheme of shareable server links
+const ChatURLScheme = "marchat"
+
+// ChatURL is a shareable link to a server, e.g.
+// marchat://chat.example.com:8443/general?user=alice&invite=TOKEN. Links
+// point at TLS servers unless they carry tls=false.
+type ChatURL struct {
+	Host    string // host[:port]
+	Channel string // path after the host; servers currently have a single room
+	User    string // suggested username
+	TLS     bool
+	Invite  string // single-use invite token, see :invite
+}
+
+// ParseChatURL parses a marchat:// link
+func ParseChatURL(raw string) (ChatURL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ChatURL{}, fmt.Errorf("invalid marchat URL: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, ChatURLScheme) {
+		return ChatURL{}, fmt.Errorf("not a %s:// URL", ChatURLScheme)
+	}
+	if u.Host == "" {
+		return ChatURL{}, fmt.Errorf("marchat URL has no host")
+	}
+	q := u.Query()
+	return ChatURL{
+		Host:    u.Host,
+		Channel: strings.Trim(u.Path, "/"),
+		User:    q.Get("user"),
+		TLS:     !strings.EqualFold(q.Get("tls"), "false"),
+		Invite:  q.Get("invite"),
+	}, nil
+}
+
+// String formats the link
+func (c ChatURL) String() string {
+	u := url.URL{Scheme: ChatURLScheme, Host: c.Host}
+	if c.Channel != "" {
+		u.Path = "/" + c.Channel
+	}
+	q := url.Values{}
+	if c.User != "" {
+		q.Set("user", c.User)
+	}
+	if c.Invite != "" {
+		q.Set("invite", c.Invite)
+	}
+	if !c.TLS {
+		q.Set("tls", "false")
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// WebSocketURL is the server endpoint the client connects to
+func (c ChatURL) WebSocketURL() string {
+	scheme := "wss"
+	if !c.TLS {
+		scheme = "ws"
+	}
+	return scheme + "://" + c.Host + "/ws"
+}
@@ -0,0 +1,31 @@
+package shared
+
+import "testing"
+
+func TestChatURL(t *testing.T) {
+	link, err := ParseChatURL("marchat://chat.example.com:8443?invite=ABC123")
+	if err != nil {
+		t.Fatalf("ParseChatURL failed: %v", err)
+	}
+	if link.Host != "chat.example.com:8443" || !link.TLS || link.Invite != "ABC123" {
+		t.Errorf("Unexpected link %+v", link)
+	}
+	if got := link.WebSocketURL(); got != "wss://chat.example.com:8443/ws" {
+		t.Errorf("WebSocketURL = %q", got)
+	}
+
+	plain := ChatURL{Host: "localhost:8080", Channel: "general", User: "alice", Invite: "XYZ"}
+	parsed, err := ParseChatURL(plain.String())
+	if err != nil || parsed != plain {
+		t.Errorf("Round trip of %q gave %+v (%v)", plain.String(), parsed, err)
+	}
+	if got := parsed.WebSocketURL(); got != "ws://localhost:8080/ws" {
+		t.Errorf("WebSocketURL = %q", got)
+	}
+
+	for _, bad := range []string{"https://example.com", "marchat://", "marchat:%zz"} {
+		if _, err := ParseChatURL(bad); err == nil {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+}
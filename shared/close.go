@@ -0,0 +1,27 @@
+package shared
+
+// WebSocket close codes the server uses when it refuses a connection during
+// the handshake. They sit in the 4000-4999 range RFC 6455 leaves to
+// applications. The close reason is a human readable explanation, except
+// for CloseUnsupportedVersion where it is the oldest client version the
+// server accepts.
+const (
+	CloseInvalidHandshake   = 4000
+	CloseUsernameInvalid    = 4001
+	CloseUsernameTaken      = 4002
+	CloseBanned             = 4003
+	CloseServerFull         = 4004
+	CloseUnsupportedVersion = 4005
+	CloseNotAllowed         = 4006 // not on the allowlist, or a bad invite
+	CloseSpectatorsRefused  = 4007
+	CloseChallengeFailed    = 4008
+	CloseNotAdmin           = 4009
+
+	// CloseIdle ends a connection that sent nothing for the server's idle
+	// timeout. Clients should wait for their user before reconnecting.
+	CloseIdle = 4010
+
+	// CloseSessionRevoked ends a session its user revoked from another
+	// device with :sessions revoke. Clients must not reconnect.
+	CloseSessionRevoked = 4011
+)
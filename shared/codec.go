@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Wire formats, negotiated as WebSocket subprotocols on the upgrade. Clients
+// and servers that offer none speak JSON, so either side can be older.
+const (
+	SubprotocolCBOR = "marchat.cbor"
+	SubprotocolJSON = "marchat.json"
+)
+
+// Subprotocols lists the wire formats a server accepts, preferred first
+var Subprotocols = []string{SubprotocolCBOR, SubprotocolJSON}
+
+// Codec encodes frames in one wire format
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	// Binary reports whether frames go out as binary rather than text
+	Binary() bool
+}
+
+// CodecFor returns the codec for a negotiated subprotocol; anything unknown,
+// including none, is JSON
+func CodecFor(subprotocol string) Codec {
+	if subprotocol == SubprotocolCBOR {
+		return cborCodec{}
+	}
+	return jsonCodec{}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Binary() bool                       { return false }
+
+// CBOR uses the json struct tags, so the same types serve both formats.
+// Times keep their nanoseconds and zone, as they do in JSON; byte slices such
+// as file data travel raw instead of as base64.
+var (
+	cborEnc, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	cborDec, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}.DecMode()
+)
+
+type cborCodec struct{}
+
+func (cborCodec) Marshal(v any) ([]byte, error)      { return cborEnc.Marshal(v) }
+func (cborCodec) Unmarshal(data []byte, v any) error { return cborDec.Unmarshal(data, v) }
+func (cborCodec) Binary() bool                       { return true }
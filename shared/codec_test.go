@@ -0,0 +1,84 @@
+package shared
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleMessage() Message {
+	return Message{
+		Sender:    "alice",
+		Content:   strings.Repeat("deploy finished, see the logs ", 4),
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.FixedZone("", 2*60*60)),
+		Type:      FileMessageType,
+		File:      &FileMeta{Filename: "notes.txt", Size: 2048, Data: bytes.Repeat([]byte{0xAB}, 2048)},
+	}
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	for _, subprotocol := range []string{SubprotocolCBOR, SubprotocolJSON, ""} {
+		codec := CodecFor(subprotocol)
+		want := sampleMessage()
+		data, err := codec.Marshal(want)
+		if err != nil {
+			t.Fatalf("%q: Marshal: %v", subprotocol, err)
+		}
+		var got Message
+		if err := codec.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%q: Unmarshal: %v", subprotocol, err)
+		}
+		if got.Sender != want.Sender || got.Content != want.Content || got.Type != want.Type ||
+			!got.CreatedAt.Equal(want.CreatedAt) || got.File == nil || !bytes.Equal(got.File.Data, want.File.Data) {
+			t.Errorf("%q: round trip changed the message: %+v", subprotocol, got)
+		}
+		if codec.Binary() != (subprotocol == SubprotocolCBOR) {
+			t.Errorf("%q: unexpected frame type", subprotocol)
+		}
+	}
+}
+
+func TestCBORIsSmaller(t *testing.T) {
+	msg := sampleMessage()
+	j, _ := CodecFor(SubprotocolJSON).Marshal(msg)
+	c, _ := CodecFor(SubprotocolCBOR).Marshal(msg)
+	if len(c) >= len(j) {
+		t.Errorf("Expected CBOR to be smaller than JSON for a file message, got %d vs %d bytes", len(c), len(j))
+	}
+}
+
+func TestCBORIgnoresUnknownFields(t *testing.T) {
+	// A client decodes every frame as a Message first, then as a typed
+	// envelope, so unrelated frames must decode without error
+	codec := CodecFor(SubprotocolCBOR)
+	data, err := codec.Marshal(map[string]any{"type": "userlist", "data": []byte(`{"users":["alice"]}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg Message
+	if err := codec.Unmarshal(data, &msg); err != nil || msg.Sender != "" {
+		t.Errorf("Expected an envelope to decode as an empty message, got %+v (%v)", msg, err)
+	}
+}
+
+func benchmarkCodec(b *testing.B, subprotocol string, msg Message) {
+	codec := CodecFor(subprotocol)
+	data, _ := codec.Marshal(msg)
+	b.ReportMetric(float64(len(data)), "bytes/frame")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, _ := codec.Marshal(msg)
+		var out Message
+		_ = codec.Unmarshal(data, &out)
+	}
+}
+
+func chatLine() Message {
+	return Message{Sender: "alice", Content: "lunch at noon?", CreatedAt: time.Now(), Type: TextMessage}
+}
+
+func BenchmarkJSONChatLine(b *testing.B) { benchmarkCodec(b, SubprotocolJSON, chatLine()) }
+func BenchmarkCBORChatLine(b *testing.B) { benchmarkCodec(b, SubprotocolCBOR, chatLine()) }
+func BenchmarkJSONFile(b *testing.B)     { benchmarkCodec(b, SubprotocolJSON, sampleMessage()) }
+func BenchmarkCBORFile(b *testing.B)     { benchmarkCodec(b, SubprotocolCBOR, sampleMessage()) }
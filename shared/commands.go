@@ -0,0 +1,25 @@
+package shared
+
+// CommandInfo describes one server command for a client's help. The server
+// sends the commands the requesting user may run, as a "commands" WebSocket
+// message, in reply to :help.
+type CommandInfo struct {
+	Name        string `json:"name"` // including the leading colon
+	Usage       string `json:"usage"`
+	Description string `json:"description"`
+	AdminOnly   bool   `json:"admin_only,omitempty"`
+	Plugin      string `json:"plugin,omitempty"` // the plugin providing the command, if any
+	// Args are the arguments a plugin command declares, if any
+	Args []CommandArg `json:"args,omitempty"`
+}
+
+// CommandArg describes one argument of a command, mirroring the plugin
+// SDK's CommandArg so clients can ask for arguments in a form
+type CommandArg struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+	Rest        bool     `json:"rest,omitempty"`
+}
@@ -134,6 +134,11 @@ func DecryptMessage(sessionKey *SessionKey, encrypted *EncryptedMessage) ([]byte
 		return nil, fmt.Errorf("failed to create AEAD: %w", err)
 	}
 
+	// Open panics on a nonce of the wrong size, and peers can send any
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+
 	// Decrypt the ciphertext
 	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Encrypted, nil)
 	if err != nil {
@@ -322,6 +322,13 @@ func TestDecryptMessageInvalidData(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when decrypting with corrupted nonce")
 	}
+
+	// A short nonce is refused rather than panicking
+	encrypted.Nonce = encrypted.Nonce[:8]
+	_, err = DecryptMessage(sessionKey, encrypted)
+	if err == nil {
+		t.Error("Expected error when decrypting with a short nonce")
+	}
 }
 
 func TestDeriveSessionKeyDifferentConversations(t *testing.T) {
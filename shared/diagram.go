@@ -0,0 +1,74 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Diagram limits: room for a whiteboard sketch, not enough to flood the chat
+const (
+	MaxDiagramWidth = 120
+	MaxDiagramLines = 60
+)
+
+// wideRunesStart is where scripts with double-width characters (Hangul,
+// CJK) begin; everything a diagram may use comes before it
+const wideRunesStart = 0x1100
+
+var ErrEmptyDiagram = errors.New("diagram is empty")
+
+// IsDiagramRune reports whether r may appear in a diagram: printable and
+// exactly one column wide
+func IsDiagramRune(r rune) bool {
+	return r < wideRunesStart && unicode.IsPrint(r) && !unicode.In(r, unicode.Mn, unicode.Me)
+}
+
+// NormalizeDiagram prepares a diagram so it lines up the same in every
+// terminal: tabs become spaces, blank lines around it and trailing spaces
+// go, and every line is padded to the width of the longest. Characters that
+// are not exactly one column wide, such as emoji, CJK or combining accents,
+// are refused rather than left to shift the columns.
+func NormalizeDiagram(s string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	rows := make([][]rune, 0, len(lines))
+	width := 0
+	for n, line := range lines {
+		var row []rune
+		for _, r := range line {
+			if r == '\t' {
+				row = append(row, []rune(strings.Repeat(" ", 4-len(row)%4))...)
+				continue
+			}
+			if !IsDiagramRune(r) {
+				return "", fmt.Errorf("line %d: %q is not one column wide", n+1, r)
+			}
+			row = append(row, r)
+		}
+		row = []rune(strings.TrimRight(string(row), " "))
+		width = max(width, len(row))
+		rows = append(rows, row)
+	}
+	for len(rows) > 0 && len(rows[0]) == 0 {
+		rows = rows[1:]
+	}
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	if len(rows) == 0 {
+		return "", ErrEmptyDiagram
+	}
+	if width > MaxDiagramWidth || len(rows) > MaxDiagramLines {
+		return "", fmt.Errorf("diagram too large (max %d columns, %d lines)", MaxDiagramWidth, MaxDiagramLines)
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(string(row))
+		b.WriteString(strings.Repeat(" ", width-len(row)))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,36 @@
+package shared
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDiagram(t *testing.T) {
+	got, err := NormalizeDiagram("\n\n+--+\t|\r\n|  |  \n+--+\ncafé\n\n")
+	want := "+--+    |\n|  |     \n+--+     \ncafé     "
+	if err != nil || got != want {
+		t.Errorf("NormalizeDiagram = %q, %v; want %q", got, err, want)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if n := len([]rune(line)); n != 9 {
+			t.Errorf("Line %q is %d columns, want 9", line, n)
+		}
+	}
+
+	if _, err := NormalizeDiagram(" \n\t\n"); !errors.Is(err, ErrEmptyDiagram) {
+		t.Errorf("Expected ErrEmptyDiagram, got %v", err)
+	}
+	for name, in := range map[string]string{
+		"emoji":     "+--+ 🚀",
+		"cjk":       "| 图 |",
+		"combining": "e\u0301",
+		"control":   "a\x1b[31mb",
+		"too wide":  strings.Repeat("-", MaxDiagramWidth+1),
+		"too tall":  strings.Repeat("|\n", MaxDiagramLines+1),
+	} {
+		if _, err := NormalizeDiagram(in); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
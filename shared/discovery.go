@@ -0,0 +1,337 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Minimal mDNS/DNS-SD (RFC 6762/6763) for finding servers on the local
+// network: servers answer queries for MDNSService, clients browse for it.
+
+// MDNSService is the DNS-SD service type marchat servers advertise
+const MDNSService = "_marchat._tcp.local."
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// DiscoveredServer is a server found on the LAN
+type DiscoveredServer struct {
+	Name string // instance name, e.g. the server's hostname
+	Host string // IPv4 address
+	Port int
+	TLS  bool
+}
+
+// WebSocketURL is the endpoint clients connect to
+func (s DiscoveredServer) WebSocketURL() string {
+	scheme := "ws"
+	if s.TLS {
+		scheme = "wss"
+	}
+	return scheme + "://" + net.JoinHostPort(s.Host, strconv.Itoa(s.Port)) + "/ws"
+}
+
+// Advertiser answers mDNS queries for one marchat server
+type Advertiser struct {
+	instance string
+	port     int
+	tls      bool
+	conn     *net.UDPConn
+}
+
+// NewAdvertiser listens on the mDNS group. Call Serve to answer queries and
+// Close to stop.
+func NewAdvertiser(instance string, port int, tls bool) (*Advertiser, error) {
+	if instance == "" {
+		instance, _ = os.Hostname()
+		if instance == "" {
+			instance = "marchat"
+		}
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return nil, fmt.Errorf("mDNS listen failed: %w", err)
+	}
+	return &Advertiser{instance: instance, port: port, tls: tls, conn: conn}, nil
+}
+
+// Serve answers queries until Close is called
+func (a *Advertiser) Serve() {
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		var p dnsmessage.Parser
+		hdr, err := p.Start(buf[:n])
+		if err != nil || hdr.Response {
+			continue
+		}
+		questions, err := p.AllQuestions()
+		if err != nil || !asksForService(questions) {
+			continue
+		}
+		resp, err := a.response(hdr.ID, LocalIPv4s())
+		if err != nil {
+			continue
+		}
+		// Queries from an ephemeral port get a unicast reply (RFC 6762
+		// section 6.7); full mDNS queriers hear the multicast one
+		dst := mdnsGroup
+		if src.Port != mdnsGroup.Port {
+			dst = src
+		}
+		_, _ = a.conn.WriteToUDP(resp, dst)
+	}
+}
+
+// Close stops answering queries
+func (a *Advertiser) Close() error {
+	return a.conn.Close()
+}
+
+func asksForService(questions []dnsmessage.Question) bool {
+	for _, q := range questions {
+		if strings.EqualFold(q.Name.String(), MDNSService) && (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) {
+			return true
+		}
+	}
+	return false
+}
+
+// response builds the PTR, SRV, TXT and A records describing this server
+func (a *Advertiser) response(id uint16, ips []net.IP) ([]byte, error) {
+	service := dnsmessage.MustNewName(MDNSService)
+	instance, err := dnsmessage.NewName(dnsLabel(a.instance) + "." + MDNSService)
+	if err != nil {
+		return nil, err
+	}
+	target, err := dnsmessage.NewName(dnsLabel(a.instance) + ".local.")
+	if err != nil {
+		return nil, err
+	}
+	tls := "tls=0"
+	if a.tls {
+		tls = "tls=1"
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, Response: true, Authoritative: true})
+	b.EnableCompression()
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	hdr := func(name dnsmessage.Name) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: 120}
+	}
+	if err := b.PTRResource(hdr(service), dnsmessage.PTRResource{PTR: instance}); err != nil {
+		return nil, err
+	}
+	if err := b.SRVResource(hdr(instance), dnsmessage.SRVResource{Target: target, Port: uint16(a.port)}); err != nil {
+		return nil, err
+	}
+	if err := b.TXTResource(hdr(instance), dnsmessage.TXTResource{TXT: []string{tls, "path=/ws"}}); err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		var addr [4]byte
+		copy(addr[:], ip.To4())
+		if err := b.AResource(hdr(target), dnsmessage.AResource{A: addr}); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// dnsLabel makes name usable as a single DNS label
+func dnsLabel(name string) string {
+	label := strings.Map(func(r rune) rune {
+		if r == '.' || r < ' ' {
+			return '-'
+		}
+		return r
+	}, name)
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return label
+}
+
+// LocalIPv4s lists this host's non-loopback IPv4 addresses
+func LocalIPv4s() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+			if ip4 := ipnet.IP.To4(); ip4 != nil {
+				ips = append(ips, ip4)
+			}
+		}
+	}
+	return ips
+}
+
+// Browse queries the LAN for marchat servers, collecting answers until
+// timeout or ctx is done
+func Browse(ctx context.Context, timeout time.Duration) ([]DiscoveredServer, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: dnsmessage.MustNewName(MDNSService), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	query, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteToUDP(query, mdnsGroup); err != nil {
+		return nil, fmt.Errorf("mDNS query failed: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	r := newBrowseResults()
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break // deadline reached
+		}
+		r.add(buf[:n])
+	}
+	return r.servers(), nil
+}
+
+// browseResults gathers records from mDNS answers, which may arrive split
+// across several packets
+type browseResults struct {
+	instances map[string]bool
+	srv       map[string]dnsmessage.SRVResource
+	txt       map[string][]string
+	addrs     map[string][]net.IP
+}
+
+func newBrowseResults() *browseResults {
+	return &browseResults{
+		instances: map[string]bool{},
+		srv:       map[string]dnsmessage.SRVResource{},
+		txt:       map[string][]string{},
+		addrs:     map[string][]net.IP{},
+	}
+}
+
+func (r *browseResults) add(packet []byte) {
+	var p dnsmessage.Parser
+	if hdr, err := p.Start(packet); err != nil || !hdr.Response {
+		return
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return
+	}
+	// Answers and additionals carry the same kinds of records
+	for section := 0; section < 3; section++ {
+		for {
+			h, err := nextHeader(&p, section)
+			if err != nil {
+				break
+			}
+			name := strings.ToLower(h.Name.String())
+			switch h.Type {
+			case dnsmessage.TypePTR:
+				res, err := p.PTRResource()
+				if err == nil && strings.EqualFold(name, MDNSService) {
+					r.instances[strings.ToLower(res.PTR.String())] = true
+				}
+			case dnsmessage.TypeSRV:
+				if res, err := p.SRVResource(); err == nil {
+					r.srv[name] = res
+				}
+			case dnsmessage.TypeTXT:
+				if res, err := p.TXTResource(); err == nil {
+					r.txt[name] = res.TXT
+				}
+			case dnsmessage.TypeA:
+				if res, err := p.AResource(); err == nil {
+					r.addrs[name] = append(r.addrs[name], net.IP(res.A[:]))
+				}
+			default:
+				_ = skipResource(&p, section)
+			}
+		}
+	}
+}
+
+func nextHeader(p *dnsmessage.Parser, section int) (dnsmessage.ResourceHeader, error) {
+	switch section {
+	case 0:
+		return p.AnswerHeader()
+	case 1:
+		return p.AuthorityHeader()
+	default:
+		return p.AdditionalHeader()
+	}
+}
+
+func skipResource(p *dnsmessage.Parser, section int) error {
+	switch section {
+	case 0:
+		return p.SkipAnswer()
+	case 1:
+		return p.SkipAuthority()
+	default:
+		return p.SkipAdditional()
+	}
+}
+
+// servers resolves the collected records into reachable servers
+func (r *browseResults) servers() []DiscoveredServer {
+	var found []DiscoveredServer
+	for instance := range r.instances {
+		srv, ok := r.srv[instance]
+		if !ok {
+			continue
+		}
+		ips := r.addrs[strings.ToLower(srv.Target.String())]
+		if len(ips) == 0 {
+			continue
+		}
+		s := DiscoveredServer{
+			Name: strings.TrimSuffix(instance, "."+strings.ToLower(MDNSService)),
+			Host: ips[0].String(),
+			Port: int(srv.Port),
+		}
+		for _, kv := range r.txt[instance] {
+			if kv == "tls=1" {
+				s.TLS = true
+			}
+		}
+		found = append(found, s)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
@@ -0,0 +1,42 @@
+package shared
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDiscoveryRecords(t *testing.T) {
+	a := &Advertiser{instance: "lab.server", port: 8443, tls: true}
+	packet, err := a.response(0, []net.IP{net.IPv4(192, 168, 1, 20)})
+	if err != nil {
+		t.Fatalf("response failed: %v", err)
+	}
+
+	r := newBrowseResults()
+	r.add(packet)
+	servers := r.servers()
+	if len(servers) != 1 {
+		t.Fatalf("Expected one server, got %+v", servers)
+	}
+	s := servers[0]
+	if s.Name != "lab-server" || s.Host != "192.168.1.20" || s.Port != 8443 || !s.TLS {
+		t.Errorf("Unexpected server %+v", s)
+	}
+	if got := s.WebSocketURL(); got != "wss://192.168.1.20:8443/ws" {
+		t.Errorf("WebSocketURL = %q", got)
+	}
+}
+
+func TestAsksForService(t *testing.T) {
+	q := func(name string, typ dnsmessage.Type) []dnsmessage.Question {
+		return []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET}}
+	}
+	if !asksForService(q(MDNSService, dnsmessage.TypePTR)) {
+		t.Error("PTR queries for the service should be answered")
+	}
+	if asksForService(q("_http._tcp.local.", dnsmessage.TypePTR)) || asksForService(q(MDNSService, dnsmessage.TypeA)) {
+		t.Error("Other queries should be ignored")
+	}
+}
@@ -0,0 +1,17 @@
+package shared
+
+import "time"
+
+// ChannelNotes is a channel's shared scratchpad. The server sends it as a
+// "notes" WebSocket message when asked with :notes, and to everyone when it
+// is saved or someone starts or stops editing. One member edits at a time:
+// Editor holds the lock, and only they may send a NotesMessageType with the
+// new text.
+type ChannelNotes struct {
+	Channel   string    `json:"channel"`
+	Text      string    `json:"text"`
+	Version   int64     `json:"version"` // incremented on every save
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	Editor    string    `json:"editor,omitempty"` // empty when nobody is editing
+}
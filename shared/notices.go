@@ -0,0 +1,45 @@
+package shared
+
+import "time"
+
+// Topic is a channel's topic. The server sends it as a "topic" WebSocket
+// message on connect and whenever an admin changes it; empty Text means the
+// topic was cleared.
+type Topic struct {
+	Channel string    `json:"channel"`
+	Text    string    `json:"text"`
+	SetBy   string    `json:"set_by,omitempty"`
+	SetAt   time.Time `json:"set_at,omitempty"`
+}
+
+// MOTD is the server's message of the day. The server sends it as a "motd"
+// WebSocket message on connect and whenever an admin changes it; empty Text
+// means there is none.
+type MOTD struct {
+	Text  string    `json:"text"`
+	SetBy string    `json:"set_by,omitempty"`
+	SetAt time.Time `json:"set_at,omitempty"`
+}
+
+// Maintenance is a planned maintenance window. The server sends it as a
+// "maintenance" WebSocket message on connect and whenever an admin changes
+// it; clients count down to Start in their banner. From Start to End the
+// server is drained and refuses connections. A zero Start means none is
+// planned.
+type Maintenance struct {
+	Start   time.Time `json:"start,omitempty"`
+	End     time.Time `json:"end,omitempty"`
+	Message string    `json:"message,omitempty"`
+	SetBy   string    `json:"set_by,omitempty"`
+	SetAt   time.Time `json:"set_at,omitempty"`
+}
+
+// Scheduled reports whether a window is planned
+func (m Maintenance) Scheduled() bool {
+	return !m.Start.IsZero()
+}
+
+// Active reports whether t falls within the window
+func (m Maintenance) Active(t time.Time) bool {
+	return m.Scheduled() && !t.Before(m.Start) && t.Before(m.End)
+}
@@ -0,0 +1,20 @@
+package shared
+
+import "time"
+
+// LinkPreview is the OpenGraph summary of a URL posted in chat, fetched by
+// the server when link previews are enabled
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// PreviewUpdate is the "preview" WebSocket payload. The server sends it
+// after the message it belongs to, which clients find by sender and time.
+type PreviewUpdate struct {
+	Sender    string      `json:"sender"`
+	CreatedAt time.Time   `json:"created_at"`
+	Preview   LinkPreview `json:"preview"`
+}
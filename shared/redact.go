@@ -0,0 +1,86 @@
+package shared
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in log output
+const Redacted = "[REDACTED]"
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string // longest first, so a secret containing another is masked whole
+)
+
+// Patterns for secrets whose values are not known in advance
+var (
+	// Three base64url segments starting with a JSON header: a JWT
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// JSON fields that hold keys, passphrases, invite tokens or ciphertext
+	secretFieldPattern = regexp.MustCompile(`"(admin_key|adminKey|AdminKey|passphrase|join_passphrase|keystore_passphrase|password|token|invite|jwt|secret|encrypted_data|private_key|signature)"(\s*:\s*)"[^"]*"`)
+	// KEY=value and "key: value" in flags, env dumps, messages, invite links
+	// and %+v dumps of the handshake
+	secretAssignPattern = regexp.MustCompile(`(?i)\b((?:[a-z0-9]+_)*(?:admin[_-]?key|passphrase|password|secret|token|invite|e2e[_-]?key|encryption[_-]?key))(\s*[:=]\s*)([^\s,;"']+)`)
+	// Authorization headers
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+	// The content of an end-to-end encrypted message is ciphertext
+	encryptedContentPattern = regexp.MustCompile(`"content"(\s*:\s*)"[^"]*"`)
+)
+
+// RegisterSecret makes Redact mask value wherever it appears, for secrets
+// known at startup such as the admin key. Very short values are ignored so
+// common words are not masked.
+func RegisterSecret(value string) {
+	if len(value) < 4 {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == value {
+			return
+		}
+	}
+	secrets = append(secrets, value)
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact masks admin keys, JWTs, passphrases and encrypted payloads in s
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	secretsMu.RUnlock()
+
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	s = secretFieldPattern.ReplaceAllString(s, `"$1"$2"`+Redacted+`"`)
+	s = secretAssignPattern.ReplaceAllString(s, "$1$2"+Redacted)
+	if strings.Contains(s, `"encrypted":true`) {
+		s = encryptedContentPattern.ReplaceAllString(s, `"content"$1"`+Redacted+`"`)
+	}
+	return s
+}
+
+// redactWriter masks secrets in everything written through it
+type redactWriter struct {
+	w io.Writer
+}
+
+// RedactWriter wraps w so secrets are masked before they reach it. The log
+// package writes each entry in one call, so patterns never span writes.
+func RedactWriter(w io.Writer) io.Writer {
+	return redactWriter{w: w}
+}
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
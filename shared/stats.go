@@ -0,0 +1,39 @@
+package shared
+
+import "time"
+
+// Scopes of :stats
+const (
+	StatsScopeMe      = "me"
+	StatsScopeChannel = "channel"
+)
+
+// ChatStats is a week of activity, for yourself or the whole channel. The
+// server sends it as a "stats" WebSocket message to whoever ran :stats me or
+// :stats channel. Hourly counts the messages in each hour starting at From,
+// oldest first, so clients can work out today and the busiest hours in
+// their own time zone.
+type ChatStats struct {
+	Scope   string        `json:"scope"`   // StatsScopeMe or StatsScopeChannel
+	Subject string        `json:"subject"` // the username or the channel
+	From    time.Time     `json:"from"`
+	Hourly  []int         `json:"hourly"`
+	Talkers []TalkerStats `json:"talkers,omitempty"` // channel scope: most messages first
+	Rank    int           `json:"rank,omitempty"`    // me scope: place among the week's talkers, 0 when silent
+	Senders int           `json:"senders"`           // people who posted during the week
+}
+
+// TalkerStats is how many messages someone posted during the week
+type TalkerStats struct {
+	Username string `json:"username"`
+	Messages int    `json:"messages"`
+}
+
+// Total is the number of messages in the week
+func (s *ChatStats) Total() int {
+	total := 0
+	for _, n := range s.Hourly {
+		total += n
+	}
+	return total
+}
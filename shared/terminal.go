@@ -0,0 +1,145 @@
+package shared
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// TerminalReset undoes what a full-screen program may have left switched
+// on: the alternate screen, a hidden cursor, mouse reporting and bracketed
+// paste. A program that dies, or a remote one whose connection drops,
+// never sends these itself.
+const TerminalReset = "\x1b[?1049l\x1b[?25h\x1b[?1000l\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[?2004l\x1b[0m"
+
+// terminalSignals end a program holding the terminal
+var terminalSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// exitProcess is os.Exit, replaced in tests
+var exitProcess = os.Exit
+
+// TerminalGuard puts a terminal back the way it was found however the
+// program holding it ends: normally, on a signal, or in a panic on any
+// goroutine. It does nothing when in or out is not a terminal.
+type TerminalGuard struct {
+	mu    sync.Mutex
+	in    uintptr
+	out   io.Writer
+	state *term.State // as found, nil when not a terminal
+	armed bool        // the terminal has been changed and not put back
+}
+
+// NewTerminalGuard records the state of the terminal on in and out
+func NewTerminalGuard(in, out *os.File) *TerminalGuard {
+	g := &TerminalGuard{in: in.Fd(), out: out}
+	if term.IsTerminal(in.Fd()) && term.IsTerminal(out.Fd()) {
+		if state, err := term.GetState(in.Fd()); err == nil {
+			g.state = state
+		}
+	}
+	return g
+}
+
+// MakeRaw puts the terminal in raw mode until Restore
+func (g *TerminalGuard) MakeRaw() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.state == nil {
+		return nil
+	}
+	if _, err := term.MakeRaw(g.in); err != nil {
+		return err
+	}
+	g.armed = true
+	return nil
+}
+
+// Arm marks the terminal as changed by someone else, such as a Bubble Tea
+// program, so Restore puts it back
+func (g *TerminalGuard) Arm() {
+	g.mu.Lock()
+	g.armed = g.state != nil
+	g.mu.Unlock()
+}
+
+// Disarm marks the terminal as already put back by whoever changed it
+func (g *TerminalGuard) Disarm() {
+	g.mu.Lock()
+	g.armed = false
+	g.mu.Unlock()
+}
+
+// Restore resets the screen and the terminal's mode if they were changed.
+// It is safe to call more than once and from any goroutine.
+func (g *TerminalGuard) Restore() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.armed {
+		return
+	}
+	g.armed = false
+	_, _ = io.WriteString(g.out, TerminalReset)
+	_ = term.Restore(g.in, g.state)
+}
+
+// Recover restores the terminal if the goroutine is panicking, then lets
+// the panic carry on so its trace is printed to a usable terminal. Defer
+// it at the top of each goroutine that runs while the terminal is changed.
+func (g *TerminalGuard) Recover() {
+	if r := recover(); r != nil {
+		g.Restore()
+		panic(r)
+	}
+}
+
+// Exit restores the terminal and exits the process
+func (g *TerminalGuard) Exit(code int) {
+	g.Restore()
+	exitProcess(code)
+}
+
+// HandleSignals calls stop on an interrupt, termination or hangup signal
+// so the program can shut down and put the terminal back itself. If it
+// hasn't within grace, or a second signal arrives, the terminal is
+// restored and the process exits. The returned func stops listening.
+func (g *TerminalGuard) HandleSignals(grace time.Duration, stop func()) func() {
+	signals := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	signal.Notify(signals, terminalSignals...)
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-signals:
+		case <-done:
+			return
+		}
+		go stop()
+		select {
+		case <-done:
+			return
+		case sig = <-signals:
+		case <-time.After(grace):
+		}
+		g.Exit(signalExitCode(sig))
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// signalExitCode is the shell's exit status for a process killed by sig
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
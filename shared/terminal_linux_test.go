@@ -0,0 +1,164 @@
+package shared
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a pseudo-terminal, returning the controlling side a test
+// reads the screen from and the terminal side a program runs on
+func openPTY(t *testing.T) (ptm, pts *os.File) {
+	t.Helper()
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("No pseudo-terminals here: %v", err)
+	}
+	var n int
+	raw, _ := ptm.SyscallConn()
+	_ = raw.Control(func(fd uintptr) {
+		if err = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); err == nil {
+			n, err = unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to unlock the pseudo-terminal: %v", err)
+	}
+	pts, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open the pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { pts.Close(); ptm.Close() })
+	return ptm, pts
+}
+
+// isRaw reports whether the terminal has line editing switched off
+func isRaw(t *testing.T, pts *os.File) bool {
+	t.Helper()
+	termios, err := unix.IoctlGetTermios(int(pts.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatalf("Failed to read the terminal mode: %v", err)
+	}
+	return termios.Lflag&unix.ICANON == 0
+}
+
+// readScreen reads what has been written to the terminal so far
+func readScreen(t *testing.T, ptm *os.File) string {
+	t.Helper()
+	var out bytes.Buffer
+	buf := make([]byte, 4096)
+	for {
+		_ = ptm.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, err := ptm.Read(buf)
+		out.Write(buf[:n])
+		if err != nil {
+			return out.String()
+		}
+	}
+}
+
+func TestTerminalGuardRestoresAfterPanic(t *testing.T) {
+	ptm, pts := openPTY(t)
+	guard := NewTerminalGuard(pts, pts)
+	if err := guard.MakeRaw(); err != nil {
+		t.Fatalf("MakeRaw failed: %v", err)
+	}
+	if !isRaw(t, pts) {
+		t.Fatal("Expected the terminal in raw mode")
+	}
+
+	panicked := make(chan any)
+	go func() {
+		defer func() { panicked <- recover() }()
+		defer guard.Recover()
+		panic("read loop failed")
+	}()
+	if r := <-panicked; r != "read loop failed" {
+		t.Errorf("Expected the panic to carry on, got %v", r)
+	}
+	if isRaw(t, pts) {
+		t.Error("Expected the terminal back in its normal mode")
+	}
+	if screen := readScreen(t, ptm); !strings.Contains(screen, TerminalReset) {
+		t.Errorf("Expected the screen reset, got %q", screen)
+	}
+
+	// Already put back: nothing more is written
+	guard.Restore()
+	if screen := readScreen(t, ptm); screen != "" {
+		t.Errorf("Expected a second restore to do nothing, got %q", screen)
+	}
+}
+
+func TestTerminalGuardDisarmed(t *testing.T) {
+	ptm, pts := openPTY(t)
+	guard := NewTerminalGuard(pts, pts)
+	guard.Arm()
+	guard.Disarm()
+	guard.Restore()
+	if screen := readScreen(t, ptm); screen != "" {
+		t.Errorf("Expected a terminal put back by its program to be left alone, got %q", screen)
+	}
+
+	// Not a terminal: nothing to guard
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	piped := NewTerminalGuard(r, w)
+	if err := piped.MakeRaw(); err != nil {
+		t.Errorf("Expected MakeRaw on a pipe to do nothing, got %v", err)
+	}
+	piped.Restore()
+	w.Close()
+	if out, _ := io.ReadAll(r); len(out) != 0 {
+		t.Errorf("Expected nothing written to a pipe, got %q", out)
+	}
+}
+
+func TestTerminalGuardSignal(t *testing.T) {
+	ptm, pts := openPTY(t)
+	exited := make(chan int, 1)
+	exitProcess = func(code int) { exited <- code }
+	t.Cleanup(func() { exitProcess = os.Exit })
+
+	guard := NewTerminalGuard(pts, pts)
+	if err := guard.MakeRaw(); err != nil {
+		t.Fatalf("MakeRaw failed: %v", err)
+	}
+	// The program is stuck and never shuts down
+	stopped := make(chan struct{})
+	stopSignals := guard.HandleSignals(100*time.Millisecond, func() { close(stopped) })
+	defer stopSignals()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case code := <-exited:
+		if code != 128+int(syscall.SIGHUP) {
+			t.Errorf("Expected the hangup exit status, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the process to exit after the grace period")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Expected the program asked to stop first")
+	}
+	if isRaw(t, pts) {
+		t.Error("Expected the terminal back in its normal mode before exiting")
+	}
+	if screen := readScreen(t, ptm); !strings.Contains(screen, TerminalReset) {
+		t.Errorf("Expected the screen reset, got %q", screen)
+	}
+}
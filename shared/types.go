@@ -7,9 +7,17 @@ import "time"
 type MessageType string
 
 const (
-	TextMessage      MessageType = "text"
-	FileMessageType  MessageType = "file"
-	AdminCommandType MessageType = "admin_command"
+	TextMessage        MessageType = "text"
+	FileMessageType    MessageType = "file"
+	AdminCommandType   MessageType = "admin_command"
+	AnnouncementType   MessageType = "announcement" // admin broadcast shown as a banner
+	PollMessageType    MessageType = "poll"         // live poll state, see Poll
+	SnippetMessageType MessageType = "snippet"      // long paste to store server-side, see Snippet
+	ArtMessageType     MessageType = "art"          // ASCII art rendered by the sender's client (:figlet, :cowsay)
+	GapMessageType     MessageType = "gap"          // history hidden from this user, see HistoryGap
+	AudioMessageType   MessageType = "audio"        // voice note, see AudioMeta
+	NotesMessageType   MessageType = "notes"        // new text for the channel's shared notes, see ChannelNotes
+	DiagramMessageType MessageType = "diagram"      // monospace diagram from the composer, see NormalizeDiagram
 )
 
 type Message struct {
@@ -18,8 +26,56 @@ type Message struct {
 	CreatedAt time.Time   `json:"created_at"`
 	Type      MessageType `json:"type,omitempty"`
 	Encrypted bool        `json:"encrypted,omitempty"` // Indicates if content is encrypted
+	// Position in the channel, assigned by the server as it accepts the
+	// message. Clients order by it rather than by clocks; 0 = unnumbered
+	Seq int64 `json:"seq,omitempty"`
 	// For file messages, Content is empty and File is set
 	File *FileMeta `json:"file,omitempty"`
+	// For voice notes, Content is empty and Audio is set
+	Audio *AudioMeta `json:"audio,omitempty"`
+	// For poll messages, Poll holds the current tally
+	Poll *Poll `json:"poll,omitempty"`
+	// For snippet uploads Content holds the code; references carry the stored ID
+	Snippet *Snippet `json:"snippet,omitempty"`
+	// For gap markers, Gap says how much history was hidden
+	Gap *HistoryGap `json:"gap,omitempty"`
+	// Summary of the first link in the message, see PreviewUpdate
+	Preview *LinkPreview `json:"preview,omitempty"`
+	// Lowercase usernames the server resolved the message's @mentions to,
+	// with groups such as @here and @admins expanded
+	Mentions []string `json:"mentions,omitempty"`
+	// Admin commands carry a one-time nonce and an HMAC, see SignAdminCommand
+	Nonce     string `json:"nonce,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Snippet describes a long paste stored on the server. Clients upload the code
+// as a SnippetMessageType message and everyone receives a short reference;
+// the full text is served from /snippets/{id}.
+type Snippet struct {
+	ID        string    `json:"id,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	Lines     int       `json:"lines,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	Content   string    `json:"content,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// HistoryGap stands in for a run of history messages the server withheld,
+// such as those sent while the user was banned, so clients can show that
+// something is missing
+type HistoryGap struct {
+	Hidden int       `json:"hidden"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// CustomEmoji is a server-level shortcode registered by an admin. The server
+// sends the full set to clients on connect and whenever it changes.
+type CustomEmoji struct {
+	Shortcode string `json:"shortcode"`
+	Glyph     string `json:"glyph,omitempty"`
+	Image     []byte `json:"image,omitempty"` // small PNG for graphics-capable terminals
 }
 
 type FileMeta struct {
@@ -28,11 +84,53 @@ type FileMeta struct {
 	Data     []byte `json:"data"` // raw bytes (base64-encoded in JSON)
 }
 
+// Poll is the server's current view of a poll. The server re-sends it whenever
+// the tally changes; clients replace any earlier state with the same ID.
+type Poll struct {
+	ID        int          `json:"id"`
+	Question  string       `json:"question"`
+	Options   []PollOption `json:"options"`
+	Creator   string       `json:"creator"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Closed    bool         `json:"closed"`
+}
+
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes int    `json:"votes"`
+}
+
+// TotalVotes returns the number of votes cast across all options
+func (p *Poll) TotalVotes() int {
+	total := 0
+	for _, opt := range p.Options {
+		total += opt.Votes
+	}
+	return total
+}
+
 // Handshake is sent by the client on WebSocket connect for authentication
 // Admin key is only sent if admin is true
 // Username is always sent (case-insensitive match on server)
 type Handshake struct {
-	Username string `json:"username"`
-	Admin    bool   `json:"admin"`
-	AdminKey string `json:"admin_key,omitempty"`
+	Username    string `json:"username"`
+	Admin       bool   `json:"admin"`
+	AdminKey    string `json:"admin_key,omitempty"`
+	DisplayName string `json:"display_name,omitempty"` // restored from the client config, see :nick
+	// Answers to the server's JoinChallenge, when it sent one
+	ChallengeSolution string `json:"challenge_solution,omitempty"`
+	Passphrase        string `json:"passphrase,omitempty"`
+	// Single-use invite token from a marchat:// link, see :invite
+	Invite string `json:"invite,omitempty"`
+	// Spectators receive messages but cannot post or run commands
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Client build version, compared with the server's, see CheckVersionCompat
+	ClientVersion string `json:"client_version,omitempty"`
+	// The client applies userlist_delta frames; without it every change
+	// sends the full user list
+	UserListDeltas bool `json:"userlist_deltas,omitempty"`
+	// The client's clock as it sent the handshake, echoed back in the
+	// version frame so the client can measure the server's clock
+	ClientTime time.Time `json:"client_time,omitempty"`
 }
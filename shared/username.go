@@ -0,0 +1,27 @@
+package shared
+
+// Username rejection codes sent in a "username_rejected" message when the
+// server refuses a handshake because of the username
+const (
+	UsernameEmpty        = "empty"
+	UsernameTooShort     = "too_short"
+	UsernameTooLong      = "too_long"
+	UsernameInvalidChars = "invalid_chars"
+	UsernamePattern      = "pattern"
+	UsernameReserved     = "reserved"
+	UsernameBlocked      = "blocked"
+	UsernameTaken        = "taken"
+)
+
+// UsernameRejection is the "username_rejected" payload. Clients render Code
+// in their own language and fall back to Reason for codes they don't know.
+type UsernameRejection struct {
+	Code      string `json:"code"`
+	Reason    string `json:"reason"`
+	MinLength int    `json:"min_length,omitempty"`
+	MaxLength int    `json:"max_length,omitempty"`
+}
+
+func (r *UsernameRejection) Error() string {
+	return r.Reason
+}
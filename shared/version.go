@@ -1,6 +1,12 @@
 package shared
 
-import "fmt"
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Version variables that can be set at build time using ldflags
 var (
@@ -26,3 +32,101 @@ func GetVersionInfo() string {
 func GetServerVersionInfo() string {
 	return fmt.Sprintf("%s (build: %s, commit: %s)", ServerVersion, BuildTime, GitCommit)
 }
+
+// VersionCompat is the outcome of comparing client and server versions
+type VersionCompat int
+
+const (
+	// VersionCompatible also covers versions that cannot be compared, such as
+	// "dev" builds
+	VersionCompatible VersionCompat = iota
+	// VersionMajorMismatch means client and server differ in major version
+	VersionMajorMismatch
+	// VersionClientTooOld means the server requires a newer client
+	VersionClientTooOld
+)
+
+// VersionInfo is the "version" WebSocket payload the server sends after the
+// handshake, so clients can warn about incompatibilities themselves. It
+// also carries the server's clock and the handshake's ClientTime, from
+// which clients work out how far apart the two clocks are.
+type VersionInfo struct {
+	ServerVersion    string    `json:"server_version"`
+	MinClientVersion string    `json:"min_client_version,omitempty"`
+	ServerTime       time.Time `json:"server_time,omitempty"`
+	ClientTime       time.Time `json:"client_time,omitempty"`
+}
+
+// CheckVersionCompat compares a client against a server and the oldest
+// client version the server accepts (empty = any)
+func CheckVersionCompat(clientVersion, serverVersion, minClientVersion string) VersionCompat {
+	client, ok := ParseSemver(clientVersion)
+	if !ok {
+		return VersionCompatible
+	}
+	if min, ok := ParseSemver(minClientVersion); ok && client.Compare(min) < 0 {
+		return VersionClientTooOld
+	}
+	if server, ok := ParseSemver(serverVersion); ok && server.Major != client.Major {
+		return VersionMajorMismatch
+	}
+	return VersionCompatible
+}
+
+// Semver is a parsed semantic version such as v0.9.0-beta.2
+type Semver struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+// ParseSemver parses major.minor.patch with an optional leading "v",
+// pre-release and build metadata. Missing minor or patch parts are zero.
+func ParseSemver(s string) (Semver, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, _ := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if core == "" || len(parts) > 3 {
+		return Semver{}, false
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Semver{}, false
+		}
+		nums[i] = n
+	}
+	return Semver{Major: nums[0], Minor: nums[1], Patch: nums[2], Pre: pre}, true
+}
+
+// Compare returns -1, 0 or 1 as v is older than, equal to or newer than o.
+// A pre-release is older than its release.
+func (v Semver) Compare(o Semver) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] != d[1] {
+			return cmp.Compare(d[0], d[1])
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(v.Pre, "."), strings.Split(o.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		if errX == nil && errY == nil {
+			return cmp.Compare(x, y)
+		}
+		return cmp.Compare(a[i], b[i])
+	}
+	return cmp.Compare(len(a), len(b))
+}
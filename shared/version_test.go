@@ -116,3 +116,40 @@ func TestVersionInfoNonEmpty(t *testing.T) {
 		t.Error("Server version info should not be empty")
 	}
 }
+
+func TestSemverCompare(t *testing.T) {
+	ordered := []string{"v0.8.9", "v0.9.0-beta.1", "v0.9.0-beta.2", "v0.9.0-beta.10", "v0.9.0", "0.9.1", "v1.0"}
+	for i := 1; i < len(ordered); i++ {
+		a, okA := ParseSemver(ordered[i-1])
+		b, okB := ParseSemver(ordered[i])
+		if !okA || !okB {
+			t.Fatalf("Failed to parse %q or %q", ordered[i-1], ordered[i])
+		}
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("Expected %s < %s", ordered[i-1], ordered[i])
+		}
+	}
+	for _, bad := range []string{"", "dev", "unknown", "1.2.3.4", "v1.x"} {
+		if _, ok := ParseSemver(bad); ok {
+			t.Errorf("Expected %q not to parse", bad)
+		}
+	}
+}
+
+func TestCheckVersionCompat(t *testing.T) {
+	for _, tt := range []struct {
+		client, server, min string
+		want                VersionCompat
+	}{
+		{"v0.9.0", "v0.9.2", "", VersionCompatible},
+		{"v0.9.0", "v1.0.0", "", VersionMajorMismatch},
+		{"v0.8.0", "v0.9.0", "v0.9.0-beta.1", VersionClientTooOld},
+		{"v0.9.0", "v0.9.0", "v0.9.0-beta.1", VersionCompatible},
+		{"dev", "v1.0.0", "v1.0.0", VersionCompatible},
+		{"v1.0.0", "dev", "", VersionCompatible},
+	} {
+		if got := CheckVersionCompat(tt.client, tt.server, tt.min); got != tt.want {
+			t.Errorf("CheckVersionCompat(%q, %q, %q) = %d, want %d", tt.client, tt.server, tt.min, got, tt.want)
+		}
+	}
+}